@@ -0,0 +1,90 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/operations"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	checkName             = "check"
+	checkShortDescription = "Check the credentials of acs-engine before any operation"
+	checkLongDescription  = "Check that the credentials authenticate to the Azure subscription, and have the role assignments acs-engine needs on the resource group of a cluster: Contributor, and User Access Administrator when the VMs use managed identities"
+)
+
+type checkCmd struct {
+	authProvider
+
+	// user input
+	resourceGroup      string
+	useManagedIdentity bool
+
+	// derived
+	client armhelpers.ACSEngineClient
+}
+
+func newCheckCmd() *cobra.Command {
+	cc := checkCmd{
+		authProvider: &authArgs{},
+	}
+
+	checkCmd := &cobra.Command{
+		Use:   checkName,
+		Short: checkShortDescription,
+		Long:  checkLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cc.run(cmd, args)
+		},
+	}
+
+	f := checkCmd.Flags()
+	f.StringVarP(&cc.resourceGroup, "resource-group", "g", "", "the resource group of the cluster (required)")
+	f.BoolVar(&cc.useManagedIdentity, "use-managed-identity", false, "check the permissions needed when the VMs of the cluster use managed identities")
+	addAuthFlags(cc.getAuthArgs(), f)
+
+	return checkCmd
+}
+
+func (cc *checkCmd) validate(cmd *cobra.Command) error {
+	if cc.resourceGroup == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+	return cc.getAuthArgs().validateAuthArgs()
+}
+
+func (cc *checkCmd) run(cmd *cobra.Command, args []string) error {
+	if err := cc.validate(cmd); err != nil {
+		return errors.Wrap(err, "error validating check command")
+	}
+
+	var err error
+	// getting the client authenticates, as it ensures the resource providers of the subscription are registered
+	if cc.client, err = cc.authProvider.getClient(); err != nil {
+		return errors.Wrap(err, "failed to authenticate to Azure")
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Authenticated to subscription %s.\n", cc.getAuthArgs().SubscriptionID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	missing, err := operations.CheckPermissions(ctx, cc.client, cc.resourceGroup, cc.useManagedIdentity)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		for _, permission := range missing {
+			fmt.Fprintf(out, "Missing permission %s on resource group %s, granted by the %s role.\n", permission.Action, cc.resourceGroup, permission.Role)
+		}
+		return errors.Errorf("the credentials lack %d permissions on resource group %s", len(missing), cc.resourceGroup)
+	}
+	fmt.Fprintf(out, "The credentials have the permissions acs-engine needs on resource group %s.\n", cc.resourceGroup)
+	return nil
+}
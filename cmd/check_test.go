@@ -0,0 +1,101 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+var _ = Describe("the check command", func() {
+	// newTestCheckCmd returns a check command of the resource group "rg" using the mock client
+	newTestCheckCmd := func(client armhelpers.ACSEngineClient, useManagedIdentity bool) (*checkCmd, *cobra.Command, *bytes.Buffer) {
+		cc := &checkCmd{
+			authProvider: &mockAuthProvider{
+				getClientMock: client,
+				authArgs: &authArgs{
+					rawSubscriptionID:   "99999999-0000-0000-0000-000000000000",
+					RawAzureEnvironment: "AzurePublicCloud",
+					AuthMethod:          "client_secret",
+					rawClientID:         "88888888-0000-0000-0000-000000000000",
+					ClientSecret:        "secret",
+				},
+			},
+			resourceGroup:      "rg",
+			useManagedIdentity: useManagedIdentity,
+		}
+		cmd := &cobra.Command{}
+		out := &bytes.Buffer{}
+		cmd.SetOutput(out)
+		return cc, cmd, out
+	}
+	contributor := authorization.Permission{
+		Actions: &[]string{"*"},
+		NotActions: &[]string{
+			"Microsoft.Authorization/*/Delete",
+			"Microsoft.Authorization/*/Write",
+			"Microsoft.Authorization/elevateAccess/Action",
+		},
+	}
+	userAccessAdministrator := authorization.Permission{
+		Actions:    &[]string{"*/read", "Microsoft.Authorization/*", "Microsoft.Support/*"},
+		NotActions: &[]string{},
+	}
+
+	It("should create a check command", func() {
+		output := newCheckCmd()
+
+		Expect(output.Use).Should(Equal(checkName))
+		Expect(output.Short).Should(Equal(checkShortDescription))
+		Expect(output.Long).Should(Equal(checkLongDescription))
+		Expect(output.Flags().Lookup("resource-group")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("use-managed-identity")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("subscription-id")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("client-id")).NotTo(BeNil())
+	})
+
+	It("should require the resource group", func() {
+		cc, cmd, _ := newTestCheckCmd(&armhelpers.MockACSEngineClient{}, false)
+		cc.resourceGroup = ""
+		err := cc.run(cmd, nil)
+		Expect(err).To(MatchError("error validating check command: --resource-group must be specified"))
+	})
+
+	It("should succeed when the credentials are Contributor of the resource group", func() {
+		cc, cmd, out := newTestCheckCmd(&armhelpers.MockACSEngineClient{Permissions: []authorization.Permission{contributor}}, false)
+		Expect(cc.run(cmd, nil)).To(Succeed())
+		Expect(out.String()).To(Equal("Authenticated to subscription 99999999-0000-0000-0000-000000000000.\n" +
+			"The credentials have the permissions acs-engine needs on resource group rg.\n"))
+	})
+
+	It("should report the missing User Access Administrator role when the VMs use managed identities", func() {
+		cc, cmd, out := newTestCheckCmd(&armhelpers.MockACSEngineClient{Permissions: []authorization.Permission{contributor}}, true)
+		err := cc.run(cmd, nil)
+		Expect(err).To(MatchError("the credentials lack 2 permissions on resource group rg"))
+		Expect(out.String()).To(ContainSubstring("Missing permission Microsoft.Authorization/roleAssignments/write on resource group rg, granted by the User Access Administrator role.\n"))
+		Expect(out.String()).To(ContainSubstring("Missing permission Microsoft.Authorization/roleAssignments/delete on resource group rg, granted by the User Access Administrator role.\n"))
+
+		cc, cmd, _ = newTestCheckCmd(&armhelpers.MockACSEngineClient{Permissions: []authorization.Permission{contributor, userAccessAdministrator}}, true)
+		Expect(cc.run(cmd, nil)).To(Succeed())
+	})
+
+	It("should report the missing Contributor role", func() {
+		cc, cmd, out := newTestCheckCmd(&armhelpers.MockACSEngineClient{Permissions: []authorization.Permission{userAccessAdministrator}}, true)
+		err := cc.run(cmd, nil)
+		Expect(err).To(MatchError("the credentials lack 8 permissions on resource group rg"))
+		Expect(out.String()).To(ContainSubstring("Missing permission Microsoft.Resources/deployments/write on resource group rg, granted by the Contributor role.\n"))
+		Expect(out.String()).NotTo(ContainSubstring("User Access Administrator"))
+	})
+
+	It("should report the failure to authenticate or to get the permissions", func() {
+		cc, cmd, _ := newTestCheckCmd(&armhelpers.MockACSEngineClient{FailListPermissions: true}, false)
+		err := cc.run(cmd, nil)
+		Expect(err).To(MatchError("error getting the permissions on resource group rg: ListPermissionsForResourceGroup failed"))
+	})
+})
@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/api/vlabs"
+	"github.com/Azure/acs-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	convertName             = "convert"
+	convertShortDescription = "Convert an API model to the current schema version"
+	convertLongDescription  = "Converts an API model authored against an older apiVersion to the current (vlabs) schema, migrating deprecated properties"
+)
+
+type convertCmd struct {
+	apimodelPath string
+	outputFile   string
+
+	// derived
+	locale *gotext.Locale
+}
+
+func newConvertCmd() *cobra.Command {
+	cc := convertCmd{}
+
+	convertCmd := &cobra.Command{
+		Use:   convertName,
+		Short: convertShortDescription,
+		Long:  convertLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cc.validate(cmd, args); err != nil {
+				log.Fatalf("error validating convertCmd: %s", err.Error())
+			}
+			if err := cc.run(cmd); err != nil {
+				log.Fatalf("error running convertCmd: %s", err.Error())
+			}
+			return nil
+		},
+	}
+
+	f := convertCmd.Flags()
+	f.StringVarP(&cc.apimodelPath, "api-model", "m", "", "path to the apimodel file to convert")
+	f.StringVarP(&cc.outputFile, "output-file", "o", "", "path to write the converted apimodel to (stdout if absent)")
+
+	return convertCmd
+}
+
+func (cc *convertCmd) validate(cmd *cobra.Command, args []string) error {
+	var err error
+
+	cc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if cc.apimodelPath == "" {
+		if len(args) == 1 {
+			cc.apimodelPath = args[0]
+		} else if len(args) > 1 {
+			cmd.Usage()
+			return errors.New("too many arguments were provided to 'convert'")
+		} else {
+			cmd.Usage()
+			return errors.New("--api-model was not supplied, nor was one specified as a positional argument")
+		}
+	}
+
+	if _, err := os.Stat(cc.apimodelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", cc.apimodelPath)
+	}
+
+	return nil
+}
+
+func (cc *convertCmd) run(cmd *cobra.Command) error {
+	contents, err := ioutil.ReadFile(cc.apimodelPath)
+	if err != nil {
+		return errors.Wrapf(err, "error reading api model %s", cc.apimodelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: cc.locale,
+		},
+	}
+	converted, version, err := apiloader.MigrateAPIModel(contents)
+	if err != nil {
+		return errors.Wrapf(err, "error converting api model %s", cc.apimodelPath)
+	}
+
+	if cc.outputFile == "" {
+		_, err = cmd.OutOrStdout().Write(append(converted, '\n'))
+		return err
+	}
+	if err = ioutil.WriteFile(cc.outputFile, converted, 0600); err != nil {
+		return errors.Wrapf(err, "error writing converted api model to %s", cc.outputFile)
+	}
+	log.Infof("converted api model %s from apiVersion %s to %s: %s", cc.apimodelPath, version, vlabs.APIVersion, cc.outputFile)
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewConvertCmd(t *testing.T) {
+	output := newConvertCmd()
+	if output.Use != convertName || output.Short != convertShortDescription || output.Long != convertLongDescription {
+		t.Fatalf("convert command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, convertName, output.Short, convertShortDescription, output.Long, convertLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "output-file"}
+	for _, f := range expectedFlags {
+		if output.Flags().Lookup(f) == nil {
+			t.Fatalf("convert command should have flag %s", f)
+		}
+	}
+}
+
+func TestConvertCmdValidate(t *testing.T) {
+	c := &convertCmd{}
+	r := &cobra.Command{}
+
+	if err := c.validate(r, []string{"../pkg/acsengine/testdata/v20160330/dcos.json"}); err != nil {
+		t.Fatalf("unexpected error validating 1 arg: %s", err.Error())
+	}
+
+	c = &convertCmd{}
+	if err := c.validate(r, []string{}); err == nil {
+		t.Fatalf("expected error validating 0 args")
+	}
+
+	c = &convertCmd{}
+	if err := c.validate(r, []string{"../pkg/acsengine/testdata/v20160330/dcos.json", "arg1"}); err == nil {
+		t.Fatalf("expected error validating multiple args")
+	}
+
+	c = &convertCmd{}
+	if err := c.validate(r, []string{"../pkg/acsengine/testdata/v20160330/missing.json"}); err == nil {
+		t.Fatalf("expected error validating a missing api model")
+	}
+}
+
+func TestConvertCmdRun(t *testing.T) {
+	r := &cobra.Command{}
+	c := &convertCmd{}
+	if err := c.validate(r, []string{"../pkg/acsengine/testdata/v20160330/swarm.json"}); err != nil {
+		t.Fatalf("unexpected error validating: %s", err.Error())
+	}
+
+	var out bytes.Buffer
+	r.SetOutput(&out)
+	if err := c.run(r); err != nil {
+		t.Fatalf("unexpected error converting to stdout: %s", err.Error())
+	}
+	if !strings.Contains(out.String(), `"apiVersion": "vlabs"`) {
+		t.Fatalf("expected the converted api model to be written to stdout, got %s", out.String())
+	}
+
+	dir, err := ioutil.TempDir("", "acs-engine-convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c.outputFile = path.Join(dir, "apimodel.json")
+	if err := c.run(r); err != nil {
+		t.Fatalf("unexpected error converting to a file: %s", err.Error())
+	}
+	b, err := ioutil.ReadFile(c.outputFile)
+	if err != nil {
+		t.Fatalf("expected the converted api model to be written to %s: %s", c.outputFile, err.Error())
+	}
+	if strings.TrimSpace(string(b)) != strings.TrimSpace(out.String()) {
+		t.Fatalf("expected the same converted api model on stdout and in the output file")
+	}
+}
@@ -213,7 +213,7 @@ func (uc *dcosUpgradeCmd) run(cmd *cobra.Command, args []string) error {
 		Translator: &i18n.Translator{
 			Locale: uc.locale,
 		},
-		Logger: log.NewEntry(log.New()),
+		Logger: log.NewEntry(log.StandardLogger()),
 		Client: uc.client,
 	}
 
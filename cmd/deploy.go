@@ -27,7 +27,9 @@ import (
 	"github.com/Azure/acs-engine/pkg/armhelpers"
 	"github.com/Azure/acs-engine/pkg/helpers"
 	"github.com/Azure/acs-engine/pkg/i18n"
+	"github.com/Azure/acs-engine/pkg/operations"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 )
@@ -58,6 +60,9 @@ type deployCmd struct {
 	parametersOnly    bool
 	set               []string
 
+	skipVMSizeValidation bool
+	deploymentMode       string
+
 	// derived
 	containerService *api.ContainerService
 	apiVersion       string
@@ -91,6 +96,17 @@ func newDeployCmd() *cobra.Command {
 			if _, _, err := dc.validateApimodel(); err != nil {
 				log.Fatalf("Failed to validate the apimodel after populating values: %s", err.Error())
 			}
+			if !dc.skipVMSizeValidation {
+				if err := dc.validateVMSizes(); err != nil {
+					log.Fatalf("Failed to validate the VM sizes: %s", err.Error())
+				}
+			}
+			if err := dc.validateStorageAccounts(); err != nil {
+				log.Fatalf("Failed to validate the existing storage accounts: %s", err.Error())
+			}
+			if err := dc.validateNATGateway(); err != nil {
+				log.Fatalf("Failed to validate the existing NAT gateway: %s", err.Error())
+			}
 			return dc.run()
 		},
 	}
@@ -106,6 +122,8 @@ func newDeployCmd() *cobra.Command {
 	f.StringVarP(&dc.location, "location", "l", "", "location to deploy to (required)")
 	f.BoolVarP(&dc.forceOverwrite, "force-overwrite", "f", false, "automatically overwrite existing files in the output directory")
 	f.StringArrayVar(&dc.set, "set", []string{}, "set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	f.BoolVar(&dc.skipVMSizeValidation, "skip-vm-size-validation", false, "skip checking that the VM sizes of the cluster are offered in the location before deploying")
+	f.StringVar(&dc.deploymentMode, "deployment-mode", string(resources.Incremental), "mode of the ARM deployment, Incremental or Complete (Complete deletes the resources of the resource group which are not in the template)")
 
 	addAuthFlags(dc.getAuthArgs(), f)
 
@@ -140,6 +158,16 @@ func (dc *deployCmd) validateArgs(cmd *cobra.Command, args []string) error {
 	}
 	dc.location = helpers.NormalizeAzureRegion(dc.location)
 
+	switch resources.DeploymentMode(dc.deploymentMode) {
+	case "":
+		dc.deploymentMode = string(resources.Incremental)
+	case resources.Incremental:
+	case resources.Complete:
+		log.Warnf("--deployment-mode %s deletes all the resources of the resource group which are not in the generated template", resources.Complete)
+	default:
+		return errors.Errorf("--deployment-mode must be %s or %s, got %s", resources.Incremental, resources.Complete, dc.deploymentMode)
+	}
+
 	return nil
 }
 
@@ -318,7 +346,7 @@ func autofillApimodel(dc *deployCmd) error {
 
 	if !useManagedIdentity {
 		spp := dc.containerService.Properties.ServicePrincipalProfile
-		if spp != nil && spp.ClientID == "" && spp.Secret == "" && spp.KeyvaultSecretRef == nil && (dc.getAuthArgs().ClientID.String() == "" || dc.getAuthArgs().ClientID.String() == "00000000-0000-0000-0000-000000000000") && dc.getAuthArgs().ClientSecret == "" {
+		if spp != nil && spp.ClientID == "" && spp.Secret == "" && spp.KeyvaultSecretRef == nil && spp.Certificate == "" && (dc.getAuthArgs().ClientID.String() == "" || dc.getAuthArgs().ClientID.String() == "00000000-0000-0000-0000-000000000000") && dc.getAuthArgs().ClientSecret == "" {
 			log.Warnln("apimodel: ServicePrincipalProfile was missing or empty, creating application...")
 
 			// TODO: consider caching the creds here so they persist between subsequent runs of 'deploy'
@@ -381,9 +409,9 @@ func (dc *deployCmd) validateApimodel() (*api.ContainerService, string, error) {
 
 	p := dc.containerService.Properties
 	if strings.ToLower(p.OrchestratorProfile.OrchestratorType) == "kubernetes" {
-		if p.ServicePrincipalProfile == nil || (p.ServicePrincipalProfile.ClientID == "" || (p.ServicePrincipalProfile.Secret == "" && p.ServicePrincipalProfile.KeyvaultSecretRef == nil)) {
+		if p.ServicePrincipalProfile == nil || (p.ServicePrincipalProfile.ClientID == "" || (p.ServicePrincipalProfile.Secret == "" && p.ServicePrincipalProfile.KeyvaultSecretRef == nil && p.ServicePrincipalProfile.Certificate == "")) {
 			if p.OrchestratorProfile.KubernetesConfig != nil && !p.OrchestratorProfile.KubernetesConfig.UseManagedIdentity {
-				return nil, "", errors.New("when using the kubernetes orchestrator, must either set useManagedIdentity in the kubernetes config or set --client-id and --client-secret or KeyvaultSecretRef of secret (also available in the API model)")
+				return nil, "", errors.New("when using the kubernetes orchestrator, must either set useManagedIdentity in the kubernetes config or set --client-id and --client-secret or KeyvaultSecretRef of secret or a client certificate (also available in the API model)")
 			}
 		}
 	}
@@ -396,6 +424,30 @@ func (dc *deployCmd) validateApimodel() (*api.ContainerService, string, error) {
 	return apiloader.DeserializeContainerService(rawVersionedAPIModel, true, false, nil)
 }
 
+// validateVMSizes checks that the VM sizes of all the profiles are offered in the location, and not
+// restricted for the subscription, before anything is generated
+func (dc *deployCmd) validateVMSizes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	return operations.ValidateVMSizes(ctx, dc.client, dc.containerService.Properties, dc.location)
+}
+
+// validateStorageAccounts checks that the existing storage accounts holding the VHDs of the unmanaged disks
+// can be used by the cluster, before anything is generated
+func (dc *deployCmd) validateStorageAccounts() error {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	return operations.ValidateStorageAccounts(ctx, dc.client, dc.containerService.Properties, dc.location)
+}
+
+// validateNATGateway checks that the existing NAT gateway the subnets of the cluster are associated with
+// is in the subscription and location of the cluster, before anything is generated
+func (dc *deployCmd) validateNATGateway() error {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	return operations.ValidateNATGateway(ctx, dc.client, dc.containerService.Properties, dc.location)
+}
+
 func (dc *deployCmd) run() error {
 	ctx := acsengine.Context{
 		Translator: &i18n.Translator{
@@ -460,6 +512,7 @@ func (dc *deployCmd) run() error {
 		fmt.Sprintf("%s-%d", dc.resourceGroup, deploymentSuffix),
 		templateJSON,
 		parametersJSON,
+		resources.DeploymentMode(dc.deploymentMode),
 	); err != nil {
 		if res.Response.Response != nil && res.Body != nil {
 			defer res.Body.Close()
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Azure/acs-engine/pkg/api"
 	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	"github.com/spf13/cobra"
@@ -99,7 +100,7 @@ func TestNewDeployCmd(t *testing.T) {
 		t.Fatalf("deploy command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, deployName, output.Short, deployShortDescription, output.Long, versionLongDescription)
 	}
 
-	expectedFlags := []string{"api-model", "dns-prefix", "auto-suffix", "output-directory", "ca-private-key-path", "resource-group", "location", "force-overwrite"}
+	expectedFlags := []string{"api-model", "dns-prefix", "auto-suffix", "output-directory", "ca-private-key-path", "resource-group", "location", "force-overwrite", "deployment-mode"}
 	for _, f := range expectedFlags {
 		if output.Flags().Lookup(f) == nil {
 			t.Fatalf("deploy command should have flag %s", f)
@@ -192,6 +193,24 @@ func TestValidate(t *testing.T) {
 			args:        []string{},
 			expectedErr: nil,
 		},
+		{
+			dc: &deployCmd{
+				apimodelPath:   apimodelPath,
+				location:       "westus",
+				deploymentMode: "Complete",
+			},
+			args:        []string{},
+			expectedErr: nil,
+		},
+		{
+			dc: &deployCmd{
+				apimodelPath:   apimodelPath,
+				location:       "westus",
+				deploymentMode: "Replace",
+			},
+			args:        []string{},
+			expectedErr: errors.New("--deployment-mode must be Incremental or Complete, got Replace"),
+		},
 	}
 
 	for _, c := range cases {
@@ -577,3 +596,45 @@ func TestDeployCmdRun(t *testing.T) {
 	}
 
 }
+
+func TestDeployCmdRunDeploymentMode(t *testing.T) {
+	for _, mode := range []resources.DeploymentMode{resources.Incremental, resources.Complete} {
+		client := &armhelpers.MockACSEngineClient{}
+		d := &deployCmd{
+			authProvider: &mockAuthProvider{
+				authArgs:      &authArgs{},
+				getClientMock: client,
+			},
+			apimodelPath:    "../pkg/acsengine/testdata/simple/kubernetes.json",
+			outputDirectory: "_test_output",
+			forceOverwrite:  true,
+			location:        "westus",
+			deploymentMode:  string(mode),
+		}
+
+		r := &cobra.Command{}
+		addAuthFlags(d.getAuthArgs(), r.Flags())
+		d.getAuthArgs().SubscriptionID = uuid.Must(uuid.FromString("6dc93fae-9a76-421f-bbe5-cc6460ea81cb"))
+		d.getAuthArgs().rawSubscriptionID = "6dc93fae-9a76-421f-bbe5-cc6460ea81cb"
+
+		if err := d.loadAPIModel(r, []string{}); err != nil {
+			t.Fatalf("Failed to call LoadAPIModel: %s", err)
+		}
+		if err := d.run(); err != nil {
+			t.Fatalf("Failed to call run: %s", err)
+		}
+		if len(client.DeploymentModes) != 1 || client.DeploymentModes[0] != mode {
+			t.Errorf("expected the template to be deployed once in the %s mode, got the modes %v", mode, client.DeploymentModes)
+		}
+	}
+}
+
+func TestValidateDefaultsDeploymentMode(t *testing.T) {
+	d := &deployCmd{location: "westus"}
+	if err := d.validateArgs(&cobra.Command{}, []string{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.deploymentMode != string(resources.Incremental) {
+		t.Errorf("expected the deployment mode to default to %s, got %s", resources.Incremental, d.deploymentMode)
+	}
+}
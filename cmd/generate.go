@@ -4,14 +4,19 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"regexp"
+	"sort"
 
 	"github.com/Azure/acs-engine/pkg/acsengine"
 	"github.com/Azure/acs-engine/pkg/acsengine/transform"
 	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/api/common"
+	"github.com/Azure/acs-engine/pkg/helpers"
 	"github.com/Azure/acs-engine/pkg/i18n"
 	"github.com/leonelquinteros/gotext"
 	"github.com/pkg/errors"
@@ -25,6 +30,23 @@ const (
 	generateLongDescription  = "Generates an Azure Resource Manager template, parameters file and other assets for a cluster"
 )
 
+// environmentNameRegex matches the environment names, which are part of the names of their parameters files
+var environmentNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// environmentOverrides are the settings which differ between the environments a cluster is promoted
+// across, e.g. dev, stage and prod. They are all set by parameters, so the environments share the template
+type environmentOverrides struct {
+	DNSPrefix               string                              `json:"dnsPrefix,omitempty"`
+	Location                string                              `json:"location,omitempty"`
+	ServicePrincipalProfile *environmentServicePrincipalProfile `json:"servicePrincipalProfile,omitempty"`
+}
+
+// environmentServicePrincipalProfile is the service principal of an environment
+type environmentServicePrincipalProfile struct {
+	ClientID string `json:"clientId"`
+	Secret   string `json:"secret"`
+}
+
 type generateCmd struct {
 	apimodelPath      string
 	outputDirectory   string // can be auto-determined from clusterDefinition
@@ -32,12 +54,16 @@ type generateCmd struct {
 	caPrivateKeyPath  string
 	noPrettyPrint     bool
 	parametersOnly    bool
+	helmChart         bool
+	deployScripts     bool
+	environmentsPath  string
 	set               []string
 
 	// derived
 	containerService *api.ContainerService
 	apiVersion       string
 	locale           *gotext.Locale
+	environments     map[string]environmentOverrides
 }
 
 func newGenerateCmd() *cobra.Command {
@@ -72,6 +98,9 @@ func newGenerateCmd() *cobra.Command {
 	f.StringArrayVar(&gc.set, "set", []string{}, "set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
 	f.BoolVar(&gc.noPrettyPrint, "no-pretty-print", false, "skip pretty printing the output")
 	f.BoolVar(&gc.parametersOnly, "parameters-only", false, "only output parameters files")
+	f.BoolVar(&gc.helmChart, "helm-chart", false, "also output the addon manifests as a Helm chart, in the cluster-addons directory of the output directory (Kubernetes only)")
+	f.BoolVar(&gc.deployScripts, "deploy-scripts", false, "also output deploy.sh and deploy.ps1 scripts deploying the template to a resource group with the Azure CLI")
+	f.StringVar(&gc.environmentsPath, "environments", "", "path to a JSON file of environment names to their dnsPrefix, location and servicePrincipalProfile overrides, to also output an azuredeploy.parameters.<environment>.json file for each environment")
 
 	return generateCmd
 }
@@ -100,6 +129,39 @@ func (gc *generateCmd) validate(cmd *cobra.Command, args []string) error {
 		return errors.Errorf("specified api model does not exist (%s)", gc.apimodelPath)
 	}
 
+	if gc.environmentsPath != "" {
+		if err := gc.loadEnvironments(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gc *generateCmd) loadEnvironments() error {
+	contents, err := ioutil.ReadFile(gc.environmentsPath)
+	if err != nil {
+		return errors.Wrapf(err, "error reading the environments file %s", gc.environmentsPath)
+	}
+	if err = json.Unmarshal(contents, &gc.environments); err != nil {
+		return errors.Wrapf(err, "error parsing the environments file %s", gc.environmentsPath)
+	}
+	if len(gc.environments) == 0 {
+		return errors.Errorf("the environments file %s doesn't define any environment", gc.environmentsPath)
+	}
+	for name, overrides := range gc.environments {
+		if !environmentNameRegex.MatchString(name) {
+			return errors.Errorf("environment name '%s' is invalid, it must only contain alphanumerics, '-' and '_'", name)
+		}
+		if overrides.DNSPrefix != "" {
+			if err = common.ValidateDNSPrefix(overrides.DNSPrefix); err != nil {
+				return errors.Wrapf(err, "invalid dnsPrefix of environment %s", name)
+			}
+		}
+		if sp := overrides.ServicePrincipalProfile; sp != nil && (sp.ClientID == "" || sp.Secret == "") {
+			return errors.Errorf("the servicePrincipalProfile of environment %s must have both a clientId and a secret", name)
+		}
+	}
 	return nil
 }
 
@@ -211,5 +273,123 @@ func (gc *generateCmd) run() error {
 		log.Fatalf("error writing artifacts: %s \n", err.Error())
 	}
 
+	if gc.helmChart {
+		chart, err := acsengine.GenerateHelmChart(gc.containerService, acsengine.DefaultGeneratorCode, BuildTag)
+		if err != nil {
+			log.Fatalf("error generating the Helm chart: %s \n", err.Error())
+		}
+		if err = writer.WriteHelmChart(chart, gc.outputDirectory); err != nil {
+			log.Fatalf("error writing the Helm chart: %s \n", err.Error())
+		}
+	}
+
+	if gc.deployScripts {
+		scripts, err := acsengine.GenerateDeployScripts(gc.containerService, BuildTag)
+		if err != nil {
+			log.Fatalf("error generating the deploy scripts: %s \n", err.Error())
+		}
+		if err = writer.WriteDeployScripts(scripts, gc.outputDirectory); err != nil {
+			log.Fatalf("error writing the deploy scripts: %s \n", err.Error())
+		}
+	}
+
+	var names []string
+	for name := range gc.environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err = gc.generateEnvironment(templateGenerator, writer, name); err != nil {
+			log.Fatalf("error generating the parameters of environment %s: %s \n", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// generateEnvironment writes the parameters file of an environment, for the template of the cluster. The
+// certificates generated for the environment, if any, are written to its directory in the output directory
+func (gc *generateCmd) generateEnvironment(templateGenerator *acsengine.TemplateGenerator, writer *acsengine.ArtifactWriter, name string) error {
+	cs, err := gc.loadEnvironment(name)
+	if err != nil {
+		return err
+	}
+	certsGenerated, err := cs.SetPropertiesDefaults(false, false)
+	if err != nil {
+		return errors.Wrap(err, "error in SetPropertiesDefaults")
+	}
+	// the cluster name is a setting of the template, rather than a parameter
+	if cs.Properties.OrchestratorProfile.IsKubernetes() {
+		cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig["--cluster-name"] =
+			gc.containerService.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig["--cluster-name"]
+	}
+	template, parameters, err := templateGenerator.GenerateTemplate(cs, acsengine.DefaultGeneratorCode, BuildTag)
+	if err != nil {
+		return errors.Wrap(err, "error generating the parameters")
+	}
+	if !gc.noPrettyPrint {
+		if parameters, err = transform.BuildAzureParametersFile(parameters); err != nil {
+			return errors.Wrap(err, "error pretty printing the template parameters")
+		}
+	}
+
+	f := &helpers.FileSaver{
+		Translator: writer.Translator,
+	}
+	if err = f.SaveFileString(gc.outputDirectory, fmt.Sprintf("azuredeploy.parameters.%s.json", name), parameters); err != nil {
+		return err
+	}
+	if certsGenerated {
+		return writer.WriteTLSArtifacts(cs, gc.apiVersion, template, parameters, path.Join(gc.outputDirectory, name), true, true)
+	}
 	return nil
 }
+
+// loadEnvironment loads the api model again, and applies the overrides of the environment to it. The
+// environments keep the names of the resources of the cluster, which are scoped to their resource groups,
+// and must be in the same cloud, so that they can all be deployed with the template of the cluster
+func (gc *generateCmd) loadEnvironment(name string) (*api.ContainerService, error) {
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: gc.locale,
+		},
+	}
+	cs, _, err := apiloader.LoadContainerServiceFromFile(gc.apimodelPath, true, false, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing the api model")
+	}
+
+	cs.Properties.ClusterID = gc.containerService.Properties.GetClusterID()
+
+	overrides := gc.environments[name]
+	if overrides.Location != "" {
+		if helpers.GetCloudTargetEnv(overrides.Location) != helpers.GetCloudTargetEnv(gc.containerService.Location) {
+			return nil, errors.Errorf("location %s is not in the cloud of the api model, %s", overrides.Location, helpers.GetCloudTargetEnv(gc.containerService.Location))
+		}
+		cs.Location = overrides.Location
+	}
+	if overrides.DNSPrefix != "" {
+		if cs.Properties.MasterProfile != nil {
+			cs.Properties.MasterProfile.DNSPrefix = overrides.DNSPrefix
+		} else {
+			cs.Properties.HostedMasterProfile.DNSPrefix = overrides.DNSPrefix
+		}
+	}
+	if sp := overrides.ServicePrincipalProfile; sp != nil {
+		if cs.Properties.ServicePrincipalProfile == nil {
+			cs.Properties.ServicePrincipalProfile = &api.ServicePrincipalProfile{}
+		}
+		cs.Properties.ServicePrincipalProfile.ClientID = sp.ClientID
+		cs.Properties.ServicePrincipalProfile.Secret = sp.Secret
+	}
+
+	// the environments share the CA given on the command line
+	if gc.caCertificatePath != "" {
+		if cs.Properties.CertificateProfile == nil {
+			cs.Properties.CertificateProfile = &api.CertificateProfile{}
+		}
+		cs.Properties.CertificateProfile.CaCertificate = gc.containerService.Properties.CertificateProfile.CaCertificate
+		cs.Properties.CertificateProfile.CaPrivateKey = gc.containerService.Properties.CertificateProfile.CaPrivateKey
+	}
+	return cs, nil
+}
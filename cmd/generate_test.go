@@ -4,6 +4,12 @@
 package cmd
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -15,7 +21,7 @@ func TestNewGenerateCmd(t *testing.T) {
 		t.Fatalf("generate command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, generateName, output.Short, generateShortDescription, output.Long, generateLongDescription)
 	}
 
-	expectedFlags := []string{"api-model", "output-directory", "ca-certificate-path", "ca-private-key-path", "set", "no-pretty-print", "parameters-only"}
+	expectedFlags := []string{"api-model", "output-directory", "ca-certificate-path", "ca-private-key-path", "set", "no-pretty-print", "parameters-only", "helm-chart", "deploy-scripts", "environments"}
 	for _, f := range expectedFlags {
 		if output.Flags().Lookup(f) == nil {
 			t.Fatalf("generate command should have flag %s", f)
@@ -118,3 +124,153 @@ func TestGenerateCmdMLoadAPIModel(t *testing.T) {
 		t.Fatalf("unexpected error loading api model: %s", err.Error())
 	}
 }
+
+func TestGenerateCmdEnvironments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acs-engine-generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	environmentsPath := path.Join(dir, "environments.json")
+	writeEnvironments := func(environments string) {
+		if err := ioutil.WriteFile(environmentsPath, []byte(environments), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &cobra.Command{}
+
+	for environments, expectedError := range map[string]string{
+		`{}`:                             "doesn't define any environment",
+		`{"dev/1": {}}`:                  "environment name 'dev/1' is invalid, it must only contain alphanumerics, '-' and '_'",
+		`{"dev": {"dnsPrefix": "-dev"}}`: "invalid dnsPrefix of environment dev",
+		`{"dev": {"servicePrincipalProfile": {"clientId": "dev-client-id"}}}`: "the servicePrincipalProfile of environment dev must have both a clientId and a secret",
+	} {
+		writeEnvironments(environments)
+		g := &generateCmd{environmentsPath: environmentsPath}
+		err := g.validate(r, []string{"../pkg/acsengine/testdata/simple/kubernetes.json"})
+		if err == nil || !strings.Contains(err.Error(), expectedError) {
+			t.Errorf("expected error %q validating the environments %s, got %v", expectedError, environments, err)
+		}
+	}
+
+	writeEnvironments(`{
+  "dev": {"dnsPrefix": "mycluster-dev", "location": "westus2", "servicePrincipalProfile": {"clientId": "dev-client-id", "secret": "dev-secret"}},
+  "prod": {"dnsPrefix": "mycluster-prod", "location": "eastus"}
+}`)
+	g := &generateCmd{
+		environmentsPath: environmentsPath,
+		outputDirectory:  path.Join(dir, "_output"),
+	}
+	if err = g.validate(r, []string{"../pkg/acsengine/testdata/simple/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating the environments: %s", err.Error())
+	}
+	if err = g.loadAPIModel(r, nil); err != nil {
+		t.Fatalf("unexpected error loading api model: %s", err.Error())
+	}
+	if err = g.run(); err != nil {
+		t.Fatalf("unexpected error generating the environments: %s", err.Error())
+	}
+
+	templates, _ := filepath.Glob(path.Join(g.outputDirectory, "azuredeploy.json"))
+	parametersFiles, _ := filepath.Glob(path.Join(g.outputDirectory, "azuredeploy.parameters.*.json"))
+	if len(templates) != 1 || len(parametersFiles) != 2 {
+		t.Fatalf("expected one template and 2 environment parameters files, got %v and %v", templates, parametersFiles)
+	}
+	for name, expected := range map[string]map[string]string{
+		"dev": {
+			"masterEndpointDNSNamePrefix":  "mycluster-dev",
+			"location":                     "westus2",
+			"servicePrincipalClientId":     "dev-client-id",
+			"servicePrincipalClientSecret": "dev-secret",
+		},
+		"prod": {
+			"masterEndpointDNSNamePrefix":  "mycluster-prod",
+			"location":                     "eastus",
+			"servicePrincipalClientId":     "ServicePrincipalClientID",
+			"servicePrincipalClientSecret": "myServicePrincipalClientSecret",
+		},
+	} {
+
+		b, err := ioutil.ReadFile(path.Join(g.outputDirectory, "azuredeploy.parameters."+name+".json"))
+		if err != nil {
+			t.Fatalf("expected the parameters file of environment %s to be generated: %s", name, err.Error())
+		}
+		var parameters struct {
+			Parameters map[string]struct {
+				Value interface{} `json:"value"`
+			} `json:"parameters"`
+		}
+		if err = json.Unmarshal(b, &parameters); err != nil {
+			t.Fatalf("unexpected error parsing the parameters file of environment %s: %s", name, err.Error())
+		}
+		for parameter, value := range expected {
+			if parameters.Parameters[parameter].Value != value {
+				t.Errorf("expected parameter %s of environment %s to be %s, got %v", parameter, name, value, parameters.Parameters[parameter].Value)
+			}
+		}
+	}
+
+	// the environments share the names of the resources of the cluster
+	cs, err := g.loadEnvironment("dev")
+	if err != nil {
+		t.Fatalf("unexpected error loading environment dev: %s", err.Error())
+	}
+	if cs.Properties.GetClusterID() != g.containerService.Properties.GetClusterID() {
+		t.Errorf("expected environment dev to have the cluster ID %s, got %s", g.containerService.Properties.GetClusterID(), cs.Properties.GetClusterID())
+	}
+
+	g.environments["gov"] = environmentOverrides{Location: "usgovvirginia"}
+	if _, err = g.loadEnvironment("gov"); err == nil || err.Error() != "location usgovvirginia is not in the cloud of the api model, AzurePublicCloud" {
+		t.Errorf("expected an error loading an environment in another cloud, got %v", err)
+	}
+}
+
+func TestGenerateCmdDeployScripts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acs-engine-generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &cobra.Command{}
+	g := &generateCmd{
+		deployScripts:   true,
+		outputDirectory: dir,
+	}
+	if err = g.validate(r, []string{"../pkg/acsengine/testdata/simple/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating the generate command: %s", err.Error())
+	}
+	if err = g.loadAPIModel(r, nil); err != nil {
+		t.Fatalf("unexpected error loading api model: %s", err.Error())
+	}
+	g.containerService.Location = "westus2"
+	if err = g.run(); err != nil {
+		t.Fatalf("unexpected error generating the deploy scripts: %s", err.Error())
+	}
+
+	// the scripts deploy the generated template and parameters files
+	for _, file := range []string{"azuredeploy.json", "azuredeploy.parameters.json"} {
+		if _, err = os.Stat(path.Join(dir, file)); err != nil {
+			t.Errorf("expected %s to be generated: %s", file, err.Error())
+		}
+	}
+	for script, expected := range map[string][]string{
+		"deploy.sh":  {"--template-file azuredeploy.json", `PARAMETERS_FILE="${3:-azuredeploy.parameters.json}"`, `LOCATION="${2:-westus2}"`},
+		"deploy.ps1": {"--template-file azuredeploy.json", `$ParametersFile = "azuredeploy.parameters.json"`, `$Location = "westus2"`},
+	} {
+		b, err := ioutil.ReadFile(path.Join(dir, script))
+		if err != nil {
+			t.Fatalf("expected %s to be generated: %s", script, err.Error())
+		}
+		for _, e := range expected {
+			if !strings.Contains(string(b), e) {
+				t.Errorf("expected %s to contain %q", script, e)
+			}
+		}
+	}
+	info, err := os.Stat(path.Join(dir, "deploy.sh"))
+	if err != nil || info.Mode()&0100 == 0 {
+		t.Errorf("expected deploy.sh to be executable, got %v", info)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	"github.com/Azure/acs-engine/pkg/i18n"
+	"github.com/Azure/acs-engine/pkg/operations"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	getLogsName             = "get-logs"
+	getLogsShortDescription = "Collect the logs of an existing Kubernetes cluster"
+	getLogsLongDescription  = "Collect the kubelet, container runtime, cloud-init and control plane logs of the nodes of an existing Kubernetes cluster into a tarball, over SSH"
+)
+
+type getLogsCmd struct {
+	// user input
+	deploymentDirectory string
+	location            string
+	sshPrivateKeyPath   string
+	outputFile          string
+
+	// derived
+	containerService *api.ContainerService
+	locale           *gotext.Locale
+	sshPrivateKey    []byte
+}
+
+func newGetLogsCmd() *cobra.Command {
+	glc := getLogsCmd{}
+
+	getLogsCmd := &cobra.Command{
+		Use:   getLogsName,
+		Short: getLogsShortDescription,
+		Long:  getLogsLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return glc.run(cmd, args)
+		},
+	}
+
+	f := getLogsCmd.Flags()
+	f.StringVarP(&glc.location, "location", "l", "", "location the cluster is deployed in (required if absent from the api model)")
+	f.StringVar(&glc.deploymentDirectory, "deployment-dir", "", "the location of the output from `generate` (required)")
+	f.StringVar(&glc.sshPrivateKeyPath, "ssh-private-key-path", "", "ssh private key path (default: <deployment-dir>/<adminUsername>_rsa)")
+	f.StringVarP(&glc.outputFile, "output-file", "o", "", "path to write the logs tarball to (default: <deployment-dir>/cluster-logs-<timestamp>.tar.gz)")
+
+	return getLogsCmd
+}
+
+func (glc *getLogsCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	glc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if glc.deploymentDirectory == "" {
+		cmd.Usage()
+		return errors.New("--deployment-dir must be specified")
+	}
+	if glc.location != "" {
+		glc.location = helpers.NormalizeAzureRegion(glc.location)
+	}
+	if glc.outputFile == "" {
+		glc.outputFile = path.Join(glc.deploymentDirectory, fmt.Sprintf("cluster-logs-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z")))
+	}
+	return nil
+}
+
+func (glc *getLogsCmd) loadCluster() error {
+	var err error
+
+	// load apimodel from the deployment directory
+	apiModelPath := path.Join(glc.deploymentDirectory, "apimodel.json")
+	if _, err = os.Stat(apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: glc.locale,
+		},
+	}
+	glc.containerService, _, err = apiloader.LoadContainerServiceFromFile(apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+	if !glc.containerService.Properties.OrchestratorProfile.IsKubernetes() {
+		return errors.New("collecting logs is only supported for Kubernetes clusters")
+	}
+
+	if glc.containerService.Location == "" {
+		if glc.location == "" {
+			return errors.New("--location must be specified when the api model has no location")
+		}
+		glc.containerService.Location = glc.location
+	} else if glc.location != "" && glc.containerService.Location != glc.location {
+		return errors.New("--location does not match api model location")
+	}
+
+	if glc.sshPrivateKeyPath == "" {
+		glc.sshPrivateKeyPath = path.Join(glc.deploymentDirectory, fmt.Sprintf("%s_rsa", glc.containerService.Properties.LinuxProfile.AdminUsername))
+	}
+	if glc.sshPrivateKey, err = ioutil.ReadFile(glc.sshPrivateKeyPath); err != nil {
+		return errors.Wrap(err, "error reading the ssh private key, --ssh-private-key-path must be specified")
+	}
+	return nil
+}
+
+func (glc *getLogsCmd) run(cmd *cobra.Command, args []string) error {
+	if err := glc.validate(cmd); err != nil {
+		log.Fatalf("error validating get-logs command: %v", err)
+	}
+	if err := glc.loadCluster(); err != nil {
+		log.Fatalf("error loading existing cluster: %v", err)
+	}
+
+	user := glc.containerService.Properties.LinuxProfile.AdminUsername
+	masterFQDN := glc.containerService.GetAzureProdFQDN()
+	// the first master is reached through the SSH NAT rule for port 22 of the master load balancer
+	master := operations.SSHCommandRunner(user, masterFQDN, 22, glc.sshPrivateKey)
+	nodeRunner := func(addr string) operations.RemoteCommandRunner {
+		return operations.SSHJumpboxCommandRunner(user, masterFQDN, 22, glc.sshPrivateKey, addr)
+	}
+
+	f, err := os.OpenFile(glc.outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %s", glc.outputFile)
+	}
+	logger := log.NewEntry(log.StandardLogger())
+	if err = operations.CollectClusterLogs(logger, master, nodeRunner, f); err != nil {
+		f.Close()
+		os.Remove(glc.outputFile)
+		return errors.Wrap(err, "error collecting the cluster logs")
+	}
+	if err = f.Close(); err != nil {
+		return errors.Wrapf(err, "error writing %s", glc.outputFile)
+	}
+	log.Infof("cluster logs written to %s", glc.outputFile)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+var _ = Describe("the get-logs command", func() {
+
+	It("should create a get-logs command", func() {
+		output := newGetLogsCmd()
+
+		Expect(output.Use).Should(Equal(getLogsName))
+		Expect(output.Short).Should(Equal(getLogsShortDescription))
+		Expect(output.Long).Should(Equal(getLogsLongDescription))
+		Expect(output.Flags().Lookup("location")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("deployment-dir")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("ssh-private-key-path")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("output-file")).NotTo(BeNil())
+	})
+
+	It("should validate the get-logs command", func() {
+		r := &cobra.Command{}
+
+		glc := &getLogsCmd{}
+		err := glc.validate(r)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("--deployment-dir must be specified"))
+
+		glc = &getLogsCmd{deploymentDirectory: "_output/test", location: "West US"}
+		Expect(glc.validate(r)).To(Succeed())
+		Expect(glc.location).To(Equal("westus"))
+		Expect(glc.outputFile).To(MatchRegexp(`^_output/test/cluster-logs-\d{8}T\d{6}Z\.tar\.gz$`))
+	})
+
+	It("should load the cluster and its ssh private key from the deployment directory", func() {
+		dir, err := ioutil.TempDir("", "acs-engine-get-logs")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		apimodel, err := ioutil.ReadFile("../pkg/acsengine/testdata/simple/kubernetes.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(path.Join(dir, "apimodel.json"), apimodel, 0600)).To(Succeed())
+
+		glc := &getLogsCmd{deploymentDirectory: dir}
+		Expect(glc.validate(&cobra.Command{})).To(Succeed())
+		err = glc.loadCluster()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("--location must be specified when the api model has no location"))
+
+		glc.location = "westus"
+		err = glc.loadCluster()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("error reading the ssh private key"))
+
+		Expect(ioutil.WriteFile(path.Join(dir, "azureuser_rsa"), []byte("key"), 0600)).To(Succeed())
+		Expect(glc.loadCluster()).To(Succeed())
+		Expect(string(glc.sshPrivateKey)).To(Equal("key"))
+		Expect(glc.containerService.GetAzureProdFQDN()).To(Equal("masterdns1.westus.cloudapp.azure.com"))
+	})
+})
@@ -30,6 +30,7 @@ const (
 
 var (
 	debug            bool
+	logFormat        string
 	dumpDefaultModel bool
 )
 
@@ -39,10 +40,16 @@ func NewRootCmd() *cobra.Command {
 		Use:   rootName,
 		Short: rootShortDescription,
 		Long:  rootLongDescription,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if debug {
 				log.SetLevel(log.DebugLevel)
 			}
+			formatter, err := helpers.NewLogFormatter(logFormat)
+			if err != nil {
+				return errors.Wrap(err, "--log-format")
+			}
+			log.SetFormatter(formatter)
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if dumpDefaultModel {
@@ -54,17 +61,21 @@ func NewRootCmd() *cobra.Command {
 
 	p := rootCmd.PersistentFlags()
 	p.BoolVar(&debug, "debug", false, "enable verbose debug logs")
+	p.StringVar(&logFormat, "log-format", helpers.LogFormatText, "the format of the logs, 'text' or 'json'")
 
 	f := rootCmd.Flags()
 	f.BoolVar(&dumpDefaultModel, "show-default-model", false, "Dump the default API model to stdout")
 
 	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(newGenerateCmd())
+	rootCmd.AddCommand(newConvertCmd())
 	rootCmd.AddCommand(newDeployCmd())
 	rootCmd.AddCommand(newOrchestratorsCmd())
 	rootCmd.AddCommand(newUpgradeCmd())
 	rootCmd.AddCommand(newScaleCmd())
 	rootCmd.AddCommand(newDcosUpgradeCmd())
+	rootCmd.AddCommand(newGetLogsCmd())
+	rootCmd.AddCommand(newCheckCmd())
 	rootCmd.AddCommand(getCompletionCmd(rootCmd))
 
 	return rootCmd
@@ -100,21 +111,25 @@ type authArgs struct {
 	AuthMethod          string
 	rawClientID         string
 
-	ClientID        uuid.UUID
-	ClientSecret    string
-	CertificatePath string
-	PrivateKeyPath  string
-	language        string
+	ClientID              uuid.UUID
+	ClientSecret          string
+	CertificatePath       string
+	PrivateKeyPath        string
+	CertificateBundlePath string
+	CertificatePassword   string
+	language              string
 }
 
 func addAuthFlags(authArgs *authArgs, f *flag.FlagSet) {
 	f.StringVar(&authArgs.RawAzureEnvironment, "azure-env", "AzurePublicCloud", "the target Azure cloud")
 	f.StringVarP(&authArgs.rawSubscriptionID, "subscription-id", "s", "", "azure subscription id (required)")
-	f.StringVar(&authArgs.AuthMethod, "auth-method", "device", "auth method (default:`device`, `client_secret`, `client_certificate`)")
-	f.StringVar(&authArgs.rawClientID, "client-id", "", "client id (used with --auth-method=[client_secret|client_certificate])")
+	f.StringVar(&authArgs.AuthMethod, "auth-method", "device", "auth method (default:`device`, `client_secret`, `client_certificate`, `client_certificate_bundle`)")
+	f.StringVar(&authArgs.rawClientID, "client-id", "", "client id (used with --auth-method=[client_secret|client_certificate|client_certificate_bundle])")
 	f.StringVar(&authArgs.ClientSecret, "client-secret", "", "client secret (used with --auth-mode=client_secret)")
 	f.StringVar(&authArgs.CertificatePath, "certificate-path", "", "path to client certificate (used with --auth-method=client_certificate)")
 	f.StringVar(&authArgs.PrivateKeyPath, "private-key-path", "", "path to private key (used with --auth-method=client_certificate)")
+	f.StringVar(&authArgs.CertificateBundlePath, "certificate-bundle-path", "", "path to a PEM bundle of the client certificate and its private key (used with --auth-method=client_certificate_bundle)")
+	f.StringVar(&authArgs.CertificatePassword, "certificate-password", "", "password of the private key of the certificate bundle (used with --auth-method=client_certificate_bundle)")
 	f.StringVar(&authArgs.language, "language", "en-us", "language to return error messages in")
 }
 
@@ -136,6 +151,10 @@ func (authArgs *authArgs) validateAuthArgs() error {
 		if authArgs.ClientID.String() == "00000000-0000-0000-0000-000000000000" || authArgs.CertificatePath == "" || authArgs.PrivateKeyPath == "" {
 			return errors.New(`--client-id and --certificate-path, and --private-key-path must be specified when --auth-method="client_certificate"`)
 		}
+	} else if authArgs.AuthMethod == "client_certificate_bundle" {
+		if authArgs.ClientID.String() == "00000000-0000-0000-0000-000000000000" || authArgs.CertificateBundlePath == "" {
+			return errors.New(`--client-id and --certificate-bundle-path must be specified when --auth-method="client_certificate_bundle"`)
+		}
 	}
 
 	if authArgs.SubscriptionID.String() == "00000000-0000-0000-0000-000000000000" {
@@ -206,6 +225,8 @@ func (authArgs *authArgs) getClient() (armhelpers.ACSEngineClient, error) {
 		client, err = armhelpers.NewAzureClientWithClientSecret(env, authArgs.SubscriptionID.String(), authArgs.ClientID.String(), authArgs.ClientSecret)
 	case "client_certificate":
 		client, err = armhelpers.NewAzureClientWithClientCertificateFile(env, authArgs.SubscriptionID.String(), authArgs.ClientID.String(), authArgs.CertificatePath, authArgs.PrivateKeyPath)
+	case "client_certificate_bundle":
+		client, err = armhelpers.NewAzureClientWithClientCertificateBundleFile(env, authArgs.SubscriptionID.String(), authArgs.ClientID.String(), authArgs.CertificateBundlePath, authArgs.CertificatePassword)
 	default:
 		return nil, errors.Errorf("--auth-method: ERROR: method unsupported. method=%q", authArgs.AuthMethod)
 	}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	ini "gopkg.in/ini.v1"
 )
@@ -16,7 +17,7 @@ func TestNewRootCmd(t *testing.T) {
 	if output.Use != rootName || output.Short != rootShortDescription || output.Long != rootLongDescription {
 		t.Fatalf("root command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, rootName, output.Short, rootShortDescription, output.Long, rootLongDescription)
 	}
-	expectedCommands := []*cobra.Command{getCompletionCmd(output), newDcosUpgradeCmd(), newDeployCmd(), newGenerateCmd(), newOrchestratorsCmd(), newScaleCmd(), newUpgradeCmd(), newVersionCmd()}
+	expectedCommands := []*cobra.Command{newCheckCmd(), getCompletionCmd(output), newConvertCmd(), newDcosUpgradeCmd(), newDeployCmd(), newGenerateCmd(), newGetLogsCmd(), newOrchestratorsCmd(), newScaleCmd(), newUpgradeCmd(), newVersionCmd()}
 	rc := output.Commands()
 	for i, c := range expectedCommands {
 		if rc[i].Use != c.Use {
@@ -25,6 +26,28 @@ func TestNewRootCmd(t *testing.T) {
 	}
 }
 
+func TestRootCmdLogFormat(t *testing.T) {
+	defer log.SetFormatter(&log.TextFormatter{})
+
+	root := NewRootCmd()
+	if err := root.PersistentFlags().Set("log-format", "json"); err != nil {
+		t.Fatalf("unexpected error setting --log-format: %v", err)
+	}
+	if err := root.PersistentPreRunE(root, nil); err != nil {
+		t.Fatalf("unexpected error for --log-format=json: %v", err)
+	}
+	if _, ok := log.StandardLogger().Formatter.(*log.JSONFormatter); !ok {
+		t.Errorf("expected --log-format=json to log in JSON, got %T", log.StandardLogger().Formatter)
+	}
+
+	if err := root.PersistentFlags().Set("log-format", "xml"); err != nil {
+		t.Fatalf("unexpected error setting --log-format: %v", err)
+	}
+	if err := root.PersistentPreRunE(root, nil); err == nil {
+		t.Errorf("expected an error for an unsupported --log-format")
+	}
+}
+
 func TestGetSelectedCloudFromAzConfig(t *testing.T) {
 	for _, test := range []struct {
 		desc   string
@@ -109,3 +132,49 @@ func TestGetCloudSubFromAzConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAuthArgsClientCertificateBundle(t *testing.T) {
+	cases := []struct {
+		authArgs    authArgs
+		expectedErr bool
+	}{
+		{
+			authArgs: authArgs{
+				RawAzureEnvironment:   "AzurePublicCloud",
+				rawSubscriptionID:     "e1ae5f4e-8fd8-4c3a-9a7b-5a0d4bb7a0a1",
+				AuthMethod:            "client_certificate_bundle",
+				rawClientID:           "9d5b7c7c-6bb5-4c0d-8f43-0c6a1a1b3c2d",
+				CertificateBundlePath: "/tmp/bundle.pem",
+			},
+			expectedErr: false,
+		},
+		{
+			authArgs: authArgs{
+				RawAzureEnvironment: "AzurePublicCloud",
+				rawSubscriptionID:   "e1ae5f4e-8fd8-4c3a-9a7b-5a0d4bb7a0a1",
+				AuthMethod:          "client_certificate_bundle",
+				rawClientID:         "9d5b7c7c-6bb5-4c0d-8f43-0c6a1a1b3c2d",
+			},
+			expectedErr: true,
+		},
+		{
+			authArgs: authArgs{
+				RawAzureEnvironment:   "AzurePublicCloud",
+				rawSubscriptionID:     "e1ae5f4e-8fd8-4c3a-9a7b-5a0d4bb7a0a1",
+				AuthMethod:            "client_certificate_bundle",
+				CertificateBundlePath: "/tmp/bundle.pem",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		err := c.authArgs.validateAuthArgs()
+		if c.expectedErr && err == nil {
+			t.Errorf("expected an error for %+v", c.authArgs)
+		}
+		if !c.expectedErr && err != nil {
+			t.Errorf("unexpected error for %+v: %v", c.authArgs, err)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/Azure/acs-engine/pkg/i18n"
 	"github.com/Azure/acs-engine/pkg/openshift/filesystem"
 	"github.com/Azure/acs-engine/pkg/operations"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/leonelquinteros/gotext"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -41,6 +43,11 @@ type scaleCmd struct {
 	location             string
 	agentPoolToScale     string
 	masterFQDN           string
+	dryRun               bool
+	waitForNodes         bool
+	nodeReadyTimeout     time.Duration
+	rebalancePods        bool
+	rebalanceStrategies  []string
 
 	// derived
 	containerService *api.ContainerService
@@ -61,6 +68,9 @@ const (
 	apiModelFilename      = "apimodel.json"
 )
 
+// nodeReadyPollInterval is the interval at which the API server is polled while waiting for the nodes to be Ready
+var nodeReadyPollInterval = 10 * time.Second
+
 // NewScaleCmd run a command to upgrade a Kubernetes cluster
 func newScaleCmd() *cobra.Command {
 	sc := scaleCmd{}
@@ -81,6 +91,11 @@ func newScaleCmd() *cobra.Command {
 	f.IntVarP(&sc.newDesiredAgentCount, "new-node-count", "c", 0, "desired number of nodes")
 	f.StringVar(&sc.agentPoolToScale, "node-pool", "", "node pool to scale")
 	f.StringVar(&sc.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer, Needed to scale down Kubernetes agent pools")
+	f.BoolVar(&sc.dryRun, "dry-run", false, "print the VMs that would be added or removed, without scaling")
+	f.BoolVar(&sc.waitForNodes, "wait-for-nodes", false, "after scaling up a Kubernetes agent pool, wait for the new nodes to register and be Ready (requires --master-FQDN)")
+	f.DurationVar(&sc.nodeReadyTimeout, "node-ready-timeout", 20*time.Minute, "how long --wait-for-nodes waits for the new nodes to be Ready")
+	f.BoolVar(&sc.rebalancePods, "rebalance-pods", false, "after scaling up a Kubernetes agent pool and waiting for its new nodes, run a descheduler job evicting pods onto them (requires --master-FQDN)")
+	f.StringSliceVar(&sc.rebalanceStrategies, "rebalance-strategies", []string{operations.DeschedulerStrategyLowNodeUtilization}, "the descheduler strategies --rebalance-pods runs")
 
 	addAuthFlags(&sc.authArgs, f)
 
@@ -118,11 +133,34 @@ func (sc *scaleCmd) validate(cmd *cobra.Command) error {
 		return errors.New("--deployment-dir must be specified")
 	}
 
+	if sc.rebalancePods {
+		if sc.masterFQDN == "" {
+			cmd.Usage()
+			return errors.New("--master-FQDN must be specified to rebalance the pods")
+		}
+		// the pods are only rebalanced onto the new nodes once they're Ready
+		sc.waitForNodes = true
+	}
+
+	if sc.waitForNodes {
+		if sc.masterFQDN == "" {
+			cmd.Usage()
+			return errors.New("--master-FQDN must be specified to wait for the nodes")
+		}
+		if sc.nodeReadyTimeout <= 0 {
+			return errors.Errorf("--node-ready-timeout must be positive, got %v", sc.nodeReadyTimeout)
+		}
+	}
+
 	return nil
 }
 
 func (sc *scaleCmd) load(cmd *cobra.Command) error {
-	sc.logger = log.New().WithField("source", "scaling command line")
+	sc.logger = log.NewEntry(log.StandardLogger()).WithFields(log.Fields{
+		"source":                         "scaling command line",
+		operations.LogFieldOperation:     "scale",
+		operations.LogFieldResourceGroup: sc.resourceGroupName,
+	})
 	var err error
 
 	if err = sc.authArgs.validateAuthArgs(); err != nil {
@@ -133,11 +171,14 @@ func (sc *scaleCmd) load(cmd *cobra.Command) error {
 		return errors.Wrap(err, "failed to get client")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
-	defer cancel()
-	_, err = sc.client.EnsureResourceGroup(ctx, sc.resourceGroupName, sc.location, nil)
-	if err != nil {
-		return err
+	// a dry run must not create the resource group
+	if !sc.dryRun {
+		ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+		defer cancel()
+		_, err = sc.client.EnsureResourceGroup(ctx, sc.resourceGroupName, sc.location, nil)
+		if err != nil {
+			return err
+		}
 	}
 
 	// load apimodel from the deployment directory
@@ -203,6 +244,39 @@ func (sc *scaleCmd) load(cmd *cobra.Command) error {
 	return nil
 }
 
+// scalePlan is the change a scale operation makes to an agent pool, computed from the deployed VMs
+type scalePlan struct {
+	AgentPool        string `json:"agentPool"`
+	ScaleSet         string `json:"scaleSet,omitempty"`
+	CurrentNodeCount int    `json:"currentNodeCount"`
+	DesiredNodeCount int    `json:"desiredNodeCount"`
+	// VMsToAdd are the availability set VMs deployed when scaling up, NewIndexes is the range of their indexes
+	VMsToAdd   []string    `json:"vmsToAdd,omitempty"`
+	NewIndexes *indexRange `json:"newIndexes,omitempty"`
+	// VMsToDelete are the VMs drained and deleted when scaling down, highest index first
+	VMsToDelete []string `json:"vmsToDelete,omitempty"`
+
+	highestUsedIndex int
+	winPoolIndex     int
+}
+
+type indexRange struct {
+	First int `json:"first"`
+	Last  int `json:"last"`
+}
+
+// templateCount is the node count to deploy the agent pool template with. Our templates generate a range of
+// nodes based on a count and offset, it is possible for there to be holes in the template. So we need to set
+// the count in the template to get enough nodes for the range, if there are holes that number will be larger
+// than the desired count
+func (p *scalePlan) templateCount() int {
+	countForTemplate := p.DesiredNodeCount
+	if p.highestUsedIndex != 0 {
+		countForTemplate += p.highestUsedIndex + 1 - p.CurrentNodeCount
+	}
+	return countForTemplate
+}
+
 func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 	if err := sc.validate(cmd); err != nil {
 		return errors.Wrap(err, "failed to validate scale command")
@@ -210,20 +284,25 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 	if err := sc.load(cmd); err != nil {
 		return errors.Wrap(err, "failed to load existing container service")
 	}
+	return sc.scale(cmd)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
-	defer cancel()
-	orchestratorInfo := sc.containerService.Properties.OrchestratorProfile
-	var currentNodeCount, highestUsedIndex, index, winPoolIndex int
-	winPoolIndex = -1
+// plan lists the deployed VMs of the agent pool and computes the VMs to add or delete
+func (sc *scaleCmd) plan(ctx context.Context) (*scalePlan, error) {
+	plan := &scalePlan{
+		AgentPool:        sc.agentPool.Name,
+		DesiredNodeCount: sc.newDesiredAgentCount,
+		winPoolIndex:     -1,
+	}
+	var index int
 	indexes := make([]int, 0)
 	indexToVM := make(map[int]string)
 	if sc.agentPool.IsAvailabilitySets() {
 		for vmsListPage, err := sc.client.ListVirtualMachines(ctx, sc.resourceGroupName); vmsListPage.NotDone(); err = vmsListPage.Next() {
 			if err != nil {
-				return errors.Wrap(err, "failed to get vms in the resource group")
+				return nil, errors.Wrap(err, "failed to get vms in the resource group")
 			} else if len(vmsListPage.Values()) < 1 {
-				return errors.New("The provided resource group does not contain any vms")
+				return nil, errors.New("The provided resource group does not contain any vms")
 			}
 			for _, vm := range vmsListPage.Values() {
 				vmName := *vm.Name
@@ -233,12 +312,12 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 
 				osPublisher := vm.StorageProfile.ImageReference.Publisher
 				if osPublisher != nil && strings.EqualFold(*osPublisher, "MicrosoftWindowsServer") {
-					_, _, winPoolIndex, index, err = utils.WindowsVMNameParts(vmName)
+					_, _, plan.winPoolIndex, index, err = utils.WindowsVMNameParts(vmName)
 				} else {
 					_, _, index, err = utils.K8sLinuxVMNameParts(vmName)
 				}
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				indexToVM[index] = vmName
@@ -248,34 +327,110 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 		sortedIndexes := sort.IntSlice(indexes)
 		sortedIndexes.Sort()
 		indexes = []int(sortedIndexes)
-		currentNodeCount = len(indexes)
+		plan.CurrentNodeCount = len(indexes)
+
+		if plan.CurrentNodeCount == sc.newDesiredAgentCount {
+			return plan, nil
+		}
+		plan.highestUsedIndex = indexes[len(indexes)-1]
+
+		if plan.CurrentNodeCount > sc.newDesiredAgentCount {
+			for i := plan.CurrentNodeCount - 1; i >= sc.newDesiredAgentCount; i-- {
+				index = indexes[i]
+				plan.VMsToDelete = append(plan.VMsToDelete, indexToVM[index])
+			}
+		} else {
+			// new VMs are named after the deployed ones, which end with their index
+			vmPrefix := strings.TrimSuffix(indexToVM[plan.highestUsedIndex], strconv.Itoa(plan.highestUsedIndex))
+			plan.NewIndexes = &indexRange{First: plan.highestUsedIndex + 1, Last: plan.templateCount() - 1}
+			for i := plan.NewIndexes.First; i <= plan.NewIndexes.Last; i++ {
+				plan.VMsToAdd = append(plan.VMsToAdd, vmPrefix+strconv.Itoa(i))
+			}
+		}
+	} else {
+		for vmssListPage, err := sc.client.ListVirtualMachineScaleSets(ctx, sc.resourceGroupName); vmssListPage.NotDone(); vmssListPage.Next() {
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get vmss list in the resource group")
+			}
+			for _, vmss := range vmssListPage.Values() {
+				vmName := *vmss.Name
+				if !sc.vmInAgentPool(vmName, vmss.Tags) {
+					continue
+				}
+
+				osPublisher := vmss.VirtualMachineProfile.StorageProfile.ImageReference.Publisher
+				if osPublisher != nil && strings.EqualFold(*osPublisher, "MicrosoftWindowsServer") {
+					_, _, plan.winPoolIndex, _, err = utils.WindowsVMNameParts(vmName)
+					log.Errorln(err)
+				}
+
+				plan.ScaleSet = vmName
+				plan.CurrentNodeCount = int(*vmss.Sku.Capacity)
+				plan.highestUsedIndex = 0
+			}
+		}
+	}
+	return plan, nil
+}
+
+// scale scales the loaded agent pool to the desired node count, or only prints the plan in dry-run mode
+func (sc *scaleCmd) scale(cmd *cobra.Command) error {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	orchestratorInfo := sc.containerService.Properties.OrchestratorProfile
+
+	if sc.agentPool.MaxCount != nil && sc.newDesiredAgentCount > *sc.agentPool.MaxCount {
+		return errors.Errorf("cannot scale node pool %s to %d nodes, its maxCount is %d", sc.agentPool.Name, sc.newDesiredAgentCount, *sc.agentPool.MaxCount)
+	}
+	if sc.agentPool.MinCount != nil && sc.newDesiredAgentCount < *sc.agentPool.MinCount {
+		return errors.Errorf("cannot scale node pool %s to %d nodes, its minCount is %d", sc.agentPool.Name, sc.newDesiredAgentCount, *sc.agentPool.MinCount)
+	}
+
+	plan, err := sc.plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	if sc.dryRun {
+		b, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "error encoding the scale plan")
+		}
+		_, err = cmd.OutOrStdout().Write(append(b, '\n'))
+		return err
+	}
+
+	var kubeConfig string
+	if orchestratorInfo.OrchestratorType == api.Kubernetes && sc.masterFQDN != "" {
+		kubeConfig, err = acsengine.GenerateKubeConfig(sc.containerService.Properties, sc.location)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate kube config")
+		}
+		if _, err = sc.checkMasters(kubeConfig); err != nil {
+			return err
+		}
+	}
 
-		if currentNodeCount == sc.newDesiredAgentCount {
+	if sc.agentPool.IsAvailabilitySets() {
+		if plan.CurrentNodeCount == sc.newDesiredAgentCount {
 			log.Info("Cluster is currently at the desired agent count.")
 			return nil
 		}
-		highestUsedIndex = indexes[len(indexes)-1]
 
 		// Scale down Scenario
-		if currentNodeCount > sc.newDesiredAgentCount {
+		if len(plan.VMsToDelete) > 0 {
 			if sc.masterFQDN == "" {
 				cmd.Usage()
 				return errors.New("master-FQDN is required to scale down a kubernetes cluster's agent pool")
 			}
 
-			vmsToDelete := make([]string, 0)
-			for i := currentNodeCount - 1; i >= sc.newDesiredAgentCount; i-- {
-				index = indexes[i]
-				vmsToDelete = append(vmsToDelete, indexToVM[index])
-			}
-
 			switch orchestratorInfo.OrchestratorType {
 			case api.Kubernetes:
 				kubeConfig, err := acsengine.GenerateKubeConfig(sc.containerService.Properties, sc.location)
 				if err != nil {
 					return errors.Wrap(err, "failed to generate kube config")
 				}
-				err = sc.drainNodes(kubeConfig, vmsToDelete)
+				err = sc.drainNodes(kubeConfig, plan.VMsToDelete)
 				if err != nil {
 					return errors.Wrap(err, "Got error while draining the nodes to be deleted")
 				}
@@ -289,13 +444,13 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 				if err != nil {
 					return errors.Wrap(err, "failed to read kube config")
 				}
-				err = sc.drainNodes(string(kubeConfig), vmsToDelete)
+				err = sc.drainNodes(string(kubeConfig), plan.VMsToDelete)
 				if err != nil {
 					return errors.Wrap(err, "Got error while draining the nodes to be deleted")
 				}
 			}
 
-			errList := operations.ScaleDownVMs(sc.client, sc.logger, sc.SubscriptionID.String(), sc.resourceGroupName, vmsToDelete...)
+			errList := operations.ScaleDownVMs(sc.client, sc.logger, sc.SubscriptionID.String(), sc.resourceGroupName, plan.VMsToDelete...)
 			if errList != nil {
 				var err error
 				format := "Node '%s' failed to delete with error: '%s'"
@@ -314,27 +469,6 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 
 			return sc.saveAPIModel()
 		}
-	} else {
-		for vmssListPage, err := sc.client.ListVirtualMachineScaleSets(ctx, sc.resourceGroupName); vmssListPage.NotDone(); vmssListPage.Next() {
-			if err != nil {
-				return errors.Wrap(err, "failed to get vmss list in the resource group")
-			}
-			for _, vmss := range vmssListPage.Values() {
-				vmName := *vmss.Name
-				if !sc.vmInAgentPool(vmName, vmss.Tags) {
-					continue
-				}
-
-				osPublisher := vmss.VirtualMachineProfile.StorageProfile.ImageReference.Publisher
-				if osPublisher != nil && strings.EqualFold(*osPublisher, "MicrosoftWindowsServer") {
-					_, _, winPoolIndex, _, err = utils.WindowsVMNameParts(vmName)
-					log.Errorln(err)
-				}
-
-				currentNodeCount = int(*vmss.Sku.Capacity)
-				highestUsedIndex = 0
-			}
-		}
 	}
 
 	translator := acsengine.Context{
@@ -349,11 +483,29 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 
 	sc.containerService.Properties.AgentPoolProfiles = []*api.AgentPoolProfile{sc.agentPool}
 
+	var bootstrapToken string
+	if orchestratorInfo.KubernetesConfig != nil {
+		bootstrapToken = orchestratorInfo.KubernetesConfig.BootstrapToken
+	}
 	_, err = sc.containerService.SetPropertiesDefaults(false, true)
 	if err != nil {
 		log.Fatalf("error in SetPropertiesDefaults template %s: %s", sc.apiModelPath, err.Error())
 		os.Exit(1)
 	}
+	// the masters don't know the token generated as the previous one expired, the new nodes can only join
+	// with it once its secret is created
+	if k := orchestratorInfo.KubernetesConfig; k.IsBootstrapTokenEnabled() && k.BootstrapToken != bootstrapToken {
+		if kubeConfig == "" {
+			return errors.New("master-FQDN is required to scale a cluster whose bootstrap token has expired")
+		}
+		client, err := sc.client.GetKubernetesClient(sc.masterURL(), kubeConfig, time.Duration(1)*time.Second, time.Duration(1)*time.Minute)
+		if err != nil {
+			return errors.Wrap(err, "failed to get a Kubernetes client")
+		}
+		if err = operations.ApplyBootstrapToken(client, sc.logger, k); err != nil {
+			return err
+		}
+	}
 	template, parameters, err := templateGenerator.GenerateTemplate(sc.containerService, acsengine.DefaultGeneratorCode, BuildTag)
 	if err != nil {
 		return errors.Wrapf(err, "error generating template %s", sc.apiModelPath)
@@ -377,16 +529,10 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 	}
 
 	transformer := transform.Transformer{Translator: translator.Translator}
-	// Our templates generate a range of nodes based on a count and offset, it is possible for there to be holes in the template
-	// So we need to set the count in the template to get enough nodes for the range, if there are holes that number will be larger than the desired count
-	countForTemplate := sc.newDesiredAgentCount
-	if highestUsedIndex != 0 {
-		countForTemplate += highestUsedIndex + 1 - currentNodeCount
-	}
-	addValue(parametersJSON, sc.agentPool.Name+"Count", countForTemplate)
+	addValue(parametersJSON, sc.agentPool.Name+"Count", plan.templateCount())
 
-	if winPoolIndex != -1 {
-		templateJSON["variables"].(map[string]interface{})[sc.agentPool.Name+"Index"] = winPoolIndex
+	if plan.winPoolIndex != -1 {
+		templateJSON["variables"].(map[string]interface{})[sc.agentPool.Name+"Index"] = plan.winPoolIndex
 	}
 	switch orchestratorInfo.OrchestratorType {
 	case api.OpenShift:
@@ -395,7 +541,7 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 			return errors.Wrapf(err, "error tranforming the template for scaling template %s", sc.apiModelPath)
 		}
 		if sc.agentPool.IsAvailabilitySets() {
-			addValue(parametersJSON, fmt.Sprintf("%sOffset", sc.agentPool.Name), highestUsedIndex+1)
+			addValue(parametersJSON, fmt.Sprintf("%sOffset", sc.agentPool.Name), plan.highestUsedIndex+1)
 		}
 	case api.Kubernetes:
 		err = transformer.NormalizeForK8sVMASScalingUp(sc.logger, templateJSON)
@@ -403,7 +549,7 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 			return errors.Wrapf(err, "error tranforming the template for scaling template %s", sc.apiModelPath)
 		}
 		if sc.agentPool.IsAvailabilitySets() {
-			addValue(parametersJSON, fmt.Sprintf("%sOffset", sc.agentPool.Name), highestUsedIndex+1)
+			addValue(parametersJSON, fmt.Sprintf("%sOffset", sc.agentPool.Name), plan.highestUsedIndex+1)
 		}
 	case api.Swarm:
 	case api.SwarmMode:
@@ -422,12 +568,68 @@ func (sc *scaleCmd) run(cmd *cobra.Command, args []string) error {
 		sc.resourceGroupName,
 		fmt.Sprintf("%s-%d", sc.resourceGroupName, deploymentSuffix),
 		templateJSON,
-		parametersJSON)
+		parametersJSON,
+		resources.Incremental)
 	if err != nil {
 		return err
 	}
 
-	return sc.saveAPIModel()
+	if err = sc.saveAPIModel(); err != nil {
+		return err
+	}
+
+	if sc.waitForNodes {
+		if orchestratorInfo.OrchestratorType != api.Kubernetes {
+			sc.logger.Warnf("Not waiting for the nodes, which is only supported for Kubernetes")
+			return nil
+		}
+		if err = sc.waitForNewNodes(ctx, plan, kubeConfig); err != nil {
+			return err
+		}
+	}
+
+	if sc.rebalancePods {
+		client, err := sc.client.GetKubernetesClient(sc.masterURL(), kubeConfig, time.Duration(1)*time.Second, time.Duration(1)*time.Minute)
+		if err != nil {
+			return errors.Wrap(err, "failed to get a Kubernetes client")
+		}
+		if _, err = operations.RebalancePods(client, sc.logger, operations.RebalanceOptions{Strategies: sc.rebalanceStrategies}); err != nil {
+			return errors.Wrapf(err, "node pool %s was scaled to %d nodes, but its pods couldn't be rebalanced", sc.agentPool.Name, sc.newDesiredAgentCount)
+		}
+	}
+	return nil
+}
+
+// waitForNewNodes waits for the nodes of the VMs the scale operation deployed to register and be Ready, which
+// are all the VMs of a scale set as the names of its new VMs aren't known beforehand
+func (sc *scaleCmd) waitForNewNodes(ctx context.Context, plan *scalePlan, kubeConfig string) error {
+	nodeNames := plan.VMsToAdd
+	if plan.ScaleSet != "" {
+		nodeNames = nil
+		for page, err := sc.client.ListVirtualMachineScaleSetVMs(ctx, sc.resourceGroupName, plan.ScaleSet); page.NotDone(); err = page.Next() {
+			if err != nil {
+				return errors.Wrapf(err, "failed to list the VMs of scale set %s", plan.ScaleSet)
+			}
+			for _, vm := range page.Values() {
+				if vm.VirtualMachineScaleSetVMProperties != nil && vm.OsProfile != nil && vm.OsProfile.ComputerName != nil {
+					nodeNames = append(nodeNames, *vm.OsProfile.ComputerName)
+				}
+			}
+		}
+	}
+	if len(nodeNames) == 0 {
+		return nil
+	}
+
+	client, err := sc.client.GetKubernetesClient(sc.masterURL(), kubeConfig, nodeReadyPollInterval, sc.nodeReadyTimeout)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+	sc.logger.Infof("Waiting up to %v for the nodes %s to be Ready", sc.nodeReadyTimeout, strings.Join(nodeNames, ", "))
+	if _, err = operations.WaitForNodes(client, sc.logger, nodeNames, nodeReadyPollInterval, sc.nodeReadyTimeout); err != nil {
+		return errors.Wrapf(err, "node pool %s was scaled to %d nodes, but its new nodes didn't join the cluster", sc.agentPool.Name, sc.newDesiredAgentCount)
+	}
+	return nil
 }
 
 func (sc *scaleCmd) saveAPIModel() error {
@@ -438,11 +640,17 @@ func (sc *scaleCmd) saveAPIModel() error {
 		},
 	}
 	var apiVersion string
+	kubernetesConfig := sc.containerService.Properties.OrchestratorProfile.KubernetesConfig
 	sc.containerService, apiVersion, err = apiloader.LoadContainerServiceFromFile(sc.apiModelPath, false, true, nil)
 	if err != nil {
 		return err
 	}
 	sc.containerService.Properties.AgentPoolProfiles[sc.agentPoolIndex].Count = sc.newDesiredAgentCount
+	// keep the bootstrap token the new nodes joined with, which replaced an expired one
+	if k := sc.containerService.Properties.OrchestratorProfile.KubernetesConfig; k != nil && kubernetesConfig.IsBootstrapTokenEnabled() {
+		k.BootstrapToken = kubernetesConfig.BootstrapToken
+		k.BootstrapTokenExpiration = kubernetesConfig.BootstrapTokenExpiration
+	}
 
 	b, err := apiloader.SerializeContainerService(sc.containerService, apiVersion)
 
@@ -485,11 +693,56 @@ func addValue(m paramsMap, k string, v interface{}) {
 	}
 }
 
-func (sc *scaleCmd) drainNodes(kubeConfig string, vmsToDelete []string) error {
-	masterURL := sc.masterFQDN
-	if !strings.HasPrefix(masterURL, "https://") {
-		masterURL = fmt.Sprintf("https://%s", masterURL)
+// masterURL is the URL of the API server load balancer of the cluster
+func (sc *scaleCmd) masterURL() string {
+	if !strings.HasPrefix(sc.masterFQDN, "https://") {
+		return fmt.Sprintf("https://%s", sc.masterFQDN)
+	}
+	return sc.masterFQDN
+}
+
+// checkMasters lists the masters of a Kubernetes cluster through the API server load balancer, which only
+// routes to the masters passing its health probe, so that the scale operation proceeds while a master is
+// unreachable. It warns about the masters which aren't Ready and returns their names, and fails when fewer
+// than a quorum of the masters are Ready, as etcd can't commit the changes of the scale operation then
+func (sc *scaleCmd) checkMasters(kubeConfig string) ([]string, error) {
+	client, err := sc.client.GetKubernetesClient(sc.masterURL(), kubeConfig, time.Duration(1)*time.Second, time.Duration(1)*time.Minute)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+	nodes, err := client.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the nodes of the cluster")
+	}
+
+	count := sc.containerService.Properties.MasterProfile.Count
+	ready := 0
+	var unhealthy []string
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if node.Labels["kubernetes.io/role"] != "master" {
+			continue
+		}
+		if operations.IsNodeReady(node) {
+			ready++
+		} else {
+			unhealthy = append(unhealthy, node.Name)
+		}
+	}
+	for _, name := range unhealthy {
+		sc.logger.Warnf("Master %s is not Ready, scaling through the other masters", name)
+	}
+	if missing := count - ready - len(unhealthy); missing > 0 {
+		sc.logger.Warnf("%d of the %d masters are not registered, scaling through the other masters", missing, count)
 	}
+	if quorum := count/2 + 1; ready < quorum {
+		return unhealthy, errors.Errorf("only %d of the %d masters are Ready, scaling needs a quorum of %d Ready masters", ready, count, quorum)
+	}
+	return unhealthy, nil
+}
+
+func (sc *scaleCmd) drainNodes(kubeConfig string, vmsToDelete []string) error {
+	masterURL := sc.masterURL()
 	numVmsToDrain := len(vmsToDelete)
 	errChan := make(chan *operations.VMScalingErrorDetails, numVmsToDrain)
 	defer close(errChan)
@@ -4,10 +4,27 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	"github.com/Azure/acs-engine/pkg/operations"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-04-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
 )
 
 func TestNewScaleCmd(t *testing.T) {
@@ -16,7 +33,7 @@ func TestNewScaleCmd(t *testing.T) {
 		t.Fatalf("scale command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, scaleName, output.Short, scaleShortDescription, output.Long, scaleLongDescription)
 	}
 
-	expectedFlags := []string{"location", "resource-group", "deployment-dir", "new-node-count", "node-pool", "master-FQDN"}
+	expectedFlags := []string{"location", "resource-group", "deployment-dir", "new-node-count", "node-pool", "master-FQDN", "dry-run", "wait-for-nodes", "node-ready-timeout"}
 	for _, f := range expectedFlags {
 		if output.Flags().Lookup(f) == nil {
 			t.Fatalf("scale command should have flag %s", f)
@@ -74,6 +91,42 @@ func TestScaleCmdValidate(t *testing.T) {
 			},
 			expectedErr: errors.New("--deployment-dir must be specified"),
 		},
+		{
+			sc: &scaleCmd{
+				location:             "centralus",
+				resourceGroupName:    "testRG",
+				deploymentDirectory:  "_output/test",
+				agentPoolToScale:     "agentpool1",
+				newDesiredAgentCount: 5,
+				waitForNodes:         true,
+				nodeReadyTimeout:     time.Minute,
+			},
+			expectedErr: errors.New("--master-FQDN must be specified to wait for the nodes"),
+		},
+		{
+			sc: &scaleCmd{
+				location:             "centralus",
+				resourceGroupName:    "testRG",
+				deploymentDirectory:  "_output/test",
+				agentPoolToScale:     "agentpool1",
+				newDesiredAgentCount: 5,
+				masterFQDN:           "test",
+				waitForNodes:         true,
+			},
+			expectedErr: errors.New("--node-ready-timeout must be positive, got 0s"),
+		},
+		{
+			sc: &scaleCmd{
+				location:             "centralus",
+				resourceGroupName:    "testRG",
+				deploymentDirectory:  "_output/test",
+				agentPoolToScale:     "agentpool1",
+				newDesiredAgentCount: 5,
+				rebalancePods:        true,
+				nodeReadyTimeout:     time.Minute,
+			},
+			expectedErr: errors.New("--master-FQDN must be specified to rebalance the pods"),
+		},
 		{
 			sc: &scaleCmd{
 				location:             "centralus",
@@ -102,3 +155,605 @@ func TestScaleCmdValidate(t *testing.T) {
 		}
 	}
 }
+
+// scaleRecordingClient lists the given agent pool VMs and records the calls which would change the cluster
+type scaleRecordingClient struct {
+	*armhelpers.MockACSEngineClient
+	vms           []compute.VirtualMachine
+	mutatingCalls []string
+}
+
+func (c *scaleRecordingClient) ListVirtualMachines(ctx context.Context, resourceGroup string) (armhelpers.VirtualMachineListResultPage, error) {
+	return &armhelpers.MockVirtualMachineListResultPage{
+		Fn: func(lastResults compute.VirtualMachineListResult) (compute.VirtualMachineListResult, error) {
+			return compute.VirtualMachineListResult{}, nil
+		},
+		Vmlr: compute.VirtualMachineListResult{Value: &c.vms},
+	}, nil
+}
+
+func (c *scaleRecordingClient) EnsureResourceGroup(ctx context.Context, resourceGroup, location string, managedBy *string) (*resources.Group, error) {
+	c.mutatingCalls = append(c.mutatingCalls, "EnsureResourceGroup")
+	return c.MockACSEngineClient.EnsureResourceGroup(ctx, resourceGroup, location, managedBy)
+}
+
+func (c *scaleRecordingClient) DeployTemplate(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}, mode resources.DeploymentMode) (resources.DeploymentExtended, error) {
+	c.mutatingCalls = append(c.mutatingCalls, "DeployTemplate")
+	return c.MockACSEngineClient.DeployTemplate(ctx, resourceGroup, name, template, parameters, mode)
+}
+
+func (c *scaleRecordingClient) DeleteVirtualMachine(ctx context.Context, resourceGroup, name string) error {
+	c.mutatingCalls = append(c.mutatingCalls, "DeleteVirtualMachine "+name)
+	return c.MockACSEngineClient.DeleteVirtualMachine(ctx, resourceGroup, name)
+}
+
+func (c *scaleRecordingClient) DeleteNetworkInterface(ctx context.Context, resourceGroup, nicName string) error {
+	c.mutatingCalls = append(c.mutatingCalls, "DeleteNetworkInterface "+nicName)
+	return c.MockACSEngineClient.DeleteNetworkInterface(ctx, resourceGroup, nicName)
+}
+
+func (c *scaleRecordingClient) DeleteManagedDisk(ctx context.Context, resourceGroupName string, diskName string) error {
+	c.mutatingCalls = append(c.mutatingCalls, "DeleteManagedDisk "+diskName)
+	return c.MockACSEngineClient.DeleteManagedDisk(ctx, resourceGroupName, diskName)
+}
+
+func TestScaleCmdDryRun(t *testing.T) {
+	cases := []struct {
+		name         string
+		desiredCount int
+		expectedPlan scalePlan
+	}{
+		{
+			name:         "scale up",
+			desiredCount: 5,
+			expectedPlan: scalePlan{
+				AgentPool:        "agentpool1",
+				CurrentNodeCount: 3,
+				DesiredNodeCount: 5,
+				// the hole at index 2 is not filled, new VMs are added after the highest used index
+				VMsToAdd:   []string{"k8s-agentpool1-12345678-4", "k8s-agentpool1-12345678-5"},
+				NewIndexes: &indexRange{First: 4, Last: 5},
+			},
+		},
+		{
+			name:         "scale down",
+			desiredCount: 1,
+			expectedPlan: scalePlan{
+				AgentPool:        "agentpool1",
+				CurrentNodeCount: 3,
+				DesiredNodeCount: 1,
+				VMsToDelete:      []string{"k8s-agentpool1-12345678-3", "k8s-agentpool1-12345678-1"},
+			},
+		},
+		{
+			name:         "at the desired count",
+			desiredCount: 3,
+			expectedPlan: scalePlan{
+				AgentPool:        "agentpool1",
+				CurrentNodeCount: 3,
+				DesiredNodeCount: 3,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &scaleRecordingClient{MockACSEngineClient: &armhelpers.MockACSEngineClient{}}
+			for _, name := range []string{"k8s-agentpool1-12345678-3", "k8s-agentpool1-12345678-0", "k8s-master-12345678-0", "k8s-agentpool1-12345678-1"} {
+				vmName := name
+				client.vms = append(client.vms, compute.VirtualMachine{
+					Name: &vmName,
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						StorageProfile: &compute.StorageProfile{ImageReference: &compute.ImageReference{}},
+					},
+				})
+			}
+			cs := api.CreateMockContainerService("testcluster", "1.10.9", 1, 3, false)
+			sc := &scaleCmd{
+				resourceGroupName:    "rg",
+				location:             "westus",
+				masterFQDN:           "testcluster.westus.cloudapp.azure.com",
+				newDesiredAgentCount: c.desiredCount,
+				agentPoolToScale:     "agentpool1",
+				dryRun:               true,
+				containerService:     cs,
+				agentPool:            cs.Properties.AgentPoolProfiles[0],
+				client:               client,
+				nameSuffix:           "12345678",
+				logger:               log.NewEntry(log.New()),
+			}
+
+			var out bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOutput(&out)
+			if err := sc.scale(cmd); err != nil {
+				t.Fatalf("unexpected error planning the scale operation: %v", err)
+			}
+			if len(client.mutatingCalls) > 0 {
+				t.Errorf("expected a dry run not to change the cluster, got calls %v", client.mutatingCalls)
+			}
+
+			var plan scalePlan
+			if err := json.Unmarshal(out.Bytes(), &plan); err != nil {
+				t.Fatalf("expected the scale plan to be printed as JSON, got %s: %v", out.String(), err)
+			}
+			if !reflect.DeepEqual(plan, c.expectedPlan) {
+				t.Errorf("expected scale plan %+v, got %+v", c.expectedPlan, plan)
+			}
+		})
+	}
+}
+
+func TestScaleCmdNodeCountBounds(t *testing.T) {
+	cases := []struct {
+		name         string
+		desiredCount int
+		expectedErr  string
+	}{
+		{
+			name:         "above the max count",
+			desiredCount: 6,
+			expectedErr:  "cannot scale node pool agentpool1 to 6 nodes, its maxCount is 5",
+		},
+		{
+			name:         "below the min count",
+			desiredCount: 1,
+			expectedErr:  "cannot scale node pool agentpool1 to 1 nodes, its minCount is 2",
+		},
+		{
+			name:         "at the max count",
+			desiredCount: 5,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &scaleRecordingClient{MockACSEngineClient: &armhelpers.MockACSEngineClient{}}
+			for _, name := range []string{"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1", "k8s-agentpool1-12345678-2"} {
+				vmName := name
+				client.vms = append(client.vms, compute.VirtualMachine{
+					Name: &vmName,
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						StorageProfile: &compute.StorageProfile{ImageReference: &compute.ImageReference{}},
+					},
+				})
+			}
+			cs := api.CreateMockContainerService("testcluster", "1.10.9", 1, 3, false)
+			cs.Properties.AgentPoolProfiles[0].MinCount = helpers.PointerToInt(2)
+			cs.Properties.AgentPoolProfiles[0].MaxCount = helpers.PointerToInt(5)
+			sc := &scaleCmd{
+				resourceGroupName:    "rg",
+				location:             "westus",
+				newDesiredAgentCount: c.desiredCount,
+				agentPoolToScale:     "agentpool1",
+				dryRun:               true,
+				containerService:     cs,
+				agentPool:            cs.Properties.AgentPoolProfiles[0],
+				client:               client,
+				nameSuffix:           "12345678",
+				logger:               log.NewEntry(log.New()),
+			}
+
+			cmd := &cobra.Command{}
+			cmd.SetOutput(&bytes.Buffer{})
+			err := sc.scale(cmd)
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected scaling within the min and max counts to succeed, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("expected error %q, got %v", c.expectedErr, err)
+			}
+			if len(client.vms) != 3 || len(client.mutatingCalls) > 0 {
+				t.Errorf("expected the node pool not to be scaled, got calls %v", client.mutatingCalls)
+			}
+		})
+	}
+}
+
+func TestScaleCmdUnhealthyMaster(t *testing.T) {
+	cases := []struct {
+		name             string
+		notReadyMasters  int
+		expectedErr      string
+		expectedWarnings []string
+	}{
+		{
+			name:             "one master down",
+			notReadyMasters:  1,
+			expectedWarnings: []string{"Master k8s-master-12345678-0 is not Ready, scaling through the other masters"},
+		},
+		{
+			name:            "quorum lost",
+			notReadyMasters: 2,
+			expectedErr:     "only 1 of the 3 masters are Ready, scaling needs a quorum of 2 Ready masters",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			nodes := &v1.NodeList{}
+			for i := 0; i < 3; i++ {
+				node := v1.Node{}
+				node.Name = fmt.Sprintf("k8s-master-12345678-%d", i)
+				node.Labels = map[string]string{"kubernetes.io/role": "master"}
+				status := v1.ConditionTrue
+				if i < c.notReadyMasters {
+					status = v1.ConditionUnknown
+				}
+				node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}
+				nodes.Items = append(nodes.Items, node)
+			}
+			client := &scaleRecordingClient{MockACSEngineClient: &armhelpers.MockACSEngineClient{
+				MockKubernetesClient: &armhelpers.MockKubernetesClient{NodesList: nodes},
+			}}
+			for _, name := range []string{"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1"} {
+				vmName := name
+				client.vms = append(client.vms, compute.VirtualMachine{
+					Name: &vmName,
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						StorageProfile: &compute.StorageProfile{ImageReference: &compute.ImageReference{}},
+					},
+				})
+			}
+			deploymentDirectory, err := ioutil.TempDir("", "scale")
+			if err != nil {
+				t.Fatalf("unexpected error creating the deployment directory: %v", err)
+			}
+			defer os.RemoveAll(deploymentDirectory)
+
+			var logs bytes.Buffer
+			logger := log.New()
+			logger.Out = &logs
+			cs := api.CreateMockContainerService("testcluster", "1.10.9", 3, 2, false)
+			sc := &scaleCmd{
+				resourceGroupName:    "rg",
+				location:             "westus",
+				masterFQDN:           "testcluster.westus.cloudapp.azure.com",
+				newDesiredAgentCount: 1,
+				agentPoolToScale:     "agentpool1",
+				deploymentDirectory:  deploymentDirectory,
+				apiModelPath:         "../pkg/acsengine/testdata/simple/kubernetes.json",
+				containerService:     cs,
+				agentPool:            cs.Properties.AgentPoolProfiles[0],
+				client:               client,
+				nameSuffix:           "12345678",
+				logger:               log.NewEntry(logger),
+			}
+
+			err = sc.scale(&cobra.Command{})
+			if c.expectedErr != "" {
+				if err == nil || err.Error() != c.expectedErr {
+					t.Errorf("expected error %q, got %v", c.expectedErr, err)
+				}
+				if len(client.mutatingCalls) > 0 {
+					t.Errorf("expected the node pool not to be scaled without a quorum of masters, got calls %v", client.mutatingCalls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the scale operation to succeed with a quorum of masters, got %v", err)
+			}
+			if len(client.mutatingCalls) == 0 || client.mutatingCalls[0] != "DeleteVirtualMachine k8s-agentpool1-12345678-1" {
+				t.Errorf("expected the node pool to be scaled down, got calls %v", client.mutatingCalls)
+			}
+			for _, warning := range c.expectedWarnings {
+				if !strings.Contains(logs.String(), "level=warning msg=\""+warning+"\"") {
+					t.Errorf("expected the warning %q, got the logs %s", warning, logs.String())
+				}
+			}
+		})
+	}
+}
+
+func TestScaleCmdWaitForNodes(t *testing.T) {
+	defer func(interval time.Duration) { nodeReadyPollInterval = interval }(nodeReadyPollInterval)
+	nodeReadyPollInterval = time.Millisecond
+
+	cases := []struct {
+		name             string
+		readyAfterCalls  int
+		nodeReadyTimeout time.Duration
+		expectedErr      string
+	}{
+		{
+			name:             "new nodes joining late",
+			readyAfterCalls:  4,
+			nodeReadyTimeout: time.Minute,
+		},
+		{
+			name:             "new nodes not joining",
+			readyAfterCalls:  -1,
+			nodeReadyTimeout: 20 * time.Millisecond,
+			expectedErr:      "node pool agentpool1 was scaled to 4 nodes, but its new nodes didn't join the cluster: 2 of the 2 nodes were not Ready within 20ms: k8s-agentpool1-12345678-2, k8s-agentpool1-12345678-3",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			calls := 0
+			listNodes := func() (*v1.NodeList, error) {
+				calls++
+				nodes := &v1.NodeList{}
+				names := []string{"k8s-master-12345678-0", "k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1"}
+				// the new nodes register and become Ready after the master check and a few polls
+				if c.readyAfterCalls > 0 && calls >= c.readyAfterCalls {
+					names = append(names, "k8s-agentpool1-12345678-2", "k8s-agentpool1-12345678-3")
+				}
+				for _, name := range names {
+					node := v1.Node{}
+					node.Name = name
+					if strings.Contains(name, "master") {
+						node.Labels = map[string]string{"kubernetes.io/role": "master"}
+					}
+					node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+					nodes.Items = append(nodes.Items, node)
+				}
+				return nodes, nil
+			}
+			client := &scaleRecordingClient{MockACSEngineClient: &armhelpers.MockACSEngineClient{
+				MockKubernetesClient: &armhelpers.MockKubernetesClient{ListNodesFunc: listNodes},
+			}}
+			for _, name := range []string{"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1"} {
+				vmName := name
+				client.vms = append(client.vms, compute.VirtualMachine{
+					Name: &vmName,
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						StorageProfile: &compute.StorageProfile{ImageReference: &compute.ImageReference{}},
+					},
+				})
+			}
+			deploymentDirectory, err := ioutil.TempDir("", "scale")
+			if err != nil {
+				t.Fatalf("unexpected error creating the deployment directory: %v", err)
+			}
+			defer os.RemoveAll(deploymentDirectory)
+
+			cs := api.CreateMockContainerService("testcluster", "1.10.9", 1, 2, false)
+			sc := &scaleCmd{
+				resourceGroupName:    "rg",
+				location:             "westus",
+				masterFQDN:           "testcluster.westus.cloudapp.azure.com",
+				newDesiredAgentCount: 4,
+				agentPoolToScale:     "agentpool1",
+				waitForNodes:         true,
+				nodeReadyTimeout:     c.nodeReadyTimeout,
+				deploymentDirectory:  deploymentDirectory,
+				apiModelPath:         "../pkg/acsengine/testdata/simple/kubernetes.json",
+				containerService:     cs,
+				agentPool:            cs.Properties.AgentPoolProfiles[0],
+				client:               client,
+				nameSuffix:           "12345678",
+				logger:               log.NewEntry(log.New()),
+			}
+
+			err = sc.scale(&cobra.Command{})
+			if len(client.mutatingCalls) != 1 || client.mutatingCalls[0] != "DeployTemplate" {
+				t.Errorf("expected the node pool to be scaled up, got calls %v", client.mutatingCalls)
+			}
+			if c.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected the scale operation to wait for the new nodes, got %v", err)
+				}
+				if calls != c.readyAfterCalls {
+					t.Errorf("expected the nodes to be listed %d times, got %d", c.readyAfterCalls, calls)
+				}
+				return
+			}
+			if err == nil || err.Error() != c.expectedErr {
+				t.Errorf("expected error %q, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestScaleCmdRebalancePods(t *testing.T) {
+	defer func(interval time.Duration) { nodeReadyPollInterval = interval }(nodeReadyPollInterval)
+	nodeReadyPollInterval = time.Millisecond
+
+	cases := []struct {
+		name          string
+		failCreateJob bool
+		expectedErr   string
+	}{
+		{
+			name: "descheduler job created",
+		},
+		{
+			name:          "descheduler job failing",
+			failCreateJob: true,
+			expectedErr:   "node pool agentpool1 was scaled to 4 nodes, but its pods couldn't be rebalanced: error creating the descheduler job: CreateJob failed",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			listNodes := func() (*v1.NodeList, error) {
+				nodes := &v1.NodeList{}
+				for _, name := range []string{"k8s-master-12345678-0", "k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1", "k8s-agentpool1-12345678-2", "k8s-agentpool1-12345678-3"} {
+					node := v1.Node{}
+					node.Name = name
+					if strings.Contains(name, "master") {
+						node.Labels = map[string]string{"kubernetes.io/role": "master"}
+					}
+					node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+					nodes.Items = append(nodes.Items, node)
+				}
+				return nodes, nil
+			}
+			kubeClient := &armhelpers.MockKubernetesClient{ListNodesFunc: listNodes, FailCreateJob: c.failCreateJob}
+			client := &scaleRecordingClient{MockACSEngineClient: &armhelpers.MockACSEngineClient{MockKubernetesClient: kubeClient}}
+			for _, name := range []string{"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1"} {
+				vmName := name
+				client.vms = append(client.vms, compute.VirtualMachine{
+					Name: &vmName,
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						StorageProfile: &compute.StorageProfile{ImageReference: &compute.ImageReference{}},
+					},
+				})
+			}
+			deploymentDirectory, err := ioutil.TempDir("", "scale")
+			if err != nil {
+				t.Fatalf("unexpected error creating the deployment directory: %v", err)
+			}
+			defer os.RemoveAll(deploymentDirectory)
+
+			cs := api.CreateMockContainerService("testcluster", "1.10.9", 1, 2, false)
+			sc := &scaleCmd{
+				resourceGroupName:    "rg",
+				location:             "westus",
+				masterFQDN:           "testcluster.westus.cloudapp.azure.com",
+				newDesiredAgentCount: 4,
+				agentPoolToScale:     "agentpool1",
+				waitForNodes:         true,
+				nodeReadyTimeout:     time.Minute,
+				rebalancePods:        true,
+				rebalanceStrategies:  []string{operations.DeschedulerStrategyRemoveDuplicates},
+				deploymentDirectory:  deploymentDirectory,
+				apiModelPath:         "../pkg/acsengine/testdata/simple/kubernetes.json",
+				containerService:     cs,
+				agentPool:            cs.Properties.AgentPoolProfiles[0],
+				client:               client,
+				nameSuffix:           "12345678",
+				logger:               log.NewEntry(log.New()),
+			}
+
+			err = sc.scale(&cobra.Command{})
+			if c.expectedErr != "" {
+				if err == nil || err.Error() != c.expectedErr {
+					t.Errorf("expected error %q, got %v", c.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error rebalancing the pods: %v", err)
+			}
+			if len(kubeClient.Jobs) != 1 {
+				t.Fatalf("expected a descheduler job to be created, got %v", kubeClient.Jobs)
+			}
+			var policy string
+			for _, o := range kubeClient.Applied {
+				if configMap, ok := o.(*v1.ConfigMap); ok {
+					policy = configMap.Data["policy.yaml"]
+				}
+			}
+			if !strings.Contains(policy, operations.DeschedulerStrategyRemoveDuplicates) {
+				t.Errorf("expected the descheduler policy to run the %s strategy, got:\n%s", operations.DeschedulerStrategyRemoveDuplicates, policy)
+			}
+		})
+	}
+}
+
+func TestScaleCmdExpiredBootstrapToken(t *testing.T) {
+	cases := []struct {
+		name        string
+		masterFQDN  string
+		expectedErr string
+	}{
+		{
+			name:       "token secret created",
+			masterFQDN: "testcluster.westus.cloudapp.azure.com",
+		},
+		{
+			name:        "no master FQDN",
+			expectedErr: "master-FQDN is required to scale a cluster whose bootstrap token has expired",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			master := v1.Node{}
+			master.Name = "k8s-master-12345678-0"
+			master.Labels = map[string]string{"kubernetes.io/role": "master"}
+			master.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+			kubeClient := &armhelpers.MockKubernetesClient{NodesList: &v1.NodeList{Items: []v1.Node{master}}}
+			client := &scaleRecordingClient{MockACSEngineClient: &armhelpers.MockACSEngineClient{
+				MockKubernetesClient: kubeClient,
+			}}
+			for _, name := range []string{"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1"} {
+				vmName := name
+				client.vms = append(client.vms, compute.VirtualMachine{
+					Name: &vmName,
+					VirtualMachineProperties: &compute.VirtualMachineProperties{
+						StorageProfile: &compute.StorageProfile{ImageReference: &compute.ImageReference{}},
+					},
+				})
+			}
+			deploymentDirectory, err := ioutil.TempDir("", "scale")
+			if err != nil {
+				t.Fatalf("unexpected error creating the deployment directory: %v", err)
+			}
+			defer os.RemoveAll(deploymentDirectory)
+
+			cs := api.CreateMockContainerService("testcluster", "1.10.9", 1, 2, false)
+			cs.Properties.OrchestratorProfile.KubernetesConfig.BootstrapTokenTTL = "2h"
+			cs.Properties.OrchestratorProfile.KubernetesConfig.BootstrapToken = "abcdef.0123456789abcdef"
+			cs.Properties.OrchestratorProfile.KubernetesConfig.BootstrapTokenExpiration = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+			apiloader := &api.Apiloader{
+				Translator: nil,
+			}
+			b, err := apiloader.SerializeContainerService(cs, "vlabs")
+			if err != nil {
+				t.Fatalf("unexpected error serializing the apimodel: %v", err)
+			}
+			apiModelPath := deploymentDirectory + "/apimodel.json"
+			if err = ioutil.WriteFile(apiModelPath, b, 0600); err != nil {
+				t.Fatalf("unexpected error writing the apimodel: %v", err)
+			}
+
+			sc := &scaleCmd{
+				resourceGroupName:    "rg",
+				location:             "westus",
+				masterFQDN:           c.masterFQDN,
+				newDesiredAgentCount: 4,
+				agentPoolToScale:     "agentpool1",
+				deploymentDirectory:  deploymentDirectory,
+				apiModelPath:         apiModelPath,
+				containerService:     cs,
+				agentPool:            cs.Properties.AgentPoolProfiles[0],
+				client:               client,
+				nameSuffix:           "12345678",
+				logger:               log.NewEntry(log.New()),
+			}
+
+			err = sc.scale(&cobra.Command{})
+			if c.expectedErr != "" {
+				if err == nil || err.Error() != c.expectedErr {
+					t.Errorf("expected error %q, got %v", c.expectedErr, err)
+				}
+				if len(client.mutatingCalls) > 0 {
+					t.Errorf("expected the node pool not to be scaled without creating the secret of the token, got calls %v", client.mutatingCalls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the scale operation to succeed, got %v", err)
+			}
+			if len(client.mutatingCalls) != 1 || client.mutatingCalls[0] != "DeployTemplate" {
+				t.Errorf("expected the node pool to be scaled up, got calls %v", client.mutatingCalls)
+			}
+
+			k := cs.Properties.OrchestratorProfile.KubernetesConfig
+			if k.BootstrapToken == "abcdef.0123456789abcdef" {
+				t.Fatalf("expected the expired bootstrap token to be rotated")
+			}
+			if len(kubeClient.Applied) != 1 {
+				t.Fatalf("expected the secret of the new bootstrap token to be created, got %v", kubeClient.Applied)
+			}
+			secret, ok := kubeClient.Applied[0].(*v1.Secret)
+			if !ok || secret.Name != "bootstrap-token-"+k.GetBootstrapTokenID() || secret.StringData["token-secret"] != k.GetBootstrapTokenSecret() {
+				t.Errorf("expected the secret of bootstrap token %s, got %v", k.BootstrapToken, kubeClient.Applied[0])
+			}
+
+			saved, err := ioutil.ReadFile(apiModelPath)
+			if err != nil {
+				t.Fatalf("unexpected error reading the saved apimodel: %v", err)
+			}
+			if !strings.Contains(string(saved), k.BootstrapToken) {
+				t.Errorf("expected the saved apimodel to keep the bootstrap token %s", k.BootstrapToken)
+			}
+		})
+	}
+}
@@ -40,6 +40,9 @@ type upgradeCmd struct {
 	upgradeVersion      string
 	location            string
 	timeoutInMinutes    int
+	poolOrder           []string
+	agentPool           string
+	maxSurge            int
 
 	// derived
 	containerService    *api.ContainerService
@@ -49,6 +52,7 @@ type upgradeCmd struct {
 	nameSuffix          string
 	agentPoolsToUpgrade []string
 	timeout             *time.Duration
+	controlPlaneVersion string
 }
 
 // NewUpgradeCmd run a command to upgrade a Kubernetes cluster
@@ -70,6 +74,9 @@ func newUpgradeCmd() *cobra.Command {
 	f.StringVar(&uc.deploymentDirectory, "deployment-dir", "", "the location of the output from `generate` (required)")
 	f.StringVarP(&uc.upgradeVersion, "upgrade-version", "k", "", "desired kubernetes version (required)")
 	f.IntVar(&uc.timeoutInMinutes, "vm-timeout", -1, "how long to wait for each vm to be upgraded in minutes")
+	f.StringSliceVar(&uc.poolOrder, "pool-order", []string{}, "the names of the agent pools in the order they are upgraded after the masters, the pools not listed are upgraded last in the order of the api model (separate the names with commas: pool1,pool2)")
+	f.StringVar(&uc.agentPool, "agent-pool", "", "the name of a single agent pool to upgrade, leaving the masters and the other agent pools untouched; --upgrade-version must not be newer than the masters nor more than two minor versions older")
+	f.IntVar(&uc.maxSurge, "max-surge", 0, "the number of agent nodes created at the target version before the nodes they replace are drained and deleted, 0 replaces the nodes one at a time with a single extra node")
 	addAuthFlags(&uc.authArgs, f)
 
 	return upgradeCmd
@@ -111,6 +118,16 @@ func (uc *upgradeCmd) validate(cmd *cobra.Command) error {
 		cmd.Usage()
 		return errors.New("--deployment-dir must be specified")
 	}
+
+	if uc.agentPool != "" && len(uc.poolOrder) > 0 {
+		cmd.Usage()
+		return errors.New("--pool-order cannot be used with --agent-pool")
+	}
+
+	if uc.maxSurge < 0 {
+		cmd.Usage()
+		return errors.New("--max-surge must not be negative")
+	}
 	return nil
 }
 
@@ -157,28 +174,35 @@ func (uc *upgradeCmd) loadCluster(cmd *cobra.Command) error {
 		return errors.New("--location does not match api model location")
 	}
 
-	// get available upgrades for container service
-	orchestratorInfo, err := api.GetOrchestratorVersionProfile(uc.containerService.Properties.OrchestratorProfile, uc.containerService.Properties.HasWindows())
-	if err != nil {
-		return errors.Wrap(err, "error getting list of available upgrades")
-	}
+	if uc.agentPool != "" {
+		// the upgrade validates the version against the nodes of the agent pool and the masters, the api model
+		// keeps the version of the masters once the agent pool is upgraded
+		uc.controlPlaneVersion = uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion
+		uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.upgradeVersion
+	} else {
+		// get available upgrades for container service
+		orchestratorInfo, err := api.GetOrchestratorVersionProfile(uc.containerService.Properties.OrchestratorProfile, uc.containerService.Properties.HasWindows())
+		if err != nil {
+			return errors.Wrap(err, "error getting list of available upgrades")
+		}
 
-	// add the current version if upgrade has failed
-	orchestratorInfo.Upgrades = append(orchestratorInfo.Upgrades, &api.OrchestratorProfile{
-		OrchestratorType:    uc.containerService.Properties.OrchestratorProfile.OrchestratorType,
-		OrchestratorVersion: uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion})
-
-	// validate desired upgrade version and set goal state
-	found := false
-	for _, up := range orchestratorInfo.Upgrades {
-		if up.OrchestratorVersion == uc.upgradeVersion {
-			uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.upgradeVersion
-			found = true
-			break
+		// add the current version if upgrade has failed
+		orchestratorInfo.Upgrades = append(orchestratorInfo.Upgrades, &api.OrchestratorProfile{
+			OrchestratorType:    uc.containerService.Properties.OrchestratorProfile.OrchestratorType,
+			OrchestratorVersion: uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion})
+
+		// validate desired upgrade version and set goal state
+		found := false
+		for _, up := range orchestratorInfo.Upgrades {
+			if up.OrchestratorVersion == uc.upgradeVersion {
+				uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.upgradeVersion
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("Upgrading to version %s is not supported. To see a list of available upgrades, use 'acs-engine orchestrators --orchestrator kubernetes --version %s'", uc.upgradeVersion, uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion)
 		}
-	}
-	if !found {
-		return errors.Errorf("Upgrading to version %s is not supported. To see a list of available upgrades, use 'acs-engine orchestrators --orchestrator kubernetes --version %s'", uc.upgradeVersion, uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion)
 	}
 
 	// Read name suffix to identify nodes in the resource group that belong
@@ -199,14 +223,46 @@ func (uc *upgradeCmd) loadCluster(cmd *cobra.Command) error {
 	uc.nameSuffix = nameSuffixParam["defaultValue"].(string)
 	log.Infoln(fmt.Sprintf("Name suffix: %s", uc.nameSuffix))
 
-	uc.agentPoolsToUpgrade = []string{}
+	if uc.agentPool != "" {
+		return nil
+	}
 	log.Infoln(fmt.Sprintf("Gathering agent pool names..."))
-	for _, agentPool := range uc.containerService.Properties.AgentPoolProfiles {
-		uc.agentPoolsToUpgrade = append(uc.agentPoolsToUpgrade, agentPool.Name)
+	if uc.agentPoolsToUpgrade, err = orderAgentPools(uc.containerService.Properties.AgentPoolProfiles, uc.poolOrder); err != nil {
+		return err
 	}
 	return nil
 }
 
+// orderAgentPools returns the names of the agent pools in the upgrade order: the pools of poolOrder
+// first, then the other pools in the order of the api model
+func orderAgentPools(agentPools []*api.AgentPoolProfile, poolOrder []string) ([]string, error) {
+	ordered := []string{}
+	listed := map[string]bool{}
+	for _, name := range poolOrder {
+		if listed[name] {
+			return nil, errors.Errorf("--pool-order lists the agent pool %s more than once", name)
+		}
+		found := false
+		for _, agentPool := range agentPools {
+			if agentPool.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("--pool-order lists the agent pool %s, which is not in the api model", name)
+		}
+		listed[name] = true
+		ordered = append(ordered, name)
+	}
+	for _, agentPool := range agentPools {
+		if !listed[agentPool.Name] {
+			ordered = append(ordered, agentPool.Name)
+		}
+	}
+	return ordered, nil
+}
+
 func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 	err := uc.validate(cmd)
 	if err != nil {
@@ -222,9 +278,10 @@ func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 		Translator: &i18n.Translator{
 			Locale: uc.locale,
 		},
-		Logger:      log.NewEntry(log.New()),
+		Logger:      log.NewEntry(log.StandardLogger()),
 		Client:      uc.client,
 		StepTimeout: uc.timeout,
+		MaxSurge:    uc.maxSurge,
 	}
 
 	kubeConfig, err := acsengine.GenerateKubeConfig(uc.containerService.Properties, uc.location)
@@ -232,7 +289,13 @@ func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 		log.Fatalf("failed to generate kube config: %v", err) // TODO: cleanup
 	}
 
-	if err = upgradeCluster.UpgradeCluster(uc.authArgs.SubscriptionID, uc.client, kubeConfig, uc.resourceGroupName,
+	if uc.agentPool != "" {
+		if err = upgradeCluster.UpgradeAgentPool(uc.authArgs.SubscriptionID, uc.client, kubeConfig, uc.resourceGroupName,
+			uc.containerService, uc.nameSuffix, uc.agentPool, BuildTag); err != nil {
+			log.Fatalf("Error upgrading agent pool %s: %v\n", uc.agentPool, err)
+		}
+		uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.controlPlaneVersion
+	} else if err = upgradeCluster.UpgradeCluster(uc.authArgs.SubscriptionID, uc.client, kubeConfig, uc.resourceGroupName,
 		uc.containerService, uc.nameSuffix, uc.agentPoolsToUpgrade, BuildTag); err != nil {
 		log.Fatalf("Error upgrading cluster: %v\n", err)
 	}
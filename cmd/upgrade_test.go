@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"github.com/Azure/acs-engine/pkg/api"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
@@ -22,6 +23,50 @@ var _ = Describe("the upgrade command", func() {
 		Expect(output.Flags().Lookup("resource-group")).NotTo(BeNil())
 		Expect(output.Flags().Lookup("deployment-dir")).NotTo(BeNil())
 		Expect(output.Flags().Lookup("upgrade-version")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("pool-order")).NotTo(BeNil())
+		Expect(output.Flags().Lookup("agent-pool")).NotTo(BeNil())
+	})
+
+	It("should order the agent pools to upgrade", func() {
+		agentPools := []*api.AgentPoolProfile{{Name: "user1"}, {Name: "system"}, {Name: "user2"}}
+
+		cases := []struct {
+			poolOrder   []string
+			expected    []string
+			expectedErr error
+		}{
+			{
+				poolOrder: []string{},
+				expected:  []string{"user1", "system", "user2"},
+			},
+			{
+				poolOrder: []string{"system"},
+				expected:  []string{"system", "user1", "user2"},
+			},
+			{
+				poolOrder: []string{"user2", "system", "user1"},
+				expected:  []string{"user2", "system", "user1"},
+			},
+			{
+				poolOrder:   []string{"system", "gpu"},
+				expectedErr: errors.New("--pool-order lists the agent pool gpu, which is not in the api model"),
+			},
+			{
+				poolOrder:   []string{"system", "system"},
+				expectedErr: errors.New("--pool-order lists the agent pool system more than once"),
+			},
+		}
+
+		for _, c := range cases {
+			ordered, err := orderAgentPools(agentPools, c.poolOrder)
+			if c.expectedErr != nil {
+				Expect(err).NotTo(BeNil())
+				Expect(err.Error()).To(Equal(c.expectedErr.Error()))
+			} else {
+				Expect(err).To(BeNil())
+				Expect(ordered).To(Equal(c.expected))
+			}
+		}
 	})
 
 	It("should validate an upgrade command", func() {
@@ -81,6 +126,47 @@ var _ = Describe("the upgrade command", func() {
 				},
 				expectedErr: errors.New("--deployment-dir must be specified"),
 			},
+			{
+				uc: &upgradeCmd{
+					resourceGroupName:   "test",
+					deploymentDirectory: "_output/mydir",
+					upgradeVersion:      "1.9.0",
+					location:            "southcentralus",
+					agentPool:           "agentpool1",
+					poolOrder:           []string{"agentpool1"},
+				},
+				expectedErr: errors.New("--pool-order cannot be used with --agent-pool"),
+			},
+			{
+				uc: &upgradeCmd{
+					resourceGroupName:   "test",
+					deploymentDirectory: "_output/mydir",
+					upgradeVersion:      "1.9.0",
+					location:            "southcentralus",
+					maxSurge:            -1,
+				},
+				expectedErr: errors.New("--max-surge must not be negative"),
+			},
+			{
+				uc: &upgradeCmd{
+					resourceGroupName:   "test",
+					deploymentDirectory: "_output/mydir",
+					upgradeVersion:      "1.9.0",
+					location:            "southcentralus",
+					maxSurge:            3,
+				},
+				expectedErr: nil,
+			},
+			{
+				uc: &upgradeCmd{
+					resourceGroupName:   "test",
+					deploymentDirectory: "_output/mydir",
+					upgradeVersion:      "1.9.0",
+					location:            "southcentralus",
+					agentPool:           "agentpool1",
+				},
+				expectedErr: nil,
+			},
 			{
 				uc: &upgradeCmd{
 					resourceGroupName:   "test",
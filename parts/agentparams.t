@@ -70,6 +70,13 @@
       },
       "type": "string"
     },
+    "{{.Name}}osImageId": {
+      "defaultValue": "",
+      "metadata": {
+        "description": "Resource ID of a shared image gallery image version. Takes precedence over osImageName and osImageResourceGroup."
+      },
+      "type": "string"
+    },
     "{{.Name}}osImageOffer": {
       "defaultValue": "UbuntuServer",
       "metadata": {
@@ -98,6 +105,14 @@
       },
       "type": "string"
     },
+{{if .HasExistingStorageAccount}}
+    "{{.Name}}StorageAccountID": {
+      "metadata": {
+        "description": "Resource ID of the existing storage account holding the VHDs of agent pool '{{.Name}}'."
+      },
+      "type": "string"
+    },
+{{end}}
 {{if .IsCustomVNET}}
     "{{.Name}}VnetSubnetID": {
       "metadata": {
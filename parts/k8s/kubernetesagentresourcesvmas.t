@@ -78,13 +78,22 @@
     },
 {{if .IsManagedDisks}}
    {
+      "copy": {
+        "count": "[variables('{{.Name}}AvailabilitySetCount')]",
+        "name": "availabilitySetLoop"
+      },
       "location": "[variables('location')]",
-      "name": "[variables('{{.Name}}AvailabilitySet')]",
+      "name": "[if(equals(copyIndex(), 0), variables('{{.Name}}AvailabilitySet'), concat(variables('{{.Name}}AvailabilitySet'), '-', string(copyIndex())))]",
       "apiVersion": "[variables('apiVersionCompute')]",
       "properties":
         {
-            "platformFaultDomainCount": 2,
-            "platformUpdateDomainCount": 3
+            "platformFaultDomainCount": {{.PlatformFaultDomainCount}},
+            "platformUpdateDomainCount": {{.PlatformUpdateDomainCount}}
+{{if .HasProximityPlacementGroup}}
+            ,"proximityPlacementGroup": {
+              "id": "{{.ProximityPlacementGroupID}}"
+            }
+{{end}}
         },
       "sku": {
         "name": "Aligned"
@@ -92,6 +101,7 @@
       "type": "Microsoft.Compute/availabilitySets"
     },
 {{else if .IsStorageAccount}}
+    {{if not .HasExistingStorageAccount}}
     {
       "apiVersion": "[variables('apiVersionStorage')]",
       "copy": {
@@ -134,22 +144,33 @@
       "type": "Microsoft.Storage/storageAccounts"
     },
     {{end}}
+    {{end}}
     {
       "location": "[variables('location')]",
       "name": "[variables('{{.Name}}AvailabilitySet')]",
       "apiVersion": "[variables('apiVersionCompute')]",
-      "properties": {},
+      "properties": {
+{{if .HasProximityPlacementGroup}}
+        "proximityPlacementGroup": {
+          "id": "{{.ProximityPlacementGroupID}}"
+        }
+{{end}}
+      },
       "type": "Microsoft.Compute/availabilitySets"
     },
 {{end}}
   {
+{{if .HasDiskEncryptionSet}}
+      "apiVersion": "[variables('apiVersionComputeDiskEncryptionSet')]",
+{{else}}
       "apiVersion": "[variables('apiVersionCompute')]",
+{{end}}
       "copy": {
         "count": "[sub(variables('{{.Name}}Count'), variables('{{.Name}}Offset'))]",
         "name": "vmLoopNode"
       },
       "dependsOn": [
-{{if .IsStorageAccount}}
+{{if and .IsStorageAccount (not .HasExistingStorageAccount)}}
         "[concat('Microsoft.Storage/storageAccounts/',variables('storageAccountPrefixes')[mod(add(div(copyIndex(variables('{{.Name}}Offset')),variables('maxVMsPerStorageAccount')),variables('{{.Name}}StorageAccountOffset')),variables('storageAccountPrefixesCount'))],variables('storageAccountPrefixes')[div(add(div(copyIndex(variables('{{.Name}}Offset')),variables('maxVMsPerStorageAccount')),variables('{{.Name}}StorageAccountOffset')),variables('storageAccountPrefixesCount'))],variables('{{.Name}}AccountName'))]",
 
   {{if .HasDisks}}
@@ -157,7 +178,11 @@
   {{end}}
 {{end}}
         "[concat('Microsoft.Network/networkInterfaces/', variables('{{.Name}}VMNamePrefix'), 'nic-', copyIndex(variables('{{.Name}}Offset')))]",
+{{if .IsManagedDisks}}
+        "[concat('Microsoft.Compute/availabilitySets/', if(equals(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet')), 0), variables('{{.Name}}AvailabilitySet'), concat(variables('{{.Name}}AvailabilitySet'), '-', string(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet'))))))]"
+{{else}}
         "[concat('Microsoft.Compute/availabilitySets/', variables('{{.Name}}AvailabilitySet'))]"
+{{end}}
       ],
       "tags":
       {
@@ -165,6 +190,7 @@
         "resourceNameSuffix" : "[parameters('nameSuffix')]",
         "orchestrator" : "[variables('orchestratorNameVersionTag')]",
         "acsengineVersion" : "[parameters('acsengineVersion')]",
+        "acsengineGenerationTime" : "[parameters('acsengineGenerationTime')]",
         "poolName" : "{{.Name}}"
       },
       "location": "[variables('location')]",
@@ -192,7 +218,11 @@
       {{end}}
       "properties": {
         "availabilitySet": {
+{{if .IsManagedDisks}}
+          "id": "[resourceId('Microsoft.Compute/availabilitySets', if(equals(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet')), 0), variables('{{.Name}}AvailabilitySet'), concat(variables('{{.Name}}AvailabilitySet'), '-', string(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet'))))))]"
+{{else}}
           "id": "[resourceId('Microsoft.Compute/availabilitySets',variables('{{.Name}}AvailabilitySet'))]"
+{{end}}
         },
         "hardwareProfile": {
           "vmSize": "[variables('{{.Name}}VMSize')]"
@@ -231,7 +261,9 @@
           {{GetDataDisks .}}
           {{end}}
           "imageReference": {
-            {{if UseAgentCustomImage .}}
+            {{if UseAgentGalleryImage .}}
+            "id": "[variables('{{.Name}}osImageId')]"
+            {{else if UseAgentCustomImage .}}
             "id": "[resourceId(variables('{{.Name}}osImageResourceGroup'), 'Microsoft.Compute/images', variables('{{.Name}}osImageName'))]"
             {{else}}
             "offer": "[variables('{{.Name}}osImageOffer')]",
@@ -246,12 +278,19 @@
           {{if .IsStorageAccount}}
             ,"name": "[concat(variables('{{.Name}}VMNamePrefix'), copyIndex(variables('{{.Name}}Offset')),'-osdisk')]"
             ,"vhd": {
-              "uri": "[concat(reference(concat('Microsoft.Storage/storageAccounts/',variables('storageAccountPrefixes')[mod(add(div(copyIndex(variables('{{.Name}}Offset')),variables('maxVMsPerStorageAccount')),variables('{{.Name}}StorageAccountOffset')),variables('storageAccountPrefixesCount'))],variables('storageAccountPrefixes')[div(add(div(copyIndex(variables('{{.Name}}Offset')),variables('maxVMsPerStorageAccount')),variables('{{.Name}}StorageAccountOffset')),variables('storageAccountPrefixesCount'))],variables('{{.Name}}AccountName')),variables('apiVersionStorage')).primaryEndpoints.blob,'osdisk/', variables('{{.Name}}VMNamePrefix'), copyIndex(variables('{{.Name}}Offset')), '-osdisk.vhd')]"
+              "uri": "[concat(reference({{GetAgentStorageAccountReference .}},variables('apiVersionStorage')).primaryEndpoints.blob,'osdisk/', variables('{{.Name}}VMNamePrefix'), copyIndex(variables('{{.Name}}Offset')), '-osdisk.vhd')]"
             }
           {{end}}
           {{if ne .OSDiskSizeGB 0}}
             ,"diskSizeGB": {{.OSDiskSizeGB}}
           {{end}}
+          {{if .HasDiskEncryptionSet}}
+            ,"managedDisk": {
+              "diskEncryptionSet": {
+                "id": "{{.DiskEncryptionSetID}}"
+              }
+            }
+          {{end}}
           }
         }
       },
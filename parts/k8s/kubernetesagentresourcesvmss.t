@@ -10,7 +10,11 @@
   },
 {{end}}
   {
+{{if .HasDiskEncryptionSet}}
+    "apiVersion": "[variables('apiVersionComputeDiskEncryptionSet')]",
+{{else}}
     "apiVersion": "[variables('apiVersionCompute')]",
+{{end}}
     "dependsOn": [
     {{if .IsCustomVNET}}
       "[variables('nsgID')]"
@@ -23,6 +27,8 @@
       "creationSource" : "[concat(parameters('generatorCode'), '-', variables('{{.Name}}VMNamePrefix'))]",
       "resourceNameSuffix" : "[parameters('nameSuffix')]",
       "orchestrator" : "[variables('orchestratorNameVersionTag')]",
+      "acsengineVersion" : "[parameters('acsengineVersion')]",
+      "acsengineGenerationTime" : "[parameters('acsengineGenerationTime')]",
       "poolName" : "{{.Name}}"
     },
     "location": "[variables('location')]",
@@ -51,9 +57,17 @@
     },
     "properties": {
       "singlePlacementGroup": {{UseSinglePlacementGroup .}},
-      "overprovision": false,
+      "overprovision": {{.IsOverProvisioned}},
+{{if .HasProximityPlacementGroup}}
+      "proximityPlacementGroup": {
+        "id": "{{.ProximityPlacementGroupID}}"
+      },
+{{end}}
+      {{if .IsOverProvisioned}}
+      "doNotRunExtensionsOnOverprovisionedVMs": true,
+      {{end}}
       "upgradePolicy": {
-        "mode": "Manual"
+        "mode": "{{.UpgradePolicyMode}}"
       },
       "virtualMachineProfile": {
         {{if .IsLowPriorityScaleSet}}
@@ -127,7 +141,9 @@
             {{GetDataDisks .}}
           {{end}}
           "imageReference": {
-            {{if UseAgentCustomImage .}}
+            {{if UseAgentGalleryImage .}}
+            "id": "[variables('{{.Name}}osImageId')]"
+            {{else if UseAgentCustomImage .}}
             "id": "[resourceId(variables('{{.Name}}osImageResourceGroup'), 'Microsoft.Compute/images', variables('{{.Name}}osImageName'))]"
             {{else}}
             "offer": "[variables('{{.Name}}osImageOffer')]",
@@ -142,6 +158,13 @@
           {{if ne .OSDiskSizeGB 0}}
             ,"diskSizeGB": {{.OSDiskSizeGB}}
           {{end}}
+          {{if .HasDiskEncryptionSet}}
+            ,"managedDisk": {
+              "diskEncryptionSet": {
+                "id": "{{.DiskEncryptionSetID}}"
+              }
+            }
+          {{end}}
           }
         },
         "extensionProfile": {
@@ -159,6 +182,21 @@
                 }
               }
             }
+            {{if eq .UpgradePolicyMode "Rolling"}}
+            ,{
+              "name": "HealthExtension",
+              "properties": {
+                "publisher": "Microsoft.ManagedServices",
+                "type": "ApplicationHealthLinux",
+                "typeHandlerVersion": "1.0",
+                "autoUpgradeMinorVersion": true,
+                "settings": {
+                  "protocol": "tcp",
+                  "port": 10250
+                }
+              }
+            }
+            {{end}}
             {{if UseAksExtension}}
             ,{
               "name": "[concat(variables('{{.Name}}VMNamePrefix'), '-computeAksLinuxBilling')]",
@@ -1,3 +1,6 @@
+{{if .HasExistingStorageAccount}}
+    "{{.Name}}StorageAccountID": "[parameters('{{.Name}}StorageAccountID')]",
+{{end}}
 {{if .IsStorageAccount}}
     "{{.Name}}StorageAccountOffset": "[mul(variables('maxStorageAccountsPerAgent'),variables('{{.Name}}Index'))]",
     "{{.Name}}StorageAccountsCount": "[add(div(variables('{{.Name}}Count'), variables('maxVMsPerStorageAccount')), mod(add(mod(variables('{{.Name}}Count'), variables('maxVMsPerStorageAccount')),2), add(mod(variables('{{.Name}}Count'), variables('maxVMsPerStorageAccount')),1)))]",
@@ -10,6 +13,9 @@
 {{if .IsAvailabilitySets}}
     "{{.Name}}Offset": "[parameters('{{.Name}}Offset')]",
     "{{.Name}}AvailabilitySet": "[concat('{{.Name}}-availabilitySet-', parameters('nameSuffix'))]",
+  {{if .IsManagedDisks}}
+    "{{.Name}}AvailabilitySetCount": "[add(div(sub(variables('{{.Name}}Count'), 1), variables('maxVMsPerAvailabilitySet')), 1)]",
+  {{end}}
 {{else}}
     {{if .IsLowPriorityScaleSet}}
     "{{.Name}}ScaleSetPriority": "[parameters('{{.Name}}ScaleSetPriority')]",
@@ -31,3 +37,4 @@
     "{{.Name}}osImageVersion": "[parameters('{{.Name}}osImageVersion')]",
     "{{.Name}}osImageName": "[parameters('{{.Name}}osImageName')]",
     "{{.Name}}osImageResourceGroup": "[parameters('{{.Name}}osImageResourceGroup')]",
+    "{{.Name}}osImageId": "[parameters('{{.Name}}osImageId')]",
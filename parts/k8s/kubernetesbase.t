@@ -128,9 +128,9 @@
       }
     {{else}}
       {{if IsMasterVirtualMachineScaleSets}}
-          ,{{template "k8s/kubernetesmasterresourcesvmss.t" .}}
+          {{if .AgentPoolProfiles}},{{end}}{{template "k8s/kubernetesmasterresourcesvmss.t" .}}
         {{else}}
-          ,{{template "k8s/kubernetesmasterresources.t" .}}
+          {{if .AgentPoolProfiles}},{{end}}{{template "k8s/kubernetesmasterresources.t" .}}
         {{end}}
     {{end}}
   ],
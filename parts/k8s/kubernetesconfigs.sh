@@ -77,6 +77,21 @@ configureEtcd() {
     echo "${ETCD_PEER_CERT}" | base64 --decode > "${ETCD_PEER_CERTIFICATE_PATH}"
     set -x
 
+    if [[ -n "${SERVICE_ACCOUNT_SIGNING_KEY}" ]]; then
+        SERVICE_ACCOUNT_SIGNING_KEY_PATH="/etc/kubernetes/certs/serviceaccount.key"
+        SERVICE_ACCOUNT_VERIFICATION_KEY_PATH="/etc/kubernetes/certs/serviceaccount-verification.key"
+        for KEY_PATH in "${SERVICE_ACCOUNT_SIGNING_KEY_PATH}" "${SERVICE_ACCOUNT_VERIFICATION_KEY_PATH}"; do
+            touch "${KEY_PATH}"
+            chmod 0600 "${KEY_PATH}"
+            chown root:root "${KEY_PATH}"
+        done
+        set +x
+        echo "${SERVICE_ACCOUNT_SIGNING_KEY}" | base64 --decode > "${SERVICE_ACCOUNT_SIGNING_KEY_PATH}"
+        # the legacy service account tokens are still signed with the apiserver key
+        cat "${APISERVER_PRIVATE_KEY_PATH}" "${SERVICE_ACCOUNT_SIGNING_KEY_PATH}" > "${SERVICE_ACCOUNT_VERIFICATION_KEY_PATH}"
+        set -x
+    fi
+
     ETCD_SETUP_FILE=/opt/azure/containers/setup-etcd.sh
     wait_for_file 1200 1 $ETCD_SETUP_FILE || exit $ERR_ETCD_CONFIG_FAIL
     $ETCD_SETUP_FILE > /opt/azure/containers/setup-etcd.log 2>&1
@@ -110,10 +125,19 @@ runAptDaily() {
     /usr/lib/apt/apt.systemd.daily
 }
 
-generateAggregatedAPICerts() {
-    AGGREGATED_API_CERTS_SETUP_FILE=/etc/kubernetes/generate-proxy-certs.sh
-    wait_for_file 1200 1 $AGGREGATED_API_CERTS_SETUP_FILE || exit $ERR_FILE_WATCH_TIMEOUT
-    $AGGREGATED_API_CERTS_SETUP_FILE
+configureAggregatedAPICerts() {
+    PROXY_CA_CERTIFICATE_PATH="/etc/kubernetes/certs/proxy-ca.crt"
+    PROXY_CLIENT_CERTIFICATE_PATH="/etc/kubernetes/certs/proxy.crt"
+    PROXY_CLIENT_PRIVATE_KEY_PATH="/etc/kubernetes/certs/proxy.key"
+    touch "${PROXY_CLIENT_PRIVATE_KEY_PATH}"
+    chmod 0600 "${PROXY_CLIENT_PRIVATE_KEY_PATH}"
+    chown root:root "${PROXY_CLIENT_PRIVATE_KEY_PATH}"
+
+    set +x
+    echo "${FRONT_PROXY_CA_CERTIFICATE}" | base64 --decode > "${PROXY_CA_CERTIFICATE_PATH}"
+    echo "${FRONT_PROXY_CLIENT_CERTIFICATE}" | base64 --decode > "${PROXY_CLIENT_CERTIFICATE_PATH}"
+    echo "${FRONT_PROXY_CLIENT_PRIVATE_KEY}" | base64 --decode > "${PROXY_CLIENT_PRIVATE_KEY_PATH}"
+    set -x
 }
 
 configureK8s() {
@@ -138,6 +162,17 @@ configureK8s() {
     # Perform the required JSON escaping for special characters " and \
     SERVICE_PRINCIPAL_CLIENT_SECRET=$(echo $SERVICE_PRINCIPAL_CLIENT_SECRET | sed "s|\\\\|\\\\\\\|g")
     SERVICE_PRINCIPAL_CLIENT_SECRET=$(echo $SERVICE_PRINCIPAL_CLIENT_SECRET | sed 's|"|\\"|g')
+    SERVICE_PRINCIPAL_CLIENT_CERT_PATH=""
+    if [[ -n "${SERVICE_PRINCIPAL_CLIENT_CERT}" ]]; then
+        # the azure cloudprovider only reads PKCS#12 client certificates
+        SERVICE_PRINCIPAL_CLIENT_CERT_PATH="/etc/kubernetes/certs/spclient.pfx"
+        echo "${SERVICE_PRINCIPAL_CLIENT_CERT}" | base64 --decode | openssl pkcs12 -export -out "${SERVICE_PRINCIPAL_CLIENT_CERT_PATH}" \
+            -passin env:SERVICE_PRINCIPAL_CLIENT_CERT_PASSWORD -passout env:SERVICE_PRINCIPAL_CLIENT_CERT_PASSWORD
+        chmod 0600 "${SERVICE_PRINCIPAL_CLIENT_CERT_PATH}"
+        chown root:root "${SERVICE_PRINCIPAL_CLIENT_CERT_PATH}"
+        SERVICE_PRINCIPAL_CLIENT_CERT_PASSWORD=$(echo $SERVICE_PRINCIPAL_CLIENT_CERT_PASSWORD | sed "s|\\\\|\\\\\\\|g")
+        SERVICE_PRINCIPAL_CLIENT_CERT_PASSWORD=$(echo $SERVICE_PRINCIPAL_CLIENT_CERT_PASSWORD | sed 's|"|\\"|g')
+    fi
     cat << EOF > "${AZURE_JSON_PATH}"
 {
     "cloud":"${TARGET_ENVIRONMENT}",
@@ -145,6 +180,8 @@ configureK8s() {
     "subscriptionId": "${SUBSCRIPTION_ID}",
     "aadClientId": "${SERVICE_PRINCIPAL_CLIENT_ID}",
     "aadClientSecret": "${SERVICE_PRINCIPAL_CLIENT_SECRET}",
+    "aadClientCertPath": "${SERVICE_PRINCIPAL_CLIENT_CERT_PATH}",
+    "aadClientCertPassword": "${SERVICE_PRINCIPAL_CLIENT_CERT_PASSWORD}",
     "resourceGroup": "${RESOURCE_GROUP}",
     "location": "${LOCATION}",
     "vmType": "${VM_TYPE}",
@@ -173,10 +210,15 @@ configureK8s() {
     "providerKeyVersion": ""
 }
 EOF
+    if [[ -n "${CLOUDPROVIDER_CONFIG}" ]]; then
+        # merge in memory, azure.json holds the service principal secret
+        AZURE_JSON=$(echo "${CLOUDPROVIDER_CONFIG}" | base64 --decode | jq -s '.[0] * .[1]' "${AZURE_JSON_PATH}" -) || exit $ERR_CLOUDPROVIDER_CONFIG_FAIL
+        echo "${AZURE_JSON}" > "${AZURE_JSON_PATH}"
+    fi
     set -x
     if [[ ! -z "${MASTER_NODE}" ]]; then
         if [[ "${ENABLE_AGGREGATED_APIS}" = True ]]; then
-            generateAggregatedAPICerts
+            configureAggregatedAPICerts
         fi
     fi
 }
@@ -187,7 +229,17 @@ configureCNI() {
     echo -n "br_netfilter" > /etc/modules-load.d/br_netfilter.conf
     if [[ "${NETWORK_PLUGIN}" = "azure" ]]; then
         if [[ "${NETWORK_POLICY}" != "calico" ]]; then
-            mv $CNI_BIN_DIR/10-azure.conflist $CNI_CONFIG_DIR/
+            if [ -f $AZURE_CNI_OVERLAY_CONFIG ]; then
+                # the overlay mode replaces the VNET integrated configuration shipped with the plugin
+                mv $AZURE_CNI_OVERLAY_CONFIG $CNI_CONFIG_DIR/10-azure.conflist
+            else
+                mv $CNI_BIN_DIR/10-azure.conflist $CNI_CONFIG_DIR/
+            fi
+            if [[ "${POD_MTU}" -gt 0 ]]; then
+                # the tuning plugin sets the MTU of the interface of the pods created by the azure-vnet plugin
+                jq ".plugins += [{\"type\": \"tuning\", \"mtu\": ${POD_MTU}}]" $CNI_CONFIG_DIR/10-azure.conflist > $CNI_CONFIG_DIR/10-azure.conflist.tmp
+                mv $CNI_CONFIG_DIR/10-azure.conflist.tmp $CNI_CONFIG_DIR/10-azure.conflist
+            fi
             chmod 600 $CNI_CONFIG_DIR/10-azure.conflist
         fi
         /sbin/ebtables -t nat --list
@@ -213,8 +265,14 @@ setupContainerd() {
     CRI_CONTAINERD_CONFIG="/etc/containerd/config.toml"
     echo "subreaper = false" > "$CRI_CONTAINERD_CONFIG"
     echo "oom_score = 0" >> "$CRI_CONTAINERD_CONFIG"
+    if [ -f $CONTAINER_RUNTIME_DISK_SCRIPT ]; then
+        echo "root = \"$CONTAINER_RUNTIME_DISK_MOUNT/containerd\"" >> "$CRI_CONTAINERD_CONFIG"
+    fi
     echo "[plugins.cri]" >> "$CRI_CONTAINERD_CONFIG"
     echo "sandbox_image = \"$POD_INFRA_CONTAINER_SPEC\"" >> "$CRI_CONTAINERD_CONFIG"
+    if [[ "$CGROUP_DRIVER" == "systemd" ]]; then
+        echo "systemd_cgroup = true" >> "$CRI_CONTAINERD_CONFIG"
+    fi
     echo "[plugins.cri.containerd.untrusted_workload_runtime]" >> "$CRI_CONTAINERD_CONFIG"
     echo "runtime_type = 'io.containerd.runtime.v1.linux'" >> "$CRI_CONTAINERD_CONFIG"
     if [[ "$CONTAINER_RUNTIME" == "clear-containers" ]]; then
@@ -227,6 +285,11 @@ setupContainerd() {
     echo "[plugins.cri.containerd.default_runtime]" >> "$CRI_CONTAINERD_CONFIG"
     echo "runtime_type = 'io.containerd.runtime.v1.linux'" >> "$CRI_CONTAINERD_CONFIG"
     echo "runtime_engine = '/usr/local/sbin/runc'" >> "$CRI_CONTAINERD_CONFIG"
+    if [[ -n "${REGISTRY_MIRRORS}" ]]; then
+        # the mirrors of Docker Hub, which remains the last endpoint for the images missing from the mirrors
+        echo "[plugins.cri.registry.mirrors.\"docker.io\"]" >> "$CRI_CONTAINERD_CONFIG"
+        echo "endpoint = [\"${REGISTRY_MIRRORS//,/\", \"}\", \"https://registry-1.docker.io\"]" >> "$CRI_CONTAINERD_CONFIG"
+    fi
     setKubeletOpts " --container-runtime=remote --runtime-request-timeout=15m --container-runtime-endpoint=unix:///run/containerd/containerd.sock"
 }
 
@@ -256,6 +319,17 @@ ensureDocker() {
     systemctlEnableAndStart docker-monitor.timer || exit $ERR_SYSTEMCTL_START_FAIL
 }
 
+prePullImages() {
+    # a failed pull doesn't fail the provisioning, the kubelet pulls the image again when a pod needs it
+    for IMAGE in $(cat $PREPULL_IMAGES_LIST); do
+        if [[ "$CONTAINER_RUNTIME" == "docker" ]]; then
+            retrycmd_if_failure 10 5 600 docker pull $IMAGE
+        else
+            retrycmd_if_failure 10 5 600 ctr --namespace k8s.io images pull $IMAGE
+        fi
+    done
+}
+
 ensureKMS() {
     systemctlEnableAndStart kms || exit $ERR_SYSTEMCTL_START_FAIL
 }
@@ -291,6 +365,61 @@ ensurePodSecurityPolicy() {
     fi
 }
 
+ensureAdmissionWebhooks() {
+    # the oneshot unit waits for the core components, which the provisioning doesn't wait for, and
+    # runs again on boot if the reboot ending the provisioning interrupts it
+    retrycmd_if_failure 120 5 25 systemctl enable admission-webhooks.service || exit $ERR_SYSTEMCTL_START_FAIL
+    systemctl start --no-block admission-webhooks.service || exit $ERR_SYSTEMCTL_START_FAIL
+}
+
+applyAdmissionWebhooks() {
+    # the admission webhooks are only given to the addon-manager once the deployments of kube-system are
+    # available, so that a webhook whose service isn't running yet can't block the core components
+    for i in $(seq 1 180); do
+        DEPLOYMENTS=$(timeout 25 $KUBECTL get deployments --namespace kube-system -o jsonpath='{range .items[*]}{.metadata.name}={.status.availableReplicas}{"\n"}{end}')
+        if [ -n "$DEPLOYMENTS" ] && ! echo "$DEPLOYMENTS" | grep -q "=$"; then
+            cp $ADMISSION_WEBHOOKS_DIR/*.yaml /etc/kubernetes/addons/
+            return
+        fi
+        sleep 10
+    done
+    exit $ERR_ADMISSION_WEBHOOKS_TIMEOUT
+}
+
+configPrivateRegistryAuth() {
+    # the kubelet reads the credentials of the registries from its root dir, whichever the container runtime
+    PRIVATE_REGISTRY_AUTH_FILES="/var/lib/kubelet/config.json"
+    if [[ "$CONTAINER_RUNTIME" == "docker" ]]; then
+        # so do the images pulled with docker while provisioning
+        PRIVATE_REGISTRY_AUTH_FILES="$PRIVATE_REGISTRY_AUTH_FILES /root/.docker/config.json"
+    fi
+    for PRIVATE_REGISTRY_AUTH_FILE in $PRIVATE_REGISTRY_AUTH_FILES; do
+        mkdir -p $(dirname $PRIVATE_REGISTRY_AUTH_FILE)
+        touch $PRIVATE_REGISTRY_AUTH_FILE
+        chmod 600 $PRIVATE_REGISTRY_AUTH_FILE
+        set +x
+        PRIVATE_REGISTRY_AUTH=$(echo -n "${PRIVATE_REGISTRY_USERNAME}:${PRIVATE_REGISTRY_PASSWORD}" | base64 -w 0)
+        echo "{\"auths\": {\"${PRIVATE_REGISTRY_SERVER}\": {\"auth\": \"${PRIVATE_REGISTRY_AUTH}\"}}}" > $PRIVATE_REGISTRY_AUTH_FILE
+        set -x
+    done
+}
+
+ensurePrivateRegistryPullSecret() {
+    if $REBOOTREQUIRED || [ "$NO_OUTBOUND" = "true" ]; then
+        return
+    fi
+    # the pods of the default service accounts can then pull from the registry whichever node they run on
+    PRIVATE_REGISTRY_SECRET_FILE=/etc/kubernetes/private-registry-secret.yaml
+    touch $PRIVATE_REGISTRY_SECRET_FILE
+    chmod 600 $PRIVATE_REGISTRY_SECRET_FILE
+    for NAMESPACE in default kube-system kube-public; do
+        retrycmd_if_failure_no_stats 120 5 25 $KUBECTL create secret generic private-registry --namespace=$NAMESPACE --type=kubernetes.io/dockerconfigjson --from-file=.dockerconfigjson=/var/lib/kubelet/config.json --dry-run -o yaml > $PRIVATE_REGISTRY_SECRET_FILE || exit $ERR_PRIVATE_REGISTRY_PULL_SECRET_FAIL
+        retrycmd_if_failure 120 5 25 $KUBECTL apply -f $PRIVATE_REGISTRY_SECRET_FILE || exit $ERR_PRIVATE_REGISTRY_PULL_SECRET_FAIL
+        retrycmd_if_failure 120 5 25 $KUBECTL patch serviceaccount default --namespace=$NAMESPACE -p '{"imagePullSecrets": [{"name": "private-registry"}]}' || exit $ERR_PRIVATE_REGISTRY_PULL_SECRET_FAIL
+    done
+    rm -f $PRIVATE_REGISTRY_SECRET_FILE
+}
+
 ensureK8sControlPlane() {
     if $REBOOTREQUIRED || [ "$NO_OUTBOUND" = "true" ]; then
         return
@@ -389,6 +518,17 @@ configACIConnectorAddon() {
     sed -i "s|<key>|$(echo $ACI_CONNECTOR_KEY)|g" $ACI_CONNECTOR_ADDON_FILE
 }
 
+configVeleroAddon() {
+    VELERO_ADDON_FILE=/etc/kubernetes/addons/velero.yaml
+    wait_for_file 1200 1 $VELERO_ADDON_FILE || exit $ERR_FILE_WATCH_TIMEOUT
+    set +x
+    # the credentials file is multi-line, so it is base64 encoded on a single line before being pasted into the secret
+    VELERO_CREDENTIALS=$(printf 'AZURE_SUBSCRIPTION_ID=%s\nAZURE_TENANT_ID=%s\nAZURE_CLIENT_ID=%s\nAZURE_CLIENT_SECRET="%s"\nAZURE_RESOURCE_GROUP=%s\nAZURE_CLOUD_NAME=%s\n' \
+        "${SUBSCRIPTION_ID}" "${TENANT_ID}" "${SERVICE_PRINCIPAL_CLIENT_ID}" "${SERVICE_PRINCIPAL_CLIENT_SECRET}" "${RESOURCE_GROUP}" "${TARGET_ENVIRONMENT}" | base64 -w 0)
+    sed -i "s|<veleroCredentials>|${VELERO_CREDENTIALS}|g" $VELERO_ADDON_FILE
+    set -x
+}
+
 configAddons() {
     if [[ "${CLUSTER_AUTOSCALER_ADDON}" = True ]]; then
         configClusterAutoscalerAddon
@@ -397,6 +537,10 @@ configAddons() {
     if [[ "${ACI_CONNECTOR_ADDON}" = True ]]; then
         configACIConnectorAddon
     fi
+
+    if [[ "${VELERO_ADDON}" = true ]]; then
+        configVeleroAddon
+    fi
 }
 
 configGPUDrivers() {
@@ -25,6 +25,18 @@ wait_for_file 3600 1 $config_script || exit $ERR_FILE_WATCH_TIMEOUT
 source $config_script
 
 CUSTOM_SEARCH_DOMAIN_SCRIPT=/opt/azure/containers/setup-custom-search-domains.sh
+SWAP_SCRIPT=/opt/azure/containers/setup-swap.sh
+READONLY_ROOT_SCRIPT=/opt/azure/containers/setup-readonly-root.sh
+CONTAINER_RUNTIME_DISK_SCRIPT=/opt/azure/containers/setup-container-runtime-disk.sh
+CONTAINER_RUNTIME_DISK_MOUNT=/var/lib/containerruntimedisk
+RESOLVED_STUB_CONFIG=/etc/systemd/resolved.conf.d/disable-stub-listener.conf
+CGROUP_V2_GRUB_CONFIG=/etc/default/grub.d/70-cgroup-v2.cfg
+KERNEL_BOOT_PARAMETERS_GRUB_CONFIG=/etc/default/grub.d/60-acs-engine-agentpool.cfg
+PID_MAX_SYSCTL_CONFIG=/etc/sysctl.d/61-acs-engine-pid-max.conf
+NOFILE_SYSTEMD_CONFIG=/etc/systemd/system.conf.d/60-acs-engine-nofile.conf
+PREPULL_IMAGES_LIST=/opt/azure/containers/prepull-images.list
+AZURE_CNI_OVERLAY_CONFIG=/opt/azure/containers/10-azure-overlay.conflist
+ADMISSION_WEBHOOKS_DIR=/etc/kubernetes/admission-webhooks
 
 set +x
 ETCD_PEER_CERT=$(echo ${ETCD_PEER_CERTIFICATES} | cut -d'[' -f 2 | cut -d']' -f 1 | cut -d',' -f $((${NODE_INDEX}+1)))
@@ -36,6 +48,11 @@ if [[ $OS == $COREOS_OS_NAME ]]; then
     KUBECTL=/opt/kubectl
 fi
 
+if [[ ! -z "${MASTER_NODE}" ]]; then
+    # kubectl reaches the apiserver on its secure port, the insecure port being disabled by default
+    export KUBECONFIG=/var/lib/kubelet/kubeconfig
+fi
+
 if [ -f /var/run/reboot-required ]; then
     REBOOTREQUIRED=true
 else
@@ -93,6 +110,34 @@ if [ -f $CUSTOM_SEARCH_DOMAIN_SCRIPT ]; then
     $CUSTOM_SEARCH_DOMAIN_SCRIPT > /opt/azure/containers/setup-custom-search-domain.log 2>&1 || exit $ERR_CUSTOM_SEARCH_DOMAINS_FAIL
 fi
 
+if [ -f $RESOLVED_STUB_CONFIG ]; then
+    # without the stub listener, /etc/resolv.conf needs to list the upstream name servers systemd-resolved uses
+    ln -sf /run/systemd/resolve/resolv.conf /etc/resolv.conf
+    systemctl_restart 20 5 10 systemd-resolved || exit $ERR_RESOLVED_CONFIG_FAIL
+fi
+
+if [ -f $SWAP_SCRIPT ]; then
+    $SWAP_SCRIPT > /opt/azure/containers/setup-swap.log 2>&1 || exit $ERR_SWAP_SETUP_FAIL
+fi
+
+if [ -f $CONTAINER_RUNTIME_DISK_SCRIPT ]; then
+    # the data disk is mounted before the container runtime is (re)started with its data-root on it
+    $CONTAINER_RUNTIME_DISK_SCRIPT > /opt/azure/containers/setup-container-runtime-disk.log 2>&1 || exit $ERR_CONTAINER_RUNTIME_DISK_SETUP_FAIL
+fi
+
+if [ -f $PID_MAX_SYSCTL_CONFIG ]; then
+    sysctl -p $PID_MAX_SYSCTL_CONFIG || exit $ERR_PROCESS_LIMITS_SETUP_FAIL
+fi
+
+if [ -f $NOFILE_SYSTEMD_CONFIG ]; then
+    # the manager only reads its default limits when it is executed, the services started from now on then get them
+    systemctl daemon-reexec || exit $ERR_PROCESS_LIMITS_SETUP_FAIL
+fi
+
+if [[ -n "${PRIVATE_REGISTRY_SERVER}" ]]; then
+    configPrivateRegistryAuth
+fi
+
 if [[ "$CONTAINER_RUNTIME" == "docker" ]]; then
     ensureDocker
 elif [[ "$CONTAINER_RUNTIME" == "clear-containers" ]]; then
@@ -114,6 +159,11 @@ fi
 
 ensureContainerd
 
+if [ -f $PREPULL_IMAGES_LIST ]; then
+    # the images are pulled in the background, while the kubelet starts and registers the node
+    prePullImages > /opt/azure/containers/prepull-images.log 2>&1 &
+fi
+
 if [[ ! -z "${MASTER_NODE}" && "${KMS_PROVIDER_VAULT_NAME}" != "" ]]; then
     ensureKMS
 fi
@@ -126,6 +176,12 @@ if [[ ! -z "${MASTER_NODE}" ]]; then
     ensureEtcd
     ensureK8sControlPlane
     ensurePodSecurityPolicy
+    if [[ -n "${PRIVATE_REGISTRY_SERVER}" ]]; then
+        ensurePrivateRegistryPullSecret
+    fi
+    if [ -d $ADMISSION_WEBHOOKS_DIR ]; then
+        ensureAdmissionWebhooks
+    fi
 fi
 
 if $FULL_INSTALL_REQUIRED; then
@@ -140,6 +196,29 @@ if $FULL_INSTALL_REQUIRED; then
     fi
 fi
 
+if [ -f $READONLY_ROOT_SCRIPT ]; then
+    # the root filesystem is made read-only on boot, before the container runtime and the kubelet start
+    systemctl enable readonly-root.service || exit $ERR_READONLY_ROOT_SETUP_FAIL
+    REBOOTREQUIRED=true
+fi
+
+if [ -f $CGROUP_V2_GRUB_CONFIG ] && [ ! -f /sys/fs/cgroup/cgroup.controllers ]; then
+    # the unified cgroup hierarchy is only mounted once the node reboots with the updated kernel command line
+    update-grub || exit $ERR_CGROUP_V2_SETUP_FAIL
+    REBOOTREQUIRED=true
+fi
+
+if [ -f $KERNEL_BOOT_PARAMETERS_GRUB_CONFIG ]; then
+    # the kernel boot parameters only apply once the node reboots with the updated kernel command line
+    for parameter in $(. $KERNEL_BOOT_PARAMETERS_GRUB_CONFIG && echo $ACS_ENGINE_KERNEL_BOOT_PARAMETERS); do
+        if ! tr ' ' '\n' < /proc/cmdline | grep -qxF -- "$parameter"; then
+            update-grub || exit $ERR_KERNEL_BOOT_PARAMETERS_SETUP_FAIL
+            REBOOTREQUIRED=true
+            break
+        fi
+    done
+fi
+
 echo "Custom script finished successfully"
 
 echo `date`,`hostname`, endcustomscript>>/opt/m
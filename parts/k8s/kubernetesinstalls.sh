@@ -131,6 +131,10 @@ installClearContainersRuntime() {
 }
 
 installNetworkPlugin() {
+    # a CNI brought by the user is installed by its own manifest
+    if [[ "${NETWORK_PLUGIN}" = "cni" ]]; then
+        return
+    fi
     if [[ "${NETWORK_PLUGIN}" = "azure" ]]; then
         installAzureCNI
     fi
@@ -216,7 +220,8 @@ extractHyperkube() {
 }
 
 installKubeletAndKubectl() {
-    if [[ ! -f "/usr/local/bin/kubectl-${KUBERNETES_VERSION}" ]]; then
+    # binaries cached in the VHD are built from the default hyperkube image
+    if [[ ! -f "/usr/local/bin/kubectl-${KUBERNETES_VERSION}" ]] || [[ "${CUSTOM_HYPERKUBE_IMAGE}" == "true" ]]; then
         if [[ "$CONTAINER_RUNTIME" == "docker" ]]; then
             extractHyperkube "docker"
         else
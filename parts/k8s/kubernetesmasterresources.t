@@ -6,8 +6,13 @@
       "name": "[variables('masterAvailabilitySet')]",
       "properties":
       {
-        "platformFaultDomainCount": 2,
-        "platformUpdateDomainCount": 3
+        "platformFaultDomainCount": {{.MasterProfile.PlatformFaultDomainCount}},
+        "platformUpdateDomainCount": {{.MasterProfile.PlatformUpdateDomainCount}}
+{{if .MasterProfile.HasProximityPlacementGroup}}
+        ,"proximityPlacementGroup": {
+          "id": "{{.MasterProfile.ProximityPlacementGroupID}}"
+        }
+{{end}}
       },
       "sku": {
         "name": "Aligned"
@@ -21,10 +26,17 @@
       "apiVersion": "[variables('apiVersionCompute')]",
       "location": "[variables('location')]",
       "name": "[variables('masterAvailabilitySet')]",
-      "properties": {},
+      "properties": {
+{{if .MasterProfile.HasProximityPlacementGroup}}
+        "proximityPlacementGroup": {
+          "id": "{{.MasterProfile.ProximityPlacementGroupID}}"
+        }
+{{end}}
+      },
       "type": "Microsoft.Compute/availabilitySets"
     },
     {{end}}
+    {{if not .MasterProfile.HasExistingStorageAccount}}
     {
       "apiVersion": "[variables('apiVersionStorage')]",
 {{if not IsPrivateCluster}}
@@ -39,10 +51,15 @@
       },
       "type": "Microsoft.Storage/storageAccounts"
     },
+    {{end}}
 {{end}}
 {{if not .MasterProfile.IsCustomVNET}}
 {
+{{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+      "apiVersion": "[variables('apiVersionNetworkNATGateway')]",
+{{else}}
       "apiVersion": "[variables('apiVersionNetwork')]",
+{{end}}
       "dependsOn": [
 {{if RequireRouteTable}}
         "[concat('Microsoft.Network/routeTables/', variables('routeTableName'))]"{{if not IsOpenShift}},{{end}}
@@ -75,6 +92,12 @@
               "routeTable": {
                 "id": "[variables('routeTableID')]"
               }
+{{end}}
+{{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+              ,
+              "natGateway": {
+                "id": "[parameters('natGatewayID')]"
+              }
 {{end}}
             }
           }
@@ -110,7 +133,7 @@
             "properties": {
               "access": "Allow",
               "description": "Allow SSH traffic to master",
-              "destinationAddressPrefix": "*",
+              "destinationAddressPrefix": {{if .HasSSHDisabledAgentPool}}"[reference(parameters('masterVnetSubnetID'), variables('apiVersionNetwork')).addressPrefix]"{{else}}"*"{{end}},
               "destinationPortRange": "22-22",
               "direction": "Inbound",
               "priority": 101,
@@ -125,7 +148,7 @@
               "access": "Allow",
               "description": "Allow kube-apiserver (tls) traffic to master",
               "destinationAddressPrefix": "*",
-              "destinationPortRange": {{if IsOpenShift}}"8443-8443"{{else}}"443-443"{{end}},
+              "destinationPortRange": {{if IsOpenShift}}"8443-8443"{{else}}"{{GetAPIServerSecurePort}}-{{GetAPIServerSecurePort}}"{{end}},
               "direction": "Inbound",
               "priority": 100,
               "protocol": "Tcp",
@@ -133,6 +156,24 @@
               "sourcePortRange": "*"
             }
           }
+        {{if .HasSSHDisabledAgentPool}}
+          ,{
+            "name": "deny_ssh_agents",
+            "properties": {
+              "access": "Deny",
+              "description": "Deny SSH traffic to the agent pools with SSH disabled",
+              "destinationAddressPrefixes": [
+                {{GetSSHDisabledAgentPoolSubnetPrefixes}}
+              ],
+              "destinationPortRange": "22-22",
+              "direction": "Inbound",
+              "priority": 103,
+              "protocol": "Tcp",
+              "sourceAddressPrefix": "*",
+              "sourcePortRange": "*"
+            }
+          }
+        {{end}}
         {{if IsFeatureEnabled "BlockOutboundInternet"}}
           ,{
             "name": "allow_vnet",
@@ -172,9 +213,64 @@
       "apiVersion": "[variables('apiVersionNetwork')]",
       "location": "[variables('location')]",
       "name": "[variables('routeTableName')]",
+{{if .OrchestratorProfile.KubernetesConfig.HasEgressFirewall}}
+  {{if not IsPrivateCluster}}
+      "dependsOn": [
+        "[concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))]"
+      ],
+  {{end}}
+      "properties": {
+        "routes": [
+          {
+            "name": "egress-firewall",
+            "properties": {
+              "addressPrefix": "0.0.0.0/0",
+              "nextHopType": "VirtualAppliance",
+              "nextHopIpAddress": "{{.OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP}}"
+            }
+          }
+          {{if not IsPrivateCluster}}
+          ,{
+            "name": "apiserver-endpoint",
+            "properties": {
+              "addressPrefix": "[concat(reference(variables('masterPublicIPAddressName')).ipAddress, '/32')]",
+              "nextHopType": "Internet"
+            }
+          }
+          {{end}}
+          {{range $i, $prefix := .OrchestratorProfile.KubernetesConfig.EgressFirewall.BypassAddressPrefixes}}
+          ,{
+            "name": "egress-firewall-bypass-{{$i}}",
+            "properties": {
+              "addressPrefix": "{{$prefix}}",
+              "nextHopType": "Internet"
+            }
+          }
+          {{end}}
+        ]
+      },
+{{end}}
       "type": "Microsoft.Network/routeTables"
     },
 {{end}}
+{{if .OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID}}
+    {
+      "apiVersion": "[variables('apiVersionNetwork')]",
+      "location": "[variables('location')]",
+      "name": "[variables('serviceInternalLbName')]",
+      "properties": {
+        "backendAddressPools": [
+          {
+            "name": "[parameters('masterEndpointDNSNamePrefix')]"
+          }
+        ]
+      },
+      "sku": {
+        "name": "[variables('loadBalancerSku')]"
+      },
+      "type": "Microsoft.Network/loadBalancers"
+    },
+{{end}}
 {{if not IsPrivateCluster}}
     {
       "apiVersion": "[variables('apiVersionNetwork')]",
@@ -225,11 +321,14 @@
                 "id": "[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbBackendPoolName'))]"
               },
               "protocol": "Tcp",
-              "frontendPort": {{if IsOpenShift}}8443{{else}}443{{end}},
-              "backendPort": {{if IsOpenShift}}8443{{else}}443{{end}},
+              "frontendPort": {{if IsOpenShift}}8443{{else}}{{GetAPIServerSecurePort}}{{end}},
+              "backendPort": {{if IsOpenShift}}8443{{else}}{{GetAPIServerSecurePort}}{{end}},
               "enableFloatingIP": false,
-              "idleTimeoutInMinutes": 5,
+              "idleTimeoutInMinutes": {{.MasterProfile.LoadBalancerIdleTimeoutInMinutes}},
               "loadDistribution": "Default",
+{{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+              "disableOutboundSnat": true,
+{{end}}
               "probe": {
                 "id": "[concat(variables('masterLbID'),'/probes/tcpHTTPSProbe')]"
               }
@@ -241,9 +340,9 @@
             "name": "tcpHTTPSProbe",
             "properties": {
               "protocol": "Tcp",
-              "port": {{if IsOpenShift}}8443{{else}}443{{end}},
-              "intervalInSeconds": 5,
-              "numberOfProbes": "2"
+              "port": {{if IsOpenShift}}8443{{else}}{{GetAPIServerSecurePort}}{{end}},
+              "intervalInSeconds": {{.MasterProfile.LoadBalancerProbeIntervalInSeconds}},
+              "numberOfProbes": {{.MasterProfile.LoadBalancerProbeThreshold}}
             }
           }
         ]
@@ -638,8 +737,8 @@
               "frontendIPConfiguration": {
                 "id": "[variables('masterInternalLbIPConfigID')]"
               },
-              "frontendPort": {{if IsOpenShift}}8443{{else}}443{{end}},
-              "idleTimeoutInMinutes": 5,
+              "frontendPort": {{if IsOpenShift}}8443{{else}}{{GetAPIServerSecurePort}}{{end}},
+              "idleTimeoutInMinutes": {{.MasterProfile.LoadBalancerIdleTimeoutInMinutes}},
               "protocol": "Tcp",
               "probe": {
                 "id": "[concat(variables('masterInternalLbID'),'/probes/tcpHTTPSProbe')]"
@@ -651,8 +750,8 @@
           {
             "name": "tcpHTTPSProbe",
             "properties": {
-              "intervalInSeconds": 5,
-              "numberOfProbes": 2,
+              "intervalInSeconds": {{.MasterProfile.LoadBalancerProbeIntervalInSeconds}},
+              "numberOfProbes": {{.MasterProfile.LoadBalancerProbeThreshold}},
               "port": {{if IsOpenShift}}8443{{else}}4443{{end}},
               "protocol": "Tcp"
             }
@@ -788,7 +887,7 @@
         {{if not .MasterProfile.HasAvailabilityZones}}
         ,"[concat('Microsoft.Compute/availabilitySets/',variables('masterAvailabilitySet'))]"
         {{end}}
-{{if .MasterProfile.IsStorageAccount}}
+{{if and .MasterProfile.IsStorageAccount (not .MasterProfile.HasExistingStorageAccount)}}
         ,"[variables('masterStorageAccountName')]"
 {{end}}
       ],
@@ -798,6 +897,7 @@
         "resourceNameSuffix" : "[parameters('nameSuffix')]",
         "orchestrator" : "[variables('orchestratorNameVersionTag')]",
         "acsengineVersion" : "[parameters('acsengineVersion')]",
+        "acsengineGenerationTime" : "[parameters('acsengineGenerationTime')]",
         "poolName" : "master"
       },
       "location": "[variables('location')]",
@@ -874,7 +974,7 @@
               ,"name": "[concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-etcddisk')]"
               {{if .MasterProfile.IsStorageAccount}}
               ,"vhd": {
-                "uri": "[concat(reference(concat('Microsoft.Storage/storageAccounts/',variables('masterStorageAccountName')),variables('apiVersionStorage')).primaryEndpoints.blob,'vhds/', variables('masterVMNamePrefix'),copyIndex(variables('masterOffset')),'-etcddisk.vhd')]"
+                "uri": "[concat(reference({{GetMasterStorageAccountReference}},variables('apiVersionStorage')).primaryEndpoints.blob,'vhds/', variables('masterVMNamePrefix'),copyIndex(variables('masterOffset')),'-etcddisk.vhd')]"
               }
               {{end}}
             }
@@ -896,7 +996,7 @@
 {{if .MasterProfile.IsStorageAccount}}
             ,"name": "[concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')),'-osdisk')]"
             ,"vhd": {
-              "uri": "[concat(reference(concat('Microsoft.Storage/storageAccounts/',variables('masterStorageAccountName')),variables('apiVersionStorage')).primaryEndpoints.blob,'vhds/',variables('masterVMNamePrefix'),copyIndex(variables('masterOffset')),'-osdisk.vhd')]"
+              "uri": "[concat(reference({{GetMasterStorageAccountReference}},variables('apiVersionStorage')).primaryEndpoints.blob,'vhds/',variables('masterVMNamePrefix'),copyIndex(variables('masterOffset')),'-osdisk.vhd')]"
             }
 {{end}}
 {{if ne .MasterProfile.OSDiskSizeGB 0}}
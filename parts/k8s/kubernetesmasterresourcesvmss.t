@@ -77,7 +77,7 @@
         "properties": {
           "access": "Allow",
           "description": "Allow SSH traffic to master",
-          "destinationAddressPrefix": "*",
+          "destinationAddressPrefix": {{if .HasSSHDisabledAgentPool}}"[reference(parameters('masterVnetSubnetID'), variables('apiVersionNetwork')).addressPrefix]"{{else}}"*"{{end}},
           "destinationPortRange": "22-22",
           "direction": "Inbound",
           "priority": 101,
@@ -92,7 +92,7 @@
           "access": "Allow",
           "description": "Allow kube-apiserver (tls) traffic to master",
           "destinationAddressPrefix": "*",
-          "destinationPortRange":"443-443",
+          "destinationPortRange":"{{GetAPIServerSecurePort}}-{{GetAPIServerSecurePort}}",
           "direction": "Inbound",
           "priority": 100,
           "protocol": "Tcp",
@@ -100,6 +100,24 @@
           "sourcePortRange": "*"
         }
       }
+    {{if .HasSSHDisabledAgentPool}}
+      ,{
+        "name": "deny_ssh_agents",
+        "properties": {
+          "access": "Deny",
+          "description": "Deny SSH traffic to the agent pools with SSH disabled",
+          "destinationAddressPrefixes": [
+            {{GetSSHDisabledAgentPoolSubnetPrefixes}}
+          ],
+          "destinationPortRange": "22-22",
+          "direction": "Inbound",
+          "priority": 103,
+          "protocol": "Tcp",
+          "sourceAddressPrefix": "*",
+          "sourcePortRange": "*"
+        }
+      }
+    {{end}}
     {{if IsFeatureEnabled "BlockOutboundInternet"}}
       ,{
         "name": "allow_vnet",
@@ -139,12 +157,53 @@
   "apiVersion": "[variables('apiVersionNetwork')]",
   "location": "[variables('location')]",
   "name": "[variables('routeTableName')]",
+{{if .OrchestratorProfile.KubernetesConfig.HasEgressFirewall}}
+  {{if not IsPrivateCluster}}
+  "dependsOn": [
+    "[concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))]"
+  ],
+  {{end}}
+  "properties": {
+    "routes": [
+      {
+        "name": "egress-firewall",
+        "properties": {
+          "addressPrefix": "0.0.0.0/0",
+          "nextHopType": "VirtualAppliance",
+          "nextHopIpAddress": "{{.OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP}}"
+        }
+      }
+      {{if not IsPrivateCluster}}
+      ,{
+        "name": "apiserver-endpoint",
+        "properties": {
+          "addressPrefix": "[concat(reference(variables('masterPublicIPAddressName')).ipAddress, '/32')]",
+          "nextHopType": "Internet"
+        }
+      }
+      {{end}}
+      {{range $i, $prefix := .OrchestratorProfile.KubernetesConfig.EgressFirewall.BypassAddressPrefixes}}
+      ,{
+        "name": "egress-firewall-bypass-{{$i}}",
+        "properties": {
+          "addressPrefix": "{{$prefix}}",
+          "nextHopType": "Internet"
+        }
+      }
+      {{end}}
+    ]
+  },
+{{end}}
   "type": "Microsoft.Network/routeTables"
 },
 {{end}}
 {{if not .MasterProfile.IsCustomVNET}}
 {
+  {{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+  "apiVersion": "[variables('apiVersionNetworkNATGateway')]",
+  {{else}}
   "apiVersion": "[variables('apiVersionNetwork')]",
+  {{end}}
   "dependsOn": [
     {{if RequireRouteTable}}
     "[concat('Microsoft.Network/routeTables/', variables('routeTableName'))]",
@@ -172,6 +231,11 @@
             "id": "[variables('routeTableID')]"
           }
           {{end}}
+          {{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+          ,"natGateway": {
+            "id": "[parameters('natGatewayID')]"
+          }
+          {{end}}
         }
       },
       {  
@@ -186,6 +250,11 @@
             "id": "[variables('routeTableID')]"
           }
           {{end}}
+          {{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+          ,"natGateway": {
+            "id": "[parameters('natGatewayID')]"
+          }
+          {{end}}
         }
       }
       ]
@@ -193,6 +262,24 @@
     "type": "Microsoft.Network/virtualNetworks"
 },
 {{end}}
+{{if .OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID}}
+{
+  "apiVersion": "[variables('apiVersionNetwork')]",
+  "location": "[variables('location')]",
+  "name": "[variables('serviceInternalLbName')]",
+  "properties": {
+    "backendAddressPools": [
+      {
+        "name": "[parameters('masterEndpointDNSNamePrefix')]"
+      }
+    ]
+  },
+  "sku": {
+    "name": "[variables('loadBalancerSku')]"
+  },
+  "type": "Microsoft.Network/loadBalancers"
+},
+{{end}}
 {
   "apiVersion": "[variables('apiVersionNetwork')]",
   "location": "[variables('location')]",
@@ -201,7 +288,7 @@
     "dnsSettings": {
       "domainNameLabel": "[variables('masterFqdnPrefix')]"
     },
-    {{ if eq LoadBalancerSku "Standard"}}
+    {{ if or (eq LoadBalancerSku "Standard") .OrchestratorProfile.KubernetesConfig.HasEgressFirewall}}
     "publicIPAllocationMethod": "Static"
     {{else}}
     "publicIPAllocationMethod": "Dynamic"
@@ -241,9 +328,9 @@
               "name": "tcpHTTPSProbe",
               "properties": {
                   "protocol": "Tcp",
-                  "port": 443,
-                  "intervalInSeconds": 5,
-                  "numberOfProbes": 2
+                  "port": {{GetAPIServerSecurePort}},
+                  "intervalInSeconds": {{.MasterProfile.LoadBalancerProbeIntervalInSeconds}},
+                  "numberOfProbes": {{.MasterProfile.LoadBalancerProbeThreshold}}
               }
           }
         ],
@@ -273,11 +360,14 @@
                     "id": "[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbBackendPoolName'))]"
                 },
                 "protocol": "Tcp",
-                "frontendPort": 443,
-                "backendPort": 443,
+                "frontendPort": {{GetAPIServerSecurePort}},
+                "backendPort": {{GetAPIServerSecurePort}},
                 "enableFloatingIP": false,
-                "idleTimeoutInMinutes": 5,
+                "idleTimeoutInMinutes": {{.MasterProfile.LoadBalancerIdleTimeoutInMinutes}},
                 "loadDistribution": "Default",
+                {{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+                "disableOutboundSnat": true,
+                {{end}}
                 "probe": {
                     "id": "[concat(variables('masterLbID'),'/probes/tcpHTTPSProbe')]"
                 }
@@ -305,6 +395,7 @@
       "resourceNameSuffix": "[parameters('nameSuffix')]",
       "orchestrator": "[variables('orchestratorNameVersionTag')]",
       "acsengineVersion" : "[parameters('acsengineVersion')]",
+      "acsengineGenerationTime" : "[parameters('acsengineGenerationTime')]",
       "poolName": "master"
     },
     "location": "[variables('location')]",
@@ -330,6 +421,11 @@
     "properties": {
       "singlePlacementGroup": {{ .MasterProfile.SinglePlacementGroup}},
       "overprovision": false,
+{{if .MasterProfile.HasProximityPlacementGroup}}
+      "proximityPlacementGroup": {
+        "id": "{{.MasterProfile.ProximityPlacementGroupID}}"
+      },
+{{end}}
       "upgradePolicy": {
         "mode": "Manual"
       },
@@ -126,6 +126,34 @@
       },
       "type": "securestring"
     },
+{{if .OrchestratorProfile.KubernetesConfig.HasServiceAccountIssuer}}
+    "serviceAccountSigningKey": {
+      "metadata": {
+        "description": "The base 64 private key the API server signs the bound service account tokens with."
+      },
+      "type": "securestring"
+    },
+{{end}}
+{{if and (not IsHostedMaster) EnableAggregatedAPIs}}
+    "frontProxyCACertificate": {
+      "metadata": {
+        "description": "The base 64 certificate authority certificate the API server trusts the request headers of the aggregation layer from"
+      },
+      "type": "string"
+    },
+    "frontProxyClientCertificate": {
+      "metadata": {
+        "description": "The base 64 client certificate the API server proxies the requests to the aggregated API servers with"
+      },
+      "type": "string"
+    },
+    "frontProxyClientPrivateKey": {
+      "metadata": {
+        "description": "The base 64 client private key the API server proxies the requests to the aggregated API servers with."
+      },
+      "type": "securestring"
+    },
+{{end}}
     "caCertificate": {
       "metadata": {
         "description": "The base 64 certificate authority certificate"
@@ -261,6 +289,42 @@
       },
       "type": "bool"
     },
+{{end}}
+{{if .OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID}}
+    "serviceInternalLBSubnetID": {
+      "metadata": {
+        "description": "Sets the vnet subnet of the internal load balancer of the LoadBalancer services."
+      },
+      "type": "string"
+    },
+{{end}}
+{{if .OrchestratorProfile.KubernetesConfig.HasNATGateway}}
+    "natGatewayID": {
+      "metadata": {
+        "description": "Sets the existing NAT gateway the egress traffic of the cluster subnets goes through."
+      },
+      "type": "string"
+    },
+{{end}}
+{{if .OrchestratorProfile.KubernetesConfig.HasPrivateRegistry}}
+    "privateRegistryServer": {
+      "metadata": {
+        "description": "The host of the private container registry the nodes pull images from."
+      },
+      "type": "string"
+    },
+    "privateRegistryUsername": {
+      "metadata": {
+        "description": "The username the nodes authenticate to the private container registry with."
+      },
+      "type": "string"
+    },
+    "privateRegistryPassword": {
+      "metadata": {
+        "description": "The password the nodes authenticate to the private container registry with."
+      },
+      "type": "securestring"
+    },
 {{end}}
     "kubernetesACIConnectorEnabled": {
       "metadata": {
@@ -274,6 +338,14 @@
       },
       "type": "bool"
     },
+{{if .OrchestratorProfile.KubernetesConfig.GetContainerMonitoringWorkspaceResourceID}}
+    "containerMonitoringWorkspaceResourceId": {
+      "metadata": {
+        "description": "Resource ID of the existing Log Analytics workspace the container monitoring addon reports to"
+      },
+      "type": "string"
+    },
+{{end}}
 {{if .OrchestratorProfile.KubernetesConfig.IsClusterAutoscalerEnabled}}
     "kubernetesClusterAutoscalerAzureCloud": {
       "metadata": {
@@ -354,13 +426,14 @@
     "networkPlugin": {
       "defaultValue": "{{.OrchestratorProfile.KubernetesConfig.NetworkPlugin}}",
       "metadata": {
-        "description": "The network plugin to use for Kubernetes (kubenet|azure|flannel|cilium)"
+        "description": "The network plugin to use for Kubernetes (kubenet|azure|flannel|cilium|cni)"
       },
       "allowedValues": [
         "kubenet",
         "azure",
         "flannel",
-        "cilium"
+        "cilium",
+        "cni"
       ],
       "type": "string"
     },
@@ -441,6 +514,21 @@
       },
       "type": "securestring"
     },
+{{if HasServicePrincipalCertificate}}
+    "servicePrincipalClientCertificate": {
+      "metadata": {
+        "description": "The base64 encoded PEM bundle of the Service Principal Client Certificate and its private key."
+      },
+      "type": "securestring"
+    },
+    "servicePrincipalClientCertificatePassword": {
+      "defaultValue": "",
+      "metadata": {
+        "description": "The password of the Service Principal Client Certificate private key."
+      },
+      "type": "securestring"
+    },
+{{end}}
 {{ end }}
     "masterOffset": {
       "defaultValue": 0,
@@ -480,6 +568,26 @@
       },
       "type": "string"
     }
+{{if IsBootstrapTokenEnabled}}
+    ,"bootstrapTokenID": {
+      "metadata": {
+        "description": "The public id of the short-lived token nodes use to join the cluster"
+      },
+      "type": "string"
+    },
+    "bootstrapTokenSecret": {
+      "metadata": {
+        "description": "The secret of the short-lived token nodes use to join the cluster"
+      },
+      "type": "securestring"
+    },
+    "bootstrapTokenExpiration": {
+      "metadata": {
+        "description": "The RFC3339 timestamp after which the bootstrap token is no longer valid"
+      },
+      "type": "string"
+    }
+{{end}}
 {{if ProvisionJumpbox}}
     ,"jumpboxVMName": {
       "metadata": {
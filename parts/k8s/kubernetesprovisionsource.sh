@@ -38,8 +38,18 @@ ERR_KATA_APT_KEY_TIMEOUT=61 # Timeout waiting for kata apt-key
 ERR_KATA_INSTALL_TIMEOUT=62 # Timeout waiting for kata install
 ERR_CONTAINERD_DOWNLOAD_TIMEOUT=70 # Timeout waiting for containerd download(s)
 ERR_CUSTOM_SEARCH_DOMAINS_FAIL=80 # Unable to configure custom search domains
+ERR_SWAP_SETUP_FAIL=81 # Unable to set up swap
+ERR_READONLY_ROOT_SETUP_FAIL=82 # Unable to make the root filesystem read-only on boot
+ERR_PRIVATE_REGISTRY_PULL_SECRET_FAIL=83 # Unable to create the private registry pull secret of the default service accounts
 ERR_GPU_DRIVERS_START_FAIL=84 # nvidia-modprobe could not be started by systemctl
 ERR_GPU_DRIVERS_INSTALL_TIMEOUT=85 # Timeout waiting for GPU drivers install
+ERR_RESOLVED_CONFIG_FAIL=86 # Unable to disable the systemd-resolved stub resolver
+ERR_CGROUP_V2_SETUP_FAIL=87 # Unable to update the kernel command line to boot with the unified cgroup hierarchy
+ERR_PROCESS_LIMITS_SETUP_FAIL=88 # Unable to apply the PID and open files limits of the node
+ERR_KERNEL_BOOT_PARAMETERS_SETUP_FAIL=89 # Unable to update the kernel command line with the kernel boot parameters of the node
+ERR_ADMISSION_WEBHOOKS_TIMEOUT=90 # Timeout waiting for the deployments of kube-system before applying the admission webhooks
+ERR_CONTAINER_RUNTIME_DISK_SETUP_FAIL=91 # Unable to format and mount the container runtime data disk
+ERR_CLOUDPROVIDER_CONFIG_FAIL=92 # Unable to merge the cloudProviderConfig of the api model into azure.json
 ERR_APT_DAILY_TIMEOUT=98 # Timeout waiting for apt daily updates
 ERR_APT_UPDATE_TIMEOUT=99 # Timeout waiting for apt-get update to complete
 ERR_CSE_PROVISION_SCRIPT_NOT_READY_TIMEOUT=100 # Timeout waiting for cloud-init to place this (!) script on the vm
@@ -63,13 +63,22 @@
     },
 {{if .IsManagedDisks}}
    {
+      "copy": {
+        "count": "[variables('{{.Name}}AvailabilitySetCount')]",
+        "name": "availabilitySetLoop"
+      },
       "location": "[variables('location')]",
-      "name": "[variables('{{.Name}}AvailabilitySet')]",
+      "name": "[if(equals(copyIndex(), 0), variables('{{.Name}}AvailabilitySet'), concat(variables('{{.Name}}AvailabilitySet'), '-', string(copyIndex())))]",
       "apiVersion": "[variables('apiVersionCompute')]",
       "properties":
         {
-            "platformFaultDomainCount": 2,
-            "platformUpdateDomainCount": 3
+            "platformFaultDomainCount": {{.PlatformFaultDomainCount}},
+            "platformUpdateDomainCount": {{.PlatformUpdateDomainCount}}
+{{if .HasProximityPlacementGroup}}
+            ,"proximityPlacementGroup": {
+              "id": "{{.ProximityPlacementGroupID}}"
+            }
+{{end}}
         },
       "sku": {
         "name": "Aligned"
@@ -123,12 +132,22 @@
       "location": "[variables('location')]",
       "name": "[variables('{{.Name}}AvailabilitySet')]",
       "apiVersion": "[variables('apiVersionCompute')]",
-      "properties": {},
+      "properties": {
+{{if .HasProximityPlacementGroup}}
+        "proximityPlacementGroup": {
+          "id": "{{.ProximityPlacementGroupID}}"
+        }
+{{end}}
+      },
       "type": "Microsoft.Compute/availabilitySets"
     },
 {{end}}
     {
+{{if .HasDiskEncryptionSet}}
+      "apiVersion": "[variables('apiVersionComputeDiskEncryptionSet')]",
+{{else}}
       "apiVersion": "[variables('apiVersionCompute')]",
+{{end}}
       "copy": {
         "count": "[sub(variables('{{.Name}}Count'), variables('{{.Name}}Offset'))]",
         "name": "vmLoopNode"
@@ -141,7 +160,11 @@
   {{end}}
 {{end}}
         "[concat('Microsoft.Network/networkInterfaces/', variables('{{.Name}}VMNamePrefix'), 'nic-', copyIndex(variables('{{.Name}}Offset')))]",
+{{if .IsManagedDisks}}
+        "[concat('Microsoft.Compute/availabilitySets/', if(equals(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet')), 0), variables('{{.Name}}AvailabilitySet'), concat(variables('{{.Name}}AvailabilitySet'), '-', string(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet'))))))]"
+{{else}}
         "[concat('Microsoft.Compute/availabilitySets/', variables('{{.Name}}AvailabilitySet'))]"
+{{end}}
       ],
       "tags":
       {
@@ -149,6 +172,7 @@
         "resourceNameSuffix" : "[variables('winResourceNamePrefix')]",
         "orchestrator" : "[variables('orchestratorNameVersionTag')]",
         "acsengineVersion" : "[parameters('acsengineVersion')]",
+        "acsengineGenerationTime" : "[parameters('acsengineGenerationTime')]",
         "poolName" : "{{.Name}}"
       },
       "location": "[variables('location')]",
@@ -160,7 +184,11 @@
       {{end}}
       "properties": {
         "availabilitySet": {
+{{if .IsManagedDisks}}
+          "id": "[resourceId('Microsoft.Compute/availabilitySets', if(equals(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet')), 0), variables('{{.Name}}AvailabilitySet'), concat(variables('{{.Name}}AvailabilitySet'), '-', string(div(copyIndex(variables('{{.Name}}Offset')), variables('maxVMsPerAvailabilitySet'))))))]"
+{{else}}
           "id": "[resourceId('Microsoft.Compute/availabilitySets',variables('{{.Name}}AvailabilitySet'))]"
+{{end}}
         },
         "hardwareProfile": {
           "vmSize": "[variables('{{.Name}}VMSize')]"
@@ -201,6 +229,13 @@
 {{end}}
 {{if ne .OSDiskSizeGB 0}}
             ,"diskSizeGB": {{.OSDiskSizeGB}}
+{{end}}
+{{if .HasDiskEncryptionSet}}
+            ,"managedDisk": {
+              "diskEncryptionSet": {
+                "id": "{{.DiskEncryptionSetID}}"
+              }
+            }
 {{end}}
           }
         }
@@ -10,7 +10,11 @@
   },
 {{end}}
   {
+{{if .HasDiskEncryptionSet}}
+    "apiVersion": "[variables('apiVersionComputeDiskEncryptionSet')]",
+{{else}}
     "apiVersion": "[variables('apiVersionCompute')]",
+{{end}}
     "dependsOn": [
     {{if .IsCustomVNET}}
       "[variables('nsgID')]"
@@ -23,6 +27,8 @@
       "creationSource" : "[concat(parameters('generatorCode'), '-', variables('{{.Name}}VMNamePrefix'))]",
       "resourceNameSuffix" : "[variables('winResourceNamePrefix')]",
       "orchestrator" : "[variables('orchestratorNameVersionTag')]",
+      "acsengineVersion" : "[parameters('acsengineVersion')]",
+      "acsengineGenerationTime" : "[parameters('acsengineGenerationTime')]",
       "poolName" : "{{.Name}}"
     },
     "location": "[variables('location')]",
@@ -42,9 +48,17 @@
     },
     "properties": {
       "singlePlacementGroup": {{UseSinglePlacementGroup .}},
-      "overprovision": false,
+      "overprovision": {{.IsOverProvisioned}},
+{{if .HasProximityPlacementGroup}}
+      "proximityPlacementGroup": {
+        "id": "{{.ProximityPlacementGroupID}}"
+      },
+{{end}}
+      {{if .IsOverProvisioned}}
+      "doNotRunExtensionsOnOverprovisionedVMs": true,
+      {{end}}
       "upgradePolicy": {
-        "mode": "Manual"
+        "mode": "{{.UpgradePolicyMode}}"
       },
       "virtualMachineProfile": {
         "networkProfile": {
@@ -102,6 +116,13 @@
           {{if ne .OSDiskSizeGB 0}}
             ,"diskSizeGB": {{.OSDiskSizeGB}}
           {{end}}
+          {{if .HasDiskEncryptionSet}}
+            ,"managedDisk": {
+              "diskEncryptionSet": {
+                "id": "{{.DiskEncryptionSetID}}"
+              }
+            }
+          {{end}}
           }
         },
         "extensionProfile": {
@@ -119,6 +140,21 @@
                 }
               }
             }
+            {{if eq .UpgradePolicyMode "Rolling"}}
+            ,{
+              "name": "HealthExtension",
+              "properties": {
+                "publisher": "Microsoft.ManagedServices",
+                "type": "ApplicationHealthWindows",
+                "typeHandlerVersion": "1.0",
+                "autoUpgradeMinorVersion": true,
+                "settings": {
+                  "protocol": "tcp",
+                  "port": 10250
+                }
+              }
+            }
+            {{end}}
             {{if UseAksExtension}}
             ,{
               "name": "[concat(variables('{{.Name}}VMNamePrefix'), '-computeAksLinuxBilling')]",
@@ -66,7 +66,7 @@ $global:TenantId = "{{WrapAsVariable "tenantID"}}"
 $global:SubscriptionId = "{{WrapAsVariable "subscriptionId"}}"
 $global:ResourceGroup = "{{WrapAsVariable "resourceGroup"}}"
 $global:VmType = "{{WrapAsVariable "vmType"}}"
-$global:SubnetName = "{{WrapAsVariable "subnetName"}}"
+$global:SubnetName = "{{WrapAsVariable "cloudProviderSubnetName"}}"
 $global:MasterSubnet = "{{WrapAsParameter "masterSubnet"}}"
 $global:SecurityGroupName = "{{WrapAsVariable "nsgName"}}"
 $global:VNetName = "{{WrapAsVariable "virtualNetworkName"}}"
@@ -76,8 +76,12 @@ $global:PrimaryScaleSetName = "{{WrapAsVariable "primaryScaleSetName"}}"
 
 $global:KubeClusterCIDR = "{{WrapAsParameter "kubeClusterCidr"}}"
 $global:KubeServiceCIDR = "{{WrapAsParameter "kubeServiceCidr"}}"
+$global:KubeDnsSearchPath = "svc.{{WrapAsParameter "kubernetesKubeletClusterDomain"}}"
 $global:KubeletNodeLabels = "{{GetAgentKubernetesLabels . "',variables('labelResourceGroup'),'"}}"
 $global:KubeletConfigArgs = @( {{GetKubeletConfigKeyValsPsh .KubernetesConfig }} )
+{{if .GetKubernetesTaints}}
+$global:KubeletConfigArgs += "--register-with-taints={{GetAgentKubernetesTaints .}}"
+{{end}}
 
 $global:UseManagedIdentityExtension = "{{WrapAsVariable "useManagedIdentityExtension"}}"
 $global:UserAssignedClientID = "{{WrapAsVariable "userAssignedClientID"}}"
@@ -91,8 +95,6 @@ $global:ExcludeMasterFromStandardLB = "{{WrapAsVariable "excludeMasterFromStanda
 $global:KubeDir = "c:\k"
 $global:HNSModule = [Io.path]::Combine("$global:KubeDir", "hns.psm1")
 
-$global:KubeDnsSearchPath = "svc.cluster.local"
-
 $global:CNIPath = [Io.path]::Combine("$global:KubeDir", "cni")
 $global:NetworkMode = "L2Bridge"
 $global:CNIConfig = [Io.path]::Combine($global:CNIPath, "config", "`$global:NetworkMode.conf")
@@ -233,6 +235,7 @@ try
             -CNIConfigPath $global:CNIConfigPath `
             -MasterIP $MasterIP `
             -KubeDnsServiceIp $KubeDnsServiceIp `
+            -KubeDnsSearchPath $global:KubeDnsSearchPath `
             -MasterSubnet $global:MasterSubnet `
             -KubeClusterCIDR $global:KubeClusterCIDR `
             -KubeServiceCIDR $global:KubeServiceCIDR `
@@ -307,6 +307,8 @@ Install-KubernetesServices
         [Parameter(Mandatory=$true)][string]
         $KubeDnsServiceIp,
         [Parameter(Mandatory=$true)][string]
+        $KubeDnsSearchPath,
+        [Parameter(Mandatory=$true)][string]
         $MasterSubnet,
         [Parameter(Mandatory=$true)][string]
         $KubeClusterCIDR,
@@ -369,7 +371,7 @@ Install-KubernetesServices
 
     $kubeStartStr = @"
 `$global:MasterIP = "$MasterIP"
-`$global:KubeDnsSearchPath = "svc.cluster.local"
+`$global:KubeDnsSearchPath = "$KubeDnsSearchPath"
 `$global:KubeDnsServiceIp = "$KubeDnsServiceIp"
 `$global:MasterSubnet = "$MasterSubnet"
 `$global:KubeClusterCIDR = "$KubeClusterCIDR"
@@ -16,6 +16,12 @@
       },
       "type": "string"
     },
+    "acsengineGenerationTime": {
+      "metadata": {
+        "description": "The UTC time at which acs-engine generated the template"
+      },
+      "type": "string"
+    },
     {{range .ExtensionProfiles}}
       "{{.Name}}Parameters": {
         "metadata": {
@@ -25,6 +31,14 @@
       },
     {{end}}
 {{if not IsHostedMaster }}
+  {{if .MasterProfile.HasExistingStorageAccount}}
+    "masterStorageAccountID": {
+      "metadata": {
+        "description": "Resource ID of the existing storage account holding the VHDs of the masters."
+      },
+      "type": "string"
+    },
+  {{end}}
   {{if .MasterProfile.IsCustomVNET}}
     "masterVnetSubnetID": {
       "metadata": {
@@ -82,6 +82,48 @@ func kubernetesContainerAddonSettingsInit(profile *api.Properties) map[string]ku
 			profile.OrchestratorProfile.KubernetesConfig.IsReschedulerEnabled(),
 			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultReschedulerAddonName),
 		},
+		DefaultGatekeeperAddonName: {
+			"kubernetesmasteraddons-gatekeeper-deployment.yaml",
+			"gatekeeper-deployment.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsGatekeeperEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultGatekeeperAddonName),
+		},
+		DefaultNodeProblemDetectorAddonName: {
+			"kubernetesmasteraddons-node-problem-detector-daemonset.yaml",
+			"node-problem-detector.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsNodeProblemDetectorEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultNodeProblemDetectorAddonName),
+		},
+		DefaultStartupTaintRemoverAddonName: {
+			"kubernetesmasteraddons-startup-taint-remover-deployment.yaml",
+			"startup-taint-remover.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsStartupTaintRemoverEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultStartupTaintRemoverAddonName),
+		},
+		DefaultAzureDiskCSIDriverAddonName: {
+			"kubernetesmasteraddons-azuredisk-csi-driver-deployment.yaml",
+			"azuredisk-csi-driver-deployment.yaml",
+			profile.OrchestratorProfile.IsAzureDiskCSIDriverEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultAzureDiskCSIDriverAddonName),
+		},
+		DefaultAzureFileCSIDriverAddonName: {
+			"kubernetesmasteraddons-azurefile-csi-driver-deployment.yaml",
+			"azurefile-csi-driver-deployment.yaml",
+			profile.OrchestratorProfile.IsAzureFileCSIDriverEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultAzureFileCSIDriverAddonName),
+		},
+		DefaultCloudNodeManagerAddonName: {
+			"kubernetesmasteraddons-cloud-node-manager-daemonset.yaml",
+			"cloud-node-manager-daemonset.yaml",
+			profile.OrchestratorProfile.IsCloudNodeManagerEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultCloudNodeManagerAddonName),
+		},
+		DefaultVeleroAddonName: {
+			"kubernetesmasteraddons-velero-deployment.yaml",
+			"velero.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsVeleroEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultVeleroAddonName),
+		},
 		NVIDIADevicePluginAddonName: {
 			"kubernetesmasteraddons-nvidia-device-plugin-daemonset.yaml",
 			"nvidia-device-plugin.yaml",
@@ -109,15 +151,26 @@ func kubernetesContainerAddonSettingsInit(profile *api.Properties) map[string]ku
 		DefaultDNSAutoscalerAddonName: {
 			"dns-autoscaler.yaml",
 			"dns-autoscaler.yaml",
-			// TODO enable this when it has been smoke tested
-			//common.IsKubernetesVersionGe(profile.OrchestratorProfile.OrchestratorVersion, "1.12.0"),
-			false,
+			profile.OrchestratorProfile.KubernetesConfig.IsDNSAutoscalerEnabled(),
 			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultDNSAutoscalerAddonName),
 		},
+		DefaultNodeLocalDNSAddonName: {
+			"kubernetesmasteraddons-nodelocaldns-daemonset.yaml",
+			"nodelocaldns-daemonset.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsNodeLocalDNSEnabled(),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultNodeLocalDNSAddonName),
+		},
 	}
 }
 
 func kubernetesAddonSettingsInit(profile *api.Properties) []kubernetesFeatureSetting {
+	// the storage classes match the disks of the first agent pool, or of the masters in a cluster without agent pools
+	var storageProfile string
+	if len(profile.AgentPoolProfiles) > 0 {
+		storageProfile = profile.AgentPoolProfiles[0].StorageProfile
+	} else if profile.MasterProfile != nil {
+		storageProfile = profile.MasterProfile.StorageProfile
+	}
 	return []kubernetesFeatureSetting{
 		{
 
@@ -141,20 +194,20 @@ func kubernetesAddonSettingsInit(profile *api.Properties) []kubernetesFeatureSet
 		{
 			"kubernetesmasteraddons-kube-proxy-daemonset.yaml",
 			"kube-proxy-daemonset.yaml",
-			true,
+			!profile.OrchestratorProfile.KubernetesConfig.IsKubeProxyStaticPod(),
 			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultKubeProxyAddonName),
 		},
 		{
 			"kubernetesmasteraddons-unmanaged-azure-storage-classes.yaml",
 			"azure-storage-classes.yaml",
-			profile.AgentPoolProfiles[0].StorageProfile != api.ManagedDisks,
-			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultAzureStorageClassesAddonName),
+			storageProfile != api.ManagedDisks,
+			getAzureStorageClassesScript(profile, "kubernetesmasteraddons-unmanaged-azure-storage-classes.yaml", false),
 		},
 		{
 			"kubernetesmasteraddons-managed-azure-storage-classes.yaml",
 			"azure-storage-classes.yaml",
-			profile.AgentPoolProfiles[0].StorageProfile == api.ManagedDisks,
-			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultAzureStorageClassesAddonName),
+			storageProfile == api.ManagedDisks,
+			getAzureStorageClassesScript(profile, "kubernetesmasteraddons-managed-azure-storage-classes.yaml", true),
 		},
 		{
 			"kubernetesmasteraddons-azure-npm-daemonset.yaml",
@@ -181,6 +234,12 @@ func kubernetesAddonSettingsInit(profile *api.Properties) []kubernetesFeatureSet
 			profile.OrchestratorProfile.KubernetesConfig.NetworkPlugin == NetworkPluginFlannel,
 			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultFlannelDaemonSetAddonName),
 		},
+		{
+			"",
+			"cni.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.NetworkPlugin == NetworkPluginCNI,
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultCNIAddonName),
+		},
 		{
 			"kubernetesmasteraddons-aad-default-admin-group-rbac.yaml",
 			"aad-default-admin-group-rbac.yaml",
@@ -193,6 +252,12 @@ func kubernetesAddonSettingsInit(profile *api.Properties) []kubernetesFeatureSet
 			true,
 			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultAzureCloudProviderDeploymentAddonName),
 		},
+		{
+			"kubernetesmasteraddons-cloud-controller-manager-deployment.yaml",
+			"cloud-controller-manager-deployment.yaml",
+			helpers.IsTrueBoolPointer(profile.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager),
+			profile.OrchestratorProfile.KubernetesConfig.CloudControllerManagerConfig["data"],
+		},
 		{
 			"kubernetesmaster-audit-policy.yaml",
 			"audit-policy.yaml",
@@ -205,6 +270,24 @@ func kubernetesAddonSettingsInit(profile *api.Properties) []kubernetesFeatureSet
 			profile.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == "Standard",
 			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultELBSVCAddonName),
 		},
+		{
+			"kubernetesmasteraddons-priority-classes.yaml",
+			"priority-classes.yaml",
+			helpers.IsTrueBoolPointer(profile.OrchestratorProfile.KubernetesConfig.EnablePodPriority),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultPriorityClassesAddonName),
+		},
+		{
+			"kubernetesmasteraddons-prometheus-metrics-rbac.yaml",
+			"prometheus-metrics-rbac.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsPrometheusMetricsEnabled() && helpers.IsTrueBoolPointer(profile.OrchestratorProfile.KubernetesConfig.EnableRbac),
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultPrometheusMetricsRBACAddonName),
+		},
+		{
+			"kubernetesmasteraddons-acs-engine-info-configmap.yaml",
+			"acs-engine-info-configmap.yaml",
+			true,
+			profile.OrchestratorProfile.KubernetesConfig.GetAddonScript(DefaultACSEngineInfoAddonName),
+		},
 	}
 }
 
@@ -222,12 +305,6 @@ func kubernetesManifestSettingsInit(profile *api.Properties) []kubernetesFeature
 			true,
 			profile.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig["data"],
 		},
-		{
-			"kubernetesmaster-cloud-controller-manager.yaml",
-			"cloud-controller-manager.yaml",
-			profile.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager != nil && *profile.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager,
-			profile.OrchestratorProfile.KubernetesConfig.CloudControllerManagerConfig["data"],
-		},
 		{
 			"kubernetesmaster-pod-security-policy.yaml",
 			"pod-security-policy.yaml",
@@ -246,6 +323,23 @@ func kubernetesManifestSettingsInit(profile *api.Properties) []kubernetesFeature
 			true,
 			"",
 		},
+		{
+			"kubernetes-kube-proxy.yaml",
+			"kube-proxy.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsKubeProxyStaticPod(),
+			"",
+		},
+	}
+}
+
+func kubernetesManifestSettingsInitAgent(profile *api.Properties) []kubernetesFeatureSetting {
+	return []kubernetesFeatureSetting{
+		{
+			"kubernetes-kube-proxy.yaml",
+			"kube-proxy.yaml",
+			profile.OrchestratorProfile.KubernetesConfig.IsKubeProxyStaticPod(),
+			"",
+		},
 	}
 }
 
@@ -23,6 +23,8 @@ const (
 	NetworkPluginKubenet = "kubenet"
 	// NetworkPluginFlannel is the string expression for flannel network policy config option
 	NetworkPluginFlannel = "flannel"
+	// NetworkPluginCNI is the string expression for a CNI brought by the user
+	NetworkPluginCNI = "cni"
 	// DefaultKubeHeapsterDeploymentAddonName is the name of the kube-heapster-deployment addon
 	DefaultKubeHeapsterDeploymentAddonName = "kube-heapster-deployment"
 	// DefaultKubeDNSDeploymentAddonName is the name of the kube-dns-deployment addon
@@ -31,6 +33,8 @@ const (
 	DefaultCoreDNSAddonName = "coredns"
 	// DefaultDNSAutoscalerAddonName is the name of the coredns addon
 	DefaultDNSAutoscalerAddonName = "dns-autoscaler"
+	// DefaultNodeLocalDNSAddonName is the name of the nodelocaldns addon
+	DefaultNodeLocalDNSAddonName = "nodelocaldns"
 	// DefaultKubeProxyAddonName is the name of the kube-proxy config addon
 	DefaultKubeProxyAddonName = "kube-proxy-daemonset"
 	// DefaultAzureStorageClassesAddonName is the name of the azure storage classes addon
@@ -43,6 +47,8 @@ const (
 	DefaultCiliumDaemonSetAddonName = "cilium-daemonset"
 	// DefaultFlannelDaemonSetAddonName is the name of flannel plugin daemonset addon
 	DefaultFlannelDaemonSetAddonName = "flannel-daemonset"
+	// DefaultCNIAddonName is the name of the addon whose data is the manifest installing a CNI brought by the user
+	DefaultCNIAddonName = "cni"
 	// DefaultAADAdminGroupRBACAddonName is the name of the default admin group RBAC addon
 	DefaultAADAdminGroupRBACAddonName = "aad-default-admin-group-rbac"
 	// DefaultAzureCloudProviderDeploymentAddonName is the name of the azure cloud provider deployment addon
@@ -67,12 +73,32 @@ const (
 	DefaultSMBFlexVolumeAddonName = "smb-flexvolume"
 	// DefaultKeyVaultFlexVolumeAddonName is the name of the keyvault flexvolume addon deployment
 	DefaultKeyVaultFlexVolumeAddonName = "keyvault-flexvolume"
+	// DefaultPriorityClassesAddonName is the name of the default priority classes addon
+	DefaultPriorityClassesAddonName = "priority-classes"
+	// DefaultPrometheusMetricsRBACAddonName is the name of the addon authorizing the scrapers of the control plane metrics
+	DefaultPrometheusMetricsRBACAddonName = "prometheus-metrics-rbac"
 	// DefaultELBSVCAddonName is the name of the elb service addon deployment
 	DefaultELBSVCAddonName = "elb-svc"
+	// DefaultACSEngineInfoAddonName is the name of the config map addon annotated with the acs-engine version which generated the cluster
+	DefaultACSEngineInfoAddonName = "acs-engine-info"
 	// DefaultGeneratorCode specifies the source generator of the cluster template.
 	DefaultGeneratorCode = "acsengine"
 	// DefaultReschedulerAddonName is the name of the rescheduler addon deployment
 	DefaultReschedulerAddonName = "rescheduler"
+	// DefaultGatekeeperAddonName is the name of the OPA Gatekeeper addon
+	DefaultGatekeeperAddonName = "gatekeeper"
+	// DefaultNodeProblemDetectorAddonName is the name of the node-problem-detector addon daemon set
+	DefaultNodeProblemDetectorAddonName = "node-problem-detector"
+	// DefaultStartupTaintRemoverAddonName is the name of the addon removing the startup taint of the nodes
+	DefaultStartupTaintRemoverAddonName = "startup-taint-remover"
+	// DefaultAzureDiskCSIDriverAddonName is the name of the Azure disk CSI driver addon
+	DefaultAzureDiskCSIDriverAddonName = "azuredisk-csi-driver"
+	// DefaultAzureFileCSIDriverAddonName is the name of the Azure file CSI driver addon
+	DefaultAzureFileCSIDriverAddonName = "azurefile-csi-driver"
+	// DefaultCloudNodeManagerAddonName is the name of the cloud-node-manager addon daemon set
+	DefaultCloudNodeManagerAddonName = "cloud-node-manager"
+	// DefaultVeleroAddonName is the name of the addon backing up the cluster with Velero
+	DefaultVeleroAddonName = "velero"
 	// DefaultMetricsServerAddonName is the name of the kubernetes Metrics server addon deployment
 	DefaultMetricsServerAddonName = "metrics-server"
 	// NVIDIADevicePluginAddonName is the name of the kubernetes NVIDIA Device Plugin daemon set
@@ -108,18 +134,17 @@ const (
 )
 
 const (
-	kubernetesMasterCustomDataYaml           = "k8s/kubernetesmastercustomdata.yml"
-	kubernetesCustomScript                   = "k8s/kubernetescustomscript.sh"
-	kubernetesProvisionSourceScript          = "k8s/kubernetesprovisionsource.sh"
-	kubernetesHealthMonitorScript            = "k8s/health-monitor.sh"
-	kubernetesInstalls                       = "k8s/kubernetesinstalls.sh"
-	kubernetesConfigurations                 = "k8s/kubernetesconfigs.sh"
-	kubernetesMountetcd                      = "k8s/kubernetes_mountetcd.sh"
-	kubernetesCustomSearchDomainsScript      = "k8s/setup-custom-search-domains.sh"
-	kubernetesMasterGenerateProxyCertsScript = "k8s/kubernetesmastergenerateproxycertscript.sh"
-	kubernetesAgentCustomDataYaml            = "k8s/kubernetesagentcustomdata.yml"
-	kubernetesJumpboxCustomDataYaml          = "k8s/kubernetesjumpboxcustomdata.yml"
-	kubeConfigJSON                           = "k8s/kubeconfig.json"
+	kubernetesMasterCustomDataYaml      = "k8s/kubernetesmastercustomdata.yml"
+	kubernetesCustomScript              = "k8s/kubernetescustomscript.sh"
+	kubernetesProvisionSourceScript     = "k8s/kubernetesprovisionsource.sh"
+	kubernetesHealthMonitorScript       = "k8s/health-monitor.sh"
+	kubernetesInstalls                  = "k8s/kubernetesinstalls.sh"
+	kubernetesConfigurations            = "k8s/kubernetesconfigs.sh"
+	kubernetesMountetcd                 = "k8s/kubernetes_mountetcd.sh"
+	kubernetesCustomSearchDomainsScript = "k8s/setup-custom-search-domains.sh"
+	kubernetesAgentCustomDataYaml       = "k8s/kubernetesagentcustomdata.yml"
+	kubernetesJumpboxCustomDataYaml     = "k8s/kubernetesjumpboxcustomdata.yml"
+	kubeConfigJSON                      = "k8s/kubeconfig.json"
 	// Windows custom scripts
 	kubernetesWindowsAgentCustomDataPS1   = "k8s/kuberneteswindowssetup.ps1"
 	kubernetesWindowsAgentFunctionsPS1    = "k8s/kuberneteswindowsfunctions.ps1"
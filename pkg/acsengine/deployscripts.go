@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package acsengine
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DeployScriptName is the name of the bash script deploying the generated template
+	DeployScriptName = "deploy.sh"
+	// DeployPowerShellScriptName is the name of the PowerShell script deploying the generated template
+	DeployPowerShellScriptName = "deploy.ps1"
+)
+
+// deployScriptTemplates are the scripts deploying the template and parameters files, which they expect
+// alongside them, to a resource group with the Azure CLI. The location of the resource group defaults to
+// the location of the cluster, if the api model has one, while the location of the resources of the
+// cluster remains set by the parameters file
+var deployScriptTemplates = map[string]string{
+	DeployScriptName: `#!/bin/bash
+# Deploys the cluster generated by acs-engine {{.Version}} to a resource group with the Azure CLI
+{{- if .Location}}
+# Usage: ./deploy.sh <resource group> [location] [parameters file]
+# The location of the resource group defaults to {{.Location}}, the location of the cluster
+{{- else}}
+# Usage: ./deploy.sh <resource group> <location> [parameters file]
+{{- end}}
+# The parameters file, in the directory of this script, defaults to {{.ParametersFile}}
+set -euo pipefail
+
+if [[ $# -lt {{if .Location}}1{{else}}2{{end}} ]]; then
+  echo "Usage: $0 <resource group> {{if .Location}}[location]{{else}}<location>{{end}} [parameters file]" >&2
+  exit 1
+fi
+RESOURCE_GROUP="$1"
+LOCATION="${2:-{{.Location}}}"
+PARAMETERS_FILE="${3:-{{.ParametersFile}}}"
+
+cd "$(dirname "$0")"
+az group create --name "${RESOURCE_GROUP}" --location "${LOCATION}"
+az deployment group create --resource-group "${RESOURCE_GROUP}" --template-file {{.TemplateFile}} --parameters "@${PARAMETERS_FILE}"
+`,
+	DeployPowerShellScriptName: `# Deploys the cluster generated by acs-engine {{.Version}} to a resource group with the Azure CLI
+{{- if .Location}}
+# Usage: .\deploy.ps1 -ResourceGroup <resource group> [-Location <location>] [-ParametersFile <parameters file>]
+# The location of the resource group defaults to {{.Location}}, the location of the cluster
+{{- else}}
+# Usage: .\deploy.ps1 -ResourceGroup <resource group> -Location <location> [-ParametersFile <parameters file>]
+{{- end}}
+# The parameters file, in the directory of this script, defaults to {{.ParametersFile}}
+param(
+    [Parameter(Mandatory=$true)]
+    [string]$ResourceGroup,
+{{- if .Location}}
+    [string]$Location = "{{.Location}}",
+{{- else}}
+    [Parameter(Mandatory=$true)]
+    [string]$Location,
+{{- end}}
+    [string]$ParametersFile = "{{.ParametersFile}}"
+)
+
+$ErrorActionPreference = "Stop"
+
+Push-Location $PSScriptRoot
+try {
+    az group create --name $ResourceGroup --location $Location
+    if ($LASTEXITCODE -ne 0) {
+        throw "error creating resource group $ResourceGroup"
+    }
+    az deployment group create --resource-group $ResourceGroup --template-file {{.TemplateFile}} --parameters "@$ParametersFile"
+    if ($LASTEXITCODE -ne 0) {
+        throw "error deploying {{.TemplateFile}} to resource group $ResourceGroup"
+    }
+} finally {
+    Pop-Location
+}
+`,
+}
+
+// GenerateDeployScripts returns the bash and PowerShell scripts, keyed by their file names, which deploy
+// the template and parameters files generated for a cluster to a resource group with the Azure CLI
+func GenerateDeployScripts(cs *api.ContainerService, acsengineVersion string) (map[string]string, error) {
+	values := struct {
+		Version        string
+		Location       string
+		TemplateFile   string
+		ParametersFile string
+	}{
+		Version:        acsengineVersion,
+		Location:       cs.Location,
+		TemplateFile:   "azuredeploy.json",
+		ParametersFile: "azuredeploy.parameters.json",
+	}
+
+	scripts := map[string]string{}
+	for name, script := range deployScriptTemplates {
+		templ, err := template.New(name).Parse(script)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing %s", name)
+		}
+		var buffer bytes.Buffer
+		if err = templ.Execute(&buffer, values); err != nil {
+			return nil, errors.Wrapf(err, "error generating %s", name)
+		}
+		scripts[name] = buffer.String()
+	}
+	return scripts, nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package acsengine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/acs-engine/pkg/api"
+)
+
+func TestGenerateDeployScripts(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.11.5", 3, 2, false)
+	cs.Location = "westus2"
+	scripts, err := GenerateDeployScripts(cs, TestACSEngineVersion)
+	if err != nil {
+		t.Fatalf("unexpected error generating the deploy scripts: %v", err)
+	}
+	if len(scripts) != 2 {
+		t.Fatalf("expected deploy.sh and deploy.ps1, got %d scripts", len(scripts))
+	}
+
+	for name, expected := range map[string][]string{
+		DeployScriptName: {
+			"#!/bin/bash\n",
+			`if [[ $# -lt 1 ]]; then`,
+			`LOCATION="${2:-westus2}"`,
+			`PARAMETERS_FILE="${3:-azuredeploy.parameters.json}"`,
+			`az group create --name "${RESOURCE_GROUP}" --location "${LOCATION}"`,
+			`az deployment group create --resource-group "${RESOURCE_GROUP}" --template-file azuredeploy.json --parameters "@${PARAMETERS_FILE}"`,
+		},
+		DeployPowerShellScriptName: {
+			"[Parameter(Mandatory=$true)]\n    [string]$ResourceGroup,\n",
+			`[string]$Location = "westus2",`,
+			`[string]$ParametersFile = "azuredeploy.parameters.json"`,
+			`az group create --name $ResourceGroup --location $Location`,
+			`az deployment group create --resource-group $ResourceGroup --template-file azuredeploy.json --parameters "@$ParametersFile"`,
+		},
+	} {
+		for _, e := range expected {
+			if !strings.Contains(scripts[name], e) {
+				t.Errorf("expected %s to contain %q, got:\n%s", name, e, scripts[name])
+			}
+		}
+	}
+
+	// without a location in the api model, the location of the resource group is required
+	cs.Location = ""
+	if scripts, err = GenerateDeployScripts(cs, TestACSEngineVersion); err != nil {
+		t.Fatalf("unexpected error generating the deploy scripts: %v", err)
+	}
+	if !strings.Contains(scripts[DeployScriptName], `if [[ $# -lt 2 ]]; then`) {
+		t.Errorf("expected deploy.sh to require the location, got:\n%s", scripts[DeployScriptName])
+	}
+	if !strings.Contains(scripts[DeployPowerShellScriptName], "[Parameter(Mandatory=$true)]\n    [string]$Location,\n") {
+		t.Errorf("expected deploy.ps1 to require the location, got:\n%s", scripts[DeployPowerShellScriptName])
+	}
+}
@@ -63,6 +63,10 @@ func GenerateKubeConfig(properties *api.Properties, location string) (string, er
 	kubeconfig := string(b)
 	// variable replacement
 	kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVerbatim \"parameters('caCertificate')\"}}", base64.StdEncoding.EncodeToString([]byte(properties.CertificateProfile.CaCertificate)), -1)
+	// the server is reached on the default HTTPS port, unless the apiserver is configured to serve on another port
+	if properties.OrchestratorProfile != nil && properties.OrchestratorProfile.KubernetesConfig.HasCustomAPIServerSecurePort() {
+		kubeconfig = strings.Replace(kubeconfig, "dnsSettings.fqdn\"}}\"", fmt.Sprintf("dnsSettings.fqdn\"}}:%d\"", properties.OrchestratorProfile.KubernetesConfig.GetAPIServerSecurePort()), -1)
+	}
 	if properties.OrchestratorProfile != nil &&
 		properties.OrchestratorProfile.KubernetesConfig != nil &&
 		properties.OrchestratorProfile.KubernetesConfig.PrivateCluster != nil &&
@@ -82,7 +86,23 @@ func GenerateKubeConfig(properties *api.Properties, location string) (string, er
 	} else {
 		kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVerbatim \"reference(concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))).dnsSettings.fqdn\"}}", api.FormatAzureProdFQDNByLocation(properties.MasterProfile.DNSPrefix, location), -1)
 	}
-	kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVariable \"resourceGroup\"}}", properties.MasterProfile.DNSPrefix, -1)
+	// the names default to the DNS prefix, unless configured to keep kubeconfigs merged from many clusters unambiguous
+	clusterName, contextName, userName := properties.MasterProfile.DNSPrefix, properties.MasterProfile.DNSPrefix, properties.MasterProfile.DNSPrefix+"-admin"
+	if properties.OrchestratorProfile != nil && properties.OrchestratorProfile.KubernetesConfig != nil {
+		k := properties.OrchestratorProfile.KubernetesConfig
+		if k.KubeConfigClusterName != "" {
+			clusterName = k.KubeConfigClusterName
+		}
+		if k.KubeConfigContextName != "" {
+			contextName = k.KubeConfigContextName
+		}
+		if k.KubeConfigUserName != "" {
+			userName = k.KubeConfigUserName
+		}
+	}
+	kubeconfig = strings.Replace(kubeconfig, "{{kubeConfigClusterName}}", clusterName, -1)
+	kubeconfig = strings.Replace(kubeconfig, "{{kubeConfigContextName}}", contextName, -1)
+	kubeconfig = strings.Replace(kubeconfig, "{{kubeConfigUserName}}", userName, -1)
 
 	var authInfo string
 	if properties.AADProfile == nil {
@@ -106,6 +126,87 @@ func GenerateKubeConfig(properties *api.Properties, location string) (string, er
 	return kubeconfig, nil
 }
 
+// getWebhookTokenAuthConfigFile returns the kubeconfig with which the API server calls the token review webhook
+func getWebhookTokenAuthConfigFile(webhook *api.WebhookTokenAuth) (string, error) {
+	return getWebhookConfigFile("token-webhook", webhook.URL, webhook.CACertificate)
+}
+
+// getAuditWebhookConfigFile returns the kubeconfig with which the API server sends the audit events to the webhook
+func getAuditWebhookConfigFile(webhook *api.AuditWebhook) (string, error) {
+	return getWebhookConfigFile("audit-webhook", webhook.URL, webhook.CACertificate)
+}
+
+// getWebhookConfigFile returns the kubeconfig with which the API server calls a webhook, trusting the system
+// roots unless the CA certificate of the webhook server is given
+func getWebhookConfigFile(name, server, caCertificate string) (string, error) {
+	cluster := map[string]string{"server": server}
+	if caCertificate != "" {
+		cluster["certificate-authority-data"] = base64.StdEncoding.EncodeToString([]byte(caCertificate))
+	}
+	kubeconfig := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Config",
+		"clusters": []map[string]interface{}{
+			{"name": name, "cluster": cluster},
+		},
+		"users": []map[string]interface{}{
+			{"name": "kube-apiserver", "user": map[string]string{}},
+		},
+		"contexts": []map[string]interface{}{
+			{"name": name, "context": map[string]string{"cluster": name, "user": "kube-apiserver"}},
+		},
+		"current-context": name,
+	}
+	b, err := json.MarshalIndent(kubeconfig, "", "  ")
+	if err != nil {
+		return "", errors.Wrapf(err, "error generating the kubeconfig of the %s", strings.Replace(name, "-", " ", -1))
+	}
+	return string(b), nil
+}
+
+// getAdmissionWebhookManifest returns the manifest of an admission webhook configuration, labelled to be
+// reconciled by the addon-manager unless it sets its own mode
+func getAdmissionWebhookManifest(webhook api.AdmissionWebhook) (string, error) {
+	manifest := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(webhook.Manifest), &manifest); err != nil {
+		return "", errors.Wrapf(err, "error parsing the manifest of the admission webhook %s", webhook.Name)
+	}
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		manifest["metadata"] = metadata
+	}
+	labels, _ := metadata["labels"].(map[string]interface{})
+	if labels == nil {
+		labels = map[string]interface{}{}
+		metadata["labels"] = labels
+	}
+	if _, ok := labels["addonmanager.kubernetes.io/mode"]; !ok {
+		labels["addonmanager.kubernetes.io/mode"] = "Reconcile"
+	}
+	b, err := yaml.Marshal(manifest)
+	if err != nil {
+		return "", errors.Wrapf(err, "error generating the manifest of the admission webhook %s", webhook.Name)
+	}
+	return string(b), nil
+}
+
+// getFullyQualifiedImageReference returns the reference of an image with its registry and tag, which docker
+// defaults to docker.io and latest, while ctr requires them
+func getFullyQualifiedImageReference(image string) string {
+	domain, remainder := "docker.io", image
+	if i := strings.Index(image, "/"); i >= 0 && (strings.ContainsAny(image[:i], ".:") || image[:i] == "localhost") {
+		domain, remainder = image[:i], image[i+1:]
+	}
+	if domain == "docker.io" && !strings.Contains(remainder, "/") {
+		remainder = "library/" + remainder
+	}
+	if !strings.ContainsAny(remainder[strings.LastIndex(remainder, "/")+1:], ":@") {
+		remainder += ":latest"
+	}
+	return domain + "/" + remainder
+}
+
 // validateDistro checks if the requested orchestrator type is supported on the requested Linux distro.
 func validateDistro(cs *api.ContainerService) bool {
 	// Check Master distro
@@ -571,8 +672,17 @@ func getSecurityRule(port int, portIndex int) string {
           }`, port, port, port, BaseLBPriority+portIndex)
 }
 
+// getAgentStorageAccountReference returns the ARM expression referencing the storage account holding the OS disk of
+// an agent, either the existing storage account of the pool or one of the storage accounts created for it
+func getAgentStorageAccountReference(a *api.AgentPoolProfile) string {
+	if a.HasExistingStorageAccount() {
+		return fmt.Sprintf("variables('%sStorageAccountID')", a.Name)
+	}
+	return fmt.Sprintf("concat('Microsoft.Storage/storageAccounts/',variables('storageAccountPrefixes')[mod(add(div(copyIndex(variables('%[1]sOffset')),variables('maxVMsPerStorageAccount')),variables('%[1]sStorageAccountOffset')),variables('storageAccountPrefixesCount'))],variables('storageAccountPrefixes')[div(add(div(copyIndex(variables('%[1]sOffset')),variables('maxVMsPerStorageAccount')),variables('%[1]sStorageAccountOffset')),variables('storageAccountPrefixesCount'))],variables('%[1]sAccountName'))", a.Name)
+}
+
 func getDataDisks(a *api.AgentPoolProfile) string {
-	if !a.HasDisks() {
+	if !a.HasDisks() && !a.HasContainerRuntimeDisk() {
 		return ""
 	}
 	var buf bytes.Buffer
@@ -586,21 +696,49 @@ func getDataDisks(a *api.AgentPoolProfile) string {
                 "uri": "[concat('http://',variables('storageAccountPrefixes')[mod(add(add(div(copyIndex(),variables('maxVMsPerStorageAccount')),variables('%sStorageAccountOffset')),variables('dataStorageAccountPrefixSeed')),variables('storageAccountPrefixesCount'))],variables('storageAccountPrefixes')[div(add(add(div(copyIndex(),variables('maxVMsPerStorageAccount')),variables('%sStorageAccountOffset')),variables('dataStorageAccountPrefixSeed')),variables('storageAccountPrefixesCount'))],variables('%sDataAccountName'),'.blob.core.windows.net/vhds/',variables('%sVMNamePrefix'),copyIndex(), '--datadisk%d.vhd')]"
               }
             }`
+	existingAccountDataDisks := `            {
+              "createOption": "Empty",
+              "diskSizeGB": "%d",
+              "lun": %d,
+              "name": "[concat(variables('%sVMNamePrefix'), copyIndex(),'-datadisk%d')]",
+              "vhd": {
+                "uri": "[concat(reference(variables('%sStorageAccountID'),variables('apiVersionStorage')).primaryEndpoints.blob,'vhds/',variables('%sVMNamePrefix'),copyIndex(), '--datadisk%d.vhd')]"
+              }
+            }`
 	managedDataDisks := `            {
               "diskSizeGB": "%d",
               "lun": %d,
-              "createOption": "Empty"
+              "createOption": "Empty"%s
             }`
+	// the managed data disks are encrypted with the disk encryption set of the agent pool, like its OS disk
+	managedDisk := ""
+	if a.HasDiskEncryptionSet() {
+		managedDisk = fmt.Sprintf(`,
+              "managedDisk": {
+                "diskEncryptionSet": {
+                  "id": "%s"
+                }
+              }`, a.DiskEncryptionSetID)
+	}
 	for i, diskSize := range a.DiskSizesGB {
 		if i > 0 {
 			buf.WriteString(",\n")
 		}
-		if a.StorageProfile == api.StorageAccount {
+		if a.HasExistingStorageAccount() {
+			buf.WriteString(fmt.Sprintf(existingAccountDataDisks, diskSize, i, a.Name, i, a.Name, a.Name, i))
+		} else if a.StorageProfile == api.StorageAccount {
 			buf.WriteString(fmt.Sprintf(dataDisks, diskSize, i, a.Name, i, a.Name, a.Name, a.Name, a.Name, i))
 		} else if a.StorageProfile == api.ManagedDisks {
-			buf.WriteString(fmt.Sprintf(managedDataDisks, diskSize, i))
+			buf.WriteString(fmt.Sprintf(managedDataDisks, diskSize, i, managedDisk))
 		}
 	}
+	if a.HasContainerRuntimeDisk() {
+		// the container runtime data disk is always managed, validation rejecting it with storage accounts
+		if a.HasDisks() {
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(fmt.Sprintf(managedDataDisks, a.ContainerRuntimeDiskSizeGB, a.GetContainerRuntimeDiskLun(), managedDisk))
+	}
 	buf.WriteString("\n          ],")
 	return buf.String()
 }
@@ -696,8 +834,14 @@ func getDCOSProvisionScript(script string) string {
 	return strings.Replace(strings.Replace(provisionScript, "\r\n", "\n", -1), "\n", "\n\n    ", -1)
 }
 
-func getAddonFuncMap(addon api.KubernetesAddon) template.FuncMap {
+func getAddonFuncMap(addon api.KubernetesAddon, orchestratorProfile *api.OrchestratorProfile) template.FuncMap {
 	return template.FuncMap{
+		"IsPodPriorityEnabled": func() bool {
+			return helpers.IsTrueBoolPointer(orchestratorProfile.KubernetesConfig.EnablePodPriority)
+		},
+		"IsKubernetesVersionGe": func(version string) bool {
+			return common.IsKubernetesVersionGe(orchestratorProfile.OrchestratorVersion, version)
+		},
 		"ContainerImage": func(name string) string {
 			i := addon.GetAddonContainersIndexByName(name)
 			return addon.Containers[i].Image
@@ -725,6 +869,28 @@ func getAddonFuncMap(addon api.KubernetesAddon) template.FuncMap {
 		"ContainerConfig": func(name string) string {
 			return addon.Config[name]
 		},
+		"DNSServiceIP": func() string {
+			return orchestratorProfile.KubernetesConfig.DNSServiceIP
+		},
+		"ClusterDomain": func() string {
+			return orchestratorProfile.KubernetesConfig.ClusterDomain
+		},
+		// ContainerConfigFiles parses a config entry holding a JSON object of files, and returns
+		// the compacted JSON content of each file keyed by its name
+		"ContainerConfigFiles": func(name string) map[string]string {
+			files := map[string]string{}
+			var contents map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(addon.Config[name]), &contents); err != nil {
+				return files
+			}
+			for file, content := range contents {
+				var b bytes.Buffer
+				if err := json.Compact(&b, content); err == nil {
+					files[file] = b.String()
+				}
+			}
+			return files
+		},
 	}
 }
 
@@ -743,24 +909,9 @@ func getContainerAddonsString(properties *api.Properties, sourcePath string) str
 	for _, addonName := range addonNames {
 		setting := settingsMap[addonName]
 		if setting.isEnabled {
-			var input string
-			if setting.rawScript != "" {
-				input = setting.rawScript
-			} else {
-				addon := properties.OrchestratorProfile.KubernetesConfig.GetAddonByName(addonName)
-				templ := template.New("addon resolver template").Funcs(getAddonFuncMap(addon))
-				addonFile := sourcePath + "/" + setting.sourceFile
-				addonFileBytes, err := Asset(addonFile)
-				if err != nil {
-					return ""
-				}
-				_, err = templ.Parse(string(addonFileBytes))
-				if err != nil {
-					return ""
-				}
-				var buffer bytes.Buffer
-				templ.Execute(&buffer, addon)
-				input = buffer.String()
+			input, err := getContainerAddonManifest(properties, addonName, setting, sourcePath)
+			if err != nil {
+				return ""
 			}
 			result += getAddonString(input, "/etc/kubernetes/addons", setting.destinationFile)
 		}
@@ -768,6 +919,26 @@ func getContainerAddonsString(properties *api.Properties, sourcePath string) str
 	return result
 }
 
+// getContainerAddonManifest renders the manifest of a container addon with the settings of its
+// containers, unless the api model provides the manifest
+func getContainerAddonManifest(properties *api.Properties, addonName string, setting kubernetesFeatureSetting, sourcePath string) (string, error) {
+	if setting.rawScript != "" {
+		return setting.rawScript, nil
+	}
+	addon := properties.OrchestratorProfile.KubernetesConfig.GetAddonByName(addonName)
+	templ := template.New("addon resolver template").Funcs(getAddonFuncMap(addon, properties.OrchestratorProfile))
+	addonFileBytes, err := Asset(sourcePath + "/" + setting.sourceFile)
+	if err != nil {
+		return "", err
+	}
+	if _, err = templ.Parse(string(addonFileBytes)); err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	templ.Execute(&buffer, addon)
+	return buffer.String(), nil
+}
+
 func getDCOSAgentProvisionScript(profile *api.AgentPoolProfile, orchProfile *api.OrchestratorProfile, bootstrapIP string) string {
 	// add the provision script
 	scriptname := dcos2Provision
@@ -0,0 +1,206 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package acsengine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// HelmChartName is the name of the Helm chart of the cluster addons, and of its directory
+const HelmChartName = "cluster-addons"
+
+// helmExcludedAddons are the addon files which are not Kubernetes objects, or which embed
+// secrets only known to the masters, and which are thus left out of the Helm chart
+var helmExcludedAddons = map[string]bool{
+	"audit-policy.yaml":     true,
+	"cilium-daemonset.yaml": true,
+}
+
+// helmAddonValues maps the placeholders of the addon files, which the masters substitute with
+// template parameters when provisioning, to the parameters providing the chart values
+var helmAddonValues = map[string]map[string]string{
+	"kube-proxy-daemonset.yaml": {
+		"<img>":  "kubernetesHyperkubeSpec",
+		"<CIDR>": "kubeClusterCidr",
+	},
+	"kube-dns-deployment.yaml": {
+		"<img>":        "kubernetesKubeDNSSpec",
+		"<imgMasq>":    "kubernetesDNSMasqSpec",
+		"<imgHealthz>": "kubernetesExecHealthzSpec",
+		"<imgSidecar>": "kubernetesDNSSidecarSpec",
+		"<domain>":     "kubernetesKubeletClusterDomain",
+		"<clustIP>":    "kubeDNSServiceIP",
+	},
+	"coredns.yaml": {
+		"<img>":     "kubernetesCoreDNSSpec",
+		"<domain>":  "kubernetesKubeletClusterDomain",
+		"<clustIP>": "kubeDNSServiceIP",
+	},
+	"kube-heapster-deployment.yaml": {
+		"<img>":      "kubernetesHeapsterSpec",
+		"<imgNanny>": "kubernetesAddonResizerSpec",
+	},
+	"aad-default-admin-group-rbac.yaml": {
+		"<gID>": "aadAdminGroupId",
+	},
+	"cluster-autoscaler-deployment.yaml": {
+		"<cloud>":              "kubernetesClusterAutoscalerAzureCloud",
+		"<useManagedIdentity>": "kubernetesClusterAutoscalerUseManagedIdentity",
+	},
+	"elb-svc.yaml": {
+		"<svcName>": "kuberneteselbsvcname",
+	},
+	"calico-daemonset.yaml": {
+		"<kubeClusterCidr>": "kubeClusterCidr",
+	},
+	"flannel-daemonset.yaml": {
+		"<kubeClusterCidr>": "kubeClusterCidr",
+	},
+	"acs-engine-info-configmap.yaml": {
+		"<acsengineVersion>": "acsengineVersion",
+		"<generationTime>":   "acsengineGenerationTime",
+	},
+}
+
+// GenerateHelmChart packages the addon manifests of a Kubernetes cluster as a minimal Helm chart, so
+// that they can be versioned and applied by GitOps tooling. The chart is returned as the contents
+// of its files keyed by their paths in the chart directory. values.yaml enables each addon of the
+// cluster and holds the images and settings which the masters otherwise substitute when provisioning
+func GenerateHelmChart(cs *api.ContainerService, generatorCode string, acsengineVersion string) (map[string]string, error) {
+	properties := cs.Properties
+	if !properties.OrchestratorProfile.IsKubernetes() {
+		return nil, errors.New("a Helm chart can only be generated for Kubernetes clusters")
+	}
+	parametersMap, err := getParameters(cs, generatorCode, acsengineVersion, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	chart := map[string]string{}
+	addons := map[string]bool{}
+	values := map[string]interface{}{"addons": addons}
+	addTemplate := func(name, destinationFile, manifest string) {
+		manifest = strings.Replace(manifest, "{{", `{{"{{"}}`, -1)
+		manifest = strings.Replace(manifest, "<startupTaintKey>", properties.OrchestratorProfile.KubernetesConfig.GetStartupTaintKey(), -1)
+		for placeholder, parameter := range helmAddonValues[destinationFile] {
+			if p, ok := parametersMap[parameter].(paramsMap); ok {
+				values[parameter] = p["value"]
+			}
+			manifest = strings.Replace(manifest, placeholder, fmt.Sprintf("{{ .Values.%s }}", parameter), -1)
+		}
+		if destinationFile == "calico-daemonset.yaml" {
+			ipamConfig := `{"type": "host-local", "subnet": "usePodCidr"}`
+			if properties.OrchestratorProfile.IsAzureCNIVNETIntegrated() {
+				ipamConfig = `{"type": "azure-vnet-ipam"}`
+			}
+			manifest = strings.Replace(manifest, "<calicoIPAMConfig>", ipamConfig, -1)
+		}
+		if destinationFile == "omsagent-daemonset.yaml" {
+			// the ID and key of an existing workspace are only known to ARM when deploying, so they are
+			// required chart values, the base64 encoded ID and primary shared key of the workspace
+			if workspaceResourceID := properties.OrchestratorProfile.KubernetesConfig.GetContainerMonitoringWorkspaceResourceID(); workspaceResourceID != "" {
+				values["containerMonitoringWorkspaceResourceId"] = workspaceResourceID
+				values["containerMonitoringWorkspaceGuid"] = ""
+				values["containerMonitoringWorkspaceKey"] = ""
+				manifest = strings.Replace(manifest, "<workspaceGuid>",
+					fmt.Sprintf("{{ required %q .Values.containerMonitoringWorkspaceGuid }}", "containerMonitoringWorkspaceGuid, the base64 encoded ID of workspace "+workspaceResourceID+", is required"), -1)
+				manifest = strings.Replace(manifest, "<workspaceKey>",
+					fmt.Sprintf("{{ required %q .Values.containerMonitoringWorkspaceKey }}", "containerMonitoringWorkspaceKey, the base64 encoded primary shared key of workspace "+workspaceResourceID+", is required"), -1)
+			}
+		}
+		addons[name] = true
+		chart[path.Join("templates", destinationFile)] = fmt.Sprintf("{{- if index .Values.addons %q }}\n%s\n{{- end }}\n", name, strings.TrimRight(manifest, "\n"))
+	}
+
+	versions := strings.Split(properties.OrchestratorProfile.OrchestratorVersion, ".")
+	versionedSourcePath := path.Join("k8s/addons", versions[0]+"."+versions[1])
+	for _, setting := range kubernetesAddonSettingsInit(properties) {
+		if !setting.isEnabled || helmExcludedAddons[setting.destinationFile] {
+			continue
+		}
+		var manifest string
+		if setting.rawScript != "" {
+			if manifest, err = decodeAddonScript(setting.rawScript); err != nil {
+				return nil, errors.Wrapf(err, "error decoding the %s addon", setting.destinationFile)
+			}
+		} else {
+			b, e := Asset(path.Join(versionedSourcePath, setting.sourceFile))
+			if e != nil {
+				b, e = Asset(path.Join("k8s/addons", setting.sourceFile))
+			}
+			if e != nil {
+				return nil, errors.Wrapf(e, "error reading the %s addon", setting.destinationFile)
+			}
+			manifest = string(b)
+		}
+		addTemplate(strings.TrimSuffix(setting.destinationFile, ".yaml"), setting.destinationFile, manifest)
+	}
+
+	containerAddons := kubernetesContainerAddonSettingsInit(properties)
+	for _, name := range sortedAddonNames(containerAddons) {
+		setting := containerAddons[name]
+		if !setting.isEnabled {
+			continue
+		}
+		manifest, e := getContainerAddonManifest(properties, name, setting, "k8s/containeraddons")
+		if e != nil {
+			return nil, errors.Wrapf(e, "error rendering the %s addon", name)
+		}
+		if setting.rawScript != "" {
+			if manifest, e = decodeAddonScript(manifest); e != nil {
+				return nil, errors.Wrapf(e, "error decoding the %s addon", name)
+			}
+		}
+		addTemplate(name, setting.destinationFile, manifest)
+	}
+
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, errors.Wrap(err, "error writing the chart values")
+	}
+	chart["values.yaml"] = string(valuesYAML)
+	chart["Chart.yaml"] = fmt.Sprintf("apiVersion: v1\nname: %s\nversion: %s\nappVersion: %q\ndescription: Addons of a Kubernetes %s cluster, generated by acs-engine %s\n",
+		HelmChartName, properties.OrchestratorProfile.OrchestratorVersion, properties.OrchestratorProfile.OrchestratorVersion,
+		properties.OrchestratorProfile.OrchestratorVersion, acsengineVersion)
+	return chart, nil
+}
+
+// decodeAddonScript returns the manifest of an addon provided base64 encoded, and possibly
+// gzipped, by the api model
+func decodeAddonScript(script string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(script)
+	if err != nil {
+		return "", err
+	}
+	if len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		return string(b), nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	b, err = ioutil.ReadAll(r)
+	return string(b), err
+}
+
+func sortedAddonNames(settings map[string]kubernetesFeatureSetting) []string {
+	var names []string
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,178 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package acsengine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	"github.com/Azure/acs-engine/pkg/i18n"
+	"github.com/ghodss/yaml"
+	"github.com/leonelquinteros/gotext"
+)
+
+func TestGenerateHelmChart(t *testing.T) {
+	locale := gotext.NewLocale(path.Join("..", "..", "translations"), "en_US")
+	i18n.Initialize(locale)
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: locale,
+		},
+	}
+	cs, _, err := apiloader.LoadContainerServiceFromFile("./testdata/simple/kubernetes.json", true, false, nil)
+	if err != nil {
+		t.Fatalf("failed to load the api model: %v", err)
+	}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+		{Name: DefaultTillerAddonName, Enabled: helpers.PointerToBool(true)},
+		{Name: DefaultDashboardAddonName, Enabled: helpers.PointerToBool(false)},
+	}
+	cs.SetPropertiesDefaults(false, false)
+
+	chart, err := GenerateHelmChart(cs, DefaultGeneratorCode, TestACSEngineVersion)
+	if err != nil {
+		t.Fatalf("unexpected error generating the Helm chart: %v", err)
+	}
+
+	version := cs.Properties.OrchestratorProfile.OrchestratorVersion
+	if !strings.Contains(chart["Chart.yaml"], "name: cluster-addons\n") || !strings.Contains(chart["Chart.yaml"], "version: "+version+"\n") {
+		t.Errorf("expected Chart.yaml to name the chart and version it after the cluster, got:\n%s", chart["Chart.yaml"])
+	}
+	for _, name := range []string{"templates/kube-proxy-daemonset.yaml", "templates/kube-tiller-deployment.yaml", "templates/azure-storage-classes.yaml"} {
+		if _, ok := chart[name]; !ok {
+			t.Errorf("expected the chart to have %s", name)
+		}
+	}
+	for _, name := range []string{"templates/kubernetes-dashboard-deployment.yaml", "templates/audit-policy.yaml", "templates/kube-apiserver.yaml"} {
+		if _, ok := chart[name]; ok {
+			t.Errorf("expected the chart not to have %s", name)
+		}
+	}
+
+	var values map[string]interface{}
+	if err = yaml.Unmarshal([]byte(chart["values.yaml"]), &values); err != nil {
+		t.Fatalf("failed to parse values.yaml: %v", err)
+	}
+	addons := values["addons"].(map[string]interface{})
+	for _, name := range []string{"kube-proxy-daemonset", DefaultTillerAddonName} {
+		if addons[name] != true {
+			t.Errorf("expected values.yaml to enable the %s addon, got %v", name, addons[name])
+		}
+	}
+	if _, ok := addons[DefaultDashboardAddonName]; ok {
+		t.Errorf("expected values.yaml not to list the disabled %s addon", DefaultDashboardAddonName)
+	}
+	hyperkube, _ := values["kubernetesHyperkubeSpec"].(string)
+	if !strings.Contains(hyperkube, "hyperkube") {
+		t.Errorf("expected values.yaml to hold the hyperkube image, got %v", values["kubernetesHyperkubeSpec"])
+	}
+
+	// the chart templates are rendered by Helm with the chart values
+	render := func(name string, values map[string]interface{}) string {
+		templ, err := template.New(name).Parse(chart[name])
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", name, err)
+		}
+		var b bytes.Buffer
+		if err = templ.Execute(&b, map[string]interface{}{"Values": values}); err != nil {
+			t.Fatalf("failed to render %s: %v", name, err)
+		}
+		return b.String()
+	}
+	for name := range chart {
+		if strings.HasPrefix(name, "templates/") {
+			if manifest := render(name, values); strings.Contains(manifest, "<img>") {
+				t.Errorf("expected the placeholders of %s to be substituted, got:\n%s", name, manifest)
+			}
+		}
+	}
+	kubeProxy := render("templates/kube-proxy-daemonset.yaml", values)
+	if !strings.Contains(kubeProxy, "image: "+hyperkube) || strings.Contains(kubeProxy, "<img>") {
+		t.Errorf("expected the kube-proxy daemonset to run the hyperkube image, got:\n%s", kubeProxy)
+	}
+	addons["kube-proxy-daemonset"] = false
+	if kubeProxy = render("templates/kube-proxy-daemonset.yaml", values); strings.TrimSpace(kubeProxy) != "" {
+		t.Errorf("expected the kube-proxy daemonset not to be rendered once disabled, got:\n%s", kubeProxy)
+	}
+
+	dir, err := ioutil.TempDir("", "acs-engine-helm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writer := &ArtifactWriter{
+		Translator: &i18n.Translator{
+			Locale: locale,
+		},
+	}
+	if err = writer.WriteHelmChart(chart, dir); err != nil {
+		t.Fatalf("unexpected error writing the Helm chart: %v", err)
+	}
+	for _, name := range []string{"Chart.yaml", "values.yaml", "templates/kube-proxy-daemonset.yaml"} {
+		if _, err := os.Stat(path.Join(dir, HelmChartName, name)); err != nil {
+			t.Errorf("expected the Helm chart to have been written with %s: %v", name, err)
+		}
+	}
+}
+
+func TestGenerateHelmChartContainerMonitoringWorkspace(t *testing.T) {
+	locale := gotext.NewLocale(path.Join("..", "..", "translations"), "en_US")
+	i18n.Initialize(locale)
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: locale,
+		},
+	}
+	cs, _, err := apiloader.LoadContainerServiceFromFile("./testdata/simple/kubernetes.json", true, false, nil)
+	if err != nil {
+		t.Fatalf("failed to load the api model: %v", err)
+	}
+	workspaceResourceID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.OperationalInsights/workspaces/ws"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+		{
+			Name:    api.ContainerMonitoringAddonName,
+			Enabled: helpers.PointerToBool(true),
+			Config:  map[string]string{"workspaceResourceId": workspaceResourceID},
+		},
+	}
+	cs.SetPropertiesDefaults(false, false)
+
+	chart, err := GenerateHelmChart(cs, DefaultGeneratorCode, TestACSEngineVersion)
+	if err != nil {
+		t.Fatalf("unexpected error generating the Helm chart: %v", err)
+	}
+
+	manifest, ok := chart["templates/omsagent-daemonset.yaml"]
+	if !ok {
+		t.Fatalf("expected the chart to have templates/omsagent-daemonset.yaml")
+	}
+	if strings.Contains(manifest, "<workspaceGuid>") || strings.Contains(manifest, "<workspaceKey>") {
+		t.Errorf("expected the workspace placeholders of the omsagent daemonset to be substituted, got:\n%s", manifest)
+	}
+	for _, value := range []string{".Values.containerMonitoringWorkspaceGuid", ".Values.containerMonitoringWorkspaceKey"} {
+		if !strings.Contains(manifest, value) {
+			t.Errorf("expected the omsagent daemonset to require %s, got:\n%s", value, manifest)
+		}
+	}
+
+	var values map[string]interface{}
+	if err = yaml.Unmarshal([]byte(chart["values.yaml"]), &values); err != nil {
+		t.Fatalf("failed to parse values.yaml: %v", err)
+	}
+	if values["containerMonitoringWorkspaceResourceId"] != workspaceResourceID {
+		t.Errorf("expected values.yaml to hold the workspace resource ID, got %v", values["containerMonitoringWorkspaceResourceId"])
+	}
+	for _, value := range []string{"containerMonitoringWorkspaceGuid", "containerMonitoringWorkspaceKey"} {
+		if _, ok := values[value]; !ok {
+			t.Errorf("expected values.yaml to list %s", value)
+		}
+	}
+}
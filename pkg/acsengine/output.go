@@ -6,6 +6,7 @@ package acsengine
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"strconv"
@@ -144,3 +145,34 @@ func (w *ArtifactWriter) WriteTLSArtifacts(containerService *api.ContainerServic
 
 	return nil
 }
+
+// WriteHelmChart saves the files of the Helm chart of the cluster addons to its directory in the
+// artifacts directory
+func (w *ArtifactWriter) WriteHelmChart(chart map[string]string, artifactsDir string) error {
+	f := &helpers.FileSaver{
+		Translator: w.Translator,
+	}
+	for name, contents := range chart {
+		if e := f.SaveFileString(path.Join(artifactsDir, HelmChartName, path.Dir(name)), path.Base(name), contents); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// WriteDeployScripts saves the scripts deploying the template to the artifacts directory, the bash
+// script being executable
+func (w *ArtifactWriter) WriteDeployScripts(scripts map[string]string, artifactsDir string) error {
+	f := &helpers.FileSaver{
+		Translator: w.Translator,
+	}
+	for name, contents := range scripts {
+		if e := f.SaveFileString(artifactsDir, name, contents); e != nil {
+			return e
+		}
+	}
+	if _, ok := scripts[DeployScriptName]; ok {
+		return os.Chmod(path.Join(artifactsDir, DeployScriptName), 0700)
+	}
+	return nil
+}
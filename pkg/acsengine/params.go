@@ -7,13 +7,14 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Azure/acs-engine/pkg/api"
 	"github.com/Azure/acs-engine/pkg/api/common"
 	"github.com/Azure/acs-engine/pkg/helpers"
 )
 
-func getParameters(cs *api.ContainerService, generatorCode string, acsengineVersion string) (paramsMap, error) {
+func getParameters(cs *api.ContainerService, generatorCode string, acsengineVersion string, generationTime time.Time) (paramsMap, error) {
 	properties := cs.Properties
 	location := cs.Location
 	parametersMap := paramsMap{}
@@ -21,6 +22,7 @@ func getParameters(cs *api.ContainerService, generatorCode string, acsengineVers
 
 	// acsengine Parameters
 	addValue(parametersMap, "acsengineVersion", acsengineVersion)
+	addValue(parametersMap, "acsengineGenerationTime", generationTime.Format(time.RFC3339))
 
 	// Master Parameters
 	addValue(parametersMap, "location", location)
@@ -59,6 +61,9 @@ func getParameters(cs *api.ContainerService, generatorCode string, acsengineVers
 		addValue(parametersMap, "masterEndpointDNSNamePrefix", properties.HostedMasterProfile.DNSPrefix)
 	}
 	if properties.MasterProfile != nil {
+		if properties.MasterProfile.HasExistingStorageAccount() {
+			addValue(parametersMap, "masterStorageAccountID", properties.MasterProfile.StorageAccountID)
+		}
 		if properties.MasterProfile.IsCustomVNET() {
 			addValue(parametersMap, "masterVnetSubnetID", properties.MasterProfile.VnetSubnetID)
 			if properties.MasterProfile.IsVirtualMachineScaleSets() {
@@ -187,6 +192,9 @@ func getParameters(cs *api.ContainerService, generatorCode string, acsengineVers
 		if agentProfile.HasAvailabilityZones() {
 			addValue(parametersMap, fmt.Sprintf("%sAvailabilityZones", agentProfile.Name), agentProfile.AvailabilityZones)
 		}
+		if agentProfile.HasExistingStorageAccount() {
+			addValue(parametersMap, fmt.Sprintf("%sStorageAccountID", agentProfile.Name), agentProfile.StorageAccountID)
+		}
 		if agentProfile.IsCustomVNET() {
 			addValue(parametersMap, fmt.Sprintf("%sVnetSubnetID", agentProfile.Name), agentProfile.VnetSubnetID)
 		} else {
@@ -202,6 +210,7 @@ func getParameters(cs *api.ContainerService, generatorCode string, acsengineVers
 			if agentProfile.ImageRef != nil {
 				addValue(parametersMap, fmt.Sprintf("%sosImageName", agentProfile.Name), agentProfile.ImageRef.Name)
 				addValue(parametersMap, fmt.Sprintf("%sosImageResourceGroup", agentProfile.Name), agentProfile.ImageRef.ResourceGroup)
+				addValue(parametersMap, fmt.Sprintf("%sosImageId", agentProfile.Name), agentProfile.ImageRef.ID)
 			}
 			addValue(parametersMap, fmt.Sprintf("%sosImageOffer", agentProfile.Name), cloudSpecConfig.OSImageConfig[agentProfile.Distro].ImageOffer)
 			addValue(parametersMap, fmt.Sprintf("%sosImageSKU", agentProfile.Name), cloudSpecConfig.OSImageConfig[agentProfile.Distro].ImageSku)
@@ -76,6 +76,9 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 			} else {
 				addValue(parametersMap, "kubernetesClusterAutoscalerEnabled", false)
 			}
+			if workspaceResourceID := kubernetesConfig.GetContainerMonitoringWorkspaceResourceID(); workspaceResourceID != "" {
+				addValue(parametersMap, "containerMonitoringWorkspaceResourceId", workspaceResourceID)
+			}
 			if kubernetesConfig.LoadBalancerSku == "Standard" {
 				random := rand.New(rand.NewSource(time.Now().UnixNano()))
 				elbsvcName := random.Int()
@@ -100,6 +103,11 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 			})
 			addValue(parametersMap, "kubeClusterCidr", kubernetesConfig.ClusterSubnet)
 			addValue(parametersMap, "kubernetesKubeletClusterDomain", kubernetesConfig.KubeletConfig["--cluster-domain"])
+			if kubernetesConfig.HasPrivateRegistry() {
+				addValue(parametersMap, "privateRegistryServer", kubernetesConfig.PrivateRegistry.Server)
+				addValue(parametersMap, "privateRegistryUsername", kubernetesConfig.PrivateRegistry.Username)
+				addSecret(parametersMap, "privateRegistryPassword", kubernetesConfig.PrivateRegistry.Password, false)
+			}
 			addValue(parametersMap, "dockerBridgeCidr", kubernetesConfig.DockerBridgeSubnet)
 			addValue(parametersMap, "networkPolicy", kubernetesConfig.NetworkPolicy)
 			addValue(parametersMap, "networkPlugin", kubernetesConfig.NetworkPlugin)
@@ -114,6 +122,17 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 			addValue(parametersMap, "etcdVersion", kubernetesConfig.EtcdVersion)
 			addValue(parametersMap, "etcdDiskSizeGB", kubernetesConfig.EtcdDiskSizeGB)
 			addValue(parametersMap, "etcdEncryptionKey", kubernetesConfig.EtcdEncryptionKey)
+			if kubernetesConfig.IsBootstrapTokenEnabled() {
+				addValue(parametersMap, "bootstrapTokenID", kubernetesConfig.GetBootstrapTokenID())
+				addValue(parametersMap, "bootstrapTokenSecret", kubernetesConfig.GetBootstrapTokenSecret())
+				addValue(parametersMap, "bootstrapTokenExpiration", kubernetesConfig.BootstrapTokenExpiration)
+			}
+			if kubernetesConfig.ServiceInternalLBSubnetID != "" {
+				addValue(parametersMap, "serviceInternalLBSubnetID", kubernetesConfig.ServiceInternalLBSubnetID)
+			}
+			if kubernetesConfig.HasNATGateway() {
+				addValue(parametersMap, "natGatewayID", kubernetesConfig.NATGatewayID)
+			}
 			if kubernetesConfig.PrivateJumpboxProvision() {
 				addValue(parametersMap, "jumpboxVMName", kubernetesConfig.PrivateCluster.JumpboxProfile.Name)
 				addValue(parametersMap, "jumpboxVMSize", kubernetesConfig.PrivateCluster.JumpboxProfile.VMSize)
@@ -155,6 +174,10 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 			} else {
 				addValue(parametersMap, "servicePrincipalClientSecret", properties.ServicePrincipalProfile.Secret)
 			}
+			if properties.ServicePrincipalProfile.Certificate != "" {
+				addSecret(parametersMap, "servicePrincipalClientCertificate", properties.ServicePrincipalProfile.Certificate, true)
+				addValue(parametersMap, "servicePrincipalClientCertificatePassword", properties.ServicePrincipalProfile.CertificatePassword)
+			}
 
 			if kubernetesConfig != nil && helpers.IsTrueBoolPointer(kubernetesConfig.EnableEncryptionWithExternalKms) && !kubernetesConfig.UseManagedIdentity && properties.ServicePrincipalProfile.ObjectID != "" {
 				addValue(parametersMap, "servicePrincipalObjectId", properties.ServicePrincipalProfile.ObjectID)
@@ -173,12 +196,18 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 		 - kubeConfigCertificate
 		 - kubeConfigPrivateKey
 		 - servicePrincipalClientSecret
+		 - servicePrincipalClientCertificate
+		 - privateRegistryPassword
 		 - etcdClientCertificate
 		 - etcdClientPrivateKey
 		 - etcdServerCertificate
 		 - etcdServerPrivateKey
 		 - etcdPeerCertificates
 		 - etcdPeerPrivateKeys
+		 - serviceAccountSigningKey
+		 - frontProxyCACertificate
+		 - frontProxyClientCertificate
+		 - frontProxyClientPrivateKey
 
 		 To refer to a keyvault secret, the value of the parameter in the api model file should be formatted as:
 
@@ -222,6 +251,14 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 				for i, pk := range certificateProfile.EtcdPeerPrivateKeys {
 					addSecret(parametersMap, "etcdPeerPrivateKey"+strconv.Itoa(i), pk, true)
 				}
+				if orchestratorProfile.KubernetesConfig.HasServiceAccountIssuer() {
+					addSecret(parametersMap, "serviceAccountSigningKey", certificateProfile.ServiceAccountSigningKey, true)
+				}
+				if orchestratorProfile.KubernetesConfig.EnableAggregatedAPIs {
+					addSecret(parametersMap, "frontProxyCACertificate", certificateProfile.FrontProxyCACertificate, true)
+					addSecret(parametersMap, "frontProxyClientCertificate", certificateProfile.FrontProxyClientCertificate, true)
+					addSecret(parametersMap, "frontProxyClientPrivateKey", certificateProfile.FrontProxyClientPrivateKey, true)
+				}
 			}
 		}
 
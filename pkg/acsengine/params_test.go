@@ -6,6 +6,7 @@ package acsengine
 import (
 	"path"
 	"testing"
+	"time"
 
 	"github.com/Azure/acs-engine/pkg/api"
 	"github.com/Azure/acs-engine/pkg/i18n"
@@ -38,7 +39,7 @@ func TestAssignParameters(t *testing.T) {
 
 		containerService.Location = "eastus"
 		containerService.SetPropertiesDefaults(false, false)
-		parametersMap, err := getParameters(containerService, DefaultGeneratorCode, "testversion")
+		parametersMap, err := getParameters(containerService, DefaultGeneratorCode, "testversion", time.Now())
 		if err != nil {
 			t.Errorf("should not get error when populating parameters")
 		}
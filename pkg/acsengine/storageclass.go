@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package acsengine
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+// defaultStorageClassTemplate is the StorageClass named default, annotated as the default StorageClass of
+// the cluster. The reclaim policy and the volume binding mode are only set when they differ from the
+// defaults of Kubernetes, as the StorageClasses of older Kubernetes versions don't know these fields
+var defaultStorageClassTemplate = template.Must(template.New("default-storage-class").Parse(`apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: default
+  annotations:
+    storageclass.beta.kubernetes.io/is-default-class: "true"
+    storageclass.kubernetes.io/is-default-class: "true"
+  labels:
+    kubernetes.io/cluster-service: "true"
+provisioner: {{.Provisioner}}
+parameters:
+{{- if eq .Provisioner "kubernetes.io/azure-file"}}
+  skuName: {{.SKU}}
+{{- else}}
+{{- if .ManagedDisks}}
+  kind: Managed
+{{- end}}
+  storageaccounttype: {{.SKU}}
+  cachingmode: None
+{{- end}}
+{{- if and .ReclaimPolicy (ne .ReclaimPolicy "Delete")}}
+reclaimPolicy: {{.ReclaimPolicy}}
+{{- end}}
+{{- if and .VolumeBindingMode (ne .VolumeBindingMode "Immediate")}}
+volumeBindingMode: {{.VolumeBindingMode}}
+{{- end}}
+`))
+
+// getDefaultStorageClassManifest returns the manifest of the default StorageClass of the cluster
+func getDefaultStorageClassManifest(storageClass *api.StorageClass, managedDisks bool) (string, error) {
+	values := struct {
+		*api.StorageClass
+		ManagedDisks bool
+	}{
+		StorageClass: storageClass,
+		ManagedDisks: managedDisks,
+	}
+	var buffer bytes.Buffer
+	if err := defaultStorageClassTemplate.Execute(&buffer, values); err != nil {
+		return "", errors.Wrap(err, "error generating the default StorageClass")
+	}
+	return buffer.String(), nil
+}
+
+// getAzureStorageClassesScript returns the script of the azure-storage-classes addon: the script provided
+// by the user, if any, else the StorageClasses of sourceFile with their default StorageClass generated from
+// the configuration of the default StorageClass of the cluster, if any
+func getAzureStorageClassesScript(profile *api.Properties, sourceFile string, managedDisks bool) string {
+	k := profile.OrchestratorProfile.KubernetesConfig
+	if script := k.GetAddonScript(DefaultAzureStorageClassesAddonName); script != "" || k.DefaultStorageClass == nil {
+		return script
+	}
+	b, err := Asset("k8s/addons/" + sourceFile)
+	if err != nil {
+		return ""
+	}
+	defaultStorageClass, err := getDefaultStorageClassManifest(k.DefaultStorageClass, managedDisks)
+	if err != nil {
+		return ""
+	}
+	docs := strings.Split(string(b), "---\n")
+	for i, doc := range docs {
+		if strings.Contains(doc, "\n  name: default\n") {
+			docs[i] = defaultStorageClass
+		}
+	}
+	return getBase64CustomScriptFromStr(strings.Join(docs, "---\n"))
+}
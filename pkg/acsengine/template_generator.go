@@ -7,12 +7,14 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"runtime/debug"
 	"sort"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Azure/acs-engine/pkg/api"
 	"github.com/Azure/acs-engine/pkg/api/common"
@@ -25,12 +27,15 @@ import (
 // TemplateGenerator represents the object that performs the template generation.
 type TemplateGenerator struct {
 	Translator *i18n.Translator
+	// GenerationTime is stamped, along with the acs-engine version, on the resources of the generated templates
+	GenerationTime time.Time
 }
 
 // InitializeTemplateGenerator creates a new template generator object
 func InitializeTemplateGenerator(ctx Context) (*TemplateGenerator, error) {
 	t := &TemplateGenerator{
-		Translator: ctx.Translator,
+		Translator:     ctx.Translator,
+		GenerationTime: time.Now().UTC(),
 	}
 
 	if err := t.verifyFiles(); err != nil {
@@ -98,10 +103,14 @@ func (t *TemplateGenerator) GenerateTemplate(containerService *api.ContainerServ
 	templateRaw = b.String()
 
 	var parametersMap paramsMap
-	if parametersMap, err = getParameters(containerService, generatorCode, acsengineVersion); err != nil {
+	if parametersMap, err = getParameters(containerService, generatorCode, acsengineVersion, t.GenerationTime); err != nil {
 		return templateRaw, parametersRaw, err
 	}
 
+	if templateRaw, err = addExtraTemplateEntries(properties, templateRaw, parametersMap); err != nil {
+		return "", "", err
+	}
+
 	var parameterBytes []byte
 	if parameterBytes, err = helpers.JSONMarshal(parametersMap, false); err != nil {
 		return templateRaw, parametersRaw, err
@@ -111,6 +120,81 @@ func (t *TemplateGenerator) GenerateTemplate(containerService *api.ContainerServ
 	return templateRaw, parametersRaw, err
 }
 
+// addExtraTemplateEntries merges the extraParameters and extraVariables of the api model into the
+// parameters and variables of the generated template, and the values of the extraParameters into
+// the parameters map. Entries colliding with generated ones are rejected rather than overriding them
+func addExtraTemplateEntries(properties *api.Properties, templateRaw string, parametersMap paramsMap) (string, error) {
+	if len(properties.ExtraParameters) == 0 && len(properties.ExtraVariables) == 0 {
+		return templateRaw, nil
+	}
+
+	var template map[string]interface{}
+	if err := json.Unmarshal([]byte(templateRaw), &template); err != nil {
+		return "", errors.Wrap(err, "error parsing the generated template to add the extra parameters and variables")
+	}
+	parameters, ok := template["parameters"].(map[string]interface{})
+	if !ok {
+		parameters = map[string]interface{}{}
+		template["parameters"] = parameters
+	}
+	variables, ok := template["variables"].(map[string]interface{})
+	if !ok {
+		variables = map[string]interface{}{}
+		template["variables"] = variables
+	}
+
+	for _, name := range sortedKeys(properties.ExtraParameters) {
+		if _, ok := parameters[name]; ok {
+			return "", errors.Errorf("extraParameters '%s' collides with a parameter of the generated template", name)
+		}
+		value := properties.ExtraParameters[name]
+		parameters[name] = map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"description": "Set by the extraParameters of the api model.",
+			},
+			"type": armParameterType(value),
+		}
+		addValue(parametersMap, name, value)
+	}
+	for _, name := range sortedKeys(properties.ExtraVariables) {
+		if _, ok := variables[name]; ok {
+			return "", errors.Errorf("extraVariables '%s' collides with a variable of the generated template", name)
+		}
+		variables[name] = properties.ExtraVariables[name]
+	}
+
+	templateBytes, err := helpers.JSONMarshal(template, false)
+	if err != nil {
+		return "", err
+	}
+	return string(templateBytes), nil
+}
+
+// armParameterType returns the ARM template parameter type holding a value decoded from JSON
+func armParameterType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "bool"
+	case float64, int:
+		return "int"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (t *TemplateGenerator) verifyFiles() error {
 	allFiles := commonTemplateFiles
 	allFiles = append(allFiles, dcosTemplateFiles...)
@@ -210,6 +294,10 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"IsMasterVirtualMachineScaleSets": func() bool {
 			return cs.Properties.MasterProfile != nil && cs.Properties.MasterProfile.IsVirtualMachineScaleSets()
 		},
+		"HasCustomHyperkubeImage": func() bool {
+			k := cs.Properties.OrchestratorProfile.KubernetesConfig
+			return k != nil && k.CustomHyperkubeImage != ""
+		},
 		"IsHostedMaster": func() bool {
 			return cs.Properties.IsHostedMasterProfile()
 		},
@@ -235,6 +323,9 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 			var buf bytes.Buffer
 			buf.WriteString("node-role.kubernetes.io/agent=")
 			buf.WriteString(fmt.Sprintf(",kubernetes.io/role=agent,agentpool=%s", profile.Name))
+			if profile.IsSystemPool() || profile.IsUserPool() || profile.IsDaemonSetPool() {
+				buf.WriteString(fmt.Sprintf(",%s=%s", api.AgentPoolModeLabelKey, profile.Role))
+			}
 			if profile.StorageProfile == api.ManagedDisks {
 				storagetier, _ := getStorageAccountType(profile.VMSize)
 				buf.WriteString(fmt.Sprintf(",storageprofile=managed,storagetier=%s", storagetier))
@@ -249,6 +340,22 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 			}
 			return buf.String()
 		},
+		"HasEtcdStorageLimit": func() bool {
+			// etcd 2.x has no storage backend quota
+			k := cs.Properties.OrchestratorProfile.KubernetesConfig
+			return k.EtcdStorageLimitGB > 0 && !strings.HasPrefix(k.EtcdVersion, "2.")
+		},
+		"GetEtcdStorageLimitBytes": func() int64 {
+			return int64(cs.Properties.OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB) * 1024 * 1024 * 1024
+		},
+		"GetAgentKubernetesTaints": func(profile *api.AgentPoolProfile) string {
+			taints := profile.GetKubernetesTaints()
+			// the startup-taint-remover waits for the Linux-only critical DaemonSets, e.g. kube-proxy
+			if startupTaint := cs.Properties.OrchestratorProfile.KubernetesConfig.GetStartupTaint(); startupTaint != "" && !profile.IsWindows() {
+				taints = append(taints, startupTaint)
+			}
+			return strings.Join(taints, ",")
+		},
 		"GetKubeletConfigKeyVals": func(kc *api.KubernetesConfig) string {
 			if kc == nil {
 				return ""
@@ -326,8 +433,12 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"IsAzureCNI": func() bool {
 			return cs.Properties.OrchestratorProfile.IsAzureCNI()
 		},
+		"IsAzureCNIOverlay": func() bool {
+			return cs.Properties.OrchestratorProfile.IsAzureCNIOverlay()
+		},
 		"RequireRouteTable": func() bool {
-			return cs.Properties.OrchestratorProfile.RequireRouteTable()
+			// the route table also holds the routes of the egress firewall, whatever the network plugin
+			return cs.Properties.OrchestratorProfile.RequireRouteTable() || cs.Properties.OrchestratorProfile.KubernetesConfig.HasEgressFirewall()
 		},
 
 		"IsPrivateCluster": func() bool {
@@ -355,6 +466,11 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"UseManagedIdentity": func() bool {
 			return cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity
 		},
+		"HasServicePrincipalCertificate": func() bool {
+			return !cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity &&
+				cs.Properties.ServicePrincipalProfile != nil &&
+				cs.Properties.ServicePrincipalProfile.Certificate != ""
+		},
 		"UserAssignedIDEnabled": func() bool {
 			if cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity &&
 				cs.Properties.OrchestratorProfile.KubernetesConfig.UserAssignedID != "" {
@@ -420,6 +536,15 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"GetDataDisks": func(profile *api.AgentPoolProfile) string {
 			return getDataDisks(profile)
 		},
+		"GetMasterStorageAccountReference": func() string {
+			if cs.Properties.MasterProfile.HasExistingStorageAccount() {
+				return "parameters('masterStorageAccountID')"
+			}
+			return "concat('Microsoft.Storage/storageAccounts/',variables('masterStorageAccountName'))"
+		},
+		"GetAgentStorageAccountReference": func(profile *api.AgentPoolProfile) string {
+			return getAgentStorageAccountReference(profile)
+		},
 		"HasBootstrap": func() bool {
 			return cs.Properties.OrchestratorProfile.DcosConfig != nil && cs.Properties.OrchestratorProfile.DcosConfig.BootstrapProfile != nil
 		},
@@ -557,6 +682,21 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"Base64": func(s string) string {
 			return base64.StdEncoding.EncodeToString([]byte(s))
 		},
+		"GetWebhookTokenAuthConfigFile": func() (string, error) {
+			config, err := getWebhookTokenAuthConfigFile(cs.Properties.OrchestratorProfile.KubernetesConfig.WebhookTokenAuth)
+			return base64.StdEncoding.EncodeToString([]byte(config)), err
+		},
+		"GetAuditWebhookConfigFile": func() (string, error) {
+			config, err := getAuditWebhookConfigFile(cs.Properties.OrchestratorProfile.KubernetesConfig.AuditWebhook)
+			return base64.StdEncoding.EncodeToString([]byte(config)), err
+		},
+		"GetAdmissionWebhookManifest": func(webhook api.AdmissionWebhook) (string, error) {
+			manifest, err := getAdmissionWebhookManifest(webhook)
+			return base64.StdEncoding.EncodeToString([]byte(manifest)), err
+		},
+		"GetFullyQualifiedImageReference": func(image string) string {
+			return getFullyQualifiedImageReference(image)
+		},
 		"GetDefaultInternalLbStaticIPOffset": func() int {
 			return DefaultInternalLbStaticIPOffset
 		},
@@ -571,6 +711,14 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 				panic(e)
 			}
 
+			// add manifests
+			str = substituteConfigString(str,
+				kubernetesManifestSettingsInitAgent(cs.Properties),
+				"k8s/manifests",
+				"/etc/kubernetes/manifests",
+				"AGENT_MANIFESTS_CONFIG_PLACEHOLDER",
+				cs.Properties.OrchestratorProfile.OrchestratorVersion)
+
 			// add artifacts
 			str = substituteConfigString(str,
 				kubernetesArtifactSettingsInitAgent(cs.Properties),
@@ -615,9 +763,6 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"GetKubernetesB64CustomSearchDomainsScript": func() string {
 			return getBase64CustomScript(kubernetesCustomSearchDomainsScript)
 		},
-		"GetKubernetesB64GenerateProxyCerts": func() string {
-			return getBase64CustomScript(kubernetesMasterGenerateProxyCertsScript)
-		},
 		"GetB64sshdConfig": func() string {
 			return getBase64CustomScript(sshdConfig)
 		},
@@ -786,6 +931,60 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"IsNVIDIADevicePluginEnabled": func() bool {
 			return cs.Properties.IsNVIDIADevicePluginEnabled()
 		},
+		"IsVeleroEnabled": func() bool {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.IsVeleroEnabled()
+		},
+		"GetCgroupDriver": func() string {
+			if k := cs.Properties.OrchestratorProfile.KubernetesConfig; k != nil && k.CgroupDriver != "" {
+				return k.CgroupDriver
+			}
+			return api.DefaultCgroupDriver
+		},
+		"GetContainerLogMaxSize": func() string {
+			if k := cs.Properties.OrchestratorProfile.KubernetesConfig; k != nil && k.ContainerLogMaxSize != "" {
+				return k.ContainerLogMaxSize
+			}
+			return api.DefaultContainerLogMaxSize
+		},
+		"HasRegistryMirrors": func() bool {
+			k := cs.Properties.OrchestratorProfile.KubernetesConfig
+			return k != nil && len(k.RegistryMirrors) > 0
+		},
+		"GetRegistryMirrors": func() string {
+			if k := cs.Properties.OrchestratorProfile.KubernetesConfig; k != nil {
+				return strings.Join(k.RegistryMirrors, ",")
+			}
+			return ""
+		},
+		"GetRegistryMirrorsJSON": func() string {
+			if k := cs.Properties.OrchestratorProfile.KubernetesConfig; k != nil && len(k.RegistryMirrors) > 0 {
+				b, _ := json.Marshal(k.RegistryMirrors)
+				return string(b)
+			}
+			return "[]"
+		},
+		"GetCloudProviderConfig": func() string {
+			k := cs.Properties.OrchestratorProfile.KubernetesConfig
+			if k == nil || len(k.CloudProviderConfig) == 0 {
+				return ""
+			}
+			config := map[string]interface{}{}
+			for key, val := range k.CloudProviderConfig {
+				v, err := common.ParseCloudProviderConfigValue(key, val)
+				if err != nil {
+					continue
+				}
+				config[key] = v
+			}
+			b, _ := json.Marshal(config)
+			return base64.StdEncoding.EncodeToString(b)
+		},
+		"GetContainerLogMaxFiles": func() int {
+			if k := cs.Properties.OrchestratorProfile.KubernetesConfig; k != nil && k.ContainerLogMaxFiles != 0 {
+				return k.ContainerLogMaxFiles
+			}
+			return api.DefaultContainerLogMaxFiles
+		},
 		"IsNSeriesSKU": func(profile *api.AgentPoolProfile) bool {
 			return common.IsNvidiaEnabledSKU(profile.VMSize)
 		},
@@ -795,6 +994,20 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"HasAvailabilityZones": func(profile *api.AgentPoolProfile) bool {
 			return profile.HasAvailabilityZones()
 		},
+		"GetSSHDisabledAgentPoolSubnetPrefixes": func() string {
+			// the address prefixes of the custom VNET subnets of the agent pools with SSH disabled,
+			// a subnet shared by several such pools being listed once
+			subnets := map[string]bool{}
+			var prefixes []string
+			for _, profile := range cs.Properties.AgentPoolProfiles {
+				if !profile.DisableSSH || subnets[strings.ToLower(profile.VnetSubnetID)] {
+					continue
+				}
+				subnets[strings.ToLower(profile.VnetSubnetID)] = true
+				prefixes = append(prefixes, fmt.Sprintf(`"[reference(parameters('%sVnetSubnetID'), variables('apiVersionNetwork')).addressPrefix]"`, profile.Name))
+			}
+			return strings.Join(prefixes, ",\n")
+		},
 		"HasLinuxSecrets": func() bool {
 			return cs.Properties.LinuxProfile.HasSecrets()
 		},
@@ -850,7 +1063,10 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		},
 		"UseAgentCustomImage": func(profile *api.AgentPoolProfile) bool {
 			imageRef := profile.ImageRef
-			return imageRef != nil && len(imageRef.Name) > 0 && len(imageRef.ResourceGroup) > 0
+			return imageRef != nil && (len(imageRef.ID) > 0 || (len(imageRef.Name) > 0 && len(imageRef.ResourceGroup) > 0))
+		},
+		"UseAgentGalleryImage": func(profile *api.AgentPoolProfile) bool {
+			return profile.ImageRef != nil && len(profile.ImageRef.ID) > 0
 		},
 		"UseMasterCustomImage": func() bool {
 			imageRef := cs.Properties.MasterProfile.ImageRef
@@ -886,6 +1102,30 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"AdminGroupID": func() bool {
 			return cs.Properties.AADProfile != nil && cs.Properties.AADProfile.AdminGroupID != ""
 		},
+		"IsBootstrapTokenEnabled": func() bool {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.IsBootstrapTokenEnabled()
+		},
+		"GetAPIServerSecurePort": func() int {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.GetAPIServerSecurePort()
+		},
+		"HasCustomAPIServerSecurePort": func() bool {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.HasCustomAPIServerSecurePort()
+		},
+		"IsKubeletCertRotationEnabled": func() bool {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.IsKubeletCertRotationEnabled()
+		},
+		"IsKubeProxyStaticPod": func() bool {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.IsKubeProxyStaticPod()
+		},
+		"GetStartupTaintKey": func() string {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.GetStartupTaintKey()
+		},
+		"IsIPv6DualStackEnabled": func() bool {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.IsIPv6DualStackEnabled()
+		},
+		"IsPrometheusMetricsEnabled": func() bool {
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.IsPrometheusMetricsEnabled()
+		},
 		"EnableDataEncryptionAtRest": func() bool {
 			return helpers.IsTrueBoolPointer(cs.Properties.OrchestratorProfile.KubernetesConfig.EnableDataEncryptionAtRest)
 		},
@@ -893,12 +1133,7 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 			return helpers.IsTrueBoolPointer(cs.Properties.OrchestratorProfile.KubernetesConfig.EnableEncryptionWithExternalKms)
 		},
 		"EnableAggregatedAPIs": func() bool {
-			if cs.Properties.OrchestratorProfile.KubernetesConfig.EnableAggregatedAPIs {
-				return true
-			} else if common.IsKubernetesVersionGe(cs.Properties.OrchestratorProfile.OrchestratorVersion, "1.9.0") {
-				return true
-			}
-			return false
+			return cs.Properties.OrchestratorProfile.KubernetesConfig.EnableAggregatedAPIs
 		},
 		"EnablePodSecurityPolicy": func() bool {
 			return helpers.IsTrueBoolPointer(cs.Properties.OrchestratorProfile.KubernetesConfig.EnablePodSecurityPolicy)
@@ -42,11 +42,16 @@ const (
 	vmExtensionType  = "Microsoft.Compute/virtualMachines/extensions"
 	nicResourceType  = "Microsoft.Network/networkInterfaces"
 	vnetResourceType = "Microsoft.Network/virtualNetworks"
+	lbResourceType   = "Microsoft.Network/loadBalancers"
 
 	// resource ids
 	nsgID  = "nsgID"
 	rtID   = "routeTableID"
 	vnetID = "vnetID"
+
+	// serviceInternalLbName names the internal load balancer of the LoadBalancer services, whose
+	// frontends are added by the cloud provider and would be dropped by redeploying it
+	serviceInternalLbName = "variables('serviceInternalLbName')"
 )
 
 // Translator defines all required interfaces for i18n.Translator.
@@ -272,6 +277,9 @@ func (t *Transformer) NormalizeMasterResourcesForScaling(logger *logrus.Entry, t
 			if strings.Contains(resourceName, "variables('masterVMNamePrefix')") && resourceType == vmExtensionType {
 				indexesToRemove = append(indexesToRemove, index)
 			}
+			if strings.Contains(resourceName, serviceInternalLbName) && resourceType == lbResourceType {
+				indexesToRemove = append(indexesToRemove, index)
+			}
 			continue
 		}
 
@@ -371,6 +379,14 @@ func (t *Transformer) NormalizeResourcesForK8sMasterUpgrade(logger *logrus.Entry
 			continue
 		}
 
+		if resourceType == lbResourceType {
+			if resourceName, _ := resourceMap[nameFieldName].(string); strings.Contains(resourceName, serviceInternalLbName) {
+				logger.Infoln(fmt.Sprintf("Removing load balancer: %s from template", resourceName))
+				filteredResources = filteredResources[:len(filteredResources)-1]
+			}
+			continue
+		}
+
 		if !(resourceType == vmResourceType || resourceType == vmExtensionType || resourceType == nicResourceType) {
 			continue
 		}
@@ -154,6 +154,40 @@ func TestNormalizeResourcesForK8sMasterUpgrade(t *testing.T) {
 	ValidateTemplate(templateMap, expectedFileContents, "TestNormalizeResourcesForK8sMasterUpgrade")
 }
 
+func TestNormalizeServiceInternalLB(t *testing.T) {
+	RegisterTestingT(t)
+	logger := logrus.New().WithField("testName", "TestNormalizeServiceInternalLB")
+	templateMap := func() map[string]interface{} {
+		return map[string]interface{}{
+			"resources": []interface{}{
+				map[string]interface{}{
+					"name": "[variables('masterLbName')]",
+					"type": "Microsoft.Network/loadBalancers",
+				},
+				map[string]interface{}{
+					"name": "[variables('serviceInternalLbName')]",
+					"type": "Microsoft.Network/loadBalancers",
+				},
+			},
+		}
+	}
+	expectedResources := []interface{}{
+		map[string]interface{}{
+			"name": "[variables('masterLbName')]",
+			"type": "Microsoft.Network/loadBalancers",
+		},
+	}
+	transformer := &Transformer{}
+
+	scaled := templateMap()
+	Expect(transformer.NormalizeMasterResourcesForScaling(logger, scaled)).To(Succeed())
+	Expect(scaled["resources"]).To(Equal(expectedResources))
+
+	upgraded := templateMap()
+	Expect(transformer.NormalizeResourcesForK8sMasterUpgrade(logger, upgraded, false, nil)).To(Succeed())
+	Expect(upgraded["resources"]).To(Equal(expectedResources))
+}
+
 func TestNormalizeResourcesForK8sAgentUpgrade(t *testing.T) {
 	RegisterTestingT(t)
 	logger := logrus.New().WithField("testName", "TestNormalizeResourcesForK8sAgentUpgrade")
@@ -5,6 +5,7 @@ package api
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/Azure/acs-engine/pkg/api/common"
 	"github.com/Azure/acs-engine/pkg/helpers"
@@ -68,11 +69,22 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 				MemoryRequests: "300Mi",
 				CPULimits:      "100m",
 				MemoryLimits:   "300Mi",
-				Image:          specConfig.KubernetesImageBase + k8sComponents[DefaultClusterAutoscalerAddonName],
+				Image:          getDefaultAddonImage(specConfig.KubernetesImageBase, k8sComponents[DefaultClusterAutoscalerAddonName]),
 			},
 		},
 	}
 
+	// the cluster autoscaler scales the first agent pool, within its min and max counts
+	if len(cs.Properties.AgentPoolProfiles) > 0 {
+		primaryPool := cs.Properties.AgentPoolProfiles[0]
+		if primaryPool.MinCount != nil {
+			defaultClusterAutoscalerAddonsConfig.Config["min-nodes"] = strconv.Itoa(*primaryPool.MinCount)
+		}
+		if primaryPool.MaxCount != nil {
+			defaultClusterAutoscalerAddonsConfig.Config["max-nodes"] = strconv.Itoa(*primaryPool.MaxCount)
+		}
+	}
+
 	defaultBlobfuseFlexVolumeAddonsConfig := KubernetesAddon{
 		Name:    DefaultBlobfuseFlexVolumeAddonName,
 		Enabled: helpers.PointerToBool(common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.8.0") && DefaultBlobfuseFlexVolumeAddonEnabled),
@@ -148,15 +160,117 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		},
 	}
 
+	defaultGatekeeperAddonsConfig := KubernetesAddon{
+		Name:    DefaultGatekeeperAddonName,
+		Enabled: helpers.PointerToBool(DefaultGatekeeperAddonEnabled),
+		Config: map[string]string{
+			"replicas":      strconv.Itoa(DefaultGatekeeperReplicas),
+			"auditInterval": strconv.Itoa(DefaultGatekeeperAuditInterval),
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           DefaultGatekeeperAddonName,
+				CPURequests:    "100m",
+				MemoryRequests: "256Mi",
+				CPULimits:      "1000m",
+				MemoryLimits:   "512Mi",
+				Image:          "quay.io/open-policy-agent/gatekeeper:v3.1.0-beta.2",
+			},
+		},
+	}
+
+	defaultNodeProblemDetectorAddonsConfig := KubernetesAddon{
+		Name:    DefaultNodeProblemDetectorAddonName,
+		Enabled: helpers.PointerToBool(DefaultNodeProblemDetectorAddonEnabled),
+		Config: map[string]string{
+			"monitors": DefaultNodeProblemDetectorMonitors,
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           DefaultNodeProblemDetectorAddonName,
+				CPURequests:    "20m",
+				MemoryRequests: "20Mi",
+				CPULimits:      "200m",
+				MemoryLimits:   "100Mi",
+				Image:          specConfig.KubernetesImageBase + "node-problem-detector:v0.6.3",
+			},
+		},
+	}
+
+	defaultStartupTaintRemoverAddonsConfig := KubernetesAddon{
+		Name:    DefaultStartupTaintRemoverAddonName,
+		Enabled: helpers.PointerToBool(DefaultStartupTaintRemoverAddonEnabled),
+		Config: map[string]string{
+			"taint":      DefaultStartupTaint,
+			"daemonSets": getStartupTaintDaemonSets(o),
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           DefaultStartupTaintRemoverAddonName,
+				CPURequests:    "10m",
+				MemoryRequests: "20Mi",
+				CPULimits:      "50m",
+				MemoryLimits:   "50Mi",
+				Image:          getHyperkubeImage(o, specConfig.KubernetesImageBase, k8sComponents),
+			},
+		},
+	}
+
+	defaultAzureDiskCSIDriverAddonsConfig := KubernetesAddon{
+		Name:       DefaultAzureDiskCSIDriverAddonName,
+		Enabled:    helpers.PointerToBool(DefaultAzureDiskCSIDriverAddonEnabled),
+		Containers: getCSIDriverContainers(DefaultAzureDiskCSIDriverAddonName, "mcr.microsoft.com/k8s/csi/azuredisk-csi:v0.3.0"),
+	}
+
+	defaultAzureFileCSIDriverAddonsConfig := KubernetesAddon{
+		Name:       DefaultAzureFileCSIDriverAddonName,
+		Enabled:    helpers.PointerToBool(DefaultAzureFileCSIDriverAddonEnabled),
+		Containers: getCSIDriverContainers(DefaultAzureFileCSIDriverAddonName, "mcr.microsoft.com/k8s/csi/azurefile-csi:v0.3.0"),
+	}
+
+	defaultVeleroAddonsConfig := KubernetesAddon{
+		Name:    DefaultVeleroAddonName,
+		Enabled: helpers.PointerToBool(DefaultVeleroAddonEnabled),
+		Config: map[string]string{
+			"container": "velero",
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           DefaultVeleroAddonName,
+				CPURequests:    "500m",
+				MemoryRequests: "128Mi",
+				CPULimits:      "1",
+				MemoryLimits:   "256Mi",
+				Image:          "velero/velero:v1.2.0",
+			},
+			{
+				Name:           "velero-plugin-for-microsoft-azure",
+				CPURequests:    "50m",
+				MemoryRequests: "50Mi",
+				CPULimits:      "100m",
+				MemoryLimits:   "100Mi",
+				Image:          "velero/velero-plugin-for-microsoft-azure:v1.0.0",
+			},
+		},
+	}
+
 	defaultMetricsServerAddonsConfig := KubernetesAddon{
 		Name:    DefaultMetricsServerAddonName,
 		Enabled: k8sVersionMetricsServerAddonEnabled(o),
 		Containers: []KubernetesContainerSpec{
 			{
-				Name:  DefaultMetricsServerAddonName,
-				Image: specConfig.KubernetesImageBase + k8sComponents[DefaultMetricsServerAddonName],
+				Name:           DefaultMetricsServerAddonName,
+				CPURequests:    "50m",
+				MemoryRequests: "100Mi",
+				CPULimits:      "100m",
+				MemoryLimits:   "300Mi",
+				Image:          specConfig.KubernetesImageBase + k8sComponents[DefaultMetricsServerAddonName],
 			},
 		},
+		Config: map[string]string{
+			"metric-resolution":    DefaultMetricsServerMetricResolution,
+			"kubelet-insecure-tls": "false",
+		},
 	}
 
 	defaultNVIDIADevicePluginAddonsConfig := KubernetesAddon{
@@ -170,7 +284,7 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 				MemoryRequests: "10Mi",
 				CPULimits:      "50m",
 				MemoryLimits:   "10Mi",
-				Image:          specConfig.NVIDIAImageBase + k8sComponents[NVIDIADevicePluginAddonName],
+				Image:          getDefaultAddonImage(specConfig.NVIDIAImageBase, k8sComponents[NVIDIADevicePluginAddonName]),
 			},
 		},
 	}
@@ -224,6 +338,21 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		},
 	}
 
+	defaultCloudNodeManagerAddonsConfig := KubernetesAddon{
+		Name:    DefaultCloudNodeManagerAddonName,
+		Enabled: cloudNodeManagerAddonEnabled(o),
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           DefaultCloudNodeManagerAddonName,
+				Image:          "mcr.microsoft.com/oss/kubernetes/azure-cloud-node-manager:v0.5.1",
+				CPURequests:    "50m",
+				MemoryRequests: "50Mi",
+				CPULimits:      "2",
+				MemoryLimits:   "512Mi",
+			},
+		},
+	}
+
 	defaultAzureNetworkPolicyAddonsConfig := KubernetesAddon{
 		Name:    AzureNetworkPolicyAddonName,
 		Enabled: azureNetworkPolicyAddonEnabled(o),
@@ -237,6 +366,11 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 	defaultDNSAutoScalerAddonsConfig := KubernetesAddon{
 		Name:    DefaultDNSAutoscalerAddonName,
 		Enabled: helpers.PointerToBool(DefaultDNSAutoscalerAddonEnabled),
+		Config: map[string]string{
+			"coresPerReplica": "256",
+			"nodesPerReplica": "16",
+			"min":             "1",
+		},
 		Containers: []KubernetesContainerSpec{
 			{
 				Name:           DefaultDNSAutoscalerAddonName,
@@ -247,6 +381,23 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		},
 	}
 
+	defaultNodeLocalDNSAddonsConfig := KubernetesAddon{
+		Name:    DefaultNodeLocalDNSAddonName,
+		Enabled: helpers.PointerToBool(DefaultNodeLocalDNSAddonEnabled),
+		Config: map[string]string{
+			"localIP": DefaultNodeLocalDNSLocalIP,
+		},
+		Containers: []KubernetesContainerSpec{
+			{
+				Name:           DefaultNodeLocalDNSAddonName,
+				Image:          specConfig.KubernetesImageBase + "k8s-dns-node-cache:1.15.13",
+				CPURequests:    "25m",
+				MemoryRequests: "5Mi",
+				MemoryLimits:   "30Mi",
+			},
+		},
+	}
+
 	defaultAddons := []KubernetesAddon{
 		defaultTillerAddonsConfig,
 		defaultACIConnectorAddonsConfig,
@@ -256,6 +407,13 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		defaultKeyVaultFlexVolumeAddonsConfig,
 		defaultDashboardAddonsConfig,
 		defaultReschedulerAddonsConfig,
+		defaultGatekeeperAddonsConfig,
+		defaultNodeProblemDetectorAddonsConfig,
+		defaultStartupTaintRemoverAddonsConfig,
+		defaultAzureDiskCSIDriverAddonsConfig,
+		defaultAzureFileCSIDriverAddonsConfig,
+		defaultCloudNodeManagerAddonsConfig,
+		defaultVeleroAddonsConfig,
 		defaultMetricsServerAddonsConfig,
 		defaultNVIDIADevicePluginAddonsConfig,
 		defaultContainerMonitoringAddonsConfig,
@@ -263,6 +421,7 @@ func (cs *ContainerService) setAddonsConfig(isUpdate bool) {
 		defaultAzureNetworkPolicyAddonsConfig,
 		defaultIPMasqAgentAddonsConfig,
 		defaultDNSAutoScalerAddonsConfig,
+		defaultNodeLocalDNSAddonsConfig,
 	}
 	// Add default addons specification, if no user-provided spec exists
 	if o.KubernetesConfig.Addons == nil {
@@ -300,7 +459,13 @@ func assignDefaultAddonVals(addon, defaults KubernetesAddon, isUpdate bool) Kube
 		if c < 0 {
 			addon.Containers = append(addon.Containers, defaults.Containers[i])
 		} else {
-			if addon.Containers[c].Image == "" || isUpdate {
+			// a pinned version keeps the image of the container on its tag through the updates of the cluster
+			if addon.Containers[c].Version != "" {
+				if addon.Containers[c].Image == "" {
+					addon.Containers[c].Image = defaults.Containers[i].Image
+				}
+				addon.Containers[c].Image = getImageWithTag(addon.Containers[c].Image, addon.Containers[c].Version)
+			} else if addon.Containers[c].Image == "" || isUpdate {
 				addon.Containers[c].Image = defaults.Containers[i].Image
 			}
 			if addon.Containers[c].CPURequests == "" {
@@ -328,6 +493,26 @@ func assignDefaultAddonVals(addon, defaults KubernetesAddon, isUpdate bool) Kube
 	return addon
 }
 
+// getImageWithTag returns the container image reference with its tag, or digest, replaced by the given tag
+func getImageWithTag(image, tag string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image + ":" + tag
+}
+
+// getDefaultAddonImage returns the default image of an addon container, or no image
+// when the Kubernetes version does not ship the component
+func getDefaultAddonImage(imageBase, component string) string {
+	if component == "" {
+		return ""
+	}
+	return imageBase + component
+}
+
 func synthesizeAddonsConfig(addons []KubernetesAddon, addon KubernetesAddon, enableIfNil bool, isUpdate bool) {
 	i := getAddonsIndexByName(addons, addon.Name)
 	if i >= 0 {
@@ -341,10 +526,87 @@ func k8sVersionMetricsServerAddonEnabled(o *OrchestratorProfile) *bool {
 	return helpers.PointerToBool(common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.9.0"))
 }
 
+// getStartupTaintDaemonSets returns the DaemonSets, formatted namespace/name, networking the nodes
+// of the cluster, whose pods need to be ready on a node before the startup taint is removed from it
+func getStartupTaintDaemonSets(o *OrchestratorProfile) string {
+	var daemonSets []string
+	if !o.KubernetesConfig.IsKubeProxyStaticPod() {
+		daemonSets = append(daemonSets, "kube-system/kube-proxy")
+	}
+	switch {
+	case o.KubernetesConfig.NetworkPolicy == NetworkPolicyCalico:
+		daemonSets = append(daemonSets, "kube-system/calico-node")
+	case o.KubernetesConfig.NetworkPolicy == NetworkPolicyCilium:
+		daemonSets = append(daemonSets, "kube-system/cilium")
+	case o.KubernetesConfig.NetworkPlugin == NetworkPluginFlannel:
+		daemonSets = append(daemonSets, "kube-system/kube-flannel-ds")
+	}
+	return strings.Join(daemonSets, ",")
+}
+
+// getCSIDriverContainers returns the containers of a CSI driver addon: the driver, run by the controller
+// Deployment and the node DaemonSet, and the CSI sidecars of the Kubernetes storage SIG
+func getCSIDriverContainers(driverName, driverImage string) []KubernetesContainerSpec {
+	return []KubernetesContainerSpec{
+		{
+			Name:           driverName,
+			CPURequests:    "10m",
+			MemoryRequests: "20Mi",
+			CPULimits:      "200m",
+			MemoryLimits:   "200Mi",
+			Image:          driverImage,
+		},
+		{
+			Name:           "csi-provisioner",
+			CPURequests:    "10m",
+			MemoryRequests: "20Mi",
+			CPULimits:      "100m",
+			MemoryLimits:   "100Mi",
+			Image:          "quay.io/k8scsi/csi-provisioner:v1.0.1",
+		},
+		{
+			Name:           "csi-attacher",
+			CPURequests:    "10m",
+			MemoryRequests: "20Mi",
+			CPULimits:      "100m",
+			MemoryLimits:   "100Mi",
+			Image:          "quay.io/k8scsi/csi-attacher:v1.0.1",
+		},
+		{
+			Name:           "csi-node-driver-registrar",
+			CPURequests:    "10m",
+			MemoryRequests: "20Mi",
+			CPULimits:      "100m",
+			MemoryLimits:   "100Mi",
+			Image:          "quay.io/k8scsi/csi-node-driver-registrar:v1.1.0",
+		},
+		{
+			Name:           "livenessprobe",
+			CPURequests:    "10m",
+			MemoryRequests: "20Mi",
+			CPULimits:      "100m",
+			MemoryLimits:   "100Mi",
+			Image:          "quay.io/k8scsi/livenessprobe:v1.1.0",
+		},
+	}
+}
+
+// getHyperkubeImage returns the hyperkube image of the cluster, which also provides kubectl
+func getHyperkubeImage(o *OrchestratorProfile, kubernetesImageBase string, k8sComponents map[string]string) string {
+	if o.KubernetesConfig.CustomHyperkubeImage != "" {
+		return o.KubernetesConfig.CustomHyperkubeImage
+	}
+	return kubernetesImageBase + k8sComponents["hyperkube"]
+}
+
 func azureNetworkPolicyAddonEnabled(o *OrchestratorProfile) *bool {
 	return helpers.PointerToBool(o.KubernetesConfig.NetworkPlugin == NetworkPluginAzure && o.KubernetesConfig.NetworkPolicy == NetworkPolicyAzure)
 }
 
+func cloudNodeManagerAddonEnabled(o *OrchestratorProfile) *bool {
+	return helpers.PointerToBool(helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager))
+}
+
 func azureCNINetworkMonitorAddonEnabled(o *OrchestratorProfile) *bool {
 	return helpers.PointerToBool(o.IsAzureCNI())
 }
@@ -126,3 +126,35 @@ func GetAllSupportedSwarmVersions() []string {
 func GetAllSupportedDockerCEVersions() []string {
 	return []string{DockerCEVersion}
 }
+
+// the kinds of the azure.json values settable through cloudProviderConfig
+const (
+	// CloudProviderConfigString is a JSON string value
+	CloudProviderConfigString = "string"
+	// CloudProviderConfigBool is a JSON boolean value
+	CloudProviderConfigBool = "bool"
+	// CloudProviderConfigInt is a JSON integer value
+	CloudProviderConfigInt = "int"
+	// CloudProviderConfigFloat is a JSON number value
+	CloudProviderConfigFloat = "float"
+)
+
+// CloudProviderConfigKeys maps the azure.json keys that can be set through cloudProviderConfig to the kind of their value
+var CloudProviderConfigKeys = map[string]string{
+	"cloudProviderBackoff":              CloudProviderConfigBool,
+	"cloudProviderBackoffRetries":       CloudProviderConfigInt,
+	"cloudProviderBackoffExponent":      CloudProviderConfigFloat,
+	"cloudProviderBackoffDuration":      CloudProviderConfigInt,
+	"cloudProviderBackoffJitter":        CloudProviderConfigFloat,
+	"cloudProviderRatelimit":            CloudProviderConfigBool,
+	"cloudProviderRateLimitQPS":         CloudProviderConfigFloat,
+	"cloudProviderRateLimitBucket":      CloudProviderConfigInt,
+	"cloudProviderRateLimitQPSWrite":    CloudProviderConfigFloat,
+	"cloudProviderRateLimitBucketWrite": CloudProviderConfigInt,
+	"loadBalancerResourceGroup":         CloudProviderConfigString,
+	"routeTableResourceGroup":           CloudProviderConfigString,
+	"maximumLoadBalancerRuleCount":      CloudProviderConfigInt,
+	"useInstanceMetadata":               CloudProviderConfigBool,
+	"excludeMasterFromStandardLB":       CloudProviderConfigBool,
+	"disableOutboundSNAT":               CloudProviderConfigBool,
+}
@@ -5,6 +5,7 @@ package common
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -69,6 +70,22 @@ func ValidateDNSPrefix(dnsName string) error {
 	return nil
 }
 
+// ParseCloudProviderConfigValue returns the azure.json value of a cloudProviderConfig key,
+// typed after the kind the key has in CloudProviderConfigKeys
+func ParseCloudProviderConfigValue(key, value string) (interface{}, error) {
+	switch CloudProviderConfigKeys[key] {
+	case CloudProviderConfigString:
+		return value, nil
+	case CloudProviderConfigBool:
+		return strconv.ParseBool(value)
+	case CloudProviderConfigInt:
+		return strconv.Atoi(value)
+	case CloudProviderConfigFloat:
+		return strconv.ParseFloat(value, 64)
+	}
+	return nil, errors.Errorf("unknown cloudProviderConfig key %s", key)
+}
+
 // IsNvidiaEnabledSKU determines if an VM SKU has nvidia driver support
 func IsNvidiaEnabledSKU(vmSize string) bool {
 	/* If a new GPU sku becomes available, add a key to this map, but only if you have a confirmation
@@ -104,6 +104,14 @@ const (
 	ScaleSetEvictionPolicyDelete = "Delete"
 	// ScaleSetEvictionPolicyDeallocate means a Low-priority VM ScaleSet will deallocate, rather than delete, VMs.
 	ScaleSetEvictionPolicyDeallocate = "Deallocate"
+	// UpgradePolicyModeManual is the default upgrade policy of the scale sets, whose VMs are only updated to the
+	// latest model of the scale set when they are upgraded one by one
+	UpgradePolicyModeManual = "Manual"
+	// UpgradePolicyModeAutomatic means all the VMs of a scale set are updated at once when its model changes
+	UpgradePolicyModeAutomatic = "Automatic"
+	// UpgradePolicyModeRolling means the VMs of a scale set are updated in batches when its model changes, after
+	// the VMs of the previous batch are healthy again
+	UpgradePolicyModeRolling = "Rolling"
 )
 
 // storage profiles
@@ -136,6 +144,22 @@ const (
 	DefaultDashboardAddonEnabled = true
 	// DefaultReschedulerAddonEnabled determines the acs-engine provided default for enabling kubernetes-rescheduler addon
 	DefaultReschedulerAddonEnabled = false
+	// DefaultGatekeeperAddonEnabled determines the acs-engine provided default for enabling the OPA Gatekeeper addon
+	DefaultGatekeeperAddonEnabled = false
+	// DefaultGatekeeperReplicas is the default number of Gatekeeper controller replicas
+	DefaultGatekeeperReplicas = 1
+	// DefaultGatekeeperAuditInterval is the default interval, in seconds, between Gatekeeper audit runs
+	DefaultGatekeeperAuditInterval = 60
+	// DefaultNodeProblemDetectorAddonEnabled determines the acs-engine provided default for enabling the node-problem-detector addon
+	DefaultNodeProblemDetectorAddonEnabled = false
+	// DefaultStartupTaintRemoverAddonEnabled determines the acs-engine provided default for enabling the startup-taint-remover addon
+	DefaultStartupTaintRemoverAddonEnabled = false
+	// DefaultAzureDiskCSIDriverAddonEnabled determines the acs-engine provided default for enabling the azuredisk-csi-driver addon
+	DefaultAzureDiskCSIDriverAddonEnabled = false
+	// DefaultAzureFileCSIDriverAddonEnabled determines the acs-engine provided default for enabling the azurefile-csi-driver addon
+	DefaultAzureFileCSIDriverAddonEnabled = false
+	// DefaultVeleroAddonEnabled determines the acs-engine provided default for enabling the velero addon
+	DefaultVeleroAddonEnabled = false
 	// DefaultRBACEnabled determines the acs-engine provided default for enabling kubernetes RBAC
 	DefaultRBACEnabled = true
 	// DefaultUseInstanceMetadata determines the acs-engine provided default for enabling Azure cloudprovider instance metadata service
@@ -146,6 +170,8 @@ const (
 	DefaultExcludeMasterFromStandardLB = true
 	// DefaultSecureKubeletEnabled determines the acs-engine provided default for securing kubelet communications
 	DefaultSecureKubeletEnabled = true
+	// DefaultInsecurePortEnabled determines the acs-engine provided default for serving the unauthenticated apiserver insecure port
+	DefaultInsecurePortEnabled = false
 	// DefaultMetricsServerAddonEnabled determines the acs-engine provided default for enabling kubernetes metrics-server addon
 	DefaultMetricsServerAddonEnabled = false
 	// DefaultNVIDIADevicePluginAddonEnabled determines the acs-engine provided default for enabling NVIDIA Device Plugin
@@ -154,6 +180,8 @@ const (
 	DefaultContainerMonitoringAddonEnabled = false
 	// DefaultDNSAutoscalerAddonEnabled determines the acs-engine provided default for dns-autoscaler addon
 	DefaultDNSAutoscalerAddonEnabled = false
+	// DefaultNodeLocalDNSAddonEnabled determines the acs-engine provided default for enabling the nodelocaldns addon
+	DefaultNodeLocalDNSAddonEnabled = false
 	// IPMasqAgentAddonEnabled enables the ip-masq-agent addon
 	IPMasqAgentAddonEnabled = true
 	// DefaultTillerAddonName is the name of the tiller addon deployment
@@ -174,8 +202,24 @@ const (
 	DefaultDashboardAddonName = "kubernetes-dashboard"
 	// DefaultReschedulerAddonName is the name of the rescheduler addon deployment
 	DefaultReschedulerAddonName = "rescheduler"
+	// DefaultGatekeeperAddonName is the name of the OPA Gatekeeper addon
+	DefaultGatekeeperAddonName = "gatekeeper"
+	// DefaultNodeProblemDetectorAddonName is the name of the node-problem-detector addon daemon set
+	DefaultNodeProblemDetectorAddonName = "node-problem-detector"
+	// DefaultStartupTaintRemoverAddonName is the name of the addon removing the startup taint of the nodes
+	DefaultStartupTaintRemoverAddonName = "startup-taint-remover"
+	// DefaultAzureDiskCSIDriverAddonName is the name of the Azure disk CSI driver addon
+	DefaultAzureDiskCSIDriverAddonName = "azuredisk-csi-driver"
+	// DefaultAzureFileCSIDriverAddonName is the name of the Azure file CSI driver addon
+	DefaultAzureFileCSIDriverAddonName = "azurefile-csi-driver"
+	// DefaultCloudNodeManagerAddonName is the name of the cloud-node-manager addon daemon set
+	DefaultCloudNodeManagerAddonName = "cloud-node-manager"
+	// DefaultVeleroAddonName is the name of the addon backing up the cluster with Velero
+	DefaultVeleroAddonName = "velero"
 	// DefaultMetricsServerAddonName is the name of the kubernetes metrics server addon deployment
 	DefaultMetricsServerAddonName = "metrics-server"
+	// DefaultMetricsServerMetricResolution is the interval at which metrics-server scrapes metrics from the kubelets
+	DefaultMetricsServerMetricResolution = "60s"
 	// NVIDIADevicePluginAddonName is the name of the NVIDIA device plugin addon deployment
 	NVIDIADevicePluginAddonName = "nvidia-device-plugin"
 	// ContainerMonitoringAddonName is the name of the kubernetes Container Monitoring addon deployment
@@ -192,9 +236,29 @@ const (
 	NetworkPluginKubenet = "kubenet"
 	// NetworkPluginAzure is the string expression for Azure CNI plugin.
 	NetworkPluginAzure = "azure"
+	// NetworkPluginCNI is the string expression for a CNI brought by the user, installed by the manifest of the cni addon
+	NetworkPluginCNI = "cni"
+	// DefaultCNIConfDir is the directory the kubelet reads the CNI configuration from
+	DefaultCNIConfDir = "/etc/cni/net.d"
+	// DefaultCNIBinDir is the directory the kubelet runs the CNI plugins from
+	DefaultCNIBinDir = "/opt/cni/bin"
+	// NetworkPluginModeOverlay is the string expression for the Azure CNI overlay mode, in which pods get their IPs
+	// from the cluster subnet instead of the VNET
+	NetworkPluginModeOverlay = "overlay"
 	// DefaultSinglePlacementGroup determines the acs-engine provided default for supporting large VMSS
 	// (true = single placement group 0-100 VMs, false = multiple placement group 0-1000 VMs)
 	DefaultSinglePlacementGroup = true
+	// DefaultPlatformFaultDomainCount is the number of fault domains of the availability sets of existing clusters
+	// which do not configure it, new clusters use as many fault domains as their region has
+	DefaultPlatformFaultDomainCount = 2
+	// DefaultPlatformUpdateDomainCount is the acs-engine provided default number of update domains of availability sets
+	DefaultPlatformUpdateDomainCount = 3
+	// DefaultMasterLoadBalancerIdleTimeoutInMinutes is the idle timeout of the connections to the API server through its load balancers
+	DefaultMasterLoadBalancerIdleTimeoutInMinutes = 5
+	// DefaultMasterLoadBalancerProbeIntervalInSeconds is the interval of the health probes of the API server load balancers
+	DefaultMasterLoadBalancerProbeIntervalInSeconds = 5
+	// DefaultMasterLoadBalancerProbeThreshold is the number of failed health probes after which a master is taken out of rotation
+	DefaultMasterLoadBalancerProbeThreshold = 2
 	// ARMNetworkNamespace is the ARM-specific namespace for ARM's network providers.
 	ARMNetworkNamespace = "Microsoft.Networks"
 	// ARMVirtualNetworksResourceType is the ARM resource type for virtual network resources of ARM.
@@ -205,6 +269,8 @@ const (
 	DefaultAcceleratedNetworking = true
 	// DefaultDNSAutoscalerAddonName is the name of the dns-autoscaler addon
 	DefaultDNSAutoscalerAddonName = "dns-autoscaler"
+	// DefaultNodeLocalDNSAddonName is the name of the addon caching the DNS queries of the pods on each node
+	DefaultNodeLocalDNSAddonName = "nodelocaldns"
 )
 
 const (
@@ -217,8 +283,75 @@ const (
 	AgentPoolProfileRoleInfra AgentPoolProfileRole = "infra"
 	// AgentPoolProfileRoleMaster is the master role
 	AgentPoolProfileRoleMaster AgentPoolProfileRole = "master"
+	// AgentPoolProfileRoleSystem is the role of pools hosting system addons
+	AgentPoolProfileRoleSystem AgentPoolProfileRole = "system"
+	// AgentPoolProfileRoleUser is the role of pools hosting user workloads
+	AgentPoolProfileRoleUser AgentPoolProfileRole = "user"
+	// AgentPoolProfileRoleDaemonSet is the role of pools reserved for DaemonSet workloads
+	AgentPoolProfileRoleDaemonSet AgentPoolProfileRole = "daemonset"
 )
 
+const (
+	// AgentPoolModeLabelKey is the node label identifying the role of a system, user or daemonset agent pool
+	AgentPoolModeLabelKey = "kubernetes.azure.com/mode"
+	// SystemAgentPoolTaint is registered on the nodes of system agent pools to keep user workloads away
+	SystemAgentPoolTaint = "CriticalAddonsOnly=true:PreferNoSchedule"
+	// DaemonSetAgentPoolTaint is registered on the nodes of daemonset agent pools, only the DaemonSets
+	// tolerating it are scheduled there
+	DaemonSetAgentPoolTaint = "kubernetes.azure.com/mode=daemonset:NoSchedule"
+	// DefaultStartupTaint is the taint registered on the Linux agent nodes by the startup-taint-remover addon,
+	// keeping pods away from a node until the critical DaemonSets are ready on it
+	DefaultStartupTaint = "kubernetes.azure.com/startup=true:NoSchedule"
+)
+
+const (
+	// StorageClassProvisionerAzureDisk is the provisioner of the StorageClasses of Azure disks
+	StorageClassProvisionerAzureDisk = "kubernetes.io/azure-disk"
+	// StorageClassProvisionerAzureFile is the provisioner of the StorageClasses of Azure files
+	StorageClassProvisionerAzureFile = "kubernetes.io/azure-file"
+	// DefaultStorageClassSKU is the default SKU of the default StorageClass of the cluster
+	DefaultStorageClassSKU = "Standard_LRS"
+	// DefaultStorageClassReclaimPolicy is the default reclaim policy of the default StorageClass of the cluster
+	DefaultStorageClassReclaimPolicy = "Delete"
+	// DefaultStorageClassVolumeBindingMode is the default volume binding mode of the default StorageClass of the cluster
+	DefaultStorageClassVolumeBindingMode = "Immediate"
+)
+
+// DefaultNodeProblemDetectorMonitors are the system log monitors run by the node-problem-detector addon,
+// keyed by the name of their file in its config map. They report kernel deadlocks as a node condition,
+// and OOM kills, hung tasks, kernel oopses and corrupt docker images as node events
+const DefaultNodeProblemDetectorMonitors = `{
+  "kernel-monitor.json": {
+    "plugin": "kmsg",
+    "logPath": "/dev/kmsg",
+    "lookback": "5m",
+    "bufferSize": 10,
+    "source": "kernel-monitor",
+    "conditions": [
+      {"type": "KernelDeadlock", "reason": "KernelHasNoDeadlock", "message": "kernel has no deadlock"}
+    ],
+    "rules": [
+      {"type": "temporary", "reason": "OOMKilling", "pattern": "Kill process \\d+ (.+) score \\d+ or sacrifice child\\nKilled process \\d+ (.+) total-vm:\\d+kB, anon-rss:\\d+kB, file-rss:\\d+kB.*"},
+      {"type": "temporary", "reason": "TaskHung", "pattern": "task \\S+:\\w+ blocked for more than \\w+ seconds\\."},
+      {"type": "temporary", "reason": "KernelOops", "pattern": "BUG: unable to handle kernel NULL pointer dereference at .*"},
+      {"type": "permanent", "condition": "KernelDeadlock", "reason": "AUFSUmountHung", "pattern": "task umount\\.aufs:\\w+ blocked for more than \\w+ seconds\\."},
+      {"type": "permanent", "condition": "KernelDeadlock", "reason": "DockerHung", "pattern": "task docker:\\w+ blocked for more than \\w+ seconds\\."}
+    ]
+  },
+  "docker-monitor.json": {
+    "plugin": "journald",
+    "pluginConfig": {"source": "dockerd"},
+    "logPath": "/var/log/journal",
+    "lookback": "5m",
+    "bufferSize": 10,
+    "source": "docker-monitor",
+    "conditions": [],
+    "rules": [
+      {"type": "temporary", "reason": "CorruptDockerImage", "pattern": "Error trying v2 registry: failed to register layer: rename /var/lib/docker/image/(.+) /var/lib/docker/image/(.+): directory not empty.*"}
+    ]
+  }
+}`
+
 const (
 	// VHDDiskSizeAKS maps to the OSDiskSizeGB for AKS VHD image
 	VHDDiskSizeAKS = 30
@@ -296,6 +429,8 @@ const (
 	DefaultKubernetesMaxPodsVNETIntegrated = 30
 	// DefaultKubernetesClusterDomain is the dns suffix used in the cluster (used as a SAN in the PKI generation)
 	DefaultKubernetesClusterDomain = "cluster.local"
+	// DefaultNodeLocalDNSLocalIP is the link-local IP the nodelocaldns addon listens on, and the kubelets hand to the pods as their DNS server
+	DefaultNodeLocalDNSLocalIP = "169.254.20.10"
 	// DefaultInternalLbStaticIPOffset specifies the offset of the internal LoadBalancer's IP
 	// address relative to the first consecutive Kubernetes static IP
 	DefaultInternalLbStaticIPOffset = 10
@@ -315,6 +450,22 @@ const (
 	DefaultNetworkPolicyWindows = ""
 	// DefaultContainerRuntime is docker
 	DefaultContainerRuntime = "docker"
+	// CgroupDriverCgroupfs manages cgroups through the cgroup filesystem
+	CgroupDriverCgroupfs = "cgroupfs"
+	// CgroupDriverSystemd manages cgroups through systemd
+	CgroupDriverSystemd = "systemd"
+	// DefaultCgroupDriver is the cgroup driver of the kubelet and container runtime, cgroupfs being the default of docker and containerd
+	DefaultCgroupDriver = CgroupDriverCgroupfs
+	// KubeProxyDeploymentModeDaemonSet runs kube-proxy as a DaemonSet managed by the addon manager
+	KubeProxyDeploymentModeDaemonSet = "DaemonSet"
+	// KubeProxyDeploymentModeStaticPod runs kube-proxy as a static pod of each Linux node
+	KubeProxyDeploymentModeStaticPod = "StaticPod"
+	// DefaultKubeProxyDeploymentMode is the way kube-proxy is deployed by default
+	DefaultKubeProxyDeploymentMode = KubeProxyDeploymentModeDaemonSet
+	// DefaultContainerLogMaxSize is the size at which the container logs are rotated on the nodes
+	DefaultContainerLogMaxSize = "50Mi"
+	// DefaultContainerLogMaxFiles is the number of log files kept for each container on the nodes
+	DefaultContainerLogMaxFiles = 5
 	// DefaultKubernetesNodeStatusUpdateFrequency is 10s, see --node-status-update-frequency at https://kubernetes.io/docs/admin/kubelet/
 	DefaultKubernetesNodeStatusUpdateFrequency = "10s"
 	// DefaultKubernetesHardEvictionThreshold is memory.available<100Mi,nodefs.available<10%,nodefs.inodesFree<5%, see --eviction-hard at https://kubernetes.io/docs/admin/kubelet/
@@ -349,6 +500,8 @@ const (
 	DefaultEtcdDiskSizeGT10Nodes = "1024"
 	// DefaultEtcdDiskSizeGT20Nodes = size for Kubernetes master etcd disk volumes in GB if > 20 nodes
 	DefaultEtcdDiskSizeGT20Nodes = "2048"
+	// DefaultEtcdStorageLimitGB specifies the default storage backend quota of etcd in GB, the default quota of etcd
+	DefaultEtcdStorageLimitGB = 2
 	// AzureCNINetworkMonitoringAddonName is the name of the Azure CNI networkmonitor addon
 	AzureCNINetworkMonitoringAddonName = "azure-cni-networkmonitor"
 	// AzureNetworkPolicyAddonName is the name of the Azure CNI networkmonitor addon
@@ -365,6 +518,8 @@ const (
 	DefaultJumpboxUsername = "azureuser"
 	// DefaultKubeletPodMaxPIDs specifies the default max pid authorized by pods
 	DefaultKubeletPodMaxPIDs = 100
+	// DefaultKubernetesSwapSizeMB specifies the default size of the swap file on nodes with swap enabled
+	DefaultKubernetesSwapSizeMB = 2048
 	// DefaultKubernetesAgentSubnetVMSS specifies the default subnet for agents when master is VMSS
 	DefaultKubernetesAgentSubnetVMSS = "10.248.0.0/13"
 	// DefaultKubernetesClusterSubnet specifies the default subnet for pods.
@@ -381,10 +536,34 @@ const (
 	DefaultKubernetesMaxPodsAzureCNI = "30"
 	// DefaultKubernetesAPIServerEnableProfiling is the config that enables profiling via web interface host:port/debug/pprof/
 	DefaultKubernetesAPIServerEnableProfiling = "false"
+	// DefaultKubernetesAPIServerSecurePort is the default apiserver --secure-port, exposed by the master load balancers
+	DefaultKubernetesAPIServerSecurePort = 443
+	// DefaultKubernetesAPIServerMaxRequestsInflight is the default apiserver --max-requests-inflight
+	DefaultKubernetesAPIServerMaxRequestsInflight = 400
+	// DefaultKubernetesAPIServerMaxMutatingRequestsInflight is the default apiserver --max-mutating-requests-inflight
+	DefaultKubernetesAPIServerMaxMutatingRequestsInflight = 200
+	// DefaultKubernetesAPIServerInflightNodesPerMasterGT100 = inflight limits multiplier if > 100 nodes per master
+	DefaultKubernetesAPIServerInflightNodesPerMasterGT100 = 2
+	// DefaultKubernetesAPIServerInflightNodesPerMasterGT500 = inflight limits multiplier if > 500 nodes per master
+	DefaultKubernetesAPIServerInflightNodesPerMasterGT500 = 4
 	// DefaultKubernetesCtrMgrEnableProfiling is the config that enables profiling via web interface host:port/debug/pprof/
 	DefaultKubernetesCtrMgrEnableProfiling = "false"
 	// DefaultKubernetesSchedulerEnableProfiling is the config that enables profiling via web interface host:port/debug/pprof/
 	DefaultKubernetesSchedulerEnableProfiling = "false"
+	// DefaultKubeletCertSigningDuration is the validity of the kubelet certificates signed by the controller-manager, the Kubernetes default
+	DefaultKubeletCertSigningDuration = "8760h0m0s"
+	// DefaultKubernetesCtrlMgrSecurePort is the port the controller-manager serves its metrics on when Prometheus scrapes them
+	DefaultKubernetesCtrlMgrSecurePort = "10257"
+	// DefaultKubernetesSchedulerSecurePort is the port the scheduler serves its metrics on when Prometheus scrapes them
+	DefaultKubernetesSchedulerSecurePort = "10259"
+	// DefaultControlPlaneOnlyBootstrapTokenTTL is the default lifetime of the bootstrap token with which nodes join
+	// a cluster generated without agent pools
+	DefaultControlPlaneOnlyBootstrapTokenTTL = "24h"
+	// DefaultFrontProxyCACommonName is the common name of the generated certificate authority of the aggregation layer
+	DefaultFrontProxyCACommonName = "proxyClientCA"
+	// DefaultFrontProxyClientCommonName is the common name of the generated client certificate the API server proxies
+	// the requests to the aggregated API servers with
+	DefaultFrontProxyClientCommonName = "aggregator"
 )
 
 const (
@@ -487,6 +487,19 @@ func convertPropertiesToVLabs(api *Properties, vlabsProps *vlabs.Properties) {
 		vlabsProps.FeatureFlags = &vlabs.FeatureFlags{}
 		convertFeatureFlagsToVLabs(api.FeatureFlags, vlabsProps.FeatureFlags)
 	}
+
+	if api.ExtraParameters != nil {
+		vlabsProps.ExtraParameters = map[string]interface{}{}
+		for key, val := range api.ExtraParameters {
+			vlabsProps.ExtraParameters[key] = val
+		}
+	}
+	if api.ExtraVariables != nil {
+		vlabsProps.ExtraVariables = map[string]interface{}{}
+		for key, val := range api.ExtraVariables {
+			vlabsProps.ExtraVariables[key] = val
+		}
+	}
 }
 
 func convertLinuxProfileToV20160930(api *LinuxProfile, obj *v20160930.LinuxProfile) {
@@ -723,11 +736,38 @@ func convertKubernetesConfigToVLabs(api *KubernetesConfig, vlabs *vlabs.Kubernet
 	vlabs.KubernetesImageBase = api.KubernetesImageBase
 	vlabs.ClusterSubnet = api.ClusterSubnet
 	vlabs.DNSServiceIP = api.DNSServiceIP
+	vlabs.ClusterDomain = api.ClusterDomain
+	vlabs.KubeProxyDeploymentMode = api.KubeProxyDeploymentMode
 	vlabs.ServiceCidr = api.ServiceCIDR
 	vlabs.NetworkPolicy = api.NetworkPolicy
 	vlabs.NetworkPlugin = api.NetworkPlugin
+	vlabs.NetworkPluginMode = api.NetworkPluginMode
 	vlabs.MaxPods = api.MaxPods
+	vlabs.MaxRequestsInflight = api.MaxRequestsInflight
+	vlabs.MaxMutatingRequestsInflight = api.MaxMutatingRequestsInflight
+	vlabs.RequestTimeout = api.RequestTimeout
+	vlabs.MinRequestTimeout = api.MinRequestTimeout
+	vlabs.NodeMonitorGracePeriod = api.NodeMonitorGracePeriod
+	vlabs.NodeMonitorPeriod = api.NodeMonitorPeriod
+	vlabs.PodEvictionTimeout = api.PodEvictionTimeout
+	vlabs.SwapEnabled = api.SwapEnabled
+	vlabs.SwapSizeMB = api.SwapSizeMB
+	vlabs.ReadOnlyRootFilesystem = api.ReadOnlyRootFilesystem
+	vlabs.ResolvConf = api.ResolvConf
+	vlabs.DisableResolvedStub = api.DisableResolvedStub
+	vlabs.CgroupDriver = api.CgroupDriver
+	vlabs.CgroupV2Enabled = api.CgroupV2Enabled
+	vlabs.PodMaxPids = api.PodMaxPids
+	vlabs.PidMax = api.PidMax
+	vlabs.MaxOpenFiles = api.MaxOpenFiles
+	vlabs.PrePulledImages = api.PrePulledImages
+	vlabs.RegistryMirrors = api.RegistryMirrors
+	vlabs.TLSCipherSuites = api.TLSCipherSuites
+	vlabs.TLSMinVersion = api.TLSMinVersion
+	vlabs.ContainerLogMaxSize = api.ContainerLogMaxSize
+	vlabs.ContainerLogMaxFiles = api.ContainerLogMaxFiles
 	vlabs.DockerBridgeSubnet = api.DockerBridgeSubnet
+	vlabs.PodMTU = api.PodMTU
 	vlabs.CloudProviderBackoff = api.CloudProviderBackoff
 	vlabs.CloudProviderBackoffDuration = api.CloudProviderBackoffDuration
 	vlabs.CloudProviderBackoffExponent = api.CloudProviderBackoffExponent
@@ -740,6 +780,7 @@ func convertKubernetesConfigToVLabs(api *KubernetesConfig, vlabs *vlabs.Kubernet
 	vlabs.UserAssignedID = api.UserAssignedID
 	vlabs.UserAssignedClientID = api.UserAssignedClientID
 	vlabs.CustomHyperkubeImage = api.CustomHyperkubeImage
+	vlabs.SchedulerPolicy = api.SchedulerPolicy
 	vlabs.CustomCcmImage = api.CustomCcmImage
 	vlabs.UseCloudControllerManager = api.UseCloudControllerManager
 	vlabs.CustomWindowsPackageURL = api.CustomWindowsPackageURL
@@ -747,17 +788,33 @@ func convertKubernetesConfigToVLabs(api *KubernetesConfig, vlabs *vlabs.Kubernet
 	vlabs.UseInstanceMetadata = api.UseInstanceMetadata
 	vlabs.LoadBalancerSku = api.LoadBalancerSku
 	vlabs.ExcludeMasterFromStandardLB = api.ExcludeMasterFromStandardLB
+	vlabs.ServiceInternalLBSubnetID = api.ServiceInternalLBSubnetID
+	vlabs.NATGatewayID = api.NATGatewayID
+	vlabs.DisableAgentOutbound = api.DisableAgentOutbound
 	vlabs.EnableRbac = api.EnableRbac
 	vlabs.EnableSecureKubelet = api.EnableSecureKubelet
+	vlabs.EnableKubeletCertRotation = api.EnableKubeletCertRotation
+	vlabs.KubeletCertSigningDuration = api.KubeletCertSigningDuration
+	vlabs.EnableInsecurePort = api.EnableInsecurePort
+	vlabs.APIServerAdvertiseAddress = api.APIServerAdvertiseAddress
+	vlabs.APIServerSecurePort = api.APIServerSecurePort
 	vlabs.EnableAggregatedAPIs = api.EnableAggregatedAPIs
 	vlabs.EnableDataEncryptionAtRest = api.EnableDataEncryptionAtRest
 	vlabs.EnableEncryptionWithExternalKms = api.EnableEncryptionWithExternalKms
 	vlabs.EnablePodSecurityPolicy = api.EnablePodSecurityPolicy
+	vlabs.EnablePodPriority = api.EnablePodPriority
+	vlabs.EnablePrometheusMetrics = api.EnablePrometheusMetrics
 	vlabs.GCHighThreshold = api.GCHighThreshold
 	vlabs.GCLowThreshold = api.GCLowThreshold
 	vlabs.EtcdVersion = api.EtcdVersion
 	vlabs.EtcdDiskSizeGB = api.EtcdDiskSizeGB
+	vlabs.EtcdStorageLimitGB = api.EtcdStorageLimitGB
+	vlabs.EtcdCompactionInterval = api.EtcdCompactionInterval
+	vlabs.EtcdDefragSchedule = api.EtcdDefragSchedule
 	vlabs.EtcdEncryptionKey = api.EtcdEncryptionKey
+	vlabs.BootstrapTokenTTL = api.BootstrapTokenTTL
+	vlabs.BootstrapToken = api.BootstrapToken
+	vlabs.BootstrapTokenExpiration = api.BootstrapTokenExpiration
 	vlabs.AzureCNIVersion = api.AzureCNIVersion
 	vlabs.AzureCNIURLLinux = api.AzureCNIURLLinux
 	vlabs.AzureCNIURLWindows = api.AzureCNIURLWindows
@@ -767,8 +824,23 @@ func convertKubernetesConfigToVLabs(api *KubernetesConfig, vlabs *vlabs.Kubernet
 	convertCloudControllerManagerConfigToVlabs(api, vlabs)
 	convertAPIServerConfigToVlabs(api, vlabs)
 	convertSchedulerConfigToVlabs(api, vlabs)
+	convertFeatureGatesToVlabs(api, vlabs)
+	convertWatchCacheSizesToVlabs(api, vlabs)
 	convertPrivateClusterToVlabs(api, vlabs)
+	convertPrivateRegistryToVlabs(api, vlabs)
+	convertEgressFirewallToVlabs(api, vlabs)
+	convertOIDCConfigToVlabs(api, vlabs)
+	convertWebhookTokenAuthToVlabs(api, vlabs)
+	convertAuditWebhookToVlabs(api, vlabs)
+	convertAdmissionWebhooksToVlabs(api, vlabs)
+	convertDefaultStorageClassToVlabs(api, vlabs)
+	vlabs.ServiceAccountIssuer = api.ServiceAccountIssuer
+	vlabs.APIAudiences = api.APIAudiences
+	vlabs.KubeConfigClusterName = api.KubeConfigClusterName
+	vlabs.KubeConfigContextName = api.KubeConfigContextName
+	vlabs.KubeConfigUserName = api.KubeConfigUserName
 	convertPodSecurityPolicyConfigToVlabs(api, vlabs)
+	convertCloudProviderConfigToVlabs(api, vlabs)
 }
 
 func convertKubeletConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
@@ -818,6 +890,24 @@ func convertSchedulerConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfi
 	}
 }
 
+func convertFeatureGatesToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.FeatureGates != nil {
+		v.FeatureGates = map[string]bool{}
+		for gate, enabled := range a.FeatureGates {
+			v.FeatureGates[gate] = enabled
+		}
+	}
+}
+
+func convertWatchCacheSizesToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.WatchCacheSizes != nil {
+		v.WatchCacheSizes = map[string]int{}
+		for resource, size := range a.WatchCacheSizes {
+			v.WatchCacheSizes[resource] = size
+		}
+	}
+}
+
 func convertPodSecurityPolicyConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
 	v.PodSecurityPolicyConfig = map[string]string{}
 	for key, val := range a.PodSecurityPolicyConfig {
@@ -825,6 +915,15 @@ func convertPodSecurityPolicyConfigToVlabs(a *KubernetesConfig, v *vlabs.Kuberne
 	}
 }
 
+func convertCloudProviderConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.CloudProviderConfig != nil {
+		v.CloudProviderConfig = map[string]string{}
+		for key, val := range a.CloudProviderConfig {
+			v.CloudProviderConfig[key] = val
+		}
+	}
+}
+
 func convertPrivateClusterToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
 	if a.PrivateCluster != nil {
 		v.PrivateCluster = &vlabs.PrivateCluster{}
@@ -836,6 +935,84 @@ func convertPrivateClusterToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig
 	}
 }
 
+func convertPrivateRegistryToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.PrivateRegistry != nil {
+		v.PrivateRegistry = &vlabs.PrivateRegistry{
+			Server:   a.PrivateRegistry.Server,
+			Username: a.PrivateRegistry.Username,
+			Password: a.PrivateRegistry.Password,
+		}
+	}
+}
+
+func convertEgressFirewallToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.EgressFirewall != nil {
+		v.EgressFirewall = &vlabs.EgressFirewall{
+			VirtualApplianceIP:    a.EgressFirewall.VirtualApplianceIP,
+			BypassAddressPrefixes: a.EgressFirewall.BypassAddressPrefixes,
+		}
+	}
+}
+
+func convertOIDCConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.OIDCConfig != nil {
+		v.OIDCConfig = &vlabs.OIDCConfig{
+			IssuerURL:      a.OIDCConfig.IssuerURL,
+			ClientID:       a.OIDCConfig.ClientID,
+			UsernameClaim:  a.OIDCConfig.UsernameClaim,
+			UsernamePrefix: a.OIDCConfig.UsernamePrefix,
+			GroupsClaim:    a.OIDCConfig.GroupsClaim,
+			GroupsPrefix:   a.OIDCConfig.GroupsPrefix,
+		}
+	}
+}
+
+func convertWebhookTokenAuthToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.WebhookTokenAuth != nil {
+		v.WebhookTokenAuth = &vlabs.WebhookTokenAuth{
+			URL:           a.WebhookTokenAuth.URL,
+			CACertificate: a.WebhookTokenAuth.CACertificate,
+			CacheTTL:      a.WebhookTokenAuth.CacheTTL,
+		}
+	}
+}
+
+func convertAuditWebhookToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.AuditWebhook != nil {
+		v.AuditWebhook = &vlabs.AuditWebhook{
+			URL:             a.AuditWebhook.URL,
+			CACertificate:   a.AuditWebhook.CACertificate,
+			Mode:            a.AuditWebhook.Mode,
+			BatchBufferSize: a.AuditWebhook.BatchBufferSize,
+			BatchMaxSize:    a.AuditWebhook.BatchMaxSize,
+			BatchMaxWait:    a.AuditWebhook.BatchMaxWait,
+		}
+	}
+}
+
+func convertAdmissionWebhooksToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.AdmissionWebhooks != nil {
+		v.AdmissionWebhooks = []vlabs.AdmissionWebhook{}
+		for _, webhook := range a.AdmissionWebhooks {
+			v.AdmissionWebhooks = append(v.AdmissionWebhooks, vlabs.AdmissionWebhook{
+				Name:     webhook.Name,
+				Manifest: webhook.Manifest,
+			})
+		}
+	}
+}
+
+func convertDefaultStorageClassToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.DefaultStorageClass != nil {
+		v.DefaultStorageClass = &vlabs.StorageClass{
+			Provisioner:       a.DefaultStorageClass.Provisioner,
+			SKU:               a.DefaultStorageClass.SKU,
+			ReclaimPolicy:     a.DefaultStorageClass.ReclaimPolicy,
+			VolumeBindingMode: a.DefaultStorageClass.VolumeBindingMode,
+		}
+	}
+}
+
 func convertPrivateJumpboxProfileToVlabs(api *PrivateJumpboxProfile, vlabsProfile *vlabs.PrivateJumpboxProfile) {
 	vlabsProfile.Name = api.Name
 	vlabsProfile.OSDiskSizeGB = api.OSDiskSizeGB
@@ -858,6 +1035,7 @@ func convertAddonsToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
 			v.Addons[i].Containers = append(v.Addons[i].Containers, vlabs.KubernetesContainerSpec{
 				Name:           a.Addons[i].Containers[j].Name,
 				Image:          a.Addons[i].Containers[j].Image,
+				Version:        a.Addons[i].Containers[j].Version,
 				CPURequests:    a.Addons[i].Containers[j].CPURequests,
 				MemoryRequests: a.Addons[i].Containers[j].MemoryRequests,
 				CPULimits:      a.Addons[i].Containers[j].CPULimits,
@@ -919,6 +1097,8 @@ func convertMasterProfileToVLabs(api *MasterProfile, vlabsProfile *vlabs.MasterP
 	vlabsProfile.SetSubnet(api.Subnet)
 	vlabsProfile.FQDN = api.FQDN
 	vlabsProfile.StorageProfile = api.StorageProfile
+	vlabsProfile.StorageAccountID = api.StorageAccountID
+	vlabsProfile.ProximityPlacementGroupID = api.ProximityPlacementGroupID
 	if api.PreprovisionExtension != nil {
 		vlabsExtension := &vlabs.Extension{}
 		convertExtensionToVLabs(api.PreprovisionExtension, vlabsExtension)
@@ -939,12 +1119,31 @@ func convertMasterProfileToVLabs(api *MasterProfile, vlabsProfile *vlabs.MasterP
 		vlabsProfile.ImageRef = &vlabs.ImageReference{}
 		vlabsProfile.ImageRef.Name = api.ImageRef.Name
 		vlabsProfile.ImageRef.ResourceGroup = api.ImageRef.ResourceGroup
+		vlabsProfile.ImageRef.ID = api.ImageRef.ID
 	}
 	vlabsProfile.AvailabilityProfile = api.AvailabilityProfile
 	vlabsProfile.AgentSubnet = api.AgentSubnet
 	vlabsProfile.AvailabilityZones = api.AvailabilityZones
 	vlabsProfile.SinglePlacementGroup = api.SinglePlacementGroup
+	vlabsProfile.PlatformFaultDomainCount = api.PlatformFaultDomainCount
+	vlabsProfile.PlatformUpdateDomainCount = api.PlatformUpdateDomainCount
+	vlabsProfile.LoadBalancerIdleTimeoutInMinutes = api.LoadBalancerIdleTimeoutInMinutes
+	vlabsProfile.LoadBalancerProbeIntervalInSeconds = api.LoadBalancerProbeIntervalInSeconds
+	vlabsProfile.LoadBalancerProbeThreshold = api.LoadBalancerProbeThreshold
 	convertCustomFilesToVlabs(api, vlabsProfile)
+	convertStaticPodsToVlabs(api, vlabsProfile)
+}
+
+func convertStaticPodsToVlabs(a *MasterProfile, v *vlabs.MasterProfile) {
+	if a.StaticPods != nil {
+		v.StaticPods = []vlabs.StaticPod{}
+		for _, pod := range a.StaticPods {
+			v.StaticPods = append(v.StaticPods, vlabs.StaticPod{
+				Name:     pod.Name,
+				Manifest: pod.Manifest,
+			})
+		}
+	}
 }
 
 func convertKeyVaultSecretsToVlabs(api *KeyVaultSecrets, vlabsSecrets *vlabs.KeyVaultSecrets) {
@@ -1016,6 +1215,15 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.ScaleSetPriority = api.ScaleSetPriority
 	p.ScaleSetEvictionPolicy = api.ScaleSetEvictionPolicy
 	p.StorageProfile = api.StorageProfile
+	p.StorageAccountID = api.StorageAccountID
+	p.ProximityPlacementGroupID = api.ProximityPlacementGroupID
+	p.OverProvision = api.OverProvision
+	p.UpgradePolicyMode = api.UpgradePolicyMode
+	p.CustomNodeTaints = api.CustomNodeTaints
+	p.MinCount = api.MinCount
+	p.MaxCount = api.MaxCount
+	p.DiskEncryptionSetID = api.DiskEncryptionSetID
+	p.ContainerRuntimeDiskSizeGB = api.ContainerRuntimeDiskSizeGB
 	p.DiskSizesGB = []int{}
 	p.DiskSizesGB = append(p.DiskSizesGB, api.DiskSizesGB...)
 	p.VnetSubnetID = api.VnetSubnetID
@@ -1026,11 +1234,22 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.AcceleratedNetworkingEnabledWindows = api.AcceleratedNetworkingEnabledWindows
 	p.AvailabilityZones = api.AvailabilityZones
 	p.SinglePlacementGroup = api.SinglePlacementGroup
+	p.PlatformFaultDomainCount = api.PlatformFaultDomainCount
+	p.PlatformUpdateDomainCount = api.PlatformUpdateDomainCount
+	p.DisableSSH = api.DisableSSH
 
 	for k, v := range api.CustomNodeLabels {
 		p.CustomNodeLabels[k] = v
 	}
 
+	if api.Sysctls != nil {
+		p.Sysctls = map[string]string{}
+		for k, v := range api.Sysctls {
+			p.Sysctls[k] = v
+		}
+	}
+	p.KernelBootParameters = api.KernelBootParameters
+
 	if api.PreprovisionExtension != nil {
 		vlabsExtension := &vlabs.Extension{}
 		convertExtensionToVLabs(api.PreprovisionExtension, vlabsExtension)
@@ -1052,6 +1271,7 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 		p.ImageRef = &vlabs.ImageReference{}
 		p.ImageRef.Name = api.ImageRef.Name
 		p.ImageRef.ResourceGroup = api.ImageRef.ResourceGroup
+		p.ImageRef.ID = api.ImageRef.ID
 	}
 	p.Role = vlabs.AgentPoolProfileRole(api.Role)
 }
@@ -1148,6 +1368,8 @@ func convertServicePrincipalProfileToV20170701(api *ServicePrincipalProfile, v *
 func convertServicePrincipalProfileToVLabs(api *ServicePrincipalProfile, v *vlabs.ServicePrincipalProfile) {
 	v.ClientID = api.ClientID
 	v.Secret = api.Secret
+	v.Certificate = api.Certificate
+	v.CertificatePassword = api.CertificatePassword
 	v.ObjectID = api.ObjectID
 	if api.KeyvaultSecretRef != nil {
 		v.KeyvaultSecretRef = &vlabs.KeyvaultSecretRef{
@@ -1173,6 +1395,11 @@ func convertCertificateProfileToVLabs(api *CertificateProfile, vlabs *vlabs.Cert
 	vlabs.EtcdClientPrivateKey = api.EtcdClientPrivateKey
 	vlabs.EtcdPeerCertificates = api.EtcdPeerCertificates
 	vlabs.EtcdPeerPrivateKeys = api.EtcdPeerPrivateKeys
+	vlabs.ServiceAccountSigningKey = api.ServiceAccountSigningKey
+	vlabs.FrontProxyCACertificate = api.FrontProxyCACertificate
+	vlabs.FrontProxyCAPrivateKey = api.FrontProxyCAPrivateKey
+	vlabs.FrontProxyClientCertificate = api.FrontProxyClientCertificate
+	vlabs.FrontProxyClientPrivateKey = api.FrontProxyClientPrivateKey
 }
 
 func convertAADProfileToVLabs(api *AADProfile, vlabs *vlabs.AADProfile) {
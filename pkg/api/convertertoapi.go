@@ -407,6 +407,19 @@ func convertVLabsProperties(vlabs *vlabs.Properties, api *Properties, isUpdate b
 		api.FeatureFlags = &FeatureFlags{}
 		convertVLabsFeatureFlags(vlabs.FeatureFlags, api.FeatureFlags)
 	}
+
+	if vlabs.ExtraParameters != nil {
+		api.ExtraParameters = map[string]interface{}{}
+		for key, val := range vlabs.ExtraParameters {
+			api.ExtraParameters[key] = val
+		}
+	}
+	if vlabs.ExtraVariables != nil {
+		api.ExtraVariables = map[string]interface{}{}
+		for key, val := range vlabs.ExtraVariables {
+			api.ExtraVariables[key] = val
+		}
+	}
 }
 
 func convertVLabsAZProfile(vlabs *vlabs.AzProfile, api *AzProfile) {
@@ -691,11 +704,38 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	api.KubernetesImageBase = vlabs.KubernetesImageBase
 	api.ClusterSubnet = vlabs.ClusterSubnet
 	api.DNSServiceIP = vlabs.DNSServiceIP
+	api.ClusterDomain = vlabs.ClusterDomain
+	api.KubeProxyDeploymentMode = vlabs.KubeProxyDeploymentMode
 	api.ServiceCIDR = vlabs.ServiceCidr
 	api.NetworkPlugin = vlabs.NetworkPlugin
+	api.NetworkPluginMode = vlabs.NetworkPluginMode
 	api.ContainerRuntime = vlabs.ContainerRuntime
 	api.MaxPods = vlabs.MaxPods
+	api.MaxRequestsInflight = vlabs.MaxRequestsInflight
+	api.MaxMutatingRequestsInflight = vlabs.MaxMutatingRequestsInflight
+	api.RequestTimeout = vlabs.RequestTimeout
+	api.MinRequestTimeout = vlabs.MinRequestTimeout
+	api.NodeMonitorGracePeriod = vlabs.NodeMonitorGracePeriod
+	api.NodeMonitorPeriod = vlabs.NodeMonitorPeriod
+	api.PodEvictionTimeout = vlabs.PodEvictionTimeout
+	api.SwapEnabled = vlabs.SwapEnabled
+	api.SwapSizeMB = vlabs.SwapSizeMB
+	api.ReadOnlyRootFilesystem = vlabs.ReadOnlyRootFilesystem
+	api.ResolvConf = vlabs.ResolvConf
+	api.DisableResolvedStub = vlabs.DisableResolvedStub
+	api.CgroupDriver = vlabs.CgroupDriver
+	api.CgroupV2Enabled = vlabs.CgroupV2Enabled
+	api.PodMaxPids = vlabs.PodMaxPids
+	api.PidMax = vlabs.PidMax
+	api.MaxOpenFiles = vlabs.MaxOpenFiles
+	api.PrePulledImages = vlabs.PrePulledImages
+	api.RegistryMirrors = vlabs.RegistryMirrors
+	api.TLSCipherSuites = vlabs.TLSCipherSuites
+	api.TLSMinVersion = vlabs.TLSMinVersion
+	api.ContainerLogMaxSize = vlabs.ContainerLogMaxSize
+	api.ContainerLogMaxFiles = vlabs.ContainerLogMaxFiles
 	api.DockerBridgeSubnet = vlabs.DockerBridgeSubnet
+	api.PodMTU = vlabs.PodMTU
 	api.CloudProviderBackoff = vlabs.CloudProviderBackoff
 	api.CloudProviderBackoffDuration = vlabs.CloudProviderBackoffDuration
 	api.CloudProviderBackoffExponent = vlabs.CloudProviderBackoffExponent
@@ -708,6 +748,7 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	api.UserAssignedID = vlabs.UserAssignedID
 	api.UserAssignedClientID = vlabs.UserAssignedClientID
 	api.CustomHyperkubeImage = vlabs.CustomHyperkubeImage
+	api.SchedulerPolicy = vlabs.SchedulerPolicy
 	api.CustomCcmImage = vlabs.CustomCcmImage
 	api.UseCloudControllerManager = vlabs.UseCloudControllerManager
 	api.CustomWindowsPackageURL = vlabs.CustomWindowsPackageURL
@@ -715,17 +756,33 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	api.UseInstanceMetadata = vlabs.UseInstanceMetadata
 	api.LoadBalancerSku = vlabs.LoadBalancerSku
 	api.ExcludeMasterFromStandardLB = vlabs.ExcludeMasterFromStandardLB
+	api.ServiceInternalLBSubnetID = vlabs.ServiceInternalLBSubnetID
+	api.NATGatewayID = vlabs.NATGatewayID
+	api.DisableAgentOutbound = vlabs.DisableAgentOutbound
 	api.EnableRbac = vlabs.EnableRbac
 	api.EnableSecureKubelet = vlabs.EnableSecureKubelet
+	api.EnableKubeletCertRotation = vlabs.EnableKubeletCertRotation
+	api.KubeletCertSigningDuration = vlabs.KubeletCertSigningDuration
+	api.EnableInsecurePort = vlabs.EnableInsecurePort
+	api.APIServerAdvertiseAddress = vlabs.APIServerAdvertiseAddress
+	api.APIServerSecurePort = vlabs.APIServerSecurePort
 	api.EnableAggregatedAPIs = vlabs.EnableAggregatedAPIs
 	api.EnableDataEncryptionAtRest = vlabs.EnableDataEncryptionAtRest
 	api.EnableEncryptionWithExternalKms = vlabs.EnableEncryptionWithExternalKms
 	api.EnablePodSecurityPolicy = vlabs.EnablePodSecurityPolicy
+	api.EnablePodPriority = vlabs.EnablePodPriority
+	api.EnablePrometheusMetrics = vlabs.EnablePrometheusMetrics
 	api.GCHighThreshold = vlabs.GCHighThreshold
 	api.GCLowThreshold = vlabs.GCLowThreshold
 	api.EtcdVersion = vlabs.EtcdVersion
 	api.EtcdDiskSizeGB = vlabs.EtcdDiskSizeGB
+	api.EtcdStorageLimitGB = vlabs.EtcdStorageLimitGB
+	api.EtcdCompactionInterval = vlabs.EtcdCompactionInterval
+	api.EtcdDefragSchedule = vlabs.EtcdDefragSchedule
 	api.EtcdEncryptionKey = vlabs.EtcdEncryptionKey
+	api.BootstrapTokenTTL = vlabs.BootstrapTokenTTL
+	api.BootstrapToken = vlabs.BootstrapToken
+	api.BootstrapTokenExpiration = vlabs.BootstrapTokenExpiration
 	api.AzureCNIVersion = vlabs.AzureCNIVersion
 	api.AzureCNIURLLinux = vlabs.AzureCNIURLLinux
 	api.AzureCNIURLWindows = vlabs.AzureCNIURLWindows
@@ -735,8 +792,23 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	convertCloudControllerManagerConfigToAPI(vlabs, api)
 	convertAPIServerConfigToAPI(vlabs, api)
 	convertSchedulerConfigToAPI(vlabs, api)
+	convertFeatureGatesToAPI(vlabs, api)
+	convertWatchCacheSizesToAPI(vlabs, api)
 	convertPrivateClusterToAPI(vlabs, api)
+	convertPrivateRegistryToAPI(vlabs, api)
+	convertEgressFirewallToAPI(vlabs, api)
+	convertOIDCConfigToAPI(vlabs, api)
+	convertWebhookTokenAuthToAPI(vlabs, api)
+	convertAuditWebhookToAPI(vlabs, api)
+	convertAdmissionWebhooksToAPI(vlabs, api)
+	convertDefaultStorageClassToAPI(vlabs, api)
+	api.ServiceAccountIssuer = vlabs.ServiceAccountIssuer
+	api.APIAudiences = vlabs.APIAudiences
+	api.KubeConfigClusterName = vlabs.KubeConfigClusterName
+	api.KubeConfigContextName = vlabs.KubeConfigContextName
+	api.KubeConfigUserName = vlabs.KubeConfigUserName
 	convertPodSecurityPolicyConfigToAPI(vlabs, api)
+	convertCloudProviderConfigToAPI(vlabs, api)
 }
 
 func setVlabsKubernetesDefaults(vp *vlabs.Properties, api *OrchestratorProfile) {
@@ -780,6 +852,7 @@ func convertAddonsToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
 			a.Addons[i].Containers = append(a.Addons[i].Containers, KubernetesContainerSpec{
 				Name:           v.Addons[i].Containers[j].Name,
 				Image:          v.Addons[i].Containers[j].Image,
+				Version:        v.Addons[i].Containers[j].Version,
 				CPURequests:    v.Addons[i].Containers[j].CPURequests,
 				MemoryRequests: v.Addons[i].Containers[j].MemoryRequests,
 				CPULimits:      v.Addons[i].Containers[j].CPULimits,
@@ -842,6 +915,24 @@ func convertSchedulerConfigToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig)
 	}
 }
 
+func convertFeatureGatesToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.FeatureGates != nil {
+		a.FeatureGates = map[string]bool{}
+		for gate, enabled := range v.FeatureGates {
+			a.FeatureGates[gate] = enabled
+		}
+	}
+}
+
+func convertWatchCacheSizesToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.WatchCacheSizes != nil {
+		a.WatchCacheSizes = map[string]int{}
+		for resource, size := range v.WatchCacheSizes {
+			a.WatchCacheSizes[resource] = size
+		}
+	}
+}
+
 func convertPodSecurityPolicyConfigToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
 	a.PodSecurityPolicyConfig = map[string]string{}
 	for key, val := range v.PodSecurityPolicyConfig {
@@ -849,6 +940,15 @@ func convertPodSecurityPolicyConfigToAPI(v *vlabs.KubernetesConfig, a *Kubernete
 	}
 }
 
+func convertCloudProviderConfigToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.CloudProviderConfig != nil {
+		a.CloudProviderConfig = map[string]string{}
+		for key, val := range v.CloudProviderConfig {
+			a.CloudProviderConfig[key] = val
+		}
+	}
+}
+
 func convertPrivateClusterToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
 	if v.PrivateCluster != nil {
 		a.PrivateCluster = &PrivateCluster{}
@@ -860,6 +960,84 @@ func convertPrivateClusterToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig)
 	}
 }
 
+func convertPrivateRegistryToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.PrivateRegistry != nil {
+		a.PrivateRegistry = &PrivateRegistry{
+			Server:   v.PrivateRegistry.Server,
+			Username: v.PrivateRegistry.Username,
+			Password: v.PrivateRegistry.Password,
+		}
+	}
+}
+
+func convertEgressFirewallToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.EgressFirewall != nil {
+		a.EgressFirewall = &EgressFirewall{
+			VirtualApplianceIP:    v.EgressFirewall.VirtualApplianceIP,
+			BypassAddressPrefixes: v.EgressFirewall.BypassAddressPrefixes,
+		}
+	}
+}
+
+func convertOIDCConfigToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.OIDCConfig != nil {
+		a.OIDCConfig = &OIDCConfig{
+			IssuerURL:      v.OIDCConfig.IssuerURL,
+			ClientID:       v.OIDCConfig.ClientID,
+			UsernameClaim:  v.OIDCConfig.UsernameClaim,
+			UsernamePrefix: v.OIDCConfig.UsernamePrefix,
+			GroupsClaim:    v.OIDCConfig.GroupsClaim,
+			GroupsPrefix:   v.OIDCConfig.GroupsPrefix,
+		}
+	}
+}
+
+func convertWebhookTokenAuthToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.WebhookTokenAuth != nil {
+		a.WebhookTokenAuth = &WebhookTokenAuth{
+			URL:           v.WebhookTokenAuth.URL,
+			CACertificate: v.WebhookTokenAuth.CACertificate,
+			CacheTTL:      v.WebhookTokenAuth.CacheTTL,
+		}
+	}
+}
+
+func convertAdmissionWebhooksToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.AdmissionWebhooks != nil {
+		a.AdmissionWebhooks = []AdmissionWebhook{}
+		for _, webhook := range v.AdmissionWebhooks {
+			a.AdmissionWebhooks = append(a.AdmissionWebhooks, AdmissionWebhook{
+				Name:     webhook.Name,
+				Manifest: webhook.Manifest,
+			})
+		}
+	}
+}
+
+func convertDefaultStorageClassToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.DefaultStorageClass != nil {
+		a.DefaultStorageClass = &StorageClass{
+			Provisioner:       v.DefaultStorageClass.Provisioner,
+			SKU:               v.DefaultStorageClass.SKU,
+			ReclaimPolicy:     v.DefaultStorageClass.ReclaimPolicy,
+			VolumeBindingMode: v.DefaultStorageClass.VolumeBindingMode,
+		}
+	}
+}
+
+func convertAuditWebhookToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.AuditWebhook != nil {
+		a.AuditWebhook = &AuditWebhook{
+			URL:             v.AuditWebhook.URL,
+			CACertificate:   v.AuditWebhook.CACertificate,
+			Mode:            v.AuditWebhook.Mode,
+			BatchBufferSize: v.AuditWebhook.BatchBufferSize,
+			BatchMaxSize:    v.AuditWebhook.BatchMaxSize,
+			BatchMaxWait:    v.AuditWebhook.BatchMaxWait,
+		}
+	}
+}
+
 func convertPrivateJumpboxProfileToAPI(v *vlabs.PrivateJumpboxProfile, a *PrivateJumpboxProfile) {
 	a.Name = v.Name
 	a.OSDiskSizeGB = v.OSDiskSizeGB
@@ -926,6 +1104,8 @@ func convertVLabsMasterProfile(vlabs *vlabs.MasterProfile, api *MasterProfile) {
 	api.IPAddressCount = vlabs.IPAddressCount
 	api.FQDN = vlabs.FQDN
 	api.StorageProfile = vlabs.StorageProfile
+	api.StorageAccountID = vlabs.StorageAccountID
+	api.ProximityPlacementGroupID = vlabs.ProximityPlacementGroupID
 	api.HTTPSourceAddressPrefix = vlabs.HTTPSourceAddressPrefix
 	api.OAuthEnabled = vlabs.OAuthEnabled
 	// by default vlabs will use managed disks as it has encryption at rest
@@ -955,13 +1135,32 @@ func convertVLabsMasterProfile(vlabs *vlabs.MasterProfile, api *MasterProfile) {
 		api.ImageRef = &ImageReference{}
 		api.ImageRef.Name = vlabs.ImageRef.Name
 		api.ImageRef.ResourceGroup = vlabs.ImageRef.ResourceGroup
+		api.ImageRef.ID = vlabs.ImageRef.ID
 	}
 
 	api.AvailabilityProfile = vlabs.AvailabilityProfile
 	api.AgentSubnet = vlabs.AgentSubnet
 	api.AvailabilityZones = vlabs.AvailabilityZones
 	api.SinglePlacementGroup = vlabs.SinglePlacementGroup
+	api.PlatformFaultDomainCount = vlabs.PlatformFaultDomainCount
+	api.PlatformUpdateDomainCount = vlabs.PlatformUpdateDomainCount
+	api.LoadBalancerIdleTimeoutInMinutes = vlabs.LoadBalancerIdleTimeoutInMinutes
+	api.LoadBalancerProbeIntervalInSeconds = vlabs.LoadBalancerProbeIntervalInSeconds
+	api.LoadBalancerProbeThreshold = vlabs.LoadBalancerProbeThreshold
 	convertCustomFilesToAPI(vlabs, api)
+	convertStaticPodsToAPI(vlabs, api)
+}
+
+func convertStaticPodsToAPI(v *vlabs.MasterProfile, a *MasterProfile) {
+	if v.StaticPods != nil {
+		a.StaticPods = []StaticPod{}
+		for _, pod := range v.StaticPods {
+			a.StaticPods = append(a.StaticPods, StaticPod{
+				Name:     pod.Name,
+				Manifest: pod.Manifest,
+			})
+		}
+	}
 }
 
 func convertV20160930AgentPoolProfile(v20160930 *v20160930.AgentPoolProfile, availabilityProfile string, api *AgentPoolProfile) {
@@ -1037,6 +1236,15 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.ScaleSetPriority = vlabs.ScaleSetPriority
 	api.ScaleSetEvictionPolicy = vlabs.ScaleSetEvictionPolicy
 	api.StorageProfile = vlabs.StorageProfile
+	api.StorageAccountID = vlabs.StorageAccountID
+	api.ProximityPlacementGroupID = vlabs.ProximityPlacementGroupID
+	api.OverProvision = vlabs.OverProvision
+	api.UpgradePolicyMode = vlabs.UpgradePolicyMode
+	api.CustomNodeTaints = vlabs.CustomNodeTaints
+	api.MinCount = vlabs.MinCount
+	api.MaxCount = vlabs.MaxCount
+	api.DiskEncryptionSetID = vlabs.DiskEncryptionSetID
+	api.ContainerRuntimeDiskSizeGB = vlabs.ContainerRuntimeDiskSizeGB
 	api.DiskSizesGB = []int{}
 	api.DiskSizesGB = append(api.DiskSizesGB, vlabs.DiskSizesGB...)
 	api.VnetSubnetID = vlabs.VnetSubnetID
@@ -1047,12 +1255,23 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.AcceleratedNetworkingEnabledWindows = vlabs.AcceleratedNetworkingEnabledWindows
 	api.AvailabilityZones = vlabs.AvailabilityZones
 	api.SinglePlacementGroup = vlabs.SinglePlacementGroup
+	api.PlatformFaultDomainCount = vlabs.PlatformFaultDomainCount
+	api.PlatformUpdateDomainCount = vlabs.PlatformUpdateDomainCount
+	api.DisableSSH = vlabs.DisableSSH
 
 	api.CustomNodeLabels = map[string]string{}
 	for k, v := range vlabs.CustomNodeLabels {
 		api.CustomNodeLabels[k] = v
 	}
 
+	if vlabs.Sysctls != nil {
+		api.Sysctls = map[string]string{}
+		for k, v := range vlabs.Sysctls {
+			api.Sysctls[k] = v
+		}
+	}
+	api.KernelBootParameters = vlabs.KernelBootParameters
+
 	if vlabs.PreProvisionExtension != nil {
 		apiExtension := &Extension{}
 		convertVLabsExtension(vlabs.PreProvisionExtension, apiExtension)
@@ -1074,6 +1293,7 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 		api.ImageRef = &ImageReference{}
 		api.ImageRef.Name = vlabs.ImageRef.Name
 		api.ImageRef.ResourceGroup = vlabs.ImageRef.ResourceGroup
+		api.ImageRef.ID = vlabs.ImageRef.ID
 	}
 	api.Role = AgentPoolProfileRole(vlabs.Role)
 }
@@ -1171,6 +1391,8 @@ func convertV20170701ServicePrincipalProfile(v20170701 *v20170701.ServicePrincip
 func convertVLabsServicePrincipalProfile(vlabs *vlabs.ServicePrincipalProfile, api *ServicePrincipalProfile) {
 	api.ClientID = vlabs.ClientID
 	api.Secret = vlabs.Secret
+	api.Certificate = vlabs.Certificate
+	api.CertificatePassword = vlabs.CertificatePassword
 	api.ObjectID = vlabs.ObjectID
 	if vlabs.KeyvaultSecretRef != nil {
 		api.KeyvaultSecretRef = &KeyvaultSecretRef{
@@ -1208,6 +1430,11 @@ func convertVLabsCertificateProfile(vlabs *vlabs.CertificateProfile, api *Certif
 	api.EtcdClientPrivateKey = vlabs.EtcdClientPrivateKey
 	api.EtcdPeerCertificates = vlabs.EtcdPeerCertificates
 	api.EtcdPeerPrivateKeys = vlabs.EtcdPeerPrivateKeys
+	api.ServiceAccountSigningKey = vlabs.ServiceAccountSigningKey
+	api.FrontProxyCACertificate = vlabs.FrontProxyCACertificate
+	api.FrontProxyCAPrivateKey = vlabs.FrontProxyCAPrivateKey
+	api.FrontProxyClientCertificate = vlabs.FrontProxyClientCertificate
+	api.FrontProxyClientPrivateKey = vlabs.FrontProxyClientPrivateKey
 }
 
 func convertVLabsAADProfile(vlabs *vlabs.AADProfile, api *AADProfile) {
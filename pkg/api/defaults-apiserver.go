@@ -4,7 +4,9 @@
 package api
 
 import (
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/Azure/acs-engine/pkg/api/common"
 	"github.com/Azure/acs-engine/pkg/helpers"
@@ -18,8 +20,8 @@ func (cs *ContainerService) setAPIServerConfig() {
 		"--allow-privileged":            "true",
 		"--anonymous-auth":              "false",
 		"--audit-log-path":              "/var/log/kubeaudit/audit.log",
-		"--insecure-port":               "8080",
-		"--secure-port":                 "443",
+		"--insecure-port":               "0",
+		"--secure-port":                 strconv.Itoa(o.KubernetesConfig.GetAPIServerSecurePort()),
 		"--service-account-lookup":      "true",
 		"--etcd-cafile":                 "/etc/kubernetes/certs/ca.crt",
 		"--etcd-certfile":               "/etc/kubernetes/certs/etcdclient.crt",
@@ -46,6 +48,53 @@ func (cs *ContainerService) setAPIServerConfig() {
 		"--profiling":           DefaultKubernetesAPIServerEnableProfiling,
 	}
 
+	// The insecure port serves unauthenticated requests, the masters reach the apiserver on the secure port
+	if helpers.IsTrueBoolPointer(o.KubernetesConfig.EnableInsecurePort) {
+		staticAPIServerConfig["--insecure-port"] = "8080"
+	}
+
+	// The address advertised to the members of the cluster, replacing the address of the master load balancer
+	if o.KubernetesConfig.APIServerAdvertiseAddress != "" {
+		staticAPIServerConfig["--advertise-address"] = o.KubernetesConfig.APIServerAdvertiseAddress
+	}
+
+	// Inflight request limits
+	if o.KubernetesConfig.MaxRequestsInflight > 0 {
+		staticAPIServerConfig["--max-requests-inflight"] = strconv.Itoa(o.KubernetesConfig.MaxRequestsInflight)
+	}
+	if o.KubernetesConfig.MaxMutatingRequestsInflight > 0 {
+		staticAPIServerConfig["--max-mutating-requests-inflight"] = strconv.Itoa(o.KubernetesConfig.MaxMutatingRequestsInflight)
+	}
+
+	// Request timeouts and watch cache sizes, overriding the corresponding apiServerConfig flags
+	if o.KubernetesConfig.RequestTimeout != "" {
+		staticAPIServerConfig["--request-timeout"] = o.KubernetesConfig.RequestTimeout
+	}
+	if o.KubernetesConfig.MinRequestTimeout > 0 {
+		staticAPIServerConfig["--min-request-timeout"] = strconv.Itoa(o.KubernetesConfig.MinRequestTimeout)
+	}
+	if len(o.KubernetesConfig.WatchCacheSizes) > 0 {
+		var sizes []string
+		for resource, size := range o.KubernetesConfig.WatchCacheSizes {
+			sizes = append(sizes, resource+"#"+strconv.Itoa(size))
+		}
+		sort.Strings(sizes)
+		staticAPIServerConfig["--watch-cache-sizes"] = strings.Join(sizes, ",")
+	}
+
+	// The interval of the compactions of the etcd history requested by the apiserver
+	if o.KubernetesConfig.EtcdCompactionInterval != "" {
+		staticAPIServerConfig["--etcd-compaction-interval"] = o.KubernetesConfig.EtcdCompactionInterval
+	}
+
+	// TLS configuration, overriding the corresponding apiServerConfig flags
+	if len(o.KubernetesConfig.TLSCipherSuites) > 0 {
+		staticAPIServerConfig["--tls-cipher-suites"] = strings.Join(o.KubernetesConfig.TLSCipherSuites, ",")
+	}
+	if o.KubernetesConfig.TLSMinVersion != "" {
+		staticAPIServerConfig["--tls-min-version"] = o.KubernetesConfig.TLSMinVersion
+	}
+
 	// Data Encryption at REST configuration conditions
 	if helpers.IsTrueBoolPointer(o.KubernetesConfig.EnableDataEncryptionAtRest) || helpers.IsTrueBoolPointer(o.KubernetesConfig.EnableEncryptionWithExternalKms) {
 		staticAPIServerConfig["--experimental-encryption-provider-config"] = "/etc/kubernetes/encryption-config.yaml"
@@ -62,8 +111,10 @@ func (cs *ContainerService) setAPIServerConfig() {
 		defaultAPIServerConfig["--requestheader-username-headers"] = "X-Remote-User"
 	}
 
-	// Enable cloudprovider if we're not using cloud controller manager
-	if !helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager) {
+	// Enable cloudprovider, or defer to the cloud controller manager
+	if helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager) {
+		staticAPIServerConfig["--cloud-provider"] = "external"
+	} else {
 		staticAPIServerConfig["--cloud-provider"] = "azure"
 		staticAPIServerConfig["--cloud-config"] = "/etc/kubernetes/azure.json"
 	}
@@ -80,6 +131,63 @@ func (cs *ContainerService) setAPIServerConfig() {
 		defaultAPIServerConfig["--oidc-issuer-url"] = "https://" + issuerHost + "/" + cs.Properties.AADProfile.TenantID + "/"
 	}
 
+	// OIDC configuration, overriding the corresponding apiServerConfig flags
+	if oidc := o.KubernetesConfig.OIDCConfig; oidc != nil {
+		for flag, val := range map[string]string{
+			"--oidc-issuer-url":      oidc.IssuerURL,
+			"--oidc-client-id":       oidc.ClientID,
+			"--oidc-username-claim":  oidc.UsernameClaim,
+			"--oidc-username-prefix": oidc.UsernamePrefix,
+			"--oidc-groups-claim":    oidc.GroupsClaim,
+			"--oidc-groups-prefix":   oidc.GroupsPrefix,
+		} {
+			if val != "" {
+				staticAPIServerConfig[flag] = val
+			}
+		}
+	}
+
+	// Webhook token authentication configuration, the webhook kubeconfig is written by the master custom data
+	if webhook := o.KubernetesConfig.WebhookTokenAuth; webhook != nil {
+		staticAPIServerConfig["--authentication-token-webhook-config-file"] = "/etc/kubernetes/webhook-token-auth-config.yaml"
+		if webhook.CacheTTL != "" {
+			staticAPIServerConfig["--authentication-token-webhook-cache-ttl"] = webhook.CacheTTL
+		}
+	}
+
+	// Audit webhook configuration, the webhook kubeconfig is written by the master custom data
+	if webhook := o.KubernetesConfig.AuditWebhook; webhook != nil {
+		staticAPIServerConfig["--audit-webhook-config-file"] = "/etc/kubernetes/audit-webhook-config.yaml"
+		staticAPIServerConfig["--audit-webhook-mode"] = "batch"
+		if webhook.Mode != "" {
+			staticAPIServerConfig["--audit-webhook-mode"] = webhook.Mode
+		}
+		if webhook.BatchBufferSize > 0 {
+			staticAPIServerConfig["--audit-webhook-batch-buffer-size"] = strconv.Itoa(webhook.BatchBufferSize)
+		}
+		if webhook.BatchMaxSize > 0 {
+			staticAPIServerConfig["--audit-webhook-batch-max-size"] = strconv.Itoa(webhook.BatchMaxSize)
+		}
+		if webhook.BatchMaxWait != "" {
+			staticAPIServerConfig["--audit-webhook-batch-max-wait"] = webhook.BatchMaxWait
+		}
+	}
+
+	// Bound service account tokens are signed with their own key, the controller-manager keeps signing the legacy
+	// tokens with the apiserver key, so the API server verifies the tokens with both keys
+	if o.KubernetesConfig.HasServiceAccountIssuer() {
+		staticAPIServerConfig["--service-account-issuer"] = o.KubernetesConfig.ServiceAccountIssuer
+		staticAPIServerConfig["--service-account-signing-key-file"] = "/etc/kubernetes/certs/serviceaccount.key"
+		staticAPIServerConfig["--service-account-key-file"] = "/etc/kubernetes/certs/serviceaccount-verification.key"
+		if len(o.KubernetesConfig.APIAudiences) > 0 {
+			audiencesKey := "--service-account-api-audiences"
+			if common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.13.0") {
+				audiencesKey = "--api-audiences"
+			}
+			staticAPIServerConfig[audiencesKey] = strings.Join(o.KubernetesConfig.APIAudiences, ",")
+		}
+	}
+
 	// Audit Policy configuration
 	if common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.8.0") {
 		defaultAPIServerConfig["--audit-policy-file"] = "/etc/kubernetes/addons/audit-policy.yaml"
@@ -117,6 +225,12 @@ func (cs *ContainerService) setAPIServerConfig() {
 		o.KubernetesConfig.APIServerConfig[key] = val
 	}
 
+	// Allocates the cluster IPs of dual-stack services from the comma separated service CIDRs
+	if o.KubernetesConfig.IsIPv6DualStackEnabled() {
+		addDefaultFeatureGates(o.KubernetesConfig.APIServerConfig, o.OrchestratorVersion, "", "IPv6DualStack=true")
+	}
+	addFeatureGates(o.KubernetesConfig.APIServerConfig, o.KubernetesConfig.FeatureGates)
+
 	// Remove flags for secure communication to kubelet, if configured
 	if !helpers.IsTrueBoolPointer(o.KubernetesConfig.EnableSecureKubelet) {
 		for _, key := range []string{"--kubelet-client-certificate", "--kubelet-client-key"} {
@@ -156,5 +270,10 @@ func getDefaultAdmissionControls(cs *ContainerService) (string, string) {
 		admissionControlValues += ",PodSecurityPolicy"
 	}
 
+	// Pod priority configuration, the Priority admission controller resolves the priority class of the pods
+	if helpers.IsTrueBoolPointer(o.KubernetesConfig.EnablePodPriority) {
+		admissionControlValues += ",Priority"
+	}
+
 	return admissionControlKey, admissionControlValues
 }
@@ -4,6 +4,7 @@
 package api
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Azure/acs-engine/pkg/helpers"
@@ -111,12 +112,12 @@ func TestAPIServerConfigUseCloudControllerManager(t *testing.T) {
 	cs.Properties.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager = helpers.PointerToBool(true)
 	cs.setAPIServerConfig()
 	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
-	if _, ok := a["--cloud-provider"]; ok {
-		t.Fatalf("got unexpected '--cloud-provider' API server config value for UseCloudControllerManager=false: %s",
+	if a["--cloud-provider"] != "external" {
+		t.Fatalf("got unexpected '--cloud-provider' API server config value for UseCloudControllerManager=true: %s",
 			a["--cloud-provider"])
 	}
 	if _, ok := a["--cloud-config"]; ok {
-		t.Fatalf("got unexpected '--cloud-config' API server config value for UseCloudControllerManager=false: %s",
+		t.Fatalf("got unexpected '--cloud-config' API server config value for UseCloudControllerManager=true: %s",
 			a["--cloud-config"])
 	}
 
@@ -126,11 +127,11 @@ func TestAPIServerConfigUseCloudControllerManager(t *testing.T) {
 	cs.setAPIServerConfig()
 	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
 	if a["--cloud-provider"] != "azure" {
-		t.Fatalf("got unexpected '--cloud-provider' API server config value for UseCloudControllerManager=true: %s",
+		t.Fatalf("got unexpected '--cloud-provider' API server config value for UseCloudControllerManager=false: %s",
 			a["--cloud-provider"])
 	}
 	if a["--cloud-config"] != "/etc/kubernetes/azure.json" {
-		t.Fatalf("got unexpected '--cloud-config' API server config value for UseCloudControllerManager=true: %s",
+		t.Fatalf("got unexpected '--cloud-config' API server config value for UseCloudControllerManager=false: %s",
 			a["--cloud-config"])
 	}
 }
@@ -246,6 +247,260 @@ func TestAPIServerConfigHasAadProfile(t *testing.T) {
 	}
 }
 
+func TestAPIServerConfigOIDCConfig(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.OIDCConfig = &OIDCConfig{
+		IssuerURL:      "https://dex.example.com",
+		ClientID:       "kubectl",
+		UsernameClaim:  "email",
+		UsernamePrefix: "-",
+		GroupsClaim:    "groups",
+		GroupsPrefix:   "oidc:",
+	}
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	for flag, expected := range map[string]string{
+		"--oidc-issuer-url":      "https://dex.example.com",
+		"--oidc-client-id":       "kubectl",
+		"--oidc-username-claim":  "email",
+		"--oidc-username-prefix": "-",
+		"--oidc-groups-claim":    "groups",
+		"--oidc-groups-prefix":   "oidc:",
+	} {
+		if a[flag] != expected {
+			t.Fatalf("got unexpected '%s' API server config value for OIDCConfig: %s, expected %s", flag, a[flag], expected)
+		}
+	}
+
+	// the OIDCConfig fields override the apiServerConfig flags, and unset fields keep them
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.OIDCConfig = &OIDCConfig{
+		IssuerURL: "https://dex.example.com",
+		ClientID:  "kubectl",
+	}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig = map[string]string{
+		"--oidc-issuer-url":     "https://other.example.com",
+		"--oidc-username-claim": "sub",
+	}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--oidc-issuer-url"] != "https://dex.example.com" {
+		t.Fatalf("got unexpected '--oidc-issuer-url' API server config value for OIDCConfig: %s", a["--oidc-issuer-url"])
+	}
+	if a["--oidc-username-claim"] != "sub" {
+		t.Fatalf("got unexpected '--oidc-username-claim' API server config value for OIDCConfig: %s", a["--oidc-username-claim"])
+	}
+	for _, flag := range []string{"--oidc-username-prefix", "--oidc-groups-claim", "--oidc-groups-prefix"} {
+		if _, ok := a[flag]; ok {
+			t.Fatalf("got unexpected '%s' API server config value for OIDCConfig without it: %s", flag, a[flag])
+		}
+	}
+}
+
+func TestAPIServerConfigInsecurePort(t *testing.T) {
+	// Default: the insecure port is disabled
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setOrchestratorDefaults(false)
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--insecure-port"] != "0" {
+		t.Fatalf("got unexpected '--insecure-port' API server config value for default EnableInsecurePort: %s", a["--insecure-port"])
+	}
+	if a["--anonymous-auth"] != "false" {
+		t.Fatalf("got unexpected '--anonymous-auth' API server config value: %s", a["--anonymous-auth"])
+	}
+
+	// The insecure port cannot be enabled through apiServerConfig
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig = map[string]string{
+		"--insecure-port": "8080",
+	}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--insecure-port"] != "0" {
+		t.Fatalf("got unexpected '--insecure-port' API server config value with an apiServerConfig override: %s", a["--insecure-port"])
+	}
+
+	// EnableInsecurePort=true
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnableInsecurePort = helpers.PointerToBool(true)
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--insecure-port"] != "8080" {
+		t.Fatalf("got unexpected '--insecure-port' API server config value for EnableInsecurePort=true: %s", a["--insecure-port"])
+	}
+}
+
+func TestAPIServerConfigEndpoint(t *testing.T) {
+	// Default: the apiserver advertises the address of the master load balancer and serves on 443
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--advertise-address"] != "<advertiseAddr>" {
+		t.Fatalf("got unexpected '--advertise-address' API server config value: %s", a["--advertise-address"])
+	}
+	if a["--secure-port"] != "443" {
+		t.Fatalf("got unexpected '--secure-port' API server config value: %s", a["--secure-port"])
+	}
+
+	// Custom advertise address and secure port
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerAdvertiseAddress = "10.255.255.5"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerSecurePort = 6443
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--advertise-address"] != "10.255.255.5" {
+		t.Fatalf("got unexpected '--advertise-address' API server config value for APIServerAdvertiseAddress: %s", a["--advertise-address"])
+	}
+	if a["--secure-port"] != "6443" {
+		t.Fatalf("got unexpected '--secure-port' API server config value for APIServerSecurePort: %s", a["--secure-port"])
+	}
+}
+
+func TestAPIServerConfigRequestTimeouts(t *testing.T) {
+	// Default: the apiserver keeps its own timeouts and watch cache sizes
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	for _, key := range []string{"--request-timeout", "--min-request-timeout", "--watch-cache-sizes"} {
+		if _, ok := a[key]; ok {
+			t.Fatalf("got unexpected '%s' API server config value by default: %s", key, a[key])
+		}
+	}
+
+	// The fields override the corresponding apiServerConfig flags
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig = map[string]string{
+		"--request-timeout":   "30s",
+		"--watch-cache-sizes": "pods#100",
+	}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.RequestTimeout = "2m0s"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.MinRequestTimeout = 3600
+	cs.Properties.OrchestratorProfile.KubernetesConfig.WatchCacheSizes = map[string]int{
+		"pods":             5000,
+		"deployments.apps": 1000,
+	}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	for key, expected := range map[string]string{
+		"--request-timeout":     "2m0s",
+		"--min-request-timeout": "3600",
+		"--watch-cache-sizes":   "deployments.apps#1000,pods#5000",
+	} {
+		if a[key] != expected {
+			t.Fatalf("got unexpected '%s' API server config value: %s, expected %s", key, a[key], expected)
+		}
+	}
+}
+
+func TestAPIServerConfigTLS(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig = map[string]string{
+		"--tls-min-version": "VersionTLS10",
+	}
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--tls-min-version"] != "VersionTLS10" {
+		t.Fatalf("got unexpected '--tls-min-version' API server config value without TLSMinVersion: %s", a["--tls-min-version"])
+	}
+	if _, ok := a["--tls-cipher-suites"]; ok {
+		t.Fatalf("got unexpected '--tls-cipher-suites' API server config value without TLSCipherSuites: %s", a["--tls-cipher-suites"])
+	}
+
+	// the TLS settings override the apiServerConfig flags
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.TLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.TLSMinVersion = "VersionTLS12"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig = map[string]string{
+		"--tls-min-version": "VersionTLS10",
+	}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--tls-cipher-suites"] != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384" {
+		t.Fatalf("got unexpected '--tls-cipher-suites' API server config value for TLSCipherSuites: %s", a["--tls-cipher-suites"])
+	}
+	if a["--tls-min-version"] != "VersionTLS12" {
+		t.Fatalf("got unexpected '--tls-min-version' API server config value for TLSMinVersion: %s", a["--tls-min-version"])
+	}
+}
+
+func TestAPIServerConfigWebhookTokenAuth(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.WebhookTokenAuth = &WebhookTokenAuth{
+		URL:      "https://auth.example.com/authenticate",
+		CacheTTL: "30s",
+	}
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	for flag, expected := range map[string]string{
+		"--authentication-token-webhook-config-file": "/etc/kubernetes/webhook-token-auth-config.yaml",
+		"--authentication-token-webhook-cache-ttl":   "30s",
+	} {
+		if a[flag] != expected {
+			t.Fatalf("got unexpected '%s' API server config value for WebhookTokenAuth: %s, expected %s", flag, a[flag], expected)
+		}
+	}
+
+	// the API server keeps its default cache TTL
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.WebhookTokenAuth = &WebhookTokenAuth{URL: "https://auth.example.com/authenticate"}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if _, ok := a["--authentication-token-webhook-cache-ttl"]; ok {
+		t.Fatalf("got unexpected '--authentication-token-webhook-cache-ttl' API server config value without a cache TTL: %s", a["--authentication-token-webhook-cache-ttl"])
+	}
+
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if _, ok := a["--authentication-token-webhook-config-file"]; ok {
+		t.Fatalf("got unexpected '--authentication-token-webhook-config-file' API server config value without WebhookTokenAuth: %s", a["--authentication-token-webhook-config-file"])
+	}
+}
+
+func TestAPIServerConfigAuditWebhook(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.AuditWebhook = &AuditWebhook{
+		URL:             "https://siem.example.com/audit",
+		BatchBufferSize: 10000,
+		BatchMaxSize:    400,
+		BatchMaxWait:    "30s",
+	}
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	for flag, expected := range map[string]string{
+		"--audit-webhook-config-file":       "/etc/kubernetes/audit-webhook-config.yaml",
+		"--audit-webhook-mode":              "batch",
+		"--audit-webhook-batch-buffer-size": "10000",
+		"--audit-webhook-batch-max-size":    "400",
+		"--audit-webhook-batch-max-wait":    "30s",
+	} {
+		if a[flag] != expected {
+			t.Fatalf("got unexpected '%s' API server config value for AuditWebhook: %s, expected %s", flag, a[flag], expected)
+		}
+	}
+
+	// the API server keeps its default batch settings
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.AuditWebhook = &AuditWebhook{URL: "https://siem.example.com/audit", Mode: "blocking"}
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--audit-webhook-mode"] != "blocking" {
+		t.Fatalf("got unexpected '--audit-webhook-mode' API server config value for the blocking mode: %s", a["--audit-webhook-mode"])
+	}
+	for _, flag := range []string{"--audit-webhook-batch-buffer-size", "--audit-webhook-batch-max-size", "--audit-webhook-batch-max-wait"} {
+		if _, ok := a[flag]; ok {
+			t.Fatalf("got unexpected '%s' API server config value without batch settings: %s", flag, a[flag])
+		}
+	}
+
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if _, ok := a["--audit-webhook-config-file"]; ok {
+		t.Fatalf("got unexpected '--audit-webhook-config-file' API server config value without AuditWebhook: %s", a["--audit-webhook-config-file"])
+	}
+}
+
 func TestAPIServerConfigEnableRbac(t *testing.T) {
 	// Test EnableRbac = true
 	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
@@ -366,6 +621,66 @@ func TestAPIServerConfigDefaultAdmissionControls(t *testing.T) {
 	}
 }
 
+func TestAPIServerConfigEnablePodPriority(t *testing.T) {
+	// Test EnablePodPriority = true
+	cs := CreateMockContainerService("testcluster", "1.11.5", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnablePodPriority = helpers.PointerToBool(true)
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if !strings.HasSuffix(a["--enable-admission-plugins"], ",Priority") {
+		t.Fatalf("got unexpected '--enable-admission-plugins' API server config value for EnablePodPriority=true: %s",
+			a["--enable-admission-plugins"])
+	}
+
+	// Test EnablePodPriority = false
+	cs = CreateMockContainerService("testcluster", "1.11.5", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnablePodPriority = helpers.PointerToBool(false)
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if strings.Contains(a["--enable-admission-plugins"], "Priority") {
+		t.Fatalf("got unexpected '--enable-admission-plugins' API server config value for EnablePodPriority=false: %s",
+			a["--enable-admission-plugins"])
+	}
+}
+
+func TestAPIServerConfigServiceAccountIssuer(t *testing.T) {
+	cases := []struct {
+		version      string
+		audiencesKey string
+	}{
+		{"1.12.2", "--service-account-api-audiences"},
+		{"1.13.0", "--api-audiences"},
+	}
+	for _, c := range cases {
+		cs := CreateMockContainerService("testcluster", c.version, 3, 2, false)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer = "https://issuer.contoso.com"
+		cs.Properties.OrchestratorProfile.KubernetesConfig.APIAudiences = []string{"api", "vault"}
+		cs.setAPIServerConfig()
+		a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+		for flag, expected := range map[string]string{
+			"--service-account-issuer":           "https://issuer.contoso.com",
+			"--service-account-signing-key-file": "/etc/kubernetes/certs/serviceaccount.key",
+			"--service-account-key-file":         "/etc/kubernetes/certs/serviceaccount-verification.key",
+			c.audiencesKey:                       "api,vault",
+		} {
+			if a[flag] != expected {
+				t.Fatalf("got unexpected '%s' API server config value for Kubernetes %s: %s, expected %s", flag, c.version, a[flag], expected)
+			}
+		}
+	}
+
+	// Test without ServiceAccountIssuer
+	cs := CreateMockContainerService("testcluster", "1.13.0", 3, 2, false)
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if _, ok := a["--service-account-issuer"]; ok {
+		t.Fatalf("got unexpected '--service-account-issuer' API server config value without ServiceAccountIssuer: %s", a["--service-account-issuer"])
+	}
+	if a["--service-account-key-file"] != "/etc/kubernetes/certs/apiserver.key" {
+		t.Fatalf("got unexpected '--service-account-key-file' API server config value without ServiceAccountIssuer: %s", a["--service-account-key-file"])
+	}
+}
+
 func TestAPIServerConfigEnableProfiling(t *testing.T) {
 	// Test
 	// "apiServerConfig": {
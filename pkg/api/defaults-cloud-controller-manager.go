@@ -10,10 +10,10 @@ import (
 func (cs *ContainerService) setCloudControllerManagerConfig() {
 	o := cs.Properties.OrchestratorProfile
 	staticCloudControllerManagerConfig := map[string]string{
-		"--allocate-node-cidrs":    strconv.FormatBool(!o.IsAzureCNI()),
+		"--allocate-node-cidrs":    strconv.FormatBool(!o.IsAzureCNIVNETIntegrated()),
 		"--configure-cloud-routes": strconv.FormatBool(o.RequireRouteTable()),
 		"--cloud-provider":         "azure",
-		"--cloud-config":           "/etc/kubernetes/azure.json",
+		"--cloud-config":           "/etc/kubernetes/cloud-config/cloud-config",
 		"--cluster-cidr":           o.KubernetesConfig.ClusterSubnet,
 		"--kubeconfig":             "/var/lib/kubelet/kubeconfig",
 		"--leader-elect":           "true",
@@ -13,7 +13,7 @@ func (cs *ContainerService) setControllerManagerConfig() {
 	o := cs.Properties.OrchestratorProfile
 	staticControllerManagerConfig := map[string]string{
 		"--kubeconfig":                       "/var/lib/kubelet/kubeconfig",
-		"--allocate-node-cidrs":              strconv.FormatBool(!o.IsAzureCNI()),
+		"--allocate-node-cidrs":              strconv.FormatBool(!o.IsAzureCNIVNETIntegrated()),
 		"--configure-cloud-routes":           strconv.FormatBool(o.RequireRouteTable()),
 		"--cluster-cidr":                     o.KubernetesConfig.ClusterSubnet,
 		"--root-ca-file":                     "/etc/kubernetes/certs/ca.crt",
@@ -32,8 +32,37 @@ func (cs *ContainerService) setControllerManagerConfig() {
 		staticControllerManagerConfig["--cluster-name"] = cs.Properties.HostedMasterProfile.DNSPrefix
 	}
 
-	// Enable cloudprovider if we're not using cloud controller manager
-	if !helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager) {
+	// Node monitor timings
+	if o.KubernetesConfig.NodeMonitorGracePeriod != "" {
+		staticControllerManagerConfig["--node-monitor-grace-period"] = o.KubernetesConfig.NodeMonitorGracePeriod
+	}
+	if o.KubernetesConfig.NodeMonitorPeriod != "" {
+		staticControllerManagerConfig["--node-monitor-period"] = o.KubernetesConfig.NodeMonitorPeriod
+	}
+	if o.KubernetesConfig.PodEvictionTimeout != "" {
+		staticControllerManagerConfig["--pod-eviction-timeout"] = o.KubernetesConfig.PodEvictionTimeout
+	}
+
+	// Serve the metrics on the secure port of all the interfaces, authenticating and authorizing the scrapers against the apiserver
+	if o.KubernetesConfig.IsPrometheusMetricsEnabled() {
+		staticControllerManagerConfig["--bind-address"] = "0.0.0.0"
+		staticControllerManagerConfig["--secure-port"] = DefaultKubernetesCtrlMgrSecurePort
+		staticControllerManagerConfig["--authentication-kubeconfig"] = "/var/lib/kubelet/kubeconfig"
+		staticControllerManagerConfig["--authorization-kubeconfig"] = "/var/lib/kubelet/kubeconfig"
+	}
+
+	// Sign the certificate signing requests with which the kubelets renew their certificates for the configured duration
+	if o.KubernetesConfig.IsKubeletCertRotationEnabled() {
+		staticControllerManagerConfig["--experimental-cluster-signing-duration"] = DefaultKubeletCertSigningDuration
+		if o.KubernetesConfig.KubeletCertSigningDuration != "" {
+			staticControllerManagerConfig["--experimental-cluster-signing-duration"] = o.KubernetesConfig.KubeletCertSigningDuration
+		}
+	}
+
+	// Enable cloudprovider, or defer to the cloud controller manager
+	if helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager) {
+		staticControllerManagerConfig["--cloud-provider"] = "external"
+	} else {
 		staticControllerManagerConfig["--cloud-provider"] = "azure"
 		staticControllerManagerConfig["--cloud-config"] = "/etc/kubernetes/azure.json"
 	}
@@ -67,6 +96,12 @@ func (cs *ContainerService) setControllerManagerConfig() {
 	// Enable the consumption of local ephemeral storage and also the sizeLimit property of an emptyDir volume.
 	addDefaultFeatureGates(o.KubernetesConfig.ControllerManagerConfig, o.OrchestratorVersion, "1.10.0", "LocalStorageCapacityIsolation=true")
 
+	// Allocates both an IPv4 and an IPv6 pod CIDR, from the comma separated cluster CIDRs, to each node
+	if o.KubernetesConfig.IsIPv6DualStackEnabled() {
+		addDefaultFeatureGates(o.KubernetesConfig.ControllerManagerConfig, o.OrchestratorVersion, "", "IPv6DualStack=true")
+	}
+	addFeatureGates(o.KubernetesConfig.ControllerManagerConfig, o.KubernetesConfig.FeatureGates)
+
 	// We don't support user-configurable values for the following,
 	// so any of the value assignments below will override user-provided values
 	for key, val := range staticControllerManagerConfig {
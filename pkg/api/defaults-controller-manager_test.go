@@ -4,6 +4,7 @@
 package api
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Azure/acs-engine/pkg/helpers"
@@ -31,6 +32,37 @@ func TestControllerManagerConfigEnableRbac(t *testing.T) {
 	}
 
 }
+
+func TestControllerManagerConfigUseCloudControllerManager(t *testing.T) {
+	// Test UseCloudControllerManager = true
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager = helpers.PointerToBool(true)
+	cs.setControllerManagerConfig()
+	cm := cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	if cm["--cloud-provider"] != "external" {
+		t.Fatalf("got unexpected '--cloud-provider' Controller Manager config value for UseCloudControllerManager=true: %s",
+			cm["--cloud-provider"])
+	}
+	if _, ok := cm["--cloud-config"]; ok {
+		t.Fatalf("got unexpected '--cloud-config' Controller Manager config value for UseCloudControllerManager=true: %s",
+			cm["--cloud-config"])
+	}
+
+	// Test UseCloudControllerManager = false
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager = helpers.PointerToBool(false)
+	cs.setControllerManagerConfig()
+	cm = cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	if cm["--cloud-provider"] != "azure" {
+		t.Fatalf("got unexpected '--cloud-provider' Controller Manager config value for UseCloudControllerManager=false: %s",
+			cm["--cloud-provider"])
+	}
+	if cm["--cloud-config"] != "/etc/kubernetes/azure.json" {
+		t.Fatalf("got unexpected '--cloud-config' Controller Manager config value for UseCloudControllerManager=false: %s",
+			cm["--cloud-config"])
+	}
+}
+
 func TestControllerManagerConfigEnableProfiling(t *testing.T) {
 	// Test
 	// "controllerManagerConfig": {
@@ -85,3 +117,98 @@ func TestControllerManagerConfigDefaultFeatureGates(t *testing.T) {
 			cm["--feature-gates"])
 	}
 }
+
+func TestControllerManagerConfigNodeMonitorTimings(t *testing.T) {
+	// Test defaults
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setControllerManagerConfig()
+	cm := cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	if cm["--node-monitor-grace-period"] != DefaultKubernetesCtrlMgrNodeMonitorGracePeriod ||
+		cm["--pod-eviction-timeout"] != DefaultKubernetesCtrlMgrPodEvictionTimeout {
+		t.Fatalf("got unexpected default node monitor timings: %s %s",
+			cm["--node-monitor-grace-period"], cm["--pod-eviction-timeout"])
+	}
+	if _, ok := cm["--node-monitor-period"]; ok {
+		t.Fatalf("got unexpected '--node-monitor-period' Controller Manager config value: %s", cm["--node-monitor-period"])
+	}
+
+	// Test configured timings, which take precedence over controllerManagerConfig
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NodeMonitorGracePeriod = "2m"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NodeMonitorPeriod = "10s"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PodEvictionTimeout = "10m"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig = map[string]string{
+		"--pod-eviction-timeout": "1m",
+	}
+	cs.setControllerManagerConfig()
+	cm = cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	for flag, expected := range map[string]string{
+		"--node-monitor-grace-period": "2m",
+		"--node-monitor-period":       "10s",
+		"--pod-eviction-timeout":      "10m",
+	} {
+		if cm[flag] != expected {
+			t.Fatalf("got unexpected '%s' Controller Manager config value: %s, expected %s", flag, cm[flag], expected)
+		}
+	}
+}
+
+func TestControllerManagerConfigKubeletCertRotation(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.12.2", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnableKubeletCertRotation = helpers.PointerToBool(true)
+	cs.setControllerManagerConfig()
+	cm := cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	if cm["--experimental-cluster-signing-duration"] != DefaultKubeletCertSigningDuration {
+		t.Fatalf("got unexpected '--experimental-cluster-signing-duration' Controller Manager config value: %s", cm["--experimental-cluster-signing-duration"])
+	}
+
+	cs = CreateMockContainerService("testcluster", "1.10.8", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnableKubeletCertRotation = helpers.PointerToBool(true)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletCertSigningDuration = "720h"
+	cs.setControllerManagerConfig()
+	cm = cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	if cm["--experimental-cluster-signing-duration"] != "720h" {
+		t.Fatalf("got unexpected '--experimental-cluster-signing-duration' Controller Manager config value: %s", cm["--experimental-cluster-signing-duration"])
+	}
+	if strings.Contains(cm["--feature-gates"], "RotateKubeletServerCertificate") {
+		t.Fatalf("got unexpected Controller Manager feature gates with 1.10: %s", cm["--feature-gates"])
+	}
+
+	// Test default
+	cs = CreateMockContainerService("testcluster", "1.12.2", 3, 2, false)
+	cs.setControllerManagerConfig()
+	if val, ok := cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig["--experimental-cluster-signing-duration"]; ok {
+		t.Fatalf("got unexpected '--experimental-cluster-signing-duration' Controller Manager config value: %s", val)
+	}
+}
+
+func TestControllerManagerConfigEnablePrometheusMetrics(t *testing.T) {
+	// Test EnablePrometheusMetrics = true, which takes precedence over controllerManagerConfig
+	cs := CreateMockContainerService("testcluster", "1.12.2", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnablePrometheusMetrics = helpers.PointerToBool(true)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig = map[string]string{
+		"--bind-address": "127.0.0.1",
+	}
+	cs.setControllerManagerConfig()
+	cm := cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	for flag, expected := range map[string]string{
+		"--bind-address":              "0.0.0.0",
+		"--secure-port":               DefaultKubernetesCtrlMgrSecurePort,
+		"--authentication-kubeconfig": "/var/lib/kubelet/kubeconfig",
+		"--authorization-kubeconfig":  "/var/lib/kubelet/kubeconfig",
+	} {
+		if cm[flag] != expected {
+			t.Fatalf("got unexpected '%s' Controller Manager config value for EnablePrometheusMetrics=true: %s, expected %s", flag, cm[flag], expected)
+		}
+	}
+
+	// Test default
+	cs = CreateMockContainerService("testcluster", "1.12.2", 3, 2, false)
+	cs.setControllerManagerConfig()
+	cm = cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	for _, flag := range []string{"--bind-address", "--secure-port", "--authentication-kubeconfig", "--authorization-kubeconfig"} {
+		if _, ok := cm[flag]; ok {
+			t.Fatalf("got unexpected '%s' Controller Manager config value: %s", flag, cm[flag])
+		}
+	}
+}
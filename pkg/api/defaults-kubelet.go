@@ -4,6 +4,7 @@
 package api
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,6 +14,46 @@ import (
 
 func (cs *ContainerService) setKubeletConfig() {
 	o := cs.Properties.OrchestratorProfile
+
+	// The kubelet and the container runtime must use the same cgroup driver
+	if o.KubernetesConfig.CgroupDriver == "" {
+		o.KubernetesConfig.CgroupDriver = DefaultCgroupDriver
+		if val := o.KubernetesConfig.KubeletConfig["--cgroup-driver"]; val != "" {
+			o.KubernetesConfig.CgroupDriver = val
+		} else if cs.Properties.hasCgroupV2Nodes() {
+			// cgroupfs doesn't manage the unified cgroup hierarchy
+			o.KubernetesConfig.CgroupDriver = CgroupDriverSystemd
+		}
+	}
+
+	// Container logs are rotated by docker, or by the kubelet for the CRI container runtimes
+	if o.KubernetesConfig.ContainerLogMaxSize == "" {
+		o.KubernetesConfig.ContainerLogMaxSize = DefaultContainerLogMaxSize
+		if val := o.KubernetesConfig.KubeletConfig["--container-log-max-size"]; val != "" {
+			o.KubernetesConfig.ContainerLogMaxSize = val
+		}
+	}
+	if o.KubernetesConfig.ContainerLogMaxFiles == 0 {
+		o.KubernetesConfig.ContainerLogMaxFiles = DefaultContainerLogMaxFiles
+		if val, err := strconv.Atoi(o.KubernetesConfig.KubeletConfig["--container-log-max-files"]); err == nil {
+			o.KubernetesConfig.ContainerLogMaxFiles = val
+		}
+	}
+	containerLogRotationConfig := map[string]string{}
+	if !o.KubernetesConfig.RequiresDocker() && common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.11.0") {
+		containerLogRotationConfig["--container-log-max-size"] = o.KubernetesConfig.ContainerLogMaxSize
+		containerLogRotationConfig["--container-log-max-files"] = strconv.Itoa(o.KubernetesConfig.ContainerLogMaxFiles)
+	}
+
+	// The TLS settings of the cluster take precedence over the kubelet config of the cluster, masters and pools
+	tlsConfig := map[string]string{}
+	if len(o.KubernetesConfig.TLSCipherSuites) > 0 {
+		tlsConfig["--tls-cipher-suites"] = strings.Join(o.KubernetesConfig.TLSCipherSuites, ",")
+	}
+	if o.KubernetesConfig.TLSMinVersion != "" {
+		tlsConfig["--tls-min-version"] = o.KubernetesConfig.TLSMinVersion
+	}
+
 	staticLinuxKubeletConfig := map[string]string{
 		"--address":                     "0.0.0.0",
 		"--allow-privileged":            "true",
@@ -25,6 +66,7 @@ func (cs *ContainerService) setKubeletConfig() {
 		"--enforce-node-allocatable":    "pods",
 		"--kubeconfig":                  "/var/lib/kubelet/kubeconfig",
 		"--keep-terminated-pod-volumes": "false",
+		"--cgroup-driver":               o.KubernetesConfig.CgroupDriver,
 	}
 
 	// Start with copy of Linux config
@@ -33,6 +75,14 @@ func (cs *ContainerService) setKubeletConfig() {
 		staticWindowsKubeletConfig[key] = val
 	}
 
+	// The pods of the Linux nodes query the DNS cache of their node, which forwards the cluster queries
+	// to the DNS service; the Windows nodes don't run the cache and keep querying the DNS service
+	if o.KubernetesConfig.IsNodeLocalDNSEnabled() {
+		if localIP := o.KubernetesConfig.GetAddonByName(DefaultNodeLocalDNSAddonName).Config["localIP"]; localIP != "" {
+			staticLinuxKubeletConfig["--cluster-dns"] = localIP
+		}
+	}
+
 	// Add Windows-specific overrides
 	// Eventually paths should not be hardcoded here. They should be relative to $global:KubeDir in the PowerShell script
 	staticWindowsKubeletConfig["--azure-container-registry-config"] = "c:\\k\\azure.json"
@@ -46,10 +96,11 @@ func (cs *ContainerService) setKubeletConfig() {
 	staticWindowsKubeletConfig["--hairpin-mode"] = "promiscuous-bridge"
 	staticWindowsKubeletConfig["--image-pull-progress-deadline"] = "20m"
 	staticWindowsKubeletConfig["--resolv-conf"] = "\"\"\"\""
+	delete(staticWindowsKubeletConfig, "--cgroup-driver")
 
 	// Default Kubelet config
 	defaultKubeletConfig := map[string]string{
-		"--cluster-domain":                  "cluster.local",
+		"--cluster-domain":                  o.KubernetesConfig.ClusterDomain,
 		"--network-plugin":                  "cni",
 		"--pod-infra-container-image":       o.KubernetesConfig.KubernetesImageBase + K8sComponentsByVersionMap[o.OrchestratorVersion]["pause"],
 		"--max-pods":                        strconv.Itoa(DefaultKubernetesMaxPods),
@@ -69,11 +120,11 @@ func (cs *ContainerService) setKubeletConfig() {
 
 	// AKS overrides
 	if cs.Properties.IsHostedMasterProfile() {
-		defaultKubeletConfig["--non-masquerade-cidr"] = cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet
+		defaultKubeletConfig["--non-masquerade-cidr"] = cs.Properties.OrchestratorProfile.KubernetesConfig.GetIPv4ClusterSubnet()
 	}
 
-	// Apply Azure CNI-specific --max-pods value
-	if o.KubernetesConfig.NetworkPlugin == NetworkPluginAzure {
+	// Apply Azure CNI-specific --max-pods value, the pods of an overlay don't need IP configurations of the VNET
+	if o.IsAzureCNIVNETIntegrated() {
 		defaultKubeletConfig["--max-pods"] = strconv.Itoa(DefaultKubernetesMaxPodsVNETIntegrated)
 	}
 
@@ -81,24 +132,54 @@ func (cs *ContainerService) setKubeletConfig() {
 	setMissingKubeletValues(o.KubernetesConfig, defaultKubeletConfig)
 	addDefaultFeatureGates(o.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "", "")
 	addDefaultFeatureGates(o.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "1.8.0", "PodPriority=true")
+	// the Kubernetes versions supporting dual-stack networking are enforced by the API model validation
+	if o.KubernetesConfig.IsIPv6DualStackEnabled() {
+		addDefaultFeatureGates(o.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "", "IPv6DualStack=true")
+	}
+	addFeatureGates(o.KubernetesConfig.KubeletConfig, o.KubernetesConfig.FeatureGates)
 
 	// Override default cloud-provider?
 	if helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager) {
 		staticLinuxKubeletConfig["--cloud-provider"] = "external"
 	}
 
+	// Override default --pod-max-pids?
+	if o.KubernetesConfig.PodMaxPids > 0 {
+		o.KubernetesConfig.KubeletConfig["--pod-max-pids"] = strconv.Itoa(o.KubernetesConfig.PodMaxPids)
+	}
+
 	// Override default --network-plugin?
 	if o.KubernetesConfig.NetworkPlugin == NetworkPluginKubenet {
 		if o.KubernetesConfig.NetworkPolicy != NetworkPolicyCalico {
 			o.KubernetesConfig.KubeletConfig["--network-plugin"] = NetworkPluginKubenet
+			// kubenet sets the MTU of the bridge and the interfaces of the pods
+			if o.KubernetesConfig.PodMTU > 0 {
+				o.KubernetesConfig.KubeletConfig["--network-plugin-mtu"] = strconv.Itoa(o.KubernetesConfig.PodMTU)
+			}
 		}
 	}
 
+	// A CNI brought by the user installs its plugins and configuration in the standard directories
+	if o.KubernetesConfig.NetworkPlugin == NetworkPluginCNI {
+		o.KubernetesConfig.KubeletConfig["--network-plugin"] = NetworkPluginCNI
+		o.KubernetesConfig.KubeletConfig["--cni-conf-dir"] = DefaultCNIConfDir
+		o.KubernetesConfig.KubeletConfig["--cni-bin-dir"] = DefaultCNIBinDir
+	}
+
 	// We don't support user-configurable values for the following,
 	// so any of the value assignments below will override user-provided values
 	for key, val := range staticLinuxKubeletConfig {
 		o.KubernetesConfig.KubeletConfig[key] = val
 	}
+	for key, val := range containerLogRotationConfig {
+		o.KubernetesConfig.KubeletConfig[key] = val
+	}
+	for key, val := range tlsConfig {
+		o.KubernetesConfig.KubeletConfig[key] = val
+	}
+	if o.KubernetesConfig.IsKubeletCertRotationEnabled() {
+		setKubeletCertRotation(o.KubernetesConfig.KubeletConfig)
+	}
 
 	// Remove secure kubelet flags, if configured
 	if !helpers.IsTrueBoolPointer(o.KubernetesConfig.EnableSecureKubelet) {
@@ -116,7 +197,26 @@ func (cs *ContainerService) setKubeletConfig() {
 			cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig = make(map[string]string)
 		}
 		setMissingKubeletValues(cs.Properties.MasterProfile.KubernetesConfig, o.KubernetesConfig.KubeletConfig)
+		setNodeResolvConf(cs.Properties.MasterProfile.KubernetesConfig, o.KubernetesConfig)
+		if cs.Properties.MasterProfile.KubernetesConfig.CgroupV2Enabled == nil {
+			cs.Properties.MasterProfile.KubernetesConfig.CgroupV2Enabled = o.KubernetesConfig.CgroupV2Enabled
+		}
+		setNodeProcessLimits(cs.Properties.MasterProfile.KubernetesConfig, o.KubernetesConfig)
+		if cs.Properties.MasterProfile.KubernetesConfig.PrePulledImages == nil {
+			cs.Properties.MasterProfile.KubernetesConfig.PrePulledImages = o.KubernetesConfig.PrePulledImages
+		}
+		cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig["--cgroup-driver"] = o.KubernetesConfig.CgroupDriver
+		for key, val := range containerLogRotationConfig {
+			cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig[key] = val
+		}
+		for key, val := range tlsConfig {
+			cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig[key] = val
+		}
+		if o.KubernetesConfig.IsKubeletCertRotationEnabled() {
+			setKubeletCertRotation(cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig)
+		}
 		addDefaultFeatureGates(cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "", "")
+		addFeatureGates(cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig, o.KubernetesConfig.FeatureGates)
 
 		removeKubeletFlags(cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig, o.OrchestratorVersion)
 	}
@@ -133,10 +233,45 @@ func (cs *ContainerService) setKubeletConfig() {
 			}
 		}
 		setMissingKubeletValues(profile.KubernetesConfig, o.KubernetesConfig.KubeletConfig)
+		for key, val := range tlsConfig {
+			profile.KubernetesConfig.KubeletConfig[key] = val
+		}
+		if o.KubernetesConfig.IsKubeletCertRotationEnabled() {
+			setKubeletCertRotation(profile.KubernetesConfig.KubeletConfig)
+		}
+
+		if profile.OSType != "Windows" {
+			setAgentPoolSwap(profile.KubernetesConfig, o.KubernetesConfig)
+			if profile.KubernetesConfig.ReadOnlyRootFilesystem == nil {
+				profile.KubernetesConfig.ReadOnlyRootFilesystem = o.KubernetesConfig.ReadOnlyRootFilesystem
+			}
+			if profile.KubernetesConfig.CgroupV2Enabled == nil {
+				profile.KubernetesConfig.CgroupV2Enabled = o.KubernetesConfig.CgroupV2Enabled
+			}
+			setNodeResolvConf(profile.KubernetesConfig, o.KubernetesConfig)
+			setNodeProcessLimits(profile.KubernetesConfig, o.KubernetesConfig)
+			if profile.KubernetesConfig.PrePulledImages == nil {
+				profile.KubernetesConfig.PrePulledImages = o.KubernetesConfig.PrePulledImages
+			}
+			profile.KubernetesConfig.KubeletConfig["--cgroup-driver"] = o.KubernetesConfig.CgroupDriver
+			for key, val := range containerLogRotationConfig {
+				profile.KubernetesConfig.KubeletConfig[key] = val
+			}
+			setAgentPoolUnsafeSysctls(profile, o.OrchestratorVersion)
+		}
 
 		if profile.OSType == "Windows" {
 			// Remove Linux-specific values
 			delete(profile.KubernetesConfig.KubeletConfig, "--pod-manifest-path")
+			delete(profile.KubernetesConfig.KubeletConfig, "--cgroup-driver")
+			for key := range containerLogRotationConfig {
+				delete(profile.KubernetesConfig.KubeletConfig, key)
+			}
+		} else if o.KubernetesConfig.IsBootstrapTokenEnabled() {
+			// Join via TLS bootstrapping with the short-lived bootstrap token; the kubelet writes its own kubeconfig
+			profile.KubernetesConfig.KubeletConfig["--bootstrap-kubeconfig"] = "/var/lib/kubelet/bootstrap-kubeconfig"
+			profile.KubernetesConfig.KubeletConfig["--kubeconfig"] = "/var/lib/kubelet/bootstrapped-kubeconfig"
+			profile.KubernetesConfig.KubeletConfig["--rotate-certificates"] = "true"
 		}
 
 		// For N Series (GPU) VMs
@@ -146,11 +281,129 @@ func (cs *ContainerService) setKubeletConfig() {
 				addDefaultFeatureGates(profile.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "1.6.0", "Accelerators=true")
 			}
 		}
+		addFeatureGates(profile.KubernetesConfig.KubeletConfig, o.KubernetesConfig.FeatureGates)
 
 		removeKubeletFlags(profile.KubernetesConfig.KubeletConfig, o.OrchestratorVersion)
 	}
 }
 
+// setKubeletCertRotation makes a kubelet renew its client certificate with certificate signing requests
+func setKubeletCertRotation(k map[string]string) {
+	k["--rotate-certificates"] = "true"
+}
+
+// setAgentPoolSwap applies the cluster-wide swap settings to a Linux agent pool that doesn't
+// configure its own, and allows the kubelet to start on a node with swap enabled
+func setAgentPoolSwap(p *KubernetesConfig, cluster *KubernetesConfig) {
+	if p.SwapEnabled == nil {
+		p.SwapEnabled = cluster.SwapEnabled
+	}
+	if !p.IsSwapEnabled() {
+		return
+	}
+	if p.SwapSizeMB == 0 {
+		p.SwapSizeMB = cluster.SwapSizeMB
+	}
+	if p.SwapSizeMB == 0 {
+		p.SwapSizeMB = DefaultKubernetesSwapSizeMB
+	}
+	p.KubeletConfig["--fail-swap-on"] = "false"
+}
+
+// setNodeProcessLimits applies the cluster-wide PID and open files limits to a Linux node that doesn't
+// configure its own, and limits the PIDs of its pods accordingly
+func setNodeProcessLimits(p *KubernetesConfig, cluster *KubernetesConfig) {
+	if p.PodMaxPids == 0 {
+		p.PodMaxPids = cluster.PodMaxPids
+	}
+	if p.PidMax == 0 {
+		p.PidMax = cluster.PidMax
+	}
+	if p.MaxOpenFiles == 0 {
+		p.MaxOpenFiles = cluster.MaxOpenFiles
+	}
+	if p.PodMaxPids > 0 {
+		p.KubeletConfig["--pod-max-pids"] = strconv.Itoa(p.PodMaxPids)
+	}
+}
+
+// hasCgroupV2Nodes checks if the master or any Linux agent pool boots with the unified cgroup v2 hierarchy,
+// either enabled cluster-wide or by the node's own config
+func (p *Properties) hasCgroupV2Nodes() bool {
+	if p.OrchestratorProfile.KubernetesConfig.IsCgroupV2Enabled() {
+		return true
+	}
+	if p.MasterProfile != nil && p.MasterProfile.KubernetesConfig.IsCgroupV2Enabled() {
+		return true
+	}
+	for _, profile := range p.AgentPoolProfiles {
+		if profile.OSType != Windows && profile.KubernetesConfig.IsCgroupV2Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// setNodeResolvConf points the kubelet of the nodes using a config at the resolv.conf which the pods inherit
+// the name servers of, unless the nodes override the cluster-wide settings
+func setNodeResolvConf(k *KubernetesConfig, cluster *KubernetesConfig) {
+	if k.ResolvConf == "" {
+		k.ResolvConf = cluster.ResolvConf
+	}
+	if k.DisableResolvedStub == nil {
+		k.DisableResolvedStub = cluster.DisableResolvedStub
+	}
+	if k.ResolvConf != "" {
+		k.KubeletConfig["--resolv-conf"] = k.ResolvConf
+	}
+}
+
+// setAgentPoolUnsafeSysctls allows the pods of an agent pool to set the namespaced sysctls which the pool
+// tunes on its nodes, as the sysctls of the network and IPC namespaces of the pods don't inherit the
+// values of the node. Sysctls allowed by the user's kubelet config are left as they are
+func setAgentPoolUnsafeSysctls(profile *AgentPoolProfile, orchestratorVersion string) {
+	var unsafeSysctls []string
+	for key := range profile.Sysctls {
+		if isNamespacedSysctl(key) && !safeSysctls[key] {
+			unsafeSysctls = append(unsafeSysctls, key)
+		}
+	}
+	if len(unsafeSysctls) == 0 {
+		return
+	}
+	sort.Strings(unsafeSysctls)
+	flag := "--allowed-unsafe-sysctls"
+	if !common.IsKubernetesVersionGe(orchestratorVersion, "1.11.0") {
+		flag = "--experimental-allowed-unsafe-sysctls"
+	}
+	if _, ok := profile.KubernetesConfig.KubeletConfig[flag]; !ok {
+		profile.KubernetesConfig.KubeletConfig[flag] = strings.Join(unsafeSysctls, ",")
+	}
+}
+
+// safeSysctls are the namespaced sysctls which pods can set without the kubelet allowing them
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced":       true,
+	"net.ipv4.ip_local_port_range": true,
+	"net.ipv4.tcp_syncookies":      true,
+}
+
+// isNamespacedSysctl tells whether a sysctl is set per network or IPC namespace, and thus per pod
+func isNamespacedSysctl(key string) bool {
+	switch key {
+	case "kernel.sem":
+		return true
+	case "net.netfilter.nf_conntrack_max", "net.netfilter.nf_conntrack_expect_max":
+		return false
+	}
+	for _, prefix := range []string{"net.", "kernel.shm", "kernel.msg", "fs.mqueue."} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func removeKubeletFlags(k map[string]string, v string) {
 	// Get rid of values not supported until v1.10
 	if !common.IsKubernetesVersionGe(v, "1.10.0") {
@@ -169,7 +422,10 @@ func removeKubeletFlags(k map[string]string, v string) {
 
 func setMissingKubeletValues(p *KubernetesConfig, d map[string]string) {
 	if p.KubeletConfig == nil {
-		p.KubeletConfig = d
+		p.KubeletConfig = make(map[string]string)
+		for key, val := range d {
+			p.KubeletConfig[key] = val
+		}
 	} else {
 		for key, val := range d {
 			// If we don't have a user-configurable value for each option
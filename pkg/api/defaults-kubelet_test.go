@@ -5,6 +5,7 @@ package api
 
 import (
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/Azure/acs-engine/pkg/helpers"
@@ -127,6 +128,30 @@ func TestKubeletConfigNetworkPlugin(t *testing.T) {
 
 }
 
+func TestKubeletConfigPodMTU(t *testing.T) {
+	// Test PodMTU with NetworkPlugin = "kubenet"
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = NetworkPluginKubenet
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PodMTU = 1400
+	cs.setKubeletConfig()
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig
+	if k["--network-plugin-mtu"] != "1400" {
+		t.Fatalf("got unexpected '--network-plugin-mtu' kubelet config value for PodMTU=1400: %s",
+			k["--network-plugin-mtu"])
+	}
+
+	// Test PodMTU with NetworkPlugin = "azure"
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = NetworkPluginAzure
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PodMTU = 1400
+	cs.setKubeletConfig()
+	k = cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig
+	if _, ok := k["--network-plugin-mtu"]; ok {
+		t.Fatalf("got unexpected '--network-plugin-mtu' kubelet config value for NetworkPlugin=azure: %s",
+			k["--network-plugin-mtu"])
+	}
+}
+
 func TestKubeletConfigEnableSecureKubelet(t *testing.T) {
 	// Test EnableSecureKubelet = true
 	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
@@ -211,3 +236,381 @@ func TestKubeletCalico(t *testing.T) {
 			NetworkPolicyCalico, k["--network-plugin"])
 	}
 }
+
+func TestKubeletConfigSwap(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.SwapEnabled = helpers.PointerToBool(true)
+	for _, name := range []string{"agentpool2", "agentpool3"} {
+		pool := *cs.Properties.AgentPoolProfiles[0]
+		pool.Name = name
+		cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &pool)
+	}
+	cs.Properties.AgentPoolProfiles[1].KubernetesConfig = &KubernetesConfig{SwapEnabled: helpers.PointerToBool(false)}
+	cs.Properties.AgentPoolProfiles[2].KubernetesConfig = &KubernetesConfig{SwapSizeMB: 4096}
+	cs.setKubeletConfig()
+
+	if _, ok := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--fail-swap-on"]; ok {
+		t.Fatalf("expected swap to only be configured on the agent pools")
+	}
+	for i, expected := range []struct {
+		swapSizeMB int
+		failSwapOn string
+	}{
+		{DefaultKubernetesSwapSizeMB, "false"},
+		{0, ""},
+		{4096, "false"},
+	} {
+		k := cs.Properties.AgentPoolProfiles[i].KubernetesConfig
+		if k.SwapSizeMB != expected.swapSizeMB {
+			t.Fatalf("expected agent pool %d to have a swap size of %dMB, got %dMB", i, expected.swapSizeMB, k.SwapSizeMB)
+		}
+		if k.KubeletConfig["--fail-swap-on"] != expected.failSwapOn {
+			t.Fatalf("got unexpected '--fail-swap-on' kubelet config value for agent pool %d: %s", i, k.KubeletConfig["--fail-swap-on"])
+		}
+	}
+}
+
+func TestKubeletConfigReadOnlyRootFilesystem(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ReadOnlyRootFilesystem = helpers.PointerToBool(true)
+	for _, name := range []string{"agentpool2", "windowspool"} {
+		pool := *cs.Properties.AgentPoolProfiles[0]
+		pool.Name = name
+		cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &pool)
+	}
+	cs.Properties.AgentPoolProfiles[1].KubernetesConfig = &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(false)}
+	cs.Properties.AgentPoolProfiles[2].OSType = Windows
+	cs.setKubeletConfig()
+
+	for i, expected := range []bool{true, false, false} {
+		if enabled := cs.Properties.AgentPoolProfiles[i].KubernetesConfig.IsReadOnlyRootFilesystemEnabled(); enabled != expected {
+			t.Fatalf("expected agent pool %d to have a read-only root filesystem %t, got %t", i, expected, enabled)
+		}
+	}
+}
+
+func TestKubeletConfigCgroupV2(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupV2Enabled = helpers.PointerToBool(true)
+	pool := *cs.Properties.AgentPoolProfiles[0]
+	pool.Name = "agentpool2"
+	pool.KubernetesConfig = &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(false)}
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &pool)
+	cs.setKubeletConfig()
+
+	if !cs.Properties.MasterProfile.KubernetesConfig.IsCgroupV2Enabled() {
+		t.Fatalf("expected the master to inherit the cluster-wide cgroup v2 hierarchy")
+	}
+	for i, expected := range []bool{true, false} {
+		if enabled := cs.Properties.AgentPoolProfiles[i].KubernetesConfig.IsCgroupV2Enabled(); enabled != expected {
+			t.Fatalf("expected agent pool %d to boot with the cgroup v2 hierarchy %t, got %t", i, expected, enabled)
+		}
+		if driver := cs.Properties.AgentPoolProfiles[i].KubernetesConfig.KubeletConfig["--cgroup-driver"]; driver != CgroupDriverSystemd {
+			t.Fatalf("expected agent pool %d to use the systemd cgroup driver, got %s", i, driver)
+		}
+	}
+
+	// a single pool booting with the cgroup v2 hierarchy switches the cluster to the systemd cgroup driver
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.AgentPoolProfiles[0].KubernetesConfig = &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)}
+	cs.setKubeletConfig()
+	if cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupDriver != CgroupDriverSystemd {
+		t.Fatalf("expected the systemd cgroup driver, got %s", cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupDriver)
+	}
+	if cs.Properties.MasterProfile.KubernetesConfig.IsCgroupV2Enabled() {
+		t.Fatalf("expected the master to keep the cgroup v1 hierarchy")
+	}
+}
+
+func TestKubeletConfigProcessLimits(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.11.5", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PodMaxPids = 2048
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PidMax = 1048576
+	cs.Properties.OrchestratorProfile.KubernetesConfig.MaxOpenFiles = 1048576
+	pool := *cs.Properties.AgentPoolProfiles[0]
+	pool.Name = "agentpool2"
+	pool.KubernetesConfig = &KubernetesConfig{PodMaxPids: 512}
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &pool)
+	cs.setKubeletConfig()
+
+	if pids := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--pod-max-pids"]; pids != "2048" {
+		t.Fatalf("expected the cluster-wide --pod-max-pids to be 2048, got %s", pids)
+	}
+	configs := []*KubernetesConfig{
+		cs.Properties.MasterProfile.KubernetesConfig,
+		cs.Properties.AgentPoolProfiles[0].KubernetesConfig,
+		cs.Properties.AgentPoolProfiles[1].KubernetesConfig,
+	}
+	for i, expected := range []string{"2048", "2048", "512"} {
+		if pids := configs[i].KubeletConfig["--pod-max-pids"]; pids != expected {
+			t.Fatalf("expected node config %d to run the kubelet with --pod-max-pids %s, got %s", i, expected, pids)
+		}
+		if configs[i].PidMax != 1048576 || configs[i].MaxOpenFiles != 1048576 {
+			t.Fatalf("expected node config %d to inherit the cluster-wide PID and open files limits, got %d and %d", i, configs[i].PidMax, configs[i].MaxOpenFiles)
+		}
+	}
+
+	// the kubelet of the older versions can't limit the PIDs of the pods
+	cs = CreateMockContainerService("testcluster", "1.9.10", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PodMaxPids = 2048
+	cs.setKubeletConfig()
+	if pids, ok := cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig["--pod-max-pids"]; ok {
+		t.Fatalf("expected no --pod-max-pids for Kubernetes 1.9.10, got %s", pids)
+	}
+}
+
+func TestKubeletConfigCgroupDriver(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setKubeletConfig()
+	if cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupDriver != DefaultCgroupDriver {
+		t.Fatalf("expected the default cgroup driver %s, got %s", DefaultCgroupDriver, cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupDriver)
+	}
+
+	// an existing kubeletConfig value is adopted as the cgroup driver of the container runtime
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--cgroup-driver"] = CgroupDriverSystemd
+	cs.setKubeletConfig()
+	if cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupDriver != CgroupDriverSystemd {
+		t.Fatalf("expected the cgroup driver to be adopted from kubeletConfig, got %s", cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupDriver)
+	}
+
+	// the cgroup driver takes precedence over the kubelet config of the cluster, masters and pools
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.CgroupDriver = CgroupDriverSystemd
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--cgroup-driver"] = CgroupDriverCgroupfs
+	cs.Properties.AgentPoolProfiles[0].KubernetesConfig = &KubernetesConfig{
+		KubeletConfig: map[string]string{"--cgroup-driver": CgroupDriverCgroupfs},
+	}
+	windowsPool := *cs.Properties.AgentPoolProfiles[0]
+	windowsPool.Name = "windowspool"
+	windowsPool.OSType = Windows
+	windowsPool.KubernetesConfig = nil
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &windowsPool)
+	cs.setKubeletConfig()
+	for name, k := range map[string]map[string]string{
+		"cluster": cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig,
+		"master":  cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig,
+		"agent":   cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig,
+	} {
+		if k["--cgroup-driver"] != CgroupDriverSystemd {
+			t.Fatalf("got unexpected '--cgroup-driver' %s kubelet config value: %s", name, k["--cgroup-driver"])
+		}
+	}
+	if _, ok := cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig["--cgroup-driver"]; ok {
+		t.Fatalf("expected no '--cgroup-driver' kubelet config for Windows agent pools")
+	}
+}
+
+func TestKubeletConfigContainerLogRotation(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setKubeletConfig()
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if k.ContainerLogMaxSize != DefaultContainerLogMaxSize || k.ContainerLogMaxFiles != DefaultContainerLogMaxFiles {
+		t.Fatalf("expected the default container log rotation %s/%d, got %s/%d", DefaultContainerLogMaxSize, DefaultContainerLogMaxFiles, k.ContainerLogMaxSize, k.ContainerLogMaxFiles)
+	}
+	// docker rotates the container logs itself
+	for _, key := range []string{"--container-log-max-size", "--container-log-max-files"} {
+		if _, ok := k.KubeletConfig[key]; ok {
+			t.Fatalf("expected no '%s' kubelet config with docker", key)
+		}
+	}
+
+	// existing kubeletConfig values are adopted as the container log rotation
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--container-log-max-size"] = "20Mi"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--container-log-max-files"] = "3"
+	cs.setKubeletConfig()
+	k = cs.Properties.OrchestratorProfile.KubernetesConfig
+	if k.ContainerLogMaxSize != "20Mi" || k.ContainerLogMaxFiles != 3 {
+		t.Fatalf("expected the container log rotation to be adopted from kubeletConfig, got %s/%d", k.ContainerLogMaxSize, k.ContainerLogMaxFiles)
+	}
+
+	// with containerd the kubelet rotates the container logs, the settings taking precedence over
+	// the kubelet config of the cluster, masters and pools
+	cs = CreateMockContainerService("testcluster", "1.11.2", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ContainerRuntime = "containerd"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ContainerLogMaxSize = "100Mi"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ContainerLogMaxFiles = 10
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--container-log-max-size"] = "20Mi"
+	cs.Properties.AgentPoolProfiles[0].KubernetesConfig = &KubernetesConfig{
+		KubeletConfig: map[string]string{"--container-log-max-files": "3"},
+	}
+	windowsPool := *cs.Properties.AgentPoolProfiles[0]
+	windowsPool.Name = "windowspool"
+	windowsPool.OSType = Windows
+	windowsPool.KubernetesConfig = nil
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &windowsPool)
+	cs.setKubeletConfig()
+	for name, k := range map[string]map[string]string{
+		"cluster": cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig,
+		"master":  cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig,
+		"agent":   cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig,
+	} {
+		if k["--container-log-max-size"] != "100Mi" || k["--container-log-max-files"] != "10" {
+			t.Fatalf("got unexpected container log rotation %s kubelet config values: %s/%s", name, k["--container-log-max-size"], k["--container-log-max-files"])
+		}
+	}
+	if _, ok := cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig["--container-log-max-size"]; ok {
+		t.Fatalf("expected no '--container-log-max-size' kubelet config for Windows agent pools")
+	}
+}
+
+func TestKubeletConfigTLS(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setKubeletConfig()
+	for _, key := range []string{"--tls-cipher-suites", "--tls-min-version"} {
+		if _, ok := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig[key]; ok {
+			t.Fatalf("expected no '%s' kubelet config by default", key)
+		}
+	}
+
+	// the TLS settings take precedence over the kubelet config of the cluster, masters and pools, Windows included
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.TLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.TLSMinVersion = "VersionTLS12"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--tls-min-version"] = "VersionTLS10"
+	cs.Properties.MasterProfile.KubernetesConfig = &KubernetesConfig{
+		KubeletConfig: map[string]string{"--tls-cipher-suites": "TLS_RSA_WITH_RC4_128_SHA"},
+	}
+	windowsPool := *cs.Properties.AgentPoolProfiles[0]
+	windowsPool.Name = "windowspool"
+	windowsPool.OSType = Windows
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &windowsPool)
+	cs.setKubeletConfig()
+	for name, k := range map[string]map[string]string{
+		"cluster": cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig,
+		"master":  cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig,
+		"agent":   cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig,
+		"windows": cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig,
+	} {
+		if k["--tls-cipher-suites"] != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384" || k["--tls-min-version"] != "VersionTLS12" {
+			t.Fatalf("got unexpected TLS %s kubelet config values: %s/%s", name, k["--tls-cipher-suites"], k["--tls-min-version"])
+		}
+	}
+}
+
+func TestKubeletConfigCertRotation(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.12.2", 3, 2, false)
+	cs.setKubeletConfig()
+	for _, key := range []string{"--rotate-certificates", "--rotate-server-certificates"} {
+		if _, ok := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig[key]; ok {
+			t.Fatalf("expected no '%s' kubelet config by default", key)
+		}
+	}
+
+	// the cert rotation takes precedence over the kubelet config of the cluster, masters and pools, Windows included
+	cs = CreateMockContainerService("testcluster", "1.10.8", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnableKubeletCertRotation = helpers.PointerToBool(true)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--rotate-certificates"] = "false"
+	cs.Properties.MasterProfile.KubernetesConfig = &KubernetesConfig{
+		KubeletConfig: map[string]string{"--rotate-certificates": "false"},
+	}
+	windowsPool := *cs.Properties.AgentPoolProfiles[0]
+	windowsPool.Name = "windowspool"
+	windowsPool.OSType = Windows
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &windowsPool)
+	cs.setKubeletConfig()
+	for name, k := range map[string]map[string]string{
+		"cluster": cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig,
+		"master":  cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig,
+		"agent":   cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig,
+		"windows": cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig,
+	} {
+		if k["--rotate-certificates"] != "true" {
+			t.Fatalf("got unexpected '--rotate-certificates' %s kubelet config value: %s", name, k["--rotate-certificates"])
+		}
+		// nothing approves the serving certificate signing requests of the kubelets
+		if _, ok := k["--rotate-server-certificates"]; ok || strings.Contains(k["--feature-gates"], "RotateKubeletServerCertificate") {
+			t.Fatalf("got unexpected serving cert rotation %s kubelet config values: %s/%s", name, k["--rotate-server-certificates"], k["--feature-gates"])
+		}
+	}
+}
+
+func TestKubeletConfigAgentPoolSysctls(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.11.2", 3, 2, false)
+	cs.Properties.AgentPoolProfiles[0].Sysctls = map[string]string{
+		"net.core.somaxconn":           "16384",
+		"net.ipv4.tcp_max_syn_backlog": "8192",
+		"net.ipv4.ip_local_port_range": "1024 65000",
+		"fs.inotify.max_user_watches":  "1048576",
+		"kernel.msgmax":                "65536",
+	}
+	otherPool := *cs.Properties.AgentPoolProfiles[0]
+	otherPool.Name = "otherpool"
+	otherPool.Sysctls = nil
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &otherPool)
+	cs.setKubeletConfig()
+	k := cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig
+	// the safe and the node-level sysctls need no allowing
+	if k["--allowed-unsafe-sysctls"] != "kernel.msgmax,net.core.somaxconn,net.ipv4.tcp_max_syn_backlog" {
+		t.Fatalf("got unexpected '--allowed-unsafe-sysctls' kubelet config value: %s", k["--allowed-unsafe-sysctls"])
+	}
+	if _, ok := cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig["--allowed-unsafe-sysctls"]; ok {
+		t.Fatalf("expected no '--allowed-unsafe-sysctls' kubelet config for an agent pool without sysctls")
+	}
+
+	// node-level sysctls only
+	cs = CreateMockContainerService("testcluster", "1.11.2", 3, 2, false)
+	cs.Properties.AgentPoolProfiles[0].Sysctls = map[string]string{
+		"fs.file-max":                    "2097152",
+		"net.netfilter.nf_conntrack_max": "1048576",
+	}
+	cs.setKubeletConfig()
+	if _, ok := cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig["--allowed-unsafe-sysctls"]; ok {
+		t.Fatalf("expected no '--allowed-unsafe-sysctls' kubelet config for node-level sysctls")
+	}
+
+	// the flag is experimental before 1.11, and a user-configured value is kept
+	cs = CreateMockContainerService("testcluster", "1.10.8", 3, 2, false)
+	cs.Properties.AgentPoolProfiles[0].Sysctls = map[string]string{"net.core.somaxconn": "16384"}
+	otherPool = *cs.Properties.AgentPoolProfiles[0]
+	otherPool.Name = "otherpool"
+	otherPool.KubernetesConfig = &KubernetesConfig{
+		KubeletConfig: map[string]string{"--experimental-allowed-unsafe-sysctls": "net.*"},
+	}
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &otherPool)
+	cs.setKubeletConfig()
+	if v := cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig["--experimental-allowed-unsafe-sysctls"]; v != "net.core.somaxconn" {
+		t.Fatalf("got unexpected '--experimental-allowed-unsafe-sysctls' kubelet config value: %s", v)
+	}
+	if v := cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig["--experimental-allowed-unsafe-sysctls"]; v != "net.*" {
+		t.Fatalf("expected the user-configured '--experimental-allowed-unsafe-sysctls' to be kept, got %s", v)
+	}
+}
+
+func TestKubeletConfigNodeLocalDNS(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.DNSServiceIP = DefaultKubernetesDNSServiceIP
+	cs.setKubeletConfig()
+	if v := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--cluster-dns"]; v != DefaultKubernetesDNSServiceIP {
+		t.Fatalf("expected '--cluster-dns' to be the DNS service IP by default, got %s", v)
+	}
+
+	// the Linux kubelets hand the local IP of the cache to the pods, the Windows kubelets keep the DNS service IP
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.DNSServiceIP = DefaultKubernetesDNSServiceIP
+	cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []KubernetesAddon{
+		{
+			Name:    DefaultNodeLocalDNSAddonName,
+			Enabled: helpers.PointerToBool(true),
+			Config:  map[string]string{"localIP": "169.254.25.10"},
+		},
+	}
+	windowsPool := *cs.Properties.AgentPoolProfiles[0]
+	windowsPool.Name = "windowspool"
+	windowsPool.OSType = Windows
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &windowsPool)
+	cs.setKubeletConfig()
+	for name, k := range map[string]map[string]string{
+		"cluster": cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig,
+		"master":  cs.Properties.MasterProfile.KubernetesConfig.KubeletConfig,
+		"agent":   cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig,
+	} {
+		if k["--cluster-dns"] != "169.254.25.10" {
+			t.Fatalf("expected the %s '--cluster-dns' to be the nodelocaldns local IP, got %s", name, k["--cluster-dns"])
+		}
+	}
+	if v := cs.Properties.AgentPoolProfiles[1].KubernetesConfig.KubeletConfig["--cluster-dns"]; v != DefaultKubernetesDNSServiceIP {
+		t.Fatalf("expected the windows '--cluster-dns' to be the DNS service IP, got %s", v)
+	}
+}
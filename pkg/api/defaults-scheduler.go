@@ -3,6 +3,9 @@
 
 package api
 
+// schedulerPolicyFilePath is where the scheduler policy document is placed on the masters
+const schedulerPolicyFilePath = "/etc/kubernetes/scheduler-policy.json"
+
 // staticSchedulerConfig is not user-overridable
 var staticSchedulerConfig = map[string]string{
 	"--kubeconfig":   "/var/lib/kubelet/kubeconfig",
@@ -20,14 +23,13 @@ func (cs *ContainerService) setSchedulerConfig() {
 
 	// If no user-configurable scheduler config values exists, use the defaults
 	if o.KubernetesConfig.SchedulerConfig == nil {
-		o.KubernetesConfig.SchedulerConfig = defaultSchedulerConfig
-	} else {
-		for key, val := range defaultSchedulerConfig {
-			// If we don't have a user-configurable scheduler config for each option
-			if _, ok := o.KubernetesConfig.SchedulerConfig[key]; !ok {
-				// then assign the default value
-				o.KubernetesConfig.SchedulerConfig[key] = val
-			}
+		o.KubernetesConfig.SchedulerConfig = make(map[string]string)
+	}
+	for key, val := range defaultSchedulerConfig {
+		// If we don't have a user-configurable scheduler config for each option
+		if _, ok := o.KubernetesConfig.SchedulerConfig[key]; !ok {
+			// then assign the default value
+			o.KubernetesConfig.SchedulerConfig[key] = val
 		}
 	}
 
@@ -36,4 +38,17 @@ func (cs *ContainerService) setSchedulerConfig() {
 	for key, val := range staticSchedulerConfig {
 		o.KubernetesConfig.SchedulerConfig[key] = val
 	}
+	// Serve the metrics on the secure port of all the interfaces, authenticating and authorizing the scrapers against the apiserver
+	if o.KubernetesConfig.IsPrometheusMetricsEnabled() {
+		o.KubernetesConfig.SchedulerConfig["--bind-address"] = "0.0.0.0"
+		o.KubernetesConfig.SchedulerConfig["--secure-port"] = DefaultKubernetesSchedulerSecurePort
+		o.KubernetesConfig.SchedulerConfig["--authentication-kubeconfig"] = "/var/lib/kubelet/kubeconfig"
+		o.KubernetesConfig.SchedulerConfig["--authorization-kubeconfig"] = "/var/lib/kubelet/kubeconfig"
+	}
+	addFeatureGates(o.KubernetesConfig.SchedulerConfig, o.KubernetesConfig.FeatureGates)
+
+	// The scheduler policy document is written to the masters by cloud-init
+	if o.KubernetesConfig.SchedulerPolicy != "" {
+		o.KubernetesConfig.SchedulerConfig["--policy-config-file"] = schedulerPolicyFilePath
+	}
 }
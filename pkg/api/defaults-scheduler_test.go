@@ -5,6 +5,8 @@ package api
 
 import (
 	"testing"
+
+	"github.com/Azure/acs-engine/pkg/helpers"
 )
 
 func TestSchedulerDefaultConfig(t *testing.T) {
@@ -82,3 +84,57 @@ func TestSchedulerConfigEnableProfiling(t *testing.T) {
 			s["--profiling"])
 	}
 }
+
+func TestSchedulerConfigSchedulerPolicy(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerPolicy = `{"kind": "Policy", "apiVersion": "v1"}`
+	cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig = map[string]string{
+		"--policy-config-file": "/etc/kubernetes/other-policy.json",
+	}
+	cs.setSchedulerConfig()
+	s := cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig
+	if s["--policy-config-file"] != "/etc/kubernetes/scheduler-policy.json" {
+		t.Fatalf("got unexpected '--policy-config-file' Scheduler config value: %s",
+			s["--policy-config-file"])
+	}
+
+	// Test default
+	cs = CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
+	cs.setSchedulerConfig()
+	s = cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig
+	if _, ok := s["--policy-config-file"]; ok {
+		t.Fatalf("got unexpected '--policy-config-file' Scheduler config value without a scheduler policy: %s",
+			s["--policy-config-file"])
+	}
+}
+
+func TestSchedulerConfigEnablePrometheusMetrics(t *testing.T) {
+	// Test EnablePrometheusMetrics = true, which takes precedence over schedulerConfig
+	cs := CreateMockContainerService("testcluster", "1.12.2", 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnablePrometheusMetrics = helpers.PointerToBool(true)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig = map[string]string{
+		"--secure-port": "0",
+	}
+	cs.setSchedulerConfig()
+	s := cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig
+	for flag, expected := range map[string]string{
+		"--bind-address":              "0.0.0.0",
+		"--secure-port":               DefaultKubernetesSchedulerSecurePort,
+		"--authentication-kubeconfig": "/var/lib/kubelet/kubeconfig",
+		"--authorization-kubeconfig":  "/var/lib/kubelet/kubeconfig",
+	} {
+		if s[flag] != expected {
+			t.Fatalf("got unexpected '%s' Scheduler config value for EnablePrometheusMetrics=true: %s, expected %s", flag, s[flag], expected)
+		}
+	}
+
+	// Test default
+	cs = CreateMockContainerService("testcluster", "1.12.2", 3, 2, false)
+	cs.setSchedulerConfig()
+	s = cs.Properties.OrchestratorProfile.KubernetesConfig.SchedulerConfig
+	for _, flag := range []string{"--bind-address", "--secure-port", "--authentication-kubeconfig", "--authorization-kubeconfig"} {
+		if _, ok := s[flag]; ok {
+			t.Fatalf("got unexpected '%s' Scheduler config value: %s", flag, s[flag])
+		}
+	}
+}
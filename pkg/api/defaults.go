@@ -13,6 +13,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/acs-engine/pkg/api/common"
 	"github.com/Azure/acs-engine/pkg/helpers"
@@ -38,6 +39,7 @@ func (cs *ContainerService) SetPropertiesDefaults(isUpgrade, isScale bool) (bool
 	properties.setAgentProfileDefaults(isUpgrade, isScale)
 
 	properties.setStorageDefaults()
+	cs.setAvailabilitySetDefaults(isUpgrade, isScale)
 	properties.setExtensionDefaults()
 	// Set VMSS Defaults for Agents
 	if cs.Properties.HasVMSSAgentPool() {
@@ -53,7 +55,15 @@ func (cs *ContainerService) SetPropertiesDefaults(isUpgrade, isScale bool) (bool
 	if e != nil {
 		return false, e
 	}
-	return certsGenerated, nil
+	keyGenerated, e := properties.setDefaultServiceAccountSigningKey()
+	if e != nil {
+		return false, e
+	}
+	frontProxyCertsGenerated, e := properties.setDefaultFrontProxyCerts()
+	if e != nil {
+		return false, e
+	}
+	return certsGenerated || keyGenerated || frontProxyCertsGenerated, nil
 }
 
 // setOrchestratorDefaults for orchestrators
@@ -115,7 +125,7 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			o.KubernetesConfig.ContainerRuntime = DefaultContainerRuntime
 		}
 		if o.KubernetesConfig.ClusterSubnet == "" {
-			if o.IsAzureCNI() {
+			if o.IsAzureCNIVNETIntegrated() {
 				// When Azure CNI is enabled, all masters, agents and pods share the same large subnet.
 				// Except when master is VMSS, then masters and agents have separate subnets within the same large subnet.
 				o.KubernetesConfig.ClusterSubnet = DefaultKubernetesSubnet
@@ -132,6 +142,17 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 		if o.KubernetesConfig.DNSServiceIP == "" {
 			o.KubernetesConfig.DNSServiceIP = DefaultKubernetesDNSServiceIP
 		}
+		if o.KubernetesConfig.ClusterDomain == "" {
+			// clusters defined before clusterDomain existed could only set it through the kubelet config
+			if clusterDomain := o.KubernetesConfig.KubeletConfig["--cluster-domain"]; clusterDomain != "" {
+				o.KubernetesConfig.ClusterDomain = clusterDomain
+			} else {
+				o.KubernetesConfig.ClusterDomain = DefaultKubernetesClusterDomain
+			}
+		}
+		if o.KubernetesConfig.KubeProxyDeploymentMode == "" {
+			o.KubernetesConfig.KubeProxyDeploymentMode = DefaultKubeProxyDeploymentMode
+		}
 		if o.KubernetesConfig.DockerBridgeSubnet == "" {
 			o.KubernetesConfig.DockerBridgeSubnet = DefaultDockerBridgeSubnet
 		}
@@ -176,12 +197,51 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			}
 		}
 
+		if a.OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB == 0 {
+			a.OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB = DefaultEtcdStorageLimitGB
+		}
+
+		if a.MasterProfile != nil && a.MasterProfile.Count > 0 {
+			// Each apiserver serves its share of the cluster, so scale the inflight limits by nodes per master
+			multiplier := 1
+			switch nodesPerMaster := a.TotalNodes() / a.MasterProfile.Count; {
+			case nodesPerMaster > 500:
+				multiplier = DefaultKubernetesAPIServerInflightNodesPerMasterGT500
+			case nodesPerMaster > 100:
+				multiplier = DefaultKubernetesAPIServerInflightNodesPerMasterGT100
+			}
+			// Values previously configured through apiServerConfig are carried over
+			if o.KubernetesConfig.MaxRequestsInflight == 0 {
+				if v, err := strconv.Atoi(o.KubernetesConfig.APIServerConfig["--max-requests-inflight"]); err == nil && v > 0 {
+					o.KubernetesConfig.MaxRequestsInflight = v
+				} else {
+					o.KubernetesConfig.MaxRequestsInflight = DefaultKubernetesAPIServerMaxRequestsInflight * multiplier
+				}
+			}
+			if o.KubernetesConfig.MaxMutatingRequestsInflight == 0 {
+				if v, err := strconv.Atoi(o.KubernetesConfig.APIServerConfig["--max-mutating-requests-inflight"]); err == nil && v > 0 {
+					o.KubernetesConfig.MaxMutatingRequestsInflight = v
+				} else {
+					o.KubernetesConfig.MaxMutatingRequestsInflight = DefaultKubernetesAPIServerMaxMutatingRequestsInflight * multiplier
+				}
+			}
+		}
+
 		if helpers.IsTrueBoolPointer(o.KubernetesConfig.EnableDataEncryptionAtRest) {
 			if "" == a.OrchestratorProfile.KubernetesConfig.EtcdEncryptionKey {
 				a.OrchestratorProfile.KubernetesConfig.EtcdEncryptionKey = generateEtcdEncryptionKey()
 			}
 		}
 
+		// the nodes of a cluster generated without agent pools are added later, and join it with a bootstrap token
+		if len(a.AgentPoolProfiles) == 0 && !a.IsHostedMasterProfile() && o.KubernetesConfig.BootstrapTokenTTL == "" &&
+			common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.8.0") {
+			o.KubernetesConfig.BootstrapTokenTTL = DefaultControlPlaneOnlyBootstrapTokenTTL
+		}
+		if o.KubernetesConfig.IsBootstrapTokenEnabled() {
+			setBootstrapToken(o.KubernetesConfig, time.Now())
+		}
+
 		if a.OrchestratorProfile.KubernetesConfig.PrivateJumpboxProvision() && a.OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile.OSDiskSizeGB == 0 {
 			a.OrchestratorProfile.KubernetesConfig.PrivateCluster.JumpboxProfile.OSDiskSizeGB = DefaultJumpboxDiskSize
 		}
@@ -208,6 +268,25 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			a.OrchestratorProfile.KubernetesConfig.EnableSecureKubelet = helpers.PointerToBool(DefaultSecureKubeletEnabled)
 		}
 
+		if a.OrchestratorProfile.KubernetesConfig.EnableInsecurePort == nil {
+			a.OrchestratorProfile.KubernetesConfig.EnableInsecurePort = helpers.PointerToBool(DefaultInsecurePortEnabled)
+		}
+
+		if sc := a.OrchestratorProfile.KubernetesConfig.DefaultStorageClass; sc != nil {
+			if sc.Provisioner == "" {
+				sc.Provisioner = StorageClassProvisionerAzureDisk
+			}
+			if sc.SKU == "" {
+				sc.SKU = DefaultStorageClassSKU
+			}
+			if sc.ReclaimPolicy == "" {
+				sc.ReclaimPolicy = DefaultStorageClassReclaimPolicy
+			}
+			if sc.VolumeBindingMode == "" {
+				sc.VolumeBindingMode = DefaultStorageClassVolumeBindingMode
+			}
+		}
+
 		if a.OrchestratorProfile.KubernetesConfig.UseInstanceMetadata == nil {
 			a.OrchestratorProfile.KubernetesConfig.UseInstanceMetadata = helpers.PointerToBool(DefaultUseInstanceMetadata)
 		}
@@ -220,6 +299,18 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			a.OrchestratorProfile.KubernetesConfig.ExcludeMasterFromStandardLB = helpers.PointerToBool(DefaultExcludeMasterFromStandardLB)
 		}
 
+		// Egress goes through the NAT gateway or the egress firewall, so the cloud provider must not add SNAT to
+		// the service load balancing rules
+		if a.OrchestratorProfile.KubernetesConfig.HasNATGateway() ||
+			(a.OrchestratorProfile.KubernetesConfig.IsAgentOutboundDisabled() && a.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == "Standard") {
+			if a.OrchestratorProfile.KubernetesConfig.CloudProviderConfig == nil {
+				a.OrchestratorProfile.KubernetesConfig.CloudProviderConfig = map[string]string{}
+			}
+			if _, ok := a.OrchestratorProfile.KubernetesConfig.CloudProviderConfig["disableOutboundSNAT"]; !ok {
+				a.OrchestratorProfile.KubernetesConfig.CloudProviderConfig["disableOutboundSNAT"] = "true"
+			}
+		}
+
 		if a.OrchestratorProfile.IsAzureCNI() {
 			if a.HasWindows() {
 				a.OrchestratorProfile.KubernetesConfig.AzureCNIVersion = AzureCniPluginVerWindows
@@ -295,9 +386,9 @@ func (p *Properties) setMasterProfileDefaults(isUpgrade bool) {
 
 	if !p.MasterProfile.IsCustomVNET() {
 		if p.OrchestratorProfile.OrchestratorType == Kubernetes {
-			if p.OrchestratorProfile.IsAzureCNI() {
+			if p.OrchestratorProfile.IsAzureCNIVNETIntegrated() {
 				// When VNET integration is enabled, all masters, agents and pods share the same large subnet.
-				p.MasterProfile.Subnet = p.OrchestratorProfile.KubernetesConfig.ClusterSubnet
+				p.MasterProfile.Subnet = p.OrchestratorProfile.KubernetesConfig.GetIPv4ClusterSubnet()
 				// FirstConsecutiveStaticIP is not reset if it is upgrade and some value already exists
 				if !isUpgrade || len(p.MasterProfile.FirstConsecutiveStaticIP) == 0 {
 					if p.MasterProfile.IsVirtualMachineScaleSets() {
@@ -362,7 +453,7 @@ func (p *Properties) setMasterProfileDefaults(isUpgrade bool) {
 		p.MasterProfile.IPAddressCount = 1
 
 		// Allocate IP addresses for pods if VNET integration is enabled.
-		if p.OrchestratorProfile.IsAzureCNI() {
+		if p.OrchestratorProfile.IsAzureCNIVNETIntegrated() {
 			if p.OrchestratorProfile.OrchestratorType == Kubernetes {
 				masterMaxPods, _ := strconv.Atoi(p.MasterProfile.KubernetesConfig.KubeletConfig["--max-pods"])
 				p.MasterProfile.IPAddressCount += masterMaxPods
@@ -373,6 +464,16 @@ func (p *Properties) setMasterProfileDefaults(isUpgrade bool) {
 	if p.MasterProfile.HTTPSourceAddressPrefix == "" {
 		p.MasterProfile.HTTPSourceAddressPrefix = "*"
 	}
+
+	if p.MasterProfile.LoadBalancerIdleTimeoutInMinutes == 0 {
+		p.MasterProfile.LoadBalancerIdleTimeoutInMinutes = DefaultMasterLoadBalancerIdleTimeoutInMinutes
+	}
+	if p.MasterProfile.LoadBalancerProbeIntervalInSeconds == 0 {
+		p.MasterProfile.LoadBalancerProbeIntervalInSeconds = DefaultMasterLoadBalancerProbeIntervalInSeconds
+	}
+	if p.MasterProfile.LoadBalancerProbeThreshold == 0 {
+		p.MasterProfile.LoadBalancerProbeThreshold = DefaultMasterLoadBalancerProbeThreshold
+	}
 }
 
 // setVMSSDefaultsForMasters
@@ -473,7 +574,7 @@ func (p *Properties) setAgentProfileDefaults(isUpgrade, isScale bool) {
 			profile.IPAddressCount = 1
 
 			// Allocate IP addresses for pods if VNET integration is enabled.
-			if p.OrchestratorProfile.IsAzureCNI() {
+			if p.OrchestratorProfile.IsAzureCNIVNETIntegrated() {
 				agentPoolMaxPods, _ := strconv.Atoi(profile.KubernetesConfig.KubeletConfig["--max-pods"])
 				profile.IPAddressCount += agentPoolMaxPods
 			}
@@ -481,6 +582,33 @@ func (p *Properties) setAgentProfileDefaults(isUpgrade, isScale bool) {
 	}
 }
 
+// setAvailabilitySetDefaults sets the fault and update domain counts of the availability sets with managed disks.
+// New clusters use as many fault domains as their region has, whereas the existing availability sets of an upgraded
+// or scaled cluster keep the counts they were created with, as these cannot be changed
+func (cs *ContainerService) setAvailabilitySetDefaults(isUpgrade, isScale bool) {
+	faultDomainCount := DefaultPlatformFaultDomainCount
+	if !isUpgrade && !isScale {
+		faultDomainCount = helpers.GetMaxPlatformFaultDomainCount(cs.Location)
+	}
+	setDefaults := func(platformFaultDomainCount, platformUpdateDomainCount **int) {
+		if *platformFaultDomainCount == nil {
+			*platformFaultDomainCount = helpers.PointerToInt(faultDomainCount)
+		}
+		if *platformUpdateDomainCount == nil {
+			*platformUpdateDomainCount = helpers.PointerToInt(DefaultPlatformUpdateDomainCount)
+		}
+	}
+
+	if m := cs.Properties.MasterProfile; m != nil && !m.IsVirtualMachineScaleSets() && m.IsManagedDisks() {
+		setDefaults(&m.PlatformFaultDomainCount, &m.PlatformUpdateDomainCount)
+	}
+	for _, profile := range cs.Properties.AgentPoolProfiles {
+		if profile.IsAvailabilitySets() && profile.IsManagedDisks() {
+			setDefaults(&profile.PlatformFaultDomainCount, &profile.PlatformUpdateDomainCount)
+		}
+	}
+}
+
 // setStorageDefaults for agents
 func (p *Properties) setStorageDefaults() {
 	if p.MasterProfile != nil && len(p.MasterProfile.StorageProfile) == 0 {
@@ -508,6 +636,9 @@ func (p *Properties) setStorageDefaults() {
 		if len(profile.ScaleSetEvictionPolicy) == 0 && profile.ScaleSetPriority == ScaleSetPriorityLow {
 			profile.ScaleSetEvictionPolicy = ScaleSetEvictionPolicyDelete
 		}
+		if len(profile.UpgradePolicyMode) == 0 && profile.IsVirtualMachineScaleSets() && p.OrchestratorProfile.OrchestratorType == Kubernetes {
+			profile.UpgradePolicyMode = UpgradePolicyModeManual
+		}
 	}
 }
 
@@ -578,13 +709,13 @@ func (p *Properties) setDefaultCerts() (bool, []net.IP, error) {
 		p.CertificateProfile.CaPrivateKey = caPair.PrivateKeyPem
 	}
 
-	cidrFirstIP, err := common.CidrStringFirstIP(p.OrchestratorProfile.KubernetesConfig.ServiceCIDR)
+	cidrFirstIP, err := common.CidrStringFirstIP(p.OrchestratorProfile.KubernetesConfig.GetIPv4ServiceCIDR())
 	if err != nil {
 		return false, ips, err
 	}
 	ips = append(ips, cidrFirstIP)
 
-	apiServerPair, clientPair, kubeConfigPair, etcdServerPair, etcdClientPair, etcdPeerPairs, err := helpers.CreatePki(masterExtraFQDNs, ips, DefaultKubernetesClusterDomain, caPair, p.MasterProfile.Count)
+	apiServerPair, clientPair, kubeConfigPair, etcdServerPair, etcdClientPair, etcdPeerPairs, err := helpers.CreatePki(masterExtraFQDNs, ips, p.OrchestratorProfile.KubernetesConfig.ClusterDomain, caPair, p.MasterProfile.Count)
 	if err != nil {
 		return false, ips, err
 	}
@@ -618,6 +749,58 @@ func (p *Properties) setDefaultCerts() (bool, []net.IP, error) {
 	return true, ips, nil
 }
 
+// setDefaultServiceAccountSigningKey generates the key the API server signs the bound service account tokens with,
+// unless it was provided
+func (p *Properties) setDefaultServiceAccountSigningKey() (bool, error) {
+	if p.MasterProfile == nil || p.OrchestratorProfile == nil || !p.OrchestratorProfile.KubernetesConfig.HasServiceAccountIssuer() {
+		return false, nil
+	}
+	if p.CertificateProfile == nil {
+		p.CertificateProfile = &CertificateProfile{}
+	}
+	if p.CertificateProfile.ServiceAccountSigningKey != "" {
+		return false, nil
+	}
+	key, err := helpers.CreatePkiPrivateKey()
+	if err != nil {
+		return false, errors.Wrap(err, "error generating the service account signing key")
+	}
+	p.CertificateProfile.ServiceAccountSigningKey = key
+	return true, nil
+}
+
+// setDefaultFrontProxyCerts generates the certificate authority of the aggregation layer, and the client certificate
+// the API server proxies the requests to the aggregated API servers with, unless they were provided
+func (p *Properties) setDefaultFrontProxyCerts() (bool, error) {
+	if p.MasterProfile == nil || p.OrchestratorProfile == nil || p.OrchestratorProfile.OrchestratorType != Kubernetes ||
+		p.OrchestratorProfile.KubernetesConfig == nil || !p.OrchestratorProfile.KubernetesConfig.EnableAggregatedAPIs {
+		return false, nil
+	}
+	if p.CertificateProfile == nil {
+		p.CertificateProfile = &CertificateProfile{}
+	}
+	c := p.CertificateProfile
+	if c.FrontProxyCACertificate != "" && c.FrontProxyClientCertificate != "" && c.FrontProxyClientPrivateKey != "" {
+		return false, nil
+	}
+	if c.FrontProxyCACertificate == "" {
+		caPair, err := helpers.CreatePkiKeyCertPair(DefaultFrontProxyCACommonName)
+		if err != nil {
+			return false, errors.Wrap(err, "error generating the front proxy certificate authority")
+		}
+		c.FrontProxyCACertificate = caPair.CertificatePem
+		c.FrontProxyCAPrivateKey = caPair.PrivateKeyPem
+	}
+	caPair := &helpers.PkiKeyCertPair{CertificatePem: c.FrontProxyCACertificate, PrivateKeyPem: c.FrontProxyCAPrivateKey}
+	clientPair, err := helpers.CreatePkiClientKeyCertPair(DefaultFrontProxyClientCommonName, caPair)
+	if err != nil {
+		return false, errors.Wrap(err, "error generating the front proxy client certificate")
+	}
+	c.FrontProxyClientCertificate = clientPair.CertificatePem
+	c.FrontProxyClientPrivateKey = clientPair.PrivateKeyPem
+	return true, nil
+}
+
 func areAllTrue(m map[string]bool) bool {
 	for _, v := range m {
 		if !v {
@@ -677,6 +860,19 @@ func addDefaultFeatureGates(m map[string]string, version string, minVersion stri
 	}
 }
 
+// addFeatureGates applies the feature gates of the cluster to the --feature-gates of a component,
+// overriding the defaults and the gates configured for the component
+func addFeatureGates(m map[string]string, featureGates map[string]bool) {
+	if len(featureGates) == 0 {
+		return
+	}
+	var values []string
+	for gate, enabled := range featureGates {
+		values = append(values, fmt.Sprintf("%s=%t", gate, enabled))
+	}
+	m["--feature-gates"] = combineValues(m["--feature-gates"], strings.Join(values, ","))
+}
+
 func combineValues(inputs ...string) string {
 	valueMap := make(map[string]string)
 	for _, input := range inputs {
@@ -716,3 +912,31 @@ func generateEtcdEncryptionKey() string {
 	rand.Read(b)
 	return base64.StdEncoding.EncodeToString(b)
 }
+
+// setBootstrapToken generates a new bootstrap token, valid for BootstrapTokenTTL from now,
+// if none exists yet or the current one has already expired
+func setBootstrapToken(k *KubernetesConfig, now time.Time) {
+	ttl, err := time.ParseDuration(k.BootstrapTokenTTL)
+	if err != nil {
+		return
+	}
+	if k.BootstrapToken != "" {
+		expiration, err := time.Parse(time.RFC3339, k.BootstrapTokenExpiration)
+		if err == nil && now.Before(expiration) {
+			return
+		}
+	}
+	k.BootstrapToken = generateBootstrapToken()
+	k.BootstrapTokenExpiration = now.Add(ttl).UTC().Format(time.RFC3339)
+}
+
+// generateBootstrapToken returns a token in the "[a-z0-9]{6}.[a-z0-9]{16}" format expected by the apiserver
+func generateBootstrapToken() string {
+	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 22)
+	rand.Read(b)
+	for i := range b {
+		b[i] = charset[int(b[i])%len(charset)]
+	}
+	return string(b[:6]) + "." + string(b[6:])
+}
@@ -0,0 +1,157 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/api/vlabs"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// deprecatedKubernetesConfigFlag describes the component config flag that replaced a
+// KubernetesConfigDeprecated property
+type deprecatedKubernetesConfigFlag struct {
+	component string
+	flag      string
+}
+
+var deprecatedKubernetesConfigFlags = map[string]deprecatedKubernetesConfigFlag{
+	"nonMasqueradeCidr":                {"kubeletConfig", "--non-masquerade-cidr"},
+	"nodeStatusUpdateFrequency":        {"kubeletConfig", "--node-status-update-frequency"},
+	"hardEvictionThreshold":            {"kubeletConfig", "--eviction-hard"},
+	"ctrlMgrNodeMonitorGracePeriod":    {"controllerManagerConfig", "--node-monitor-grace-period"},
+	"ctrlMgrPodEvictionTimeout":        {"controllerManagerConfig", "--pod-eviction-timeout"},
+	"ctrlMgrRouteReconciliationPeriod": {"controllerManagerConfig", "--route-reconciliation-period"},
+}
+
+// MigrateAPIModel converts an API model authored against any supported apiVersion to the
+// current vlabs schema. Properties that were since replaced by component config flags are
+// carried over to those flags, and the result is validated before it is returned.
+func (a *Apiloader) MigrateAPIModel(contents []byte) ([]byte, string, error) {
+	m := &TypeMeta{}
+	if err := json.Unmarshal(contents, &m); err != nil {
+		return nil, "", errors.Wrap(err, "error parsing the api model")
+	}
+	if m.APIVersion == "" {
+		return nil, "", errors.New("the api model does not specify an apiVersion")
+	}
+
+	contents, flags, err := extractDeprecatedKubernetesConfig(contents)
+	if err != nil {
+		return nil, m.APIVersion, err
+	}
+
+	containerService, version, err := a.DeserializeContainerService(contents, true, false, nil)
+	if err != nil {
+		return nil, m.APIVersion, errors.Wrapf(err, "error loading the %s api model", m.APIVersion)
+	}
+	if containerService.Properties.HostedMasterProfile != nil {
+		return nil, version, errors.Errorf("converting agent pool only api models (apiVersion %s) is not supported", version)
+	}
+
+	if containerService.Properties.OrchestratorProfile.IsDCOS() {
+		renameDCOSPublicAgentPools(containerService.Properties)
+	}
+
+	if len(flags) > 0 {
+		o := containerService.Properties.OrchestratorProfile
+		if o.KubernetesConfig == nil {
+			o.KubernetesConfig = &KubernetesConfig{}
+		}
+		for component, values := range flags {
+			var config *map[string]string
+			switch component {
+			case "kubeletConfig":
+				config = &o.KubernetesConfig.KubeletConfig
+			case "controllerManagerConfig":
+				config = &o.KubernetesConfig.ControllerManagerConfig
+			}
+			if *config == nil {
+				*config = make(map[string]string)
+			}
+			for flag, val := range values {
+				// an explicitly configured flag takes precedence over the deprecated property
+				if _, ok := (*config)[flag]; !ok {
+					(*config)[flag] = val
+				}
+			}
+		}
+	}
+
+	converted, err := a.SerializeContainerService(containerService, vlabs.APIVersion)
+	if err != nil {
+		return nil, version, err
+	}
+	if _, _, err := a.DeserializeContainerService(converted, true, false, nil); err != nil {
+		return nil, version, errors.Wrap(err, "the converted api model is not valid")
+	}
+	return converted, version, nil
+}
+
+// renameDCOSPublicAgentPools gives the public agent pool that older apiVersions implicitly add
+// to DC/OS clusters a name that is valid in the vlabs schema, where all pools are explicit
+func renameDCOSPublicAgentPools(p *Properties) {
+	for _, pool := range p.AgentPoolProfiles {
+		if !strings.HasSuffix(pool.Name, publicAgentPoolSuffix) {
+			continue
+		}
+		name := "agentpublic"
+		for i := 1; p.getAgentPoolIndexByName(name) != -1; i++ {
+			name = fmt.Sprintf("agentpublic%d", i)
+		}
+		log.Warnf("renaming the implicit DC/OS public agent pool %s to %s", pool.Name, name)
+		pool.Name = name
+	}
+}
+
+// extractDeprecatedKubernetesConfig removes the KubernetesConfigDeprecated properties from the
+// api model, returning the remaining api model and the component config flags replacing them
+func extractDeprecatedKubernetesConfig(contents []byte) ([]byte, map[string]map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(contents, &raw); err != nil {
+		return nil, nil, errors.Wrap(err, "error parsing the api model")
+	}
+	properties, _ := raw["properties"].(map[string]interface{})
+	orchestratorProfile, _ := properties["orchestratorProfile"].(map[string]interface{})
+	kubernetesConfig, _ := orchestratorProfile["kubernetesConfig"].(map[string]interface{})
+	if kubernetesConfig == nil {
+		return contents, nil, nil
+	}
+
+	flags := make(map[string]map[string]string)
+	removed := false
+	for key, replacement := range deprecatedKubernetesConfigFlags {
+		val, ok := kubernetesConfig[key]
+		if !ok {
+			continue
+		}
+		delete(kubernetesConfig, key)
+		removed = true
+		s, ok := val.(string)
+		if !ok {
+			return nil, nil, errors.Errorf("kubernetesConfig.%s must be a string", key)
+		}
+		if s == "" {
+			continue
+		}
+		log.Infof("migrating deprecated kubernetesConfig.%s to %s[\"%s\"]", key, replacement.component, replacement.flag)
+		if flags[replacement.component] == nil {
+			flags[replacement.component] = make(map[string]string)
+		}
+		flags[replacement.component][replacement.flag] = s
+	}
+	if !removed {
+		return contents, nil, nil
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, flags, nil
+}
@@ -0,0 +1,191 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/Azure/acs-engine/pkg/api/v20160330"
+	"github.com/Azure/acs-engine/pkg/api/vlabs"
+	"github.com/Azure/acs-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+)
+
+func newTestApiloader() *Apiloader {
+	locale := gotext.NewLocale(path.Join("..", "..", "translations"), "en_US")
+	i18n.Initialize(locale)
+	return &Apiloader{
+		Translator: &i18n.Translator{
+			Locale: locale,
+		},
+	}
+}
+
+func TestMigrateAPIModelV20160330(t *testing.T) {
+	apiloader := newTestApiloader()
+
+	for _, file := range []string{"dcos.json", "swarm.json"} {
+		contents, err := ioutil.ReadFile(path.Join("..", "acsengine", "testdata", "v20160330", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		original, _, err := apiloader.DeserializeContainerService(contents, true, false, nil)
+		if err != nil {
+			t.Fatalf("unexpected error loading %s: %v", file, err)
+		}
+
+		converted, version, err := apiloader.MigrateAPIModel(contents)
+		if err != nil {
+			t.Fatalf("unexpected error converting %s: %v", file, err)
+		}
+		if version != v20160330.APIVersion {
+			t.Errorf("expected %s to be detected as apiVersion %s, got %s", file, v20160330.APIVersion, version)
+		}
+
+		cs, convertedVersion, err := apiloader.DeserializeContainerService(converted, true, false, nil)
+		if err != nil {
+			t.Fatalf("expected the converted %s to parse, got %v", file, err)
+		}
+		if convertedVersion != vlabs.APIVersion {
+			t.Errorf("expected the converted %s to have apiVersion %s, got %s", file, vlabs.APIVersion, convertedVersion)
+		}
+
+		o := cs.Properties.OrchestratorProfile
+		if o.OrchestratorType != original.Properties.OrchestratorProfile.OrchestratorType ||
+			o.OrchestratorVersion != original.Properties.OrchestratorProfile.OrchestratorVersion {
+			t.Errorf("expected the converted %s to keep orchestrator %s %s, got %s %s", file,
+				original.Properties.OrchestratorProfile.OrchestratorType, original.Properties.OrchestratorProfile.OrchestratorVersion,
+				o.OrchestratorType, o.OrchestratorVersion)
+		}
+		if cs.Properties.MasterProfile.Count != original.Properties.MasterProfile.Count ||
+			cs.Properties.MasterProfile.DNSPrefix != original.Properties.MasterProfile.DNSPrefix ||
+			cs.Properties.MasterProfile.VMSize != original.Properties.MasterProfile.VMSize {
+			t.Errorf("expected the converted %s to keep the master profile %+v, got %+v", file, original.Properties.MasterProfile, cs.Properties.MasterProfile)
+		}
+		if len(cs.Properties.AgentPoolProfiles) != len(original.Properties.AgentPoolProfiles) {
+			t.Fatalf("expected the converted %s to have %d agent pools, got %d", file, len(original.Properties.AgentPoolProfiles), len(cs.Properties.AgentPoolProfiles))
+		}
+		for i, pool := range cs.Properties.AgentPoolProfiles {
+			originalPool := original.Properties.AgentPoolProfiles[i]
+			if pool.Count != originalPool.Count || pool.VMSize != originalPool.VMSize ||
+				pool.DNSPrefix != originalPool.DNSPrefix || len(pool.Ports) != len(originalPool.Ports) {
+				t.Errorf("expected the converted %s to keep agent pool %+v, got %+v", file, originalPool, pool)
+			}
+		}
+	}
+}
+
+func TestMigrateAPIModelRenamesDCOSPublicAgentPool(t *testing.T) {
+	apiloader := newTestApiloader()
+	contents, err := ioutil.ReadFile(path.Join("..", "acsengine", "testdata", "v20160330", "dcos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, _, err := apiloader.MigrateAPIModel(contents)
+	if err != nil {
+		t.Fatalf("unexpected error converting: %v", err)
+	}
+	cs, _, err := apiloader.DeserializeContainerService(converted, true, false, nil)
+	if err != nil {
+		t.Fatalf("expected the converted api model to parse, got %v", err)
+	}
+	if cs.Properties.AgentPoolProfiles[0].Name != "agentprivate" || cs.Properties.AgentPoolProfiles[1].Name != "agentpublic" {
+		t.Errorf("expected agent pools agentprivate and agentpublic, got %s and %s", cs.Properties.AgentPoolProfiles[0].Name, cs.Properties.AgentPoolProfiles[1].Name)
+	}
+}
+
+func TestMigrateAPIModelDeprecatedKubernetesConfig(t *testing.T) {
+	apiloader := newTestApiloader()
+	contents := []byte(`{
+  "apiVersion": "vlabs",
+  "properties": {
+    "orchestratorProfile": {
+      "orchestratorType": "Kubernetes",
+      "orchestratorVersion": "1.10.9",
+      "kubernetesConfig": {
+        "nodeStatusUpdateFrequency": "20s",
+        "hardEvictionThreshold": "memory.available<200Mi",
+        "ctrlMgrNodeMonitorGracePeriod": "2m",
+        "ctrlMgrPodEvictionTimeout": "10m",
+        "controllerManagerConfig": {
+          "--pod-eviction-timeout": "5m"
+        }
+      }
+    },
+    "masterProfile": {
+      "count": 1,
+      "dnsPrefix": "migrate",
+      "vmSize": "Standard_D2_v2"
+    },
+    "agentPoolProfiles": [
+      {
+        "name": "agentpool1",
+        "count": 3,
+        "vmSize": "Standard_D2_v2"
+      }
+    ],
+    "linuxProfile": {
+      "adminUsername": "azureuser",
+      "ssh": {
+        "publicKeys": [
+          {
+            "keyData": "ssh-rsa PUBLICKEY azureuser@linuxvm"
+          }
+        ]
+      }
+    },
+    "servicePrincipalProfile": {
+      "clientId": "ServicePrincipalClientID",
+      "secret": "myServicePrincipalClientSecret"
+    }
+  }
+}`)
+
+	if _, _, err := apiloader.DeserializeContainerService(contents, true, false, nil); err == nil {
+		t.Fatalf("expected the deprecated kubernetesConfig properties to be rejected before conversion")
+	}
+
+	converted, _, err := apiloader.MigrateAPIModel(contents)
+	if err != nil {
+		t.Fatalf("unexpected error converting: %v", err)
+	}
+	cs, _, err := apiloader.DeserializeContainerService(converted, true, false, nil)
+	if err != nil {
+		t.Fatalf("expected the converted api model to parse, got %v", err)
+	}
+
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	for flag, expected := range map[string]string{
+		"--node-status-update-frequency": "20s",
+		"--eviction-hard":                "memory.available<200Mi",
+	} {
+		if k.KubeletConfig[flag] != expected {
+			t.Errorf("expected kubeletConfig %s to be %s, got %s", flag, expected, k.KubeletConfig[flag])
+		}
+	}
+	for flag, expected := range map[string]string{
+		"--node-monitor-grace-period": "2m",
+		// the explicitly configured flag wins over the deprecated property
+		"--pod-eviction-timeout": "5m",
+	} {
+		if k.ControllerManagerConfig[flag] != expected {
+			t.Errorf("expected controllerManagerConfig %s to be %s, got %s", flag, expected, k.ControllerManagerConfig[flag])
+		}
+	}
+	if cs.Properties.OrchestratorProfile.OrchestratorVersion != "1.10.9" {
+		t.Errorf("expected the orchestrator version to be preserved, got %s", cs.Properties.OrchestratorProfile.OrchestratorVersion)
+	}
+}
+
+func TestMigrateAPIModelErrors(t *testing.T) {
+	apiloader := newTestApiloader()
+	if _, _, err := apiloader.MigrateAPIModel([]byte(`{"properties": {}}`)); err == nil {
+		t.Errorf("expected an error converting an api model without apiVersion")
+	}
+	if _, _, err := apiloader.MigrateAPIModel([]byte(`{"apiVersion": "2015-01-01", "properties": {}}`)); err == nil {
+		t.Errorf("expected an error converting an api model with an unknown apiVersion")
+	}
+}
@@ -58,6 +58,7 @@ func CreateMockContainerService(containerServiceName, orchestratorVersion string
 		ServiceCIDR:         DefaultKubernetesServiceCIDR,
 		DockerBridgeSubnet:  DefaultDockerBridgeSubnet,
 		DNSServiceIP:        DefaultKubernetesDNSServiceIP,
+		ClusterDomain:       DefaultKubernetesClusterDomain,
 		GCLowThreshold:      DefaultKubernetesGCLowThreshold,
 		GCHighThreshold:     DefaultKubernetesGCHighThreshold,
 		MaxPods:             DefaultKubernetesMaxPodsVNETIntegrated,
@@ -73,6 +73,8 @@ type Properties struct {
 	AddonProfiles           map[string]AddonProfile  `json:"addonProfiles,omitempty"`
 	AzProfile               *AzProfile               `json:"azProfile,omitempty"`
 	FeatureFlags            *FeatureFlags            `json:"featureFlags,omitempty"`
+	ExtraParameters         map[string]interface{}   `json:"extraParameters,omitempty"`
+	ExtraVariables          map[string]interface{}   `json:"extraVariables,omitempty"`
 }
 
 // ClusterMetadata represents the metadata of the ACS cluster.
@@ -109,10 +111,12 @@ type FeatureFlags struct {
 
 // ServicePrincipalProfile contains the client and secret used by the cluster for Azure Resource CRUD
 type ServicePrincipalProfile struct {
-	ClientID          string             `json:"clientId"`
-	Secret            string             `json:"secret,omitempty" conform:"redact"`
-	ObjectID          string             `json:"objectId,omitempty"`
-	KeyvaultSecretRef *KeyvaultSecretRef `json:"keyvaultSecretRef,omitempty"`
+	ClientID            string             `json:"clientId"`
+	Secret              string             `json:"secret,omitempty" conform:"redact"`
+	Certificate         string             `json:"certificate,omitempty" conform:"redact"`
+	CertificatePassword string             `json:"certificatePassword,omitempty" conform:"redact"`
+	ObjectID            string             `json:"objectId,omitempty"`
+	KeyvaultSecretRef   *KeyvaultSecretRef `json:"keyvaultSecretRef,omitempty"`
 }
 
 // KeyvaultSecretRef specifies path to the Azure keyvault along with secret name and (optionaly) version
@@ -153,6 +157,16 @@ type CertificateProfile struct {
 	EtcdPeerCertificates []string `json:"etcdPeerCertificates,omitempty" conform:"redact"`
 	// EtcdPeerPrivateKeys is list of etcd peer private keys, and signed by the CA
 	EtcdPeerPrivateKeys []string `json:"etcdPeerPrivateKeys,omitempty" conform:"redact"`
+	// ServiceAccountSigningKey is the private key the API server signs the bound service account tokens with
+	ServiceAccountSigningKey string `json:"serviceAccountSigningKey,omitempty" conform:"redact"`
+	// FrontProxyCACertificate is the certificate authority the API server trusts the request headers of the aggregation layer from
+	FrontProxyCACertificate string `json:"frontProxyCACertificate,omitempty" conform:"redact"`
+	// FrontProxyCAPrivateKey is the private key of the front proxy certificate authority
+	FrontProxyCAPrivateKey string `json:"frontProxyCAPrivateKey,omitempty" conform:"redact"`
+	// FrontProxyClientCertificate is the certificate the API server proxies the requests to the aggregated API servers with, and signed by the front proxy CA
+	FrontProxyClientCertificate string `json:"frontProxyClientCertificate,omitempty" conform:"redact"`
+	// FrontProxyClientPrivateKey is the private key the API server proxies the requests to the aggregated API servers with
+	FrontProxyClientPrivateKey string `json:"frontProxyClientPrivateKey,omitempty" conform:"redact"`
 }
 
 // LinuxProfile represents the linux parameters passed to the cluster
@@ -243,6 +257,7 @@ type OrchestratorVersionProfile struct {
 type KubernetesContainerSpec struct {
 	Name           string `json:"name,omitempty"`
 	Image          string `json:"image,omitempty"`
+	Version        string `json:"version,omitempty"`
 	CPURequests    string `json:"cpuRequests,omitempty"`
 	MemoryRequests string `json:"memoryRequests,omitempty"`
 	CPULimits      string `json:"cpuLimits,omitempty"`
@@ -276,12 +291,72 @@ func (a KubernetesAddon) GetAddonContainersIndexByName(containerName string) int
 	return -1
 }
 
+// OIDCConfig configures the API server to authenticate users with the ID tokens
+// issued by an OpenID Connect provider
+type OIDCConfig struct {
+	IssuerURL      string `json:"issuerURL,omitempty"`
+	ClientID       string `json:"clientID,omitempty"`
+	UsernameClaim  string `json:"usernameClaim,omitempty"`
+	UsernamePrefix string `json:"usernamePrefix,omitempty"`
+	GroupsClaim    string `json:"groupsClaim,omitempty"`
+	GroupsPrefix   string `json:"groupsPrefix,omitempty"`
+}
+
+// WebhookTokenAuth configures the API server to authenticate bearer tokens with the token review
+// webhook of an external identity system
+type WebhookTokenAuth struct {
+	URL           string `json:"url,omitempty"`
+	CACertificate string `json:"caCertificate,omitempty"`
+	CacheTTL      string `json:"cacheTTL,omitempty"`
+}
+
+// AuditWebhook configures the API server to send its audit events to the webhook of an external system, e.g. a SIEM,
+// besides the audit log of the masters
+type AuditWebhook struct {
+	URL             string `json:"url,omitempty"`
+	CACertificate   string `json:"caCertificate,omitempty"`
+	Mode            string `json:"mode,omitempty"`
+	BatchBufferSize int    `json:"batchBufferSize,omitempty"`
+	BatchMaxSize    int    `json:"batchMaxSize,omitempty"`
+	BatchMaxWait    string `json:"batchMaxWait,omitempty"`
+}
+
+// AdmissionWebhook is a validating or mutating admission webhook configuration of a Kubernetes cluster,
+// applied by the addon-manager once the components of kube-system run, so that the webhook can't block
+// their creation
+type AdmissionWebhook struct {
+	Name     string `json:"name,omitempty"`
+	Manifest string `json:"manifest,omitempty"`
+}
+
+// StorageClass configures the "default" StorageClass of a Kubernetes cluster, which the persistent
+// volume claims without a storage class are provisioned with
+type StorageClass struct {
+	Provisioner       string `json:"provisioner,omitempty"`
+	SKU               string `json:"sku,omitempty"`
+	ReclaimPolicy     string `json:"reclaimPolicy,omitempty"`
+	VolumeBindingMode string `json:"volumeBindingMode,omitempty"`
+}
+
 // PrivateCluster defines the configuration for a private cluster
 type PrivateCluster struct {
 	Enabled        *bool                  `json:"enabled,omitempty"`
 	JumpboxProfile *PrivateJumpboxProfile `json:"jumpboxProfile,omitempty"`
 }
 
+// PrivateRegistry contains the credentials of a private container registry the nodes pull images from
+type PrivateRegistry struct {
+	Server   string `json:"server,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// EgressFirewall routes the egress traffic of the cluster through a firewall network virtual appliance
+type EgressFirewall struct {
+	VirtualApplianceIP    string   `json:"virtualApplianceIP,omitempty"`
+	BypassAddressPrefixes []string `json:"bypassAddressPrefixes,omitempty"`
+}
+
 // PrivateJumpboxProfile represents a jumpbox definition
 type PrivateJumpboxProfile struct {
 	Name           string `json:"name" validate:"required"`
@@ -293,15 +368,16 @@ type PrivateJumpboxProfile struct {
 }
 
 // CloudProviderConfig contains the KubernetesConfig properties specific to the Cloud Provider
+// The fields are not omitted when empty, the provisioning scripts read every one of them
 type CloudProviderConfig struct {
-	CloudProviderBackoff         *bool  `json:"cloudProviderBackoff,omitempty"`
-	CloudProviderBackoffRetries  int    `json:"cloudProviderBackoffRetries,omitempty"`
-	CloudProviderBackoffJitter   string `json:"cloudProviderBackoffJitter,omitempty"`
-	CloudProviderBackoffDuration int    `json:"cloudProviderBackoffDuration,omitempty"`
-	CloudProviderBackoffExponent string `json:"cloudProviderBackoffExponent,omitempty"`
-	CloudProviderRateLimit       *bool  `json:"cloudProviderRateLimit,omitempty"`
-	CloudProviderRateLimitQPS    string `json:"cloudProviderRateLimitQPS,omitempty"`
-	CloudProviderRateLimitBucket int    `json:"cloudProviderRateLimitBucket,omitempty"`
+	CloudProviderBackoff         *bool  `json:"cloudProviderBackoff"`
+	CloudProviderBackoffRetries  int    `json:"cloudProviderBackoffRetries"`
+	CloudProviderBackoffJitter   string `json:"cloudProviderBackoffJitter"`
+	CloudProviderBackoffDuration int    `json:"cloudProviderBackoffDuration"`
+	CloudProviderBackoffExponent string `json:"cloudProviderBackoffExponent"`
+	CloudProviderRateLimit       *bool  `json:"cloudProviderRateLimit"`
+	CloudProviderRateLimitQPS    string `json:"cloudProviderRateLimitQPS"`
+	CloudProviderRateLimitBucket int    `json:"cloudProviderRateLimitBucket"`
 }
 
 // KubernetesConfigDeprecated are properties that are no longer operable and will be ignored
@@ -318,63 +394,122 @@ type KubernetesConfigDeprecated struct {
 // KubernetesConfig contains the Kubernetes config structure, containing
 // Kubernetes specific configuration
 type KubernetesConfig struct {
-	KubernetesImageBase              string            `json:"kubernetesImageBase,omitempty"`
-	ClusterSubnet                    string            `json:"clusterSubnet,omitempty"`
-	NetworkPolicy                    string            `json:"networkPolicy,omitempty"`
-	NetworkPlugin                    string            `json:"networkPlugin,omitempty"`
-	ContainerRuntime                 string            `json:"containerRuntime,omitempty"`
-	MaxPods                          int               `json:"maxPods,omitempty"`
-	DockerBridgeSubnet               string            `json:"dockerBridgeSubnet,omitempty"`
-	DNSServiceIP                     string            `json:"dnsServiceIP,omitempty"`
-	ServiceCIDR                      string            `json:"serviceCidr,omitempty"`
-	UseManagedIdentity               bool              `json:"useManagedIdentity,omitempty"`
-	UserAssignedID                   string            `json:"userAssignedID,omitempty"`
-	UserAssignedClientID             string            `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
-	CustomHyperkubeImage             string            `json:"customHyperkubeImage,omitempty"`
-	DockerEngineVersion              string            `json:"dockerEngineVersion,omitempty"` // Deprecated
-	CustomCcmImage                   string            `json:"customCcmImage,omitempty"`      // Image for cloud-controller-manager
-	UseCloudControllerManager        *bool             `json:"useCloudControllerManager,omitempty"`
-	CustomWindowsPackageURL          string            `json:"customWindowsPackageURL,omitempty"`
-	WindowsNodeBinariesURL           string            `json:"windowsNodeBinariesURL,omitempty"`
-	UseInstanceMetadata              *bool             `json:"useInstanceMetadata,omitempty"`
-	EnableRbac                       *bool             `json:"enableRbac,omitempty"`
-	EnableSecureKubelet              *bool             `json:"enableSecureKubelet,omitempty"`
-	EnableAggregatedAPIs             bool              `json:"enableAggregatedAPIs,omitempty"`
-	PrivateCluster                   *PrivateCluster   `json:"privateCluster,omitempty"`
-	GCHighThreshold                  int               `json:"gchighthreshold,omitempty"`
-	GCLowThreshold                   int               `json:"gclowthreshold,omitempty"`
-	EtcdVersion                      string            `json:"etcdVersion,omitempty"`
-	EtcdDiskSizeGB                   string            `json:"etcdDiskSizeGB,omitempty"`
-	EtcdEncryptionKey                string            `json:"etcdEncryptionKey,omitempty"`
-	EnableDataEncryptionAtRest       *bool             `json:"enableDataEncryptionAtRest,omitempty"`
-	EnableEncryptionWithExternalKms  *bool             `json:"enableEncryptionWithExternalKms,omitempty"`
-	EnablePodSecurityPolicy          *bool             `json:"enablePodSecurityPolicy,omitempty"`
-	Addons                           []KubernetesAddon `json:"addons,omitempty"`
-	KubeletConfig                    map[string]string `json:"kubeletConfig,omitempty"`
-	ControllerManagerConfig          map[string]string `json:"controllerManagerConfig,omitempty"`
-	CloudControllerManagerConfig     map[string]string `json:"cloudControllerManagerConfig,omitempty"`
-	APIServerConfig                  map[string]string `json:"apiServerConfig,omitempty"`
-	SchedulerConfig                  map[string]string `json:"schedulerConfig,omitempty"`
-	PodSecurityPolicyConfig          map[string]string `json:"podSecurityPolicyConfig,omitempty"`
-	CloudProviderBackoff             *bool             `json:"cloudProviderBackoff,omitempty"`
-	CloudProviderBackoffRetries      int               `json:"cloudProviderBackoffRetries,omitempty"`
-	CloudProviderBackoffJitter       float64           `json:"cloudProviderBackoffJitter,omitempty"`
-	CloudProviderBackoffDuration     int               `json:"cloudProviderBackoffDuration,omitempty"`
-	CloudProviderBackoffExponent     float64           `json:"cloudProviderBackoffExponent,omitempty"`
-	CloudProviderRateLimit           *bool             `json:"cloudProviderRateLimit,omitempty"`
-	CloudProviderRateLimitQPS        float64           `json:"cloudProviderRateLimitQPS,omitempty"`
-	CloudProviderRateLimitBucket     int               `json:"cloudProviderRateLimitBucket,omitempty"`
-	NonMasqueradeCidr                string            `json:"nonMasqueradeCidr,omitempty"`
-	NodeStatusUpdateFrequency        string            `json:"nodeStatusUpdateFrequency,omitempty"`
-	HardEvictionThreshold            string            `json:"hardEvictionThreshold,omitempty"`
-	CtrlMgrNodeMonitorGracePeriod    string            `json:"ctrlMgrNodeMonitorGracePeriod,omitempty"`
-	CtrlMgrPodEvictionTimeout        string            `json:"ctrlMgrPodEvictionTimeout,omitempty"`
-	CtrlMgrRouteReconciliationPeriod string            `json:"ctrlMgrRouteReconciliationPeriod,omitempty"`
-	LoadBalancerSku                  string            `json:"loadBalancerSku,omitempty"`
-	ExcludeMasterFromStandardLB      *bool             `json:"excludeMasterFromStandardLB,omitempty"`
-	AzureCNIVersion                  string            `json:"azureCNIVersion,omitempty"`
-	AzureCNIURLLinux                 string            `json:"azureCNIURLLinux,omitempty"`
-	AzureCNIURLWindows               string            `json:"azureCNIURLWindows,omitempty"`
+	KubernetesImageBase              string             `json:"kubernetesImageBase,omitempty"`
+	ClusterSubnet                    string             `json:"clusterSubnet,omitempty"`
+	NetworkPolicy                    string             `json:"networkPolicy,omitempty"`
+	NetworkPlugin                    string             `json:"networkPlugin,omitempty"`
+	NetworkPluginMode                string             `json:"networkPluginMode,omitempty"`
+	ContainerRuntime                 string             `json:"containerRuntime,omitempty"`
+	MaxPods                          int                `json:"maxPods,omitempty"`
+	MaxRequestsInflight              int                `json:"maxRequestsInflight,omitempty"`
+	MaxMutatingRequestsInflight      int                `json:"maxMutatingRequestsInflight,omitempty"`
+	RequestTimeout                   string             `json:"requestTimeout,omitempty"`
+	MinRequestTimeout                int                `json:"minRequestTimeout,omitempty"`
+	WatchCacheSizes                  map[string]int     `json:"watchCacheSizes,omitempty"`
+	NodeMonitorGracePeriod           string             `json:"nodeMonitorGracePeriod,omitempty"`
+	NodeMonitorPeriod                string             `json:"nodeMonitorPeriod,omitempty"`
+	PodEvictionTimeout               string             `json:"podEvictionTimeout,omitempty"`
+	SwapEnabled                      *bool              `json:"swapEnabled,omitempty"`
+	SwapSizeMB                       int                `json:"swapSizeMB,omitempty"`
+	ReadOnlyRootFilesystem           *bool              `json:"readOnlyRootFilesystem,omitempty"`
+	ResolvConf                       string             `json:"resolvConf,omitempty"`
+	DisableResolvedStub              *bool              `json:"disableResolvedStub,omitempty"`
+	CgroupDriver                     string             `json:"cgroupDriver,omitempty"`
+	CgroupV2Enabled                  *bool              `json:"cgroupV2Enabled,omitempty"`
+	PodMaxPids                       int                `json:"podMaxPids,omitempty"`
+	PidMax                           int                `json:"pidMax,omitempty"`
+	MaxOpenFiles                     int                `json:"maxOpenFiles,omitempty"`
+	PrePulledImages                  []string           `json:"prePulledImages,omitempty"`
+	RegistryMirrors                  []string           `json:"registryMirrors,omitempty"`
+	ContainerLogMaxSize              string             `json:"containerLogMaxSize,omitempty"`
+	ContainerLogMaxFiles             int                `json:"containerLogMaxFiles,omitempty"`
+	DockerBridgeSubnet               string             `json:"dockerBridgeSubnet,omitempty"`
+	PodMTU                           int                `json:"podMTU,omitempty"`
+	TLSCipherSuites                  []string           `json:"tlsCipherSuites,omitempty"`
+	TLSMinVersion                    string             `json:"tlsMinVersion,omitempty"`
+	DNSServiceIP                     string             `json:"dnsServiceIP,omitempty"`
+	ClusterDomain                    string             `json:"clusterDomain,omitempty"`
+	KubeProxyDeploymentMode          string             `json:"kubeProxyDeploymentMode,omitempty"`
+	ServiceCIDR                      string             `json:"serviceCidr,omitempty"`
+	UseManagedIdentity               bool               `json:"useManagedIdentity,omitempty"`
+	UserAssignedID                   string             `json:"userAssignedID,omitempty"`
+	UserAssignedClientID             string             `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
+	CustomHyperkubeImage             string             `json:"customHyperkubeImage,omitempty"`
+	DockerEngineVersion              string             `json:"dockerEngineVersion,omitempty"` // Deprecated
+	CustomCcmImage                   string             `json:"customCcmImage,omitempty"`      // Image for cloud-controller-manager
+	UseCloudControllerManager        *bool              `json:"useCloudControllerManager,omitempty"`
+	CustomWindowsPackageURL          string             `json:"customWindowsPackageURL,omitempty"`
+	WindowsNodeBinariesURL           string             `json:"windowsNodeBinariesURL,omitempty"`
+	UseInstanceMetadata              *bool              `json:"useInstanceMetadata,omitempty"`
+	EnableRbac                       *bool              `json:"enableRbac,omitempty"`
+	EnableSecureKubelet              *bool              `json:"enableSecureKubelet,omitempty"`
+	EnableKubeletCertRotation        *bool              `json:"enableKubeletCertRotation,omitempty"`
+	KubeletCertSigningDuration       string             `json:"kubeletCertSigningDuration,omitempty"`
+	EnableInsecurePort               *bool              `json:"enableInsecurePort,omitempty"`
+	APIServerAdvertiseAddress        string             `json:"apiServerAdvertiseAddress,omitempty"`
+	APIServerSecurePort              int                `json:"apiServerSecurePort,omitempty"`
+	EnableAggregatedAPIs             bool               `json:"enableAggregatedAPIs,omitempty"`
+	PrivateCluster                   *PrivateCluster    `json:"privateCluster,omitempty"`
+	PrivateRegistry                  *PrivateRegistry   `json:"privateRegistry,omitempty"`
+	EgressFirewall                   *EgressFirewall    `json:"egressFirewall,omitempty"`
+	OIDCConfig                       *OIDCConfig        `json:"oidcConfig,omitempty"`
+	WebhookTokenAuth                 *WebhookTokenAuth  `json:"webhookTokenAuth,omitempty"`
+	AuditWebhook                     *AuditWebhook      `json:"auditWebhook,omitempty"`
+	AdmissionWebhooks                []AdmissionWebhook `json:"admissionWebhooks,omitempty"`
+	DefaultStorageClass              *StorageClass      `json:"defaultStorageClass,omitempty"`
+	FeatureGates                     map[string]bool    `json:"featureGates,omitempty"`
+	ServiceAccountIssuer             string             `json:"serviceAccountIssuer,omitempty"`
+	APIAudiences                     []string           `json:"apiAudiences,omitempty"`
+	KubeConfigClusterName            string             `json:"kubeConfigClusterName,omitempty"`
+	KubeConfigContextName            string             `json:"kubeConfigContextName,omitempty"`
+	KubeConfigUserName               string             `json:"kubeConfigUserName,omitempty"`
+	SchedulerPolicy                  string             `json:"schedulerPolicy,omitempty"`
+	GCHighThreshold                  int                `json:"gchighthreshold,omitempty"`
+	GCLowThreshold                   int                `json:"gclowthreshold,omitempty"`
+	EtcdVersion                      string             `json:"etcdVersion,omitempty"`
+	EtcdDiskSizeGB                   string             `json:"etcdDiskSizeGB,omitempty"`
+	EtcdStorageLimitGB               int                `json:"etcdStorageLimitGB,omitempty"`
+	EtcdCompactionInterval           string             `json:"etcdCompactionInterval,omitempty"`
+	EtcdDefragSchedule               string             `json:"etcdDefragSchedule,omitempty"`
+	EtcdEncryptionKey                string             `json:"etcdEncryptionKey,omitempty"`
+	BootstrapTokenTTL                string             `json:"bootstrapTokenTTL,omitempty"`
+	BootstrapToken                   string             `json:"bootstrapToken,omitempty"`
+	BootstrapTokenExpiration         string             `json:"bootstrapTokenExpiration,omitempty"`
+	EnableDataEncryptionAtRest       *bool              `json:"enableDataEncryptionAtRest,omitempty"`
+	EnableEncryptionWithExternalKms  *bool              `json:"enableEncryptionWithExternalKms,omitempty"`
+	EnablePodSecurityPolicy          *bool              `json:"enablePodSecurityPolicy,omitempty"`
+	EnablePodPriority                *bool              `json:"enablePodPriority,omitempty"`
+	EnablePrometheusMetrics          *bool              `json:"enablePrometheusMetrics,omitempty"`
+	Addons                           []KubernetesAddon  `json:"addons,omitempty"`
+	KubeletConfig                    map[string]string  `json:"kubeletConfig,omitempty"`
+	ControllerManagerConfig          map[string]string  `json:"controllerManagerConfig,omitempty"`
+	CloudControllerManagerConfig     map[string]string  `json:"cloudControllerManagerConfig,omitempty"`
+	APIServerConfig                  map[string]string  `json:"apiServerConfig,omitempty"`
+	SchedulerConfig                  map[string]string  `json:"schedulerConfig,omitempty"`
+	PodSecurityPolicyConfig          map[string]string  `json:"podSecurityPolicyConfig,omitempty"`
+	CloudProviderConfig              map[string]string  `json:"cloudProviderConfig,omitempty"`
+	CloudProviderBackoff             *bool              `json:"cloudProviderBackoff,omitempty"`
+	CloudProviderBackoffRetries      int                `json:"cloudProviderBackoffRetries,omitempty"`
+	CloudProviderBackoffJitter       float64            `json:"cloudProviderBackoffJitter,omitempty"`
+	CloudProviderBackoffDuration     int                `json:"cloudProviderBackoffDuration,omitempty"`
+	CloudProviderBackoffExponent     float64            `json:"cloudProviderBackoffExponent,omitempty"`
+	CloudProviderRateLimit           *bool              `json:"cloudProviderRateLimit,omitempty"`
+	CloudProviderRateLimitQPS        float64            `json:"cloudProviderRateLimitQPS,omitempty"`
+	CloudProviderRateLimitBucket     int                `json:"cloudProviderRateLimitBucket,omitempty"`
+	NonMasqueradeCidr                string             `json:"nonMasqueradeCidr,omitempty"`
+	NodeStatusUpdateFrequency        string             `json:"nodeStatusUpdateFrequency,omitempty"`
+	HardEvictionThreshold            string             `json:"hardEvictionThreshold,omitempty"`
+	CtrlMgrNodeMonitorGracePeriod    string             `json:"ctrlMgrNodeMonitorGracePeriod,omitempty"`
+	CtrlMgrPodEvictionTimeout        string             `json:"ctrlMgrPodEvictionTimeout,omitempty"`
+	CtrlMgrRouteReconciliationPeriod string             `json:"ctrlMgrRouteReconciliationPeriod,omitempty"`
+	LoadBalancerSku                  string             `json:"loadBalancerSku,omitempty"`
+	ExcludeMasterFromStandardLB      *bool              `json:"excludeMasterFromStandardLB,omitempty"`
+	ServiceInternalLBSubnetID        string             `json:"serviceInternalLBSubnetID,omitempty"`
+	NATGatewayID                     string             `json:"natGatewayID,omitempty"`
+	DisableAgentOutbound             *bool              `json:"disableAgentOutbound,omitempty"`
+	AzureCNIVersion                  string             `json:"azureCNIVersion,omitempty"`
+	AzureCNIURLLinux                 string             `json:"azureCNIURLLinux,omitempty"`
+	AzureCNIURLWindows               string             `json:"azureCNIURLWindows,omitempty"`
 }
 
 // CustomFile has source as the full absolute source path to a file and dest
@@ -432,30 +567,50 @@ type OpenShiftRouterProfile struct {
 
 // MasterProfile represents the definition of the master cluster
 type MasterProfile struct {
-	Count                    int               `json:"count"`
-	DNSPrefix                string            `json:"dnsPrefix"`
-	SubjectAltNames          []string          `json:"subjectAltNames"`
-	VMSize                   string            `json:"vmSize"`
-	OSDiskSizeGB             int               `json:"osDiskSizeGB,omitempty"`
-	VnetSubnetID             string            `json:"vnetSubnetID,omitempty"`
-	VnetCidr                 string            `json:"vnetCidr,omitempty"`
-	AgentVnetSubnetID        string            `json:"agentVnetSubnetID,omitempty"`
-	FirstConsecutiveStaticIP string            `json:"firstConsecutiveStaticIP,omitempty"`
-	Subnet                   string            `json:"subnet"`
-	IPAddressCount           int               `json:"ipAddressCount,omitempty"`
-	StorageProfile           string            `json:"storageProfile,omitempty"`
-	HTTPSourceAddressPrefix  string            `json:"HTTPSourceAddressPrefix,omitempty"`
-	OAuthEnabled             bool              `json:"oauthEnabled"`
-	PreprovisionExtension    *Extension        `json:"preProvisionExtension"`
-	Extensions               []Extension       `json:"extensions"`
-	Distro                   Distro            `json:"distro,omitempty"`
-	KubernetesConfig         *KubernetesConfig `json:"kubernetesConfig,omitempty"`
-	ImageRef                 *ImageReference   `json:"imageReference,omitempty"`
-	CustomFiles              *[]CustomFile     `json:"customFiles,omitempty"`
-	AvailabilityProfile      string            `json:"availabilityProfile"`
-	AgentSubnet              string            `json:"agentSubnet,omitempty"`
-	AvailabilityZones        []string          `json:"availabilityZones,omitempty"`
-	SinglePlacementGroup     *bool             `json:"singlePlacementGroup,omitempty"`
+	Count                     int               `json:"count"`
+	DNSPrefix                 string            `json:"dnsPrefix"`
+	SubjectAltNames           []string          `json:"subjectAltNames"`
+	VMSize                    string            `json:"vmSize"`
+	OSDiskSizeGB              int               `json:"osDiskSizeGB,omitempty"`
+	VnetSubnetID              string            `json:"vnetSubnetID,omitempty"`
+	VnetCidr                  string            `json:"vnetCidr,omitempty"`
+	AgentVnetSubnetID         string            `json:"agentVnetSubnetID,omitempty"`
+	FirstConsecutiveStaticIP  string            `json:"firstConsecutiveStaticIP,omitempty"`
+	Subnet                    string            `json:"subnet"`
+	IPAddressCount            int               `json:"ipAddressCount,omitempty"`
+	StorageProfile            string            `json:"storageProfile,omitempty"`
+	HTTPSourceAddressPrefix   string            `json:"HTTPSourceAddressPrefix,omitempty"`
+	OAuthEnabled              bool              `json:"oauthEnabled"`
+	PreprovisionExtension     *Extension        `json:"preProvisionExtension"`
+	Extensions                []Extension       `json:"extensions"`
+	Distro                    Distro            `json:"distro,omitempty"`
+	KubernetesConfig          *KubernetesConfig `json:"kubernetesConfig,omitempty"`
+	ImageRef                  *ImageReference   `json:"imageReference,omitempty"`
+	CustomFiles               *[]CustomFile     `json:"customFiles,omitempty"`
+	AvailabilityProfile       string            `json:"availabilityProfile"`
+	AgentSubnet               string            `json:"agentSubnet,omitempty"`
+	AvailabilityZones         []string          `json:"availabilityZones,omitempty"`
+	SinglePlacementGroup      *bool             `json:"singlePlacementGroup,omitempty"`
+	PlatformFaultDomainCount  *int              `json:"platformFaultDomainCount,omitempty"`
+	PlatformUpdateDomainCount *int              `json:"platformUpdateDomainCount,omitempty"`
+
+	// StorageAccountID is the resource ID of an existing storage account holding the VHDs of the unmanaged disks,
+	// which is then used instead of creating storage accounts
+	StorageAccountID string `json:"storageAccountId,omitempty"`
+
+	// ProximityPlacementGroupID is the resource ID of the proximity placement group co-locating the VMs of the
+	// profile, e.g. with the VMs of the other profiles in the same group to lower the latency between them
+	ProximityPlacementGroupID string `json:"proximityPlacementGroupID,omitempty"`
+
+	// LoadBalancerIdleTimeoutInMinutes, LoadBalancerProbeIntervalInSeconds and LoadBalancerProbeThreshold
+	// configure the load balancing rules and health probes of the API server load balancers
+	LoadBalancerIdleTimeoutInMinutes   int `json:"loadBalancerIdleTimeoutInMinutes,omitempty"`
+	LoadBalancerProbeIntervalInSeconds int `json:"loadBalancerProbeIntervalInSeconds,omitempty"`
+	LoadBalancerProbeThreshold         int `json:"loadBalancerProbeThreshold,omitempty"`
+
+	// StaticPods are the manifests of the additional static pods of the masters, e.g. of a custom scheduler,
+	// which the kubelet of each master runs alongside the control plane components
+	StaticPods []StaticPod `json:"staticPods,omitempty"`
 
 	// Master LB public endpoint/FQDN with port
 	// The format will be FQDN:2376
@@ -463,10 +618,18 @@ type MasterProfile struct {
 	FQDN string `json:"fqdn,omitempty"`
 }
 
+// StaticPod is the manifest of a static pod of the masters, written to /etc/kubernetes/manifests/<name>.yaml
+type StaticPod struct {
+	Name     string `json:"name,omitempty"`
+	Manifest string `json:"manifest,omitempty"`
+}
+
 // ImageReference represents a reference to an Image resource in Azure.
 type ImageReference struct {
 	Name          string `json:"name,omitempty"`
 	ResourceGroup string `json:"resourceGroup,omitempty"`
+	// ID is the resource ID of a shared image gallery image version, instead of the name and resource group of an image
+	ID string `json:"id,omitempty"`
 }
 
 // ExtensionProfile represents an extension definition
@@ -520,6 +683,35 @@ type AgentPoolProfile struct {
 	EnableAutoScaling                   *bool                `json:"enableAutoScaling,omitempty"`
 	AvailabilityZones                   []string             `json:"availabilityZones,omitempty"`
 	SinglePlacementGroup                *bool                `json:"singlePlacementGroup,omitempty"`
+	PlatformFaultDomainCount            *int                 `json:"platformFaultDomainCount,omitempty"`
+	PlatformUpdateDomainCount           *int                 `json:"platformUpdateDomainCount,omitempty"`
+	DisableSSH                          bool                 `json:"disableSSH,omitempty"`
+	Sysctls                             map[string]string    `json:"sysctls,omitempty"`
+	KernelBootParameters                []string             `json:"kernelBootParameters,omitempty"`
+
+	// StorageAccountID is the resource ID of an existing storage account holding the VHDs of the unmanaged disks,
+	// which is then used instead of creating storage accounts
+	StorageAccountID string `json:"storageAccountId,omitempty"`
+
+	// ProximityPlacementGroupID is the resource ID of the proximity placement group co-locating the VMs of the
+	// profile, e.g. with the VMs of the other profiles in the same group to lower the latency between them
+	ProximityPlacementGroupID string `json:"proximityPlacementGroupID,omitempty"`
+
+	// OverProvision and UpgradePolicyMode configure the scale set of a VirtualMachineScaleSets agent pool
+	OverProvision     *bool  `json:"overProvision,omitempty"`
+	UpgradePolicyMode string `json:"upgradePolicyMode,omitempty"`
+
+	// CustomNodeTaints are the taints, formatted key=value:Effect, registered on the nodes of the agent pool,
+	// which an upgrade also applies to the nodes it replaces
+	CustomNodeTaints []string `json:"customNodeTaints,omitempty"`
+
+	// DiskEncryptionSetID is the resource ID of the disk encryption set, holding a customer-managed key, which
+	// encrypts the managed OS and data disks of the agent pool
+	DiskEncryptionSetID string `json:"diskEncryptionSetID,omitempty"`
+
+	// ContainerRuntimeDiskSizeGB is the size of a managed data disk attached to the nodes of the agent pool, on
+	// which the container runtime keeps its images and containers instead of the OS disk
+	ContainerRuntimeDiskSizeGB int `json:"containerRuntimeDiskSizeGB,omitempty"`
 }
 
 // AgentPoolProfileRole represents an agent role
@@ -704,6 +896,16 @@ func (p *Properties) HasWindows() bool {
 	return false
 }
 
+// HasSSHDisabledAgentPool returns true if any of the agent pools has SSH disabled
+func (p *Properties) HasSSHDisabledAgentPool() bool {
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		if agentPoolProfile.DisableSSH {
+			return true
+		}
+	}
+	return false
+}
+
 // HasManagedDisks returns true if the cluster contains Managed Disks
 func (p *Properties) HasManagedDisks() bool {
 	if p.MasterProfile != nil && p.MasterProfile.StorageProfile == ManagedDisks {
@@ -965,14 +1167,14 @@ func (p *Properties) HasAvailabilityZones() bool {
 func (p *Properties) GetNonMasqueradeCIDR() string {
 	var nonMasqCidr string
 	if !p.IsHostedMasterProfile() {
-		if p.OrchestratorProfile.IsAzureCNI() {
+		if p.OrchestratorProfile.IsAzureCNIVNETIntegrated() {
 			if p.MasterProfile != nil && p.MasterProfile.IsCustomVNET() {
 				nonMasqCidr = p.MasterProfile.VnetCidr
 			} else {
 				nonMasqCidr = DefaultVNETCIDR
 			}
 		} else {
-			nonMasqCidr = p.OrchestratorProfile.KubernetesConfig.ClusterSubnet
+			nonMasqCidr = p.OrchestratorProfile.KubernetesConfig.GetIPv4ClusterSubnet()
 		}
 	}
 	return nonMasqCidr
@@ -1002,6 +1204,11 @@ func (m *MasterProfile) IsStorageAccount() bool {
 	return m.StorageProfile == StorageAccount
 }
 
+// HasExistingStorageAccount returns true if the VHDs of the masters are stored in an existing storage account
+func (m *MasterProfile) HasExistingStorageAccount() bool {
+	return m.IsStorageAccount() && m.StorageAccountID != ""
+}
+
 // IsRHEL returns true if the master specified a RHEL distro
 func (m *MasterProfile) IsRHEL() bool {
 	return m.Distro == RHEL
@@ -1051,6 +1258,11 @@ func (m *MasterProfile) HasAvailabilityZones() bool {
 	return m.AvailabilityZones != nil && len(m.AvailabilityZones) > 0
 }
 
+// HasProximityPlacementGroup returns true if the masters are placed in a proximity placement group
+func (m *MasterProfile) HasProximityPlacementGroup() bool {
+	return m.ProximityPlacementGroupID != ""
+}
+
 // IsCustomVNET returns true if the customer brought their own VNET
 func (a *AgentPoolProfile) IsCustomVNET() bool {
 	return len(a.VnetSubnetID) > 0
@@ -1096,16 +1308,75 @@ func (a *AgentPoolProfile) IsManagedDisks() bool {
 	return a.StorageProfile == ManagedDisks
 }
 
+// IsSystemPool returns true if the agent pool hosts system addons
+func (a *AgentPoolProfile) IsSystemPool() bool {
+	return a.Role == AgentPoolProfileRoleSystem
+}
+
+// IsUserPool returns true if the agent pool is reserved for user workloads
+func (a *AgentPoolProfile) IsUserPool() bool {
+	return a.Role == AgentPoolProfileRoleUser
+}
+
+// IsDaemonSetPool returns true if the agent pool is reserved for DaemonSet workloads
+func (a *AgentPoolProfile) IsDaemonSetPool() bool {
+	return a.Role == AgentPoolProfileRoleDaemonSet
+}
+
 // IsStorageAccount returns true if the customer specified storage account
 func (a *AgentPoolProfile) IsStorageAccount() bool {
 	return a.StorageProfile == StorageAccount
 }
 
+// IsOverProvisioned returns true if the scale set of the agent pool creates more VMs than requested, and then
+// deletes the extra VMs once the requested ones are provisioned
+func (a *AgentPoolProfile) IsOverProvisioned() bool {
+	return helpers.IsTrueBoolPointer(a.OverProvision)
+}
+
+// GetKubernetesTaints returns the taints registered on the nodes of the agent pool: the taint keeping user
+// workloads away from the nodes of a system or daemonset pool, followed by the custom taints of the pool
+func (a *AgentPoolProfile) GetKubernetesTaints() []string {
+	var taints []string
+	switch {
+	case a.IsSystemPool():
+		taints = append(taints, SystemAgentPoolTaint)
+	case a.IsDaemonSetPool():
+		taints = append(taints, DaemonSetAgentPoolTaint)
+	}
+	return append(taints, a.CustomNodeTaints...)
+}
+
+// HasExistingStorageAccount returns true if the VHDs of the agent pool are stored in an existing storage account
+func (a *AgentPoolProfile) HasExistingStorageAccount() bool {
+	return a.IsStorageAccount() && a.StorageAccountID != ""
+}
+
+// HasProximityPlacementGroup returns true if the agent pool is placed in a proximity placement group
+func (a *AgentPoolProfile) HasProximityPlacementGroup() bool {
+	return a.ProximityPlacementGroupID != ""
+}
+
+// HasDiskEncryptionSet returns true if the disks of the agent pool are encrypted with a disk encryption set
+func (a *AgentPoolProfile) HasDiskEncryptionSet() bool {
+	return a.DiskEncryptionSetID != ""
+}
+
 // HasDisks returns true if the customer specified disks
 func (a *AgentPoolProfile) HasDisks() bool {
 	return len(a.DiskSizesGB) > 0
 }
 
+// HasContainerRuntimeDisk returns true if the container runtime of the agent pool keeps its data on a data disk
+func (a *AgentPoolProfile) HasContainerRuntimeDisk() bool {
+	return a.ContainerRuntimeDiskSizeGB > 0
+}
+
+// GetContainerRuntimeDiskLun returns the LUN of the container runtime data disk, attached after the diskSizesGB ones
+func (a *AgentPoolProfile) GetContainerRuntimeDiskLun() int {
+	return len(a.DiskSizesGB)
+}
+
 // HasAvailabilityZones returns true if the agent pool has availability zones
 func (a *AgentPoolProfile) HasAvailabilityZones() bool {
 	return a.AvailabilityZones != nil && len(a.AvailabilityZones) > 0
@@ -1190,6 +1461,17 @@ func (o *OrchestratorProfile) IsAzureCNI() bool {
 	return false
 }
 
+// IsAzureCNIOverlay returns true if the Azure CNI network plugin gives the pods IPs from an overlay network, the
+// cluster subnet, instead of IPs of the VNET
+func (o *OrchestratorProfile) IsAzureCNIOverlay() bool {
+	return o.IsAzureCNI() && o.KubernetesConfig.NetworkPluginMode == NetworkPluginModeOverlay
+}
+
+// IsAzureCNIVNETIntegrated returns true if the pods get IPs of the VNET, that is Azure CNI without the overlay mode
+func (o *OrchestratorProfile) IsAzureCNIVNETIntegrated() bool {
+	return o.IsAzureCNI() && !o.IsAzureCNIOverlay()
+}
+
 // RequireRouteTable returns true if this deployment requires routing table
 func (o *OrchestratorProfile) RequireRouteTable() bool {
 	switch o.OrchestratorType {
@@ -1256,11 +1538,46 @@ func (o *OrchestratorProfile) IsMetricsServerEnabled() bool {
 		common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.9.0"))
 }
 
+// IsAzureDiskCSIDriverEnabled checks if the azuredisk-csi-driver addon is enabled, which requires
+// the external cloud provider and CSI 1.0, supported by Kubernetes 1.13.0-alpha.1 and above
+func (o *OrchestratorProfile) IsAzureDiskCSIDriverEnabled() bool {
+	return o.isCSIDriverAddonEnabled(DefaultAzureDiskCSIDriverAddonName, DefaultAzureDiskCSIDriverAddonEnabled)
+}
+
+// IsAzureFileCSIDriverEnabled checks if the azurefile-csi-driver addon is enabled, which requires
+// the external cloud provider and CSI 1.0, supported by Kubernetes 1.13.0-alpha.1 and above
+func (o *OrchestratorProfile) IsAzureFileCSIDriverEnabled() bool {
+	return o.isCSIDriverAddonEnabled(DefaultAzureFileCSIDriverAddonName, DefaultAzureFileCSIDriverAddonEnabled)
+}
+
+// IsCloudNodeManagerEnabled checks if the cloud-node-manager addon is enabled, which initializes the
+// nodes registered by kubelets running with the external cloud provider
+func (o *OrchestratorProfile) IsCloudNodeManagerEnabled() bool {
+	useCloudControllerManager := helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager)
+	return useCloudControllerManager && o.KubernetesConfig.isAddonEnabled(DefaultCloudNodeManagerAddonName, useCloudControllerManager)
+}
+
+func (o *OrchestratorProfile) isCSIDriverAddonEnabled(addonName string, defaultValue bool) bool {
+	return o.KubernetesConfig.isAddonEnabled(addonName, defaultValue) &&
+		helpers.IsTrueBoolPointer(o.KubernetesConfig.UseCloudControllerManager) &&
+		common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.13.0-alpha.1")
+}
+
 // IsContainerMonitoringEnabled checks if the container monitoring addon is enabled
 func (k *KubernetesConfig) IsContainerMonitoringEnabled() bool {
 	return k.isAddonEnabled(ContainerMonitoringAddonName, DefaultContainerMonitoringAddonEnabled)
 }
 
+// GetContainerMonitoringWorkspaceResourceID returns the resource ID of the existing Log Analytics
+// workspace the container-monitoring addon reports to, if the addon is enabled and references one
+// rather than being given the workspace id and key
+func (k *KubernetesConfig) GetContainerMonitoringWorkspaceResourceID() string {
+	if !k.IsContainerMonitoringEnabled() {
+		return ""
+	}
+	return k.GetAddonByName(ContainerMonitoringAddonName).Config["workspaceResourceId"]
+}
+
 // IsTillerEnabled checks if the tiller addon is enabled
 func (k *KubernetesConfig) IsTillerEnabled() bool {
 	return k.isAddonEnabled(DefaultTillerAddonName, DefaultTillerAddonEnabled)
@@ -1344,6 +1661,59 @@ func (k *KubernetesConfig) IsReschedulerEnabled() bool {
 	return k.isAddonEnabled(DefaultReschedulerAddonName, DefaultReschedulerAddonEnabled)
 }
 
+// IsGatekeeperEnabled checks if the OPA Gatekeeper addon is enabled
+func (k *KubernetesConfig) IsGatekeeperEnabled() bool {
+	return k.isAddonEnabled(DefaultGatekeeperAddonName, DefaultGatekeeperAddonEnabled)
+}
+
+// IsNodeProblemDetectorEnabled checks if the node-problem-detector addon is enabled
+func (k *KubernetesConfig) IsNodeProblemDetectorEnabled() bool {
+	return k.isAddonEnabled(DefaultNodeProblemDetectorAddonName, DefaultNodeProblemDetectorAddonEnabled)
+}
+
+// IsStartupTaintRemoverEnabled checks if the startup-taint-remover addon is enabled
+func (k *KubernetesConfig) IsStartupTaintRemoverEnabled() bool {
+	return k.isAddonEnabled(DefaultStartupTaintRemoverAddonName, DefaultStartupTaintRemoverAddonEnabled)
+}
+
+// IsNodeLocalDNSEnabled checks if the nodelocaldns addon, caching the DNS queries of the pods on each node, is enabled
+func (k *KubernetesConfig) IsNodeLocalDNSEnabled() bool {
+	return k.isAddonEnabled(DefaultNodeLocalDNSAddonName, DefaultNodeLocalDNSAddonEnabled)
+}
+
+// IsDNSAutoscalerEnabled checks if the dns-autoscaler addon, scaling the replicas of the DNS deployment
+// with the nodes and cores of the cluster, is enabled
+func (k *KubernetesConfig) IsDNSAutoscalerEnabled() bool {
+	return k.isAddonEnabled(DefaultDNSAutoscalerAddonName, DefaultDNSAutoscalerAddonEnabled)
+}
+
+// IsVeleroEnabled checks if the velero addon is enabled
+func (k *KubernetesConfig) IsVeleroEnabled() bool {
+	return k.isAddonEnabled(DefaultVeleroAddonName, DefaultVeleroAddonEnabled)
+}
+
+// GetStartupTaint returns the taint registered on the Linux agent nodes until the startup-taint-remover
+// addon removes it, or an empty string if the addon is disabled
+func (k *KubernetesConfig) GetStartupTaint() string {
+	if !k.IsStartupTaintRemoverEnabled() {
+		return ""
+	}
+	if taint := k.GetAddonByName(DefaultStartupTaintRemoverAddonName).Config["taint"]; taint != "" {
+		return taint
+	}
+	return DefaultStartupTaint
+}
+
+// GetStartupTaintKey returns the key of the startup taint, which the critical DaemonSets tolerate, or the
+// key of the default startup taint if the startup-taint-remover addon is disabled
+func (k *KubernetesConfig) GetStartupTaintKey() string {
+	taint := k.GetStartupTaint()
+	if taint == "" {
+		taint = DefaultStartupTaint
+	}
+	return strings.FieldsFunc(taint, func(r rune) bool { return r == '=' || r == ':' })[0]
+}
+
 // PrivateJumpboxProvision checks if a private cluster has jumpbox auto-provisioning
 func (k *KubernetesConfig) PrivateJumpboxProvision() bool {
 	if k != nil && k.PrivateCluster != nil && *k.PrivateCluster.Enabled && k.PrivateCluster.JumpboxProfile != nil {
@@ -1352,6 +1722,116 @@ func (k *KubernetesConfig) PrivateJumpboxProvision() bool {
 	return false
 }
 
+// HasPrivateRegistry checks if the nodes are given the credentials of a private container registry
+func (k *KubernetesConfig) HasPrivateRegistry() bool {
+	return k != nil && k.PrivateRegistry != nil && k.PrivateRegistry.Server != ""
+}
+
+// HasEgressFirewall checks if the egress traffic of the cluster is routed through a firewall virtual appliance
+func (k *KubernetesConfig) HasEgressFirewall() bool {
+	return k != nil && k.EgressFirewall != nil && k.EgressFirewall.VirtualApplianceIP != ""
+}
+
+// IsKubeletCertRotationEnabled checks if the kubelets renew their client certificates before they expire
+func (k *KubernetesConfig) IsKubeletCertRotationEnabled() bool {
+	return k != nil && helpers.IsTrueBoolPointer(k.EnableKubeletCertRotation)
+}
+
+// HasNATGateway checks if the egress traffic of the cluster subnets goes through an existing NAT gateway
+func (k *KubernetesConfig) HasNATGateway() bool {
+	return k != nil && k.NATGatewayID != ""
+}
+
+// IsAgentOutboundDisabled checks if the agent nodes only reach the internet through the egress firewall or NAT gateway
+func (k *KubernetesConfig) IsAgentOutboundDisabled() bool {
+	return k != nil && helpers.IsTrueBoolPointer(k.DisableAgentOutbound)
+}
+
+// GetAPIServerSecurePort returns the port the apiserver serves HTTPS on, and the master load balancers expose
+func (k *KubernetesConfig) GetAPIServerSecurePort() int {
+	if k == nil || k.APIServerSecurePort == 0 {
+		return DefaultKubernetesAPIServerSecurePort
+	}
+	return k.APIServerSecurePort
+}
+
+// HasCustomAPIServerSecurePort checks if the apiserver serves HTTPS on another port than the default 443
+func (k *KubernetesConfig) HasCustomAPIServerSecurePort() bool {
+	return k.GetAPIServerSecurePort() != DefaultKubernetesAPIServerSecurePort
+}
+
+// IsSwapEnabled checks if swap is enabled on the nodes using this config
+func (k *KubernetesConfig) IsSwapEnabled() bool {
+	return k != nil && helpers.IsTrueBoolPointer(k.SwapEnabled)
+}
+
+// IsReadOnlyRootFilesystemEnabled checks if the root filesystem of the nodes using this config is read-only
+func (k *KubernetesConfig) IsReadOnlyRootFilesystemEnabled() bool {
+	return k != nil && helpers.IsTrueBoolPointer(k.ReadOnlyRootFilesystem)
+}
+
+// IsResolvedStubDisabled checks if the systemd-resolved stub resolver of the nodes using this config is disabled,
+// their /etc/resolv.conf then lists the upstream name servers
+func (k *KubernetesConfig) IsResolvedStubDisabled() bool {
+	return k != nil && helpers.IsTrueBoolPointer(k.DisableResolvedStub)
+}
+
+// IsCgroupV2Enabled checks if the nodes using this config boot with the unified cgroup v2 hierarchy
+func (k *KubernetesConfig) IsCgroupV2Enabled() bool {
+	return k != nil && helpers.IsTrueBoolPointer(k.CgroupV2Enabled)
+}
+
+// IsPrometheusMetricsEnabled checks if the control plane components of the cluster are annotated for Prometheus
+// to scrape their metrics, on the secure ports of their delegated authentication and authorization
+func (k *KubernetesConfig) IsPrometheusMetricsEnabled() bool {
+	return k != nil && helpers.IsTrueBoolPointer(k.EnablePrometheusMetrics)
+}
+
+// IsIPv6DualStackEnabled checks if the pods and services get IPv6 addresses besides their IPv4 ones, the cluster
+// subnet then listing an IPv4 and an IPv6 CIDR separated by a comma
+func (k *KubernetesConfig) IsIPv6DualStackEnabled() bool {
+	return k != nil && strings.Contains(k.ClusterSubnet, ",")
+}
+
+// GetIPv4ClusterSubnet returns the IPv4 CIDR of the cluster subnet, the first one of a dual-stack cluster
+func (k *KubernetesConfig) GetIPv4ClusterSubnet() string {
+	return strings.Split(k.ClusterSubnet, ",")[0]
+}
+
+// GetIPv4ServiceCIDR returns the IPv4 CIDR the cluster IPs of the services are allocated from, the first one of a dual-stack cluster
+func (k *KubernetesConfig) GetIPv4ServiceCIDR() string {
+	return strings.Split(k.ServiceCIDR, ",")[0]
+}
+
+// HasServiceAccountIssuer checks if the API server issues the bound service account tokens projected into the pods
+func (k *KubernetesConfig) HasServiceAccountIssuer() bool {
+	return k != nil && k.ServiceAccountIssuer != ""
+}
+
+// IsKubeProxyStaticPod checks if kube-proxy runs as a static pod of each Linux node rather than as a DaemonSet
+func (k *KubernetesConfig) IsKubeProxyStaticPod() bool {
+	return k != nil && k.KubeProxyDeploymentMode == KubeProxyDeploymentModeStaticPod
+}
+
+// IsBootstrapTokenEnabled checks if nodes join the cluster using a short-lived bootstrap token
+func (k *KubernetesConfig) IsBootstrapTokenEnabled() bool {
+	return k != nil && k.BootstrapTokenTTL != ""
+}
+
+// GetBootstrapTokenID returns the public id portion of the bootstrap token
+func (k *KubernetesConfig) GetBootstrapTokenID() string {
+	return strings.SplitN(k.BootstrapToken, ".", 2)[0]
+}
+
+// GetBootstrapTokenSecret returns the secret portion of the bootstrap token
+func (k *KubernetesConfig) GetBootstrapTokenSecret() string {
+	parts := strings.SplitN(k.BootstrapToken, ".", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[1]
+}
+
 // RequiresDocker returns if the kubernetes settings require docker binary to be installed.
 func (k *KubernetesConfig) RequiresDocker() bool {
 	runtime := strings.ToLower(k.ContainerRuntime)
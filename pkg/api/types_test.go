@@ -1400,6 +1400,39 @@ func TestAgentPoolIsNSeriesSKU(t *testing.T) {
 	}
 }
 
+func TestAgentPoolGetKubernetesTaints(t *testing.T) {
+	cases := []struct {
+		name     string
+		profile  AgentPoolProfile
+		expected []string
+	}{
+		{
+			name:    "no taints",
+			profile: AgentPoolProfile{Role: AgentPoolProfileRoleUser},
+		},
+		{
+			name:     "system pool",
+			profile:  AgentPoolProfile{Role: AgentPoolProfileRoleSystem},
+			expected: []string{SystemAgentPoolTaint},
+		},
+		{
+			name:     "system pool with custom taints",
+			profile:  AgentPoolProfile{Role: AgentPoolProfileRoleSystem, CustomNodeTaints: []string{"dedicated=gpu:NoSchedule"}},
+			expected: []string{SystemAgentPoolTaint, "dedicated=gpu:NoSchedule"},
+		},
+		{
+			name:     "custom taints",
+			profile:  AgentPoolProfile{CustomNodeTaints: []string{"dedicated=gpu:NoSchedule", "spot:PreferNoSchedule"}},
+			expected: []string{"dedicated=gpu:NoSchedule", "spot:PreferNoSchedule"},
+		},
+	}
+	for _, c := range cases {
+		if taints := c.profile.GetKubernetesTaints(); !reflect.DeepEqual(taints, c.expected) {
+			t.Errorf("%s: expected taints %v, got %v", c.name, c.expected, taints)
+		}
+	}
+}
+
 func TestIsContainerMonitoringEnabled(t *testing.T) {
 	v := "1.9.0"
 	o := OrchestratorProfile{
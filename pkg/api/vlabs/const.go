@@ -43,6 +43,9 @@ const (
 	MinAgentCount = 1
 	// MaxAgentCount are the maximum number of agents per agent pool
 	MaxAgentCount = 100
+	// MaxAvailabilitySetsAgentCount is the maximum number of agents of a Kubernetes agent pool of availability
+	// sets with managed disks, which is split across availability sets of at most MaxAgentCount agents each
+	MaxAvailabilitySetsAgentCount = 1000
 	// MinPort specifies the minimum tcp port to open
 	MinPort = 1
 	// MaxPort specifies the maximum tcp port to open
@@ -57,6 +60,32 @@ const (
 	MinIPAddressCount = 1
 	// MaxIPAddressCount specifies the maximum number of IP addresses per network interface
 	MaxIPAddressCount = 256
+	// MinPlatformFaultDomainCount specifies the minimum number of fault domains of an availability set
+	MinPlatformFaultDomainCount = 1
+	// MaxPlatformFaultDomainCount specifies the maximum number of fault domains of an availability set
+	MaxPlatformFaultDomainCount = 3
+	// MinPlatformUpdateDomainCount specifies the minimum number of update domains of an availability set
+	MinPlatformUpdateDomainCount = 1
+	// MaxPlatformUpdateDomainCount specifies the maximum number of update domains of an availability set
+	MaxPlatformUpdateDomainCount = 20
+	// MinLoadBalancerIdleTimeoutInMinutes specifies the minimum idle timeout of a load balancing rule
+	MinLoadBalancerIdleTimeoutInMinutes = 4
+	// MaxLoadBalancerIdleTimeoutInMinutes specifies the maximum idle timeout of a load balancing rule
+	MaxLoadBalancerIdleTimeoutInMinutes = 30
+	// MinLoadBalancerProbeIntervalInSeconds specifies the minimum interval of a load balancer health probe
+	MinLoadBalancerProbeIntervalInSeconds = 5
+	// MinLoadBalancerProbeThreshold specifies the minimum number of failed load balancer health probes taking a backend out of rotation
+	MinLoadBalancerProbeThreshold = 1
+	// KubeletHealthzPort is the localhost healthz port of the kubelet probed by the health monitor of the nodes
+	KubeletHealthzPort = "10248"
+	// MinEtcdStorageLimitGB is the minimum storage backend quota of etcd in GB, the default quota of etcd
+	MinEtcdStorageLimitGB = 2
+	// MaxEtcdStorageLimitGB is the maximum storage backend quota of etcd in GB, the maximum size etcd recommends
+	MaxEtcdStorageLimitGB = 8
+	// MinPodMTU is the minimum MTU of the interface of the pods, the minimum MTU of IPv6
+	MinPodMTU = 1280
+	// MaxPodMTU is the maximum MTU of the interface of the pods, the MTU of the Azure virtual networks
+	MaxPodMTU = 1500
 )
 
 // Availability profiles
@@ -77,7 +106,10 @@ const (
 
 var (
 	// NetworkPluginValues holds the valid values for network plugin implementation
-	NetworkPluginValues = [...]string{"", "kubenet", "azure", "cilium", "flannel"}
+	NetworkPluginValues = [...]string{"", "kubenet", "azure", "cilium", "flannel", "cni"}
+
+	// NetworkPluginModeValues holds the valid values for the mode of the network plugin
+	NetworkPluginModeValues = [...]string{"", "overlay"}
 
 	// NetworkPolicyValues holds the valid values for a network policy
 	// "azure" and "none" are there for backwards-compatibility
@@ -85,12 +117,160 @@ var (
 
 	// ContainerRuntimeValues holds the valid values for container runtimes
 	ContainerRuntimeValues = [...]string{"", "docker", "clear-containers", "kata-containers", "containerd"}
+
+	// CgroupDriverValues holds the valid values for the cgroup driver of the kubelet and container runtime
+	CgroupDriverValues = [...]string{"", CgroupDriverCgroupfs, CgroupDriverSystemd}
+
+	// KubeProxyDeploymentModeValues holds the valid values for the way kube-proxy is deployed
+	KubeProxyDeploymentModeValues = [...]string{"", KubeProxyDeploymentModeDaemonSet, KubeProxyDeploymentModeStaticPod}
+
+	// TLSCipherSuiteValues holds the cipher suites the kubelet and the apiserver accept in --tls-cipher-suites
+	TLSCipherSuiteValues = [...]string{
+		"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+		"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256",
+		"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+		"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+		"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+		"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+		"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305",
+		"TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+		"TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+		"TLS_RSA_WITH_AES_128_CBC_SHA",
+		"TLS_RSA_WITH_AES_128_CBC_SHA256",
+		"TLS_RSA_WITH_AES_128_GCM_SHA256",
+		"TLS_RSA_WITH_AES_256_CBC_SHA",
+		"TLS_RSA_WITH_AES_256_GCM_SHA384",
+		"TLS_RSA_WITH_RC4_128_SHA",
+	}
+
+	// TLSMinVersionValues holds the valid values for the --tls-min-version of the kubelet and the apiserver
+	TLSMinVersionValues = [...]string{"", "VersionTLS10", "VersionTLS11", "VersionTLS12"}
+
+	// StorageClassProvisionerValues holds the valid provisioners of the default StorageClass of the cluster
+	StorageClassProvisionerValues = [...]string{"", "kubernetes.io/azure-disk", "kubernetes.io/azure-file"}
+
+	// AzureDiskStorageClassSKUValues holds the valid SKUs of a StorageClass of Azure disks
+	AzureDiskStorageClassSKUValues = [...]string{"", "Standard_LRS", "StandardSSD_LRS", "Premium_LRS"}
+
+	// AzureFileStorageClassSKUValues holds the valid SKUs of a StorageClass of Azure files
+	AzureFileStorageClassSKUValues = [...]string{"", "Standard_LRS", "Standard_GRS", "Standard_ZRS", "Standard_RAGRS", "Premium_LRS"}
+
+	// StorageClassReclaimPolicyValues holds the valid reclaim policies of the default StorageClass of the cluster
+	StorageClassReclaimPolicyValues = [...]string{"", "Delete", "Retain"}
+
+	// StorageClassVolumeBindingModeValues holds the valid volume binding modes of the default StorageClass of the cluster
+	StorageClassVolumeBindingModeValues = [...]string{"", "Immediate", "WaitForFirstConsumer"}
+)
+
+// featureGateVersions holds the Kubernetes version introducing a feature gate and, if any, the version removing it
+type featureGateVersions struct {
+	introduced string
+	removed    string
+}
+
+// knownFeatureGates holds the feature gates which can be configured for the cluster
+var knownFeatureGates = map[string]featureGateVersions{
+	"DynamicVolumeProvisioning":               {introduced: "1.3.0"},
+	"AllAlpha":                                {introduced: "1.4.0"},
+	"AppArmor":                                {introduced: "1.4.0"},
+	"DynamicKubeletConfig":                    {introduced: "1.4.0"},
+	"ExperimentalCriticalPodAnnotation":       {introduced: "1.5.0"},
+	"ExperimentalHostUserNamespaceDefaulting": {introduced: "1.5.0"},
+	"StreamingProxyRedirects":                 {introduced: "1.5.0"},
+	"Accelerators":                            {introduced: "1.6.0", removed: "1.11.0"},
+	"TaintBasedEvictions":                     {introduced: "1.6.0"},
+	"APIResponseCompression":                  {introduced: "1.7.0"},
+	"AdvancedAuditing":                        {introduced: "1.7.0"},
+	"Initializers":                            {introduced: "1.7.0"},
+	"LocalStorageCapacityIsolation":           {introduced: "1.7.0"},
+	"PersistentLocalVolumes":                  {introduced: "1.7.0"},
+	"RotateKubeletClientCertificate":          {introduced: "1.7.0"},
+	"RotateKubeletServerCertificate":          {introduced: "1.7.0"},
+	"APIListChunking":                         {introduced: "1.8.0"},
+	"CPUManager":                              {introduced: "1.8.0"},
+	"CustomResourceValidation":                {introduced: "1.8.0"},
+	"DevicePlugins":                           {introduced: "1.8.0"},
+	"EnableEquivalenceClassCache":             {introduced: "1.8.0"},
+	"ExpandPersistentVolumes":                 {introduced: "1.8.0"},
+	"HugePages":                               {introduced: "1.8.0"},
+	"MountPropagation":                        {introduced: "1.8.0"},
+	"PodPriority":                             {introduced: "1.8.0"},
+	"ServiceNodeExclusion":                    {introduced: "1.8.0"},
+	"SupportIPVSProxyMode":                    {introduced: "1.8.0"},
+	"TaintNodesByCondition":                   {introduced: "1.8.0"},
+	"BlockVolume":                             {introduced: "1.9.0"},
+	"CSIPersistentVolume":                     {introduced: "1.9.0"},
+	"CustomPodDNS":                            {introduced: "1.9.0"},
+	"MountContainers":                         {introduced: "1.9.0"},
+	"ResourceLimitsPriorityFunction":          {introduced: "1.9.0"},
+	"VolumeScheduling":                        {introduced: "1.9.0"},
+	"CRIContainerLogRotation":                 {introduced: "1.10.0"},
+	"CustomResourceSubresources":              {introduced: "1.10.0"},
+	"DebugContainers":                         {introduced: "1.10.0"},
+	"HyperVContainer":                         {introduced: "1.10.0"},
+	"PodShareProcessNamespace":                {introduced: "1.10.0"},
+	"RunAsGroup":                              {introduced: "1.10.0"},
+	"StorageObjectInUseProtection":            {introduced: "1.10.0"},
+	"SupportPodPidsLimit":                     {introduced: "1.10.0"},
+	"TokenRequest":                            {introduced: "1.10.0"},
+	"ValidateProxyRedirects":                  {introduced: "1.10.0"},
+	"VolumeSubpath":                           {introduced: "1.10.0"},
+	"AttachVolumeLimit":                       {introduced: "1.11.0"},
+	"BalanceAttachedNodeVolumes":              {introduced: "1.11.0"},
+	"CSIBlockVolume":                          {introduced: "1.11.0"},
+	"ExpandInUsePersistentVolumes":            {introduced: "1.11.0"},
+	"KubeletPluginsWatcher":                   {introduced: "1.11.0"},
+	"PodReadinessGates":                       {introduced: "1.11.0"},
+	"QOSReserved":                             {introduced: "1.11.0"},
+	"ResourceQuotaScopeSelectors":             {introduced: "1.11.0"},
+	"ScheduleDaemonSetPods":                   {introduced: "1.11.0"},
+	"Sysctls":                                 {introduced: "1.11.0"},
+	"TokenRequestProjection":                  {introduced: "1.11.0"},
+	"VolumeSubpathEnvExpansion":               {introduced: "1.11.0"},
+	"CPUCFSQuotaPeriod":                       {introduced: "1.12.0"},
+	"CSIDriverRegistry":                       {introduced: "1.12.0"},
+	"CSINodeInfo":                             {introduced: "1.12.0"},
+	"DryRun":                                  {introduced: "1.12.0"},
+	"NodeLease":                               {introduced: "1.12.0"},
+	"ProcMountType":                           {introduced: "1.12.0"},
+	"RuntimeClass":                            {introduced: "1.12.0"},
+	"SCTPSupport":                             {introduced: "1.12.0"},
+	"TTLAfterFinished":                        {introduced: "1.12.0"},
+	"VolumeSnapshotDataSource":                {introduced: "1.12.0"},
+	"BoundServiceAccountTokenVolume":          {introduced: "1.13.0"},
+	"CustomResourceWebhookConversion":         {introduced: "1.13.0"},
+	"DynamicAuditing":                         {introduced: "1.13.0"},
+	"KubeletPodResources":                     {introduced: "1.13.0"},
+	"IPv6DualStack":                           {introduced: "1.16.0"},
+}
+
+const (
+	// CgroupDriverCgroupfs manages cgroups through the cgroup filesystem
+	CgroupDriverCgroupfs = "cgroupfs"
+	// CgroupDriverSystemd manages cgroups through systemd
+	CgroupDriverSystemd = "systemd"
+)
+
+const (
+	// KubeProxyDeploymentModeDaemonSet runs kube-proxy as a DaemonSet managed by the addon manager
+	KubeProxyDeploymentModeDaemonSet = "DaemonSet"
+	// KubeProxyDeploymentModeStaticPod runs kube-proxy as a static pod of each Linux node
+	KubeProxyDeploymentModeStaticPod = "StaticPod"
 )
 
 // Kubernetes configuration
 const (
 	// KubernetesMinMaxPods is the minimum valid value for MaxPods, necessary for running kube-system pods
 	KubernetesMinMaxPods = 5
+	// KubernetesMaxPidMax is the maximum valid value for PidMax, the PID limit of a 64-bit Linux kernel
+	KubernetesMaxPidMax = 4194304
 )
 
 // vlabs default configuration
@@ -101,6 +281,8 @@ const (
 	DefaultNetworkPluginWindows = "azure"
 	// DefaultNetworkPolicy defines the network policy to use by default
 	DefaultNetworkPolicy = ""
+	// DefaultServiceCidr defines the service CIDR the cluster is given when the api model doesn't specify one
+	DefaultServiceCidr = "10.0.0.0/16"
 )
 
 const (
@@ -108,4 +290,10 @@ const (
 	AgentPoolProfileRoleEmpty AgentPoolProfileRole = ""
 	// AgentPoolProfileRoleInfra is the infra role
 	AgentPoolProfileRoleInfra AgentPoolProfileRole = "infra"
+	// AgentPoolProfileRoleSystem is the role of pools hosting system addons
+	AgentPoolProfileRoleSystem AgentPoolProfileRole = "system"
+	// AgentPoolProfileRoleUser is the role of pools hosting user workloads
+	AgentPoolProfileRoleUser AgentPoolProfileRole = "user"
+	// AgentPoolProfileRoleDaemonSet is the role of pools reserved for DaemonSet workloads
+	AgentPoolProfileRoleDaemonSet AgentPoolProfileRole = "daemonset"
 )
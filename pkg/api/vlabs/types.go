@@ -47,6 +47,8 @@ type Properties struct {
 	AADProfile              *AADProfile              `json:"aadProfile,omitempty"`
 	AzProfile               *AzProfile               `json:"azProfile,omitempty"`
 	FeatureFlags            *FeatureFlags            `json:"featureFlags,omitempty"`
+	ExtraParameters         map[string]interface{}   `json:"extraParameters,omitempty"`
+	ExtraVariables          map[string]interface{}   `json:"extraVariables,omitempty"`
 }
 
 // AzProfile holds the azure context for where the cluster resides
@@ -64,14 +66,18 @@ type FeatureFlags struct {
 }
 
 // ServicePrincipalProfile contains the client and secret used by the cluster for Azure Resource CRUD
-// The 'Secret' and 'KeyvaultSecretRef' parameters are mutually exclusive
+// The 'Secret', 'KeyvaultSecretRef' and 'Certificate' parameters are mutually exclusive
 // The 'Secret' parameter should be a secret in plain text.
 // The 'KeyvaultSecretRef' parameter is a reference to a secret in a keyvault.
+// The 'Certificate' parameter is a PEM bundle holding the client certificate and its private key,
+// the private key may be encrypted with 'CertificatePassword'.
 type ServicePrincipalProfile struct {
-	ClientID          string             `json:"clientId,omitempty"`
-	Secret            string             `json:"secret,omitempty"`
-	ObjectID          string             `json:"objectId,omitempty"`
-	KeyvaultSecretRef *KeyvaultSecretRef `json:"keyvaultSecretRef,omitempty"`
+	ClientID            string             `json:"clientId,omitempty"`
+	Secret              string             `json:"secret,omitempty"`
+	Certificate         string             `json:"certificate,omitempty"`
+	CertificatePassword string             `json:"certificatePassword,omitempty"`
+	ObjectID            string             `json:"objectId,omitempty"`
+	KeyvaultSecretRef   *KeyvaultSecretRef `json:"keyvaultSecretRef,omitempty"`
 }
 
 // KeyvaultSecretRef is a reference to a secret in a keyvault.
@@ -128,6 +134,16 @@ type CertificateProfile struct {
 	EtcdPeerCertificates []string `json:"etcdPeerCertificates,omitempty"`
 	// EtcdPeerPrivateKeys is list of etcd peer private keys, and signed by the CA
 	EtcdPeerPrivateKeys []string `json:"etcdPeerPrivateKeys,omitempty"`
+	// ServiceAccountSigningKey is the private key the API server signs the bound service account tokens with
+	ServiceAccountSigningKey string `json:"serviceAccountSigningKey,omitempty"`
+	// FrontProxyCACertificate is the certificate authority the API server trusts the request headers of the aggregation layer from
+	FrontProxyCACertificate string `json:"frontProxyCACertificate,omitempty"`
+	// FrontProxyCAPrivateKey is the private key of the front proxy certificate authority
+	FrontProxyCAPrivateKey string `json:"frontProxyCAPrivateKey,omitempty"`
+	// FrontProxyClientCertificate is the certificate the API server proxies the requests to the aggregated API servers with, and signed by the front proxy CA
+	FrontProxyClientCertificate string `json:"frontProxyClientCertificate,omitempty"`
+	// FrontProxyClientPrivateKey is the private key the API server proxies the requests to the aggregated API servers with
+	FrontProxyClientPrivateKey string `json:"frontProxyClientPrivateKey,omitempty"`
 }
 
 // LinuxProfile represents the linux parameters passed to the cluster
@@ -234,6 +250,7 @@ func (o *OrchestratorProfile) UnmarshalJSON(b []byte) error {
 type KubernetesContainerSpec struct {
 	Name           string `json:"name,omitempty"`
 	Image          string `json:"image,omitempty"`
+	Version        string `json:"version,omitempty"`
 	CPURequests    string `json:"cpuRequests,omitempty"`
 	MemoryRequests string `json:"memoryRequests,omitempty"`
 	CPULimits      string `json:"cpuLimits,omitempty"`
@@ -257,12 +274,72 @@ func (a *KubernetesAddon) IsEnabled(ifNil bool) bool {
 	return *a.Enabled
 }
 
+// OIDCConfig configures the API server to authenticate users with the ID tokens
+// issued by an OpenID Connect provider
+type OIDCConfig struct {
+	IssuerURL      string `json:"issuerURL,omitempty"`
+	ClientID       string `json:"clientID,omitempty"`
+	UsernameClaim  string `json:"usernameClaim,omitempty"`
+	UsernamePrefix string `json:"usernamePrefix,omitempty"`
+	GroupsClaim    string `json:"groupsClaim,omitempty"`
+	GroupsPrefix   string `json:"groupsPrefix,omitempty"`
+}
+
+// WebhookTokenAuth configures the API server to authenticate bearer tokens with the token review
+// webhook of an external identity system
+type WebhookTokenAuth struct {
+	URL           string `json:"url,omitempty"`
+	CACertificate string `json:"caCertificate,omitempty"`
+	CacheTTL      string `json:"cacheTTL,omitempty"`
+}
+
+// AuditWebhook configures the API server to send its audit events to the webhook of an external system, e.g. a SIEM,
+// besides the audit log of the masters
+type AuditWebhook struct {
+	URL             string `json:"url,omitempty"`
+	CACertificate   string `json:"caCertificate,omitempty"`
+	Mode            string `json:"mode,omitempty"`
+	BatchBufferSize int    `json:"batchBufferSize,omitempty"`
+	BatchMaxSize    int    `json:"batchMaxSize,omitempty"`
+	BatchMaxWait    string `json:"batchMaxWait,omitempty"`
+}
+
+// AdmissionWebhook is a validating or mutating admission webhook configuration of a Kubernetes cluster,
+// applied by the addon-manager once the components of kube-system run, so that the webhook can't block
+// their creation
+type AdmissionWebhook struct {
+	Name     string `json:"name,omitempty"`
+	Manifest string `json:"manifest,omitempty"`
+}
+
+// StorageClass configures the "default" StorageClass of a Kubernetes cluster, which the persistent
+// volume claims without a storage class are provisioned with
+type StorageClass struct {
+	Provisioner       string `json:"provisioner,omitempty"`
+	SKU               string `json:"sku,omitempty"`
+	ReclaimPolicy     string `json:"reclaimPolicy,omitempty"`
+	VolumeBindingMode string `json:"volumeBindingMode,omitempty"`
+}
+
 // PrivateCluster defines the configuration for a private cluster
 type PrivateCluster struct {
 	Enabled        *bool                  `json:"enabled,omitempty"`
 	JumpboxProfile *PrivateJumpboxProfile `json:"jumpboxProfile,omitempty"`
 }
 
+// PrivateRegistry contains the credentials of a private container registry the nodes pull images from
+type PrivateRegistry struct {
+	Server   string `json:"server,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// EgressFirewall routes the egress traffic of the cluster through a firewall network virtual appliance
+type EgressFirewall struct {
+	VirtualApplianceIP    string   `json:"virtualApplianceIP,omitempty"`
+	BypassAddressPrefixes []string `json:"bypassAddressPrefixes,omitempty"`
+}
+
 // PrivateJumpboxProfile represents a jumpbox definition
 type PrivateJumpboxProfile struct {
 	Name           string `json:"name" validate:"required"`
@@ -276,57 +353,116 @@ type PrivateJumpboxProfile struct {
 // KubernetesConfig contains the Kubernetes config structure, containing
 // Kubernetes specific configuration
 type KubernetesConfig struct {
-	KubernetesImageBase             string            `json:"kubernetesImageBase,omitempty"`
-	ClusterSubnet                   string            `json:"clusterSubnet,omitempty"`
-	DNSServiceIP                    string            `json:"dnsServiceIP,omitempty"`
-	ServiceCidr                     string            `json:"serviceCidr,omitempty"`
-	NetworkPolicy                   string            `json:"networkPolicy,omitempty"`
-	NetworkPlugin                   string            `json:"networkPlugin,omitempty"`
-	ContainerRuntime                string            `json:"containerRuntime,omitempty"`
-	MaxPods                         int               `json:"maxPods,omitempty"`
-	DockerBridgeSubnet              string            `json:"dockerBridgeSubnet,omitempty"`
-	UseManagedIdentity              bool              `json:"useManagedIdentity,omitempty"`
-	UserAssignedID                  string            `json:"userAssignedID,omitempty"`
-	UserAssignedClientID            string            `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
-	CustomHyperkubeImage            string            `json:"customHyperkubeImage,omitempty"`
-	DockerEngineVersion             string            `json:"dockerEngineVersion,omitempty"` // Deprecated
-	CustomCcmImage                  string            `json:"customCcmImage,omitempty"`
-	UseCloudControllerManager       *bool             `json:"useCloudControllerManager,omitempty"`
-	CustomWindowsPackageURL         string            `json:"customWindowsPackageURL,omitempty"`
-	WindowsNodeBinariesURL          string            `json:"windowsNodeBinariesURL,omitempty"`
-	UseInstanceMetadata             *bool             `json:"useInstanceMetadata,omitempty"`
-	EnableRbac                      *bool             `json:"enableRbac,omitempty"`
-	EnableSecureKubelet             *bool             `json:"enableSecureKubelet,omitempty"`
-	EnableAggregatedAPIs            bool              `json:"enableAggregatedAPIs,omitempty"`
-	PrivateCluster                  *PrivateCluster   `json:"privateCluster,omitempty"`
-	GCHighThreshold                 int               `json:"gchighthreshold,omitempty"`
-	GCLowThreshold                  int               `json:"gclowthreshold,omitempty"`
-	EtcdVersion                     string            `json:"etcdVersion,omitempty"`
-	EtcdDiskSizeGB                  string            `json:"etcdDiskSizeGB,omitempty"`
-	EtcdEncryptionKey               string            `json:"etcdEncryptionKey,omitempty"`
-	EnableDataEncryptionAtRest      *bool             `json:"enableDataEncryptionAtRest,omitempty"`
-	EnableEncryptionWithExternalKms *bool             `json:"enableEncryptionWithExternalKms,omitempty"`
-	EnablePodSecurityPolicy         *bool             `json:"enablePodSecurityPolicy,omitempty"`
-	Addons                          []KubernetesAddon `json:"addons,omitempty"`
-	KubeletConfig                   map[string]string `json:"kubeletConfig,omitempty"`
-	ControllerManagerConfig         map[string]string `json:"controllerManagerConfig,omitempty"`
-	CloudControllerManagerConfig    map[string]string `json:"cloudControllerManagerConfig,omitempty"`
-	APIServerConfig                 map[string]string `json:"apiServerConfig,omitempty"`
-	SchedulerConfig                 map[string]string `json:"schedulerConfig,omitempty"`
-	PodSecurityPolicyConfig         map[string]string `json:"podSecurityPolicyConfig,omitempty"`
-	CloudProviderBackoff            *bool             `json:"cloudProviderBackoff,omitempty"`
-	CloudProviderBackoffRetries     int               `json:"cloudProviderBackoffRetries,omitempty"`
-	CloudProviderBackoffJitter      float64           `json:"cloudProviderBackoffJitter,omitempty"`
-	CloudProviderBackoffDuration    int               `json:"cloudProviderBackoffDuration,omitempty"`
-	CloudProviderBackoffExponent    float64           `json:"cloudProviderBackoffExponent,omitempty"`
-	CloudProviderRateLimit          *bool             `json:"cloudProviderRateLimit,omitempty"`
-	CloudProviderRateLimitQPS       float64           `json:"cloudProviderRateLimitQPS,omitempty"`
-	CloudProviderRateLimitBucket    int               `json:"cloudProviderRateLimitBucket,omitempty"`
-	LoadBalancerSku                 string            `json:"loadBalancerSku,omitempty"`
-	ExcludeMasterFromStandardLB     *bool             `json:"excludeMasterFromStandardLB,omitempty"`
-	AzureCNIVersion                 string            `json:"azureCNIVersion,omitempty"`
-	AzureCNIURLLinux                string            `json:"azureCNIURLLinux,omitempty"`
-	AzureCNIURLWindows              string            `json:"azureCNIURLWindows,omitempty"`
+	KubernetesImageBase             string             `json:"kubernetesImageBase,omitempty"`
+	ClusterSubnet                   string             `json:"clusterSubnet,omitempty"`
+	DNSServiceIP                    string             `json:"dnsServiceIP,omitempty"`
+	ClusterDomain                   string             `json:"clusterDomain,omitempty"`
+	KubeProxyDeploymentMode         string             `json:"kubeProxyDeploymentMode,omitempty"`
+	ServiceCidr                     string             `json:"serviceCidr,omitempty"`
+	NetworkPolicy                   string             `json:"networkPolicy,omitempty"`
+	NetworkPlugin                   string             `json:"networkPlugin,omitempty"`
+	NetworkPluginMode               string             `json:"networkPluginMode,omitempty"`
+	ContainerRuntime                string             `json:"containerRuntime,omitempty"`
+	MaxPods                         int                `json:"maxPods,omitempty"`
+	MaxRequestsInflight             int                `json:"maxRequestsInflight,omitempty"`
+	MaxMutatingRequestsInflight     int                `json:"maxMutatingRequestsInflight,omitempty"`
+	RequestTimeout                  string             `json:"requestTimeout,omitempty"`
+	MinRequestTimeout               int                `json:"minRequestTimeout,omitempty"`
+	WatchCacheSizes                 map[string]int     `json:"watchCacheSizes,omitempty"`
+	NodeMonitorGracePeriod          string             `json:"nodeMonitorGracePeriod,omitempty"`
+	NodeMonitorPeriod               string             `json:"nodeMonitorPeriod,omitempty"`
+	PodEvictionTimeout              string             `json:"podEvictionTimeout,omitempty"`
+	SwapEnabled                     *bool              `json:"swapEnabled,omitempty"`
+	SwapSizeMB                      int                `json:"swapSizeMB,omitempty"`
+	ReadOnlyRootFilesystem          *bool              `json:"readOnlyRootFilesystem,omitempty"`
+	ResolvConf                      string             `json:"resolvConf,omitempty"`
+	DisableResolvedStub             *bool              `json:"disableResolvedStub,omitempty"`
+	CgroupDriver                    string             `json:"cgroupDriver,omitempty"`
+	CgroupV2Enabled                 *bool              `json:"cgroupV2Enabled,omitempty"`
+	PodMaxPids                      int                `json:"podMaxPids,omitempty"`
+	PidMax                          int                `json:"pidMax,omitempty"`
+	MaxOpenFiles                    int                `json:"maxOpenFiles,omitempty"`
+	PrePulledImages                 []string           `json:"prePulledImages,omitempty"`
+	RegistryMirrors                 []string           `json:"registryMirrors,omitempty"`
+	ContainerLogMaxSize             string             `json:"containerLogMaxSize,omitempty"`
+	ContainerLogMaxFiles            int                `json:"containerLogMaxFiles,omitempty"`
+	DockerBridgeSubnet              string             `json:"dockerBridgeSubnet,omitempty"`
+	PodMTU                          int                `json:"podMTU,omitempty"`
+	TLSCipherSuites                 []string           `json:"tlsCipherSuites,omitempty"`
+	TLSMinVersion                   string             `json:"tlsMinVersion,omitempty"`
+	UseManagedIdentity              bool               `json:"useManagedIdentity,omitempty"`
+	UserAssignedID                  string             `json:"userAssignedID,omitempty"`
+	UserAssignedClientID            string             `json:"userAssignedClientID,omitempty"` //Note: cannot be provided in config. Used *only* for transferring this to azure.json.
+	CustomHyperkubeImage            string             `json:"customHyperkubeImage,omitempty"`
+	DockerEngineVersion             string             `json:"dockerEngineVersion,omitempty"` // Deprecated
+	CustomCcmImage                  string             `json:"customCcmImage,omitempty"`
+	UseCloudControllerManager       *bool              `json:"useCloudControllerManager,omitempty"`
+	CustomWindowsPackageURL         string             `json:"customWindowsPackageURL,omitempty"`
+	WindowsNodeBinariesURL          string             `json:"windowsNodeBinariesURL,omitempty"`
+	UseInstanceMetadata             *bool              `json:"useInstanceMetadata,omitempty"`
+	EnableRbac                      *bool              `json:"enableRbac,omitempty"`
+	EnableSecureKubelet             *bool              `json:"enableSecureKubelet,omitempty"`
+	EnableKubeletCertRotation       *bool              `json:"enableKubeletCertRotation,omitempty"`
+	KubeletCertSigningDuration      string             `json:"kubeletCertSigningDuration,omitempty"`
+	EnableInsecurePort              *bool              `json:"enableInsecurePort,omitempty"`
+	APIServerAdvertiseAddress       string             `json:"apiServerAdvertiseAddress,omitempty"`
+	APIServerSecurePort             int                `json:"apiServerSecurePort,omitempty"`
+	EnableAggregatedAPIs            bool               `json:"enableAggregatedAPIs,omitempty"`
+	PrivateCluster                  *PrivateCluster    `json:"privateCluster,omitempty"`
+	PrivateRegistry                 *PrivateRegistry   `json:"privateRegistry,omitempty"`
+	EgressFirewall                  *EgressFirewall    `json:"egressFirewall,omitempty"`
+	OIDCConfig                      *OIDCConfig        `json:"oidcConfig,omitempty"`
+	WebhookTokenAuth                *WebhookTokenAuth  `json:"webhookTokenAuth,omitempty"`
+	AuditWebhook                    *AuditWebhook      `json:"auditWebhook,omitempty"`
+	AdmissionWebhooks               []AdmissionWebhook `json:"admissionWebhooks,omitempty"`
+	DefaultStorageClass             *StorageClass      `json:"defaultStorageClass,omitempty"`
+	FeatureGates                    map[string]bool    `json:"featureGates,omitempty"`
+	ServiceAccountIssuer            string             `json:"serviceAccountIssuer,omitempty"`
+	APIAudiences                    []string           `json:"apiAudiences,omitempty"`
+	KubeConfigClusterName           string             `json:"kubeConfigClusterName,omitempty"`
+	KubeConfigContextName           string             `json:"kubeConfigContextName,omitempty"`
+	KubeConfigUserName              string             `json:"kubeConfigUserName,omitempty"`
+	SchedulerPolicy                 string             `json:"schedulerPolicy,omitempty"`
+	GCHighThreshold                 int                `json:"gchighthreshold,omitempty"`
+	GCLowThreshold                  int                `json:"gclowthreshold,omitempty"`
+	EtcdVersion                     string             `json:"etcdVersion,omitempty"`
+	EtcdDiskSizeGB                  string             `json:"etcdDiskSizeGB,omitempty"`
+	EtcdStorageLimitGB              int                `json:"etcdStorageLimitGB,omitempty"`
+	EtcdCompactionInterval          string             `json:"etcdCompactionInterval,omitempty"`
+	EtcdDefragSchedule              string             `json:"etcdDefragSchedule,omitempty"`
+	EtcdEncryptionKey               string             `json:"etcdEncryptionKey,omitempty"`
+	BootstrapTokenTTL               string             `json:"bootstrapTokenTTL,omitempty"`
+	BootstrapToken                  string             `json:"bootstrapToken,omitempty"`
+	BootstrapTokenExpiration        string             `json:"bootstrapTokenExpiration,omitempty"`
+	EnableDataEncryptionAtRest      *bool              `json:"enableDataEncryptionAtRest,omitempty"`
+	EnableEncryptionWithExternalKms *bool              `json:"enableEncryptionWithExternalKms,omitempty"`
+	EnablePodSecurityPolicy         *bool              `json:"enablePodSecurityPolicy,omitempty"`
+	EnablePodPriority               *bool              `json:"enablePodPriority,omitempty"`
+	EnablePrometheusMetrics         *bool              `json:"enablePrometheusMetrics,omitempty"`
+	Addons                          []KubernetesAddon  `json:"addons,omitempty"`
+	KubeletConfig                   map[string]string  `json:"kubeletConfig,omitempty"`
+	ControllerManagerConfig         map[string]string  `json:"controllerManagerConfig,omitempty"`
+	CloudControllerManagerConfig    map[string]string  `json:"cloudControllerManagerConfig,omitempty"`
+	APIServerConfig                 map[string]string  `json:"apiServerConfig,omitempty"`
+	SchedulerConfig                 map[string]string  `json:"schedulerConfig,omitempty"`
+	PodSecurityPolicyConfig         map[string]string  `json:"podSecurityPolicyConfig,omitempty"`
+	CloudProviderConfig             map[string]string  `json:"cloudProviderConfig,omitempty"`
+	CloudProviderBackoff            *bool              `json:"cloudProviderBackoff,omitempty"`
+	CloudProviderBackoffRetries     int                `json:"cloudProviderBackoffRetries,omitempty"`
+	CloudProviderBackoffJitter      float64            `json:"cloudProviderBackoffJitter,omitempty"`
+	CloudProviderBackoffDuration    int                `json:"cloudProviderBackoffDuration,omitempty"`
+	CloudProviderBackoffExponent    float64            `json:"cloudProviderBackoffExponent,omitempty"`
+	CloudProviderRateLimit          *bool              `json:"cloudProviderRateLimit,omitempty"`
+	CloudProviderRateLimitQPS       float64            `json:"cloudProviderRateLimitQPS,omitempty"`
+	CloudProviderRateLimitBucket    int                `json:"cloudProviderRateLimitBucket,omitempty"`
+	LoadBalancerSku                 string             `json:"loadBalancerSku,omitempty"`
+	ExcludeMasterFromStandardLB     *bool              `json:"excludeMasterFromStandardLB,omitempty"`
+	ServiceInternalLBSubnetID       string             `json:"serviceInternalLBSubnetID,omitempty"`
+	NATGatewayID                    string             `json:"natGatewayID,omitempty"`
+	DisableAgentOutbound            *bool              `json:"disableAgentOutbound,omitempty"`
+	AzureCNIVersion                 string             `json:"azureCNIVersion,omitempty"`
+	AzureCNIURLLinux                string             `json:"azureCNIURLLinux,omitempty"`
+	AzureCNIURLWindows              string             `json:"azureCNIURLWindows,omitempty"`
 }
 
 // CustomFile has source as the full absolute source path to a file and dest
@@ -374,29 +510,49 @@ type OpenShiftConfig struct {
 
 // MasterProfile represents the definition of the master cluster
 type MasterProfile struct {
-	Count                    int               `json:"count" validate:"required,eq=1|eq=3|eq=5"`
-	DNSPrefix                string            `json:"dnsPrefix" validate:"required"`
-	SubjectAltNames          []string          `json:"subjectAltNames"`
-	VMSize                   string            `json:"vmSize" validate:"required"`
-	OSDiskSizeGB             int               `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
-	VnetSubnetID             string            `json:"vnetSubnetID,omitempty"`
-	VnetCidr                 string            `json:"vnetCidr,omitempty"`
-	AgentVnetSubnetID        string            `json:"agentVnetSubnetID,omitempty"`
-	FirstConsecutiveStaticIP string            `json:"firstConsecutiveStaticIP,omitempty"`
-	IPAddressCount           int               `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
-	StorageProfile           string            `json:"storageProfile,omitempty" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
-	HTTPSourceAddressPrefix  string            `json:"HTTPSourceAddressPrefix,omitempty"`
-	OAuthEnabled             bool              `json:"oauthEnabled"`
-	PreProvisionExtension    *Extension        `json:"preProvisionExtension"`
-	Extensions               []Extension       `json:"extensions"`
-	Distro                   Distro            `json:"distro,omitempty"`
-	KubernetesConfig         *KubernetesConfig `json:"kubernetesConfig,omitempty"`
-	ImageRef                 *ImageReference   `json:"imageReference,omitempty"`
-	CustomFiles              *[]CustomFile     `json:"customFiles,omitempty"`
-	AvailabilityProfile      string            `json:"availabilityProfile"`
-	AgentSubnet              string            `json:"agentSubnet,omitempty"`
-	AvailabilityZones        []string          `json:"availabilityZones,omitempty"`
-	SinglePlacementGroup     *bool             `json:"singlePlacementGroup,omitempty"`
+	Count                     int               `json:"count" validate:"required,eq=1|eq=3|eq=5"`
+	DNSPrefix                 string            `json:"dnsPrefix" validate:"required"`
+	SubjectAltNames           []string          `json:"subjectAltNames"`
+	VMSize                    string            `json:"vmSize" validate:"required"`
+	OSDiskSizeGB              int               `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
+	VnetSubnetID              string            `json:"vnetSubnetID,omitempty"`
+	VnetCidr                  string            `json:"vnetCidr,omitempty"`
+	AgentVnetSubnetID         string            `json:"agentVnetSubnetID,omitempty"`
+	FirstConsecutiveStaticIP  string            `json:"firstConsecutiveStaticIP,omitempty"`
+	IPAddressCount            int               `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
+	StorageProfile            string            `json:"storageProfile,omitempty" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
+	HTTPSourceAddressPrefix   string            `json:"HTTPSourceAddressPrefix,omitempty"`
+	OAuthEnabled              bool              `json:"oauthEnabled"`
+	PreProvisionExtension     *Extension        `json:"preProvisionExtension"`
+	Extensions                []Extension       `json:"extensions"`
+	Distro                    Distro            `json:"distro,omitempty"`
+	KubernetesConfig          *KubernetesConfig `json:"kubernetesConfig,omitempty"`
+	ImageRef                  *ImageReference   `json:"imageReference,omitempty"`
+	CustomFiles               *[]CustomFile     `json:"customFiles,omitempty"`
+	AvailabilityProfile       string            `json:"availabilityProfile"`
+	AgentSubnet               string            `json:"agentSubnet,omitempty"`
+	AvailabilityZones         []string          `json:"availabilityZones,omitempty"`
+	SinglePlacementGroup      *bool             `json:"singlePlacementGroup,omitempty"`
+	PlatformFaultDomainCount  *int              `json:"platformFaultDomainCount,omitempty"`
+	PlatformUpdateDomainCount *int              `json:"platformUpdateDomainCount,omitempty"`
+
+	// StorageAccountID is the resource ID of an existing storage account holding the VHDs of the unmanaged disks,
+	// which is then used instead of creating storage accounts
+	StorageAccountID string `json:"storageAccountId,omitempty"`
+
+	// ProximityPlacementGroupID is the resource ID of the proximity placement group co-locating the VMs of the
+	// profile, e.g. with the VMs of the other profiles in the same group to lower the latency between them
+	ProximityPlacementGroupID string `json:"proximityPlacementGroupID,omitempty"`
+
+	// LoadBalancerIdleTimeoutInMinutes, LoadBalancerProbeIntervalInSeconds and LoadBalancerProbeThreshold
+	// configure the load balancing rules and health probes of the API server load balancers
+	LoadBalancerIdleTimeoutInMinutes   int `json:"loadBalancerIdleTimeoutInMinutes,omitempty"`
+	LoadBalancerProbeIntervalInSeconds int `json:"loadBalancerProbeIntervalInSeconds,omitempty"`
+	LoadBalancerProbeThreshold         int `json:"loadBalancerProbeThreshold,omitempty"`
+
+	// StaticPods are the manifests of the additional static pods of the masters, e.g. of a custom scheduler,
+	// which the kubelet of each master runs alongside the control plane components
+	StaticPods []StaticPod `json:"staticPods,omitempty"`
 
 	// subnet is internal
 	subnet string
@@ -407,10 +563,18 @@ type MasterProfile struct {
 	FQDN string `json:"fqdn,omitempty"`
 }
 
+// StaticPod is the manifest of a static pod of the masters, written to /etc/kubernetes/manifests/<name>.yaml
+type StaticPod struct {
+	Name     string `json:"name,omitempty"`
+	Manifest string `json:"manifest,omitempty"`
+}
+
 // ImageReference represents a reference to an Image resource in Azure.
 type ImageReference struct {
 	Name          string `json:"name,omitempty"`
 	ResourceGroup string `json:"resourceGroup,omitempty"`
+	// ID is the resource ID of a shared image gallery image version, instead of the name and resource group of an image
+	ID string `json:"id,omitempty"`
 }
 
 // ExtensionProfile represents an extension definition
@@ -435,7 +599,7 @@ type Extension struct {
 // AgentPoolProfile represents an agent pool definition
 type AgentPoolProfile struct {
 	Name                                string               `json:"name" validate:"required"`
-	Count                               int                  `json:"count" validate:"required,min=1,max=100"`
+	Count                               int                  `json:"count" validate:"required,min=1"`
 	VMSize                              string               `json:"vmSize" validate:"required"`
 	OSDiskSizeGB                        int                  `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
 	DNSPrefix                           string               `json:"dnsPrefix,omitempty"`
@@ -458,12 +622,46 @@ type AgentPoolProfile struct {
 	// subnet is internal
 	subnet string
 
-	FQDN                  string            `json:"fqdn"`
-	CustomNodeLabels      map[string]string `json:"customNodeLabels,omitempty"`
-	PreProvisionExtension *Extension        `json:"preProvisionExtension"`
-	Extensions            []Extension       `json:"extensions"`
-	SinglePlacementGroup  *bool             `json:"singlePlacementGroup,omitempty"`
-	AvailabilityZones     []string          `json:"availabilityZones,omitempty"`
+	FQDN                      string            `json:"fqdn"`
+	CustomNodeLabels          map[string]string `json:"customNodeLabels,omitempty"`
+	PreProvisionExtension     *Extension        `json:"preProvisionExtension"`
+	Extensions                []Extension       `json:"extensions"`
+	SinglePlacementGroup      *bool             `json:"singlePlacementGroup,omitempty"`
+	AvailabilityZones         []string          `json:"availabilityZones,omitempty"`
+	PlatformFaultDomainCount  *int              `json:"platformFaultDomainCount,omitempty"`
+	PlatformUpdateDomainCount *int              `json:"platformUpdateDomainCount,omitempty"`
+	DisableSSH                bool              `json:"disableSSH,omitempty"`
+	Sysctls                   map[string]string `json:"sysctls,omitempty"`
+	KernelBootParameters      []string          `json:"kernelBootParameters,omitempty"`
+
+	// StorageAccountID is the resource ID of an existing storage account holding the VHDs of the unmanaged disks,
+	// which is then used instead of creating storage accounts
+	StorageAccountID string `json:"storageAccountId,omitempty"`
+
+	// ProximityPlacementGroupID is the resource ID of the proximity placement group co-locating the VMs of the
+	// profile, e.g. with the VMs of the other profiles in the same group to lower the latency between them
+	ProximityPlacementGroupID string `json:"proximityPlacementGroupID,omitempty"`
+
+	// OverProvision and UpgradePolicyMode configure the scale set of a VirtualMachineScaleSets agent pool
+	OverProvision     *bool  `json:"overProvision,omitempty"`
+	UpgradePolicyMode string `json:"upgradePolicyMode,omitempty" validate:"eq=Manual|eq=Automatic|eq=Rolling|len=0"`
+
+	// CustomNodeTaints are the taints, formatted key=value:Effect, registered on the nodes of the agent pool,
+	// which an upgrade also applies to the nodes it replaces
+	CustomNodeTaints []string `json:"customNodeTaints,omitempty"`
+
+	// MinCount and MaxCount bound the node count of the agent pool, which the scale operation and the cluster
+	// autoscaler keep within them
+	MinCount *int `json:"minCount,omitempty"`
+	MaxCount *int `json:"maxCount,omitempty"`
+
+	// DiskEncryptionSetID is the resource ID of the disk encryption set, holding a customer-managed key, which
+	// encrypts the managed OS and data disks of the agent pool
+	DiskEncryptionSetID string `json:"diskEncryptionSetID,omitempty"`
+
+	// ContainerRuntimeDiskSizeGB is the size of a managed data disk attached to the nodes of the agent pool, on
+	// which the container runtime keeps its images and containers instead of the OS disk
+	ContainerRuntimeDiskSizeGB int `json:"containerRuntimeDiskSizeGB,omitempty" validate:"min=0,max=1023"`
 }
 
 // AgentPoolProfileRole represents an agent role
@@ -5,17 +5,24 @@ package vlabs
 
 import (
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math"
 	"net"
 	"net/url"
+	"path"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Azure/acs-engine/pkg/api/common"
 	"github.com/Azure/acs-engine/pkg/helpers"
 	"github.com/blang/semver"
+	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
@@ -27,6 +34,47 @@ var (
 	keyvaultIDRegex *regexp.Regexp
 	labelValueRegex *regexp.Regexp
 	labelKeyRegex   *regexp.Regexp
+	// evictionQuantityRegex matches the absolute quantities accepted by the kubelet --eviction-* flags
+	evictionQuantityRegex *regexp.Regexp
+	bootstrapTokenRegex   *regexp.Regexp
+	imageReferenceRegex   *regexp.Regexp
+	imageTagRegex         *regexp.Regexp
+	// containerLogSizeRegex matches the log sizes understood by both docker and the kubelet
+	containerLogSizeRegex *regexp.Regexp
+	// sysctlKeyRegex matches the sysctl keys of the known top-level namespaces
+	sysctlKeyRegex   *regexp.Regexp
+	sysctlValueRegex *regexp.Regexp
+	// clusterDomainRegex matches a lowercase DNS subdomain, without the trailing dot
+	clusterDomainRegex *regexp.Regexp
+	// registryServerRegex matches a registry host, with an optional port but without scheme or path
+	registryServerRegex *regexp.Regexp
+	// logAnalyticsWorkspaceIDRegex matches the resource ID of a Log Analytics workspace
+	logAnalyticsWorkspaceIDRegex *regexp.Regexp
+	// resolvConfRegex matches an absolute path which can be passed to the kubelet unquoted
+	resolvConfRegex *regexp.Regexp
+	// galleryImageVersionIDRegex matches the resource ID of a shared image gallery image version
+	galleryImageVersionIDRegex *regexp.Regexp
+	// storageAccountIDRegex matches the resource ID of a storage account
+	storageAccountIDRegex *regexp.Regexp
+	// diskEncryptionSetIDRegex matches the resource ID of a disk encryption set
+	diskEncryptionSetIDRegex *regexp.Regexp
+	// proximityPlacementGroupIDRegex matches the resource ID of a proximity placement group
+	proximityPlacementGroupIDRegex *regexp.Regexp
+	// natGatewayIDRegex matches the resource ID of a NAT gateway
+	natGatewayIDRegex *regexp.Regexp
+	// watchCacheResourceRegex matches the lowercase resource[.group] of the watch cache sizes of the apiserver
+	watchCacheResourceRegex *regexp.Regexp
+	// storageAccountNameRegex, resourceGroupNameRegex and blobContainerNameRegex match the names of
+	// a storage account, a resource group and a blob container
+	storageAccountNameRegex *regexp.Regexp
+	resourceGroupNameRegex  *regexp.Regexp
+	blobContainerNameRegex  *regexp.Regexp
+	// kernelBootParameterRegex matches a name or name=value kernel boot parameter without quotes or spaces
+	kernelBootParameterRegex *regexp.Regexp
+	// kubeConfigNameRegex matches a cluster, context or user name of the generated kubeconfig
+	kubeConfigNameRegex *regexp.Regexp
+	// etcdDefragScheduleRegex matches the five fields of a cron schedule, or one of the cron macros
+	etcdDefragScheduleRegex *regexp.Regexp
 	// Any version has to be mirrored in https://acs-mirror.azureedge.net/github-coreos/etcd-v[Version]-linux-amd64.tar.gz
 	etcdValidVersions = [...]string{"2.2.5", "2.3.0", "2.3.1", "2.3.2", "2.3.3", "2.3.4", "2.3.5", "2.3.6", "2.3.7", "2.3.8",
 		"3.0.0", "3.0.1", "3.0.2", "3.0.3", "3.0.4", "3.0.5", "3.0.6", "3.0.7", "3.0.8", "3.0.9", "3.0.10", "3.0.11", "3.0.12", "3.0.13", "3.0.14", "3.0.15", "3.0.16", "3.0.17",
@@ -58,6 +106,10 @@ var (
 			networkPlugin: "cilium",
 			networkPolicy: "",
 		},
+		{
+			networkPlugin: "cni",
+			networkPolicy: "",
+		},
 		{
 			networkPlugin: "cilium",
 			networkPolicy: "cilium",
@@ -90,9 +142,36 @@ var (
 )
 
 const (
-	labelKeyPrefixMaxLength = 253
-	labelValueFormat        = "^([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
-	labelKeyFormat          = "^(([a-zA-Z0-9-]+[.])*[a-zA-Z0-9-]+[/])?([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
+	labelKeyPrefixMaxLength       = 253
+	labelValueFormat              = "^([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
+	labelKeyFormat                = "^(([a-zA-Z0-9-]+[.])*[a-zA-Z0-9-]+[/])?([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
+	evictionQuantityFormat        = "^[0-9]+([.][0-9]+)?([KMGTPE]i|[kMGTPE])?$"
+	bootstrapTokenFormat          = "^[a-z0-9]{6}[.][a-z0-9]{16}$"
+	containerLogSizeFormat        = "^[1-9][0-9]*(Ki|Mi|Gi)$"
+	sysctlKeyFormat               = `^(abi|debug|dev|fs|kernel|net|user|vm)([.][a-z0-9]([-_a-z0-9]*[a-z0-9])?)+$`
+	sysctlValueFormat             = `^[-A-Za-z0-9_.,:/% ]+$`
+	clusterDomainFormat           = `^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?([.][a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?)*$`
+	clusterDomainMaxLength        = 253
+	registryServerFormat          = `^([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(\.([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(:[0-9]+)?$`
+	resolvConfFormat              = `^(/[-A-Za-z0-9_.]+)+$`
+	galleryImageVersionIDFormat   = `(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Compute/galleries/[^/]+/images/[^/]+/versions/[^/]+$`
+	storageAccountIDFormat        = `(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Storage/storageAccounts/[a-z0-9]{3,24}$`
+	diskEncryptionSetIDFormat     = `(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Compute/diskEncryptionSets/[^/]+$`
+	watchCacheResourceFormat      = `^[a-z0-9]+([.][a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	storageAccountNameFormat      = `^[a-z0-9]{3,24}$`
+	resourceGroupNameFormat       = `^[-\w.()]{0,89}[-\w()]$`
+	blobContainerNameFormat       = `^[a-z0-9](-?[a-z0-9]){2,62}$`
+	kernelBootParameterFormat     = `^[A-Za-z0-9_][-A-Za-z0-9_.]*(=[-A-Za-z0-9_.,:/+@]+)?$`
+	kubeConfigNameFormat          = `^[A-Za-z0-9][-A-Za-z0-9_.@:/]{0,252}$`
+	etcdDefragScheduleFormat      = `^(@(hourly|daily|weekly|monthly)|[0-9*/,-]+( [0-9*/,-]+){4})$`
+	logAnalyticsWorkspaceIDFormat = `(?i)^/subscriptions/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}/resourceGroups/[-\w.()]{1,90}/providers/Microsoft\.OperationalInsights/workspaces/[a-z0-9][-a-z0-9]{2,61}[a-z0-9]$`
+	// imageTagFormat matches the tag of a container image reference
+	imageTagFormat = `^[\w][\w.-]{0,127}$`
+	// imageReferenceFormat matches a container image reference: [registry[:port]/]repository[:tag][@digest]
+	imageReferenceFormat = `^(([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(\.([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(:[0-9]+)?/)?` +
+		`[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*(:[\w][\w.-]{0,127})?(@sha256:[a-f0-9]{64})?$`
+	proximityPlacementGroupIDFormat = `(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Compute/proximityPlacementGroups/[^/]+$`
+	natGatewayIDFormat              = `(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Network/natGateways/[^/]+$`
 )
 
 type k8sNetworkConfig struct {
@@ -105,6 +184,29 @@ func init() {
 	keyvaultIDRegex = regexp.MustCompile(`^/subscriptions/\S+/resourceGroups/\S+/providers/Microsoft.KeyVault/vaults/[^/\s]+$`)
 	labelValueRegex = regexp.MustCompile(labelValueFormat)
 	labelKeyRegex = regexp.MustCompile(labelKeyFormat)
+	evictionQuantityRegex = regexp.MustCompile(evictionQuantityFormat)
+	bootstrapTokenRegex = regexp.MustCompile(bootstrapTokenFormat)
+	imageReferenceRegex = regexp.MustCompile(imageReferenceFormat)
+	imageTagRegex = regexp.MustCompile(imageTagFormat)
+	containerLogSizeRegex = regexp.MustCompile(containerLogSizeFormat)
+	sysctlKeyRegex = regexp.MustCompile(sysctlKeyFormat)
+	sysctlValueRegex = regexp.MustCompile(sysctlValueFormat)
+	clusterDomainRegex = regexp.MustCompile(clusterDomainFormat)
+	registryServerRegex = regexp.MustCompile(registryServerFormat)
+	logAnalyticsWorkspaceIDRegex = regexp.MustCompile(logAnalyticsWorkspaceIDFormat)
+	resolvConfRegex = regexp.MustCompile(resolvConfFormat)
+	galleryImageVersionIDRegex = regexp.MustCompile(galleryImageVersionIDFormat)
+	storageAccountIDRegex = regexp.MustCompile(storageAccountIDFormat)
+	diskEncryptionSetIDRegex = regexp.MustCompile(diskEncryptionSetIDFormat)
+	proximityPlacementGroupIDRegex = regexp.MustCompile(proximityPlacementGroupIDFormat)
+	natGatewayIDRegex = regexp.MustCompile(natGatewayIDFormat)
+	watchCacheResourceRegex = regexp.MustCompile(watchCacheResourceFormat)
+	storageAccountNameRegex = regexp.MustCompile(storageAccountNameFormat)
+	resourceGroupNameRegex = regexp.MustCompile(resourceGroupNameFormat)
+	blobContainerNameRegex = regexp.MustCompile(blobContainerNameFormat)
+	kernelBootParameterRegex = regexp.MustCompile(kernelBootParameterFormat)
+	kubeConfigNameRegex = regexp.MustCompile(kubeConfigNameFormat)
+	etcdDefragScheduleRegex = regexp.MustCompile(etcdDefragScheduleFormat)
 }
 
 // Validate implements APIObject
@@ -136,9 +238,27 @@ func (a *Properties) Validate(isUpdate bool) error {
 	if e := a.validateVNET(); e != nil {
 		return e
 	}
+	if e := a.validateDisableSSH(); e != nil {
+		return e
+	}
+	if e := a.validateServiceInternalLBSubnet(); e != nil {
+		return e
+	}
+	if e := a.validateNATGateway(); e != nil {
+		return e
+	}
+	if e := a.validateAPIServerEndpoint(); e != nil {
+		return e
+	}
+	if e := a.validateAgentOutbound(); e != nil {
+		return e
+	}
 	if e := a.validateServicePrincipalProfile(); e != nil {
 		return e
 	}
+	if e := a.validateCertificateProfile(); e != nil {
+		return e
+	}
 
 	if e := a.validateManagedIdentity(); e != nil {
 		return e
@@ -151,6 +271,10 @@ func (a *Properties) Validate(isUpdate bool) error {
 	if e := a.validateAzProfile(); e != nil {
 		return e
 	}
+
+	if e := a.validateExtraTemplateEntries(); e != nil {
+		return e
+	}
 	return nil
 }
 
@@ -214,6 +338,30 @@ func (a *Properties) validateOrchestratorProfile(isUpdate bool) error {
 				}
 			}
 
+			if e := a.validateSwap(version); e != nil {
+				return e
+			}
+
+			if e := a.validateReadOnlyRootFilesystem(); e != nil {
+				return e
+			}
+
+			if e := a.validateResolvConf(); e != nil {
+				return e
+			}
+
+			if e := a.validateCgroupV2(version); e != nil {
+				return e
+			}
+
+			if e := a.validateProcessLimits(version); e != nil {
+				return e
+			}
+
+			if e := a.validatePrePulledImages(); e != nil {
+				return e
+			}
+
 			if o.KubernetesConfig != nil {
 				err := o.KubernetesConfig.Validate(version, a.HasWindows())
 				if err != nil {
@@ -275,6 +423,28 @@ func (a *Properties) validateOrchestratorProfile(isUpdate bool) error {
 					}
 				}
 
+				if helpers.IsTrueBoolPointer(o.KubernetesConfig.EnablePodPriority) {
+					minVersion, err := semver.Make("1.11.0")
+					if err != nil {
+						return errors.Errorf("could not validate version")
+					}
+					if sv.LT(minVersion) {
+						return errors.Errorf("enablePodPriority is only supported in acs-engine for Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
+							minVersion.String(), version)
+					}
+				}
+
+				if helpers.IsTrueBoolPointer(o.KubernetesConfig.EnablePrometheusMetrics) {
+					minVersion, err := semver.Make("1.12.0")
+					if err != nil {
+						return errors.Errorf("could not validate version")
+					}
+					if sv.LT(minVersion) {
+						return errors.Errorf("enablePrometheusMetrics is only supported in acs-engine for Kubernetes version %s or greater; unable to validate for Kubernetes version %s",
+							minVersion.String(), version)
+					}
+				}
+
 				if o.KubernetesConfig.LoadBalancerSku == "Standard" {
 					minVersion, err := semver.Make("1.11.0")
 					if err != nil {
@@ -353,9 +523,75 @@ func (a *Properties) validateOrchestratorProfile(isUpdate bool) error {
 		return e
 	}
 
+	if e := a.validateCgroupDriver(); e != nil {
+		return e
+	}
+
+	return nil
+}
+
+// validateCertificateProfile ensures that a provided certificate authority, e.g. an intermediate CA of an
+// enterprise PKI, can sign the certificates generated for the cluster
+func (a *Properties) validateCertificateProfile() error {
+	c := a.CertificateProfile
+	if c == nil {
+		return nil
+	}
+	if e := c.validateFrontProxyCertificates(); e != nil {
+		return e
+	}
+	if c.CaCertificate == "" && c.CaPrivateKey == "" {
+		return nil
+	}
+	if c.CaCertificate == "" || c.CaPrivateKey == "" {
+		return errors.New("CertificateProfile.CaCertificate and CertificateProfile.CaPrivateKey must be provided together")
+	}
+	// the certificate authority doesn't sign anything when all the certificates of the cluster are provided
+	if c.hasClusterCertificates() {
+		return nil
+	}
+	if e := helpers.ValidateCertificateAuthority(c.CaCertificate, c.CaPrivateKey); e != nil {
+		return errors.Wrap(e, "CertificateProfile has an invalid certificate authority")
+	}
+	return nil
+}
+
+// validateFrontProxyCertificates ensures that a provided front proxy certificate authority can sign the client
+// certificate of the aggregation layer, unless the client certificate is provided too
+func (c *CertificateProfile) validateFrontProxyCertificates() error {
+	if (c.FrontProxyClientCertificate == "") != (c.FrontProxyClientPrivateKey == "") {
+		return errors.New("CertificateProfile.FrontProxyClientCertificate and CertificateProfile.FrontProxyClientPrivateKey must be provided together")
+	}
+	if c.FrontProxyCACertificate == "" {
+		if c.FrontProxyCAPrivateKey != "" || c.FrontProxyClientCertificate != "" {
+			return errors.New("CertificateProfile.FrontProxyCACertificate must be provided along with the front proxy CA private key or client certificate")
+		}
+		return nil
+	}
+	if c.FrontProxyClientCertificate != "" {
+		return nil
+	}
+	if c.FrontProxyCAPrivateKey == "" {
+		return errors.New("CertificateProfile.FrontProxyCAPrivateKey must be provided to issue the front proxy client certificate")
+	}
+	if e := helpers.ValidateCertificateAuthority(c.FrontProxyCACertificate, c.FrontProxyCAPrivateKey); e != nil {
+		return errors.Wrap(e, "CertificateProfile has an invalid front proxy certificate authority")
+	}
 	return nil
 }
 
+// hasClusterCertificates returns true if the certificates and keys of the API server, the clients and etcd are all provided
+func (c *CertificateProfile) hasClusterCertificates() bool {
+	for _, pem := range []string{c.APIServerCertificate, c.APIServerPrivateKey, c.ClientCertificate, c.ClientPrivateKey,
+		c.KubeConfigCertificate, c.KubeConfigPrivateKey, c.EtcdServerCertificate, c.EtcdServerPrivateKey,
+		c.EtcdClientCertificate, c.EtcdClientPrivateKey} {
+		if pem == "" {
+			return false
+		}
+	}
+	return len(c.EtcdPeerCertificates) > 0 && len(c.EtcdPeerCertificates) == len(c.EtcdPeerPrivateKeys)
+}
+
 func (a *Properties) validateMasterProfile() error {
 	m := a.MasterProfile
 	if a.OrchestratorProfile.OrchestratorType == OpenShift {
@@ -376,7 +612,17 @@ func (a *Properties) validateMasterProfile() error {
 		}
 	}
 
+	if e := validateStorageAccountID("masterProfile", m.StorageAccountID, m.StorageProfile, a.OrchestratorProfile.OrchestratorType); e != nil {
+		return e
+	}
+	if e := validateProximityPlacementGroupID("masterProfile", m.ProximityPlacementGroupID, m.AvailabilityZones, a.OrchestratorProfile.OrchestratorType); e != nil {
+		return e
+	}
+
 	if m.ImageRef != nil {
+		if m.ImageRef.ID != "" {
+			return errors.New("masterProfile.imageReference.id is not supported, shared image gallery images are only supported in agent pools")
+		}
 		if err := m.ImageRef.validateImageNameAndGroup(); err != nil {
 			return err
 		}
@@ -399,6 +645,15 @@ func (a *Properties) validateMasterProfile() error {
 	if m.SinglePlacementGroup != nil && m.AvailabilityProfile == AvailabilitySet {
 		return errors.New("singlePlacementGroup is only supported with VirtualMachineScaleSets")
 	}
+	if e := validatePlatformDomainCounts("masterProfile", m.AvailabilityProfile, m.PlatformFaultDomainCount, m.PlatformUpdateDomainCount); e != nil {
+		return e
+	}
+	if e := m.validateLoadBalancer(); e != nil {
+		return e
+	}
+	if e := m.validateStaticPods(a.OrchestratorProfile.OrchestratorType); e != nil {
+		return e
+	}
 	return common.ValidateDNSPrefix(m.DNSPrefix)
 }
 
@@ -421,6 +676,10 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 			return e
 		}
 
+		if e := agentPoolProfile.validateCount(a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
+		}
+
 		if helpers.IsTrueBoolPointer(agentPoolProfile.AcceleratedNetworkingEnabled) || helpers.IsTrueBoolPointer(agentPoolProfile.AcceleratedNetworkingEnabledWindows) {
 			if e := validatePoolAcceleratedNetworking(agentPoolProfile.VMSize); e != nil {
 				return e
@@ -432,13 +691,33 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 		}
 
 		if agentPoolProfile.ImageRef != nil {
-			return agentPoolProfile.ImageRef.validateImageNameAndGroup()
+			if e := agentPoolProfile.ImageRef.validateImageReference(); e != nil {
+				return e
+			}
+		}
+
+		if agentPoolProfile.StorageAccountID != "" && agentPoolProfile.OSType == Windows {
+			return errors.Errorf("agent pool '%s' cannot use an existing storage account, storageAccountId is not supported by Windows agent pools", agentPoolProfile.Name)
+		}
+		if e := validateStorageAccountID(fmt.Sprintf("agent pool '%s'", agentPoolProfile.Name), agentPoolProfile.StorageAccountID, agentPoolProfile.StorageProfile, a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
+		}
+		if e := validateProximityPlacementGroupID(fmt.Sprintf("agent pool '%s'", agentPoolProfile.Name), agentPoolProfile.ProximityPlacementGroupID, agentPoolProfile.AvailabilityZones, a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateDiskEncryptionSetID(a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
 		}
 
 		if e := agentPoolProfile.validateAvailabilityProfile(a.OrchestratorProfile.OrchestratorType); e != nil {
 			return e
 		}
 
+		if e := validatePlatformDomainCounts(fmt.Sprintf("agent pool '%s'", agentPoolProfile.Name), agentPoolProfile.AvailabilityProfile, agentPoolProfile.PlatformFaultDomainCount, agentPoolProfile.PlatformUpdateDomainCount); e != nil {
+			return e
+		}
+
 		if e := agentPoolProfile.validateRoles(a.OrchestratorProfile.OrchestratorType); e != nil {
 			return e
 		}
@@ -451,6 +730,26 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 			return e
 		}
 
+		if e := agentPoolProfile.validateCustomNodeTaints(a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateKubeletConfig(a.OrchestratorProfile); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateSysctls(a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateKernelBootParameters(a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateContainerRuntimeDisk(a.OrchestratorProfile.OrchestratorType); e != nil {
+			return e
+		}
+
 		if agentPoolProfile.AvailabilityProfile == VirtualMachineScaleSets {
 			e := validateVMSS(a.OrchestratorProfile, isUpdate, agentPoolProfile.StorageProfile)
 			if e != nil {
@@ -479,6 +778,10 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 		}
 	}
 
+	if e := a.validateSystemAgentPools(); e != nil {
+		return e
+	}
+
 	if a.OrchestratorProfile.OrchestratorType == OpenShift {
 		if !reflect.DeepEqual(profileNames, map[string]bool{"compute": true, "infra": true}) {
 			return errors.New("OpenShift requires exactly two agent pool profiles: compute and infra")
@@ -488,706 +791,2537 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 	return nil
 }
 
-func (a *Properties) validateZones() error {
-	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
-		// all zones or no zones should be defined for the cluster
-		if a.HasAvailabilityZones() {
-			if a.MastersAndAgentsUseAvailabilityZones() {
-				// master profile
-				if a.MasterProfile.Count < len(a.MasterProfile.AvailabilityZones)*2 {
-					return errors.New("the node count and the number of availability zones provided can result in zone imbalance. To achieve zone balance, each zone should have at least 2 nodes or more")
-				}
-				// agent pool profiles
-				for _, agentPoolProfile := range a.AgentPoolProfiles {
-					if agentPoolProfile.AvailabilityProfile == AvailabilitySet {
-						return errors.New("Availability Zones are not supported with an AvailabilitySet. Please either remove availabilityProfile or set availabilityProfile to VirtualMachineScaleSets")
-					}
-					if agentPoolProfile.Count < len(agentPoolProfile.AvailabilityZones)*2 {
-						return errors.New("the node count and the number of availability zones provided can result in zone imbalance. To achieve zone balance, each zone should have at least 2 nodes or more")
-					}
-				}
-				if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.LoadBalancerSku != "" && a.OrchestratorProfile.KubernetesConfig.LoadBalancerSku != "Standard" {
-					return errors.New("Availability Zones requires Standard LoadBalancer. Please set KubernetesConfig \"LoadBalancerSku\" to \"Standard\"")
-				}
-			} else {
-				return errors.New("Availability Zones need to be defined for master profile and all agent pool profiles. Please set \"availabilityZones\" for all profiles")
+// validateSystemAgentPools ensures that a cluster separating system and user agent pools
+// has a Linux system pool to run the system addons on, and that daemonset agent pools run
+// Linux and leave another pool to run the Deployments on
+func (a *Properties) validateSystemAgentPools() error {
+	var hasRoles, hasSystemPool, hasWorkloadPool bool
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		switch agentPoolProfile.Role {
+		case AgentPoolProfileRoleSystem:
+			if agentPoolProfile.OSType == Windows {
+				return errors.Errorf("agent pool '%s' has role '%s' but system agent pools must run Linux", agentPoolProfile.Name, agentPoolProfile.Role)
 			}
+			hasRoles, hasSystemPool = true, true
+		case AgentPoolProfileRoleUser:
+			hasRoles = true
+		case AgentPoolProfileRoleDaemonSet:
+			if agentPoolProfile.OSType == Windows {
+				return errors.Errorf("agent pool '%s' has role '%s' but daemonset agent pools must run Linux", agentPoolProfile.Name, agentPoolProfile.Role)
+			}
+			continue
 		}
+		hasWorkloadPool = true
 	}
-	return nil
-}
-
-func (a *Properties) validateLinuxProfile() error {
-	if e := validate.Var(a.LinuxProfile.SSH.PublicKeys[0].KeyData, "required"); e != nil {
-		return errors.New("KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string")
+	if hasRoles && !hasSystemPool {
+		return errors.Errorf("at least one agent pool must have role '%s' when agent pool roles are used", AgentPoolProfileRoleSystem)
 	}
-	return validateKeyVaultSecrets(a.LinuxProfile.Secrets, false)
+	if len(a.AgentPoolProfiles) > 0 && !hasWorkloadPool {
+		return errors.Errorf("at least one agent pool must not have role '%s'", AgentPoolProfileRoleDaemonSet)
+	}
+	return nil
 }
 
-func (a *Properties) validateAddons() error {
-	if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.Addons != nil {
-		var isAvailabilitySets bool
-		var IsNSeriesSKU bool
-
-		for _, agentPool := range a.AgentPoolProfiles {
-			if agentPool.IsAvailabilitySets() {
-				isAvailabilitySets = true
-			}
-
-			if agentPool.IsNSeriesSKU() {
-				IsNSeriesSKU = true
+// validateSwap ensures that swap is only enabled on Linux agent pools running a kubelet
+// that can be started with swap enabled
+func (a *Properties) validateSwap(k8sVersion string) error {
+	var clusterSwapEnabled *bool
+	if k := a.OrchestratorProfile.KubernetesConfig; k != nil {
+		if k.SwapSizeMB < 0 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.SwapSizeMB '%d' must not be negative", k.SwapSizeMB)
+		}
+		clusterSwapEnabled = k.SwapEnabled
+	}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		swapEnabled := clusterSwapEnabled
+		if k := agentPoolProfile.KubernetesConfig; k != nil {
+			if k.SwapSizeMB < 0 {
+				return errors.Errorf("agent pool '%s' has swapSizeMB '%d', it must not be negative", agentPoolProfile.Name, k.SwapSizeMB)
 			}
-		}
-		for _, addon := range a.OrchestratorProfile.KubernetesConfig.Addons {
-
-			if addon.Data != "" {
-				if len(addon.Config) > 0 || len(addon.Containers) > 0 {
-					return errors.New("Config and containers should be empty when addon.Data is specified")
-				}
-				if _, err := base64.StdEncoding.DecodeString(addon.Data); err != nil {
-					return errors.Errorf("Addon %s's data should be base64 encoded", addon.Name)
+			if k.SwapEnabled != nil {
+				if agentPoolProfile.OSType == Windows && *k.SwapEnabled {
+					return errors.Errorf("agent pool '%s' enables swap, which is not supported on Windows", agentPoolProfile.Name)
 				}
+				swapEnabled = k.SwapEnabled
 			}
+		}
+		if agentPoolProfile.OSType == Windows || !helpers.IsTrueBoolPointer(swapEnabled) {
+			continue
+		}
+		if !common.IsKubernetesVersionGe(k8sVersion, "1.8.0") {
+			return errors.Errorf("agent pool '%s' enables swap, which is only available in Kubernetes version 1.8.0 or greater; unable to validate for Kubernetes version %s", agentPoolProfile.Name, k8sVersion)
+		}
+	}
+	return nil
+}
 
-			switch addon.Name {
-			case "cluster-autoscaler":
-				if helpers.IsTrueBoolPointer(addon.Enabled) && isAvailabilitySets {
-					return errors.Errorf("Cluster Autoscaler add-on can only be used with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"%s\"", VirtualMachineScaleSets)
-				}
-			case "nvidia-device-plugin":
-				if helpers.IsTrueBoolPointer(addon.Enabled) {
-					version := common.RationalizeReleaseAndVersion(
-						a.OrchestratorProfile.OrchestratorType,
-						a.OrchestratorProfile.OrchestratorRelease,
-						a.OrchestratorProfile.OrchestratorVersion,
-						false,
-						false)
-					if version == "" {
-						return errors.Errorf("the following user supplied OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", a.OrchestratorProfile.OrchestratorType, a.OrchestratorProfile.OrchestratorRelease, a.OrchestratorProfile.OrchestratorVersion)
-					}
-					sv, err := semver.Make(version)
-					if err != nil {
-						return errors.Errorf("could not validate version %s", version)
-					}
-					minVersion, err := semver.Make("1.10.0")
-					if err != nil {
-						return errors.New("could not validate version")
-					}
-					if IsNSeriesSKU && sv.LT(minVersion) {
-						return errors.New("NVIDIA Device Plugin add-on can only be used Kubernetes 1.10 or above. Please specify \"orchestratorRelease\": \"1.10\"")
-					}
-				}
+// validateReadOnlyRootFilesystem ensures that the root filesystem is only made read-only on Linux agent
+// pools whose container runtime keeps its state in the paths left writable
+func (a *Properties) validateReadOnlyRootFilesystem() error {
+	var clusterReadOnlyRoot *bool
+	var containerRuntime string
+	if k := a.OrchestratorProfile.KubernetesConfig; k != nil {
+		clusterReadOnlyRoot = k.ReadOnlyRootFilesystem
+		containerRuntime = k.ContainerRuntime
+	}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		readOnlyRoot := clusterReadOnlyRoot
+		if k := agentPoolProfile.KubernetesConfig; k != nil && k.ReadOnlyRootFilesystem != nil {
+			if agentPoolProfile.OSType == Windows && *k.ReadOnlyRootFilesystem {
+				return errors.Errorf("agent pool '%s' enables readOnlyRootFilesystem, which is not supported on Windows", agentPoolProfile.Name)
 			}
+			readOnlyRoot = k.ReadOnlyRootFilesystem
+		}
+		if agentPoolProfile.OSType == Windows || !helpers.IsTrueBoolPointer(readOnlyRoot) {
+			continue
+		}
+		if agentPoolProfile.Distro == CoreOS {
+			return errors.Errorf("agent pool '%s' enables readOnlyRootFilesystem, which is not supported with the %s distro", agentPoolProfile.Name, CoreOS)
+		}
+		if containerRuntime != "" && containerRuntime != "docker" && containerRuntime != "containerd" {
+			return errors.Errorf("agent pool '%s' enables readOnlyRootFilesystem, which is only supported with the docker and containerd container runtimes, not %s", agentPoolProfile.Name, containerRuntime)
 		}
 	}
 	return nil
 }
 
-func (a *Properties) validateExtensions() error {
-	for _, agentPool := range a.AgentPoolProfiles {
-		if len(agentPool.Extensions) != 0 && (len(agentPool.AvailabilityProfile) == 0 || agentPool.IsVirtualMachineScaleSets()) {
-			return errors.Errorf("Extensions are currently not supported with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"%s\"", AvailabilitySet)
+// validateResolvConf ensures that the resolv.conf the kubelet hands to the pods is a clean absolute path, and
+// that only the Linux nodes configure their DNS resolver
+func (a *Properties) validateResolvConf() error {
+	if k := a.OrchestratorProfile.KubernetesConfig; k != nil && !isValidResolvConf(k.ResolvConf) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ResolvConf '%s' needs to be an absolute path", k.ResolvConf)
+	}
+	if a.MasterProfile != nil {
+		if k := a.MasterProfile.KubernetesConfig; k != nil && !isValidResolvConf(k.ResolvConf) {
+			return errors.Errorf("MasterProfile.KubernetesConfig.ResolvConf '%s' needs to be an absolute path", k.ResolvConf)
 		}
 	}
-
-	for _, extension := range a.ExtensionProfiles {
-		if extension.ExtensionParametersKeyVaultRef != nil {
-			if e := validate.Var(extension.ExtensionParametersKeyVaultRef.VaultID, "required"); e != nil {
-				return errors.Errorf("the Keyvault ID must be specified for Extension %s", extension.Name)
-			}
-			if e := validate.Var(extension.ExtensionParametersKeyVaultRef.SecretName, "required"); e != nil {
-				return errors.Errorf("the Keyvault Secret must be specified for Extension %s", extension.Name)
-			}
-			if !keyvaultIDRegex.MatchString(extension.ExtensionParametersKeyVaultRef.VaultID) {
-				return errors.Errorf("Extension %s's keyvault secret reference is of incorrect format", extension.Name)
-			}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		k := agentPoolProfile.KubernetesConfig
+		if k == nil {
+			continue
+		}
+		if agentPoolProfile.OSType == Windows && (k.ResolvConf != "" || helpers.IsTrueBoolPointer(k.DisableResolvedStub)) {
+			return errors.Errorf("agent pool '%s' configures the DNS resolver of its nodes, which is not supported on Windows", agentPoolProfile.Name)
+		}
+		if !isValidResolvConf(k.ResolvConf) {
+			return errors.Errorf("agent pool '%s' has resolvConf '%s', it needs to be an absolute path", agentPoolProfile.Name, k.ResolvConf)
 		}
 	}
 	return nil
 }
 
-func (a *Properties) validateVNET() error {
-	isCustomVNET := a.MasterProfile.IsCustomVNET()
-	for _, agentPool := range a.AgentPoolProfiles {
-		if agentPool.IsCustomVNET() != isCustomVNET {
-			return errors.New("Multiple VNET Subnet configurations specified.  The master profile and each agent pool profile must all specify a custom VNET Subnet, or none at all")
+// validateProcessLimits ensures that the PID and open files limits of the nodes are positive values the kernel
+// accepts, and that the kubelet of the nodes can limit the PIDs of their pods
+func (a *Properties) validateProcessLimits(k8sVersion string) error {
+	validateNode := func(node string, k *KubernetesConfig) error {
+		if k == nil {
+			return nil
 		}
-	}
-	if isCustomVNET {
-		if a.MasterProfile.IsVirtualMachineScaleSets() && a.MasterProfile.AgentVnetSubnetID == "" {
-			return errors.New("when master profile is using VirtualMachineScaleSets and is custom vnet, set \"vnetsubnetid\" and \"agentVnetSubnetID\" for master profile")
+		if k.PodMaxPids < 0 {
+			return errors.Errorf("%s has podMaxPids '%d', it must be a positive value", node, k.PodMaxPids)
 		}
-
-		subscription, resourcegroup, vnetname, _, e := common.GetVNETSubnetIDComponents(a.MasterProfile.VnetSubnetID)
-		if e != nil {
-			return e
+		if k.PidMax < 0 || k.PidMax > KubernetesMaxPidMax {
+			return errors.Errorf("%s has pidMax '%d', it must be a positive value no greater than %d", node, k.PidMax, KubernetesMaxPidMax)
 		}
-
-		for _, agentPool := range a.AgentPoolProfiles {
-			agentSubID, agentRG, agentVNET, _, err := common.GetVNETSubnetIDComponents(agentPool.VnetSubnetID)
-			if err != nil {
-				return err
-			}
-			if agentSubID != subscription ||
-				agentRG != resourcegroup ||
-				agentVNET != vnetname {
-				return errors.New("Multiple VNETS specified.  The master profile and each agent pool must reference the same VNET (but it is ok to reference different subnets on that VNET)")
-			}
+		if k.MaxOpenFiles < 0 {
+			return errors.Errorf("%s has maxOpenFiles '%d', it must be a positive value", node, k.MaxOpenFiles)
 		}
-
-		masterFirstIP := net.ParseIP(a.MasterProfile.FirstConsecutiveStaticIP)
-		if masterFirstIP == nil && !a.MasterProfile.IsVirtualMachineScaleSets() {
-			return errors.Errorf("MasterProfile.FirstConsecutiveStaticIP (with VNET Subnet specification) '%s' is an invalid IP address", a.MasterProfile.FirstConsecutiveStaticIP)
+		if k.PodMaxPids > 0 && !common.IsKubernetesVersionGe(k8sVersion, "1.10.0") {
+			return errors.Errorf("%s sets podMaxPids, which is only available in Kubernetes version 1.10.0 or greater; unable to validate for Kubernetes version %s", node, k8sVersion)
 		}
+		return nil
+	}
 
-		if a.MasterProfile.VnetCidr != "" {
-			_, _, err := net.ParseCIDR(a.MasterProfile.VnetCidr)
-			if err != nil {
-				return errors.Errorf("MasterProfile.VnetCidr '%s' contains invalid cidr notation", a.MasterProfile.VnetCidr)
-			}
+	if e := validateNode("OrchestratorProfile.KubernetesConfig", a.OrchestratorProfile.KubernetesConfig); e != nil {
+		return e
+	}
+	if a.MasterProfile != nil {
+		if e := validateNode("the master profile", a.MasterProfile.KubernetesConfig); e != nil {
+			return e
 		}
 	}
-	return nil
-}
-
-func (a *Properties) validateServicePrincipalProfile() error {
-	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
-		useManagedIdentity := a.OrchestratorProfile.KubernetesConfig != nil &&
-			a.OrchestratorProfile.KubernetesConfig.UseManagedIdentity
-
-		if !useManagedIdentity {
-			if a.ServicePrincipalProfile == nil {
-				return errors.Errorf("ServicePrincipalProfile must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
-			}
-			if e := validate.Var(a.ServicePrincipalProfile.ClientID, "required"); e != nil {
-				return errors.Errorf("the service principal client ID must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
-			}
-			if (len(a.ServicePrincipalProfile.Secret) == 0 && a.ServicePrincipalProfile.KeyvaultSecretRef == nil) ||
-				(len(a.ServicePrincipalProfile.Secret) != 0 && a.ServicePrincipalProfile.KeyvaultSecretRef != nil) {
-				return errors.Errorf("either the service principal client secret or keyvault secret reference must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
-			}
-
-			if a.OrchestratorProfile.KubernetesConfig != nil && helpers.IsTrueBoolPointer(a.OrchestratorProfile.KubernetesConfig.EnableEncryptionWithExternalKms) && len(a.ServicePrincipalProfile.ObjectID) == 0 {
-				return errors.Errorf("the service principal object ID must be specified with Orchestrator %s when enableEncryptionWithExternalKms is true", a.OrchestratorProfile.OrchestratorType)
-			}
-
-			if a.ServicePrincipalProfile.KeyvaultSecretRef != nil {
-				if e := validate.Var(a.ServicePrincipalProfile.KeyvaultSecretRef.VaultID, "required"); e != nil {
-					return errors.Errorf("the Keyvault ID must be specified for the Service Principle with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
-				}
-				if e := validate.Var(a.ServicePrincipalProfile.KeyvaultSecretRef.SecretName, "required"); e != nil {
-					return errors.Errorf("the Keyvault Secret must be specified for the Service Principle with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
-				}
-				if !keyvaultIDRegex.MatchString(a.ServicePrincipalProfile.KeyvaultSecretRef.VaultID) {
-					return errors.Errorf("service principal client keyvault secret reference is of incorrect format")
-				}
-			}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		if k := agentPoolProfile.KubernetesConfig; agentPoolProfile.OSType == Windows && k != nil && (k.PidMax != 0 || k.MaxOpenFiles != 0) {
+			return errors.Errorf("agent pool '%s' sets pidMax or maxOpenFiles, which are not supported on Windows", agentPoolProfile.Name)
+		}
+		if e := validateNode(fmt.Sprintf("agent pool '%s'", agentPoolProfile.Name), agentPoolProfile.KubernetesConfig); e != nil {
+			return e
 		}
 	}
 	return nil
 }
 
-func (a *Properties) validateManagedIdentity() error {
-	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
-		useManagedIdentity := a.OrchestratorProfile.KubernetesConfig != nil &&
-			a.OrchestratorProfile.KubernetesConfig.UseManagedIdentity
-
-		if useManagedIdentity {
-			version := common.RationalizeReleaseAndVersion(
-				a.OrchestratorProfile.OrchestratorType,
-				a.OrchestratorProfile.OrchestratorRelease,
-				a.OrchestratorProfile.OrchestratorVersion,
-				false,
-				false)
-			if version == "" {
-				return errors.Errorf("the following user supplied OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", a.OrchestratorProfile.OrchestratorType, a.OrchestratorProfile.OrchestratorRelease, a.OrchestratorProfile.OrchestratorVersion)
-			}
-			sv, err := semver.Make(version)
-			if err != nil {
-				return errors.Errorf("could not validate version %s", version)
-			}
-			minVersion, err := semver.Make("1.12.0")
-			if err != nil {
-				return errors.New("could not validate version")
-			}
-
-			if a.MasterProfile.IsVirtualMachineScaleSets() {
-				if sv.LT(minVersion) {
-					return errors.New("managed identity and VMSS masters can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"")
-				}
-			} else if a.OrchestratorProfile.KubernetesConfig.UserAssignedID != "" && sv.LT(minVersion) {
-				return errors.New("user assigned identity can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"")
+// validatePrePulledImages ensures that the images pulled while provisioning the nodes are valid container image
+// references, and are only pulled on Linux nodes
+func (a *Properties) validatePrePulledImages() error {
+	validateNode := func(node string, k *KubernetesConfig) error {
+		if k == nil {
+			return nil
+		}
+		for _, image := range k.PrePulledImages {
+			if !imageReferenceRegex.MatchString(image) {
+				return errors.Errorf("%s has prePulledImages '%s', which is not a valid container image reference", node, image)
 			}
+		}
+		return nil
+	}
 
+	if e := validateNode("OrchestratorProfile.KubernetesConfig", a.OrchestratorProfile.KubernetesConfig); e != nil {
+		return e
+	}
+	if a.MasterProfile != nil {
+		if e := validateNode("the master profile", a.MasterProfile.KubernetesConfig); e != nil {
+			return e
+		}
+	}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		if k := agentPoolProfile.KubernetesConfig; agentPoolProfile.OSType == Windows && k != nil && len(k.PrePulledImages) > 0 {
+			return errors.Errorf("agent pool '%s' sets prePulledImages, which are not supported on Windows", agentPoolProfile.Name)
+		}
+		if e := validateNode(fmt.Sprintf("agent pool '%s'", agentPoolProfile.Name), agentPoolProfile.KubernetesConfig); e != nil {
+			return e
 		}
 	}
 	return nil
 }
 
-func (a *Properties) validateAADProfile() error {
-	if profile := a.AADProfile; profile != nil {
-		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
-			return errors.Errorf("'aadProfile' is only supported by orchestrator '%v'", Kubernetes)
+// validateCgroupV2 ensures that the nodes only boot with the unified cgroup v2 hierarchy if their kubelet
+// and container runtime support it, which requires the systemd cgroup driver
+func (a *Properties) validateCgroupV2(k8sVersion string) error {
+	var clusterCgroupV2 *bool
+	var containerRuntime, cgroupDriver string
+	if k := a.OrchestratorProfile.KubernetesConfig; k != nil {
+		clusterCgroupV2 = k.CgroupV2Enabled
+		containerRuntime = k.ContainerRuntime
+		cgroupDriver = k.CgroupDriver
+		if cgroupDriver == "" {
+			cgroupDriver = k.KubeletConfig["--cgroup-driver"]
+		}
+	}
+	validateNode := func(node string, k *KubernetesConfig, distro Distro) error {
+		cgroupV2 := clusterCgroupV2
+		if k != nil && k.CgroupV2Enabled != nil {
+			cgroupV2 = k.CgroupV2Enabled
+		}
+		if !helpers.IsTrueBoolPointer(cgroupV2) {
+			return nil
 		}
-		if _, err := uuid.FromString(profile.ClientAppID); err != nil {
-			return errors.Errorf("clientAppID '%v' is invalid", profile.ClientAppID)
+		if distro == CoreOS {
+			return errors.Errorf("%s enables cgroupV2Enabled, which is not supported with the %s distro", node, CoreOS)
 		}
-		if _, err := uuid.FromString(profile.ServerAppID); err != nil {
-			return errors.Errorf("serverAppID '%v' is invalid", profile.ServerAppID)
+		if !common.IsKubernetesVersionGe(k8sVersion, "1.12.0") {
+			return errors.Errorf("%s enables cgroupV2Enabled, which is only available in Kubernetes version 1.12.0 or greater; unable to validate for Kubernetes version %s", node, k8sVersion)
 		}
-		if len(profile.TenantID) > 0 {
-			if _, err := uuid.FromString(profile.TenantID); err != nil {
-				return errors.Errorf("tenantID '%v' is invalid", profile.TenantID)
-			}
+		if containerRuntime != "" && containerRuntime != "docker" {
+			return errors.Errorf("%s enables cgroupV2Enabled, which is only supported with the docker container runtime, not %s", node, containerRuntime)
 		}
-		if len(profile.AdminGroupID) > 0 {
-			if _, err := uuid.FromString(profile.AdminGroupID); err != nil {
-				return errors.Errorf("adminGroupID '%v' is invalid", profile.AdminGroupID)
-			}
+		if cgroupDriver != "" && cgroupDriver != CgroupDriverSystemd {
+			return errors.Errorf("%s enables cgroupV2Enabled, which requires the %q cgroupDriver, not %q", node, CgroupDriverSystemd, cgroupDriver)
 		}
+		return nil
 	}
-	return nil
-}
 
-func (a *Properties) validateAzProfile() error {
-	switch a.OrchestratorProfile.OrchestratorType {
-	case OpenShift:
-		if a.AzProfile == nil || a.AzProfile.Location == "" ||
-			a.AzProfile.ResourceGroup == "" || a.AzProfile.SubscriptionID == "" ||
-			a.AzProfile.TenantID == "" {
-			return errors.Errorf("'azProfile' must be supplied in full for orchestrator '%v'", OpenShift)
+	if a.MasterProfile != nil {
+		if e := validateNode("the master profile", a.MasterProfile.KubernetesConfig, a.MasterProfile.Distro); e != nil {
+			return e
 		}
-	default:
-		if a.AzProfile != nil {
-			return errors.Errorf("'azProfile' is only supported by orchestrator '%v'", OpenShift)
+	}
+	for _, agentPoolProfile := range a.AgentPoolProfiles {
+		if agentPoolProfile.OSType == Windows {
+			if k := agentPoolProfile.KubernetesConfig; k != nil && helpers.IsTrueBoolPointer(k.CgroupV2Enabled) {
+				return errors.Errorf("agent pool '%s' enables cgroupV2Enabled, which is not supported on Windows", agentPoolProfile.Name)
+			}
+			continue
+		}
+		if e := validateNode(fmt.Sprintf("agent pool '%s'", agentPoolProfile.Name), agentPoolProfile.KubernetesConfig, agentPoolProfile.Distro); e != nil {
+			return e
 		}
 	}
 	return nil
 }
 
-// Validate OpenShiftConfig ensures that the OpenShiftConfig is valid.
-func (o *OpenShiftConfig) Validate() error {
-	if o.ClusterUsername == "" || o.ClusterPassword == "" {
-		return errors.Errorf("ClusterUsername and ClusterPassword must both be specified")
-	}
-	return nil
+func isValidResolvConf(resolvConf string) bool {
+	return resolvConf == "" || (resolvConfRegex.MatchString(resolvConf) && path.Clean(resolvConf) == resolvConf)
 }
 
-func (a *AgentPoolProfile) validateAvailabilityProfile(orchestratorType string) error {
-	switch a.AvailabilityProfile {
-	case AvailabilitySet:
-	case VirtualMachineScaleSets:
-	case "":
-	default:
-		{
-			return errors.Errorf("unknown availability profile type '%s' for agent pool '%s'.  Specify either %s, or %s", a.AvailabilityProfile, a.Name, AvailabilitySet, VirtualMachineScaleSets)
+func (a *AgentPoolProfile) validateKubeletConfig(o *OrchestratorProfile) error {
+	if a.KubernetesConfig == nil || a.KubernetesConfig.KubeletConfig == nil {
+		return nil
+	}
+
+	// pool values are layered over the cluster-wide kubelet config
+	kubeletConfig := make(map[string]string)
+	if o.KubernetesConfig != nil {
+		for key, val := range o.KubernetesConfig.KubeletConfig {
+			kubeletConfig[key] = val
 		}
 	}
+	for key, val := range a.KubernetesConfig.KubeletConfig {
+		kubeletConfig[key] = val
+	}
 
-	if orchestratorType == OpenShift && a.AvailabilityProfile != AvailabilitySet {
-		return errors.Errorf("Only AvailabilityProfile: AvailabilitySet is supported for Orchestrator 'OpenShift'")
+	if e := validateKubeletThresholds(kubeletConfig); e != nil {
+		return errors.Errorf("agent pool '%s' has an invalid kubeletConfig: %s", a.Name, e)
 	}
 	return nil
 }
 
-func (a *AgentPoolProfile) validateRoles(orchestratorType string) error {
-	validRoles := []AgentPoolProfileRole{AgentPoolProfileRoleEmpty}
-	if orchestratorType == OpenShift {
-		validRoles = append(validRoles, AgentPoolProfileRoleInfra)
+func (a *AgentPoolProfile) validateSysctls(orchestratorType string) error {
+	if len(a.Sysctls) == 0 {
+		return nil
 	}
-	var found bool
-	for _, validRole := range validRoles {
-		if a.Role == validRole {
-			found = true
-			break
-		}
+	if orchestratorType != Kubernetes {
+		return errors.Errorf("agent pool '%s' has sysctls, which are only supported for Kubernetes", a.Name)
 	}
-	if !found {
-		return errors.Errorf("Role %q is not supported for Orchestrator %s", a.Role, orchestratorType)
+	if a.OSType == Windows {
+		return errors.Errorf("agent pool '%s' has sysctls, which are not supported on Windows", a.Name)
+	}
+	keys := make([]string, 0, len(a.Sysctls))
+	for key := range a.Sysctls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !sysctlKeyRegex.MatchString(key) {
+			return errors.Errorf("agent pool '%s' has sysctl '%s', which is not a known sysctl key format, e.g. net.core.somaxconn", a.Name, key)
+		}
+		if val := a.Sysctls[key]; strings.TrimSpace(val) == "" || !sysctlValueRegex.MatchString(val) {
+			return errors.Errorf("agent pool '%s' has sysctl '%s' with value '%s', it must be made of letters, digits, spaces and the characters . , : / _ - %%", a.Name, key, val)
+		}
 	}
 	return nil
 }
 
-func (a *AgentPoolProfile) validateStorageProfile(orchestratorType string) error {
-	/* this switch statement is left to protect newly added orchestrators until they support Managed Disks*/
-	if a.StorageProfile == ManagedDisks {
-		switch orchestratorType {
-		case DCOS:
-		case Swarm:
-		case Kubernetes:
-		case OpenShift:
-		case SwarmMode:
-		default:
-			return errors.Errorf("HA volumes are currently unsupported for Orchestrator %s", orchestratorType)
+// validateKernelBootParameters ensures the kernel boot parameters of a Linux agent pool are name or
+// name=value parameters, which are appended to the GRUB kernel command line unquoted
+func (a *AgentPoolProfile) validateKernelBootParameters(orchestratorType string) error {
+	if len(a.KernelBootParameters) == 0 {
+		return nil
+	}
+	if orchestratorType != Kubernetes {
+		return errors.Errorf("agent pool '%s' has kernelBootParameters, which are only supported for Kubernetes", a.Name)
+	}
+	if a.OSType == Windows {
+		return errors.Errorf("agent pool '%s' has kernelBootParameters, which are not supported on Windows", a.Name)
+	}
+	if a.Distro == CoreOS {
+		return errors.Errorf("agent pool '%s' has kernelBootParameters, which are not supported with the %s distro", a.Name, CoreOS)
+	}
+	parameters := map[string]bool{}
+	for _, parameter := range a.KernelBootParameters {
+		if !kernelBootParameterRegex.MatchString(parameter) {
+			return errors.Errorf("agent pool '%s' has kernel boot parameter '%s', expected the format name or name=value, e.g. hugepages=1024 or intel_iommu=on", a.Name, parameter)
 		}
+		if parameters[parameter] {
+			return errors.Errorf("agent pool '%s' has duplicate kernel boot parameter '%s'", a.Name, parameter)
+		}
+		parameters[parameter] = true
 	}
+	return nil
+}
 
-	if orchestratorType == OpenShift && a.StorageProfile != ManagedDisks {
-		return errors.New("OpenShift orchestrator supports only ManagedDisks")
+// validateContainerRuntimeDisk ensures the container runtime data disk of a Linux agent pool is a managed disk,
+// attached after the diskSizesGB ones
+func (a *AgentPoolProfile) validateContainerRuntimeDisk(orchestratorType string) error {
+	if a.ContainerRuntimeDiskSizeGB == 0 {
+		return nil
+	}
+	if orchestratorType != Kubernetes {
+		return errors.Errorf("agent pool '%s' has a containerRuntimeDiskSizeGB, which is only supported for Kubernetes", a.Name)
+	}
+	if a.ContainerRuntimeDiskSizeGB < 1 || a.ContainerRuntimeDiskSizeGB > 1023 {
+		return errors.Errorf("agent pool '%s' has containerRuntimeDiskSizeGB %d, expected a size between 1 and 1023", a.Name, a.ContainerRuntimeDiskSizeGB)
+	}
+	if a.OSType == Windows {
+		return errors.Errorf("agent pool '%s' has a containerRuntimeDiskSizeGB, which is not supported on Windows", a.Name)
+	}
+	if a.Distro == CoreOS {
+		return errors.Errorf("agent pool '%s' has a containerRuntimeDiskSizeGB, which is not supported with the %s distro", a.Name, CoreOS)
+	}
+	if a.StorageProfile == StorageAccount {
+		return errors.Errorf("agent pool '%s' has a containerRuntimeDiskSizeGB, which requires the storageProfile %s", a.Name, ManagedDisks)
+	}
+	if len(a.DiskSizesGB) >= 4 {
+		return errors.Errorf("agent pool '%s' has a containerRuntimeDiskSizeGB and %d diskSizesGB, at most 4 data disks are supported", a.Name, len(a.DiskSizesGB))
 	}
-
 	return nil
 }
 
-func (a *AgentPoolProfile) validateCustomNodeLabels(orchestratorType string) error {
-	if len(a.CustomNodeLabels) > 0 {
-		switch orchestratorType {
-		case DCOS:
-		case Kubernetes:
-			for k, v := range a.CustomNodeLabels {
-				if e := validateKubernetesLabelKey(k); e != nil {
-					return e
+func (a *Properties) validateZones() error {
+	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
+		// all zones or no zones should be defined for the cluster
+		if a.HasAvailabilityZones() {
+			if a.MastersAndAgentsUseAvailabilityZones() {
+				// master profile
+				if a.MasterProfile.Count < len(a.MasterProfile.AvailabilityZones)*2 {
+					return errors.New("the node count and the number of availability zones provided can result in zone imbalance. To achieve zone balance, each zone should have at least 2 nodes or more")
 				}
-				if e := validateKubernetesLabelValue(v); e != nil {
-					return e
+				// agent pool profiles
+				for _, agentPoolProfile := range a.AgentPoolProfiles {
+					if agentPoolProfile.AvailabilityProfile == AvailabilitySet {
+						return errors.New("Availability Zones are not supported with an AvailabilitySet. Please either remove availabilityProfile or set availabilityProfile to VirtualMachineScaleSets")
+					}
+					if agentPoolProfile.Count < len(agentPoolProfile.AvailabilityZones)*2 {
+						return errors.New("the node count and the number of availability zones provided can result in zone imbalance. To achieve zone balance, each zone should have at least 2 nodes or more")
+					}
+				}
+				if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.LoadBalancerSku != "" && a.OrchestratorProfile.KubernetesConfig.LoadBalancerSku != "Standard" {
+					return errors.New("Availability Zones requires Standard LoadBalancer. Please set KubernetesConfig \"LoadBalancerSku\" to \"Standard\"")
 				}
+			} else {
+				return errors.New("Availability Zones need to be defined for master profile and all agent pool profiles. Please set \"availabilityZones\" for all profiles")
 			}
-		default:
-			return errors.New("Agent CustomNodeLabels are only supported for DCOS and Kubernetes")
 		}
 	}
 	return nil
 }
 
-func (a *AgentPoolProfile) validateKubernetesDistro() error {
-	switch a.Distro {
-	case AKS:
-		if a.IsNSeriesSKU() {
-			return errors.Errorf("The %s VM SKU must use the %s Distro as they require the docker-engine container runtime", a.VMSize, AKSDockerEngine)
-		}
+func (a *Properties) validateLinuxProfile() error {
+	if e := validate.Var(a.LinuxProfile.SSH.PublicKeys[0].KeyData, "required"); e != nil {
+		return errors.New("KeyData in LinuxProfile.SSH.PublicKeys cannot be empty string")
+	}
+	return validateKeyVaultSecrets(a.LinuxProfile.Secrets, false)
+}
+
+func (a *Properties) validateAddons() error {
+	if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.Addons != nil {
+		var isAvailabilitySets bool
+		var IsNSeriesSKU bool
+
+		for _, agentPool := range a.AgentPoolProfiles {
+			if agentPool.IsAvailabilitySets() {
+				isAvailabilitySets = true
+			}
+
+			if agentPool.IsNSeriesSKU() {
+				IsNSeriesSKU = true
+			}
+		}
+		for _, addon := range a.OrchestratorProfile.KubernetesConfig.Addons {
+
+			if addon.Data != "" {
+				if len(addon.Config) > 0 || len(addon.Containers) > 0 {
+					return errors.New("Config and containers should be empty when addon.Data is specified")
+				}
+				if _, err := base64.StdEncoding.DecodeString(addon.Data); err != nil {
+					return errors.Errorf("Addon %s's data should be base64 encoded", addon.Name)
+				}
+			}
+
+			for _, container := range addon.Containers {
+				if container.Image != "" && !imageReferenceRegex.MatchString(container.Image) {
+					return errors.Errorf("%s add-on container '%s' has image '%s', which is not a valid container image reference", addon.Name, container.Name, container.Image)
+				}
+				if container.Version != "" && !imageTagRegex.MatchString(container.Version) {
+					return errors.Errorf("%s add-on container '%s' has version '%s', which is not a valid container image tag", addon.Name, container.Name, container.Version)
+				}
+			}
+
+			switch addon.Name {
+			case "cluster-autoscaler":
+				if helpers.IsTrueBoolPointer(addon.Enabled) && isAvailabilitySets {
+					return errors.Errorf("Cluster Autoscaler add-on can only be used with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"%s\"", VirtualMachineScaleSets)
+				}
+			case "nvidia-device-plugin":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if version == "" {
+						return errors.Errorf("the following user supplied OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", a.OrchestratorProfile.OrchestratorType, a.OrchestratorProfile.OrchestratorRelease, a.OrchestratorProfile.OrchestratorVersion)
+					}
+					sv, err := semver.Make(version)
+					if err != nil {
+						return errors.Errorf("could not validate version %s", version)
+					}
+					minVersion, err := semver.Make("1.10.0")
+					if err != nil {
+						return errors.New("could not validate version")
+					}
+					if IsNSeriesSKU && sv.LT(minVersion) {
+						return errors.New("NVIDIA Device Plugin add-on can only be used Kubernetes 1.10 or above. Please specify \"orchestratorRelease\": \"1.10\"")
+					}
+				}
+			case "metrics-server":
+				if !helpers.IsFalseBoolPointer(addon.Enabled) {
+					if val, ok := addon.Config["metric-resolution"]; ok {
+						if d, err := time.ParseDuration(val); err != nil || d <= 0 {
+							return errors.Errorf("metrics-server add-on config metric-resolution '%s' must be a positive duration", val)
+						}
+					}
+					if val, ok := addon.Config["kubelet-insecure-tls"]; ok {
+						if val != "true" && val != "false" {
+							return errors.Errorf("metrics-server add-on config kubelet-insecure-tls '%s' must be true or false", val)
+						}
+					}
+				}
+			case "gatekeeper":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					sv, err := semver.Make(version)
+					if err != nil {
+						return errors.Errorf("could not validate version %s", version)
+					}
+					minVersion, err := semver.Make("1.10.0")
+					if err != nil {
+						return errors.New("could not validate version")
+					}
+					if sv.LT(minVersion) {
+						return errors.New("Gatekeeper add-on requires admission webhooks and can only be used with Kubernetes 1.10 or above. Please specify \"orchestratorRelease\": \"1.10\"")
+					}
+					for _, key := range []string{"replicas", "auditInterval"} {
+						if val, ok := addon.Config[key]; ok {
+							if i, err := strconv.Atoi(val); err != nil || i < 1 {
+								return errors.Errorf("Gatekeeper add-on config %s '%s' must be a positive integer", key, val)
+							}
+						}
+					}
+				}
+			case "container-monitoring":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					if e := validateContainerMonitoringWorkspace(addon.Config); e != nil {
+						return e
+					}
+				}
+			case "node-problem-detector":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					if val, ok := addon.Config["monitors"]; ok {
+						var monitors map[string]map[string]interface{}
+						if err := json.Unmarshal([]byte(val), &monitors); err != nil {
+							return errors.Wrap(err, "node-problem-detector add-on config monitors must be a JSON object of monitor configurations keyed by file name")
+						}
+						if len(monitors) == 0 {
+							return errors.New("node-problem-detector add-on config monitors must have at least one monitor")
+						}
+						for file, monitor := range monitors {
+							if !strings.HasSuffix(file, ".json") || strings.Contains(file, "/") {
+								return errors.Errorf("node-problem-detector add-on config monitors has monitor '%s', its name must be a .json file name", file)
+							}
+							if monitor == nil {
+								return errors.Errorf("node-problem-detector add-on config monitors has monitor '%s' without a configuration", file)
+							}
+						}
+					}
+				}
+			case "startup-taint-remover":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if !common.IsKubernetesVersionGe(version, "1.10.0") {
+						return errors.New("startup-taint-remover add-on can only be used with Kubernetes 1.10 or above. Please specify \"orchestratorRelease\": \"1.10\"")
+					}
+					if e := validateStartupTaintRemover(addon.Config); e != nil {
+						return e
+					}
+				}
+			case "dns-autoscaler":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if !common.IsKubernetesVersionGe(version, "1.9.0") {
+						return errors.New("dns-autoscaler add-on can only be used with Kubernetes 1.9 or above. Please specify \"orchestratorRelease\": \"1.9\"")
+					}
+					if e := validateDNSAutoscalerAddon(addon.Config); e != nil {
+						return e
+					}
+				}
+			case "nodelocaldns":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if !common.IsKubernetesVersionGe(version, "1.12.0") {
+						return errors.New("nodelocaldns add-on can only be used with Kubernetes 1.12 or above. Please specify \"orchestratorRelease\": \"1.12\"")
+					}
+					if e := validateNodeLocalDNSAddon(addon.Config, a.OrchestratorProfile.KubernetesConfig.ServiceCidr); e != nil {
+						return e
+					}
+				}
+			case "velero":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if !common.IsKubernetesVersionGe(version, "1.10.0") {
+						return errors.New("velero add-on can only be used with Kubernetes 1.10 or above. Please specify \"orchestratorRelease\": \"1.10\"")
+					}
+					if e := a.validateVeleroAddon(addon.Config); e != nil {
+						return e
+					}
+				}
+			case "cloud-node-manager":
+				if helpers.IsTrueBoolPointer(addon.Enabled) && !helpers.IsTrueBoolPointer(a.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager) {
+					return errors.New("cloud-node-manager add-on requires the external cloud provider. Please specify \"useCloudControllerManager\": true")
+				}
+			case "azuredisk-csi-driver", "azurefile-csi-driver":
+				if helpers.IsTrueBoolPointer(addon.Enabled) {
+					version := common.RationalizeReleaseAndVersion(
+						a.OrchestratorProfile.OrchestratorType,
+						a.OrchestratorProfile.OrchestratorRelease,
+						a.OrchestratorProfile.OrchestratorVersion,
+						false,
+						false)
+					if e := validateCSIDriverAddon(addon.Name, version, a.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager); e != nil {
+						return e
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateCSIDriverAddon ensures a CSI driver addon runs with the external cloud provider, whose in-tree
+// volume plugins it replaces, and with CSI 1.0, which its sidecars require
+func validateCSIDriverAddon(name, k8sVersion string, useCloudControllerManager *bool) error {
+	if !helpers.IsTrueBoolPointer(useCloudControllerManager) {
+		return errors.Errorf("%s add-on requires the external cloud provider. Please specify \"useCloudControllerManager\": true", name)
+	}
+	if !common.IsKubernetesVersionGe(k8sVersion, "1.13.0-alpha.1") {
+		return errors.Errorf("%s add-on requires CSI 1.0 and can only be used with Kubernetes 1.13.0-alpha.1 or above, the version is %s", name, k8sVersion)
+	}
+	return nil
+}
+
+// validateDNSAutoscalerAddon ensures the dns-autoscaler addon is given positive numbers of cores and
+// nodes per DNS replica, rendered as JSON numbers, and a positive minimum number of replicas
+func validateDNSAutoscalerAddon(config map[string]string) error {
+	for _, key := range []string{"coresPerReplica", "nodesPerReplica"} {
+		if val, ok := config[key]; ok {
+			var ratio float64
+			if err := json.Unmarshal([]byte(val), &ratio); err != nil || ratio <= 0 {
+				return errors.Errorf("dns-autoscaler add-on config %s '%s' is invalid, expected a positive number", key, val)
+			}
+		}
+	}
+	if val, ok := config["min"]; ok {
+		if min, err := strconv.Atoi(val); err != nil || min < 1 {
+			return errors.Errorf("dns-autoscaler add-on config min '%s' is invalid, expected a positive number of replicas", val)
+		}
+	}
+	return nil
+}
+
+// validateVeleroAddon ensures the velero addon is given the storage account, the resource group of the
+// storage account and the blob container storing the backups, and the secret of a service principal,
+// which velero authenticates with to Azure
+func (a *Properties) validateVeleroAddon(config map[string]string) error {
+	if storageAccount := config["storageAccount"]; !storageAccountNameRegex.MatchString(storageAccount) {
+		return errors.Errorf("velero add-on config storageAccount '%s' is invalid, expected the name of a storage account of 3 to 24 lowercase letters and numbers", storageAccount)
+	}
+	if resourceGroup := config["resourceGroup"]; !resourceGroupNameRegex.MatchString(resourceGroup) {
+		return errors.Errorf("velero add-on config resourceGroup '%s' is invalid, expected the name of the resource group of the storage account", resourceGroup)
+	}
+	if container, ok := config["container"]; ok && (len(container) > 63 || !blobContainerNameRegex.MatchString(container)) {
+		return errors.Errorf("velero add-on config container '%s' is invalid, expected the name of a blob container of 3 to 63 lowercase letters, numbers and single hyphens", container)
+	}
+	if a.OrchestratorProfile.KubernetesConfig.UseManagedIdentity {
+		return errors.New("velero add-on requires the secret of a service principal and cannot be used with \"useManagedIdentity\": true")
+	}
+	if a.ServicePrincipalProfile != nil && len(a.ServicePrincipalProfile.Certificate) != 0 {
+		return errors.New("velero add-on requires the secret of a service principal and cannot be used with a service principal certificate")
+	}
+	return nil
+}
+
+// validateNodeLocalDNSAddon ensures the nodelocaldns addon listens on an IPv4 address outside the service CIDR,
+// which the iptables rules of kube-proxy would otherwise redirect away from the cache of the node
+func validateNodeLocalDNSAddon(config map[string]string, serviceCidr string) error {
+	localIP, ok := config["localIP"]
+	if !ok {
+		return nil
+	}
+	ip := net.ParseIP(localIP)
+	if ip == nil || ip.To4() == nil {
+		return errors.Errorf("nodelocaldns add-on config localIP '%s' is an invalid IPv4 address", localIP)
+	}
+	if serviceCidr == "" {
+		serviceCidr = DefaultServiceCidr
+	}
+	for _, cidr := range strings.Split(serviceCidr, ",") {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			// the service CIDR itself is reported by the validation of the KubernetesConfig
+			continue
+		}
+		if subnet.Contains(ip) {
+			return errors.Errorf("nodelocaldns add-on config localIP '%s' conflicts with the ServiceCidr '%s'", localIP, serviceCidr)
+		}
+	}
+	return nil
+}
+
+// validateStartupTaintRemover ensures the startup-taint-remover addon is given a NoSchedule taint, which
+// the kubelets register, and critical DaemonSets formatted namespace/name
+func validateStartupTaintRemover(config map[string]string) error {
+	if taint, ok := config["taint"]; ok {
+		i := strings.LastIndex(taint, ":")
+		if i < 0 || taint[i+1:] != "NoSchedule" {
+			return errors.Errorf("startup-taint-remover add-on config taint '%s' is invalid, expected the format key=value:NoSchedule", taint)
+		}
+		keyValue := strings.SplitN(taint[:i], "=", 2)
+		if e := validateKubernetesLabelKey(keyValue[0]); e != nil {
+			return e
+		}
+		if len(keyValue) == 2 {
+			if e := validateKubernetesLabelValue(keyValue[1]); e != nil {
+				return e
+			}
+		}
+	}
+	if daemonSets := config["daemonSets"]; daemonSets != "" {
+		for _, daemonSet := range strings.Split(daemonSets, ",") {
+			namespaceName := strings.Split(daemonSet, "/")
+			if len(namespaceName) != 2 || !labelValueRegex.MatchString(namespaceName[0]) || !labelValueRegex.MatchString(namespaceName[1]) {
+				return errors.Errorf("startup-taint-remover add-on config daemonSets has DaemonSet '%s', expected the format namespace/name", daemonSet)
+			}
+		}
+	}
+	return nil
+}
+
+// validateContainerMonitoringWorkspace ensures the container-monitoring addon is either given the
+// resource ID of an existing Log Analytics workspace, whose id and key are looked up at deployment
+// time, or the base64 encoded id and key of the workspace, but not both
+func validateContainerMonitoringWorkspace(config map[string]string) error {
+	workspaceResourceID, workspaceGUID, workspaceKey := config["workspaceResourceId"], config["workspaceGuid"], config["workspaceKey"]
+	if workspaceResourceID != "" {
+		if workspaceGUID != "" || workspaceKey != "" {
+			return errors.New("container-monitoring add-on config workspaceResourceId cannot be combined with workspaceGuid and workspaceKey")
+		}
+		if !logAnalyticsWorkspaceIDRegex.MatchString(workspaceResourceID) {
+			return errors.Errorf("container-monitoring add-on config workspaceResourceId '%s' is not a valid Log Analytics workspace resource ID, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.OperationalInsights/workspaces/<workspace name>", workspaceResourceID)
+		}
+		return nil
+	}
+	if workspaceGUID != "" {
+		decoded, err := base64.StdEncoding.DecodeString(workspaceGUID)
+		if err != nil {
+			return errors.New("container-monitoring add-on config workspaceGuid must be base64 encoded")
+		}
+		if _, err := uuid.FromString(strings.TrimSpace(string(decoded))); err != nil {
+			return errors.Errorf("container-monitoring add-on config workspaceGuid must be the base64 encoding of the workspace ID, a GUID, got '%s'", strings.TrimSpace(string(decoded)))
+		}
+	}
+	if workspaceKey != "" {
+		if _, err := base64.StdEncoding.DecodeString(workspaceKey); err != nil {
+			return errors.New("container-monitoring add-on config workspaceKey must be base64 encoded")
+		}
+	}
+	return nil
+}
+
+func (a *Properties) validateExtensions() error {
+	for _, agentPool := range a.AgentPoolProfiles {
+		if len(agentPool.Extensions) != 0 && (len(agentPool.AvailabilityProfile) == 0 || agentPool.IsVirtualMachineScaleSets()) {
+			return errors.Errorf("Extensions are currently not supported with VirtualMachineScaleSets. Please specify \"availabilityProfile\": \"%s\"", AvailabilitySet)
+		}
+	}
+
+	for _, extension := range a.ExtensionProfiles {
+		if extension.ExtensionParametersKeyVaultRef != nil {
+			if e := validate.Var(extension.ExtensionParametersKeyVaultRef.VaultID, "required"); e != nil {
+				return errors.Errorf("the Keyvault ID must be specified for Extension %s", extension.Name)
+			}
+			if e := validate.Var(extension.ExtensionParametersKeyVaultRef.SecretName, "required"); e != nil {
+				return errors.Errorf("the Keyvault Secret must be specified for Extension %s", extension.Name)
+			}
+			if !keyvaultIDRegex.MatchString(extension.ExtensionParametersKeyVaultRef.VaultID) {
+				return errors.Errorf("Extension %s's keyvault secret reference is of incorrect format", extension.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Properties) validateVNET() error {
+	isCustomVNET := a.MasterProfile.IsCustomVNET()
+	for _, agentPool := range a.AgentPoolProfiles {
+		if agentPool.IsCustomVNET() != isCustomVNET {
+			return errors.New("Multiple VNET Subnet configurations specified.  The master profile and each agent pool profile must all specify a custom VNET Subnet, or none at all")
+		}
+	}
+	if isCustomVNET {
+		if a.MasterProfile.IsVirtualMachineScaleSets() && a.MasterProfile.AgentVnetSubnetID == "" {
+			return errors.New("when master profile is using VirtualMachineScaleSets and is custom vnet, set \"vnetsubnetid\" and \"agentVnetSubnetID\" for master profile")
+		}
+
+		subscription, resourcegroup, vnetname, _, e := common.GetVNETSubnetIDComponents(a.MasterProfile.VnetSubnetID)
+		if e != nil {
+			return e
+		}
+
+		for _, agentPool := range a.AgentPoolProfiles {
+			agentSubID, agentRG, agentVNET, _, err := common.GetVNETSubnetIDComponents(agentPool.VnetSubnetID)
+			if err != nil {
+				return err
+			}
+			if agentSubID != subscription ||
+				agentRG != resourcegroup ||
+				agentVNET != vnetname {
+				return errors.New("Multiple VNETS specified.  The master profile and each agent pool must reference the same VNET (but it is ok to reference different subnets on that VNET)")
+			}
+		}
+
+		masterFirstIP := net.ParseIP(a.MasterProfile.FirstConsecutiveStaticIP)
+		if masterFirstIP == nil && !a.MasterProfile.IsVirtualMachineScaleSets() {
+			return errors.Errorf("MasterProfile.FirstConsecutiveStaticIP (with VNET Subnet specification) '%s' is an invalid IP address", a.MasterProfile.FirstConsecutiveStaticIP)
+		}
+
+		if a.MasterProfile.VnetCidr != "" {
+			_, _, err := net.ParseCIDR(a.MasterProfile.VnetCidr)
+			if err != nil {
+				return errors.Errorf("MasterProfile.VnetCidr '%s' contains invalid cidr notation", a.MasterProfile.VnetCidr)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDisableSSH ensures that closing SSH on an agent pool's subnet leaves the masters,
+// and the agent pools keeping SSH, reachable over SSH
+func (a *Properties) validateDisableSSH() error {
+	for _, agentPool := range a.AgentPoolProfiles {
+		if !agentPool.DisableSSH {
+			continue
+		}
+		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+			return errors.Errorf("agent pool '%s' sets disableSSH, which is only supported with Kubernetes", agentPool.Name)
+		}
+		if agentPool.OSType == Windows {
+			return errors.Errorf("agent pool '%s' sets disableSSH, which is only supported on Linux agent pools", agentPool.Name)
+		}
+		if agentPool.VnetSubnetID == "" || strings.EqualFold(agentPool.VnetSubnetID, a.MasterProfile.VnetSubnetID) {
+			return errors.Errorf("agent pool '%s' sets disableSSH, which requires a vnetSubnetID separate from the masterProfile one, so that SSH access to the masters remains", agentPool.Name)
+		}
+		for _, other := range a.AgentPoolProfiles {
+			if !other.DisableSSH && strings.EqualFold(other.VnetSubnetID, agentPool.VnetSubnetID) {
+				return errors.Errorf("agent pool '%s' sets disableSSH, but shares its vnetSubnetID with agent pool '%s' which does not", agentPool.Name, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateServiceInternalLBSubnet ensures that the subnet internal services are exposed on can be
+// fronted by the cluster's standard internal load balancer, which lives in the masters' VNET
+func (a *Properties) validateServiceInternalLBSubnet() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	if k == nil || k.ServiceInternalLBSubnetID == "" {
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.New("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID is only supported with Kubernetes")
+	}
+	if k.LoadBalancerSku != "Standard" {
+		return errors.New("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID requires a Standard loadBalancerSku")
+	}
+	if a.MasterProfile == nil || !a.MasterProfile.IsCustomVNET() {
+		return errors.New("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID requires the masterProfile to set a custom vnetSubnetID")
+	}
+	subscription, resourceGroup, vnet, _, err := common.GetVNETSubnetIDComponents(k.ServiceInternalLBSubnetID)
+	if err != nil {
+		return errors.Wrap(err, "OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID is not a valid subnet ID")
+	}
+	masterSubscription, masterResourceGroup, masterVnet, _, err := common.GetVNETSubnetIDComponents(a.MasterProfile.VnetSubnetID)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(subscription, masterSubscription) || !strings.EqualFold(resourceGroup, masterResourceGroup) || !strings.EqualFold(vnet, masterVnet) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID '%s' needs to be a subnet of the masterProfile VNET '%s'", k.ServiceInternalLBSubnetID, masterVnet)
+	}
+	return nil
+}
+
+// validateNATGateway ensures that the NAT gateway can take over the egress of the subnets created
+// for the cluster from the outbound SNAT of the standard load balancers
+func (a *Properties) validateNATGateway() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	if k == nil || k.NATGatewayID == "" {
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID is only supported with Kubernetes")
+	}
+	if !natGatewayIDRegex.MatchString(k.NATGatewayID) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.NATGatewayID '%s' is not a valid NAT gateway resource ID", k.NATGatewayID)
+	}
+	if k.LoadBalancerSku != "Standard" {
+		return errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID requires a Standard loadBalancerSku")
+	}
+	if a.MasterProfile == nil || a.MasterProfile.IsCustomVNET() {
+		return errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID is not supported with a custom VNET, associate the NAT gateway with the vnetSubnetID subnets instead")
+	}
+	if k.EgressFirewall != nil {
+		return errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID and OrchestratorProfile.KubernetesConfig.EgressFirewall are mutually exclusive")
+	}
+	if v, ok := k.CloudProviderConfig["disableOutboundSNAT"]; ok {
+		if disabled, err := strconv.ParseBool(v); err == nil && !disabled {
+			return errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID requires the cloudProviderConfig disableOutboundSNAT to be true")
+		}
+	}
+	return nil
+}
+
+// validateAgentOutbound ensures that the agent nodes without outbound internet access still have an egress
+// path through a firewall virtual appliance or a NAT gateway
+func (a *Properties) validateAgentOutbound() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	if k == nil || !helpers.IsTrueBoolPointer(k.DisableAgentOutbound) {
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.New("OrchestratorProfile.KubernetesConfig.DisableAgentOutbound is only supported with Kubernetes")
+	}
+	if k.EgressFirewall == nil && k.NATGatewayID == "" {
+		return errors.New("OrchestratorProfile.KubernetesConfig.DisableAgentOutbound requires an egress path through OrchestratorProfile.KubernetesConfig.EgressFirewall or OrchestratorProfile.KubernetesConfig.NATGatewayID")
+	}
+	if v, ok := k.CloudProviderConfig["disableOutboundSNAT"]; ok && k.LoadBalancerSku == "Standard" {
+		if disabled, err := strconv.ParseBool(v); err == nil && !disabled {
+			return errors.New("OrchestratorProfile.KubernetesConfig.DisableAgentOutbound requires the cloudProviderConfig disableOutboundSNAT to be true")
+		}
+	}
+	return nil
+}
+
+// validateAPIServerEndpoint ensures that the apiserver advertises a valid address, and that its secure port
+// is a valid port not used by the other components of the masters
+func (a *Properties) validateAPIServerEndpoint() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	if k == nil || (k.APIServerAdvertiseAddress == "" && k.APIServerSecurePort == 0) {
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.New("OrchestratorProfile.KubernetesConfig.APIServerAdvertiseAddress and OrchestratorProfile.KubernetesConfig.APIServerSecurePort are only supported with Kubernetes")
+	}
+	if k.APIServerAdvertiseAddress != "" && net.ParseIP(k.APIServerAdvertiseAddress) == nil {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.APIServerAdvertiseAddress '%s' is not a valid IP address", k.APIServerAdvertiseAddress)
+	}
+	if k.APIServerSecurePort == 0 {
+		return nil
+	}
+	if k.APIServerSecurePort < 1 || k.APIServerSecurePort > 65535 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.APIServerSecurePort '%d' must be between 1 and 65535", k.APIServerSecurePort)
+	}
+	// ssh, etcd, the redirection of the internal load balancer, the kubelet, and the insecure ports
+	// of the apiserver, scheduler and controller-manager
+	for _, port := range []int{22, 2379, 2380, 4443, 8080, 10248, 10250, 10251, 10252, 10255} {
+		if k.APIServerSecurePort == port {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.APIServerSecurePort '%d' is already used on the masters", k.APIServerSecurePort)
+		}
+	}
+	if a.HasWindows() {
+		return errors.New("OrchestratorProfile.KubernetesConfig.APIServerSecurePort is not supported with Windows agent pools")
+	}
+	return nil
+}
+
+func (a *Properties) validateServicePrincipalProfile() error {
+	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
+		useManagedIdentity := a.OrchestratorProfile.KubernetesConfig != nil &&
+			a.OrchestratorProfile.KubernetesConfig.UseManagedIdentity
+
+		if !useManagedIdentity {
+			if a.ServicePrincipalProfile == nil {
+				return errors.Errorf("ServicePrincipalProfile must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
+			}
+			if e := validate.Var(a.ServicePrincipalProfile.ClientID, "required"); e != nil {
+				return errors.Errorf("the service principal client ID must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
+			}
+			credentials := 0
+			if len(a.ServicePrincipalProfile.Secret) != 0 {
+				credentials++
+			}
+			if a.ServicePrincipalProfile.KeyvaultSecretRef != nil {
+				credentials++
+			}
+			if len(a.ServicePrincipalProfile.Certificate) != 0 {
+				credentials++
+			}
+			if credentials != 1 {
+				return errors.Errorf("exactly one of the service principal client secret, keyvault secret reference or client certificate must be specified with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
+			}
+			if len(a.ServicePrincipalProfile.CertificatePassword) != 0 && len(a.ServicePrincipalProfile.Certificate) == 0 {
+				return errors.New("the service principal certificatePassword can only be specified together with certificate")
+			}
+			if len(a.ServicePrincipalProfile.Certificate) != 0 {
+				if block, _ := pem.Decode([]byte(a.ServicePrincipalProfile.Certificate)); block == nil {
+					return errors.New("the service principal certificate must be a PEM encoded certificate and private key")
+				}
+			}
+
+			if a.OrchestratorProfile.KubernetesConfig != nil && helpers.IsTrueBoolPointer(a.OrchestratorProfile.KubernetesConfig.EnableEncryptionWithExternalKms) && len(a.ServicePrincipalProfile.ObjectID) == 0 {
+				return errors.Errorf("the service principal object ID must be specified with Orchestrator %s when enableEncryptionWithExternalKms is true", a.OrchestratorProfile.OrchestratorType)
+			}
+
+			if a.ServicePrincipalProfile.KeyvaultSecretRef != nil {
+				if e := validate.Var(a.ServicePrincipalProfile.KeyvaultSecretRef.VaultID, "required"); e != nil {
+					return errors.Errorf("the Keyvault ID must be specified for the Service Principle with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
+				}
+				if e := validate.Var(a.ServicePrincipalProfile.KeyvaultSecretRef.SecretName, "required"); e != nil {
+					return errors.Errorf("the Keyvault Secret must be specified for the Service Principle with Orchestrator %s", a.OrchestratorProfile.OrchestratorType)
+				}
+				if !keyvaultIDRegex.MatchString(a.ServicePrincipalProfile.KeyvaultSecretRef.VaultID) {
+					return errors.Errorf("service principal client keyvault secret reference is of incorrect format")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Properties) validateManagedIdentity() error {
+	if a.OrchestratorProfile.OrchestratorType == Kubernetes {
+		useManagedIdentity := a.OrchestratorProfile.KubernetesConfig != nil &&
+			a.OrchestratorProfile.KubernetesConfig.UseManagedIdentity
+
+		if useManagedIdentity {
+			version := common.RationalizeReleaseAndVersion(
+				a.OrchestratorProfile.OrchestratorType,
+				a.OrchestratorProfile.OrchestratorRelease,
+				a.OrchestratorProfile.OrchestratorVersion,
+				false,
+				false)
+			if version == "" {
+				return errors.Errorf("the following user supplied OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", a.OrchestratorProfile.OrchestratorType, a.OrchestratorProfile.OrchestratorRelease, a.OrchestratorProfile.OrchestratorVersion)
+			}
+			sv, err := semver.Make(version)
+			if err != nil {
+				return errors.Errorf("could not validate version %s", version)
+			}
+			minVersion, err := semver.Make("1.12.0")
+			if err != nil {
+				return errors.New("could not validate version")
+			}
+
+			if a.MasterProfile.IsVirtualMachineScaleSets() {
+				if sv.LT(minVersion) {
+					return errors.New("managed identity and VMSS masters can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"")
+				}
+			} else if a.OrchestratorProfile.KubernetesConfig.UserAssignedID != "" && sv.LT(minVersion) {
+				return errors.New("user assigned identity can only be used with Kubernetes 1.12.0 or above. Please specify \"orchestratorRelease\": \"1.12\"")
+			}
+
+		}
+	}
+	return nil
+}
+
+func (a *Properties) validateAADProfile() error {
+	if profile := a.AADProfile; profile != nil {
+		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+			return errors.Errorf("'aadProfile' is only supported by orchestrator '%v'", Kubernetes)
+		}
+		if k := a.OrchestratorProfile.KubernetesConfig; k != nil && k.OIDCConfig != nil {
+			return errors.New("'aadProfile' configures the OIDC authentication of the API server and cannot be used together with OrchestratorProfile.KubernetesConfig.OIDCConfig")
+		}
+		if _, err := uuid.FromString(profile.ClientAppID); err != nil {
+			return errors.Errorf("clientAppID '%v' is invalid", profile.ClientAppID)
+		}
+		if _, err := uuid.FromString(profile.ServerAppID); err != nil {
+			return errors.Errorf("serverAppID '%v' is invalid", profile.ServerAppID)
+		}
+		if len(profile.TenantID) > 0 {
+			if _, err := uuid.FromString(profile.TenantID); err != nil {
+				return errors.Errorf("tenantID '%v' is invalid", profile.TenantID)
+			}
+		}
+		if len(profile.AdminGroupID) > 0 {
+			if _, err := uuid.FromString(profile.AdminGroupID); err != nil {
+				return errors.Errorf("adminGroupID '%v' is invalid", profile.AdminGroupID)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Properties) validateAzProfile() error {
+	switch a.OrchestratorProfile.OrchestratorType {
+	case OpenShift:
+		if a.AzProfile == nil || a.AzProfile.Location == "" ||
+			a.AzProfile.ResourceGroup == "" || a.AzProfile.SubscriptionID == "" ||
+			a.AzProfile.TenantID == "" {
+			return errors.Errorf("'azProfile' must be supplied in full for orchestrator '%v'", OpenShift)
+		}
+	default:
+		if a.AzProfile != nil {
+			return errors.Errorf("'azProfile' is only supported by orchestrator '%v'", OpenShift)
+		}
+	}
+	return nil
+}
+
+// validateExtraTemplateEntries ensures that the extraParameters and extraVariables can be added to
+// the generated template; their collisions with the generated entries are checked at generation
+func (a *Properties) validateExtraTemplateEntries() error {
+	for name, value := range a.ExtraParameters {
+		if name == "" {
+			return errors.New("extraParameters has a parameter with an empty name")
+		}
+		switch v := value.(type) {
+		case nil:
+			return errors.Errorf("extraParameters '%s' has no value", name)
+		case float64:
+			if v != math.Trunc(v) {
+				return errors.Errorf("extraParameters '%s' is %v, but numeric template parameters need to be whole numbers", name, v)
+			}
+		}
+	}
+	for name := range a.ExtraVariables {
+		if name == "" {
+			return errors.New("extraVariables has a variable with an empty name")
+		}
+	}
+	return nil
+}
+
+// Validate OpenShiftConfig ensures that the OpenShiftConfig is valid.
+func (o *OpenShiftConfig) Validate() error {
+	if o.ClusterUsername == "" || o.ClusterPassword == "" {
+		return errors.Errorf("ClusterUsername and ClusterPassword must both be specified")
+	}
+	return nil
+}
+
+// validatePlatformDomainCounts ensures the fault and update domain counts of the availability sets
+// of a profile are within the Azure limits
+func validatePlatformDomainCounts(profile, availabilityProfile string, platformFaultDomainCount, platformUpdateDomainCount *int) error {
+	if platformFaultDomainCount == nil && platformUpdateDomainCount == nil {
+		return nil
+	}
+	if availabilityProfile == VirtualMachineScaleSets {
+		return errors.Errorf("%s sets platformFaultDomainCount or platformUpdateDomainCount, which are only supported with availabilityProfile %s", profile, AvailabilitySet)
+	}
+	if c := platformFaultDomainCount; c != nil && (*c < MinPlatformFaultDomainCount || *c > MaxPlatformFaultDomainCount) {
+		return errors.Errorf("%s has platformFaultDomainCount %d, it needs to be in the range [%d,%d]", profile, *c, MinPlatformFaultDomainCount, MaxPlatformFaultDomainCount)
+	}
+	if c := platformUpdateDomainCount; c != nil && (*c < MinPlatformUpdateDomainCount || *c > MaxPlatformUpdateDomainCount) {
+		return errors.Errorf("%s has platformUpdateDomainCount %d, it needs to be in the range [%d,%d]", profile, *c, MinPlatformUpdateDomainCount, MaxPlatformUpdateDomainCount)
+	}
+	return nil
+}
+
+// validateLoadBalancer ensures the load balancing rules and health probes of the API server load
+// balancers are within the Azure limits
+func (m *MasterProfile) validateLoadBalancer() error {
+	if t := m.LoadBalancerIdleTimeoutInMinutes; t != 0 && (t < MinLoadBalancerIdleTimeoutInMinutes || t > MaxLoadBalancerIdleTimeoutInMinutes) {
+		return errors.Errorf("masterProfile has loadBalancerIdleTimeoutInMinutes %d, it needs to be in the range [%d,%d]", t, MinLoadBalancerIdleTimeoutInMinutes, MaxLoadBalancerIdleTimeoutInMinutes)
+	}
+	if i := m.LoadBalancerProbeIntervalInSeconds; i != 0 && i < MinLoadBalancerProbeIntervalInSeconds {
+		return errors.Errorf("masterProfile has loadBalancerProbeIntervalInSeconds %d, it needs to be at least %d", i, MinLoadBalancerProbeIntervalInSeconds)
+	}
+	if n := m.LoadBalancerProbeThreshold; n != 0 && n < MinLoadBalancerProbeThreshold {
+		return errors.Errorf("masterProfile has loadBalancerProbeThreshold %d, it needs to be at least %d", n, MinLoadBalancerProbeThreshold)
+	}
+	return nil
+}
+
+// masterManifests are the names of the static pods written by acs-engine to /etc/kubernetes/manifests on the
+// masters, which the static pods of the master profile can't replace
+var masterManifests = []string{
+	"kube-apiserver",
+	"kube-controller-manager",
+	"kube-scheduler",
+	"kube-addon-manager",
+	"kube-proxy",
+	"pod-security-policy",
+}
+
+// staticPod holds the fields of a static pod manifest which are validated
+type staticPod struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Containers []struct {
+			Name  string `json:"name"`
+			Image string `json:"image"`
+		} `json:"containers"`
+	} `json:"spec"`
+}
+
+// validateStaticPods ensures that the static pods of the masters have unique names which don't replace the
+// manifests of the control plane, and that their manifest is a single Pod with containers
+func (m *MasterProfile) validateStaticPods(orchestratorType string) error {
+	if len(m.StaticPods) > 0 && orchestratorType != Kubernetes {
+		return errors.Errorf("masterProfile staticPods are only supported with the %s orchestrator", Kubernetes)
+	}
+	names := map[string]bool{}
+	for _, pod := range m.StaticPods {
+		if !labelValueRegex.MatchString(pod.Name) {
+			return errors.Errorf("masterProfile staticPods name '%s' is invalid, it must match %s", pod.Name, labelValueFormat)
+		}
+		for _, manifest := range masterManifests {
+			if pod.Name == manifest {
+				return errors.Errorf("masterProfile staticPods name '%s' is reserved for the manifest of a master component", pod.Name)
+			}
+		}
+		if names[pod.Name] {
+			return errors.Errorf("masterProfile staticPods name '%s' is duplicated", pod.Name)
+		}
+		names[pod.Name] = true
+
+		manifest := strings.TrimPrefix(pod.Manifest, "---\n")
+		if strings.Contains("\n"+manifest, "\n---") {
+			return errors.Errorf("masterProfile staticPods '%s' manifest must hold a single Pod", pod.Name)
+		}
+		var p staticPod
+		if err := yaml.Unmarshal([]byte(manifest), &p); err != nil {
+			return errors.Wrapf(err, "masterProfile staticPods '%s' manifest is not a valid YAML manifest", pod.Name)
+		}
+		if p.APIVersion != "v1" || p.Kind != "Pod" {
+			return errors.Errorf("masterProfile staticPods '%s' manifest must be a Pod of apiVersion v1, got kind '%s' of apiVersion '%s'", pod.Name, p.Kind, p.APIVersion)
+		}
+		if p.Metadata.Name == "" {
+			return errors.Errorf("masterProfile staticPods '%s' manifest must have a metadata.name", pod.Name)
+		}
+		if len(p.Spec.Containers) == 0 {
+			return errors.Errorf("masterProfile staticPods '%s' manifest must have at least one container", pod.Name)
+		}
+		for _, c := range p.Spec.Containers {
+			if c.Name == "" || c.Image == "" {
+				return errors.Errorf("masterProfile staticPods '%s' manifest containers must have a name and an image", pod.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCount ensures the agent pool fits in a single availability set or scale set, unless it
+// is a Kubernetes pool of availability sets with managed disks, which is split across availability sets,
+// and that its count is within its min and max counts
+func (a *AgentPoolProfile) validateCount(orchestratorType string) error {
+	maxCount := MaxAgentCount
+	if orchestratorType == Kubernetes && a.IsAvailabilitySets() && !a.IsStorageAccount() {
+		maxCount = MaxAvailabilitySetsAgentCount
+	}
+	if a.Count > maxCount {
+		return errors.Errorf("AgentPoolProfile count needs to be in the range [%d,%d]", MinAgentCount, maxCount)
+	}
+	if a.MinCount != nil && *a.MinCount < 1 {
+		return errors.Errorf("agent pool '%s' minCount needs to be at least 1, got %d", a.Name, *a.MinCount)
+	}
+	if a.MaxCount != nil && *a.MaxCount > maxCount {
+		return errors.Errorf("agent pool '%s' maxCount needs to be at most %d, got %d", a.Name, maxCount, *a.MaxCount)
+	}
+	if a.MinCount != nil && a.Count < *a.MinCount {
+		return errors.Errorf("agent pool '%s' count %d is lower than its minCount %d", a.Name, a.Count, *a.MinCount)
+	}
+	if a.MaxCount != nil && a.Count > *a.MaxCount {
+		return errors.Errorf("agent pool '%s' count %d is greater than its maxCount %d", a.Name, a.Count, *a.MaxCount)
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateAvailabilityProfile(orchestratorType string) error {
+	switch a.AvailabilityProfile {
+	case AvailabilitySet:
+	case VirtualMachineScaleSets:
+	case "":
+	default:
+		{
+			return errors.Errorf("unknown availability profile type '%s' for agent pool '%s'.  Specify either %s, or %s", a.AvailabilityProfile, a.Name, AvailabilitySet, VirtualMachineScaleSets)
+		}
+	}
+
+	if orchestratorType == OpenShift && a.AvailabilityProfile != AvailabilitySet {
+		return errors.Errorf("Only AvailabilityProfile: AvailabilitySet is supported for Orchestrator 'OpenShift'")
+	}
+
+	if a.OverProvision != nil || a.UpgradePolicyMode != "" {
+		if orchestratorType != Kubernetes {
+			return errors.Errorf("agent pool '%s' sets overProvision or upgradePolicyMode, which are only supported by the Kubernetes orchestrator", a.Name)
+		}
+		if a.AvailabilityProfile == AvailabilitySet {
+			return errors.Errorf("agent pool '%s' sets overProvision or upgradePolicyMode, which are only supported by the availabilityProfile %s", a.Name, VirtualMachineScaleSets)
+		}
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateRoles(orchestratorType string) error {
+	validRoles := []AgentPoolProfileRole{AgentPoolProfileRoleEmpty}
+	switch orchestratorType {
+	case OpenShift:
+		validRoles = append(validRoles, AgentPoolProfileRoleInfra)
+	case Kubernetes:
+		validRoles = append(validRoles, AgentPoolProfileRoleSystem, AgentPoolProfileRoleUser, AgentPoolProfileRoleDaemonSet)
+	}
+	var found bool
+	for _, validRole := range validRoles {
+		if a.Role == validRole {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("Role %q is not supported for Orchestrator %s", a.Role, orchestratorType)
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateStorageProfile(orchestratorType string) error {
+	/* this switch statement is left to protect newly added orchestrators until they support Managed Disks*/
+	if a.StorageProfile == ManagedDisks {
+		switch orchestratorType {
+		case DCOS:
+		case Swarm:
+		case Kubernetes:
+		case OpenShift:
+		case SwarmMode:
+		default:
+			return errors.Errorf("HA volumes are currently unsupported for Orchestrator %s", orchestratorType)
+		}
+	}
+
+	if orchestratorType == OpenShift && a.StorageProfile != ManagedDisks {
+		return errors.New("OpenShift orchestrator supports only ManagedDisks")
+	}
+
+	return nil
+}
+
+func (a *AgentPoolProfile) validateCustomNodeLabels(orchestratorType string) error {
+	if len(a.CustomNodeLabels) > 0 {
+		switch orchestratorType {
+		case DCOS:
+		case Kubernetes:
+			for k, v := range a.CustomNodeLabels {
+				if e := validateKubernetesLabelKey(k); e != nil {
+					return e
+				}
+				if e := validateKubernetesLabelValue(v); e != nil {
+					return e
+				}
+			}
+		default:
+			return errors.New("Agent CustomNodeLabels are only supported for DCOS and Kubernetes")
+		}
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateCustomNodeTaints(orchestratorType string) error {
+	if len(a.CustomNodeTaints) == 0 {
+		return nil
+	}
+	if orchestratorType != Kubernetes {
+		return errors.New("Agent CustomNodeTaints are only supported for Kubernetes")
+	}
+	for _, taint := range a.CustomNodeTaints {
+		i := strings.LastIndex(taint, ":")
+		if i < 0 {
+			return errors.Errorf("agent pool '%s' has an invalid custom node taint '%s', expected the format key=value:Effect", a.Name, taint)
+		}
+		switch effect := taint[i+1:]; effect {
+		case "NoSchedule", "PreferNoSchedule", "NoExecute":
+		default:
+			return errors.Errorf("agent pool '%s' has an invalid custom node taint '%s': unknown effect '%s', specify either NoSchedule, PreferNoSchedule or NoExecute", a.Name, taint, effect)
+		}
+		keyValue := strings.SplitN(taint[:i], "=", 2)
+		if e := validateKubernetesLabelKey(keyValue[0]); e != nil {
+			return e
+		}
+		if len(keyValue) == 2 {
+			if e := validateKubernetesLabelValue(keyValue[1]); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateKubernetesDistro() error {
+	switch a.Distro {
+	case AKS:
+		if a.IsNSeriesSKU() {
+			return errors.Errorf("The %s VM SKU must use the %s Distro as they require the docker-engine container runtime", a.VMSize, AKSDockerEngine)
+		}
+	}
+	return nil
+}
+
+func validateVMSS(o *OrchestratorProfile, isUpdate bool, storageProfile string) error {
+	if o.OrchestratorType == Kubernetes {
+		version := common.RationalizeReleaseAndVersion(
+			o.OrchestratorType,
+			o.OrchestratorRelease,
+			o.OrchestratorVersion,
+			isUpdate,
+			false)
+		if version == "" {
+			return errors.Errorf("the following OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", o.OrchestratorType, o.OrchestratorRelease, o.OrchestratorVersion)
+		}
+
+		sv, err := semver.Make(version)
+		if err != nil {
+			return errors.Errorf("could not validate version %s", version)
+		}
+		minVersion, err := semver.Make("1.10.0")
+		if err != nil {
+			return errors.New("could not validate version")
+		}
+		if sv.LT(minVersion) {
+			return errors.Errorf("VirtualMachineScaleSets are only available in Kubernetes version %s or greater. Please set \"orchestratorVersion\" to %s or above", minVersion.String(), minVersion.String())
+		}
+		// validation for instanceMetadata using VMSS with Kubernetes
+		minVersion, err = semver.Make("1.10.2")
+		if err != nil {
+			return errors.New("could not validate version")
+		}
+		if o.KubernetesConfig != nil && o.KubernetesConfig.UseInstanceMetadata != nil {
+			if *o.KubernetesConfig.UseInstanceMetadata && sv.LT(minVersion) {
+				return errors.Errorf("VirtualMachineScaleSets with instance metadata is supported for Kubernetes version %s or greater. Please set \"useInstanceMetadata\": false in \"kubernetesConfig\" or set \"orchestratorVersion\" to %s or above", minVersion.String(), minVersion.String())
+			}
+		}
+		if storageProfile == StorageAccount {
+			return errors.Errorf("VirtualMachineScaleSets does not support %s disks.  Please specify \"storageProfile\": \"%s\" (recommended) or \"availabilityProfile\": \"%s\"", StorageAccount, ManagedDisks, AvailabilitySet)
+		}
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateWindows(o *OrchestratorProfile, w *WindowsProfile, isUpdate bool) error {
+	switch o.OrchestratorType {
+	case DCOS:
+	case Swarm:
+	case SwarmMode:
+	case Kubernetes:
+		version := common.RationalizeReleaseAndVersion(
+			o.OrchestratorType,
+			o.OrchestratorRelease,
+			o.OrchestratorVersion,
+			isUpdate,
+			true)
+		if version == "" {
+			return errors.Errorf("Orchestrator %s version %s does not support Windows", o.OrchestratorType, o.OrchestratorVersion)
+		}
+	default:
+		return errors.Errorf("Orchestrator %s does not support Windows", o.OrchestratorType)
+	}
+	if w != nil {
+		if e := w.Validate(o.OrchestratorType); e != nil {
+			return e
+		}
+	} else {
+		return errors.New("WindowsProfile is required when the cluster definition contains Windows agent pool(s)")
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateOrchestratorSpecificProperties(orchestratorType string) error {
+
+	// for Kubernetes, we don't support AgentPoolProfile.DNSPrefix
+	if orchestratorType == Kubernetes {
+		if e := validate.Var(a.DNSPrefix, "len=0"); e != nil {
+			return errors.New("AgentPoolProfile.DNSPrefix must be empty for Kubernetes")
+		}
+		if e := validate.Var(a.Ports, "len=0"); e != nil {
+			return errors.New("AgentPoolProfile.Ports must be empty for Kubernetes")
+		}
+		if validate.Var(a.ScaleSetPriority, "eq=Regular") == nil && validate.Var(a.ScaleSetEvictionPolicy, "len=0") != nil {
+			return errors.New("property 'AgentPoolProfile.ScaleSetEvictionPolicy' must be empty for AgentPoolProfile.Priority of Regular")
+		}
+	}
+
+	if a.DNSPrefix != "" {
+		if e := common.ValidateDNSPrefix(a.DNSPrefix); e != nil {
+			return e
+		}
+		if len(a.Ports) > 0 {
+			if e := validateUniquePorts(a.Ports, a.Name); e != nil {
+				return e
+			}
+		} else {
+			a.Ports = []int{80, 443, 8080}
+		}
+	} else {
+		if e := validate.Var(a.Ports, "len=0"); e != nil {
+			return errors.Errorf("AgentPoolProfile.Ports must be empty when AgentPoolProfile.DNSPrefix is empty for Orchestrator: %s", string(orchestratorType))
+		}
+	}
+
+	if len(a.DiskSizesGB) > 0 {
+		if e := validate.Var(a.StorageProfile, "eq=StorageAccount|eq=ManagedDisks"); e != nil {
+			return errors.Errorf("property 'StorageProfile' must be set to either '%s' or '%s' when attaching disks", StorageAccount, ManagedDisks)
+		}
+		if e := validate.Var(a.AvailabilityProfile, "eq=VirtualMachineScaleSets|eq=AvailabilitySet"); e != nil {
+			return errors.Errorf("property 'AvailabilityProfile' must be set to either '%s' or '%s' when attaching disks", VirtualMachineScaleSets, AvailabilitySet)
+		}
+		if a.StorageProfile == StorageAccount && (a.AvailabilityProfile == VirtualMachineScaleSets) {
+			return errors.Errorf("VirtualMachineScaleSets does not support storage account attached disks.  Instead specify 'StorageAccount': '%s' or specify AvailabilityProfile '%s'", ManagedDisks, AvailabilitySet)
+		}
+	}
+	return nil
+}
+
+func validateKeyVaultSecrets(secrets []KeyVaultSecrets, requireCertificateStore bool) error {
+	for _, s := range secrets {
+		if len(s.VaultCertificates) == 0 {
+			return errors.New("Valid KeyVaultSecrets must have no empty VaultCertificates")
+		}
+		if s.SourceVault == nil {
+			return errors.New("missing SourceVault in KeyVaultSecrets")
+		}
+		if s.SourceVault.ID == "" {
+			return errors.New("KeyVaultSecrets must have a SourceVault.ID")
+		}
+		for _, c := range s.VaultCertificates {
+			if _, e := url.Parse(c.CertificateURL); e != nil {
+				return errors.Errorf("Certificate url was invalid. received error %s", e)
+			}
+			if e := validateName(c.CertificateStore, "KeyVaultCertificate.CertificateStore"); requireCertificateStore && e != nil {
+				return errors.Errorf("%s for certificates in a WindowsProfile", e)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate ensures that the WindowsProfile is valid
+func (w *WindowsProfile) Validate(orchestratorType string) error {
+	if w.WindowsImageSourceURL != "" {
+		if orchestratorType != DCOS && orchestratorType != Kubernetes {
+			return errors.New("Windows Custom Images are only supported if the Orchestrator Type is DCOS or Kubernetes")
+		}
+	}
+	if e := validate.Var(w.AdminUsername, "required"); e != nil {
+		return errors.New("WindowsProfile.AdminUsername is required, when agent pool specifies windows")
+	}
+	if e := validate.Var(w.AdminPassword, "required"); e != nil {
+		return errors.New("WindowsProfile.AdminPassword is required, when agent pool specifies windows")
+	}
+	if !validatePasswordComplexity(w.AdminUsername, w.AdminPassword) {
+		return errors.New("WindowsProfile.AdminPassword complexity not met. Windows password should contain 3 of the following categories - uppercase letters(A-Z), lowercase(a-z) letters, digits(0-9), special characters (~!@#$%^&*_-+=`|\\(){}[]:;<>,.?/')")
+	}
+	return validateKeyVaultSecrets(w.Secrets, true)
+}
+
+func validatePasswordComplexity(name string, password string) (out bool) {
+
+	if strings.EqualFold(name, password) {
+		return false
+	}
+
+	if len(password) == 0 {
+		return false
+	}
+
+	hits := 0
+	if regexp.MustCompile(`[0-9]+`).MatchString(password) {
+		hits++
+	}
+	if regexp.MustCompile(`[A-Z]+`).MatchString(password) {
+		hits++
+	}
+	if regexp.MustCompile(`[a-z]`).MatchString(password) {
+		hits++
+	}
+	if regexp.MustCompile(`[~!@#\$%\^&\*_\-\+=\x60\|\(\){}\[\]:;"'<>,\.\?/]+`).MatchString(password) {
+		hits++
+	}
+	return hits > 2
+}
+
+// Validate validates the KubernetesConfig
+func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows bool) error {
+	// number of minimum retries allowed for kubelet to post node status
+	const minKubeletRetries = 4
+
+	if k.ClusterSubnet != "" {
+		// the IPv6 CIDR of a dual-stack cluster subnet is validated with the other dual-stack settings
+		_, subnet, err := net.ParseCIDR(strings.Split(k.ClusterSubnet, ",")[0])
+		if err != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' is an invalid subnet", k.ClusterSubnet)
+		}
+
+		if k.NetworkPlugin == "azure" {
+			ones, bits := subnet.Mask.Size()
+			if bits-ones <= 8 {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' must reserve at least 9 bits for nodes", k.ClusterSubnet)
+			}
+		}
+	}
+
+	if k.DockerBridgeSubnet != "" {
+		_, _, err := net.ParseCIDR(k.DockerBridgeSubnet)
+		if err != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet '%s' is an invalid subnet", k.DockerBridgeSubnet)
+		}
+	}
+
+	if k.MaxPods != 0 {
+		if k.MaxPods < KubernetesMinMaxPods {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.MaxPods '%v' must be at least %v", k.MaxPods, KubernetesMinMaxPods)
+		}
+	}
+
+	if e := k.validateInflightLimits(); e != nil {
+		return e
+	}
+
+	if e := k.validateRequestTimeouts(); e != nil {
+		return e
+	}
+
+	if e := k.validateWatchCacheSizes(); e != nil {
+		return e
+	}
+
+	if e := k.validateOIDCConfig(); e != nil {
+		return e
+	}
+
+	if e := k.validateWebhookTokenAuth(); e != nil {
+		return e
+	}
+
+	if e := k.validateAuditWebhook(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateKubeConfigNames(); e != nil {
+		return e
+	}
+
+	if e := k.validateAdmissionWebhooks(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateDefaultStorageClass(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateFeatureGates(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateSchedulerPolicy(); e != nil {
+		return e
+	}
+
+	if e := k.validateContainerLogRotation(); e != nil {
+		return e
+	}
+
+	if e := k.validateRegistryMirrors(); e != nil {
+		return e
+	}
+
+	if e := k.validatePodMTU(); e != nil {
+		return e
+	}
+
+	if e := k.validateTLSConfig(); e != nil {
+		return e
+	}
+
+	if e := k.validateKubeletHealthz(); e != nil {
+		return e
+	}
+
+	if k.KubeletConfig != nil {
+		if _, ok := k.KubeletConfig["--node-status-update-frequency"]; ok {
+			val := k.KubeletConfig["--node-status-update-frequency"]
+			_, err := time.ParseDuration(val)
+			if err != nil {
+				return errors.Errorf("--node-status-update-frequency '%s' is not a valid duration", val)
+			}
+		}
+	}
+
+	if e := validateKubeletThresholds(k.KubeletConfig); e != nil {
+		return e
+	}
+
+	if e := k.validateNodeMonitorTimings(); e != nil {
+		return e
+	}
+
+	if _, ok := k.ControllerManagerConfig["--node-monitor-grace-period"]; ok {
+		_, err := time.ParseDuration(k.ControllerManagerConfig["--node-monitor-grace-period"])
+		if err != nil {
+			return errors.Errorf("--node-monitor-grace-period '%s' is not a valid duration", k.ControllerManagerConfig["--node-monitor-grace-period"])
+		}
+	}
+
+	if k.KubeletConfig != nil {
+		if _, ok := k.KubeletConfig["--node-status-update-frequency"]; ok {
+			gracePeriod, ok := k.ControllerManagerConfig["--node-monitor-grace-period"]
+			if k.NodeMonitorGracePeriod != "" {
+				gracePeriod, ok = k.NodeMonitorGracePeriod, true
+			}
+			if ok {
+				nodeStatusUpdateFrequency, _ := time.ParseDuration(k.KubeletConfig["--node-status-update-frequency"])
+				ctrlMgrNodeMonitorGracePeriod, _ := time.ParseDuration(gracePeriod)
+				kubeletRetries := ctrlMgrNodeMonitorGracePeriod.Seconds() / nodeStatusUpdateFrequency.Seconds()
+				if kubeletRetries < minKubeletRetries {
+					return errors.Errorf("acs-engine requires that --node-monitor-grace-period(%f)s be larger than nodeStatusUpdateFrequency(%f)s by at least a factor of %d; ", ctrlMgrNodeMonitorGracePeriod.Seconds(), nodeStatusUpdateFrequency.Seconds(), minKubeletRetries)
+				}
+			}
+		}
+		// Re-enable this unit test if --non-masquerade-cidr is re-introduced
+		/*if _, ok := k.KubeletConfig["--non-masquerade-cidr"]; ok {
+			if _, _, err := net.ParseCIDR(k.KubeletConfig["--non-masquerade-cidr"]); err != nil {
+				return errors.Errorf("--non-masquerade-cidr kubelet config '%s' is an invalid CIDR string", k.KubeletConfig["--non-masquerade-cidr"])
+			}
+		}*/
+	}
+
+	if _, ok := k.ControllerManagerConfig["--pod-eviction-timeout"]; ok {
+		_, err := time.ParseDuration(k.ControllerManagerConfig["--pod-eviction-timeout"])
+		if err != nil {
+			return errors.Errorf("--pod-eviction-timeout '%s' is not a valid duration", k.ControllerManagerConfig["--pod-eviction-timeout"])
+		}
+	}
+
+	if _, ok := k.ControllerManagerConfig["--node-monitor-period"]; ok {
+		_, err := time.ParseDuration(k.ControllerManagerConfig["--node-monitor-period"])
+		if err != nil {
+			return errors.Errorf("--node-monitor-period '%s' is not a valid duration", k.ControllerManagerConfig["--node-monitor-period"])
+		}
+	}
+
+	if _, ok := k.ControllerManagerConfig["--route-reconciliation-period"]; ok {
+		_, err := time.ParseDuration(k.ControllerManagerConfig["--route-reconciliation-period"])
+		if err != nil {
+			return errors.Errorf("--route-reconciliation-period '%s' is not a valid duration", k.ControllerManagerConfig["--route-reconciliation-period"])
+		}
+	}
+
+	if k.DNSServiceIP != "" || k.ServiceCidr != "" {
+		if k.DNSServiceIP == "" {
+			return errors.New("OrchestratorProfile.KubernetesConfig.ServiceCidr must be specified when DNSServiceIP is")
+		}
+		if k.ServiceCidr == "" {
+			return errors.New("OrchestratorProfile.KubernetesConfig.DNSServiceIP must be specified when ServiceCidr is")
+		}
+
+		dnsIP := net.ParseIP(k.DNSServiceIP)
+		if dnsIP == nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' is an invalid IP address", k.DNSServiceIP)
+		}
+
+		// the DNS service keeps its IPv4 cluster IP in a dual-stack cluster
+		_, serviceCidr, err := net.ParseCIDR(strings.Split(k.ServiceCidr, ",")[0])
+		if err != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceCidr '%s' is an invalid CIDR subnet", k.ServiceCidr)
+		}
+
+		// Finally validate that the DNS ip is within the subnet
+		if !serviceCidr.Contains(dnsIP) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' is not within the ServiceCidr '%s'", k.DNSServiceIP, k.ServiceCidr)
+		}
+
+		// and that the DNS IP is _not_ the subnet broadcast address
+		broadcast := common.IP4BroadcastAddress(serviceCidr)
+		if dnsIP.Equal(broadcast) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' cannot be the broadcast address of ServiceCidr '%s'", k.DNSServiceIP, k.ServiceCidr)
+		}
+
+		// and that the DNS IP is _not_ the subnet network address
+		if dnsIP.Equal(serviceCidr.IP) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' cannot be the network address of ServiceCidr '%s'", k.DNSServiceIP, k.ServiceCidr)
+		}
+
+		// and that the DNS IP is _not_ the first IP in the service subnet, which is the IP of the kubernetes service
+		firstServiceIP := common.CidrFirstIP(serviceCidr.IP)
+		if firstServiceIP.Equal(dnsIP) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' cannot be the first IP of ServiceCidr '%s', which is reserved for the kubernetes service", k.DNSServiceIP, k.ServiceCidr)
+		}
+	}
+
+	if e := k.validateClusterDomain(); e != nil {
+		return e
+	}
+
+	if e := k.validatePrivateRegistry(hasWindows); e != nil {
+		return e
+	}
+
+	if e := k.validateEgressFirewall(); e != nil {
+		return e
+	}
+
+	// Validate that we have a valid etcd version
+	if e := validateEtcdVersion(k.EtcdVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateEtcdStorageLimitGB(); e != nil {
+		return e
+	}
+
+	if e := k.validateEtcdMaintenance(); e != nil {
+		return e
+	}
+
+	if e := k.validateCloudProviderConfig(); e != nil {
+		return e
+	}
+
+	if e := k.validateCloudProviderBackoffAndRateLimit(); e != nil {
+		return e
+	}
+
+	if k.UseCloudControllerManager != nil && *k.UseCloudControllerManager || k.CustomCcmImage != "" {
+		sv, err := semver.Make(k8sVersion)
+		if err != nil {
+			return errors.Errorf("could not validate version %s", k8sVersion)
+		}
+		minVersion, err := semver.Make("1.8.0")
+		if err != nil {
+			return errors.New("could not validate version")
+		}
+		if sv.LT(minVersion) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.UseCloudControllerManager and OrchestratorProfile.KubernetesConfig.CustomCcmImage not available in kubernetes version %s", k8sVersion)
+		}
+	}
+
+	if k.CustomHyperkubeImage != "" && !imageReferenceRegex.MatchString(k.CustomHyperkubeImage) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.CustomHyperkubeImage '%s' is not a valid container image reference", k.CustomHyperkubeImage)
+	}
+
+	if e := k.validateBootstrapToken(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateKubeletCertRotation(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateKubeProxyDeploymentMode(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateServiceAccountIssuer(k8sVersion); e != nil {
+		return e
+	}
+
+	if e := k.validateNetworkPlugin(); e != nil {
+		return e
+	}
+	if e := k.validateNetworkPluginMode(k8sVersion, hasWindows); e != nil {
+		return e
+	}
+	if e := k.validateNetworkPolicy(k8sVersion, hasWindows); e != nil {
+		return e
+	}
+	if e := k.validateNetworkPluginPlusPolicy(); e != nil {
+		return e
+	}
+	if e := k.validateCustomCNI(hasWindows); e != nil {
+		return e
+	}
+
+	if e := k.validateIPv6DualStack(k8sVersion, hasWindows); e != nil {
+		return e
+	}
+
+	return nil
+}
+
+// validateIPv6DualStack ensures that a dual-stack cluster subnet, and service CIDR, list an IPv4 CIDR followed
+// by an IPv6 one, and that the Kubernetes version and the network plugin support dual-stack networking
+func (k *KubernetesConfig) validateIPv6DualStack(k8sVersion string, hasWindows bool) error {
+	clusterSubnets := strings.Split(k.ClusterSubnet, ",")
+	serviceCidrs := strings.Split(k.ServiceCidr, ",")
+	if len(clusterSubnets) == 1 {
+		if len(serviceCidrs) > 1 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceCidr '%s' can only list an IPv6 CIDR if ClusterSubnet lists one too", k.ServiceCidr)
+		}
+		return nil
+	}
+
+	if len(clusterSubnets) != 2 || !isIPv4CIDR(clusterSubnets[0]) || !isIPv6CIDR(clusterSubnets[1]) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' must be an IPv4 CIDR followed by an IPv6 CIDR, separated by a comma", k.ClusterSubnet)
+	}
+	// the controller manager allocates a /64 of the IPv6 cluster subnet to each node, out of at most 16 bits
+	if _, subnet, _ := net.ParseCIDR(clusterSubnets[1]); !isMaskSizeBetween(subnet, 48, 63) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet IPv6 CIDR '%s' must have a prefix length between 48 and 63, as the nodes are allocated a /64 each", clusterSubnets[1])
+	}
+	if len(serviceCidrs) > 1 {
+		if len(serviceCidrs) != 2 || !isIPv4CIDR(serviceCidrs[0]) || !isIPv6CIDR(serviceCidrs[1]) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceCidr '%s' must be an IPv4 CIDR followed by an IPv6 CIDR, separated by a comma", k.ServiceCidr)
+		}
+		// the API server doesn't allocate the cluster IPs from larger ranges than 20 bits
+		if _, subnet, _ := net.ParseCIDR(serviceCidrs[1]); !isMaskSizeBetween(subnet, 108, 128) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceCidr IPv6 CIDR '%s' must have a prefix length of at least 108", serviceCidrs[1])
+		}
+	}
+
+	// the nodes and control plane are configured with the IPv6DualStack feature gate
+	if introduced := knownFeatureGates["IPv6DualStack"].introduced; !common.IsKubernetesVersionGe(k8sVersion, introduced) {
+		return errors.Errorf("IPv6 dual-stack networking is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", introduced, k8sVersion)
+	}
+	if hasWindows {
+		return errors.New("IPv6 dual-stack networking is not supported with Windows agent pools")
+	}
+	if k.NetworkPlugin != "" && k.NetworkPlugin != "kubenet" && k.NetworkPlugin != "azure" {
+		return errors.Errorf("IPv6 dual-stack networking is only supported with the kubenet and azure network plugins, not %s", k.NetworkPlugin)
+	}
+	// the azure and none network policies are the deprecated ways of choosing the azure and kubenet network plugins
+	if k.NetworkPolicy != "" && k.NetworkPolicy != "azure" && k.NetworkPolicy != "none" {
+		return errors.Errorf("IPv6 dual-stack networking is not supported with the %s network policy", k.NetworkPolicy)
+	}
+	return nil
+}
+
+func isIPv4CIDR(cidr string) bool {
+	ip, _, err := net.ParseCIDR(cidr)
+	return err == nil && ip.To4() != nil
+}
+
+func isIPv6CIDR(cidr string) bool {
+	ip, _, err := net.ParseCIDR(cidr)
+	return err == nil && ip.To4() == nil
+}
+
+func isMaskSizeBetween(subnet *net.IPNet, min, max int) bool {
+	ones, _ := subnet.Mask.Size()
+	return ones >= min && ones <= max
+}
+
+func (k *KubernetesConfig) validateInflightLimits() error {
+	// an apiServerConfig flag is overridden by the corresponding field when both are set
+	for _, limit := range []struct {
+		field string
+		flag  string
+		value int
+	}{
+		{"MaxRequestsInflight", "--max-requests-inflight", k.MaxRequestsInflight},
+		{"MaxMutatingRequestsInflight", "--max-mutating-requests-inflight", k.MaxMutatingRequestsInflight},
+	} {
+		if limit.value < 0 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.%s '%d' must be a positive integer", limit.field, limit.value)
+		}
+		if val, ok := k.APIServerConfig[limit.flag]; ok {
+			i, err := strconv.Atoi(val)
+			if err != nil || i < 1 {
+				return errors.Errorf("apiServerConfig %s '%s' must be a positive integer", limit.flag, val)
+			}
+		}
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateRequestTimeouts() error {
+	if k.RequestTimeout != "" {
+		if d, err := time.ParseDuration(k.RequestTimeout); err != nil || d <= 0 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.RequestTimeout '%s' is not a valid duration, it needs to be a positive duration, e.g. 1m0s", k.RequestTimeout)
+		}
+	}
+	if k.MinRequestTimeout < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.MinRequestTimeout '%d' must be a positive integer", k.MinRequestTimeout)
+	}
+	return nil
+}
+
+// validateWatchCacheSizes ensures that the watch cache sizes are positive, for resources named resource[.group]
+// as the --watch-cache-sizes of the apiserver expects them
+func (k *KubernetesConfig) validateWatchCacheSizes() error {
+	resources := make([]string, 0, len(k.WatchCacheSizes))
+	for resource := range k.WatchCacheSizes {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		if !watchCacheResourceRegex.MatchString(resource) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.WatchCacheSizes resource '%s' is not a valid resource, it needs to be a lowercase resource[.group], e.g. pods or deployments.apps", resource)
+		}
+		if size := k.WatchCacheSizes[resource]; size < 1 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.WatchCacheSizes '%d' of resource '%s' must be a positive integer", size, resource)
+		}
+	}
+	return nil
+}
+
+func (k *KubernetesConfig) validateOIDCConfig() error {
+	oidc := k.OIDCConfig
+	if oidc == nil {
+		return nil
+	}
+	// the API server only fetches the signing keys of the provider over TLS
+	if u, err := url.Parse(oidc.IssuerURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.OIDCConfig.IssuerURL '%s' must be an https URL", oidc.IssuerURL)
+	}
+	if oidc.ClientID == "" {
+		return errors.New("OrchestratorProfile.KubernetesConfig.OIDCConfig.ClientID must be specified")
 	}
 	return nil
 }
 
-func validateVMSS(o *OrchestratorProfile, isUpdate bool, storageProfile string) error {
-	if o.OrchestratorType == Kubernetes {
-		version := common.RationalizeReleaseAndVersion(
-			o.OrchestratorType,
-			o.OrchestratorRelease,
-			o.OrchestratorVersion,
-			isUpdate,
-			false)
-		if version == "" {
-			return errors.Errorf("the following OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of acs-engine", o.OrchestratorType, o.OrchestratorRelease, o.OrchestratorVersion)
+// validateKubeConfigNames ensures the cluster, context and user names of the generated kubeconfig can be
+// written unquoted in it
+func (k *KubernetesConfig) validateKubeConfigNames() error {
+	for _, name := range []struct {
+		field string
+		value string
+	}{
+		{"KubeConfigClusterName", k.KubeConfigClusterName},
+		{"KubeConfigContextName", k.KubeConfigContextName},
+		{"KubeConfigUserName", k.KubeConfigUserName},
+	} {
+		if name.value != "" && !kubeConfigNameRegex.MatchString(name.value) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.%s '%s' is invalid, it must match %s", name.field, name.value, kubeConfigNameFormat)
 		}
+	}
+	return nil
+}
 
-		sv, err := semver.Make(version)
-		if err != nil {
-			return errors.Errorf("could not validate version %s", version)
-		}
-		minVersion, err := semver.Make("1.10.0")
-		if err != nil {
-			return errors.New("could not validate version")
-		}
-		if sv.LT(minVersion) {
-			return errors.Errorf("VirtualMachineScaleSets are only available in Kubernetes version %s or greater. Please set \"orchestratorVersion\" to %s or above", minVersion.String(), minVersion.String())
-		}
-		// validation for instanceMetadata using VMSS with Kubernetes
-		minVersion, err = semver.Make("1.10.2")
-		if err != nil {
-			return errors.New("could not validate version")
-		}
-		if o.KubernetesConfig != nil && o.KubernetesConfig.UseInstanceMetadata != nil {
-			if *o.KubernetesConfig.UseInstanceMetadata && sv.LT(minVersion) {
-				return errors.Errorf("VirtualMachineScaleSets with instance metadata is supported for Kubernetes version %s or greater. Please set \"useInstanceMetadata\": false in \"kubernetesConfig\" or set \"orchestratorVersion\" to %s or above", minVersion.String(), minVersion.String())
-			}
+// validateWebhookTokenAuth ensures that the API server sends the tokens to review to the webhook over TLS
+func (k *KubernetesConfig) validateWebhookTokenAuth() error {
+	webhook := k.WebhookTokenAuth
+	if webhook == nil {
+		return nil
+	}
+	if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.URL '%s' must be an https URL", webhook.URL)
+	}
+	if webhook.CACertificate != "" {
+		if block, _ := pem.Decode([]byte(webhook.CACertificate)); block == nil || block.Type != "CERTIFICATE" {
+			return errors.New("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.CACertificate must be a PEM encoded certificate")
 		}
-		if storageProfile == StorageAccount {
-			return errors.Errorf("VirtualMachineScaleSets does not support %s disks.  Please specify \"storageProfile\": \"%s\" (recommended) or \"availabilityProfile\": \"%s\"", StorageAccount, ManagedDisks, AvailabilitySet)
+	}
+	if webhook.CacheTTL != "" {
+		if ttl, err := time.ParseDuration(webhook.CacheTTL); err != nil || ttl < 0 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.CacheTTL '%s' is not a valid duration, e.g. 2m", webhook.CacheTTL)
 		}
 	}
 	return nil
 }
 
-func (a *AgentPoolProfile) validateWindows(o *OrchestratorProfile, w *WindowsProfile, isUpdate bool) error {
-	switch o.OrchestratorType {
-	case DCOS:
-	case Swarm:
-	case SwarmMode:
-	case Kubernetes:
-		version := common.RationalizeReleaseAndVersion(
-			o.OrchestratorType,
-			o.OrchestratorRelease,
-			o.OrchestratorVersion,
-			isUpdate,
-			true)
-		if version == "" {
-			return errors.Errorf("Orchestrator %s version %s does not support Windows", o.OrchestratorType, o.OrchestratorVersion)
+// validateAuditWebhook ensures that the API server sends the audit events to the webhook over TLS, and that the
+// batch settings are usable by the batch mode of the Kubernetes version
+func (k *KubernetesConfig) validateAuditWebhook(k8sVersion string) error {
+	webhook := k.AuditWebhook
+	if webhook == nil {
+		return nil
+	}
+	if !common.IsKubernetesVersionGe(k8sVersion, "1.9.0") {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.AuditWebhook is only available in Kubernetes version 1.9.0 or greater; unable to validate for Kubernetes version %s", k8sVersion)
+	}
+	if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.AuditWebhook.URL '%s' must be an https URL", webhook.URL)
+	}
+	if webhook.CACertificate != "" {
+		if block, _ := pem.Decode([]byte(webhook.CACertificate)); block == nil || block.Type != "CERTIFICATE" {
+			return errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.CACertificate must be a PEM encoded certificate")
+		}
+	}
+	switch webhook.Mode {
+	case "", "batch":
+	case "blocking":
+		if webhook.BatchBufferSize != 0 || webhook.BatchMaxSize != 0 || webhook.BatchMaxWait != "" {
+			return errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook batch settings are only used in the batch mode, not the blocking mode")
 		}
 	default:
-		return errors.Errorf("Orchestrator %s does not support Windows", o.OrchestratorType)
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.AuditWebhook.Mode '%s' must be batch or blocking", webhook.Mode)
 	}
-	if w != nil {
-		if e := w.Validate(o.OrchestratorType); e != nil {
-			return e
+	if webhook.BatchBufferSize < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchBufferSize '%d' must not be negative", webhook.BatchBufferSize)
+	}
+	if webhook.BatchMaxSize < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchMaxSize '%d' must not be negative", webhook.BatchMaxSize)
+	}
+	if webhook.BatchBufferSize != 0 && webhook.BatchMaxSize > webhook.BatchBufferSize {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchMaxSize '%d' must not be larger than the BatchBufferSize '%d'", webhook.BatchMaxSize, webhook.BatchBufferSize)
+	}
+	if webhook.BatchMaxWait != "" {
+		if wait, err := time.ParseDuration(webhook.BatchMaxWait); err != nil || wait <= 0 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchMaxWait '%s' is not a valid duration, e.g. 30s", webhook.BatchMaxWait)
 		}
-	} else {
-		return errors.New("WindowsProfile is required when the cluster definition contains Windows agent pool(s)")
 	}
 	return nil
 }
 
-func (a *AgentPoolProfile) validateOrchestratorSpecificProperties(orchestratorType string) error {
+// admissionWebhookCAInjectionAnnotations are the annotations with which cert-manager injects the CA bundle
+// of the webhooks of a configuration, which then don't need one in their manifest
+var admissionWebhookCAInjectionAnnotations = []string{
+	"cert-manager.io/inject-ca-from",
+	"cert-manager.io/inject-ca-from-secret",
+	"cert-manager.io/inject-apiserver-ca",
+}
 
-	// for Kubernetes, we don't support AgentPoolProfile.DNSPrefix
-	if orchestratorType == Kubernetes {
-		if e := validate.Var(a.DNSPrefix, "len=0"); e != nil {
-			return errors.New("AgentPoolProfile.DNSPrefix must be empty for Kubernetes")
-		}
-		if e := validate.Var(a.Ports, "len=0"); e != nil {
-			return errors.New("AgentPoolProfile.Ports must be empty for Kubernetes")
-		}
-		if validate.Var(a.ScaleSetPriority, "eq=Regular") == nil && validate.Var(a.ScaleSetEvictionPolicy, "len=0") != nil {
-			return errors.New("property 'AgentPoolProfile.ScaleSetEvictionPolicy' must be empty for AgentPoolProfile.Priority of Regular")
-		}
-	}
+// admissionWebhookConfiguration holds the fields of a validating or mutating webhook configuration which
+// are validated
+type admissionWebhookConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name        string            `json:"name"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Webhooks []struct {
+		Name         string `json:"name"`
+		ClientConfig struct {
+			URL      string      `json:"url"`
+			Service  interface{} `json:"service"`
+			CABundle string      `json:"caBundle"`
+		} `json:"clientConfig"`
+	} `json:"webhooks"`
+}
 
-	if a.DNSPrefix != "" {
-		if e := common.ValidateDNSPrefix(a.DNSPrefix); e != nil {
-			return e
+// validateAdmissionWebhooks ensures that the admission webhooks have unique names, and that their manifest
+// is a single validating or mutating webhook configuration supported by the Kubernetes version, whose
+// webhooks either have a CA bundle or reference one injected by cert-manager
+func (k *KubernetesConfig) validateAdmissionWebhooks(k8sVersion string) error {
+	names := map[string]bool{}
+	for _, webhook := range k.AdmissionWebhooks {
+		if !labelValueRegex.MatchString(webhook.Name) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks name '%s' is invalid, it must match %s", webhook.Name, labelValueFormat)
 		}
-		if len(a.Ports) > 0 {
-			if e := validateUniquePorts(a.Ports, a.Name); e != nil {
-				return e
-			}
-		} else {
-			a.Ports = []int{80, 443, 8080}
-		}
-	} else {
-		if e := validate.Var(a.Ports, "len=0"); e != nil {
-			return errors.Errorf("AgentPoolProfile.Ports must be empty when AgentPoolProfile.DNSPrefix is empty for Orchestrator: %s", string(orchestratorType))
+		if names[webhook.Name] {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks name '%s' is duplicated", webhook.Name)
 		}
-	}
+		names[webhook.Name] = true
 
-	if len(a.DiskSizesGB) > 0 {
-		if e := validate.Var(a.StorageProfile, "eq=StorageAccount|eq=ManagedDisks"); e != nil {
-			return errors.Errorf("property 'StorageProfile' must be set to either '%s' or '%s' when attaching disks", StorageAccount, ManagedDisks)
+		manifest := strings.TrimPrefix(webhook.Manifest, "---\n")
+		if strings.Contains("\n"+manifest, "\n---") {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' manifest must hold a single webhook configuration", webhook.Name)
 		}
-		if e := validate.Var(a.AvailabilityProfile, "eq=VirtualMachineScaleSets|eq=AvailabilitySet"); e != nil {
-			return errors.Errorf("property 'AvailabilityProfile' must be set to either '%s' or '%s' when attaching disks", VirtualMachineScaleSets, AvailabilitySet)
+		var config admissionWebhookConfiguration
+		if err := yaml.Unmarshal([]byte(manifest), &config); err != nil {
+			return errors.Wrapf(err, "OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' manifest is not a valid YAML manifest", webhook.Name)
 		}
-		if a.StorageProfile == StorageAccount && (a.AvailabilityProfile == VirtualMachineScaleSets) {
-			return errors.Errorf("VirtualMachineScaleSets does not support storage account attached disks.  Instead specify 'StorageAccount': '%s' or specify AvailabilityProfile '%s'", ManagedDisks, AvailabilitySet)
+		if config.Kind != "ValidatingWebhookConfiguration" && config.Kind != "MutatingWebhookConfiguration" {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' manifest kind '%s' must be ValidatingWebhookConfiguration or MutatingWebhookConfiguration", webhook.Name, config.Kind)
 		}
-	}
-	return nil
-}
-
-func validateKeyVaultSecrets(secrets []KeyVaultSecrets, requireCertificateStore bool) error {
-	for _, s := range secrets {
-		if len(s.VaultCertificates) == 0 {
-			return errors.New("Valid KeyVaultSecrets must have no empty VaultCertificates")
+		switch config.APIVersion {
+		case "admissionregistration.k8s.io/v1beta1":
+			if !common.IsKubernetesVersionGe(k8sVersion, "1.9.0") {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' requires Kubernetes 1.9.0 or greater, the version is %s", webhook.Name, k8sVersion)
+			}
+		case "admissionregistration.k8s.io/v1":
+			if !common.IsKubernetesVersionGe(k8sVersion, "1.16.0") {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' apiVersion admissionregistration.k8s.io/v1 requires Kubernetes 1.16.0 or greater, the version is %s", webhook.Name, k8sVersion)
+			}
+		default:
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' manifest apiVersion '%s' must be admissionregistration.k8s.io/v1beta1 or admissionregistration.k8s.io/v1", webhook.Name, config.APIVersion)
 		}
-		if s.SourceVault == nil {
-			return errors.New("missing SourceVault in KeyVaultSecrets")
+		if config.Metadata.Name == "" {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' manifest must have a metadata.name", webhook.Name)
 		}
-		if s.SourceVault.ID == "" {
-			return errors.New("KeyVaultSecrets must have a SourceVault.ID")
+		if len(config.Webhooks) == 0 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' manifest must have at least one webhook", webhook.Name)
 		}
-		for _, c := range s.VaultCertificates {
-			if _, e := url.Parse(c.CertificateURL); e != nil {
-				return errors.Errorf("Certificate url was invalid. received error %s", e)
+		injected := false
+		for _, annotation := range admissionWebhookCAInjectionAnnotations {
+			if config.Metadata.Annotations[annotation] != "" {
+				injected = true
 			}
-			if e := validateName(c.CertificateStore, "KeyVaultCertificate.CertificateStore"); requireCertificateStore && e != nil {
-				return errors.Errorf("%s for certificates in a WindowsProfile", e)
+		}
+		for _, w := range config.Webhooks {
+			if w.Name == "" {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' manifest webhooks must have a name", webhook.Name)
+			}
+			if w.ClientConfig.URL == "" && w.ClientConfig.Service == nil {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' webhook '%s' must have a clientConfig url or service", webhook.Name, w.Name)
+			}
+			if w.ClientConfig.CABundle == "" {
+				if !injected {
+					return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' webhook '%s' must have a clientConfig caBundle, unless the configuration has one of the annotations %s", webhook.Name, w.Name, strings.Join(admissionWebhookCAInjectionAnnotations, ", "))
+				}
+				continue
+			}
+			ca, err := base64.StdEncoding.DecodeString(w.ClientConfig.CABundle)
+			if err != nil {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' webhook '%s' clientConfig caBundle must be base64 encoded", webhook.Name, w.Name)
+			}
+			if block, _ := pem.Decode(ca); block == nil || block.Type != "CERTIFICATE" {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks '%s' webhook '%s' clientConfig caBundle must be a PEM encoded certificate", webhook.Name, w.Name)
 			}
 		}
 	}
 	return nil
 }
 
-// Validate ensures that the WindowsProfile is valid
-func (w *WindowsProfile) Validate(orchestratorType string) error {
-	if w.WindowsImageSourceURL != "" {
-		if orchestratorType != DCOS && orchestratorType != Kubernetes {
-			return errors.New("Windows Custom Images are only supported if the Orchestrator Type is DCOS or Kubernetes")
-		}
+// validateDefaultStorageClass ensures that the SKU of the default StorageClass is supported by its provisioner,
+// and that the Kubernetes version supports its volume binding mode
+func (k *KubernetesConfig) validateDefaultStorageClass(k8sVersion string) error {
+	sc := k.DefaultStorageClass
+	if sc == nil {
+		return nil
 	}
-	if e := validate.Var(w.AdminUsername, "required"); e != nil {
-		return errors.New("WindowsProfile.AdminUsername is required, when agent pool specifies windows")
+	if !isValueInList(sc.Provisioner, StorageClassProvisionerValues[:]) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.Provisioner '%s' is not supported, the supported provisioners are %s", sc.Provisioner, strings.Join(StorageClassProvisionerValues[1:], ", "))
 	}
-	if e := validate.Var(w.AdminPassword, "required"); e != nil {
-		return errors.New("WindowsProfile.AdminPassword is required, when agent pool specifies windows")
+	skus := AzureDiskStorageClassSKUValues[:]
+	if sc.Provisioner == "kubernetes.io/azure-file" {
+		skus = AzureFileStorageClassSKUValues[:]
 	}
-	if !validatePasswordComplexity(w.AdminUsername, w.AdminPassword) {
-		return errors.New("WindowsProfile.AdminPassword complexity not met. Windows password should contain 3 of the following categories - uppercase letters(A-Z), lowercase(a-z) letters, digits(0-9), special characters (~!@#$%^&*_-+=`|\\(){}[]:;<>,.?/')")
+	if !isValueInList(sc.SKU, skus) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.SKU '%s' is not supported by the provisioner, the supported SKUs are %s", sc.SKU, strings.Join(skus[1:], ", "))
 	}
-	return validateKeyVaultSecrets(w.Secrets, true)
+	if !isValueInList(sc.ReclaimPolicy, StorageClassReclaimPolicyValues[:]) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.ReclaimPolicy '%s' is not supported, the supported reclaim policies are %s", sc.ReclaimPolicy, strings.Join(StorageClassReclaimPolicyValues[1:], ", "))
+	}
+	if !isValueInList(sc.VolumeBindingMode, StorageClassVolumeBindingModeValues[:]) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.VolumeBindingMode '%s' is not supported, the supported volume binding modes are %s", sc.VolumeBindingMode, strings.Join(StorageClassVolumeBindingModeValues[1:], ", "))
+	}
+	if sc.VolumeBindingMode == "WaitForFirstConsumer" && !common.IsKubernetesVersionGe(k8sVersion, "1.10.0") {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.VolumeBindingMode WaitForFirstConsumer requires Kubernetes 1.10.0 or greater, the version is %s", k8sVersion)
+	}
+	return nil
 }
 
-func validatePasswordComplexity(name string, password string) (out bool) {
+// validateFeatureGates ensures that the feature gates of the cluster are known to its Kubernetes version
+func (k *KubernetesConfig) validateFeatureGates(k8sVersion string) error {
+	gates := make([]string, 0, len(k.FeatureGates))
+	for gate := range k.FeatureGates {
+		gates = append(gates, gate)
+	}
+	sort.Strings(gates)
+	for _, gate := range gates {
+		versions, ok := knownFeatureGates[gate]
+		if !ok || !common.IsKubernetesVersionGe(k8sVersion, versions.introduced) ||
+			(versions.removed != "" && common.IsKubernetesVersionGe(k8sVersion, versions.removed)) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.FeatureGates '%s' is not a known feature gate of Kubernetes %s", gate, k8sVersion)
+		}
+	}
+	return nil
+}
 
-	if strings.EqualFold(name, password) {
-		return false
+func isValueInList(value string, values []string) bool {
+	for _, v := range values {
+		if value == v {
+			return true
+		}
 	}
+	return false
+}
 
-	if len(password) == 0 {
-		return false
+// validateServiceAccountIssuer ensures that the issuer of the bound service account tokens is a URL the
+// pods can discover, and that the Kubernetes version supports projecting the tokens into the pods
+func (k *KubernetesConfig) validateServiceAccountIssuer(k8sVersion string) error {
+	if k.ServiceAccountIssuer == "" {
+		if len(k.APIAudiences) > 0 {
+			return errors.New("OrchestratorProfile.KubernetesConfig.APIAudiences can only be specified with OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer")
+		}
+		return nil
+	}
+	if u, err := url.Parse(k.ServiceAccountIssuer); err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer '%s' must be an https URL without query or fragment", k.ServiceAccountIssuer)
+	}
+	for _, audience := range k.APIAudiences {
+		if strings.TrimSpace(audience) == "" || strings.Contains(audience, ",") {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.APIAudiences has an invalid audience '%s'", audience)
+		}
 	}
 
-	hits := 0
-	if regexp.MustCompile(`[0-9]+`).MatchString(password) {
-		hits++
+	sv, err := semver.Make(k8sVersion)
+	if err != nil {
+		return errors.Errorf("could not validate version %s", k8sVersion)
 	}
-	if regexp.MustCompile(`[A-Z]+`).MatchString(password) {
-		hits++
+	minVersion, err := semver.Make("1.12.0")
+	if err != nil {
+		return errors.New("could not validate version")
 	}
-	if regexp.MustCompile(`[a-z]`).MatchString(password) {
-		hits++
+	if sv.LT(minVersion) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", minVersion.String(), k8sVersion)
 	}
-	if regexp.MustCompile(`[~!@#\$%\^&\*_\-\+=\x60\|\(\){}\[\]:;"'<>,\.\?/]+`).MatchString(password) {
-		hits++
+	return nil
+}
+
+func (k *KubernetesConfig) validateSchedulerPolicy() error {
+	if k.SchedulerPolicy == "" {
+		return nil
 	}
-	return hits > 2
+	var policy struct {
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal([]byte(k.SchedulerPolicy), &policy); err != nil {
+		return errors.Wrap(err, "OrchestratorProfile.KubernetesConfig.SchedulerPolicy is not a valid JSON document")
+	}
+	if policy.Kind != "Policy" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.SchedulerPolicy has kind '%s', it needs to be a scheduler Policy", policy.Kind)
+	}
+	return nil
 }
 
-// Validate validates the KubernetesConfig
-func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows bool) error {
-	// number of minimum retries allowed for kubelet to post node status
-	const minKubeletRetries = 4
+func (k *KubernetesConfig) validateContainerLogRotation() error {
+	if k.ContainerLogMaxSize != "" && !containerLogSizeRegex.MatchString(k.ContainerLogMaxSize) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ContainerLogMaxSize '%s' is not a valid size, it needs to be a whole number of Ki, Mi or Gi, e.g. 50Mi", k.ContainerLogMaxSize)
+	}
+	// the kubelet keeps at least the log file being written and a rotated one
+	if k.ContainerLogMaxFiles != 0 && k.ContainerLogMaxFiles < 2 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ContainerLogMaxFiles %d needs to be at least 2", k.ContainerLogMaxFiles)
+	}
+	return nil
+}
 
-	if k.ClusterSubnet != "" {
-		_, subnet, err := net.ParseCIDR(k.ClusterSubnet)
-		if err != nil {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' is an invalid subnet", k.ClusterSubnet)
+// validateRegistryMirrors ensures that the registry mirrors are distinct http or https URLs of registries,
+// without a path, as the container runtimes expect them
+func (k *KubernetesConfig) validateRegistryMirrors() error {
+	mirrors := map[string]bool{}
+	for _, mirror := range k.RegistryMirrors {
+		u, err := url.Parse(mirror)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.RegistryMirrors '%s' is not a valid registry mirror, it needs to be an http or https URL without a path, e.g. https://mirror.example.com", mirror)
 		}
-
-		if k.NetworkPlugin == "azure" {
-			ones, bits := subnet.Mask.Size()
-			if bits-ones <= 8 {
-				return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' must reserve at least 9 bits for nodes", k.ClusterSubnet)
-			}
+		if mirrors[mirror] {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.RegistryMirrors '%s' is specified more than once", mirror)
 		}
+		mirrors[mirror] = true
 	}
+	return nil
+}
 
-	if k.DockerBridgeSubnet != "" {
-		_, _, err := net.ParseCIDR(k.DockerBridgeSubnet)
-		if err != nil {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DockerBridgeSubnet '%s' is an invalid subnet", k.DockerBridgeSubnet)
-		}
+// validatePodMTU ensures that the MTU of the interface of the pods is within the MTUs of the Azure virtual
+// networks, and that the network plugin of the cluster configures the interface of the pods
+func (k *KubernetesConfig) validatePodMTU() error {
+	if k.PodMTU == 0 {
+		return nil
 	}
-
-	if k.MaxPods != 0 {
-		if k.MaxPods < KubernetesMinMaxPods {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.MaxPods '%v' must be at least %v", k.MaxPods, KubernetesMinMaxPods)
-		}
+	if k.PodMTU < MinPodMTU || k.PodMTU > MaxPodMTU {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.PodMTU %d needs to be between %d and %d", k.PodMTU, MinPodMTU, MaxPodMTU)
 	}
-
-	if k.KubeletConfig != nil {
-		if _, ok := k.KubeletConfig["--node-status-update-frequency"]; ok {
-			val := k.KubeletConfig["--node-status-update-frequency"]
-			_, err := time.ParseDuration(val)
-			if err != nil {
-				return errors.Errorf("--node-status-update-frequency '%s' is not a valid duration", val)
-			}
-		}
+	if k.NetworkPlugin != "" && k.NetworkPlugin != "kubenet" && k.NetworkPlugin != "azure" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.PodMTU is only supported with the kubenet and azure network plugins, not %s", k.NetworkPlugin)
 	}
-
-	if _, ok := k.ControllerManagerConfig["--node-monitor-grace-period"]; ok {
-		_, err := time.ParseDuration(k.ControllerManagerConfig["--node-monitor-grace-period"])
-		if err != nil {
-			return errors.Errorf("--node-monitor-grace-period '%s' is not a valid duration", k.ControllerManagerConfig["--node-monitor-grace-period"])
-		}
+	// the azure and none network policies are the deprecated ways of choosing the azure and kubenet network plugins
+	if k.NetworkPolicy != "" && k.NetworkPolicy != "azure" && k.NetworkPolicy != "none" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.PodMTU is not supported with the %s network policy, which configures the interface of the pods", k.NetworkPolicy)
 	}
+	return nil
+}
 
-	if k.KubeletConfig != nil {
-		if _, ok := k.KubeletConfig["--node-status-update-frequency"]; ok {
-			if _, ok := k.ControllerManagerConfig["--node-monitor-grace-period"]; ok {
-				nodeStatusUpdateFrequency, _ := time.ParseDuration(k.KubeletConfig["--node-status-update-frequency"])
-				ctrlMgrNodeMonitorGracePeriod, _ := time.ParseDuration(k.ControllerManagerConfig["--node-monitor-grace-period"])
-				kubeletRetries := ctrlMgrNodeMonitorGracePeriod.Seconds() / nodeStatusUpdateFrequency.Seconds()
-				if kubeletRetries < minKubeletRetries {
-					return errors.Errorf("acs-engine requires that --node-monitor-grace-period(%f)s be larger than nodeStatusUpdateFrequency(%f)s by at least a factor of %d; ", ctrlMgrNodeMonitorGracePeriod.Seconds(), nodeStatusUpdateFrequency.Seconds(), minKubeletRetries)
-				}
-			}
-		}
-		// Re-enable this unit test if --non-masquerade-cidr is re-introduced
-		/*if _, ok := k.KubeletConfig["--non-masquerade-cidr"]; ok {
-			if _, _, err := net.ParseCIDR(k.KubeletConfig["--non-masquerade-cidr"]); err != nil {
-				return errors.Errorf("--non-masquerade-cidr kubelet config '%s' is an invalid CIDR string", k.KubeletConfig["--non-masquerade-cidr"])
+// validateTLSConfig ensures that the kubelet and the apiserver know the configured TLS cipher suites and min version
+func (k *KubernetesConfig) validateTLSConfig() error {
+	for _, cipherSuite := range k.TLSCipherSuites {
+		valid := false
+		for _, value := range TLSCipherSuiteValues {
+			if cipherSuite == value {
+				valid = true
+				break
 			}
-		}*/
+		}
+		if !valid {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.TLSCipherSuites '%s' is not a valid cipher suite, valid cipher suites are %s", cipherSuite, strings.Join(TLSCipherSuiteValues[:], ", "))
+		}
 	}
-
-	if _, ok := k.ControllerManagerConfig["--pod-eviction-timeout"]; ok {
-		_, err := time.ParseDuration(k.ControllerManagerConfig["--pod-eviction-timeout"])
-		if err != nil {
-			return errors.Errorf("--pod-eviction-timeout '%s' is not a valid duration", k.ControllerManagerConfig["--pod-eviction-timeout"])
+	for _, value := range TLSMinVersionValues {
+		if k.TLSMinVersion == value {
+			return nil
 		}
 	}
+	return errors.Errorf("OrchestratorProfile.KubernetesConfig.TLSMinVersion '%s' is not a valid TLS version, valid versions are %s", k.TLSMinVersion, strings.Join(TLSMinVersionValues[1:], ", "))
+}
 
-	if _, ok := k.ControllerManagerConfig["--route-reconciliation-period"]; ok {
-		_, err := time.ParseDuration(k.ControllerManagerConfig["--route-reconciliation-period"])
-		if err != nil {
-			return errors.Errorf("--route-reconciliation-period '%s' is not a valid duration", k.ControllerManagerConfig["--route-reconciliation-period"])
-		}
+// validateKubeletHealthz ensures that the health monitor of the nodes can probe the kubelet on its
+// localhost healthz endpoint, as the secure kubelet doesn't serve anonymous requests
+func (k *KubernetesConfig) validateKubeletHealthz() error {
+	if port, ok := k.KubeletConfig["--healthz-port"]; ok && port != KubeletHealthzPort {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeletConfig['--healthz-port'] '%s' is not supported, the kubelet health monitor probes port %s", port, KubeletHealthzPort)
+	}
+	if address, ok := k.KubeletConfig["--healthz-bind-address"]; ok && address != "127.0.0.1" && address != "0.0.0.0" {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeletConfig['--healthz-bind-address'] '%s' is not supported, the kubelet health monitor probes 127.0.0.1", address)
 	}
+	return nil
+}
 
-	if k.DNSServiceIP != "" || k.ServiceCidr != "" {
-		if k.DNSServiceIP == "" {
-			return errors.New("OrchestratorProfile.KubernetesConfig.ServiceCidr must be specified when DNSServiceIP is")
-		}
-		if k.ServiceCidr == "" {
-			return errors.New("OrchestratorProfile.KubernetesConfig.DNSServiceIP must be specified when ServiceCidr is")
-		}
+// validateClusterDomain ensures that the cluster domain is a valid DNS name, which the kubelet
+// config doesn't contradict, as the kubelet and the cluster DNS need to serve the same domain
+func (k *KubernetesConfig) validateClusterDomain() error {
+	if k.ClusterDomain == "" {
+		return nil
+	}
+	if len(k.ClusterDomain) > clusterDomainMaxLength || !clusterDomainRegex.MatchString(k.ClusterDomain) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterDomain '%s' is not a valid DNS domain, it needs to consist of lowercase alphanumeric characters, '-' and '.', e.g. cluster.local", k.ClusterDomain)
+	}
+	if clusterDomain, ok := k.KubeletConfig["--cluster-domain"]; ok && clusterDomain != k.ClusterDomain {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeletConfig['--cluster-domain'] '%s' doesn't match OrchestratorProfile.KubernetesConfig.ClusterDomain '%s'", clusterDomain, k.ClusterDomain)
+	}
+	return nil
+}
 
-		dnsIP := net.ParseIP(k.DNSServiceIP)
-		if dnsIP == nil {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' is an invalid IP address", k.DNSServiceIP)
+// validatePrivateRegistry ensures that the registry host and its credentials are configured together.
+// Only the Linux nodes are given the credentials, so Windows agent pools aren't supported
+func (k *KubernetesConfig) validatePrivateRegistry(hasWindows bool) error {
+	r := k.PrivateRegistry
+	if r == nil {
+		return nil
+	}
+	if r.Server == "" || r.Username == "" || r.Password == "" {
+		return errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry needs a server, a username and a password")
+	}
+	if !registryServerRegex.MatchString(r.Server) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.PrivateRegistry.Server '%s' is not a valid registry host, it needs to be a host name with an optional port, without scheme or path, e.g. myregistry.azurecr.io", r.Server)
+	}
+	// the credentials are given to the provisioning script within single quotes
+	if strings.Contains(r.Username, "'") || strings.Contains(r.Password, "'") {
+		return errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry.Username and Password cannot contain single quotes")
+	}
+	if hasWindows {
+		return errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry is not supported with Windows agent pools")
+	}
+	return nil
+}
+
+// validateEgressFirewall ensures that the egress traffic is routed to a valid IPv4 address of the virtual appliance,
+// and that the address prefixes bypassing it are valid IPv4 CIDRs
+func (k *KubernetesConfig) validateEgressFirewall() error {
+	f := k.EgressFirewall
+	if f == nil {
+		return nil
+	}
+	if ip := net.ParseIP(f.VirtualApplianceIP); ip == nil || ip.To4() == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsMulticast() {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP '%s' is not a valid IPv4 address of a virtual appliance", f.VirtualApplianceIP)
+	}
+	for _, prefix := range f.BypassAddressPrefixes {
+		if ip, _, err := net.ParseCIDR(prefix); err != nil || ip.To4() == nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.EgressFirewall.BypassAddressPrefixes has '%s', it is not a valid IPv4 CIDR", prefix)
 		}
+	}
+	return nil
+}
 
-		_, serviceCidr, err := net.ParseCIDR(k.ServiceCidr)
-		if err != nil {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceCidr '%s' is an invalid CIDR subnet", k.ServiceCidr)
+func (k *KubernetesConfig) validateNodeMonitorTimings() error {
+	for _, timing := range []struct {
+		field string
+		value string
+	}{
+		{"NodeMonitorGracePeriod", k.NodeMonitorGracePeriod},
+		{"NodeMonitorPeriod", k.NodeMonitorPeriod},
+		{"PodEvictionTimeout", k.PodEvictionTimeout},
+	} {
+		if timing.value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(timing.value); err != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.%s '%s' is not a valid duration", timing.field, timing.value)
 		}
+	}
+	return nil
+}
 
-		// Finally validate that the DNS ip is within the subnet
-		if !serviceCidr.Contains(dnsIP) {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' is not within the ServiceCidr '%s'", k.DNSServiceIP, k.ServiceCidr)
+// validateKubeProxyDeploymentMode ensures that kube-proxy is deployed in a known way, which the Kubernetes
+// version supports. The kube-proxy static pods rely on the system-node-critical priority class so that
+// the kubelet never evicts them, and the kube-proxy-daemonset addon cannot customize them
+func (k *KubernetesConfig) validateKubeProxyDeploymentMode(k8sVersion string) error {
+	valid := false
+	for _, mode := range KubeProxyDeploymentModeValues {
+		if k.KubeProxyDeploymentMode == mode {
+			valid = true
+			break
 		}
+	}
+	if !valid {
+		return errors.Errorf("unknown OrchestratorProfile.KubernetesConfig.KubeProxyDeploymentMode %q specified, supported values are %q and %q", k.KubeProxyDeploymentMode, KubeProxyDeploymentModeDaemonSet, KubeProxyDeploymentModeStaticPod)
+	}
+	if k.KubeProxyDeploymentMode != KubeProxyDeploymentModeStaticPod {
+		return nil
+	}
 
-		// and that the DNS IP is _not_ the subnet broadcast address
-		broadcast := common.IP4BroadcastAddress(serviceCidr)
-		if dnsIP.Equal(broadcast) {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' cannot be the broadcast address of ServiceCidr '%s'", k.DNSServiceIP, k.ServiceCidr)
+	sv, err := semver.Make(k8sVersion)
+	if err != nil {
+		return errors.Errorf("could not validate version %s", k8sVersion)
+	}
+	minVersion, err := semver.Make("1.11.0")
+	if err != nil {
+		return errors.New("could not validate version")
+	}
+	if sv.LT(minVersion) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeProxyDeploymentMode %q is only available in Kubernetes version %s or greater; unable to validate for Kubernetes version %s", k.KubeProxyDeploymentMode, minVersion.String(), k8sVersion)
+	}
+	for _, addon := range k.Addons {
+		if addon.Name == "kube-proxy-daemonset" {
+			return errors.Errorf("the kube-proxy-daemonset addon cannot be configured when OrchestratorProfile.KubernetesConfig.KubeProxyDeploymentMode is %q", k.KubeProxyDeploymentMode)
 		}
+	}
+	return nil
+}
 
-		// and that the DNS IP is _not_ the first IP in the service subnet
-		firstServiceIP := common.CidrFirstIP(serviceCidr.IP)
-		if firstServiceIP.Equal(dnsIP) {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' cannot be the first IP of ServiceCidr '%s'", k.DNSServiceIP, k.ServiceCidr)
+func (k *KubernetesConfig) validateBootstrapToken(k8sVersion string) error {
+	if k.BootstrapTokenTTL == "" {
+		if k.BootstrapToken != "" || k.BootstrapTokenExpiration != "" {
+			return errors.New("OrchestratorProfile.KubernetesConfig.BootstrapToken and BootstrapTokenExpiration can only be specified together with BootstrapTokenTTL")
 		}
+		return nil
 	}
 
-	// Validate that we have a valid etcd version
-	if e := validateEtcdVersion(k.EtcdVersion); e != nil {
-		return e
+	sv, err := semver.Make(k8sVersion)
+	if err != nil {
+		return errors.Errorf("could not validate version %s", k8sVersion)
+	}
+	minVersion, err := semver.Make("1.8.0")
+	if err != nil {
+		return errors.New("could not validate version")
+	}
+	if sv.LT(minVersion) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.BootstrapTokenTTL not available in kubernetes version %s", k8sVersion)
 	}
 
-	if k.UseCloudControllerManager != nil && *k.UseCloudControllerManager || k.CustomCcmImage != "" {
-		sv, err := semver.Make(k8sVersion)
-		if err != nil {
-			return errors.Errorf("could not validate version %s", k8sVersion)
-		}
-		minVersion, err := semver.Make("1.8.0")
-		if err != nil {
-			return errors.New("could not validate version")
-		}
-		if sv.LT(minVersion) {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.UseCloudControllerManager and OrchestratorProfile.KubernetesConfig.CustomCcmImage not available in kubernetes version %s", k8sVersion)
+	ttl, err := time.ParseDuration(k.BootstrapTokenTTL)
+	if err != nil {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.BootstrapTokenTTL '%s' is not a valid duration", k.BootstrapTokenTTL)
+	}
+	if ttl < time.Minute {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.BootstrapTokenTTL '%s' must be at least 1m", k.BootstrapTokenTTL)
+	}
+
+	if k.BootstrapToken != "" && !bootstrapTokenRegex.MatchString(k.BootstrapToken) {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.BootstrapToken must match the format '%s'", bootstrapTokenFormat)
+	}
+	if k.BootstrapTokenExpiration != "" {
+		if _, err := time.Parse(time.RFC3339, k.BootstrapTokenExpiration); err != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.BootstrapTokenExpiration '%s' is not a valid RFC3339 timestamp", k.BootstrapTokenExpiration)
 		}
 	}
+	return nil
+}
 
-	if e := k.validateNetworkPlugin(); e != nil {
-		return e
+// validateKubeletCertRotation ensures that the kubelets can renew their certificates, which needs the certificate
+// signing requests of the nodes to be authorized with RBAC
+func (k *KubernetesConfig) validateKubeletCertRotation(k8sVersion string) error {
+	if !helpers.IsTrueBoolPointer(k.EnableKubeletCertRotation) {
+		if k.KubeletCertSigningDuration != "" {
+			return errors.New("OrchestratorProfile.KubernetesConfig.KubeletCertSigningDuration can only be specified together with EnableKubeletCertRotation")
+		}
+		return nil
 	}
-	if e := k.validateNetworkPolicy(k8sVersion, hasWindows); e != nil {
-		return e
+	if !common.IsKubernetesVersionGe(k8sVersion, "1.8.0") {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.EnableKubeletCertRotation is only available in Kubernetes version 1.8.0 or greater; unable to validate for Kubernetes version %s", k8sVersion)
 	}
-	if e := k.validateNetworkPluginPlusPolicy(); e != nil {
-		return e
+	if k.EnableRbac != nil && !*k.EnableRbac {
+		return errors.New("OrchestratorProfile.KubernetesConfig.EnableKubeletCertRotation requires RBAC to authorize the certificate signing requests of the nodes")
+	}
+	if k.KubeletCertSigningDuration != "" {
+		duration, err := time.ParseDuration(k.KubeletCertSigningDuration)
+		if err != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeletCertSigningDuration '%s' is not a valid duration", k.KubeletCertSigningDuration)
+		}
+		if duration < time.Hour {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeletCertSigningDuration '%s' must be at least 1h", k.KubeletCertSigningDuration)
+		}
 	}
-
 	return nil
 }
 
@@ -1210,6 +3344,40 @@ func (k *KubernetesConfig) validateNetworkPlugin() error {
 	return nil
 }
 
+// validateNetworkPluginMode rejects the overlay mode, which isn't supported yet, after ensuring it is only
+// used with the azure network plugin, on Linux-only clusters of a Kubernetes version allocating the node CIDRs it needs
+func (k *KubernetesConfig) validateNetworkPluginMode(k8sVersion string, hasWindows bool) error {
+	valid := false
+	for _, mode := range NetworkPluginModeValues {
+		if k.NetworkPluginMode == mode {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return errors.Errorf("unknown networkPluginMode '%s' specified", k.NetworkPluginMode)
+	}
+	if k.NetworkPluginMode == "" {
+		return nil
+	}
+
+	if k.NetworkPlugin != "azure" {
+		return errors.Errorf("networkPluginMode '%s' requires the azure networkPlugin", k.NetworkPluginMode)
+	}
+	if !common.IsKubernetesVersionGe(k8sVersion, "1.11.0") {
+		return errors.Errorf("networkPluginMode '%s' is only available in Kubernetes version 1.11.0 or greater; unable to validate for Kubernetes version %s", k.NetworkPluginMode, k8sVersion)
+	}
+	if hasWindows {
+		return errors.Errorf("networkPluginMode '%s' is not supported with Windows agent pools", k.NetworkPluginMode)
+	}
+	if strings.Contains(k.ClusterSubnet, ",") {
+		return errors.Errorf("networkPluginMode '%s' is not supported with IPv6 dual-stack networking", k.NetworkPluginMode)
+	}
+	// the overlay IPAM is served by the Azure CNS daemon, which the nodes don't run, and the Azure CNI
+	// version they install predates the overlay mode
+	return errors.Errorf("networkPluginMode '%s' is not supported yet, it requires the Azure CNS daemon and a version of the Azure CNI plugin with overlay support", k.NetworkPluginMode)
+}
+
 func (k *KubernetesConfig) validateNetworkPolicy(k8sVersion string, hasWindows bool) error {
 
 	networkPolicy := k.NetworkPolicy
@@ -1253,6 +3421,34 @@ func (k *KubernetesConfig) validateNetworkPluginPlusPolicy() error {
 	return errors.Errorf("networkPolicy '%s' is not supported with networkPlugin '%s'", config.networkPolicy, config.networkPlugin)
 }
 
+// validateCustomCNI ensures the cni network plugin, which installs no CNI of its own, is given the
+// manifest of the CNI through the data of the cni addon
+func (k *KubernetesConfig) validateCustomCNI(hasWindows bool) error {
+	var addon *KubernetesAddon
+	for i := range k.Addons {
+		if k.Addons[i].Name == "cni" {
+			addon = &k.Addons[i]
+			break
+		}
+	}
+	if k.NetworkPlugin != "cni" {
+		if addon != nil {
+			return errors.Errorf("the cni addon is only used with networkPlugin 'cni', not '%s'", k.NetworkPlugin)
+		}
+		return nil
+	}
+	if hasWindows {
+		return errors.New("networkPlugin 'cni' is not supported with Windows agent pools")
+	}
+	if addon == nil || addon.Data == "" {
+		return errors.New("networkPlugin 'cni' requires the manifest installing the CNI in the data of the cni addon")
+	}
+	if addon.Enabled != nil && !*addon.Enabled {
+		return errors.New("networkPlugin 'cni' requires the cni addon to be enabled")
+	}
+	return nil
+}
+
 func (a *Properties) validateContainerRuntime() error {
 	var containerRuntime string
 
@@ -1285,6 +3481,35 @@ func (a *Properties) validateContainerRuntime() error {
 	return nil
 }
 
+// validateCgroupDriver ensures the cgroup driver shared by the kubelet and the container runtime
+// is supported by the container runtime
+func (a *Properties) validateCgroupDriver() error {
+	k := a.OrchestratorProfile.KubernetesConfig
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes || k == nil {
+		return nil
+	}
+
+	cgroupDriver := k.CgroupDriver
+	if cgroupDriver == "" {
+		cgroupDriver = k.KubeletConfig["--cgroup-driver"]
+	}
+	valid := false
+	for _, driver := range CgroupDriverValues {
+		if cgroupDriver == driver {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return errors.Errorf("unknown cgroupDriver %q specified, supported values are %q and %q", cgroupDriver, CgroupDriverCgroupfs, CgroupDriverSystemd)
+	}
+
+	if cgroupDriver == CgroupDriverSystemd && (k.ContainerRuntime == "clear-containers" || k.ContainerRuntime == "kata-containers") {
+		return errors.Errorf("cgroupDriver %q is not supported with containerRuntime %q", cgroupDriver, k.ContainerRuntime)
+	}
+	return nil
+}
+
 func validateName(name string, label string) error {
 	if name == "" {
 		return errors.Errorf("%s must be a non-empty value", label)
@@ -1362,6 +3587,231 @@ func validateEtcdVersion(etcdVersion string) error {
 	return errors.Errorf("Invalid etcd version \"%s\", please use one of the following versions: %s", etcdVersion, etcdValidVersions)
 }
 
+// validateEtcdStorageLimitGB ensures that the storage backend quota of etcd is within the sizes etcd
+// supports, and fits on the etcd disk of the masters
+func (k *KubernetesConfig) validateEtcdStorageLimitGB() error {
+	// 0 is a valid etcdStorageLimitGB that maps to DefaultEtcdStorageLimitGB
+	if k.EtcdStorageLimitGB == 0 {
+		return nil
+	}
+	if strings.HasPrefix(k.EtcdVersion, "2.") {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB requires etcd 3.0.0 or greater, not %s", k.EtcdVersion)
+	}
+	if k.EtcdStorageLimitGB < MinEtcdStorageLimitGB || k.EtcdStorageLimitGB > MaxEtcdStorageLimitGB {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB %d is out of range, it must be between %d and %d", k.EtcdStorageLimitGB, MinEtcdStorageLimitGB, MaxEtcdStorageLimitGB)
+	}
+	if k.EtcdDiskSizeGB != "" {
+		if diskSize, err := strconv.Atoi(k.EtcdDiskSizeGB); err == nil && k.EtcdStorageLimitGB >= diskSize {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB %d must be smaller than OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB %s", k.EtcdStorageLimitGB, k.EtcdDiskSizeGB)
+		}
+	}
+	return nil
+}
+
+// validateEtcdMaintenance ensures the apiserver is given a compaction interval it can parse, and that the
+// masters are given a cron schedule to defragment an etcd serving the v3 API
+func (k *KubernetesConfig) validateEtcdMaintenance() error {
+	if k.EtcdCompactionInterval != "" {
+		if d, err := time.ParseDuration(k.EtcdCompactionInterval); err != nil || d < 0 {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdCompactionInterval '%s' is not a valid duration, it needs to be a non-negative duration, e.g. 5m0s, or 0s to disable the compactions", k.EtcdCompactionInterval)
+		}
+	}
+	if k.EtcdDefragSchedule != "" {
+		if !etcdDefragScheduleRegex.MatchString(k.EtcdDefragSchedule) {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdDefragSchedule '%s' is not a valid cron schedule, it must match %s, e.g. \"0 3 * * 0\"", k.EtcdDefragSchedule, etcdDefragScheduleFormat)
+		}
+		if strings.HasPrefix(k.EtcdVersion, "2.") {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdDefragSchedule requires etcd 3.0.0 or greater, not %s", k.EtcdVersion)
+		}
+	}
+	return nil
+}
+
+// validateCloudProviderConfig ensures only the azure.json keys known to acs-engine are merged into
+// the cloud provider config, with values of the kind the cloud provider expects
+func (k *KubernetesConfig) validateCloudProviderConfig() error {
+	keys := make([]string, 0, len(k.CloudProviderConfig))
+	for key := range k.CloudProviderConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		kind, ok := common.CloudProviderConfigKeys[key]
+		if !ok {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderConfig key '%s' is not supported", key)
+		}
+		if _, err := common.ParseCloudProviderConfigValue(key, k.CloudProviderConfig[key]); err != nil {
+			return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderConfig value '%s' of %s is not a valid %s", k.CloudProviderConfig[key], key, kind)
+		}
+	}
+	return nil
+}
+
+// validateCloudProviderBackoffAndRateLimit ensures the backoff and rate limit settings of the cloud provider
+// are within the ranges it accepts, the zero values select the acs-engine defaults
+func (k *KubernetesConfig) validateCloudProviderBackoffAndRateLimit() error {
+	if k.CloudProviderBackoffRetries < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffRetries '%d' must not be negative", k.CloudProviderBackoffRetries)
+	}
+	if k.CloudProviderBackoffDuration < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffDuration '%d' must not be negative, it is the initial backoff in seconds", k.CloudProviderBackoffDuration)
+	}
+	if k.CloudProviderBackoffExponent != 0 && k.CloudProviderBackoffExponent < 1 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffExponent '%g' must be 1 or greater, the backoff would shrink between the retries", k.CloudProviderBackoffExponent)
+	}
+	if k.CloudProviderBackoffJitter < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffJitter '%g' must not be negative", k.CloudProviderBackoffJitter)
+	}
+	if k.CloudProviderRateLimitQPS < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderRateLimitQPS '%g' must not be negative", k.CloudProviderRateLimitQPS)
+	}
+	if k.CloudProviderRateLimitBucket < 0 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.CloudProviderRateLimitBucket '%d' must not be negative", k.CloudProviderRateLimitBucket)
+	}
+	return nil
+}
+
+func validateKubeletThresholds(kubeletConfig map[string]string) error {
+	var thresholds = make(map[string]int)
+	for _, key := range []string{"--image-gc-high-threshold", "--image-gc-low-threshold"} {
+		if val, ok := kubeletConfig[key]; ok {
+			threshold, err := strconv.Atoi(val)
+			if err != nil || threshold < 0 || threshold > 100 {
+				return errors.Errorf("%s '%s' must be an integer percentage between 0 and 100", key, val)
+			}
+			thresholds[key] = threshold
+		}
+	}
+	high, hasHigh := thresholds["--image-gc-high-threshold"]
+	low, hasLow := thresholds["--image-gc-low-threshold"]
+	if hasHigh && hasLow && low >= high {
+		return errors.Errorf("--image-gc-low-threshold '%d' must be lower than --image-gc-high-threshold '%d'", low, high)
+	}
+
+	for _, key := range []string{"--eviction-hard", "--eviction-soft"} {
+		if val, ok := kubeletConfig[key]; ok {
+			if e := validateEvictionThresholds(key, val); e != nil {
+				return e
+			}
+		}
+	}
+
+	if _, ok := kubeletConfig["--eviction-soft"]; ok {
+		val, ok := kubeletConfig["--eviction-soft-grace-period"]
+		if !ok {
+			return errors.New("--eviction-soft-grace-period must be specified when --eviction-soft is")
+		}
+		for _, gracePeriod := range strings.Split(val, ",") {
+			signal, duration := splitEvictionThreshold(gracePeriod, "=")
+			if signal == "" {
+				return errors.Errorf("--eviction-soft-grace-period '%s' is not a valid signal=duration list", val)
+			}
+			if _, err := time.ParseDuration(duration); err != nil {
+				return errors.Errorf("--eviction-soft-grace-period '%s' is not a valid duration for %s", duration, signal)
+			}
+		}
+	}
+	return nil
+}
+
+func validateEvictionThresholds(key, val string) error {
+	if val == "" {
+		return nil
+	}
+	for _, threshold := range strings.Split(val, ",") {
+		signal, quantity := splitEvictionThreshold(threshold, "<")
+		if signal == "" || quantity == "" {
+			return errors.Errorf("%s '%s' is not a valid signal<quantity list", key, val)
+		}
+		if strings.HasSuffix(quantity, "%") {
+			percentage, err := strconv.ParseFloat(strings.TrimSuffix(quantity, "%"), 64)
+			if err != nil || percentage < 0 || percentage > 100 {
+				return errors.Errorf("%s threshold '%s' for %s must be a percentage between 0%% and 100%%", key, quantity, signal)
+			}
+		} else if !evictionQuantityRegex.MatchString(quantity) {
+			return errors.Errorf("%s threshold '%s' for %s is not a valid quantity", key, quantity, signal)
+		}
+	}
+	return nil
+}
+
+func splitEvictionThreshold(threshold, separator string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(threshold), separator, 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
+// validateStorageAccountID ensures that the existing storage account of a profile is referenced by its resource ID,
+// and only holds the VHDs of unmanaged disks
+func validateStorageAccountID(profile, storageAccountID, storageProfile, orchestratorType string) error {
+	if storageAccountID == "" {
+		return nil
+	}
+	if orchestratorType != Kubernetes {
+		return errors.Errorf("the storageAccountId of %s is only supported by the Kubernetes orchestrator", profile)
+	}
+	if storageProfile != StorageAccount {
+		return errors.Errorf("the storageAccountId of %s requires the storageProfile %s, the VHDs of managed disks are not stored in storage accounts", profile, StorageAccount)
+	}
+	if !storageAccountIDRegex.MatchString(storageAccountID) {
+		return errors.Errorf("the storageAccountId of %s, '%s', is not the resource ID of a storage account, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Storage/storageAccounts/<storage account name>", profile, storageAccountID)
+	}
+	return nil
+}
+
+// validateProximityPlacementGroupID ensures that the proximity placement group of a profile is referenced by its
+// resource ID, and is not combined with availability zones, which spread the VMs across datacenters
+func validateProximityPlacementGroupID(profile, proximityPlacementGroupID string, availabilityZones []string, orchestratorType string) error {
+	if proximityPlacementGroupID == "" {
+		return nil
+	}
+	if orchestratorType != Kubernetes {
+		return errors.Errorf("the proximityPlacementGroupID of %s is only supported by the Kubernetes orchestrator", profile)
+	}
+	if len(availabilityZones) > 0 {
+		return errors.Errorf("the proximityPlacementGroupID of %s cannot be combined with availabilityZones, a proximity placement group co-locates the VMs in a single datacenter", profile)
+	}
+	if !proximityPlacementGroupIDRegex.MatchString(proximityPlacementGroupID) {
+		return errors.Errorf("the proximityPlacementGroupID of %s, '%s', is not the resource ID of a proximity placement group, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Compute/proximityPlacementGroups/<proximity placement group name>", profile, proximityPlacementGroupID)
+	}
+	return nil
+}
+
+// validateDiskEncryptionSetID ensures that the disk encryption set of an agent pool is referenced by its resource
+// ID, and only encrypts managed disks
+func (a *AgentPoolProfile) validateDiskEncryptionSetID(orchestratorType string) error {
+	if a.DiskEncryptionSetID == "" {
+		return nil
+	}
+	if orchestratorType != Kubernetes {
+		return errors.Errorf("the diskEncryptionSetID of agent pool '%s' is only supported by the Kubernetes orchestrator", a.Name)
+	}
+	if a.StorageProfile == StorageAccount {
+		return errors.Errorf("the diskEncryptionSetID of agent pool '%s' requires the storageProfile %s, disk encryption sets only encrypt managed disks", a.Name, ManagedDisks)
+	}
+	if !diskEncryptionSetIDRegex.MatchString(a.DiskEncryptionSetID) {
+		return errors.Errorf("the diskEncryptionSetID of agent pool '%s', '%s', is not the resource ID of a disk encryption set, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Compute/diskEncryptionSets/<disk encryption set name>", a.Name, a.DiskEncryptionSetID)
+	}
+	return nil
+}
+
+// validateImageReference ensures that an image is referenced either by the resource ID of a shared image gallery
+// image version, or by its name and resource group
+func (i *ImageReference) validateImageReference() error {
+	if i.ID == "" {
+		return i.validateImageNameAndGroup()
+	}
+	if i.Name != "" || i.ResourceGroup != "" {
+		return errors.New("imageReference.id cannot be combined with imageReference.name and imageReference.resourceGroup")
+	}
+	if !galleryImageVersionIDRegex.MatchString(i.ID) {
+		return errors.Errorf("imageReference.id '%s' is not the resource ID of a shared image gallery image version, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Compute/galleries/<gallery name>/images/<image definition>/versions/<image version>", i.ID)
+	}
+	return nil
+}
+
 func (i *ImageReference) validateImageNameAndGroup() error {
 	if i.Name == "" && i.ResourceGroup != "" {
 		return errors.New("imageName needs to be specified when imageResourceGroup is provided")
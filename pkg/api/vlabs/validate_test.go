@@ -4,6 +4,7 @@
 package vlabs
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"testing"
@@ -159,6 +160,30 @@ func Test_OrchestratorProfile_Validate(t *testing.T) {
 			},
 			expectedError: "enablePodSecurityPolicy is only supported in acs-engine for Kubernetes version 1.8.0 or greater; unable to validate for Kubernetes version 1.7.16",
 		},
+		"should error when KubernetesConfig has enablePodPriority enabled with invalid version": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType:    "Kubernetes",
+					OrchestratorVersion: "1.10.9",
+					KubernetesConfig: &KubernetesConfig{
+						EnablePodPriority: &trueVal,
+					},
+				},
+			},
+			expectedError: "enablePodPriority is only supported in acs-engine for Kubernetes version 1.11.0 or greater; unable to validate for Kubernetes version 1.10.9",
+		},
+		"should error when KubernetesConfig has enablePrometheusMetrics enabled with invalid version": {
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType:    "Kubernetes",
+					OrchestratorVersion: "1.11.5",
+					KubernetesConfig: &KubernetesConfig{
+						EnablePrometheusMetrics: &trueVal,
+					},
+				},
+			},
+			expectedError: "enablePrometheusMetrics is only supported in acs-engine for Kubernetes version 1.12.0 or greater; unable to validate for Kubernetes version 1.11.5",
+		},
 		"should not error with empty object": {
 			properties: &Properties{
 				OrchestratorProfile: &OrchestratorProfile{
@@ -361,6 +386,404 @@ func Test_OpenShiftConfig_Validate(t *testing.T) {
 	}
 }
 
+func Test_KubernetesConfig_ValidateBootstrapToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		k8sVersion  string
+		config      KubernetesConfig
+		expectedErr string
+	}{
+		{
+			name:       "bootstrap token disabled",
+			k8sVersion: "1.11.5",
+			config:     KubernetesConfig{},
+		},
+		{
+			name:       "valid ttl",
+			k8sVersion: "1.11.5",
+			config: KubernetesConfig{
+				BootstrapTokenTTL: "2h",
+			},
+		},
+		{
+			name:       "valid ttl with existing token",
+			k8sVersion: "1.11.5",
+			config: KubernetesConfig{
+				BootstrapTokenTTL:        "24h",
+				BootstrapToken:           "abcdef.0123456789abcdef",
+				BootstrapTokenExpiration: "2018-12-01T10:00:00Z",
+			},
+		},
+		{
+			name:       "invalid ttl",
+			k8sVersion: "1.11.5",
+			config: KubernetesConfig{
+				BootstrapTokenTTL: "1day",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.BootstrapTokenTTL '1day' is not a valid duration",
+		},
+		{
+			name:       "ttl too short",
+			k8sVersion: "1.11.5",
+			config: KubernetesConfig{
+				BootstrapTokenTTL: "30s",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.BootstrapTokenTTL '30s' must be at least 1m",
+		},
+		{
+			name:       "unsupported kubernetes version",
+			k8sVersion: "1.7.16",
+			config: KubernetesConfig{
+				BootstrapTokenTTL: "2h",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.BootstrapTokenTTL not available in kubernetes version 1.7.16",
+		},
+		{
+			name:       "malformed token",
+			k8sVersion: "1.11.5",
+			config: KubernetesConfig{
+				BootstrapTokenTTL: "2h",
+				BootstrapToken:    "ABCDEF.0123456789abcdef",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.BootstrapToken must match the format '^[a-z0-9]{6}[.][a-z0-9]{16}$'",
+		},
+		{
+			name:       "malformed expiration",
+			k8sVersion: "1.11.5",
+			config: KubernetesConfig{
+				BootstrapTokenTTL:        "2h",
+				BootstrapTokenExpiration: "tomorrow",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.BootstrapTokenExpiration 'tomorrow' is not a valid RFC3339 timestamp",
+		},
+		{
+			name:       "token without ttl",
+			k8sVersion: "1.11.5",
+			config: KubernetesConfig{
+				BootstrapToken: "abcdef.0123456789abcdef",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.BootstrapToken and BootstrapTokenExpiration can only be specified together with BootstrapTokenTTL",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.validateBootstrapToken(test.k8sVersion)
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("expected error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_KubernetesConfig_ValidateInflightLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      KubernetesConfig
+		expectedErr string
+	}{
+		{
+			name:   "limits unset",
+			config: KubernetesConfig{},
+		},
+		{
+			name: "valid limits",
+			config: KubernetesConfig{
+				MaxRequestsInflight:         800,
+				MaxMutatingRequestsInflight: 400,
+			},
+		},
+		{
+			name: "field and apiServerConfig flags",
+			config: KubernetesConfig{
+				MaxRequestsInflight: 800,
+				APIServerConfig: map[string]string{
+					"--max-requests-inflight":          "600",
+					"--max-mutating-requests-inflight": "400",
+				},
+			},
+		},
+		{
+			name: "negative limit",
+			config: KubernetesConfig{
+				MaxMutatingRequestsInflight: -1,
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.MaxMutatingRequestsInflight '-1' must be a positive integer",
+		},
+		{
+			name: "non-integer apiServerConfig flag",
+			config: KubernetesConfig{
+				APIServerConfig: map[string]string{
+					"--max-requests-inflight": "many",
+				},
+			},
+			expectedErr: "apiServerConfig --max-requests-inflight 'many' must be a positive integer",
+		},
+		{
+			name: "zero apiServerConfig flag",
+			config: KubernetesConfig{
+				APIServerConfig: map[string]string{
+					"--max-mutating-requests-inflight": "0",
+				},
+			},
+			expectedErr: "apiServerConfig --max-mutating-requests-inflight '0' must be a positive integer",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.validateInflightLimits()
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("expected error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_KubernetesConfig_ValidateRequestTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      KubernetesConfig
+		expectedErr string
+	}{
+		{
+			name:   "timeouts unset",
+			config: KubernetesConfig{},
+		},
+		{
+			name: "valid timeouts",
+			config: KubernetesConfig{
+				RequestTimeout:    "2m0s",
+				MinRequestTimeout: 3600,
+			},
+		},
+		{
+			name: "request timeout without unit",
+			config: KubernetesConfig{
+				RequestTimeout: "60",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.RequestTimeout '60' is not a valid duration, it needs to be a positive duration, e.g. 1m0s",
+		},
+		{
+			name: "negative request timeout",
+			config: KubernetesConfig{
+				RequestTimeout: "-1m",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.RequestTimeout '-1m' is not a valid duration, it needs to be a positive duration, e.g. 1m0s",
+		},
+		{
+			name: "negative min request timeout",
+			config: KubernetesConfig{
+				MinRequestTimeout: -1,
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.MinRequestTimeout '-1' must be a positive integer",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.validateRequestTimeouts()
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("expected error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_KubernetesConfig_ValidateWatchCacheSizes(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      KubernetesConfig
+		expectedErr string
+	}{
+		{
+			name:   "watch cache sizes unset",
+			config: KubernetesConfig{},
+		},
+		{
+			name: "valid watch cache sizes",
+			config: KubernetesConfig{
+				WatchCacheSizes: map[string]int{
+					"pods":             5000,
+					"deployments.apps": 1000,
+				},
+			},
+		},
+		{
+			name: "resource with a version",
+			config: KubernetesConfig{
+				WatchCacheSizes: map[string]int{
+					"deployments.v1.apps/scale": 1000,
+				},
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.WatchCacheSizes resource 'deployments.v1.apps/scale' is not a valid resource, it needs to be a lowercase resource[.group], e.g. pods or deployments.apps",
+		},
+		{
+			name: "kind instead of resource",
+			config: KubernetesConfig{
+				WatchCacheSizes: map[string]int{
+					"Pod": 1000,
+				},
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.WatchCacheSizes resource 'Pod' is not a valid resource, it needs to be a lowercase resource[.group], e.g. pods or deployments.apps",
+		},
+		{
+			name: "zero size",
+			config: KubernetesConfig{
+				WatchCacheSizes: map[string]int{
+					"nodes": 0,
+				},
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.WatchCacheSizes '0' of resource 'nodes' must be a positive integer",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.validateWatchCacheSizes()
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("expected error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_KubernetesConfig_ValidateNodeMonitorTimings(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      KubernetesConfig
+		expectedErr string
+	}{
+		{
+			name:   "timings unset",
+			config: KubernetesConfig{},
+		},
+		{
+			name: "valid timings",
+			config: KubernetesConfig{
+				NodeMonitorGracePeriod: "2m",
+				NodeMonitorPeriod:      "10s",
+				PodEvictionTimeout:     "10m",
+			},
+		},
+		{
+			name: "invalid grace period",
+			config: KubernetesConfig{
+				NodeMonitorGracePeriod: "2 minutes",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.NodeMonitorGracePeriod '2 minutes' is not a valid duration",
+		},
+		{
+			name: "invalid monitor period",
+			config: KubernetesConfig{
+				NodeMonitorPeriod: "10",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.NodeMonitorPeriod '10' is not a valid duration",
+		},
+		{
+			name: "invalid eviction timeout",
+			config: KubernetesConfig{
+				PodEvictionTimeout: "ten minutes",
+			},
+			expectedErr: "OrchestratorProfile.KubernetesConfig.PodEvictionTimeout 'ten minutes' is not a valid duration",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.validateNodeMonitorTimings()
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("expected error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+
+	// The configured grace period must allow for enough kubelet status updates
+	c := KubernetesConfig{
+		NodeMonitorGracePeriod: "30s",
+		KubeletConfig: map[string]string{
+			"--node-status-update-frequency": "10s",
+		},
+	}
+	if err := c.Validate("1.11.5", false); err == nil {
+		t.Errorf("should error when NodeMonitorGracePeriod is less than 4 times --node-status-update-frequency")
+	}
+
+	c = KubernetesConfig{
+		ControllerManagerConfig: map[string]string{
+			"--node-monitor-period": "5 seconds",
+		},
+	}
+	if err := c.Validate("1.11.5", false); err == nil {
+		t.Errorf("should error on invalid --node-monitor-period")
+	}
+}
+
+func Test_KubernetesConfig_ValidateCustomHyperkubeImage(t *testing.T) {
+	digest := "@sha256:" + strings.Repeat("a", 64)
+	for _, image := range []string{
+		"hyperkube",
+		"k8s.gcr.io/hyperkube-amd64:v1.13.0-beta.1",
+		"myregistry.azurecr.io:5000/team/hyperkube-amd64:v1.13.0-alpha.3.140_e5c5a0b7a3f28c",
+		"docker.io/user/hyperkube" + digest,
+		"localhost:5000/hyperkube:latest" + digest,
+	} {
+		c := KubernetesConfig{CustomHyperkubeImage: image}
+		if err := c.Validate(common.GetDefaultKubernetesVersion(false), false); err != nil {
+			t.Errorf("should not error on customHyperkubeImage %s: %v", image, err)
+		}
+	}
+
+	for _, image := range []string{
+		"k8s.gcr.io/Hyperkube:v1.13.0",
+		"k8s.gcr.io/hyperkube:",
+		"k8s.gcr.io/hyperkube:v1.13.0 --privileged",
+		"https://k8s.gcr.io/hyperkube:v1.13.0",
+		"k8s.gcr.io/hyperkube@sha256:abc",
+	} {
+		c := KubernetesConfig{CustomHyperkubeImage: image}
+		expectedErr := fmt.Sprintf("OrchestratorProfile.KubernetesConfig.CustomHyperkubeImage '%s' is not a valid container image reference", image)
+		if err := c.Validate(common.GetDefaultKubernetesVersion(false), false); err == nil || err.Error() != expectedErr {
+			t.Errorf("expected error %q, got %v", expectedErr, err)
+		}
+	}
+}
+
 func Test_KubernetesConfig_Validate(t *testing.T) {
 	// Tests that should pass across all versions
 	for _, k8sVersion := range common.GetAllSupportedKubernetesVersions(true, false) {
@@ -443,6 +866,16 @@ func Test_KubernetesConfig_Validate(t *testing.T) {
 			t.Error("should error on invalid --node-status-update-frequency kubelet config")
 		}
 
+		c = KubernetesConfig{
+			KubeletConfig: map[string]string{
+				"--image-gc-high-threshold": "80",
+				"--image-gc-low-threshold":  "85",
+			},
+		}
+		if err := c.Validate(k8sVersion, false); err == nil {
+			t.Error("should error when --image-gc-low-threshold is not lower than --image-gc-high-threshold")
+		}
+
 		c = KubernetesConfig{
 			ControllerManagerConfig: map[string]string{
 				"--node-monitor-grace-period": "invalid",
@@ -536,6 +969,38 @@ func Test_KubernetesConfig_Validate(t *testing.T) {
 			t.Error("should error when DNSServiceIP is first IP of ServiceCidr")
 		}
 
+		c = KubernetesConfig{
+			DNSServiceIP: "10.2.0.1",
+			ServiceCidr:  "10.2.0.0/24",
+		}
+		if err := c.Validate(k8sVersion, false); err == nil {
+			t.Error("should error when DNSServiceIP is the kubernetes service IP")
+		}
+
+		c = KubernetesConfig{
+			DNSServiceIP: "10.2.0.0",
+			ServiceCidr:  "10.2.0.0/24",
+		}
+		if err := c.Validate(k8sVersion, false); err == nil {
+			t.Error("should error when DNSServiceIP is the network address of ServiceCidr")
+		}
+
+		c = KubernetesConfig{
+			DNSServiceIP: "10.2.0.2",
+			ServiceCidr:  "10.2.0.0/24",
+		}
+		if err := c.Validate(k8sVersion, false); err != nil {
+			t.Errorf("should not error when DNSServiceIP follows the kubernetes service IP: %v", err)
+		}
+
+		c = KubernetesConfig{
+			DNSServiceIP: "10.2.1.10",
+			ServiceCidr:  "10.2.0.0/24",
+		}
+		if err := c.Validate(k8sVersion, false); err == nil {
+			t.Error("should error when DNSServiceIP is just outside of ServiceCidr")
+		}
+
 		c = KubernetesConfig{
 			DNSServiceIP: "172.99.255.10",
 			ServiceCidr:  "172.99.0.1/16",
@@ -544,6 +1009,44 @@ func Test_KubernetesConfig_Validate(t *testing.T) {
 			t.Error("should not error when DNSServiceIP and ServiceCidr are valid")
 		}
 
+		for _, clusterDomain := range []string{"cluster.local", "k8s.contoso.internal", "my-cluster"} {
+			c = KubernetesConfig{
+				ClusterDomain: clusterDomain,
+			}
+			if err := c.Validate(k8sVersion, false); err != nil {
+				t.Errorf("should not error on valid ClusterDomain %s: %v", clusterDomain, err)
+			}
+		}
+
+		for _, clusterDomain := range []string{"Cluster.Local", "cluster.local.", ".cluster.local", "cluster..local", "-cluster.local", "cluster_local", strings.Repeat("a", 64) + ".local"} {
+			c = KubernetesConfig{
+				ClusterDomain: clusterDomain,
+			}
+			if err := c.Validate(k8sVersion, false); err == nil {
+				t.Errorf("should error on invalid ClusterDomain %s", clusterDomain)
+			}
+		}
+
+		c = KubernetesConfig{
+			ClusterDomain: "k8s.contoso.internal",
+			KubeletConfig: map[string]string{
+				"--cluster-domain": "k8s.contoso.internal",
+			},
+		}
+		if err := c.Validate(k8sVersion, false); err != nil {
+			t.Errorf("should not error when the kubelet --cluster-domain matches ClusterDomain: %v", err)
+		}
+
+		c = KubernetesConfig{
+			ClusterDomain: "k8s.contoso.internal",
+			KubeletConfig: map[string]string{
+				"--cluster-domain": "cluster.local",
+			},
+		}
+		if err := c.Validate(k8sVersion, false); err == nil {
+			t.Error("should error when the kubelet --cluster-domain doesn't match ClusterDomain")
+		}
+
 		c = KubernetesConfig{
 			ClusterSubnet: "192.168.0.1/24",
 			NetworkPlugin: "azure",
@@ -879,9 +1382,65 @@ func Test_ServicePrincipalProfile_ValidateSecretOrKeyvaultSecretRef(t *testing.T
 			t.Error("error should have occurred")
 		}
 	})
-}
 
-func TestValidateKubernetesLabelValue(t *testing.T) {
+	t.Run("ServicePrincipalProfile with Certificate should pass", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.ServicePrincipalProfile.Secret = ""
+		p.ServicePrincipalProfile.Certificate = "-----BEGIN CERTIFICATE-----\nZm9v\n-----END CERTIFICATE-----\n"
+		p.ServicePrincipalProfile.CertificatePassword = "password"
+
+		if err := p.Validate(false); err != nil {
+			t.Errorf("should not error %v", err)
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with neither Secret nor Certificate should NOT pass", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.ServicePrincipalProfile.Secret = ""
+
+		expectedMsg := "exactly one of the service principal client secret, keyvault secret reference or client certificate must be specified with Orchestrator Kubernetes"
+		if err := p.Validate(false); err == nil || err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %v", expectedMsg, err)
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with Secret and Certificate should NOT pass", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.ServicePrincipalProfile.Certificate = "-----BEGIN CERTIFICATE-----\nZm9v\n-----END CERTIFICATE-----\n"
+
+		if err := p.Validate(false); err == nil {
+			t.Error("error should have occurred")
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with a non PEM Certificate should NOT pass", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.ServicePrincipalProfile.Secret = ""
+		p.ServicePrincipalProfile.Certificate = "not a certificate"
+
+		expectedMsg := "the service principal certificate must be a PEM encoded certificate and private key"
+		if err := p.Validate(false); err == nil || err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %v", expectedMsg, err)
+		}
+	})
+
+	t.Run("ServicePrincipalProfile with CertificatePassword but no Certificate should NOT pass", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.ServicePrincipalProfile.CertificatePassword = "password"
+
+		expectedMsg := "the service principal certificatePassword can only be specified together with certificate"
+		if err := p.Validate(false); err == nil || err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %v", expectedMsg, err)
+		}
+	})
+}
+
+func TestValidateKubernetesLabelValue(t *testing.T) {
 
 	validLabelValues := []string{"", "a", "a1", "this--valid--label--is--exactly--sixty--three--characters--long", "123456", "my-label_valid.com"}
 	invalidLabelValues := []string{"a$$b", "-abc", "not.valid.", "This____long____label___is______sixty______four_____chararacters", "Label with spaces"}
@@ -1219,173 +1778,509 @@ func Test_Properties_ValidateAddons(t *testing.T) {
 			"should not error on nvidia-device-plugin with k8s >= 1.10",
 		)
 	}
+
 	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
 		Addons: []KubernetesAddon{
 			{
-				Name: "kube-proxy-daemonset",
-				Data: "asdasdasdasda",
+				Name:    "gatekeeper",
+				Enabled: helpers.PointerToBool(true),
+			},
+		},
+	}
+	p.OrchestratorProfile.OrchestratorRelease = "1.9"
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on gatekeeper with k8s < 1.10",
+		)
+	}
+
+	p.OrchestratorProfile.OrchestratorRelease = "1.10"
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on gatekeeper with k8s >= 1.10",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config = map[string]string{
+		"replicas": "0",
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on gatekeeper with non-positive replicas",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config = map[string]string{
+		"auditInterval": "1m",
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on gatekeeper with a non-integer auditInterval",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "node-problem-detector",
+				Enabled: helpers.PointerToBool(true),
 				Config: map[string]string{
-					"foo": "bar",
+					"monitors": `{"kernel-monitor.json": {"plugin": "kmsg", "source": "kernel-monitor"}}`,
+				},
+			},
+		},
+	}
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on node-problem-detector with valid monitors: %v", err,
+		)
+	}
+
+	for _, monitors := range []string{
+		`{"kernel-monitor.json": {"plugin": "kmsg"`,
+		`["kernel-monitor.json"]`,
+		`{}`,
+		`{"kernel-monitor": {"plugin": "kmsg"}}`,
+		`{"../kernel-monitor.json": {"plugin": "kmsg"}}`,
+		`{"kernel-monitor.json": null}`,
+	} {
+		p.OrchestratorProfile.KubernetesConfig.Addons[0].Config["monitors"] = monitors
+		if err := p.validateAddons(); err == nil {
+			t.Errorf(
+				"should error on node-problem-detector with monitors %s", monitors,
+			)
+		}
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Enabled = helpers.PointerToBool(false)
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on a disabled node-problem-detector: %v", err,
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "startup-taint-remover",
+				Enabled: helpers.PointerToBool(true),
+				Config: map[string]string{
+					"taint":      "example.com/startup=true:NoSchedule",
+					"daemonSets": "kube-system/kube-proxy,kube-system/calico-node",
 				},
 			},
 		},
 	}
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on startup-taint-remover with a valid config: %v", err,
+		)
+	}
+
+	for key, val := range map[string]string{
+		"taint":      "example.com/startup=true:PreferNoSchedule",
+		"daemonSets": "kube-proxy",
+	} {
+		config := p.OrchestratorProfile.KubernetesConfig.Addons[0].Config
+		valid := config[key]
+		config[key] = val
+		if err := p.validateAddons(); err == nil {
+			t.Errorf(
+				"should error on startup-taint-remover with %s %s", key, val,
+			)
+		}
+		config[key] = valid
+	}
+
+	p.OrchestratorProfile.OrchestratorRelease = "1.9"
 	if err := p.validateAddons(); err == nil {
 		t.Errorf(
-			"expected error for non-empty Config with non-empty Data",
+			"should error on startup-taint-remover with k8s < 1.10",
 		)
 	}
+	p.OrchestratorProfile.OrchestratorRelease = "1.10"
+
 	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
 		Addons: []KubernetesAddon{
 			{
-				Name: "kube-proxy-daemonset",
-				Data: "asdasdasdasda",
-				Containers: []KubernetesContainerSpec{
-					{
-						Name: "FooContainerSpec",
-					},
+				Name:    "velero",
+				Enabled: helpers.PointerToBool(true),
+				Config: map[string]string{
+					"storageAccount": "backups01",
+					"resourceGroup":  "backups-rg",
 				},
 			},
 		},
 	}
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on velero with a valid config: %v", err,
+		)
+	}
+
+	p.OrchestratorProfile.OrchestratorRelease = "1.9"
 	if err := p.validateAddons(); err == nil {
 		t.Errorf(
-			"expected error for non-empty Containers with non-empty Data",
+			"should error on velero with k8s < 1.10",
 		)
 	}
+	p.OrchestratorProfile.OrchestratorRelease = "1.10"
+
 	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
 		Addons: []KubernetesAddon{
 			{
-				Name: "kube-proxy-daemonset",
-				Data: "foodata",
+				Name:    "dns-autoscaler",
+				Enabled: helpers.PointerToBool(true),
+				Config: map[string]string{
+					"nodesPerReplica": "0",
+				},
 			},
 		},
 	}
 	if err := p.validateAddons(); err == nil {
 		t.Errorf(
-			"expected error for invalid base64",
+			"should error on dns-autoscaler with nodesPerReplica 0",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config["nodesPerReplica"] = "8"
+	p.OrchestratorProfile.OrchestratorRelease = "1.8"
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on dns-autoscaler with k8s < 1.9",
 		)
 	}
+	p.OrchestratorProfile.OrchestratorRelease = "1.10"
+
 	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
 		Addons: []KubernetesAddon{
 			{
-				Name: "kube-proxy-daemonset",
-				Data: "Zm9vZGF0YQ==",
+				Name:    "tiller",
+				Enabled: helpers.PointerToBool(true),
+				Containers: []KubernetesContainerSpec{
+					{
+						Name:    "tiller",
+						Image:   "myregistry.azurecr.io:5000/helm/tiller",
+						Version: "v2.12.3",
+					},
+				},
 			},
 		},
 	}
 	if err := p.validateAddons(); err != nil {
 		t.Errorf(
-			"should not error on providing valid addon.Data",
+			"should not error on an addon container with a valid image and version: %v", err,
 		)
 	}
-}
 
-func TestWindowsVersions(t *testing.T) {
-	for _, version := range common.GetAllSupportedKubernetesVersions(false, true) {
-		p := getK8sDefaultProperties(true)
-		p.OrchestratorProfile.OrchestratorVersion = version
-		if err := p.Validate(false); err != nil {
-			t.Errorf(
-				"should not error on valid Windows version: %v", err,
-			)
-		}
-		p = getK8sDefaultProperties(true)
-		p.WindowsProfile.AdminPassword = "Password"
-		if err := p.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password complexity not match because no digits and special characters found in the password ",
-			)
-		}
-		p = getK8sDefaultProperties(true)
-		p.WindowsProfile.AdminPassword = "123!@#"
-		if err := p.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password complexity not match because uppercase and lowercase letters found in the password",
-			)
-		}
-		p = getK8sDefaultProperties(true)
-		p.WindowsProfile.AdminPassword = ""
-		if err := p.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password length is zero",
-			)
-		}
-		p = getK8sDefaultProperties(true)
-		p.WindowsProfile.AdminUsername = "User@123"
-		p.WindowsProfile.AdminPassword = "User@123"
-		if err := p.Validate(false); err == nil {
-			t.Errorf(
-				"should error on windows password complexity not match because username and password are  same",
-			)
-		}
-		sv, _ := semver.Make(version)
-		p = getK8sDefaultProperties(true)
-		p.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
-		if err := p.Validate(false); err != nil {
+	for image, version := range map[string]string{
+		"Tiller:v2.12.3":       "",
+		"helm/tiller:v2.12.3:": "",
+		"helm/tiller":          "v2.12.3/alpine",
+		"k8s.gcr.io/tiller":    ".v2",
+	} {
+		p.OrchestratorProfile.KubernetesConfig.Addons[0].Containers[0].Image = image
+		p.OrchestratorProfile.KubernetesConfig.Addons[0].Containers[0].Version = version
+		if err := p.validateAddons(); err == nil {
 			t.Errorf(
-				"should not error on valid Windows version: %v", err,
+				"should error on an addon container with image '%s' and version '%s'", image, version,
 			)
 		}
 	}
-	p := getK8sDefaultProperties(true)
-	p.OrchestratorProfile.OrchestratorRelease = "1.4"
-	if err := p.Validate(false); err == nil {
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "nodelocaldns",
+				Enabled: helpers.PointerToBool(true),
+				Config: map[string]string{
+					"localIP": "169.254.20.10",
+				},
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
 		t.Errorf(
-			"should error on invalid Windows version",
+			"should error on nodelocaldns with k8s < 1.12",
 		)
 	}
 
-	p = getK8sDefaultProperties(true)
-	p.OrchestratorProfile.OrchestratorVersion = "1.4.0"
-	if err := p.Validate(false); err == nil {
+	p.OrchestratorProfile.OrchestratorRelease = "1.12"
+	if err := p.validateAddons(); err != nil {
 		t.Errorf(
-			"should error on invalid Windows version",
+			"should not error on nodelocaldns with a valid config: %v", err,
 		)
 	}
-}
 
-func TestLinuxVersions(t *testing.T) {
-	for _, version := range common.GetAllSupportedKubernetesVersions(false, false) {
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorVersion = version
-		if err := p.Validate(false); err != nil {
-			t.Errorf(
-				"should not error on valid Linux version: %v", err,
-			)
-		}
-		sv, _ := semver.Make(version)
-		p = getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
-		if err := p.Validate(false); err != nil {
+	for _, localIP := range []string{"169.254.20", "fd00::10", "10.0.0.10"} {
+		p.OrchestratorProfile.KubernetesConfig.Addons[0].Config["localIP"] = localIP
+		if err := p.validateAddons(); err == nil {
 			t.Errorf(
-				"should not error on valid Linux version: %v", err,
+				"should error on nodelocaldns with localIP %s", localIP,
 			)
 		}
 	}
-	p := getK8sDefaultProperties(false)
-	p.OrchestratorProfile.OrchestratorRelease = "1.4"
-	if err := p.Validate(false); err == nil {
+
+	p.OrchestratorProfile.KubernetesConfig.ServiceCidr = "10.10.0.0/16"
+	if err := p.validateAddons(); err != nil {
 		t.Errorf(
-			"should error on invalid Linux version",
+			"should not error on nodelocaldns with a localIP outside the ServiceCidr: %v", err,
 		)
 	}
-
-	p = getK8sDefaultProperties(false)
-	p.OrchestratorProfile.OrchestratorVersion = "1.4.0"
-	if err := p.Validate(false); err == nil {
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config["localIP"] = "10.10.0.20"
+	if err := p.validateAddons(); err == nil {
 		t.Errorf(
-			"should error on invalid Linux version",
+			"should error on nodelocaldns with a localIP within the ServiceCidr",
 		)
 	}
-}
+	p.OrchestratorProfile.OrchestratorRelease = "1.10"
 
-func TestValidateImageNameAndGroup(t *testing.T) {
-	tests := []struct {
-		name        string
-		image       ImageReference
-		expectedErr error
-	}{
+	for _, name := range []string{"azuredisk-csi-driver", "azurefile-csi-driver"} {
+		p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+			Addons: []KubernetesAddon{
+				{
+					Name:    name,
+					Enabled: helpers.PointerToBool(true),
+				},
+			},
+		}
+		if err := p.validateAddons(); err == nil {
+			t.Errorf(
+				"should error on %s without the external cloud provider", name,
+			)
+		}
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name:    "cloud-node-manager",
+				Enabled: helpers.PointerToBool(true),
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on cloud-node-manager without the external cloud provider",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager = helpers.PointerToBool(true)
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on cloud-node-manager with the external cloud provider: %v", err,
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "metrics-server",
+				Config: map[string]string{
+					"metric-resolution":    "30s",
+					"kubelet-insecure-tls": "true",
+				},
+			},
+		},
+	}
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on metrics-server with valid config",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config = map[string]string{
+		"metric-resolution": "30",
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on metrics-server with an invalid metric-resolution",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config = map[string]string{
+		"metric-resolution": "0s",
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on metrics-server with a zero metric-resolution",
+		)
+	}
+
+	p.OrchestratorProfile.KubernetesConfig.Addons[0].Config = map[string]string{
+		"kubelet-insecure-tls": "yes",
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"should error on metrics-server with a non-boolean kubelet-insecure-tls",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "asdasdasdasda",
+				Config: map[string]string{
+					"foo": "bar",
+				},
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"expected error for non-empty Config with non-empty Data",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "asdasdasdasda",
+				Containers: []KubernetesContainerSpec{
+					{
+						Name: "FooContainerSpec",
+					},
+				},
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"expected error for non-empty Containers with non-empty Data",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "foodata",
+			},
+		},
+	}
+	if err := p.validateAddons(); err == nil {
+		t.Errorf(
+			"expected error for invalid base64",
+		)
+	}
+	p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+		Addons: []KubernetesAddon{
+			{
+				Name: "kube-proxy-daemonset",
+				Data: "Zm9vZGF0YQ==",
+			},
+		},
+	}
+	if err := p.validateAddons(); err != nil {
+		t.Errorf(
+			"should not error on providing valid addon.Data",
+		)
+	}
+}
+
+func TestWindowsVersions(t *testing.T) {
+	for _, version := range common.GetAllSupportedKubernetesVersions(false, true) {
+		p := getK8sDefaultProperties(true)
+		p.OrchestratorProfile.OrchestratorVersion = version
+		if err := p.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Windows version: %v", err,
+			)
+		}
+		p = getK8sDefaultProperties(true)
+		p.WindowsProfile.AdminPassword = "Password"
+		if err := p.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password complexity not match because no digits and special characters found in the password ",
+			)
+		}
+		p = getK8sDefaultProperties(true)
+		p.WindowsProfile.AdminPassword = "123!@#"
+		if err := p.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password complexity not match because uppercase and lowercase letters found in the password",
+			)
+		}
+		p = getK8sDefaultProperties(true)
+		p.WindowsProfile.AdminPassword = ""
+		if err := p.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password length is zero",
+			)
+		}
+		p = getK8sDefaultProperties(true)
+		p.WindowsProfile.AdminUsername = "User@123"
+		p.WindowsProfile.AdminPassword = "User@123"
+		if err := p.Validate(false); err == nil {
+			t.Errorf(
+				"should error on windows password complexity not match because username and password are  same",
+			)
+		}
+		sv, _ := semver.Make(version)
+		p = getK8sDefaultProperties(true)
+		p.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
+		if err := p.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Windows version: %v", err,
+			)
+		}
+	}
+	p := getK8sDefaultProperties(true)
+	p.OrchestratorProfile.OrchestratorRelease = "1.4"
+	if err := p.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Windows version",
+		)
+	}
+
+	p = getK8sDefaultProperties(true)
+	p.OrchestratorProfile.OrchestratorVersion = "1.4.0"
+	if err := p.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Windows version",
+		)
+	}
+}
+
+func TestLinuxVersions(t *testing.T) {
+	for _, version := range common.GetAllSupportedKubernetesVersions(false, false) {
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorVersion = version
+		if err := p.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Linux version: %v", err,
+			)
+		}
+		sv, _ := semver.Make(version)
+		p = getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorRelease = fmt.Sprintf("%d.%d", sv.Major, sv.Minor)
+		if err := p.Validate(false); err != nil {
+			t.Errorf(
+				"should not error on valid Linux version: %v", err,
+			)
+		}
+	}
+	p := getK8sDefaultProperties(false)
+	p.OrchestratorProfile.OrchestratorRelease = "1.4"
+	if err := p.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Linux version",
+		)
+	}
+
+	p = getK8sDefaultProperties(false)
+	p.OrchestratorProfile.OrchestratorVersion = "1.4.0"
+	if err := p.Validate(false); err == nil {
+		t.Errorf(
+			"should error on invalid Linux version",
+		)
+	}
+}
+
+func TestValidateImageNameAndGroup(t *testing.T) {
+	tests := []struct {
+		name        string
+		image       ImageReference
+		expectedErr error
+	}{
 		{
 			name: "valid run",
 			image: ImageReference{
@@ -1408,6 +2303,28 @@ func TestValidateImageNameAndGroup(t *testing.T) {
 			},
 			expectedErr: errors.New(`imageResourceGroup needs to be specified when imageName is provided`),
 		},
+		{
+			name: "valid shared image gallery image version",
+			image: ImageReference{
+				ID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/images/providers/Microsoft.Compute/galleries/golden/images/ubuntu-hardened/versions/1.0.0",
+			},
+		},
+		{
+			name: "invalid: shared image gallery image definition without a version",
+			image: ImageReference{
+				ID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/images/providers/Microsoft.Compute/galleries/golden/images/ubuntu-hardened",
+			},
+			expectedErr: errors.New(`imageReference.id '/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/images/providers/Microsoft.Compute/galleries/golden/images/ubuntu-hardened' is not the resource ID of a shared image gallery image version, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Compute/galleries/<gallery name>/images/<image definition>/versions/<image version>`),
+		},
+		{
+			name: "invalid: shared image gallery image version with an image name",
+			image: ImageReference{
+				Name:          "rhel9000",
+				ResourceGroup: "club",
+				ID:            "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/images/providers/Microsoft.Compute/galleries/golden/images/ubuntu-hardened/versions/1.0.0",
+			},
+			expectedErr: errors.New(`imageReference.id cannot be combined with imageReference.name and imageReference.resourceGroup`),
+		},
 	}
 
 	for _, test := range tests {
@@ -1625,6 +2542,18 @@ func TestMasterProfileValidate(t *testing.T) {
 			},
 			expectedErr: "imageName needs to be specified when imageResourceGroup is provided",
 		},
+		{
+			name:             "Master Profile with a shared image gallery image",
+			orchestratorType: Kubernetes,
+			masterProfile: MasterProfile{
+				DNSPrefix: "dummy",
+				Count:     3,
+				ImageRef: &ImageReference{
+					ID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/images/providers/Microsoft.Compute/galleries/golden/images/ubuntu-hardened/versions/1.0.0",
+				},
+			},
+			expectedErr: "masterProfile.imageReference.id is not supported, shared image gallery images are only supported in agent pools",
+		},
 		{
 			name:                "Master Profile with VMSS and Kubernetes v1.9.6",
 			orchestratorType:    Kubernetes,
@@ -2479,85 +3408,67 @@ func TestValidateAgentPoolProfiles(t *testing.T) {
 			},
 			expectedErr: errors.New("OpenShift requires exactly two agent pool profiles: compute and infra"),
 		},
-	}
-
-	for _, test := range tests {
-		test := test
-		t.Run(test.name, func(t *testing.T) {
-			t.Parallel()
-			gotErr := test.properties.validateAgentPoolProfiles(true)
-			if !helpers.EqualError(gotErr, test.expectedErr) {
-				t.Logf("running scenario %q", test.name)
-				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, gotErr)
-			}
-		})
-	}
-}
-
-func TestValidate_VaultKeySecrets(t *testing.T) {
-
-	tests := []struct {
-		name        string
-		secrets     []KeyVaultSecrets
-		expectedErr error
-	}{
 		{
-			name: "Empty Vault Certificates",
-			secrets: []KeyVaultSecrets{
-				{
-					SourceVault: &KeyVaultID{
-						ID: "0a0b0c0d0e0f",
-					},
-					VaultCertificates: []KeyVaultCertificate{},
+			name: "valid - system and user pools",
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
 				},
-			},
-			expectedErr: errors.New("Valid KeyVaultSecrets must have no empty VaultCertificates"),
-		},
-		{
-			name: "No SourceVault ID",
-			secrets: []KeyVaultSecrets{
-				{
-					SourceVault: &KeyVaultID{},
-					VaultCertificates: []KeyVaultCertificate{
-						{
-							CertificateURL:   "dummyURL",
-							CertificateStore: "dummyCertStore",
-						},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:                "system",
+						Role:                "system",
+						StorageProfile:      ManagedDisks,
+						AvailabilityProfile: AvailabilitySet,
+					},
+					{
+						Name:                "user",
+						Role:                "user",
+						StorageProfile:      ManagedDisks,
+						AvailabilityProfile: AvailabilitySet,
 					},
 				},
 			},
-			expectedErr: errors.New("KeyVaultSecrets must have a SourceVault.ID"),
+			expectedErr: nil,
 		},
 		{
-			name: "Empty SourceVault",
-			secrets: []KeyVaultSecrets{
-				{
-					VaultCertificates: []KeyVaultCertificate{
-						{
-							CertificateURL:   "dummyURL",
-							CertificateStore: "dummyCertStore",
-						},
+			name: "invalid - user pools without a system pool",
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:                "agentpool1",
+						StorageProfile:      ManagedDisks,
+						AvailabilityProfile: AvailabilitySet,
+					},
+					{
+						Name:                "user",
+						Role:                "user",
+						StorageProfile:      ManagedDisks,
+						AvailabilityProfile: AvailabilitySet,
 					},
 				},
 			},
-			expectedErr: errors.New("missing SourceVault in KeyVaultSecrets"),
+			expectedErr: errors.New("at least one agent pool must have role 'system' when agent pool roles are used"),
 		},
 		{
-			name: "Empty Certificate Store",
-			secrets: []KeyVaultSecrets{
-				{
-					SourceVault: &KeyVaultID{
-						ID: "0a0b0c0d0e0f",
-					},
-					VaultCertificates: []KeyVaultCertificate{
-						{
-							CertificateURL:   "dummyUrl",
-							CertificateStore: "",
-						},
+			name: "invalid - system role with OpenShift",
+			properties: &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: OpenShift,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:                "compute",
+						Role:                "system",
+						StorageProfile:      ManagedDisks,
+						AvailabilityProfile: AvailabilitySet,
 					},
 				},
 			},
-			expectedErr: errors.New("KeyVaultCertificate.CertificateStore must be a non-empty value for certificates in a WindowsProfile"),
+			expectedErr: errors.New("Role \"system\" is not supported for Orchestrator OpenShift"),
 		},
 	}
 
@@ -2565,193 +3476,4394 @@ func TestValidate_VaultKeySecrets(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			err := validateKeyVaultSecrets(test.secrets, true)
-			if err.Error() != test.expectedErr.Error() {
-				t.Errorf("expected error to be thrown with msg : %s", test.expectedErr.Error())
+			gotErr := test.properties.validateAgentPoolProfiles(true)
+			if !helpers.EqualError(gotErr, test.expectedErr) {
+				t.Logf("running scenario %q", test.name)
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, gotErr)
 			}
 		})
 	}
 }
 
-func TestValidateProperties_OrchestratorSpecificProperties(t *testing.T) {
-	t.Run("Should not support DNS prefix for Kubernetes orchestrators", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].DNSPrefix = "sampleprefix"
-		expectedMsg := "AgentPoolProfile.DNSPrefix must be empty for Kubernetes"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s", expectedMsg)
-		}
-	})
-
-	t.Run("Should not contain agentPool ports for Kubernetes orchestrators", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].Ports = []int{80, 443, 8080}
-		expectedMsg := "AgentPoolProfile.Ports must be empty for Kubernetes"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+func TestValidateSystemAgentPools(t *testing.T) {
+	p := &Properties{
+		AgentPoolProfiles: []*AgentPoolProfile{
+			{
+				Name:   "system",
+				Role:   "system",
+				OSType: Windows,
+			},
+		},
+	}
+	expectedErr := errors.New("agent pool 'system' has role 'system' but system agent pools must run Linux")
+	if err := p.validateSystemAgentPools(); !helpers.EqualError(err, expectedErr) {
+		t.Errorf("expected error: %v\ngot error: %v", expectedErr, err)
+	}
 
-	t.Run("Should not support ScaleSetEviction policies with regular priority", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].Ports = []int{}
-		agentPoolProfiles[0].ScaleSetPriority = "Regular"
-		agentPoolProfiles[0].ScaleSetEvictionPolicy = "Deallocate"
-		expectedMsg := "property 'AgentPoolProfile.ScaleSetEvictionPolicy' must be empty for AgentPoolProfile.Priority of Regular"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	p.AgentPoolProfiles[0].OSType = Linux
+	if err := p.validateSystemAgentPools(); err != nil {
+		t.Errorf("expected no error for a Linux system pool, got %v", err)
+	}
 
-	t.Run("Should contain a valid DNS prefix", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = OpenShift
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].DNSPrefix = "invalid_prefix"
-		expectedMsg := "DNSPrefix 'invalid_prefix' is invalid. The DNSPrefix must contain between 3 and 45 characters and can contain only letters, numbers, and hyphens.  It must start with a letter and must end with a letter or a number. (length was 14)"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	p.AgentPoolProfiles[0].Role = ""
+	if err := p.validateSystemAgentPools(); err != nil {
+		t.Errorf("expected no error without agent pool roles, got %v", err)
+	}
 
-	t.Run("Should not contain ports when DNS prefix is empty", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = OpenShift
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].Ports = []int{80, 443}
-		expectedMsg := "AgentPoolProfile.Ports must be empty when AgentPoolProfile.DNSPrefix is empty for Orchestrator: OpenShift"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	p.AgentPoolProfiles[0].Role = AgentPoolProfileRoleDaemonSet
+	expectedErr = errors.New("at least one agent pool must not have role 'daemonset'")
+	if err := p.validateSystemAgentPools(); !helpers.EqualError(err, expectedErr) {
+		t.Errorf("expected error: %v\ngot error: %v", expectedErr, err)
+	}
 
-	t.Run("Should contain unique ports", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = OpenShift
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].Ports = []int{80, 443, 80}
-		agentPoolProfiles[0].DNSPrefix = "sampleprefix"
-		expectedMsg := "agent profile 'agentpool' has duplicate port '80', ports must be unique"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	p.AgentPoolProfiles = append(p.AgentPoolProfiles, &AgentPoolProfile{Name: "agentpool", OSType: Linux})
+	if err := p.validateSystemAgentPools(); err != nil {
+		t.Errorf("expected no error for a daemonset pool next to a pool without role, got %v", err)
+	}
 
-	t.Run("Should contain valid Storage Profile", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = OpenShift
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].DiskSizesGB = []int{512, 256, 768}
-		agentPoolProfiles[0].DNSPrefix = "sampleprefix"
-		expectedMsg := "property 'StorageProfile' must be set to either 'StorageAccount' or 'ManagedDisks' when attaching disks"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	p.AgentPoolProfiles[0].OSType = Windows
+	expectedErr = errors.New("agent pool 'system' has role 'daemonset' but daemonset agent pools must run Linux")
+	if err := p.validateSystemAgentPools(); !helpers.EqualError(err, expectedErr) {
+		t.Errorf("expected error: %v\ngot error: %v", expectedErr, err)
+	}
+}
 
-	t.Run("Should contain valid Availability Profile", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = OpenShift
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].DiskSizesGB = []int{512, 256, 768}
-		agentPoolProfiles[0].StorageProfile = "ManagedDisks"
-		agentPoolProfiles[0].AvailabilityProfile = "InvalidAvailabilityProfile"
-		expectedMsg := "property 'AvailabilityProfile' must be set to either 'VirtualMachineScaleSets' or 'AvailabilitySet' when attaching disks"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+func TestValidateSwap(t *testing.T) {
+	tests := []struct {
+		name        string
+		k8sVersion  string
+		cluster     *KubernetesConfig
+		pool        *KubernetesConfig
+		osType      OSType
+		expectedErr error
+	}{
+		{
+			name:       "swap disabled",
+			k8sVersion: "1.6.9",
+		},
+		{
+			name:       "cluster-wide swap",
+			k8sVersion: "1.12.2",
+			cluster:    &KubernetesConfig{SwapEnabled: helpers.PointerToBool(true), SwapSizeMB: 4096},
+		},
+		{
+			name:       "cluster-wide swap skips Windows pools",
+			k8sVersion: "1.12.2",
+			cluster:    &KubernetesConfig{SwapEnabled: helpers.PointerToBool(true)},
+			osType:     Windows,
+		},
+		{
+			name:        "negative cluster-wide swap size",
+			k8sVersion:  "1.12.2",
+			cluster:     &KubernetesConfig{SwapEnabled: helpers.PointerToBool(true), SwapSizeMB: -1},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.SwapSizeMB '-1' must not be negative"),
+		},
+		{
+			name:        "negative pool swap size",
+			k8sVersion:  "1.12.2",
+			pool:        &KubernetesConfig{SwapEnabled: helpers.PointerToBool(true), SwapSizeMB: -1},
+			expectedErr: errors.New("agent pool 'agentpool' has swapSizeMB '-1', it must not be negative"),
+		},
+		{
+			name:        "pool swap on Windows",
+			k8sVersion:  "1.12.2",
+			pool:        &KubernetesConfig{SwapEnabled: helpers.PointerToBool(true)},
+			osType:      Windows,
+			expectedErr: errors.New("agent pool 'agentpool' enables swap, which is not supported on Windows"),
+		},
+		{
+			name:        "cluster-wide swap on an unsupported version",
+			k8sVersion:  "1.7.16",
+			cluster:     &KubernetesConfig{SwapEnabled: helpers.PointerToBool(true)},
+			expectedErr: errors.New("agent pool 'agentpool' enables swap, which is only available in Kubernetes version 1.8.0 or greater; unable to validate for Kubernetes version 1.7.16"),
+		},
+		{
+			name:       "pool opts out of cluster-wide swap on an unsupported version",
+			k8sVersion: "1.7.16",
+			cluster:    &KubernetesConfig{SwapEnabled: helpers.PointerToBool(true)},
+			pool:       &KubernetesConfig{SwapEnabled: helpers.PointerToBool(false)},
+		},
+	}
 
-	t.Run("Should not support both VirtualMachineScaleSets and StorageAccount", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = OpenShift
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].DiskSizesGB = []int{512, 256, 768}
-		agentPoolProfiles[0].StorageProfile = "StorageAccount"
-		agentPoolProfiles[0].AvailabilityProfile = "VirtualMachineScaleSets"
-		expectedMsg := "VirtualMachineScaleSets does not support storage account attached disks.  Instead specify 'StorageAccount': 'ManagedDisks' or specify AvailabilityProfile 'AvailabilitySet'"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: test.cluster,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:             "agentpool",
+						OSType:           test.osType,
+						KubernetesConfig: test.pool,
+					},
+				},
+			}
+			if err := p.validateSwap(test.k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
 }
 
-func TestValidateProperties_CustomNodeLabels(t *testing.T) {
-
-	t.Run("Should throw error for invalid Kubernetes Label Keys", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].CustomNodeLabels = map[string]string{
-			"a/b/c": "a",
-		}
-		expectedMsg := "Label key 'a/b/c' is invalid. Valid label keys have two segments: an optional prefix and name, separated by a slash (/). The name segment is required and must be 63 characters or less, beginning and ending with an alphanumeric character ([a-z0-9A-Z]) with dashes (-), underscores (_), dots (.), and alphanumerics between. The prefix is optional. If specified, the prefix must be a DNS subdomain: a series of DNS labels separated by dots (.), not longer than 253 characters in total, followed by a slash (/)"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+func TestValidateReadOnlyRootFilesystem(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     *KubernetesConfig
+		pool        *KubernetesConfig
+		osType      OSType
+		distro      Distro
+		expectedErr error
+	}{
+		{
+			name: "read-only root disabled",
+		},
+		{
+			name:    "cluster-wide read-only root",
+			cluster: &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(true)},
+		},
+		{
+			name:    "cluster-wide read-only root with containerd",
+			cluster: &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(true), ContainerRuntime: "containerd"},
+		},
+		{
+			name:    "cluster-wide read-only root skips Windows pools",
+			cluster: &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(true)},
+			osType:  Windows,
+		},
+		{
+			name:        "pool read-only root on Windows",
+			pool:        &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(true)},
+			osType:      Windows,
+			expectedErr: errors.New("agent pool 'agentpool' enables readOnlyRootFilesystem, which is not supported on Windows"),
+		},
+		{
+			name:        "pool read-only root on CoreOS",
+			pool:        &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(true)},
+			distro:      CoreOS,
+			expectedErr: errors.New("agent pool 'agentpool' enables readOnlyRootFilesystem, which is not supported with the coreos distro"),
+		},
+		{
+			name:        "cluster-wide read-only root with kata containers",
+			cluster:     &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(true), ContainerRuntime: "kata-containers"},
+			expectedErr: errors.New("agent pool 'agentpool' enables readOnlyRootFilesystem, which is only supported with the docker and containerd container runtimes, not kata-containers"),
+		},
+		{
+			name:    "pool opts out of cluster-wide read-only root with clear containers",
+			cluster: &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(true), ContainerRuntime: "clear-containers"},
+			pool:    &KubernetesConfig{ReadOnlyRootFilesystem: helpers.PointerToBool(false)},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: test.cluster,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:             "agentpool",
+						OSType:           test.osType,
+						Distro:           test.distro,
+						KubernetesConfig: test.pool,
+					},
+				},
+			}
+			if err := p.validateReadOnlyRootFilesystem(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateResolvConf(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     *KubernetesConfig
+		master      *KubernetesConfig
+		pool        *KubernetesConfig
+		osType      OSType
+		expectedErr error
+	}{
+		{
+			name: "default resolv.conf",
+		},
+		{
+			name:    "cluster-wide resolv.conf",
+			cluster: &KubernetesConfig{ResolvConf: "/run/systemd/resolve/resolv.conf", DisableResolvedStub: helpers.PointerToBool(true)},
+		},
+		{
+			name:    "cluster-wide resolv.conf skips Windows pools",
+			cluster: &KubernetesConfig{ResolvConf: "/run/systemd/resolve/resolv.conf"},
+			osType:  Windows,
+		},
+		{
+			name:        "relative cluster-wide resolv.conf",
+			cluster:     &KubernetesConfig{ResolvConf: "resolv.conf"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.ResolvConf 'resolv.conf' needs to be an absolute path"),
+		},
+		{
+			name:        "master resolv.conf with a space",
+			master:      &KubernetesConfig{ResolvConf: "/etc/resolv conf"},
+			expectedErr: errors.New("MasterProfile.KubernetesConfig.ResolvConf '/etc/resolv conf' needs to be an absolute path"),
+		},
+		{
+			name:        "unclean pool resolv.conf",
+			pool:        &KubernetesConfig{ResolvConf: "/etc/../resolv.conf"},
+			expectedErr: errors.New("agent pool 'agentpool' has resolvConf '/etc/../resolv.conf', it needs to be an absolute path"),
+		},
+		{
+			name:        "pool resolv.conf on Windows",
+			pool:        &KubernetesConfig{ResolvConf: "/etc/resolv.conf"},
+			osType:      Windows,
+			expectedErr: errors.New("agent pool 'agentpool' configures the DNS resolver of its nodes, which is not supported on Windows"),
+		},
+		{
+			name:        "pool disables the stub resolver on Windows",
+			pool:        &KubernetesConfig{DisableResolvedStub: helpers.PointerToBool(true)},
+			osType:      Windows,
+			expectedErr: errors.New("agent pool 'agentpool' configures the DNS resolver of its nodes, which is not supported on Windows"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: test.cluster,
+				},
+				MasterProfile: &MasterProfile{
+					KubernetesConfig: test.master,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:             "agentpool",
+						OSType:           test.osType,
+						KubernetesConfig: test.pool,
+					},
+				},
+			}
+			if err := p.validateResolvConf(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateCgroupDriver(t *testing.T) {
+	tests := []struct {
+		name             string
+		cgroupDriver     string
+		kubeletConfig    map[string]string
+		containerRuntime string
+		expectedErr      error
+	}{
+		{
+			name: "default cgroup driver",
+		},
+		{
+			name:         "systemd with docker",
+			cgroupDriver: CgroupDriverSystemd,
+		},
+		{
+			name:             "systemd with containerd",
+			cgroupDriver:     CgroupDriverSystemd,
+			containerRuntime: "containerd",
+		},
+		{
+			name:          "kubelet flag",
+			kubeletConfig: map[string]string{"--cgroup-driver": CgroupDriverSystemd},
+		},
+		{
+			name:         "unknown cgroup driver",
+			cgroupDriver: "cgroup",
+			expectedErr:  errors.New(`unknown cgroupDriver "cgroup" specified, supported values are "cgroupfs" and "systemd"`),
+		},
+		{
+			name:          "unknown kubelet flag",
+			kubeletConfig: map[string]string{"--cgroup-driver": "cgroup"},
+			expectedErr:   errors.New(`unknown cgroupDriver "cgroup" specified, supported values are "cgroupfs" and "systemd"`),
+		},
+		{
+			name:             "systemd with kata containers",
+			cgroupDriver:     CgroupDriverSystemd,
+			containerRuntime: "kata-containers",
+			expectedErr:      errors.New(`cgroupDriver "systemd" is not supported with containerRuntime "kata-containers"`),
+		},
+		{
+			name:             "systemd kubelet flag with clear containers",
+			kubeletConfig:    map[string]string{"--cgroup-driver": CgroupDriverSystemd},
+			containerRuntime: "clear-containers",
+			expectedErr:      errors.New(`cgroupDriver "systemd" is not supported with containerRuntime "clear-containers"`),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{
+						CgroupDriver:     test.cgroupDriver,
+						KubeletConfig:    test.kubeletConfig,
+						ContainerRuntime: test.containerRuntime,
+					},
+				},
+			}
+			if err := p.validateCgroupDriver(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateCgroupV2(t *testing.T) {
+	tests := []struct {
+		name        string
+		k8sVersion  string
+		cluster     *KubernetesConfig
+		master      *KubernetesConfig
+		pool        *KubernetesConfig
+		osType      OSType
+		distro      Distro
+		expectedErr error
+	}{
+		{
+			name:       "cgroup v2 disabled",
+			k8sVersion: "1.11.4",
+		},
+		{
+			name:       "cluster-wide cgroup v2",
+			k8sVersion: "1.12.2",
+			cluster:    &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)},
+		},
+		{
+			name:       "cluster-wide cgroup v2 with the systemd cgroup driver",
+			k8sVersion: "1.12.2",
+			cluster:    &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true), CgroupDriver: CgroupDriverSystemd, ContainerRuntime: "docker"},
+		},
+		{
+			name:        "cluster-wide cgroup v2 with an older kubelet",
+			k8sVersion:  "1.11.5",
+			cluster:     &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)},
+			expectedErr: errors.New("the master profile enables cgroupV2Enabled, which is only available in Kubernetes version 1.12.0 or greater; unable to validate for Kubernetes version 1.11.5"),
+		},
+		{
+			name:        "pool cgroup v2 with an older kubelet",
+			k8sVersion:  "1.11.5",
+			pool:        &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)},
+			expectedErr: errors.New("agent pool 'agentpool' enables cgroupV2Enabled, which is only available in Kubernetes version 1.12.0 or greater; unable to validate for Kubernetes version 1.11.5"),
+		},
+		{
+			name:        "master cgroup v2 with the cgroupfs kubelet flag",
+			k8sVersion:  "1.12.2",
+			cluster:     &KubernetesConfig{KubeletConfig: map[string]string{"--cgroup-driver": CgroupDriverCgroupfs}},
+			master:      &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)},
+			expectedErr: errors.New(`the master profile enables cgroupV2Enabled, which requires the "systemd" cgroupDriver, not "cgroupfs"`),
+		},
+		{
+			name:        "cluster-wide cgroup v2 with containerd",
+			k8sVersion:  "1.12.2",
+			cluster:     &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true), ContainerRuntime: "containerd"},
+			expectedErr: errors.New("the master profile enables cgroupV2Enabled, which is only supported with the docker container runtime, not containerd"),
+		},
+		{
+			name:        "pool cgroup v2 on CoreOS",
+			k8sVersion:  "1.12.2",
+			pool:        &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)},
+			distro:      CoreOS,
+			expectedErr: errors.New("agent pool 'agentpool' enables cgroupV2Enabled, which is not supported with the coreos distro"),
+		},
+		{
+			name:        "pool cgroup v2 on Windows",
+			k8sVersion:  "1.12.2",
+			pool:        &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)},
+			osType:      Windows,
+			expectedErr: errors.New("agent pool 'agentpool' enables cgroupV2Enabled, which is not supported on Windows"),
+		},
+		{
+			name:       "nodes opt out of cluster-wide cgroup v2 with an older kubelet",
+			k8sVersion: "1.12.1",
+			cluster:    &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(true)},
+			master:     &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(false)},
+			pool:       &KubernetesConfig{CgroupV2Enabled: helpers.PointerToBool(false)},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: test.cluster,
+				},
+				MasterProfile: &MasterProfile{
+					KubernetesConfig: test.master,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:             "agentpool",
+						OSType:           test.osType,
+						Distro:           test.distro,
+						KubernetesConfig: test.pool,
+					},
+				},
+			}
+			if err := p.validateCgroupV2(test.k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateProcessLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		k8sVersion  string
+		cluster     *KubernetesConfig
+		master      *KubernetesConfig
+		pool        *KubernetesConfig
+		osType      OSType
+		expectedErr error
+	}{
+		{
+			name:       "no process limits",
+			k8sVersion: "1.9.10",
+		},
+		{
+			name:       "cluster-wide process limits",
+			k8sVersion: "1.11.4",
+			cluster:    &KubernetesConfig{PodMaxPids: 1024, PidMax: 4194304, MaxOpenFiles: 1048576},
+		},
+		{
+			name:        "negative cluster-wide podMaxPids",
+			k8sVersion:  "1.11.4",
+			cluster:     &KubernetesConfig{PodMaxPids: -1},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig has podMaxPids '-1', it must be a positive value"),
+		},
+		{
+			name:        "master pidMax above the kernel limit",
+			k8sVersion:  "1.11.4",
+			master:      &KubernetesConfig{PidMax: 4194305},
+			expectedErr: errors.New("the master profile has pidMax '4194305', it must be a positive value no greater than 4194304"),
+		},
+		{
+			name:        "negative pool maxOpenFiles",
+			k8sVersion:  "1.11.4",
+			pool:        &KubernetesConfig{MaxOpenFiles: -65536},
+			expectedErr: errors.New("agent pool 'agentpool' has maxOpenFiles '-65536', it must be a positive value"),
+		},
+		{
+			name:        "pool podMaxPids with an older kubelet",
+			k8sVersion:  "1.9.10",
+			pool:        &KubernetesConfig{PodMaxPids: 1024},
+			expectedErr: errors.New("agent pool 'agentpool' sets podMaxPids, which is only available in Kubernetes version 1.10.0 or greater; unable to validate for Kubernetes version 1.9.10"),
+		},
+		{
+			name:        "pool pidMax on Windows",
+			k8sVersion:  "1.11.4",
+			pool:        &KubernetesConfig{PidMax: 65536},
+			osType:      Windows,
+			expectedErr: errors.New("agent pool 'agentpool' sets pidMax or maxOpenFiles, which are not supported on Windows"),
+		},
+		{
+			name:       "pool podMaxPids on Windows",
+			k8sVersion: "1.11.4",
+			pool:       &KubernetesConfig{PodMaxPids: 1024},
+			osType:     Windows,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: test.cluster,
+				},
+				MasterProfile: &MasterProfile{
+					KubernetesConfig: test.master,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:             "agentpool",
+						OSType:           test.osType,
+						KubernetesConfig: test.pool,
+					},
+				},
+			}
+			if err := p.validateProcessLimits(test.k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatePrePulledImages(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     *KubernetesConfig
+		master      *KubernetesConfig
+		pool        *KubernetesConfig
+		osType      OSType
+		expectedErr error
+	}{
+		{
+			name: "no pre-pulled images",
+		},
+		{
+			name:    "cluster-wide pre-pulled images",
+			cluster: &KubernetesConfig{PrePulledImages: []string{"nginx", "myregistry.azurecr.io/team/app:1.2.3", "k8s.gcr.io/pause-amd64@sha256:59eec8837a4d942cc19a52b8c09ea75121acc38114a2c68b98983ce9356b8610"}},
+		},
+		{
+			name:        "invalid cluster-wide image",
+			cluster:     &KubernetesConfig{PrePulledImages: []string{"nginx:"}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig has prePulledImages 'nginx:', which is not a valid container image reference"),
+		},
+		{
+			name:        "invalid master image",
+			master:      &KubernetesConfig{PrePulledImages: []string{"Nginx"}},
+			expectedErr: errors.New("the master profile has prePulledImages 'Nginx', which is not a valid container image reference"),
+		},
+		{
+			name:        "invalid pool image",
+			pool:        &KubernetesConfig{PrePulledImages: []string{"busybox", "my registry/app"}},
+			expectedErr: errors.New("agent pool 'agentpool' has prePulledImages 'my registry/app', which is not a valid container image reference"),
+		},
+		{
+			name:        "pool images on Windows",
+			pool:        &KubernetesConfig{PrePulledImages: []string{"microsoft/nanoserver"}},
+			osType:      Windows,
+			expectedErr: errors.New("agent pool 'agentpool' sets prePulledImages, which are not supported on Windows"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: test.cluster,
+				},
+				MasterProfile: &MasterProfile{
+					KubernetesConfig: test.master,
+				},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Name:             "agentpool",
+						OSType:           test.osType,
+						KubernetesConfig: test.pool,
+					},
+				},
+			}
+			if err := p.validatePrePulledImages(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkPluginMode(t *testing.T) {
+	tests := []struct {
+		name              string
+		k8sVersion        string
+		networkPlugin     string
+		networkPluginMode string
+		clusterSubnet     string
+		hasWindows        bool
+		expectedErr       error
+	}{
+		{
+			name:          "no mode",
+			k8sVersion:    "1.7.9",
+			networkPlugin: "kubenet",
+		},
+		{
+			name:              "overlay",
+			k8sVersion:        "1.11.4",
+			networkPlugin:     "azure",
+			networkPluginMode: "overlay",
+			clusterSubnet:     "10.244.0.0/16",
+			expectedErr:       errors.New("networkPluginMode 'overlay' is not supported yet, it requires the Azure CNS daemon and a version of the Azure CNI plugin with overlay support"),
+		},
+		{
+			name:              "unknown mode",
+			k8sVersion:        "1.11.4",
+			networkPlugin:     "azure",
+			networkPluginMode: "bridge",
+			expectedErr:       errors.New("unknown networkPluginMode 'bridge' specified"),
+		},
+		{
+			name:              "overlay with kubenet",
+			k8sVersion:        "1.11.4",
+			networkPlugin:     "kubenet",
+			networkPluginMode: "overlay",
+			expectedErr:       errors.New("networkPluginMode 'overlay' requires the azure networkPlugin"),
+		},
+		{
+			name:              "overlay with the default plugin",
+			k8sVersion:        "1.11.4",
+			networkPluginMode: "overlay",
+			expectedErr:       errors.New("networkPluginMode 'overlay' requires the azure networkPlugin"),
+		},
+		{
+			name:              "overlay with an older version",
+			k8sVersion:        "1.10.9",
+			networkPlugin:     "azure",
+			networkPluginMode: "overlay",
+			expectedErr:       errors.New("networkPluginMode 'overlay' is only available in Kubernetes version 1.11.0 or greater; unable to validate for Kubernetes version 1.10.9"),
+		},
+		{
+			name:              "overlay with Windows",
+			k8sVersion:        "1.11.4",
+			networkPlugin:     "azure",
+			networkPluginMode: "overlay",
+			hasWindows:        true,
+			expectedErr:       errors.New("networkPluginMode 'overlay' is not supported with Windows agent pools"),
+		},
+		{
+			name:              "overlay with dual-stack",
+			k8sVersion:        "1.16.0",
+			networkPlugin:     "azure",
+			networkPluginMode: "overlay",
+			clusterSubnet:     "10.244.0.0/16,fd00:10:244::/56",
+			expectedErr:       errors.New("networkPluginMode 'overlay' is not supported with IPv6 dual-stack networking"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				ClusterSubnet:     test.clusterSubnet,
+				NetworkPlugin:     test.networkPlugin,
+				NetworkPluginMode: test.networkPluginMode,
+			}
+			if err := k.validateNetworkPluginMode(test.k8sVersion, test.hasWindows); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateTLSConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		tlsCipherSuites []string
+		tlsMinVersion   string
+		expectedErr     error
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:            "valid settings",
+			tlsCipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+			tlsMinVersion:   "VersionTLS12",
+		},
+		{
+			name:            "unknown cipher suite",
+			tlsCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_AES_128_GCM_SHA256"},
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.TLSCipherSuites 'TLS_AES_128_GCM_SHA256' is not a valid cipher suite, valid cipher suites are " + strings.Join(TLSCipherSuiteValues[:], ", ")),
+		},
+		{
+			name:          "unknown min version",
+			tlsMinVersion: "TLS1.2",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.TLSMinVersion 'TLS1.2' is not a valid TLS version, valid versions are VersionTLS10, VersionTLS11, VersionTLS12"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				TLSCipherSuites: test.tlsCipherSuites,
+				TLSMinVersion:   test.tlsMinVersion,
+			}
+			if err := k.validateTLSConfig(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateKubeletHealthz(t *testing.T) {
+	tests := []struct {
+		name          string
+		kubeletConfig map[string]string
+		expectedErr   error
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "default healthz endpoint",
+			kubeletConfig: map[string]string{
+				"--healthz-port":         "10248",
+				"--healthz-bind-address": "127.0.0.1",
+			},
+		},
+		{
+			name: "disabled healthz port",
+			kubeletConfig: map[string]string{
+				"--healthz-port": "0",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.KubeletConfig['--healthz-port'] '0' is not supported, the kubelet health monitor probes port 10248"),
+		},
+		{
+			name: "healthz bound to another address",
+			kubeletConfig: map[string]string{
+				"--healthz-bind-address": "10.240.0.4",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.KubeletConfig['--healthz-bind-address'] '10.240.0.4' is not supported, the kubelet health monitor probes 127.0.0.1"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				KubeletConfig: test.kubeletConfig,
+			}
+			if err := k.validateKubeletHealthz(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateEtcdStorageLimitGB(t *testing.T) {
+	tests := []struct {
+		name               string
+		etcdStorageLimitGB int
+		etcdDiskSizeGB     string
+		etcdVersion        string
+		expectedErr        error
+	}{
+		{
+			name: "default quota",
+		},
+		{
+			name:               "maximum quota",
+			etcdStorageLimitGB: 8,
+			etcdDiskSizeGB:     "256",
+		},
+		{
+			name:               "quota smaller than the etcd default",
+			etcdStorageLimitGB: 1,
+			expectedErr:        errors.New("OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB 1 is out of range, it must be between 2 and 8"),
+		},
+		{
+			name:               "quota larger than etcd supports",
+			etcdStorageLimitGB: 16,
+			expectedErr:        errors.New("OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB 16 is out of range, it must be between 2 and 8"),
+		},
+		{
+			name:               "quota filling the etcd disk",
+			etcdStorageLimitGB: 4,
+			etcdDiskSizeGB:     "4",
+			expectedErr:        errors.New("OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB 4 must be smaller than OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB 4"),
+		},
+		{
+			name:               "etcd 2.x",
+			etcdStorageLimitGB: 4,
+			etcdVersion:        "2.3.8",
+			expectedErr:        errors.New("OrchestratorProfile.KubernetesConfig.EtcdStorageLimitGB requires etcd 3.0.0 or greater, not 2.3.8"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				EtcdStorageLimitGB: test.etcdStorageLimitGB,
+				EtcdDiskSizeGB:     test.etcdDiskSizeGB,
+				EtcdVersion:        test.etcdVersion,
+			}
+			if err := k.validateEtcdStorageLimitGB(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateCSIDriverAddon(t *testing.T) {
+	tests := []struct {
+		name                      string
+		k8sVersion                string
+		useCloudControllerManager *bool
+		expectedErr               error
+	}{
+		{
+			name:                      "external cloud provider",
+			k8sVersion:                "1.13.0-alpha.2",
+			useCloudControllerManager: helpers.PointerToBool(true),
+		},
+		{
+			name:        "in-tree cloud provider",
+			k8sVersion:  "1.13.0-alpha.2",
+			expectedErr: errors.New("azuredisk-csi-driver add-on requires the external cloud provider. Please specify \"useCloudControllerManager\": true"),
+		},
+		{
+			name:                      "explicit in-tree cloud provider",
+			k8sVersion:                "1.13.0-alpha.2",
+			useCloudControllerManager: helpers.PointerToBool(false),
+			expectedErr:               errors.New("azuredisk-csi-driver add-on requires the external cloud provider. Please specify \"useCloudControllerManager\": true"),
+		},
+		{
+			name:                      "CSI 0.x",
+			k8sVersion:                "1.12.2",
+			useCloudControllerManager: helpers.PointerToBool(true),
+			expectedErr:               errors.New("azuredisk-csi-driver add-on requires CSI 1.0 and can only be used with Kubernetes 1.13.0-alpha.1 or above, the version is 1.12.2"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateCSIDriverAddon("azuredisk-csi-driver", test.k8sVersion, test.useCloudControllerManager)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDNSAutoscalerAddon(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]string
+		expectedErr error
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "valid config",
+			config: map[string]string{
+				"coresPerReplica": "128",
+				"nodesPerReplica": "0.5",
+				"min":             "2",
+			},
+		},
+		{
+			name:        "zero cores per replica",
+			config:      map[string]string{"coresPerReplica": "0"},
+			expectedErr: errors.New("dns-autoscaler add-on config coresPerReplica '0' is invalid, expected a positive number"),
+		},
+		{
+			name:        "negative nodes per replica",
+			config:      map[string]string{"nodesPerReplica": "-16"},
+			expectedErr: errors.New("dns-autoscaler add-on config nodesPerReplica '-16' is invalid, expected a positive number"),
+		},
+		{
+			name:        "nodes per replica which is not a JSON number",
+			config:      map[string]string{"nodesPerReplica": "NaN"},
+			expectedErr: errors.New("dns-autoscaler add-on config nodesPerReplica 'NaN' is invalid, expected a positive number"),
+		},
+		{
+			name:        "zero min replicas",
+			config:      map[string]string{"min": "0"},
+			expectedErr: errors.New("dns-autoscaler add-on config min '0' is invalid, expected a positive number of replicas"),
+		},
+		{
+			name:        "fractional min replicas",
+			config:      map[string]string{"min": "1.5"},
+			expectedErr: errors.New("dns-autoscaler add-on config min '1.5' is invalid, expected a positive number of replicas"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateDNSAutoscalerAddon(test.config)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateVeleroAddon(t *testing.T) {
+	tests := []struct {
+		name               string
+		config             map[string]string
+		useManagedIdentity bool
+		certificate        string
+		expectedErr        error
+	}{
+		{
+			name: "valid config",
+			config: map[string]string{
+				"storageAccount": "backups01",
+				"resourceGroup":  "backups-rg",
+				"container":      "cluster-backups",
+			},
+		},
+		{
+			name: "default container",
+			config: map[string]string{
+				"storageAccount": "backups01",
+				"resourceGroup":  "backups-rg",
+			},
+		},
+		{
+			name: "no storage account",
+			config: map[string]string{
+				"resourceGroup": "backups-rg",
+			},
+			expectedErr: errors.New("velero add-on config storageAccount '' is invalid, expected the name of a storage account of 3 to 24 lowercase letters and numbers"),
+		},
+		{
+			name: "invalid storage account",
+			config: map[string]string{
+				"storageAccount": "Backups-01",
+				"resourceGroup":  "backups-rg",
+			},
+			expectedErr: errors.New("velero add-on config storageAccount 'Backups-01' is invalid, expected the name of a storage account of 3 to 24 lowercase letters and numbers"),
+		},
+		{
+			name: "no resource group",
+			config: map[string]string{
+				"storageAccount": "backups01",
+			},
+			expectedErr: errors.New("velero add-on config resourceGroup '' is invalid, expected the name of the resource group of the storage account"),
+		},
+		{
+			name: "resource group ending with a period",
+			config: map[string]string{
+				"storageAccount": "backups01",
+				"resourceGroup":  "backups.",
+			},
+			expectedErr: errors.New("velero add-on config resourceGroup 'backups.' is invalid, expected the name of the resource group of the storage account"),
+		},
+		{
+			name: "container with consecutive hyphens",
+			config: map[string]string{
+				"storageAccount": "backups01",
+				"resourceGroup":  "backups-rg",
+				"container":      "cluster--backups",
+			},
+			expectedErr: errors.New("velero add-on config container 'cluster--backups' is invalid, expected the name of a blob container of 3 to 63 lowercase letters, numbers and single hyphens"),
+		},
+		{
+			name: "container too long",
+			config: map[string]string{
+				"storageAccount": "backups01",
+				"resourceGroup":  "backups-rg",
+				"container":      strings.Repeat("a", 64),
+			},
+			expectedErr: errors.Errorf("velero add-on config container '%s' is invalid, expected the name of a blob container of 3 to 63 lowercase letters, numbers and single hyphens", strings.Repeat("a", 64)),
+		},
+		{
+			name: "managed identity",
+			config: map[string]string{
+				"storageAccount": "backups01",
+				"resourceGroup":  "backups-rg",
+			},
+			useManagedIdentity: true,
+			expectedErr:        errors.New("velero add-on requires the secret of a service principal and cannot be used with \"useManagedIdentity\": true"),
+		},
+		{
+			name: "service principal certificate",
+			config: map[string]string{
+				"storageAccount": "backups01",
+				"resourceGroup":  "backups-rg",
+			},
+			certificate: "certificate",
+			expectedErr: errors.New("velero add-on requires the secret of a service principal and cannot be used with a service principal certificate"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				UseManagedIdentity: test.useManagedIdentity,
+			}
+			p.ServicePrincipalProfile.Certificate = test.certificate
+			err := p.validateVeleroAddon(test.config)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDefaultStorageClass(t *testing.T) {
+	tests := []struct {
+		name         string
+		k8sVersion   string
+		storageClass *StorageClass
+		expectedErr  error
+	}{
+		{
+			name:       "no default StorageClass",
+			k8sVersion: "1.11.5",
+		},
+		{
+			name:         "defaults",
+			k8sVersion:   "1.11.5",
+			storageClass: &StorageClass{},
+		},
+		{
+			name:       "premium Azure disks",
+			k8sVersion: "1.11.5",
+			storageClass: &StorageClass{
+				Provisioner:       "kubernetes.io/azure-disk",
+				SKU:               "Premium_LRS",
+				ReclaimPolicy:     "Retain",
+				VolumeBindingMode: "WaitForFirstConsumer",
+			},
+		},
+		{
+			name:       "geo-redundant Azure files",
+			k8sVersion: "1.11.5",
+			storageClass: &StorageClass{
+				Provisioner: "kubernetes.io/azure-file",
+				SKU:         "Standard_GRS",
+			},
+		},
+		{
+			name:       "unsupported provisioner",
+			k8sVersion: "1.11.5",
+			storageClass: &StorageClass{
+				Provisioner: "kubernetes.io/aws-ebs",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.Provisioner 'kubernetes.io/aws-ebs' is not supported, the supported provisioners are kubernetes.io/azure-disk, kubernetes.io/azure-file"),
+		},
+		{
+			name:       "Azure disks with a SKU of Azure files",
+			k8sVersion: "1.11.5",
+			storageClass: &StorageClass{
+				Provisioner: "kubernetes.io/azure-disk",
+				SKU:         "Standard_GRS",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.SKU 'Standard_GRS' is not supported by the provisioner, the supported SKUs are Standard_LRS, StandardSSD_LRS, Premium_LRS"),
+		},
+		{
+			name:       "Azure files with a SKU of Azure disks",
+			k8sVersion: "1.11.5",
+			storageClass: &StorageClass{
+				Provisioner: "kubernetes.io/azure-file",
+				SKU:         "StandardSSD_LRS",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.SKU 'StandardSSD_LRS' is not supported by the provisioner, the supported SKUs are Standard_LRS, Standard_GRS, Standard_ZRS, Standard_RAGRS, Premium_LRS"),
+		},
+		{
+			name:       "unsupported reclaim policy",
+			k8sVersion: "1.11.5",
+			storageClass: &StorageClass{
+				ReclaimPolicy: "Recycle",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.ReclaimPolicy 'Recycle' is not supported, the supported reclaim policies are Delete, Retain"),
+		},
+		{
+			name:       "unsupported volume binding mode",
+			k8sVersion: "1.11.5",
+			storageClass: &StorageClass{
+				VolumeBindingMode: "Lazy",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.VolumeBindingMode 'Lazy' is not supported, the supported volume binding modes are Immediate, WaitForFirstConsumer"),
+		},
+		{
+			name:       "delayed volume binding before 1.10",
+			k8sVersion: "1.9.11",
+			storageClass: &StorageClass{
+				VolumeBindingMode: "WaitForFirstConsumer",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.DefaultStorageClass.VolumeBindingMode WaitForFirstConsumer requires Kubernetes 1.10.0 or greater, the version is 1.9.11"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				DefaultStorageClass: test.storageClass,
+			}
+			if err := k.validateDefaultStorageClass(test.k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateFeatureGates(t *testing.T) {
+	tests := []struct {
+		name         string
+		k8sVersion   string
+		featureGates map[string]bool
+		expectedErr  error
+	}{
+		{
+			name:       "no feature gates",
+			k8sVersion: "1.11.5",
+		},
+		{
+			name:       "known feature gates",
+			k8sVersion: "1.11.5",
+			featureGates: map[string]bool{
+				"PodPriority":      false,
+				"VolumeScheduling": true,
+			},
+		},
+		{
+			name:       "unknown feature gate",
+			k8sVersion: "1.11.5",
+			featureGates: map[string]bool{
+				"PodPriority": true,
+				"FastForward": true,
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.FeatureGates 'FastForward' is not a known feature gate of Kubernetes 1.11.5"),
+		},
+		{
+			name:       "feature gate introduced after the version",
+			k8sVersion: "1.11.5",
+			featureGates: map[string]bool{
+				"RuntimeClass": true,
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.FeatureGates 'RuntimeClass' is not a known feature gate of Kubernetes 1.11.5"),
+		},
+		{
+			name:       "feature gate removed before the version",
+			k8sVersion: "1.11.5",
+			featureGates: map[string]bool{
+				"Accelerators": true,
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.FeatureGates 'Accelerators' is not a known feature gate of Kubernetes 1.11.5"),
+		},
+		{
+			name:       "feature gate before its removal",
+			k8sVersion: "1.10.12",
+			featureGates: map[string]bool{
+				"Accelerators": true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				FeatureGates: test.featureGates,
+			}
+			if err := k.validateFeatureGates(test.k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateIPv6DualStack(t *testing.T) {
+	tests := []struct {
+		name          string
+		k8sVersion    string
+		clusterSubnet string
+		serviceCidr   string
+		networkPlugin string
+		networkPolicy string
+		hasWindows    bool
+		expectedErr   error
+	}{
+		{
+			name:          "single-stack",
+			k8sVersion:    "1.11.4",
+			clusterSubnet: "10.244.0.0/16",
+			serviceCidr:   "10.0.0.0/16",
+		},
+		{
+			name:          "dual-stack pods",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,fd00:10:244::/56",
+		},
+		{
+			name:          "dual-stack pods and services with azure cni",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.240.0.0/12,fd00:10:240::/48",
+			serviceCidr:   "10.0.0.0/16,fd00:10:96::/112",
+			networkPlugin: "azure",
+		},
+		{
+			name:          "dual-stack services only",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16",
+			serviceCidr:   "10.0.0.0/16,fd00:10:96::/112",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.ServiceCidr '10.0.0.0/16,fd00:10:96::/112' can only list an IPv6 CIDR if ClusterSubnet lists one too"),
+		},
+		{
+			name:          "IPv6 cluster subnet first",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "fd00:10:244::/56,10.244.0.0/16",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.ClusterSubnet 'fd00:10:244::/56,10.244.0.0/16' must be an IPv4 CIDR followed by an IPv6 CIDR, separated by a comma"),
+		},
+		{
+			name:          "two IPv4 cluster subnets",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,10.245.0.0/16",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.ClusterSubnet '10.244.0.0/16,10.245.0.0/16' must be an IPv4 CIDR followed by an IPv6 CIDR, separated by a comma"),
+		},
+		{
+			name:          "IPv6 cluster subnet too large",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,fd00::/32",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.ClusterSubnet IPv6 CIDR 'fd00::/32' must have a prefix length between 48 and 63, as the nodes are allocated a /64 each"),
+		},
+		{
+			name:          "invalid IPv6 service CIDR",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,fd00:10:244::/56",
+			serviceCidr:   "10.0.0.0/16,fd00:10:96::/112,fd00:10:97::/112",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.ServiceCidr '10.0.0.0/16,fd00:10:96::/112,fd00:10:97::/112' must be an IPv4 CIDR followed by an IPv6 CIDR, separated by a comma"),
+		},
+		{
+			name:          "IPv6 service CIDR too large",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,fd00:10:244::/56",
+			serviceCidr:   "10.0.0.0/16,fd00:10:96::/64",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.ServiceCidr IPv6 CIDR 'fd00:10:96::/64' must have a prefix length of at least 108"),
+		},
+		{
+			name:          "dual-stack with an older version",
+			k8sVersion:    "1.12.1",
+			clusterSubnet: "10.244.0.0/16,fd00:10:244::/56",
+			expectedErr:   errors.New("IPv6 dual-stack networking is only available in Kubernetes version 1.16.0 or greater; unable to validate for Kubernetes version 1.12.1"),
+		},
+		{
+			name:          "dual-stack with Windows",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,fd00:10:244::/56",
+			hasWindows:    true,
+			expectedErr:   errors.New("IPv6 dual-stack networking is not supported with Windows agent pools"),
+		},
+		{
+			name:          "dual-stack with flannel",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,fd00:10:244::/56",
+			networkPlugin: "flannel",
+			expectedErr:   errors.New("IPv6 dual-stack networking is only supported with the kubenet and azure network plugins, not flannel"),
+		},
+		{
+			name:          "dual-stack with calico",
+			k8sVersion:    "1.16.0",
+			clusterSubnet: "10.244.0.0/16,fd00:10:244::/56",
+			networkPlugin: "kubenet",
+			networkPolicy: "calico",
+			expectedErr:   errors.New("IPv6 dual-stack networking is not supported with the calico network policy"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				ClusterSubnet: test.clusterSubnet,
+				ServiceCidr:   test.serviceCidr,
+				NetworkPlugin: test.networkPlugin,
+				NetworkPolicy: test.networkPolicy,
+			}
+			if err := k.validateIPv6DualStack(test.k8sVersion, test.hasWindows); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_VaultKeySecrets(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		secrets     []KeyVaultSecrets
+		expectedErr error
+	}{
+		{
+			name: "Empty Vault Certificates",
+			secrets: []KeyVaultSecrets{
+				{
+					SourceVault: &KeyVaultID{
+						ID: "0a0b0c0d0e0f",
+					},
+					VaultCertificates: []KeyVaultCertificate{},
+				},
+			},
+			expectedErr: errors.New("Valid KeyVaultSecrets must have no empty VaultCertificates"),
+		},
+		{
+			name: "No SourceVault ID",
+			secrets: []KeyVaultSecrets{
+				{
+					SourceVault: &KeyVaultID{},
+					VaultCertificates: []KeyVaultCertificate{
+						{
+							CertificateURL:   "dummyURL",
+							CertificateStore: "dummyCertStore",
+						},
+					},
+				},
+			},
+			expectedErr: errors.New("KeyVaultSecrets must have a SourceVault.ID"),
+		},
+		{
+			name: "Empty SourceVault",
+			secrets: []KeyVaultSecrets{
+				{
+					VaultCertificates: []KeyVaultCertificate{
+						{
+							CertificateURL:   "dummyURL",
+							CertificateStore: "dummyCertStore",
+						},
+					},
+				},
+			},
+			expectedErr: errors.New("missing SourceVault in KeyVaultSecrets"),
+		},
+		{
+			name: "Empty Certificate Store",
+			secrets: []KeyVaultSecrets{
+				{
+					SourceVault: &KeyVaultID{
+						ID: "0a0b0c0d0e0f",
+					},
+					VaultCertificates: []KeyVaultCertificate{
+						{
+							CertificateURL:   "dummyUrl",
+							CertificateStore: "",
+						},
+					},
+				},
+			},
+			expectedErr: errors.New("KeyVaultCertificate.CertificateStore must be a non-empty value for certificates in a WindowsProfile"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateKeyVaultSecrets(test.secrets, true)
+			if err.Error() != test.expectedErr.Error() {
+				t.Errorf("expected error to be thrown with msg : %s", test.expectedErr.Error())
+			}
+		})
+	}
+}
+
+func TestValidateProperties_OrchestratorSpecificProperties(t *testing.T) {
+	t.Run("Should not support DNS prefix for Kubernetes orchestrators", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].DNSPrefix = "sampleprefix"
+		expectedMsg := "AgentPoolProfile.DNSPrefix must be empty for Kubernetes"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s", expectedMsg)
+		}
+	})
+
+	t.Run("Should not contain agentPool ports for Kubernetes orchestrators", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].Ports = []int{80, 443, 8080}
+		expectedMsg := "AgentPoolProfile.Ports must be empty for Kubernetes"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should not support ScaleSetEviction policies with regular priority", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].Ports = []int{}
+		agentPoolProfiles[0].ScaleSetPriority = "Regular"
+		agentPoolProfiles[0].ScaleSetEvictionPolicy = "Deallocate"
+		expectedMsg := "property 'AgentPoolProfile.ScaleSetEvictionPolicy' must be empty for AgentPoolProfile.Priority of Regular"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should contain a valid DNS prefix", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = OpenShift
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].DNSPrefix = "invalid_prefix"
+		expectedMsg := "DNSPrefix 'invalid_prefix' is invalid. The DNSPrefix must contain between 3 and 45 characters and can contain only letters, numbers, and hyphens.  It must start with a letter and must end with a letter or a number. (length was 14)"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should not contain ports when DNS prefix is empty", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = OpenShift
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].Ports = []int{80, 443}
+		expectedMsg := "AgentPoolProfile.Ports must be empty when AgentPoolProfile.DNSPrefix is empty for Orchestrator: OpenShift"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should contain unique ports", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = OpenShift
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].Ports = []int{80, 443, 80}
+		agentPoolProfiles[0].DNSPrefix = "sampleprefix"
+		expectedMsg := "agent profile 'agentpool' has duplicate port '80', ports must be unique"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should contain valid Storage Profile", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = OpenShift
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].DiskSizesGB = []int{512, 256, 768}
+		agentPoolProfiles[0].DNSPrefix = "sampleprefix"
+		expectedMsg := "property 'StorageProfile' must be set to either 'StorageAccount' or 'ManagedDisks' when attaching disks"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should contain valid Availability Profile", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = OpenShift
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].DiskSizesGB = []int{512, 256, 768}
+		agentPoolProfiles[0].StorageProfile = "ManagedDisks"
+		agentPoolProfiles[0].AvailabilityProfile = "InvalidAvailabilityProfile"
+		expectedMsg := "property 'AvailabilityProfile' must be set to either 'VirtualMachineScaleSets' or 'AvailabilitySet' when attaching disks"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should not support both VirtualMachineScaleSets and StorageAccount", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = OpenShift
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].DiskSizesGB = []int{512, 256, 768}
+		agentPoolProfiles[0].StorageProfile = "StorageAccount"
+		agentPoolProfiles[0].AvailabilityProfile = "VirtualMachineScaleSets"
+		expectedMsg := "VirtualMachineScaleSets does not support storage account attached disks.  Instead specify 'StorageAccount': 'ManagedDisks' or specify AvailabilityProfile 'AvailabilitySet'"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+}
+
+func TestValidateProperties_CustomNodeLabels(t *testing.T) {
+
+	t.Run("Should throw error for invalid Kubernetes Label Keys", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].CustomNodeLabels = map[string]string{
+			"a/b/c": "a",
+		}
+		expectedMsg := "Label key 'a/b/c' is invalid. Valid label keys have two segments: an optional prefix and name, separated by a slash (/). The name segment is required and must be 63 characters or less, beginning and ending with an alphanumeric character ([a-z0-9A-Z]) with dashes (-), underscores (_), dots (.), and alphanumerics between. The prefix is optional. If specified, the prefix must be a DNS subdomain: a series of DNS labels separated by dots (.), not longer than 253 characters in total, followed by a slash (/)"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should throw error for invalid Kubernetes Label Values", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].CustomNodeLabels = map[string]string{
+			"fookey": "b$$a$$r",
+		}
+		expectedMsg := "Label value 'b$$a$$r' is invalid. Valid label values must be 63 characters or less and must be empty or begin and end with an alphanumeric character ([a-z0-9A-Z]) with dashes (-), underscores (_), dots (.), and alphanumerics between"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should not support orchestratorTypes other than Kubernetes/DCOS", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = SwarmMode
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].CustomNodeLabels = map[string]string{
+			"foo": "bar",
+		}
+		expectedMsg := "Agent CustomNodeLabels are only supported for DCOS and Kubernetes"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+}
+
+func TestAgentPoolProfile_ValidateAvailabilityProfile(t *testing.T) {
+	t.Run("Should fail for invalid availability profile", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = "InvalidAvailabilityProfile"
+		expectedMsg := "unknown availability profile type 'InvalidAvailabilityProfile' for agent pool 'agentpool'.  Specify either AvailabilitySet, or VirtualMachineScaleSets"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
+			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("Should fail when using VirtualMachineScalesets with Openshift", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.OrchestratorProfile.OrchestratorType = OpenShift
+		agentPoolProfiles := p.AgentPoolProfiles
+		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
+		expectedMsg := "Only AvailabilityProfile: AvailabilitySet is supported for Orchestrator 'OpenShift'"
+		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
 			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
 		}
 	})
 
-	t.Run("Should throw error for invalid Kubernetes Label Values", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].CustomNodeLabels = map[string]string{
-			"fookey": "b$$a$$r",
-		}
-		expectedMsg := "Label value 'b$$a$$r' is invalid. Valid label values must be 63 characters or less and must be empty or begin and end with an alphanumeric character ([a-z0-9A-Z]) with dashes (-), underscores (_), dots (.), and alphanumerics between"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	t.Run("Should accept the overprovisioning and upgrade policy of a VirtualMachineScaleSets pool", func(t *testing.T) {
+		t.Parallel()
+		a := &AgentPoolProfile{
+			Name:                "agentpool",
+			AvailabilityProfile: VirtualMachineScaleSets,
+			OverProvision:       helpers.PointerToBool(true),
+			UpgradePolicyMode:   "Rolling",
+		}
+		if err := a.validateAvailabilityProfile(Kubernetes); err != nil {
+			t.Errorf("expected no error, but got %s", err.Error())
+		}
+	})
+
+	t.Run("Should fail when setting the upgrade policy of an AvailabilitySet pool", func(t *testing.T) {
+		t.Parallel()
+		a := &AgentPoolProfile{
+			Name:                "agentpool",
+			AvailabilityProfile: AvailabilitySet,
+			UpgradePolicyMode:   "Automatic",
+		}
+		expectedMsg := "agent pool 'agentpool' sets overProvision or upgradePolicyMode, which are only supported by the availabilityProfile VirtualMachineScaleSets"
+		if err := a.validateAvailabilityProfile(Kubernetes); !helpers.EqualError(err, errors.New(expectedMsg)) {
+			t.Errorf("expected error with message : %s, but got %v", expectedMsg, err)
+		}
+	})
+
+	t.Run("Should fail when setting the overprovisioning of a pool of another orchestrator", func(t *testing.T) {
+		t.Parallel()
+		a := &AgentPoolProfile{
+			Name:                "agentpool",
+			AvailabilityProfile: VirtualMachineScaleSets,
+			OverProvision:       helpers.PointerToBool(false),
+		}
+		expectedMsg := "agent pool 'agentpool' sets overProvision or upgradePolicyMode, which are only supported by the Kubernetes orchestrator"
+		if err := a.validateAvailabilityProfile(DCOS); !helpers.EqualError(err, errors.New(expectedMsg)) {
+			t.Errorf("expected error with message : %s, but got %v", expectedMsg, err)
+		}
+	})
+}
+
+func TestAgentPoolProfile_ValidateCustomNodeTaints(t *testing.T) {
+	tests := []struct {
+		name             string
+		orchestratorType string
+		taints           []string
+		expectedErr      string
+	}{
+		{
+			name:             "valid taints",
+			orchestratorType: Kubernetes,
+			taints:           []string{"dedicated=gpu:NoSchedule", "example.com/spot:PreferNoSchedule", "maintenance=:NoExecute"},
+		},
+		{
+			name:             "missing effect",
+			orchestratorType: Kubernetes,
+			taints:           []string{"dedicated=gpu"},
+			expectedErr:      "agent pool 'agentpool' has an invalid custom node taint 'dedicated=gpu', expected the format key=value:Effect",
+		},
+		{
+			name:             "unknown effect",
+			orchestratorType: Kubernetes,
+			taints:           []string{"dedicated=gpu:NoRun"},
+			expectedErr:      "agent pool 'agentpool' has an invalid custom node taint 'dedicated=gpu:NoRun': unknown effect 'NoRun', specify either NoSchedule, PreferNoSchedule or NoExecute",
+		},
+		{
+			name:             "invalid value",
+			orchestratorType: Kubernetes,
+			taints:           []string{"dedicated=gpu nodes:NoSchedule"},
+			expectedErr:      "Label value 'gpu nodes' is invalid. Valid label values must be 63 characters or less and must be empty or begin and end with an alphanumeric character ([a-z0-9A-Z]) with dashes (-), underscores (_), dots (.), and alphanumerics between",
+		},
+		{
+			name:             "other orchestrator",
+			orchestratorType: DCOS,
+			taints:           []string{"dedicated=gpu:NoSchedule"},
+			expectedErr:      "Agent CustomNodeTaints are only supported for Kubernetes",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{Name: "agentpool", CustomNodeTaints: test.taints}
+			err := a.validateCustomNodeTaints(test.orchestratorType)
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, but got %s", err.Error())
+				}
+			} else if !helpers.EqualError(err, errors.New(test.expectedErr)) {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestAgentPoolProfile_ValidateKubeletConfig(t *testing.T) {
+	tests := []struct {
+		name                 string
+		clusterKubeletConfig map[string]string
+		poolKubeletConfig    map[string]string
+		expectedErr          string
+	}{
+		{
+			name: "valid thresholds",
+			poolKubeletConfig: map[string]string{
+				"--image-gc-high-threshold":    "70",
+				"--image-gc-low-threshold":     "60",
+				"--eviction-hard":              "memory.available<100Mi,nodefs.available<10%,nodefs.inodesFree<5%",
+				"--eviction-soft":              "memory.available<1.5Gi,imagefs.available<15%",
+				"--eviction-soft-grace-period": "memory.available=1m30s,imagefs.available=2m",
+			},
+		},
+		{
+			name: "inverted image-gc thresholds",
+			poolKubeletConfig: map[string]string{
+				"--image-gc-high-threshold": "60",
+				"--image-gc-low-threshold":  "70",
+			},
+			expectedErr: "agent pool 'agentpool' has an invalid kubeletConfig: --image-gc-low-threshold '70' must be lower than --image-gc-high-threshold '60'",
+		},
+		{
+			name: "pool image-gc threshold inverted against the cluster value",
+			clusterKubeletConfig: map[string]string{
+				"--image-gc-low-threshold": "80",
+			},
+			poolKubeletConfig: map[string]string{
+				"--image-gc-high-threshold": "75",
+			},
+			expectedErr: "agent pool 'agentpool' has an invalid kubeletConfig: --image-gc-low-threshold '80' must be lower than --image-gc-high-threshold '75'",
+		},
+		{
+			name: "image-gc threshold out of range",
+			poolKubeletConfig: map[string]string{
+				"--image-gc-high-threshold": "101",
+			},
+			expectedErr: "agent pool 'agentpool' has an invalid kubeletConfig: --image-gc-high-threshold '101' must be an integer percentage between 0 and 100",
+		},
+		{
+			name: "eviction percentage out of range",
+			poolKubeletConfig: map[string]string{
+				"--eviction-hard": "nodefs.available<110%",
+			},
+			expectedErr: "agent pool 'agentpool' has an invalid kubeletConfig: --eviction-hard threshold '110%' for nodefs.available must be a percentage between 0% and 100%",
+		},
+		{
+			name: "malformed eviction threshold",
+			poolKubeletConfig: map[string]string{
+				"--eviction-hard": "memory.available=100Mi",
+			},
+			expectedErr: "agent pool 'agentpool' has an invalid kubeletConfig: --eviction-hard 'memory.available=100Mi' is not a valid signal<quantity list",
+		},
+		{
+			name: "eviction-soft without grace period",
+			poolKubeletConfig: map[string]string{
+				"--eviction-soft": "memory.available<1Gi",
+			},
+			expectedErr: "agent pool 'agentpool' has an invalid kubeletConfig: --eviction-soft-grace-period must be specified when --eviction-soft is",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				KubeletConfig: test.clusterKubeletConfig,
+			}
+			p.AgentPoolProfiles[0].KubernetesConfig = &KubernetesConfig{
+				KubeletConfig: test.poolKubeletConfig,
+			}
+			err := p.validateAgentPoolProfiles(false)
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Errorf("should not error %v", err)
+				}
+			} else if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("expected error with message : %s, but got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateOIDCConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		oidcConfig  *OIDCConfig
+		aadProfile  *AADProfile
+		expectedErr error
+	}{
+		{
+			name: "no oidc config",
+		},
+		{
+			name:       "oidc config",
+			oidcConfig: &OIDCConfig{IssuerURL: "https://dex.example.com/dex", ClientID: "kubectl", UsernameClaim: "email", UsernamePrefix: "-"},
+		},
+		{
+			name:        "http issuer",
+			oidcConfig:  &OIDCConfig{IssuerURL: "http://dex.example.com", ClientID: "kubectl"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.OIDCConfig.IssuerURL 'http://dex.example.com' must be an https URL"),
+		},
+		{
+			name:        "issuer without scheme",
+			oidcConfig:  &OIDCConfig{IssuerURL: "dex.example.com", ClientID: "kubectl"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.OIDCConfig.IssuerURL 'dex.example.com' must be an https URL"),
+		},
+		{
+			name:        "missing client id",
+			oidcConfig:  &OIDCConfig{IssuerURL: "https://dex.example.com"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.OIDCConfig.ClientID must be specified"),
+		},
+		{
+			name:       "aad profile",
+			oidcConfig: &OIDCConfig{IssuerURL: "https://dex.example.com", ClientID: "kubectl"},
+			aadProfile: &AADProfile{
+				ClientAppID: "92444486-5bc3-4291-818b-d53ae480991b",
+				ServerAppID: "403f018b-4d89-495b-b548-0cf9868cdb0a",
+			},
+			expectedErr: errors.New("'aadProfile' configures the OIDC authentication of the API server and cannot be used together with OrchestratorProfile.KubernetesConfig.OIDCConfig"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{OIDCConfig: test.oidcConfig}
+			p.AADProfile = test.aadProfile
+			if err := p.Validate(false); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookTokenAuth(t *testing.T) {
+	caCertificate := "-----BEGIN CERTIFICATE-----\nZm9v\n-----END CERTIFICATE-----\n"
+	tests := []struct {
+		name        string
+		webhook     *WebhookTokenAuth
+		expectedErr error
+	}{
+		{
+			name: "no webhook token authentication",
+		},
+		{
+			name:    "webhook token authentication",
+			webhook: &WebhookTokenAuth{URL: "https://auth.example.com/authenticate", CACertificate: caCertificate, CacheTTL: "30s"},
+		},
+		{
+			name:        "http webhook",
+			webhook:     &WebhookTokenAuth{URL: "http://auth.example.com/authenticate"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.URL 'http://auth.example.com/authenticate' must be an https URL"),
+		},
+		{
+			name:        "webhook without URL",
+			webhook:     &WebhookTokenAuth{CacheTTL: "2m"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.URL '' must be an https URL"),
+		},
+		{
+			name:        "webhook with an invalid CA certificate",
+			webhook:     &WebhookTokenAuth{URL: "https://auth.example.com/authenticate", CACertificate: "Zm9v"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.CACertificate must be a PEM encoded certificate"),
+		},
+		{
+			name:        "webhook with an invalid cache TTL",
+			webhook:     &WebhookTokenAuth{URL: "https://auth.example.com/authenticate", CacheTTL: "2 minutes"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.CacheTTL '2 minutes' is not a valid duration, e.g. 2m"),
+		},
+		{
+			name:        "webhook with a negative cache TTL",
+			webhook:     &WebhookTokenAuth{URL: "https://auth.example.com/authenticate", CacheTTL: "-1m"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.WebhookTokenAuth.CacheTTL '-1m' is not a valid duration, e.g. 2m"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{WebhookTokenAuth: test.webhook}
+			if err := k.validateWebhookTokenAuth(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateKubeConfigNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		k           *KubernetesConfig
+		expectedErr error
+	}{
+		{
+			name: "default kubeconfig names",
+			k:    &KubernetesConfig{},
+		},
+		{
+			name: "kubeconfig names",
+			k: &KubernetesConfig{
+				KubeConfigClusterName: "prod-westus2",
+				KubeConfigContextName: "prod-westus2_admin",
+				KubeConfigUserName:    "clusterAdmin@prod-westus2",
+			},
+		},
+		{
+			name:        "kubeconfig cluster name with a quote",
+			k:           &KubernetesConfig{KubeConfigClusterName: `prod"`},
+			expectedErr: errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeConfigClusterName 'prod\"' is invalid, it must match %s", kubeConfigNameFormat),
+		},
+		{
+			name:        "kubeconfig context name with a space",
+			k:           &KubernetesConfig{KubeConfigContextName: "prod admin"},
+			expectedErr: errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeConfigContextName 'prod admin' is invalid, it must match %s", kubeConfigNameFormat),
+		},
+		{
+			name:        "kubeconfig user name starting with a dash",
+			k:           &KubernetesConfig{KubeConfigUserName: "-admin"},
+			expectedErr: errors.Errorf("OrchestratorProfile.KubernetesConfig.KubeConfigUserName '-admin' is invalid, it must match %s", kubeConfigNameFormat),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if err := test.k.validateKubeConfigNames(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAdmissionWebhooks(t *testing.T) {
+	caBundle := base64.StdEncoding.EncodeToString([]byte("-----BEGIN CERTIFICATE-----\nZm9v\n-----END CERTIFICATE-----\n"))
+	manifest := func(apiVersion, kind, annotations, clientConfig string) string {
+		return "apiVersion: " + apiVersion + "\nkind: " + kind + "\nmetadata:\n  name: policy\n" + annotations +
+			"webhooks:\n- name: policy.example.com\n  clientConfig:\n" + clientConfig
+	}
+	service := "    service:\n      name: policy-webhook\n      namespace: policy\n"
+	tests := []struct {
+		name        string
+		k8sVersion  string
+		webhooks    []AdmissionWebhook
+		expectedErr error
+	}{
+		{
+			name:       "no admission webhooks",
+			k8sVersion: "1.9.0",
+		},
+		{
+			name:       "validating webhook with a CA bundle",
+			k8sVersion: "1.9.0",
+			webhooks: []AdmissionWebhook{
+				{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n")},
+			},
+		},
+		{
+			name:       "mutating webhooks with an injected CA bundle",
+			k8sVersion: "1.16.0",
+			webhooks: []AdmissionWebhook{
+				{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1", "MutatingWebhookConfiguration", "  annotations:\n    cert-manager.io/inject-ca-from: policy/policy-webhook\n", service)},
+				{Name: "defaults", Manifest: "---\n" + manifest("admissionregistration.k8s.io/v1", "MutatingWebhookConfiguration", "  annotations:\n    cert-manager.io/inject-apiserver-ca: \"true\"\n", "    url: https://defaults.example.com/mutate\n")},
+			},
+		},
+		{
+			name:        "webhook without a name",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n")}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks name '' is invalid, it must match ^([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"),
+		},
+		{
+			name:       "duplicated webhook",
+			k8sVersion: "1.9.0",
+			webhooks: []AdmissionWebhook{
+				{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n")},
+				{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n")},
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks name 'policy' is duplicated"),
+		},
+		{
+			name:       "several documents",
+			k8sVersion: "1.9.0",
+			webhooks: []AdmissionWebhook{
+				{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n") + "---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: policy\n"},
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' manifest must hold a single webhook configuration"),
+		},
+		{
+			name:        "invalid YAML",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: "kind: [\n"}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' manifest is not a valid YAML manifest: error converting YAML to JSON: yaml: line 1: did not find expected node content"),
+		},
+		{
+			name:        "not a webhook configuration",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: policy\n"}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' manifest kind 'Namespace' must be ValidatingWebhookConfiguration or MutatingWebhookConfiguration"),
+		},
+		{
+			name:        "unknown API version",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1alpha1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n")}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' manifest apiVersion 'admissionregistration.k8s.io/v1alpha1' must be admissionregistration.k8s.io/v1beta1 or admissionregistration.k8s.io/v1"),
+		},
+		{
+			name:        "v1beta1 before Kubernetes 1.9",
+			k8sVersion:  "1.8.15",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n")}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' requires Kubernetes 1.9.0 or greater, the version is 1.8.15"),
+		},
+		{
+			name:        "v1 before Kubernetes 1.16",
+			k8sVersion:  "1.15.7",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1", "ValidatingWebhookConfiguration", "", service+"    caBundle: "+caBundle+"\n")}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' apiVersion admissionregistration.k8s.io/v1 requires Kubernetes 1.16.0 or greater, the version is 1.15.7"),
+		},
+		{
+			name:        "configuration without a name",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: "apiVersion: admissionregistration.k8s.io/v1beta1\nkind: ValidatingWebhookConfiguration\nwebhooks: []\n"}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' manifest must have a metadata.name"),
+		},
+		{
+			name:        "configuration without webhooks",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: "apiVersion: admissionregistration.k8s.io/v1beta1\nkind: ValidatingWebhookConfiguration\nmetadata:\n  name: policy\n"}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' manifest must have at least one webhook"),
+		},
+		{
+			name:        "webhook without a client",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", "    caBundle: "+caBundle+"\n")}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' webhook 'policy.example.com' must have a clientConfig url or service"),
+		},
+		{
+			name:        "webhook without a CA bundle",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service)}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' webhook 'policy.example.com' must have a clientConfig caBundle, unless the configuration has one of the annotations cert-manager.io/inject-ca-from, cert-manager.io/inject-ca-from-secret, cert-manager.io/inject-apiserver-ca"),
+		},
+		{
+			name:        "CA bundle not base64 encoded",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: not-base64\n")}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' webhook 'policy.example.com' clientConfig caBundle must be base64 encoded"),
+		},
+		{
+			name:        "CA bundle not a certificate",
+			k8sVersion:  "1.9.0",
+			webhooks:    []AdmissionWebhook{{Name: "policy", Manifest: manifest("admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "", service+"    caBundle: Zm9v\n")}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AdmissionWebhooks 'policy' webhook 'policy.example.com' clientConfig caBundle must be a PEM encoded certificate"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{AdmissionWebhooks: test.webhooks}
+			if err := k.validateAdmissionWebhooks(test.k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAuditWebhook(t *testing.T) {
+	caCertificate := "-----BEGIN CERTIFICATE-----\nZm9v\n-----END CERTIFICATE-----\n"
+	tests := []struct {
+		name        string
+		k8sVersion  string
+		webhook     *AuditWebhook
+		expectedErr error
+	}{
+		{
+			name: "no audit webhook",
+		},
+		{
+			name:    "batch audit webhook",
+			webhook: &AuditWebhook{URL: "https://siem.example.com/audit", CACertificate: caCertificate, Mode: "batch", BatchBufferSize: 10000, BatchMaxSize: 400, BatchMaxWait: "30s"},
+		},
+		{
+			name:    "blocking audit webhook",
+			webhook: &AuditWebhook{URL: "https://siem.example.com/audit", Mode: "blocking"},
+		},
+		{
+			name:        "audit webhook before 1.9",
+			k8sVersion:  "1.8.15",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook is only available in Kubernetes version 1.9.0 or greater; unable to validate for Kubernetes version 1.8.15"),
+		},
+		{
+			name:        "http audit webhook",
+			webhook:     &AuditWebhook{URL: "http://siem.example.com/audit"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.URL 'http://siem.example.com/audit' must be an https URL"),
+		},
+		{
+			name:        "audit webhook without URL",
+			webhook:     &AuditWebhook{Mode: "batch"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.URL '' must be an https URL"),
+		},
+		{
+			name:        "audit webhook with an invalid CA certificate",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", CACertificate: "Zm9v"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.CACertificate must be a PEM encoded certificate"),
+		},
+		{
+			name:        "audit webhook with an invalid mode",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", Mode: "async"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.Mode 'async' must be batch or blocking"),
+		},
+		{
+			name:        "blocking audit webhook with batch settings",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", Mode: "blocking", BatchMaxWait: "30s"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook batch settings are only used in the batch mode, not the blocking mode"),
+		},
+		{
+			name:        "audit webhook with a negative batch buffer size",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", BatchBufferSize: -1},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchBufferSize '-1' must not be negative"),
+		},
+		{
+			name:        "audit webhook with a negative batch max size",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", BatchMaxSize: -1},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchMaxSize '-1' must not be negative"),
+		},
+		{
+			name:        "audit webhook with batches larger than the buffer",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", BatchBufferSize: 100, BatchMaxSize: 400},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchMaxSize '400' must not be larger than the BatchBufferSize '100'"),
+		},
+		{
+			name:        "audit webhook with an invalid batch max wait",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", BatchMaxWait: "30 seconds"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchMaxWait '30 seconds' is not a valid duration, e.g. 30s"),
+		},
+		{
+			name:        "audit webhook with a zero batch max wait",
+			webhook:     &AuditWebhook{URL: "https://siem.example.com/audit", BatchMaxWait: "0s"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.AuditWebhook.BatchMaxWait '0s' is not a valid duration, e.g. 30s"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k8sVersion := test.k8sVersion
+			if k8sVersion == "" {
+				k8sVersion = "1.11.5"
+			}
+			k := &KubernetesConfig{AuditWebhook: test.webhook}
+			if err := k.validateAuditWebhook(k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateKubeletCertRotation(t *testing.T) {
+	tests := []struct {
+		name            string
+		k8sVersion      string
+		enabled         *bool
+		enableRbac      *bool
+		signingDuration string
+		expectedErr     error
+	}{
+		{
+			name: "no cert rotation",
+		},
+		{
+			name:    "cert rotation",
+			enabled: helpers.PointerToBool(true),
+		},
+		{
+			name:            "cert rotation with a signing duration",
+			enabled:         helpers.PointerToBool(true),
+			enableRbac:      helpers.PointerToBool(true),
+			signingDuration: "720h",
+		},
+		{
+			name:            "signing duration without cert rotation",
+			enabled:         helpers.PointerToBool(false),
+			signingDuration: "720h",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.KubeletCertSigningDuration can only be specified together with EnableKubeletCertRotation"),
+		},
+		{
+			name:        "cert rotation before 1.8",
+			k8sVersion:  "1.7.16",
+			enabled:     helpers.PointerToBool(true),
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EnableKubeletCertRotation is only available in Kubernetes version 1.8.0 or greater; unable to validate for Kubernetes version 1.7.16"),
+		},
+		{
+			name:        "cert rotation without RBAC",
+			enabled:     helpers.PointerToBool(true),
+			enableRbac:  helpers.PointerToBool(false),
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EnableKubeletCertRotation requires RBAC to authorize the certificate signing requests of the nodes"),
+		},
+		{
+			name:            "invalid signing duration",
+			enabled:         helpers.PointerToBool(true),
+			signingDuration: "30 days",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.KubeletCertSigningDuration '30 days' is not a valid duration"),
+		},
+		{
+			name:            "short signing duration",
+			enabled:         helpers.PointerToBool(true),
+			signingDuration: "30m",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.KubeletCertSigningDuration '30m' must be at least 1h"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k8sVersion := test.k8sVersion
+			if k8sVersion == "" {
+				k8sVersion = "1.11.5"
+			}
+			k := &KubernetesConfig{
+				EnableKubeletCertRotation:  test.enabled,
+				EnableRbac:                 test.enableRbac,
+				KubeletCertSigningDuration: test.signingDuration,
+			}
+			if err := k.validateKubeletCertRotation(k8sVersion); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateContainerLogRotation(t *testing.T) {
+	tests := []struct {
+		name                 string
+		containerLogMaxSize  string
+		containerLogMaxFiles int
+		expectedErr          error
+	}{
+		{
+			name: "default log rotation",
+		},
+		{
+			name:                 "log rotation",
+			containerLogMaxSize:  "100Mi",
+			containerLogMaxFiles: 10,
+		},
+		{
+			name:                "docker size unit",
+			containerLogMaxSize: "100m",
+			expectedErr:         errors.New("OrchestratorProfile.KubernetesConfig.ContainerLogMaxSize '100m' is not a valid size, it needs to be a whole number of Ki, Mi or Gi, e.g. 50Mi"),
+		},
+		{
+			name:                "zero size",
+			containerLogMaxSize: "0Mi",
+			expectedErr:         errors.New("OrchestratorProfile.KubernetesConfig.ContainerLogMaxSize '0Mi' is not a valid size, it needs to be a whole number of Ki, Mi or Gi, e.g. 50Mi"),
+		},
+		{
+			name:                 "single log file",
+			containerLogMaxFiles: 1,
+			expectedErr:          errors.New("OrchestratorProfile.KubernetesConfig.ContainerLogMaxFiles 1 needs to be at least 2"),
+		},
+		{
+			name:                 "negative log files",
+			containerLogMaxFiles: -5,
+			expectedErr:          errors.New("OrchestratorProfile.KubernetesConfig.ContainerLogMaxFiles -5 needs to be at least 2"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				ContainerLogMaxSize:  test.containerLogMaxSize,
+				ContainerLogMaxFiles: test.containerLogMaxFiles,
+			}
+			if err := p.Validate(false); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatePodMTU(t *testing.T) {
+	tests := []struct {
+		name          string
+		podMTU        int
+		networkPlugin string
+		networkPolicy string
+		expectedErr   error
+	}{
+		{
+			name: "default MTU",
+		},
+		{
+			name:          "kubenet",
+			podMTU:        1400,
+			networkPlugin: "kubenet",
+		},
+		{
+			name:          "azure",
+			podMTU:        1500,
+			networkPlugin: "azure",
+		},
+		{
+			name:        "MTU too small",
+			podMTU:      576,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PodMTU 576 needs to be between 1280 and 1500"),
+		},
+		{
+			name:        "jumbo frames",
+			podMTU:      9000,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PodMTU 9000 needs to be between 1280 and 1500"),
+		},
+		{
+			name:          "flannel",
+			podMTU:        1400,
+			networkPlugin: "flannel",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.PodMTU is only supported with the kubenet and azure network plugins, not flannel"),
+		},
+		{
+			name:          "calico",
+			podMTU:        1400,
+			networkPlugin: "kubenet",
+			networkPolicy: "calico",
+			expectedErr:   errors.New("OrchestratorProfile.KubernetesConfig.PodMTU is not supported with the calico network policy, which configures the interface of the pods"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{
+				PodMTU:        test.podMTU,
+				NetworkPlugin: test.networkPlugin,
+				NetworkPolicy: test.networkPolicy,
+			}
+			if err := k.validatePodMTU(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRegistryMirrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		registryMirrors []string
+		expectedErr     error
+	}{
+		{
+			name: "no registry mirrors",
+		},
+		{
+			name:            "registry mirrors",
+			registryMirrors: []string{"https://mirror.example.com", "http://10.0.0.4:5000/"},
+		},
+		{
+			name:            "registry host",
+			registryMirrors: []string{"mirror.example.com"},
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.RegistryMirrors 'mirror.example.com' is not a valid registry mirror, it needs to be an http or https URL without a path, e.g. https://mirror.example.com"),
+		},
+		{
+			name:            "unsupported scheme",
+			registryMirrors: []string{"ftp://mirror.example.com"},
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.RegistryMirrors 'ftp://mirror.example.com' is not a valid registry mirror, it needs to be an http or https URL without a path, e.g. https://mirror.example.com"),
+		},
+		{
+			name:            "registry mirror with a path",
+			registryMirrors: []string{"https://mirror.example.com/v2/library"},
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.RegistryMirrors 'https://mirror.example.com/v2/library' is not a valid registry mirror, it needs to be an http or https URL without a path, e.g. https://mirror.example.com"),
+		},
+		{
+			name:            "duplicate registry mirrors",
+			registryMirrors: []string{"https://mirror.example.com", "https://mirror.example.com"},
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.RegistryMirrors 'https://mirror.example.com' is specified more than once"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				RegistryMirrors: test.registryMirrors,
+			}
+			if err := p.Validate(false); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateDisableSSH(t *testing.T) {
+	const vnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME"
+	tests := []struct {
+		name         string
+		customVNET   bool
+		osType       OSType
+		agentSubnets []string
+		disableSSH   []bool
+		expectedErr  error
+	}{
+		{
+			name:         "ssh disabled on an agent pool subnet",
+			customVNET:   true,
+			agentSubnets: []string{"agents", "hardened"},
+			disableSSH:   []bool{false, true},
+		},
+		{
+			name:         "ssh disabled on two agent pools sharing a subnet",
+			customVNET:   true,
+			agentSubnets: []string{"hardened", "hardened"},
+			disableSSH:   []bool{true, true},
+		},
+		{
+			name:         "ssh disabled without a custom vnet",
+			agentSubnets: []string{"", ""},
+			disableSSH:   []bool{false, true},
+			expectedErr:  errors.New("agent pool 'agentpool1' sets disableSSH, which requires a vnetSubnetID separate from the masterProfile one, so that SSH access to the masters remains"),
+		},
+		{
+			name:         "ssh disabled on the master subnet",
+			customVNET:   true,
+			agentSubnets: []string{"agents", "master"},
+			disableSSH:   []bool{false, true},
+			expectedErr:  errors.New("agent pool 'agentpool1' sets disableSSH, which requires a vnetSubnetID separate from the masterProfile one, so that SSH access to the masters remains"),
+		},
+		{
+			name:         "ssh disabled on a subnet shared with an agent pool keeping ssh",
+			customVNET:   true,
+			agentSubnets: []string{"agents", "agents"},
+			disableSSH:   []bool{false, true},
+			expectedErr:  errors.New("agent pool 'agentpool1' sets disableSSH, but shares its vnetSubnetID with agent pool 'agentpool0' which does not"),
+		},
+		{
+			name:         "ssh disabled on a windows agent pool",
+			customVNET:   true,
+			osType:       Windows,
+			agentSubnets: []string{"agents", "hardened"},
+			disableSSH:   []bool{false, true},
+			expectedErr:  errors.New("agent pool 'agentpool1' sets disableSSH, which is only supported on Linux agent pools"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			if test.customVNET {
+				p.MasterProfile.VnetSubnetID = vnetID + "/subnets/master"
+				p.MasterProfile.FirstConsecutiveStaticIP = "10.239.255.239"
+			}
+			p.AgentPoolProfiles = nil
+			for i, subnet := range test.agentSubnets {
+				pool := &AgentPoolProfile{
+					Name:                fmt.Sprintf("agentpool%d", i),
+					VMSize:              "Standard_D2_v2",
+					Count:               1,
+					AvailabilityProfile: AvailabilitySet,
+					DisableSSH:          test.disableSSH[i],
+				}
+				if subnet != "" {
+					pool.VnetSubnetID = vnetID + "/subnets/" + subnet
+				}
+				if test.disableSSH[i] {
+					pool.OSType = test.osType
+				}
+				p.AgentPoolProfiles = append(p.AgentPoolProfiles, pool)
+			}
+			if err := p.validateDisableSSH(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSysctls(t *testing.T) {
+	tests := []struct {
+		name             string
+		orchestratorType string
+		osType           OSType
+		sysctls          map[string]string
+		expectedErr      error
+	}{
+		{
+			name:             "no sysctls",
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:             "valid sysctls",
+			orchestratorType: Kubernetes,
+			sysctls: map[string]string{
+				"net.core.somaxconn":                 "16384",
+				"net.ipv4.ip_local_port_range":       "1024 65000",
+				"net.ipv4.conf.all.rp_filter":        "1",
+				"fs.inotify.max_user_watches":        "1048576",
+				"fs.file-max":                        "2097152",
+				"net.netfilter.nf_conntrack_max":     "1048576",
+				"kernel.core_pattern":                "/var/crash/core.%e",
+				"vm.max_map_count":                   "262144",
+				"net.ipv4.tcp_congestion_control":    "bbr",
+				"net.ipv6.conf.default.disable_ipv6": "0",
+			},
+		},
+		{
+			name:             "unknown sysctl namespace",
+			orchestratorType: Kubernetes,
+			sysctls:          map[string]string{"somaxconn": "16384"},
+			expectedErr:      errors.New("agent pool 'agentpool' has sysctl 'somaxconn', which is not a known sysctl key format, e.g. net.core.somaxconn"),
+		},
+		{
+			name:             "sysctl path rather than key",
+			orchestratorType: Kubernetes,
+			sysctls:          map[string]string{"/proc/sys/net/core/somaxconn": "16384"},
+			expectedErr:      errors.New("agent pool 'agentpool' has sysctl '/proc/sys/net/core/somaxconn', which is not a known sysctl key format, e.g. net.core.somaxconn"),
+		},
+		{
+			name:             "sysctl key with an empty segment",
+			orchestratorType: Kubernetes,
+			sysctls:          map[string]string{"net..somaxconn": "16384"},
+			expectedErr:      errors.New("agent pool 'agentpool' has sysctl 'net..somaxconn', which is not a known sysctl key format, e.g. net.core.somaxconn"),
+		},
+		{
+			name:             "empty sysctl value",
+			orchestratorType: Kubernetes,
+			sysctls:          map[string]string{"net.core.somaxconn": " "},
+			expectedErr:      errors.New("agent pool 'agentpool' has sysctl 'net.core.somaxconn' with value ' ', it must be made of letters, digits, spaces and the characters . , : / _ - %"),
+		},
+		{
+			name:             "sysctl value with a quote",
+			orchestratorType: Kubernetes,
+			sysctls:          map[string]string{"kernel.core_pattern": "'|/bin/false'"},
+			expectedErr:      errors.New("agent pool 'agentpool' has sysctl 'kernel.core_pattern' with value ''|/bin/false'', it must be made of letters, digits, spaces and the characters . , : / _ - %"),
+		},
+		{
+			name:             "sysctls on a windows agent pool",
+			orchestratorType: Kubernetes,
+			osType:           Windows,
+			sysctls:          map[string]string{"net.core.somaxconn": "16384"},
+			expectedErr:      errors.New("agent pool 'agentpool' has sysctls, which are not supported on Windows"),
+		},
+		{
+			name:             "sysctls with DCOS",
+			orchestratorType: DCOS,
+			sysctls:          map[string]string{"net.core.somaxconn": "16384"},
+			expectedErr:      errors.New("agent pool 'agentpool' has sysctls, which are only supported for Kubernetes"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:    "agentpool",
+				OSType:  test.osType,
+				Sysctls: test.sysctls,
+			}
+			if err := a.validateSysctls(test.orchestratorType); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateKernelBootParameters(t *testing.T) {
+	tests := []struct {
+		name                 string
+		orchestratorType     string
+		osType               OSType
+		distro               Distro
+		kernelBootParameters []string
+		expectedErr          error
+	}{
+		{
+			name:             "no kernel boot parameters",
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:             "valid kernel boot parameters",
+			orchestratorType: Kubernetes,
+			kernelBootParameters: []string{
+				"default_hugepagesz=1G",
+				"hugepagesz=1G",
+				"hugepages=16",
+				"intel_iommu=on",
+				"iommu=pt",
+				"transparent_hugepage=never",
+				"isolcpus=2-7,10",
+				"nosmt",
+				"console=ttyS0,115200n8",
+				"systemd.unified_cgroup_hierarchy=0",
+			},
+		},
+		{
+			name:                 "kernel boot parameter with a space",
+			orchestratorType:     Kubernetes,
+			kernelBootParameters: []string{"hugepages=16 nosmt"},
+			expectedErr:          errors.New("agent pool 'agentpool' has kernel boot parameter 'hugepages=16 nosmt', expected the format name or name=value, e.g. hugepages=1024 or intel_iommu=on"),
+		},
+		{
+			name:                 "kernel boot parameter with a quote",
+			orchestratorType:     Kubernetes,
+			kernelBootParameters: []string{`init="/bin/sh"`},
+			expectedErr:          errors.New(`agent pool 'agentpool' has kernel boot parameter 'init="/bin/sh"', expected the format name or name=value, e.g. hugepages=1024 or intel_iommu=on`),
+		},
+		{
+			name:                 "kernel boot parameter without a name",
+			orchestratorType:     Kubernetes,
+			kernelBootParameters: []string{"=on"},
+			expectedErr:          errors.New("agent pool 'agentpool' has kernel boot parameter '=on', expected the format name or name=value, e.g. hugepages=1024 or intel_iommu=on"),
+		},
+		{
+			name:                 "duplicate kernel boot parameter",
+			orchestratorType:     Kubernetes,
+			kernelBootParameters: []string{"intel_iommu=on", "intel_iommu=on"},
+			expectedErr:          errors.New("agent pool 'agentpool' has duplicate kernel boot parameter 'intel_iommu=on'"),
+		},
+		{
+			name:                 "kernel boot parameters on a windows agent pool",
+			orchestratorType:     Kubernetes,
+			osType:               Windows,
+			kernelBootParameters: []string{"intel_iommu=on"},
+			expectedErr:          errors.New("agent pool 'agentpool' has kernelBootParameters, which are not supported on Windows"),
+		},
+		{
+			name:                 "kernel boot parameters on a coreos agent pool",
+			orchestratorType:     Kubernetes,
+			distro:               CoreOS,
+			kernelBootParameters: []string{"intel_iommu=on"},
+			expectedErr:          errors.New("agent pool 'agentpool' has kernelBootParameters, which are not supported with the coreos distro"),
+		},
+		{
+			name:                 "kernel boot parameters with DCOS",
+			orchestratorType:     DCOS,
+			kernelBootParameters: []string{"intel_iommu=on"},
+			expectedErr:          errors.New("agent pool 'agentpool' has kernelBootParameters, which are only supported for Kubernetes"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                 "agentpool",
+				OSType:               test.osType,
+				Distro:               test.distro,
+				KernelBootParameters: test.kernelBootParameters,
+			}
+			if err := a.validateKernelBootParameters(test.orchestratorType); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateContainerRuntimeDisk(t *testing.T) {
+	tests := []struct {
+		name                       string
+		orchestratorType           string
+		osType                     OSType
+		distro                     Distro
+		storageProfile             string
+		diskSizesGB                []int
+		containerRuntimeDiskSizeGB int
+		expectedErr                error
+	}{
+		{
+			name:             "no container runtime disk",
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:                       "container runtime disk",
+			orchestratorType:           Kubernetes,
+			storageProfile:             ManagedDisks,
+			diskSizesGB:                []int{128, 128, 128},
+			containerRuntimeDiskSizeGB: 256,
+		},
+		{
+			name:                       "container runtime disk with the default storage profile",
+			orchestratorType:           Kubernetes,
+			containerRuntimeDiskSizeGB: 1023,
+		},
+		{
+			name:                       "container runtime disk too large",
+			orchestratorType:           Kubernetes,
+			containerRuntimeDiskSizeGB: 1024,
+			expectedErr:                errors.New("agent pool 'agentpool' has containerRuntimeDiskSizeGB 1024, expected a size between 1 and 1023"),
+		},
+		{
+			name:                       "negative container runtime disk size",
+			orchestratorType:           Kubernetes,
+			containerRuntimeDiskSizeGB: -1,
+			expectedErr:                errors.New("agent pool 'agentpool' has containerRuntimeDiskSizeGB -1, expected a size between 1 and 1023"),
+		},
+		{
+			name:                       "container runtime disk with storage accounts",
+			orchestratorType:           Kubernetes,
+			storageProfile:             StorageAccount,
+			containerRuntimeDiskSizeGB: 256,
+			expectedErr:                errors.New("agent pool 'agentpool' has a containerRuntimeDiskSizeGB, which requires the storageProfile ManagedDisks"),
+		},
+		{
+			name:                       "container runtime disk with 4 other data disks",
+			orchestratorType:           Kubernetes,
+			diskSizesGB:                []int{128, 128, 128, 128},
+			containerRuntimeDiskSizeGB: 256,
+			expectedErr:                errors.New("agent pool 'agentpool' has a containerRuntimeDiskSizeGB and 4 diskSizesGB, at most 4 data disks are supported"),
+		},
+		{
+			name:                       "container runtime disk on a windows agent pool",
+			orchestratorType:           Kubernetes,
+			osType:                     Windows,
+			containerRuntimeDiskSizeGB: 256,
+			expectedErr:                errors.New("agent pool 'agentpool' has a containerRuntimeDiskSizeGB, which is not supported on Windows"),
+		},
+		{
+			name:                       "container runtime disk on a coreos agent pool",
+			orchestratorType:           Kubernetes,
+			distro:                     CoreOS,
+			containerRuntimeDiskSizeGB: 256,
+			expectedErr:                errors.New("agent pool 'agentpool' has a containerRuntimeDiskSizeGB, which is not supported with the coreos distro"),
+		},
+		{
+			name:                       "container runtime disk with DCOS",
+			orchestratorType:           DCOS,
+			containerRuntimeDiskSizeGB: 256,
+			expectedErr:                errors.New("agent pool 'agentpool' has a containerRuntimeDiskSizeGB, which is only supported for Kubernetes"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                       "agentpool",
+				OSType:                     test.osType,
+				Distro:                     test.distro,
+				StorageProfile:             test.storageProfile,
+				DiskSizesGB:                test.diskSizesGB,
+				ContainerRuntimeDiskSizeGB: test.containerRuntimeDiskSizeGB,
+			}
+			if err := a.validateContainerRuntimeDisk(test.orchestratorType); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateServiceInternalLBSubnet(t *testing.T) {
+	const vnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME"
+	tests := []struct {
+		name            string
+		customVNET      bool
+		loadBalancerSku string
+		subnetID        string
+		expectedErr     error
+	}{
+		{
+			name: "no services subnet",
+		},
+		{
+			name:            "services subnet in the master vnet",
+			customVNET:      true,
+			loadBalancerSku: "Standard",
+			subnetID:        vnetID + "/subnets/services",
+		},
+		{
+			name:            "services subnet with a basic load balancer",
+			customVNET:      true,
+			loadBalancerSku: "Basic",
+			subnetID:        vnetID + "/subnets/services",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID requires a Standard loadBalancerSku"),
+		},
+		{
+			name:            "services subnet without a custom vnet",
+			loadBalancerSku: "Standard",
+			subnetID:        vnetID + "/subnets/services",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID requires the masterProfile to set a custom vnetSubnetID"),
+		},
+		{
+			name:            "invalid services subnet",
+			customVNET:      true,
+			loadBalancerSku: "Standard",
+			subnetID:        "services",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID is not a valid subnet ID: Unable to parse vnetSubnetID. Please use a vnetSubnetID with format /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"),
+		},
+		{
+			name:            "services subnet in another vnet",
+			customVNET:      true,
+			loadBalancerSku: "Standard",
+			subnetID:        "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/OTHER_VNET/subnets/services",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.ServiceInternalLBSubnetID '/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/OTHER_VNET/subnets/services' needs to be a subnet of the masterProfile VNET 'VNET_NAME'"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			if test.customVNET {
+				p.MasterProfile.VnetSubnetID = vnetID + "/subnets/master"
+				p.MasterProfile.FirstConsecutiveStaticIP = "10.239.255.239"
+			}
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				LoadBalancerSku:           test.loadBalancerSku,
+				ServiceInternalLBSubnetID: test.subnetID,
+			}
+			if err := p.validateServiceInternalLBSubnet(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateNATGateway(t *testing.T) {
+	const natGatewayID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/natGateways/NATGW_NAME"
+	tests := []struct {
+		name                string
+		customVNET          bool
+		loadBalancerSku     string
+		natGatewayID        string
+		egressFirewall      *EgressFirewall
+		cloudProviderConfig map[string]string
+		expectedErr         error
+	}{
+		{
+			name: "no NAT gateway",
+		},
+		{
+			name:            "NAT gateway",
+			loadBalancerSku: "Standard",
+			natGatewayID:    natGatewayID,
+		},
+		{
+			name:                "NAT gateway with outbound SNAT disabled",
+			loadBalancerSku:     "Standard",
+			natGatewayID:        natGatewayID,
+			cloudProviderConfig: map[string]string{"disableOutboundSNAT": "true"},
+		},
+		{
+			name:            "invalid NAT gateway ID",
+			loadBalancerSku: "Standard",
+			natGatewayID:    "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/loadBalancers/NATGW_NAME",
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID '/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/loadBalancers/NATGW_NAME' is not a valid NAT gateway resource ID"),
+		},
+		{
+			name:            "NAT gateway with a basic load balancer",
+			loadBalancerSku: "Basic",
+			natGatewayID:    natGatewayID,
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID requires a Standard loadBalancerSku"),
+		},
+		{
+			name:            "NAT gateway with a custom vnet",
+			customVNET:      true,
+			loadBalancerSku: "Standard",
+			natGatewayID:    natGatewayID,
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID is not supported with a custom VNET, associate the NAT gateway with the vnetSubnetID subnets instead"),
+		},
+		{
+			name:            "NAT gateway with an egress firewall",
+			loadBalancerSku: "Standard",
+			natGatewayID:    natGatewayID,
+			egressFirewall:  &EgressFirewall{VirtualApplianceIP: "10.0.0.4"},
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID and OrchestratorProfile.KubernetesConfig.EgressFirewall are mutually exclusive"),
+		},
+		{
+			name:                "NAT gateway with outbound SNAT enabled",
+			loadBalancerSku:     "Standard",
+			natGatewayID:        natGatewayID,
+			cloudProviderConfig: map[string]string{"disableOutboundSNAT": "false"},
+			expectedErr:         errors.New("OrchestratorProfile.KubernetesConfig.NATGatewayID requires the cloudProviderConfig disableOutboundSNAT to be true"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			if test.customVNET {
+				p.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/master"
+				p.MasterProfile.FirstConsecutiveStaticIP = "10.239.255.239"
+			}
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				LoadBalancerSku:     test.loadBalancerSku,
+				NATGatewayID:        test.natGatewayID,
+				EgressFirewall:      test.egressFirewall,
+				CloudProviderConfig: test.cloudProviderConfig,
+			}
+			if err := p.validateNATGateway(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAgentOutbound(t *testing.T) {
+	tests := []struct {
+		name                 string
+		disableAgentOutbound *bool
+		orchestratorType     string
+		loadBalancerSku      string
+		natGatewayID         string
+		egressFirewall       *EgressFirewall
+		cloudProviderConfig  map[string]string
+		expectedErr          error
+	}{
+		{
+			name: "agent outbound enabled",
+		},
+		{
+			name:                 "agent outbound disabled with an egress firewall",
+			disableAgentOutbound: helpers.PointerToBool(true),
+			egressFirewall:       &EgressFirewall{VirtualApplianceIP: "10.0.0.4"},
+		},
+		{
+			name:                 "agent outbound disabled with a NAT gateway",
+			disableAgentOutbound: helpers.PointerToBool(true),
+			loadBalancerSku:      "Standard",
+			natGatewayID:         "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/natGateways/NATGW_NAME",
+		},
+		{
+			name:                 "agent outbound disabled without an egress path",
+			disableAgentOutbound: helpers.PointerToBool(true),
+			expectedErr:          errors.New("OrchestratorProfile.KubernetesConfig.DisableAgentOutbound requires an egress path through OrchestratorProfile.KubernetesConfig.EgressFirewall or OrchestratorProfile.KubernetesConfig.NATGatewayID"),
+		},
+		{
+			name:                 "agent outbound disabled with outbound SNAT enabled",
+			disableAgentOutbound: helpers.PointerToBool(true),
+			loadBalancerSku:      "Standard",
+			egressFirewall:       &EgressFirewall{VirtualApplianceIP: "10.0.0.4"},
+			cloudProviderConfig:  map[string]string{"disableOutboundSNAT": "false"},
+			expectedErr:          errors.New("OrchestratorProfile.KubernetesConfig.DisableAgentOutbound requires the cloudProviderConfig disableOutboundSNAT to be true"),
+		},
+		{
+			name:                 "agent outbound disabled with another orchestrator",
+			disableAgentOutbound: helpers.PointerToBool(true),
+			orchestratorType:     DCOS,
+			egressFirewall:       &EgressFirewall{VirtualApplianceIP: "10.0.0.4"},
+			expectedErr:          errors.New("OrchestratorProfile.KubernetesConfig.DisableAgentOutbound is only supported with Kubernetes"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			if test.orchestratorType != "" {
+				p.OrchestratorProfile.OrchestratorType = test.orchestratorType
+			}
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				DisableAgentOutbound: test.disableAgentOutbound,
+				LoadBalancerSku:      test.loadBalancerSku,
+				NATGatewayID:         test.natGatewayID,
+				EgressFirewall:       test.egressFirewall,
+				CloudProviderConfig:  test.cloudProviderConfig,
+			}
+			if err := p.validateAgentOutbound(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAPIServerEndpoint(t *testing.T) {
+	tests := []struct {
+		name             string
+		advertiseAddress string
+		securePort       int
+		hasWindows       bool
+		expectedErr      error
+	}{
+		{
+			name: "default endpoint",
+		},
+		{
+			name:             "custom advertise address and secure port",
+			advertiseAddress: "10.255.255.5",
+			securePort:       6443,
+		},
+		{
+			name:             "invalid advertise address",
+			advertiseAddress: "10.255.255",
+			expectedErr:      errors.New("OrchestratorProfile.KubernetesConfig.APIServerAdvertiseAddress '10.255.255' is not a valid IP address"),
+		},
+		{
+			name:        "out of range secure port",
+			securePort:  65536,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIServerSecurePort '65536' must be between 1 and 65535"),
+		},
+		{
+			name:        "negative secure port",
+			securePort:  -443,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIServerSecurePort '-443' must be between 1 and 65535"),
+		},
+		{
+			name:        "secure port used by the kubelet",
+			securePort:  10250,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIServerSecurePort '10250' is already used on the masters"),
+		},
+		{
+			name:        "secure port used by the insecure port of the apiserver",
+			securePort:  8080,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIServerSecurePort '8080' is already used on the masters"),
+		},
+		{
+			name:        "secure port used by the scheduler",
+			securePort:  10251,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIServerSecurePort '10251' is already used on the masters"),
+		},
+		{
+			name:        "secure port with Windows agent pools",
+			securePort:  6443,
+			hasWindows:  true,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIServerSecurePort is not supported with Windows agent pools"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(test.hasWindows)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				APIServerAdvertiseAddress: test.advertiseAddress,
+				APIServerSecurePort:       test.securePort,
+			}
+			if err := p.validateAPIServerEndpoint(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateExtraTemplateEntries(t *testing.T) {
+	tests := []struct {
+		name            string
+		extraParameters map[string]interface{}
+		extraVariables  map[string]interface{}
+		expectedErr     error
+	}{
+		{
+			name: "no extra entries",
+		},
+		{
+			name:            "extra parameters and variables",
+			extraParameters: map[string]interface{}{"costCenter": "1234", "retentionDays": float64(30), "tags": map[string]interface{}{"team": "infra"}},
+			extraVariables:  map[string]interface{}{"costCenterTag": "[parameters('costCenter')]"},
+		},
+		{
+			name:            "extra parameter with an empty name",
+			extraParameters: map[string]interface{}{"": "1234"},
+			expectedErr:     errors.New("extraParameters has a parameter with an empty name"),
+		},
+		{
+			name:            "extra parameter without a value",
+			extraParameters: map[string]interface{}{"costCenter": nil},
+			expectedErr:     errors.New("extraParameters 'costCenter' has no value"),
+		},
+		{
+			name:            "extra parameter with a fractional value",
+			extraParameters: map[string]interface{}{"ratio": 0.5},
+			expectedErr:     errors.New("extraParameters 'ratio' is 0.5, but numeric template parameters need to be whole numbers"),
+		},
+		{
+			name:           "extra variable with an empty name",
+			extraVariables: map[string]interface{}{"": "1234"},
+			expectedErr:    errors.New("extraVariables has a variable with an empty name"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.ExtraParameters = test.extraParameters
+			p.ExtraVariables = test.extraVariables
+			if err := p.validateExtraTemplateEntries(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSchedulerPolicy(t *testing.T) {
+	tests := []struct {
+		name            string
+		schedulerPolicy string
+		expectedErr     error
+	}{
+		{
+			name: "no scheduler policy",
+		},
+		{
+			name:            "scheduler policy",
+			schedulerPolicy: `{"kind": "Policy", "apiVersion": "v1", "predicates": [{"name": "PodFitsResources"}], "priorities": [{"name": "LeastRequestedPriority", "weight": 1}]}`,
+		},
+		{
+			name:            "invalid json",
+			schedulerPolicy: `{"kind": "Policy"`,
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.SchedulerPolicy is not a valid JSON document: unexpected end of JSON input"),
+		},
+		{
+			name:            "scheduler configuration",
+			schedulerPolicy: `{"kind": "KubeSchedulerConfiguration", "apiVersion": "componentconfig/v1alpha1"}`,
+			expectedErr:     errors.New("OrchestratorProfile.KubernetesConfig.SchedulerPolicy has kind 'KubeSchedulerConfiguration', it needs to be a scheduler Policy"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{SchedulerPolicy: test.schedulerPolicy}
+			if err := p.Validate(false); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatePlatformDomainCounts(t *testing.T) {
+	tests := []struct {
+		name                string
+		availabilityProfile string
+		faultDomainCount    *int
+		updateDomainCount   *int
+		expectedErr         error
+	}{
+		{
+			name:                "default counts",
+			availabilityProfile: AvailabilitySet,
+		},
+		{
+			name:                "configured counts",
+			availabilityProfile: AvailabilitySet,
+			faultDomainCount:    helpers.PointerToInt(3),
+			updateDomainCount:   helpers.PointerToInt(20),
+		},
+		{
+			name:                "fault domain count too low",
+			availabilityProfile: AvailabilitySet,
+			faultDomainCount:    helpers.PointerToInt(0),
+			expectedErr:         errors.New("agent pool 'agentpool' has platformFaultDomainCount 0, it needs to be in the range [1,3]"),
+		},
+		{
+			name:                "fault domain count too high",
+			availabilityProfile: AvailabilitySet,
+			faultDomainCount:    helpers.PointerToInt(4),
+			expectedErr:         errors.New("agent pool 'agentpool' has platformFaultDomainCount 4, it needs to be in the range [1,3]"),
+		},
+		{
+			name:                "update domain count too high",
+			availabilityProfile: AvailabilitySet,
+			updateDomainCount:   helpers.PointerToInt(21),
+			expectedErr:         errors.New("agent pool 'agentpool' has platformUpdateDomainCount 21, it needs to be in the range [1,20]"),
+		},
+		{
+			name:                "scale set",
+			availabilityProfile: VirtualMachineScaleSets,
+			faultDomainCount:    helpers.PointerToInt(2),
+			expectedErr:         errors.New("agent pool 'agentpool' sets platformFaultDomainCount or platformUpdateDomainCount, which are only supported with availabilityProfile AvailabilitySet"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validatePlatformDomainCounts("agent pool 'agentpool'", test.availabilityProfile, test.faultDomainCount, test.updateDomainCount)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+
+	t.Run("master profile", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.MasterProfile.PlatformUpdateDomainCount = helpers.PointerToInt(0)
+		expectedErr := errors.New("masterProfile has platformUpdateDomainCount 0, it needs to be in the range [1,20]")
+		if err := p.Validate(false); !helpers.EqualError(err, expectedErr) {
+			t.Errorf("expected error: %v\ngot error: %v", expectedErr, err)
+		}
+	})
+}
+
+func TestValidateAgentPoolCount(t *testing.T) {
+	tests := []struct {
+		name                string
+		orchestratorType    string
+		count               int
+		availabilityProfile string
+		storageProfile      string
+		minCount            *int
+		maxCount            *int
+		expectedErr         error
+	}{
+		{
+			name:                "availability set pool of 100",
+			orchestratorType:    Kubernetes,
+			count:               100,
+			availabilityProfile: AvailabilitySet,
+		},
+		{
+			name:                "kubernetes availability set pool split across availability sets",
+			orchestratorType:    Kubernetes,
+			count:               250,
+			availabilityProfile: AvailabilitySet,
+			storageProfile:      ManagedDisks,
+		},
+		{
+			name:                "kubernetes availability set pool too large",
+			orchestratorType:    Kubernetes,
+			count:               1001,
+			availabilityProfile: AvailabilitySet,
+			expectedErr:         errors.New("AgentPoolProfile count needs to be in the range [1,1000]"),
+		},
+		{
+			name:                "kubernetes availability set pool with storage accounts",
+			orchestratorType:    Kubernetes,
+			count:               101,
+			availabilityProfile: AvailabilitySet,
+			storageProfile:      StorageAccount,
+			expectedErr:         errors.New("AgentPoolProfile count needs to be in the range [1,100]"),
+		},
+		{
+			name:                "kubernetes scale set pool",
+			orchestratorType:    Kubernetes,
+			count:               101,
+			availabilityProfile: VirtualMachineScaleSets,
+			expectedErr:         errors.New("AgentPoolProfile count needs to be in the range [1,100]"),
+		},
+		{
+			name:                "dcos availability set pool",
+			orchestratorType:    DCOS,
+			count:               101,
+			availabilityProfile: AvailabilitySet,
+			expectedErr:         errors.New("AgentPoolProfile count needs to be in the range [1,100]"),
+		},
+		{
+			name:                "pool within its min and max counts",
+			orchestratorType:    Kubernetes,
+			count:               3,
+			availabilityProfile: VirtualMachineScaleSets,
+			minCount:            helpers.PointerToInt(1),
+			maxCount:            helpers.PointerToInt(10),
+		},
+		{
+			name:                "pool at its min and max counts",
+			orchestratorType:    Kubernetes,
+			count:               3,
+			availabilityProfile: VirtualMachineScaleSets,
+			minCount:            helpers.PointerToInt(3),
+			maxCount:            helpers.PointerToInt(3),
+		},
+		{
+			name:                "pool with a min count of 0",
+			orchestratorType:    Kubernetes,
+			count:               3,
+			availabilityProfile: VirtualMachineScaleSets,
+			minCount:            helpers.PointerToInt(0),
+			expectedErr:         errors.New("agent pool 'agentpool1' minCount needs to be at least 1, got 0"),
+		},
+		{
+			name:                "scale set pool with a max count above the scale set limit",
+			orchestratorType:    Kubernetes,
+			count:               3,
+			availabilityProfile: VirtualMachineScaleSets,
+			maxCount:            helpers.PointerToInt(101),
+			expectedErr:         errors.New("agent pool 'agentpool1' maxCount needs to be at most 100, got 101"),
+		},
+		{
+			name:                "pool below its min count",
+			orchestratorType:    Kubernetes,
+			count:               1,
+			availabilityProfile: VirtualMachineScaleSets,
+			minCount:            helpers.PointerToInt(2),
+			expectedErr:         errors.New("agent pool 'agentpool1' count 1 is lower than its minCount 2"),
+		},
+		{
+			name:                "pool above its max count",
+			orchestratorType:    Kubernetes,
+			count:               6,
+			availabilityProfile: AvailabilitySet,
+			maxCount:            helpers.PointerToInt(5),
+			expectedErr:         errors.New("agent pool 'agentpool1' count 6 is greater than its maxCount 5"),
+		},
+		{
+			name:                "pool with a min count greater than its max count",
+			orchestratorType:    Kubernetes,
+			count:               4,
+			availabilityProfile: VirtualMachineScaleSets,
+			minCount:            helpers.PointerToInt(5),
+			maxCount:            helpers.PointerToInt(3),
+			expectedErr:         errors.New("agent pool 'agentpool1' count 4 is lower than its minCount 5"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                "agentpool1",
+				Count:               test.count,
+				AvailabilityProfile: test.availabilityProfile,
+				StorageProfile:      test.storageProfile,
+				MinCount:            test.minCount,
+				MaxCount:            test.maxCount,
+			}
+			if err := a.validateCount(test.orchestratorType); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidatePrivateRegistry(t *testing.T) {
+	tests := []struct {
+		name        string
+		registry    *PrivateRegistry
+		hasWindows  bool
+		expectedErr error
+	}{
+		{
+			name: "no private registry",
+		},
+		{
+			name:     "private registry",
+			registry: &PrivateRegistry{Server: "myregistry.azurecr.io", Username: "user", Password: "p@ss:w0rd"},
+		},
+		{
+			name:     "private registry with a port",
+			registry: &PrivateRegistry{Server: "registry.contoso.internal:5000", Username: "user", Password: "password"},
+		},
+		{
+			name:        "private registry without credentials",
+			registry:    &PrivateRegistry{Server: "myregistry.azurecr.io"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry needs a server, a username and a password"),
+		},
+		{
+			name:        "private registry without password",
+			registry:    &PrivateRegistry{Server: "myregistry.azurecr.io", Username: "user"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry needs a server, a username and a password"),
+		},
+		{
+			name:        "private registry credentials without server",
+			registry:    &PrivateRegistry{Username: "user", Password: "password"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry needs a server, a username and a password"),
+		},
+		{
+			name:        "private registry server with a scheme",
+			registry:    &PrivateRegistry{Server: "https://myregistry.azurecr.io", Username: "user", Password: "password"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry.Server 'https://myregistry.azurecr.io' is not a valid registry host, it needs to be a host name with an optional port, without scheme or path, e.g. myregistry.azurecr.io"),
+		},
+		{
+			name:        "private registry server with a path",
+			registry:    &PrivateRegistry{Server: "myregistry.azurecr.io/team", Username: "user", Password: "password"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry.Server 'myregistry.azurecr.io/team' is not a valid registry host, it needs to be a host name with an optional port, without scheme or path, e.g. myregistry.azurecr.io"),
+		},
+		{
+			name:        "private registry password with a single quote",
+			registry:    &PrivateRegistry{Server: "myregistry.azurecr.io", Username: "user", Password: "pass'word"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry.Username and Password cannot contain single quotes"),
+		},
+		{
+			name:        "private registry with Windows agent pools",
+			registry:    &PrivateRegistry{Server: "myregistry.azurecr.io", Username: "user", Password: "password"},
+			hasWindows:  true,
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.PrivateRegistry is not supported with Windows agent pools"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{PrivateRegistry: test.registry}
+			err := k.validatePrivateRegistry(test.hasWindows)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateEgressFirewall(t *testing.T) {
+	tests := []struct {
+		name        string
+		firewall    *EgressFirewall
+		expectedErr error
+	}{
+		{
+			name: "no egress firewall",
+		},
+		{
+			name:     "egress firewall",
+			firewall: &EgressFirewall{VirtualApplianceIP: "10.0.0.4"},
+		},
+		{
+			name:     "egress firewall with bypass address prefixes",
+			firewall: &EgressFirewall{VirtualApplianceIP: "10.0.0.4", BypassAddressPrefixes: []string{"40.76.0.0/16", "13.64.1.1/32"}},
+		},
+		{
+			name:        "egress firewall without virtual appliance IP",
+			firewall:    &EgressFirewall{},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP '' is not a valid IPv4 address of a virtual appliance"),
+		},
+		{
+			name:        "egress firewall with an invalid virtual appliance IP",
+			firewall:    &EgressFirewall{VirtualApplianceIP: "10.0.0.256"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP '10.0.0.256' is not a valid IPv4 address of a virtual appliance"),
+		},
+		{
+			name:        "egress firewall with an IPv6 virtual appliance IP",
+			firewall:    &EgressFirewall{VirtualApplianceIP: "fd00::4"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP 'fd00::4' is not a valid IPv4 address of a virtual appliance"),
+		},
+		{
+			name:        "egress firewall with an unspecified virtual appliance IP",
+			firewall:    &EgressFirewall{VirtualApplianceIP: "0.0.0.0"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP '0.0.0.0' is not a valid IPv4 address of a virtual appliance"),
+		},
+		{
+			name:        "egress firewall with a loopback virtual appliance IP",
+			firewall:    &EgressFirewall{VirtualApplianceIP: "127.0.0.1"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EgressFirewall.VirtualApplianceIP '127.0.0.1' is not a valid IPv4 address of a virtual appliance"),
+		},
+		{
+			name:        "egress firewall with an invalid bypass address prefix",
+			firewall:    &EgressFirewall{VirtualApplianceIP: "10.0.0.4", BypassAddressPrefixes: []string{"40.76.0.0/16", "40.77.0.0"}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EgressFirewall.BypassAddressPrefixes has '40.77.0.0', it is not a valid IPv4 CIDR"),
+		},
+		{
+			name:        "egress firewall with an IPv6 bypass address prefix",
+			firewall:    &EgressFirewall{VirtualApplianceIP: "10.0.0.4", BypassAddressPrefixes: []string{"2001:db8::/32"}},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EgressFirewall.BypassAddressPrefixes has '2001:db8::/32', it is not a valid IPv4 CIDR"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{EgressFirewall: test.firewall}
+			err := k.validateEgressFirewall()
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateKubeProxyDeploymentMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		k8sVersion  string
+		addons      []KubernetesAddon
+		expectedErr error
+	}{
+		{
+			name:       "default deployment mode",
+			k8sVersion: "1.10.9",
+		},
+		{
+			name:       "DaemonSet",
+			mode:       KubeProxyDeploymentModeDaemonSet,
+			k8sVersion: "1.10.9",
+			addons:     []KubernetesAddon{{Name: "kube-proxy-daemonset", Data: "Zm9vZGF0YQ=="}},
+		},
+		{
+			name:       "static pod",
+			mode:       KubeProxyDeploymentModeStaticPod,
+			k8sVersion: "1.11.4",
+		},
+		{
+			name:        "unknown deployment mode",
+			mode:        "Deployment",
+			k8sVersion:  "1.11.4",
+			expectedErr: errors.New(`unknown OrchestratorProfile.KubernetesConfig.KubeProxyDeploymentMode "Deployment" specified, supported values are "DaemonSet" and "StaticPod"`),
+		},
+		{
+			name:        "static pod before 1.11",
+			mode:        KubeProxyDeploymentModeStaticPod,
+			k8sVersion:  "1.10.9",
+			expectedErr: errors.New(`OrchestratorProfile.KubernetesConfig.KubeProxyDeploymentMode "StaticPod" is only available in Kubernetes version 1.11.0 or greater; unable to validate for Kubernetes version 1.10.9`),
+		},
+		{
+			name:        "static pod with the kube-proxy-daemonset addon",
+			mode:        KubeProxyDeploymentModeStaticPod,
+			k8sVersion:  "1.12.2",
+			addons:      []KubernetesAddon{{Name: "kube-proxy-daemonset", Data: "Zm9vZGF0YQ=="}},
+			expectedErr: errors.New(`the kube-proxy-daemonset addon cannot be configured when OrchestratorProfile.KubernetesConfig.KubeProxyDeploymentMode is "StaticPod"`),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{KubeProxyDeploymentMode: test.mode, Addons: test.addons}
+			err := k.validateKubeProxyDeploymentMode(test.k8sVersion)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateServiceAccountIssuer(t *testing.T) {
+	tests := []struct {
+		name        string
+		issuer      string
+		audiences   []string
+		k8sVersion  string
+		expectedErr error
+	}{
+		{
+			name:       "no issuer",
+			k8sVersion: "1.10.9",
+		},
+		{
+			name:       "issuer",
+			issuer:     "https://issuer.contoso.com/cluster",
+			audiences:  []string{"api", "https://vault.contoso.com"},
+			k8sVersion: "1.12.2",
+		},
+		{
+			name:        "audiences without issuer",
+			audiences:   []string{"api"},
+			k8sVersion:  "1.12.2",
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIAudiences can only be specified with OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer"),
+		},
+		{
+			name:        "http issuer",
+			issuer:      "http://issuer.contoso.com",
+			k8sVersion:  "1.12.2",
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer 'http://issuer.contoso.com' must be an https URL without query or fragment"),
+		},
+		{
+			name:        "issuer without scheme",
+			issuer:      "issuer.contoso.com",
+			k8sVersion:  "1.12.2",
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer 'issuer.contoso.com' must be an https URL without query or fragment"),
+		},
+		{
+			name:        "issuer with query",
+			issuer:      "https://issuer.contoso.com/?cluster=1",
+			k8sVersion:  "1.12.2",
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer 'https://issuer.contoso.com/?cluster=1' must be an https URL without query or fragment"),
+		},
+		{
+			name:        "empty audience",
+			issuer:      "https://issuer.contoso.com",
+			audiences:   []string{"api", " "},
+			k8sVersion:  "1.12.2",
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.APIAudiences has an invalid audience ' '"),
+		},
+		{
+			name:        "issuer before 1.12",
+			issuer:      "https://issuer.contoso.com",
+			k8sVersion:  "1.11.4",
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.ServiceAccountIssuer is only available in Kubernetes version 1.12.0 or greater; unable to validate for Kubernetes version 1.11.4"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			k := &KubernetesConfig{ServiceAccountIssuer: test.issuer, APIAudiences: test.audiences}
+			err := k.validateServiceAccountIssuer(test.k8sVersion)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateMasterLoadBalancer(t *testing.T) {
+	tests := []struct {
+		name        string
+		idleTimeout int
+		interval    int
+		threshold   int
+		expectedErr error
+	}{
+		{
+			name: "default settings",
+		},
+		{
+			name:        "minimum settings",
+			idleTimeout: 4,
+			interval:    5,
+			threshold:   1,
+		},
+		{
+			name:        "maximum idle timeout",
+			idleTimeout: 30,
+			interval:    60,
+			threshold:   10,
+		},
+		{
+			name:        "idle timeout too short",
+			idleTimeout: 3,
+			expectedErr: errors.New("masterProfile has loadBalancerIdleTimeoutInMinutes 3, it needs to be in the range [4,30]"),
+		},
+		{
+			name:        "idle timeout too long",
+			idleTimeout: 31,
+			expectedErr: errors.New("masterProfile has loadBalancerIdleTimeoutInMinutes 31, it needs to be in the range [4,30]"),
+		},
+		{
+			name:        "probe interval too short",
+			interval:    4,
+			expectedErr: errors.New("masterProfile has loadBalancerProbeIntervalInSeconds 4, it needs to be at least 5"),
+		},
+		{
+			name:        "negative probe threshold",
+			threshold:   -1,
+			expectedErr: errors.New("masterProfile has loadBalancerProbeThreshold -1, it needs to be at least 1"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			m := &MasterProfile{
+				LoadBalancerIdleTimeoutInMinutes:   test.idleTimeout,
+				LoadBalancerProbeIntervalInSeconds: test.interval,
+				LoadBalancerProbeThreshold:         test.threshold,
+			}
+			if err := m.validateLoadBalancer(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+
+	t.Run("master profile", func(t *testing.T) {
+		t.Parallel()
+		p := getK8sDefaultProperties(false)
+		p.MasterProfile.LoadBalancerIdleTimeoutInMinutes = 60
+		expectedErr := errors.New("masterProfile has loadBalancerIdleTimeoutInMinutes 60, it needs to be in the range [4,30]")
+		if err := p.Validate(false); !helpers.EqualError(err, expectedErr) {
+			t.Errorf("expected error: %v\ngot error: %v", expectedErr, err)
+		}
+	})
+}
+
+func TestValidateMasterStaticPods(t *testing.T) {
+	scheduler := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: custom-scheduler\n  namespace: kube-system\nspec:\n  hostNetwork: true\n  containers:\n  - name: custom-scheduler\n    image: example.azurecr.io/custom-scheduler:v1.0.0\n"
+	tests := []struct {
+		name             string
+		orchestratorType string
+		pods             []StaticPod
+		expectedErr      error
+	}{
+		{
+			name:             "no static pods",
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:             "static pods",
+			orchestratorType: Kubernetes,
+			pods: []StaticPod{
+				{Name: "custom-scheduler", Manifest: scheduler},
+				{Name: "sidecar", Manifest: "---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: sidecar\nspec:\n  containers:\n  - name: sidecar\n    image: busybox\n"},
+			},
+		},
+		{
+			name:             "not Kubernetes",
+			orchestratorType: DCOS,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: scheduler}},
+			expectedErr:      errors.New("masterProfile staticPods are only supported with the Kubernetes orchestrator"),
+		},
+		{
+			name:             "invalid name",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom/scheduler", Manifest: scheduler}},
+			expectedErr:      errors.New("masterProfile staticPods name 'custom/scheduler' is invalid, it must match ^([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"),
+		},
+		{
+			name:             "reserved name",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "kube-scheduler", Manifest: scheduler}},
+			expectedErr:      errors.New("masterProfile staticPods name 'kube-scheduler' is reserved for the manifest of a master component"),
+		},
+		{
+			name:             "duplicated name",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: scheduler}, {Name: "custom-scheduler", Manifest: scheduler}},
+			expectedErr:      errors.New("masterProfile staticPods name 'custom-scheduler' is duplicated"),
+		},
+		{
+			name:             "several documents",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: scheduler + "---\n" + scheduler}},
+			expectedErr:      errors.New("masterProfile staticPods 'custom-scheduler' manifest must hold a single Pod"),
+		},
+		{
+			name:             "invalid YAML",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: "kind: [\n"}},
+			expectedErr:      errors.New("masterProfile staticPods 'custom-scheduler' manifest is not a valid YAML manifest: error converting YAML to JSON: yaml: line 1: did not find expected node content"),
+		},
+		{
+			name:             "not a Pod",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: custom-scheduler\n"}},
+			expectedErr:      errors.New("masterProfile staticPods 'custom-scheduler' manifest must be a Pod of apiVersion v1, got kind 'Deployment' of apiVersion 'apps/v1'"),
+		},
+		{
+			name:             "Pod without a name",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: "apiVersion: v1\nkind: Pod\nspec:\n  containers:\n  - name: custom-scheduler\n    image: busybox\n"}},
+			expectedErr:      errors.New("masterProfile staticPods 'custom-scheduler' manifest must have a metadata.name"),
+		},
+		{
+			name:             "Pod without containers",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: custom-scheduler\nspec: {}\n"}},
+			expectedErr:      errors.New("masterProfile staticPods 'custom-scheduler' manifest must have at least one container"),
+		},
+		{
+			name:             "container without an image",
+			orchestratorType: Kubernetes,
+			pods:             []StaticPod{{Name: "custom-scheduler", Manifest: "apiVersion: v1\nkind: Pod\nmetadata:\n  name: custom-scheduler\nspec:\n  containers:\n  - name: custom-scheduler\n"}},
+			expectedErr:      errors.New("masterProfile staticPods 'custom-scheduler' manifest containers must have a name and an image"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			m := &MasterProfile{StaticPods: test.pods}
+			if err := m.validateStaticPods(test.orchestratorType); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateContainerMonitoringWorkspace(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]string
+		expectedErr error
+	}{
+		{
+			name: "no workspace",
+		},
+		{
+			name:   "workspace resource ID",
+			config: map[string]string{"workspaceResourceId": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/logging/providers/Microsoft.OperationalInsights/workspaces/central-logs"},
+		},
+		{
+			name:   "workspace resource ID in lowercase",
+			config: map[string]string{"workspaceResourceId": "/subscriptions/00000000-0000-0000-0000-000000000000/resourcegroups/logging/providers/microsoft.operationalinsights/workspaces/central-logs"},
+		},
+		{
+			name:   "workspace id and key",
+			config: map[string]string{"workspaceGuid": "MDAwMDAwMDAtMDAwMC0wMDAwLTAwMDAtMDAwMDAwMDAwMDAw", "workspaceKey": "a2V5"},
+		},
+		{
+			name:   "workspace id encoded with a trailing newline",
+			config: map[string]string{"workspaceGuid": "MDAwMDAwMDAtMDAwMC0wMDAwLTAwMDAtMDAwMDAwMDAwMDAwCg==", "workspaceKey": "a2V5"},
+		},
+		{
+			name:        "workspace resource ID of another resource type",
+			config:      map[string]string{"workspaceResourceId": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/logging/providers/Microsoft.Insights/components/central-logs"},
+			expectedErr: errors.New("container-monitoring add-on config workspaceResourceId '/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/logging/providers/Microsoft.Insights/components/central-logs' is not a valid Log Analytics workspace resource ID, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.OperationalInsights/workspaces/<workspace name>"),
+		},
+		{
+			name:        "workspace name",
+			config:      map[string]string{"workspaceResourceId": "central-logs"},
+			expectedErr: errors.New("container-monitoring add-on config workspaceResourceId 'central-logs' is not a valid Log Analytics workspace resource ID, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.OperationalInsights/workspaces/<workspace name>"),
+		},
+		{
+			name: "workspace resource ID with the workspace id and key",
+			config: map[string]string{
+				"workspaceResourceId": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/logging/providers/Microsoft.OperationalInsights/workspaces/central-logs",
+				"workspaceKey":        "a2V5",
+			},
+			expectedErr: errors.New("container-monitoring add-on config workspaceResourceId cannot be combined with workspaceGuid and workspaceKey"),
+		},
+		{
+			name:        "workspace id not base64 encoded",
+			config:      map[string]string{"workspaceGuid": "00000000-0000-0000-0000-000000000000"},
+			expectedErr: errors.New("container-monitoring add-on config workspaceGuid must be base64 encoded"),
+		},
+		{
+			name:        "workspace id not a GUID",
+			config:      map[string]string{"workspaceGuid": "d29ya3NwYWNl"},
+			expectedErr: errors.New("container-monitoring add-on config workspaceGuid must be the base64 encoding of the workspace ID, a GUID, got 'workspace'"),
+		},
+		{
+			name:        "workspace key not base64 encoded",
+			config:      map[string]string{"workspaceGuid": "MDAwMDAwMDAtMDAwMC0wMDAwLTAwMDAtMDAwMDAwMDAwMDAw", "workspaceKey": "key!"},
+			expectedErr: errors.New("container-monitoring add-on config workspaceKey must be base64 encoded"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{
+				Addons: []KubernetesAddon{
+					{
+						Name:    "container-monitoring",
+						Enabled: helpers.PointerToBool(true),
+						Config:  test.config,
+					},
+				},
+			}
+			if err := p.validateAddons(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateCertificateProfile(t *testing.T) {
+	caPair, err := helpers.CreatePkiKeyCertPair("ca")
+	if err != nil {
+		t.Fatalf("unexpected error creating the CA: %s", err)
+	}
+	otherCAPair, err := helpers.CreatePkiKeyCertPair("ca")
+	if err != nil {
+		t.Fatalf("unexpected error creating the CA: %s", err)
+	}
+	clusterCertificates := CertificateProfile{
+		CaCertificate:         "caCertificate",
+		CaPrivateKey:          "caPrivateKey",
+		APIServerCertificate:  "apiServerCertificate",
+		APIServerPrivateKey:   "apiServerPrivateKey",
+		ClientCertificate:     "clientCertificate",
+		ClientPrivateKey:      "clientPrivateKey",
+		KubeConfigCertificate: "kubeConfigCertificate",
+		KubeConfigPrivateKey:  "kubeConfigPrivateKey",
+		EtcdServerCertificate: "etcdServerCertificate",
+		EtcdServerPrivateKey:  "etcdServerPrivateKey",
+		EtcdClientCertificate: "etcdClientCertificate",
+		EtcdClientPrivateKey:  "etcdClientPrivateKey",
+		EtcdPeerCertificates:  []string{"etcdPeerCertificate0"},
+		EtcdPeerPrivateKeys:   []string{"etcdPeerPrivateKey0"},
+	}
+
+	tests := []struct {
+		name        string
+		profile     *CertificateProfile
+		expectedErr error
+	}{
+		{
+			name: "no certificate profile",
+		},
+		{
+			name:    "generated CA",
+			profile: &CertificateProfile{APIServerCertificate: "apiServerCertificate"},
+		},
+		{
+			name:    "provided CA",
+			profile: &CertificateProfile{CaCertificate: caPair.CertificatePem, CaPrivateKey: caPair.PrivateKeyPem},
+		},
+		{
+			name:    "provided cluster certificates",
+			profile: &clusterCertificates,
+		},
+		{
+			name:        "CA certificate without its key",
+			profile:     &CertificateProfile{CaCertificate: caPair.CertificatePem},
+			expectedErr: errors.New("CertificateProfile.CaCertificate and CertificateProfile.CaPrivateKey must be provided together"),
+		},
+		{
+			name:        "CA certificate not PEM encoded",
+			profile:     &CertificateProfile{CaCertificate: "caCertificate", CaPrivateKey: caPair.PrivateKeyPem},
+			expectedErr: errors.New("CertificateProfile has an invalid certificate authority: the CA certificate is invalid: The raw pem is not a valid PEM formatted block"),
+		},
+		{
+			name:    "provided front proxy CA",
+			profile: &CertificateProfile{FrontProxyCACertificate: caPair.CertificatePem, FrontProxyCAPrivateKey: caPair.PrivateKeyPem},
+		},
+		{
+			name: "provided front proxy client certificate",
+			profile: &CertificateProfile{FrontProxyCACertificate: "frontProxyCACertificate",
+				FrontProxyClientCertificate: "frontProxyClientCertificate", FrontProxyClientPrivateKey: "frontProxyClientPrivateKey"},
+		},
+		{
+			name:        "front proxy client certificate without its key",
+			profile:     &CertificateProfile{FrontProxyCACertificate: "frontProxyCACertificate", FrontProxyClientCertificate: "frontProxyClientCertificate"},
+			expectedErr: errors.New("CertificateProfile.FrontProxyClientCertificate and CertificateProfile.FrontProxyClientPrivateKey must be provided together"),
+		},
+		{
+			name:        "front proxy client certificate without the CA",
+			profile:     &CertificateProfile{FrontProxyClientCertificate: "frontProxyClientCertificate", FrontProxyClientPrivateKey: "frontProxyClientPrivateKey"},
+			expectedErr: errors.New("CertificateProfile.FrontProxyCACertificate must be provided along with the front proxy CA private key or client certificate"),
+		},
+		{
+			name:        "front proxy CA certificate without its key",
+			profile:     &CertificateProfile{FrontProxyCACertificate: caPair.CertificatePem},
+			expectedErr: errors.New("CertificateProfile.FrontProxyCAPrivateKey must be provided to issue the front proxy client certificate"),
+		},
+		{
+			name:        "front proxy CA private key not matching",
+			profile:     &CertificateProfile{FrontProxyCACertificate: caPair.CertificatePem, FrontProxyCAPrivateKey: otherCAPair.PrivateKeyPem},
+			expectedErr: errors.New("CertificateProfile has an invalid front proxy certificate authority: the CA private key doesn't match the CA certificate"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			p := getK8sDefaultProperties(false)
+			p.CertificateProfile = test.profile
+			if err := p.validateCertificateProfile(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateStorageAccountID(t *testing.T) {
+	storageAccountID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/vhds/providers/Microsoft.Storage/storageAccounts/clustervhds"
+	tests := []struct {
+		name             string
+		storageAccountID string
+		storageProfile   string
+		orchestratorType string
+		expectedErr      error
+	}{
+		{
+			name:             "created storage accounts",
+			storageProfile:   StorageAccount,
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:             "existing storage account",
+			storageAccountID: storageAccountID,
+			storageProfile:   StorageAccount,
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:             "existing storage account with DCOS",
+			storageAccountID: storageAccountID,
+			storageProfile:   StorageAccount,
+			orchestratorType: DCOS,
+			expectedErr:      errors.New("the storageAccountId of agent pool 'agentpool1' is only supported by the Kubernetes orchestrator"),
+		},
+		{
+			name:             "existing storage account with managed disks",
+			storageAccountID: storageAccountID,
+			storageProfile:   ManagedDisks,
+			orchestratorType: Kubernetes,
+			expectedErr:      errors.New("the storageAccountId of agent pool 'agentpool1' requires the storageProfile StorageAccount, the VHDs of managed disks are not stored in storage accounts"),
+		},
+		{
+			name:             "storage account name",
+			storageAccountID: "clustervhds",
+			storageProfile:   StorageAccount,
+			orchestratorType: Kubernetes,
+			expectedErr:      errors.New("the storageAccountId of agent pool 'agentpool1', 'clustervhds', is not the resource ID of a storage account, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Storage/storageAccounts/<storage account name>"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateStorageAccountID("agent pool 'agentpool1'", test.storageAccountID, test.storageProfile, test.orchestratorType)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
 
-	t.Run("Should not support orchestratorTypes other than Kubernetes/DCOS", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = SwarmMode
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].CustomNodeLabels = map[string]string{
-			"foo": "bar",
-		}
-		expectedMsg := "Agent CustomNodeLabels are only supported for DCOS and Kubernetes"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	p := getK8sDefaultProperties(true)
+	p.AgentPoolProfiles[0].StorageProfile = StorageAccount
+	p.AgentPoolProfiles[0].StorageAccountID = storageAccountID
+	p.AgentPoolProfiles[0].OSType = Windows
+	expectedErr := errors.New("agent pool 'agentpool' cannot use an existing storage account, storageAccountId is not supported by Windows agent pools")
+	if err := p.validateAgentPoolProfiles(true); !helpers.EqualError(err, expectedErr) {
+		t.Errorf("expected error: %v\ngot error: %v", expectedErr, err)
+	}
 }
 
-func TestAgentPoolProfile_ValidateAvailabilityProfile(t *testing.T) {
-	t.Run("Should fail for invalid availability profile", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = "InvalidAvailabilityProfile"
-		expectedMsg := "unknown availability profile type 'InvalidAvailabilityProfile' for agent pool 'agentpool'.  Specify either AvailabilitySet, or VirtualMachineScaleSets"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+func TestValidateDiskEncryptionSetID(t *testing.T) {
+	diskEncryptionSetID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/keys/providers/Microsoft.Compute/diskEncryptionSets/clusterdes"
+	tests := []struct {
+		name                string
+		diskEncryptionSetID string
+		storageProfile      string
+		orchestratorType    string
+		expectedErr         error
+	}{
+		{
+			name:             "platform-managed keys",
+			storageProfile:   ManagedDisks,
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:                "disk encryption set",
+			diskEncryptionSetID: diskEncryptionSetID,
+			storageProfile:      ManagedDisks,
+			orchestratorType:    Kubernetes,
+		},
+		{
+			name:                "disk encryption set with the default storage profile",
+			diskEncryptionSetID: diskEncryptionSetID,
+			orchestratorType:    Kubernetes,
+		},
+		{
+			name:                "disk encryption set with DCOS",
+			diskEncryptionSetID: diskEncryptionSetID,
+			storageProfile:      ManagedDisks,
+			orchestratorType:    DCOS,
+			expectedErr:         errors.New("the diskEncryptionSetID of agent pool 'agentpool1' is only supported by the Kubernetes orchestrator"),
+		},
+		{
+			name:                "disk encryption set with unmanaged disks",
+			diskEncryptionSetID: diskEncryptionSetID,
+			storageProfile:      StorageAccount,
+			orchestratorType:    Kubernetes,
+			expectedErr:         errors.New("the diskEncryptionSetID of agent pool 'agentpool1' requires the storageProfile ManagedDisks, disk encryption sets only encrypt managed disks"),
+		},
+		{
+			name:                "key vault key",
+			diskEncryptionSetID: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/keys/providers/Microsoft.KeyVault/vaults/clusterkeys",
+			storageProfile:      ManagedDisks,
+			orchestratorType:    Kubernetes,
+			expectedErr:         errors.New("the diskEncryptionSetID of agent pool 'agentpool1', '/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/keys/providers/Microsoft.KeyVault/vaults/clusterkeys', is not the resource ID of a disk encryption set, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Compute/diskEncryptionSets/<disk encryption set name>"),
+		},
+	}
 
-	t.Run("Should fail when using VirtualMachineScalesets with Openshift", func(t *testing.T) {
-		t.Parallel()
-		p := getK8sDefaultProperties(false)
-		p.OrchestratorProfile.OrchestratorType = OpenShift
-		agentPoolProfiles := p.AgentPoolProfiles
-		agentPoolProfiles[0].AvailabilityProfile = VirtualMachineScaleSets
-		expectedMsg := "Only AvailabilityProfile: AvailabilitySet is supported for Orchestrator 'OpenShift'"
-		if err := p.validateAgentPoolProfiles(true); err.Error() != expectedMsg {
-			t.Errorf("expected error with message : %s, but got %s", expectedMsg, err.Error())
-		}
-	})
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			a := &AgentPoolProfile{
+				Name:                "agentpool1",
+				StorageProfile:      test.storageProfile,
+				DiskEncryptionSetID: test.diskEncryptionSetID,
+			}
+			err := a.validateDiskEncryptionSetID(test.orchestratorType)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateProximityPlacementGroupID(t *testing.T) {
+	proximityPlacementGroupID := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/placement/providers/Microsoft.Compute/proximityPlacementGroups/clusterppg"
+	tests := []struct {
+		name                      string
+		proximityPlacementGroupID string
+		availabilityZones         []string
+		orchestratorType          string
+		expectedErr               error
+	}{
+		{
+			name:             "no proximity placement group",
+			orchestratorType: Kubernetes,
+		},
+		{
+			name:                      "proximity placement group",
+			proximityPlacementGroupID: proximityPlacementGroupID,
+			orchestratorType:          Kubernetes,
+		},
+		{
+			name:                      "proximity placement group with DCOS",
+			proximityPlacementGroupID: proximityPlacementGroupID,
+			orchestratorType:          DCOS,
+			expectedErr:               errors.New("the proximityPlacementGroupID of agent pool 'agentpool1' is only supported by the Kubernetes orchestrator"),
+		},
+		{
+			name:                      "proximity placement group with availability zones",
+			proximityPlacementGroupID: proximityPlacementGroupID,
+			availabilityZones:         []string{"1", "2"},
+			orchestratorType:          Kubernetes,
+			expectedErr:               errors.New("the proximityPlacementGroupID of agent pool 'agentpool1' cannot be combined with availabilityZones, a proximity placement group co-locates the VMs in a single datacenter"),
+		},
+		{
+			name:                      "proximity placement group name",
+			proximityPlacementGroupID: "clusterppg",
+			orchestratorType:          Kubernetes,
+			expectedErr:               errors.New("the proximityPlacementGroupID of agent pool 'agentpool1', 'clusterppg', is not the resource ID of a proximity placement group, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Compute/proximityPlacementGroups/<proximity placement group name>"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateProximityPlacementGroupID("agent pool 'agentpool1'", test.proximityPlacementGroupID, test.availabilityZones, test.orchestratorType)
+			if !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+
+	p := getK8sDefaultProperties(true)
+	p.MasterProfile.ProximityPlacementGroupID = "clusterppg"
+	expectedErr := errors.New("the proximityPlacementGroupID of masterProfile, 'clusterppg', is not the resource ID of a proximity placement group, e.g. /subscriptions/<subscription id>/resourceGroups/<resource group>/providers/Microsoft.Compute/proximityPlacementGroups/<proximity placement group name>")
+	if err := p.validateMasterProfile(); !helpers.EqualError(err, expectedErr) {
+		t.Errorf("expected error: %v\ngot error: %v", expectedErr, err)
+	}
+}
+
+func TestValidateCustomCNI(t *testing.T) {
+	manifest := base64.StdEncoding.EncodeToString([]byte("apiVersion: apps/v1\nkind: DaemonSet\n"))
+	tests := []struct {
+		name        string
+		k           *KubernetesConfig
+		hasWindows  bool
+		expectedErr error
+	}{
+		{
+			name: "built-in network plugin",
+			k:    &KubernetesConfig{NetworkPlugin: "azure"},
+		},
+		{
+			name: "cni network plugin with its manifest",
+			k: &KubernetesConfig{
+				NetworkPlugin: "cni",
+				Addons:        []KubernetesAddon{{Name: "cni", Data: manifest}},
+			},
+		},
+		{
+			name:        "cni network plugin without its manifest",
+			k:           &KubernetesConfig{NetworkPlugin: "cni"},
+			expectedErr: errors.New("networkPlugin 'cni' requires the manifest installing the CNI in the data of the cni addon"),
+		},
+		{
+			name: "cni network plugin with an empty manifest",
+			k: &KubernetesConfig{
+				NetworkPlugin: "cni",
+				Addons:        []KubernetesAddon{{Name: "cni"}},
+			},
+			expectedErr: errors.New("networkPlugin 'cni' requires the manifest installing the CNI in the data of the cni addon"),
+		},
+		{
+			name: "cni network plugin with a disabled manifest",
+			k: &KubernetesConfig{
+				NetworkPlugin: "cni",
+				Addons:        []KubernetesAddon{{Name: "cni", Enabled: helpers.PointerToBool(false), Data: manifest}},
+			},
+			expectedErr: errors.New("networkPlugin 'cni' requires the cni addon to be enabled"),
+		},
+		{
+			name: "cni network plugin with Windows agent pools",
+			k: &KubernetesConfig{
+				NetworkPlugin: "cni",
+				Addons:        []KubernetesAddon{{Name: "cni", Data: manifest}},
+			},
+			hasWindows:  true,
+			expectedErr: errors.New("networkPlugin 'cni' is not supported with Windows agent pools"),
+		},
+		{
+			name: "cni addon with a built-in network plugin",
+			k: &KubernetesConfig{
+				NetworkPlugin: "kubenet",
+				Addons:        []KubernetesAddon{{Name: "cni", Data: manifest}},
+			},
+			expectedErr: errors.New("the cni addon is only used with networkPlugin 'cni', not 'kubenet'"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if err := test.k.validateCustomCNI(test.hasWindows); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateEtcdMaintenance(t *testing.T) {
+	tests := []struct {
+		name        string
+		k           *KubernetesConfig
+		expectedErr error
+	}{
+		{
+			name: "default etcd maintenance",
+			k:    &KubernetesConfig{},
+		},
+		{
+			name: "compaction interval and defrag schedule",
+			k: &KubernetesConfig{
+				EtcdCompactionInterval: "10m",
+				EtcdDefragSchedule:     "0 3 * * 0",
+			},
+		},
+		{
+			name: "disabled compactions and cron macro",
+			k: &KubernetesConfig{
+				EtcdCompactionInterval: "0s",
+				EtcdDefragSchedule:     "@weekly",
+			},
+		},
+		{
+			name: "defrag schedule with ranges and steps",
+			k:    &KubernetesConfig{EtcdDefragSchedule: "*/30 1-5 * * 1,3,5"},
+		},
+		{
+			name:        "compaction interval without unit",
+			k:           &KubernetesConfig{EtcdCompactionInterval: "10"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EtcdCompactionInterval '10' is not a valid duration, it needs to be a non-negative duration, e.g. 5m0s, or 0s to disable the compactions"),
+		},
+		{
+			name:        "negative compaction interval",
+			k:           &KubernetesConfig{EtcdCompactionInterval: "-5m"},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EtcdCompactionInterval '-5m' is not a valid duration, it needs to be a non-negative duration, e.g. 5m0s, or 0s to disable the compactions"),
+		},
+		{
+			name:        "defrag schedule with four fields",
+			k:           &KubernetesConfig{EtcdDefragSchedule: "0 3 * *"},
+			expectedErr: errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdDefragSchedule '0 3 * *' is not a valid cron schedule, it must match %s, e.g. \"0 3 * * 0\"", etcdDefragScheduleFormat),
+		},
+		{
+			name:        "defrag schedule with a command",
+			k:           &KubernetesConfig{EtcdDefragSchedule: "0 3 * * 0 root rm -rf /"},
+			expectedErr: errors.Errorf("OrchestratorProfile.KubernetesConfig.EtcdDefragSchedule '0 3 * * 0 root rm -rf /' is not a valid cron schedule, it must match %s, e.g. \"0 3 * * 0\"", etcdDefragScheduleFormat),
+		},
+		{
+			name: "defrag schedule with etcd 2",
+			k: &KubernetesConfig{
+				EtcdDefragSchedule: "@daily",
+				EtcdVersion:        "2.3.8",
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.EtcdDefragSchedule requires etcd 3.0.0 or greater, not 2.3.8"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if err := test.k.validateEtcdMaintenance(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateCloudProviderConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		k           *KubernetesConfig
+		expectedErr error
+	}{
+		{
+			name: "no cloud provider config",
+			k:    &KubernetesConfig{},
+		},
+		{
+			name: "known keys of every kind",
+			k: &KubernetesConfig{
+				CloudProviderConfig: map[string]string{
+					"loadBalancerResourceGroup":   "lb-rg",
+					"routeTableResourceGroup":     "rt-rg",
+					"cloudProviderBackoff":        "true",
+					"cloudProviderBackoffRetries": "6",
+					"cloudProviderBackoffJitter":  "1.5",
+				},
+			},
+		},
+		{
+			name: "unknown key",
+			k: &KubernetesConfig{
+				CloudProviderConfig: map[string]string{
+					"aadClientSecret": "secret",
+				},
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderConfig key 'aadClientSecret' is not supported"),
+		},
+		{
+			name: "invalid bool",
+			k: &KubernetesConfig{
+				CloudProviderConfig: map[string]string{
+					"cloudProviderBackoff": "yes",
+				},
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderConfig value 'yes' of cloudProviderBackoff is not a valid bool"),
+		},
+		{
+			name: "invalid int",
+			k: &KubernetesConfig{
+				CloudProviderConfig: map[string]string{
+					"cloudProviderBackoffRetries": "1.5",
+				},
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderConfig value '1.5' of cloudProviderBackoffRetries is not a valid int"),
+		},
+		{
+			name: "invalid float",
+			k: &KubernetesConfig{
+				CloudProviderConfig: map[string]string{
+					"cloudProviderRateLimitQPS": "fast",
+				},
+			},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderConfig value 'fast' of cloudProviderRateLimitQPS is not a valid float"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if err := test.k.validateCloudProviderConfig(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateCloudProviderBackoffAndRateLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		k           *KubernetesConfig
+		expectedErr error
+	}{
+		{
+			name: "default backoff and rate limit",
+			k:    &KubernetesConfig{},
+		},
+		{
+			name: "tuned backoff and rate limit",
+			k: &KubernetesConfig{
+				CloudProviderBackoff:         helpers.PointerToBool(true),
+				CloudProviderBackoffRetries:  10,
+				CloudProviderBackoffDuration: 3,
+				CloudProviderBackoffExponent: 2,
+				CloudProviderBackoffJitter:   0.5,
+				CloudProviderRateLimit:       helpers.PointerToBool(true),
+				CloudProviderRateLimitQPS:    1.5,
+				CloudProviderRateLimitBucket: 20,
+			},
+		},
+		{
+			name:        "negative backoff retries",
+			k:           &KubernetesConfig{CloudProviderBackoffRetries: -1},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffRetries '-1' must not be negative"),
+		},
+		{
+			name:        "negative backoff duration",
+			k:           &KubernetesConfig{CloudProviderBackoffDuration: -5},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffDuration '-5' must not be negative, it is the initial backoff in seconds"),
+		},
+		{
+			name:        "shrinking backoff exponent",
+			k:           &KubernetesConfig{CloudProviderBackoffExponent: 0.5},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffExponent '0.5' must be 1 or greater, the backoff would shrink between the retries"),
+		},
+		{
+			name:        "negative backoff jitter",
+			k:           &KubernetesConfig{CloudProviderBackoffJitter: -0.1},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderBackoffJitter '-0.1' must not be negative"),
+		},
+		{
+			name:        "negative rate limit qps",
+			k:           &KubernetesConfig{CloudProviderRateLimitQPS: -3},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderRateLimitQPS '-3' must not be negative"),
+		},
+		{
+			name:        "negative rate limit bucket",
+			k:           &KubernetesConfig{CloudProviderRateLimitBucket: -10},
+			expectedErr: errors.New("OrchestratorProfile.KubernetesConfig.CloudProviderRateLimitBucket '-10' must not be negative"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if err := test.k.validateCloudProviderBackoffAndRateLimit(); !helpers.EqualError(err, test.expectedErr) {
+				t.Errorf("expected error: %v\ngot error: %v", test.expectedErr, err)
+			}
+		})
+	}
 }
@@ -4,6 +4,7 @@
 package armhelpers
 
 import (
+	"bytes"
 	"context"
 	"crypto/rsa"
 	"crypto/x509"
@@ -54,6 +55,7 @@ type AzureClient struct {
 	subscriptionID  string
 
 	authorizationClient             authorization.RoleAssignmentsClient
+	permissionsClient               authorization.PermissionsClient
 	deploymentsClient               resources.DeploymentsClient
 	deploymentOperationsClient      resources.DeploymentOperationsClient
 	msiClient                       msi.UserAssignedIdentitiesClient
@@ -218,6 +220,39 @@ func NewAzureClientWithClientCertificate(env azure.Environment, subscriptionID,
 	return newAzureClientWithCertificate(env, oauthConfig, subscriptionID, clientID, tenantID, certificate, privateKey)
 }
 
+// NewAzureClientWithClientCertificateBundle returns an AzureClient via client_id and jwt certificate assertion,
+// the certificate and its private key are read from a single PEM bundle as found in the ServicePrincipalProfile
+func NewAzureClientWithClientCertificateBundle(env azure.Environment, subscriptionID, clientID string, bundle []byte, password string) (*AzureClient, error) {
+	certificate, privateKey, err := parseCertificateBundle(bundle, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAzureClientWithClientCertificate(env, subscriptionID, clientID, certificate, privateKey)
+}
+
+// NewAzureClientWithClientCertificateBundleFile returns an AzureClient via client_id and jwt certificate assertion,
+// the certificate and its private key are read from a single PEM bundle file
+func NewAzureClientWithClientCertificateBundleFile(env azure.Environment, subscriptionID, clientID, bundlePath, password string) (*AzureClient, error) {
+	bundle, err := ioutil.ReadFile(bundlePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read certificate bundle")
+	}
+
+	return NewAzureClientWithClientCertificateBundle(env, subscriptionID, clientID, bundle, password)
+}
+
+// NewAzureClientWithClientCertificateBundleExternalTenant returns an AzureClient via client_id and jwt certificate assertion
+// against a 3rd party tenant, the certificate and its private key are read from a single PEM bundle
+func NewAzureClientWithClientCertificateBundleExternalTenant(env azure.Environment, subscriptionID, tenantID, clientID string, bundle []byte, password string) (*AzureClient, error) {
+	certificate, privateKey, err := parseCertificateBundle(bundle, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAzureClientWithClientCertificateExternalTenant(env, subscriptionID, tenantID, clientID, certificate, privateKey)
+}
+
 // NewAzureClientWithClientCertificateExternalTenant returns an AzureClient via client_id and jwt certificate assertion against a 3rd party tenant
 func NewAzureClientWithClientCertificateExternalTenant(env azure.Environment, subscriptionID, tenantID, clientID string, certificate *x509.Certificate, privateKey *rsa.PrivateKey) (*AzureClient, error) {
 	oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, tenantID)
@@ -310,6 +345,7 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armSpt *a
 		subscriptionID: subscriptionID,
 
 		authorizationClient:             authorization.NewRoleAssignmentsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
+		permissionsClient:               authorization.NewPermissionsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		deploymentsClient:               resources.NewDeploymentsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		deploymentOperationsClient:      resources.NewDeploymentOperationsClientWithBaseURI(env.ResourceManagerEndpoint, subscriptionID),
 		msiClient:                       msi.NewUserAssignedIdentitiesClient(subscriptionID),
@@ -329,6 +365,7 @@ func getClient(env azure.Environment, subscriptionID, tenantID string, armSpt *a
 
 	authorizer := autorest.NewBearerAuthorizer(armSpt)
 	c.authorizationClient.Authorizer = authorizer
+	c.permissionsClient.Authorizer = authorizer
 	c.deploymentsClient.Authorizer = authorizer
 	c.deploymentOperationsClient.Authorizer = authorizer
 	c.msiClient.Authorizer = authorizer
@@ -412,6 +449,10 @@ func parseRsaPrivateKey(path string) (*rsa.PrivateKey, error) {
 		return nil, errors.New("Failed to decode a pem block from private key")
 	}
 
+	return parseRsaPrivateKeyBlock(block)
+}
+
+func parseRsaPrivateKeyBlock(block *pem.Block) (*rsa.PrivateKey, error) {
 	privatePkcs1Key, errPkcs1 := x509.ParsePKCS1PrivateKey(block.Bytes)
 	if errPkcs1 == nil {
 		return privatePkcs1Key, nil
@@ -429,6 +470,60 @@ func parseRsaPrivateKey(path string) (*rsa.PrivateKey, error) {
 	return nil, errors.Errorf("failed to parse private key as Pkcs#1 or Pkcs#8. (%s). (%s)", errPkcs1, errPkcs8)
 }
 
+// parseCertificateBundle extracts the first certificate and the rsa private key from a PEM bundle.
+// An encrypted private key is decrypted with password.
+func parseCertificateBundle(bundle []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	var certificate *x509.Certificate
+	var privateKey *rsa.PrivateKey
+
+	// every block of the bundle is validated, the first certificate is the client certificate and the others its chain
+	block, rest := pem.Decode(bundle)
+	for ; block != nil; block, rest = pem.Decode(rest) {
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "Failed to parse certificate")
+			}
+			if certificate == nil {
+				certificate = cert
+			}
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			if privateKey != nil {
+				return nil, nil, errors.New("certificate bundle contains more than one private key")
+			}
+			if x509.IsEncryptedPEMBlock(block) {
+				if password == "" {
+					return nil, nil, errors.New("private key is encrypted but no certificate password was provided")
+				}
+				der, err := x509.DecryptPEMBlock(block, []byte(password))
+				if err != nil {
+					return nil, nil, errors.Wrap(err, "Failed to decrypt private key")
+				}
+				block = &pem.Block{Type: block.Type, Bytes: der}
+			}
+			key, err := parseRsaPrivateKeyBlock(block)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "Failed to parse rsa private key")
+			}
+			privateKey = key
+		default:
+			return nil, nil, errors.Errorf("unexpected pem block of type %q in certificate bundle", block.Type)
+		}
+	}
+	if len(bytes.TrimSpace(rest)) != 0 {
+		return nil, nil, errors.New("Failed to decode pem block from certificate bundle")
+	}
+
+	if certificate == nil {
+		return nil, nil, errors.New("Failed to decode a certificate pem block from certificate bundle")
+	}
+	if privateKey == nil {
+		return nil, nil, errors.New("Failed to decode a private key pem block from certificate bundle")
+	}
+	return certificate, privateKey, nil
+}
+
 //AddAcceptLanguages sets the list of languages to accept on this request
 func (az *AzureClient) AddAcceptLanguages(languages []string) {
 	az.acceptLanguages = languages
@@ -5,11 +5,16 @@ package armhelpers
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest"
 
+	"github.com/Azure/acs-engine/pkg/helpers"
+
 	. "github.com/Azure/acs-engine/pkg/test"
 	. "github.com/onsi/gomega"
 
@@ -38,3 +43,71 @@ var _ = Describe("AzureClient Aux token tests", func() {
 		Expect(request.Header.Get("x-ms-authorization-auxiliary")).To(Equal(fmt.Sprintf("Bearer %s", token)))
 	})
 })
+
+var _ = Describe("AzureClient certificate bundle tests", func() {
+	var pair *helpers.PkiKeyCertPair
+	var env azure.Environment
+
+	BeforeEach(func() {
+		var err error
+		pair, err = helpers.CreatePkiKeyCertPair("serviceprincipal")
+		Expect(err).To(BeNil())
+		env, err = azure.EnvironmentFromName("AZUREPUBLICCLOUD")
+		Expect(err).To(BeNil())
+	})
+
+	It("Should create a client from a certificate bundle", func() {
+		bundle := []byte(pair.CertificatePem + pair.PrivateKeyPem)
+		azureClient, err := NewAzureClientWithClientCertificateBundleExternalTenant(env, "subID", "d1a3-4ea4", "clientID", bundle, "")
+		Expect(err).To(BeNil())
+		Expect(azureClient).To(Not(BeNil()))
+	})
+
+	It("Should create a client from a certificate bundle with a chain", func() {
+		ca, err := helpers.CreatePkiKeyCertPair("ca")
+		Expect(err).To(BeNil())
+		bundle := []byte(pair.CertificatePem + ca.CertificatePem + pair.PrivateKeyPem)
+		azureClient, err := NewAzureClientWithClientCertificateBundleExternalTenant(env, "subID", "d1a3-4ea4", "clientID", bundle, "")
+		Expect(err).To(BeNil())
+		Expect(azureClient).To(Not(BeNil()))
+	})
+
+	It("Should decrypt an encrypted private key with the certificate password", func() {
+		block, _ := pem.Decode([]byte(pair.PrivateKeyPem))
+		Expect(block).To(Not(BeNil()))
+		encrypted, err := x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte("password"), x509.PEMCipherAES256)
+		Expect(err).To(BeNil())
+		bundle := append([]byte(pair.CertificatePem), pem.EncodeToMemory(encrypted)...)
+
+		azureClient, err := NewAzureClientWithClientCertificateBundleExternalTenant(env, "subID", "d1a3-4ea4", "clientID", bundle, "password")
+		Expect(err).To(BeNil())
+		Expect(azureClient).To(Not(BeNil()))
+
+		_, err = NewAzureClientWithClientCertificateBundle(env, "subID", "clientID", bundle, "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail when the bundle has no private key", func() {
+		_, err := NewAzureClientWithClientCertificateBundle(env, "subID", "clientID", []byte(pair.CertificatePem), "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail when the bundle has a malformed chain", func() {
+		malformed := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not a certificate")})
+		bundle := append([]byte(pair.CertificatePem), malformed...)
+		bundle = append(bundle, []byte(pair.PrivateKeyPem)...)
+		_, err := NewAzureClientWithClientCertificateBundle(env, "subID", "clientID", bundle, "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail when the bundle has undecodable data", func() {
+		bundle := []byte(pair.CertificatePem + pair.PrivateKeyPem + "-----BEGIN CERTIFICATE-----\ngarbage")
+		_, err := NewAzureClientWithClientCertificateBundle(env, "subID", "clientID", bundle, "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail when the bundle file cannot be read", func() {
+		_, err := NewAzureClientWithClientCertificateBundleFile(env, "subID", "clientID", "/nonexistent/bundle.pem", "")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -63,7 +63,7 @@ func (e *DeploymentValidationError) Error() string {
 func DeployTemplateSync(az ACSEngineClient, logger *logrus.Entry, resourceGroupName, deploymentName string, template map[string]interface{}, parameters map[string]interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), DefaultARMOperationTimeout)
 	defer cancel()
-	deploymentExtended, err := az.DeployTemplate(ctx, resourceGroupName, deploymentName, template, parameters)
+	deploymentExtended, err := az.DeployTemplate(ctx, resourceGroupName, deploymentName, template, parameters, resources.Incremental)
 	if err == nil {
 		return nil
 	}
@@ -13,16 +13,16 @@ import (
 )
 
 // DeployTemplate implements the TemplateDeployer interface for the AzureClient client
-func (az *AzureClient) DeployTemplate(ctx context.Context, resourceGroupName, deploymentName string, template map[string]interface{}, parameters map[string]interface{}) (de resources.DeploymentExtended, err error) {
+func (az *AzureClient) DeployTemplate(ctx context.Context, resourceGroupName, deploymentName string, template map[string]interface{}, parameters map[string]interface{}, mode resources.DeploymentMode) (de resources.DeploymentExtended, err error) {
 	deployment := resources.Deployment{
 		Properties: &resources.DeploymentProperties{
 			Template:   &template,
 			Parameters: &parameters,
-			Mode:       resources.Incremental,
+			Mode:       mode,
 		},
 	}
 
-	log.Infof("Starting ARM Deployment (%s). This will take some time...", deploymentName)
+	log.Infof("Starting ARM Deployment (%s) in %s mode. This will take some time...", deploymentName, mode)
 	future, err := az.deploymentsClient.CreateOrUpdate(ctx, resourceGroupName, deploymentName, deployment)
 	if err != nil {
 		return de, err
@@ -21,6 +21,8 @@ import (
 const (
 	// AADContributorRoleID is the role id that exists in every subscription for 'Contributor'
 	AADContributorRoleID = "b24988ac-6180-42a0-ab88-20f7382dd24c"
+	// AADNetworkContributorRoleID is the role id that exists in every subscription for 'Network Contributor'
+	AADNetworkContributorRoleID = "4d97b98b-1d4f-4787-a291-c67834d212e7"
 	// AADRoleReferenceTemplate is a template for a roleDefinitionId
 	AADRoleReferenceTemplate = "/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s"
 	// AADRoleResourceGroupScopeTemplate is a template for a roleDefinition scope
@@ -58,6 +60,17 @@ func (az *AzureClient) ListRoleAssignmentsForPrincipal(ctx context.Context, scop
 	return &page, err
 }
 
+// ListPermissionsForResourceGroup gets the permissions the credentials of the client have on a resource group,
+// granted by all their role assignments
+func (az *AzureClient) ListPermissionsForResourceGroup(ctx context.Context, resourceGroup string) ([]authorization.Permission, error) {
+	var permissions []authorization.Permission
+	page, err := az.permissionsClient.ListForResourceGroup(ctx, resourceGroup)
+	for ; err == nil && page.NotDone(); err = page.Next() {
+		permissions = append(permissions, page.Values()...)
+	}
+	return permissions, err
+}
+
 // CreateApp is a simpler method for creating an application
 func (az *AzureClient) CreateApp(ctx context.Context, appName, appURL string, replyURLs *[]string, requiredResourceAccess *[]graphrbac.RequiredResourceAccess) (applicationResp graphrbac.Application, servicePrincipalObjectID, servicePrincipalClientSecret string, err error) {
 	notBefore := time.Now()
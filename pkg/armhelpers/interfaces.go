@@ -12,10 +12,13 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2018-02-01/storage"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest"
 	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 )
 
 // VirtualMachineListResultPage is an interface for compute.VirtualMachineListResultPage to aid in mocking
@@ -55,8 +58,8 @@ type ACSEngineClient interface {
 
 	// RESOURCES
 
-	// DeployTemplate can deploy a template into Azure ARM
-	DeployTemplate(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}) (resources.DeploymentExtended, error)
+	// DeployTemplate can deploy a template into Azure ARM, in the Incremental or Complete deployment mode
+	DeployTemplate(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}, mode resources.DeploymentMode) (resources.DeploymentExtended, error)
 
 	// EnsureResourceGroup ensures the specified resource group exists in the specified location
 	EnsureResourceGroup(ctx context.Context, resourceGroup, location string, managedBy *string) (*resources.Group, error)
@@ -85,6 +88,9 @@ type ACSEngineClient interface {
 	// SetVirtualMachineScaleSetCapacity sets the VMSS capacity
 	SetVirtualMachineScaleSetCapacity(ctx context.Context, resourceGroup, virtualMachineScaleSet string, sku compute.Sku, location string) error
 
+	// ListResourceSkus lists the compute SKUs offered in a location, with their restrictions for the subscription
+	ListResourceSkus(ctx context.Context, location string) ([]ResourceSku, error)
+
 	//
 	// STORAGE
 
@@ -92,12 +98,18 @@ type ACSEngineClient interface {
 	// account.
 	GetStorageClient(ctx context.Context, resourceGroup, accountName string) (ACSStorageClient, error)
 
+	// GetStorageAccount retrieves the properties of the specified storage account
+	GetStorageAccount(ctx context.Context, resourceGroup, accountName string) (storage.Account, error)
+
 	//
 	// NETWORK
 
 	// DeleteNetworkInterface deletes the specified network interface.
 	DeleteNetworkInterface(ctx context.Context, resourceGroup, nicName string) error
 
+	// GetNATGateway retrieves the properties of the specified NAT gateway
+	GetNATGateway(ctx context.Context, resourceGroup, natGatewayName string) (NATGateway, error)
+
 	//
 	// GRAPH
 
@@ -112,12 +124,16 @@ type ACSEngineClient interface {
 	// User Assigned MSI
 	//CreateUserAssignedID - Creates a user assigned msi.
 	CreateUserAssignedID(location string, resourceGroup string, userAssignedID string) (*msi.Identity, error)
+	// GetUserAssignedID retrieves the specified user assigned msi.
+	GetUserAssignedID(ctx context.Context, resourceGroup string, userAssignedID string) (*msi.Identity, error)
 
 	// RBAC
 	CreateRoleAssignment(ctx context.Context, scope string, roleAssignmentName string, parameters authorization.RoleAssignmentCreateParameters) (authorization.RoleAssignment, error)
 	CreateRoleAssignmentSimple(ctx context.Context, applicationID, roleID string) error
 	DeleteRoleAssignmentByID(ctx context.Context, roleAssignmentNameID string) (authorization.RoleAssignment, error)
 	ListRoleAssignmentsForPrincipal(ctx context.Context, scope string, principalID string) (RoleAssignmentListResultPage, error)
+	// ListPermissionsForResourceGroup gets the permissions the credentials of the client have on a resource group
+	ListPermissionsForResourceGroup(ctx context.Context, resourceGroup string) ([]authorization.Permission, error)
 
 	// MANAGED DISKS
 	DeleteManagedDisk(ctx context.Context, resourceGroupName string, diskName string) error
@@ -141,12 +157,16 @@ type ACSStorageClient interface {
 	CreateContainer(containerName string, options *azStorage.CreateContainerOptions) (bool, error)
 	// SaveBlockBlob initializes a block blob by taking the byte
 	SaveBlockBlob(containerName, blobName string, b []byte, options *azStorage.PutBlobOptions) error
+	// GetBlob downloads the contents of the specified blob in the specified container.
+	GetBlob(containerName, blobName string) ([]byte, error)
 }
 
 // KubernetesClient interface models client for interacting with kubernetes api server
 type KubernetesClient interface {
 	//ListPods returns all Pods running on the passed in node
 	ListPods(node *v1.Node) (*v1.PodList, error)
+	//ListNodes returns all the nodes registered in the api server
+	ListNodes() (*v1.NodeList, error)
 	//GetNode returns details about node with passed in name
 	GetNode(name string) (*v1.Node, error)
 	//UpdateNode updates the node in the api server with the passed in info
@@ -161,4 +181,16 @@ type KubernetesClient interface {
 	EvictPod(pod *v1.Pod, policyGroupVersion string) error
 	//WaitForDelete waits until all pods are deleted. Returns all pods not deleted and an error on failure
 	WaitForDelete(logger *log.Entry, pods []v1.Pod, usingEviction bool) ([]v1.Pod, error)
+	//ApplyConfigMap creates the passed in config map, or updates it if it already exists
+	ApplyConfigMap(configMap *v1.ConfigMap) (*v1.ConfigMap, error)
+	//ApplySecret creates the passed in secret, or updates it if it already exists
+	ApplySecret(secret *v1.Secret) (*v1.Secret, error)
+	//ApplyServiceAccount creates the passed in service account, or updates it if it already exists
+	ApplyServiceAccount(serviceAccount *v1.ServiceAccount) (*v1.ServiceAccount, error)
+	//ApplyClusterRole creates the passed in cluster role, or updates it if it already exists
+	ApplyClusterRole(role *rbacv1.ClusterRole) (*rbacv1.ClusterRole, error)
+	//ApplyClusterRoleBinding creates the passed in cluster role binding, or updates it if it already exists
+	ApplyClusterRoleBinding(binding *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error)
+	//CreateJob creates the passed in job
+	CreateJob(job *batchv1.Job) (*batchv1.Job, error)
 }
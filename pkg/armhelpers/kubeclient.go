@@ -7,8 +7,10 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	policy "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -49,6 +51,11 @@ func (c *KubernetesClientSetClient) ListPods(node *v1.Node) (*v1.PodList, error)
 		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": node.Name}).String()})
 }
 
+// ListNodes returns all the nodes registered in the api server
+func (c *KubernetesClientSetClient) ListNodes() (*v1.NodeList, error) {
+	return c.clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+}
+
 //GetNode returns details about node with passed in name
 func (c *KubernetesClientSetClient) GetNode(name string) (*v1.Node, error) {
 	return c.clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
@@ -149,3 +156,53 @@ func (c *KubernetesClientSetClient) WaitForDelete(logger *log.Entry, pods []v1.P
 	})
 	return pods, err
 }
+
+// ApplyConfigMap creates the passed in config map, or updates it if it already exists
+func (c *KubernetesClientSetClient) ApplyConfigMap(configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	created, err := c.clientset.CoreV1().ConfigMaps(configMap.Namespace).Create(configMap)
+	if apierrors.IsAlreadyExists(err) {
+		return c.clientset.CoreV1().ConfigMaps(configMap.Namespace).Update(configMap)
+	}
+	return created, err
+}
+
+// ApplySecret creates the passed in secret, or updates it if it already exists
+func (c *KubernetesClientSetClient) ApplySecret(secret *v1.Secret) (*v1.Secret, error) {
+	created, err := c.clientset.CoreV1().Secrets(secret.Namespace).Create(secret)
+	if apierrors.IsAlreadyExists(err) {
+		return c.clientset.CoreV1().Secrets(secret.Namespace).Update(secret)
+	}
+	return created, err
+}
+
+// ApplyServiceAccount creates the passed in service account, or updates it if it already exists
+func (c *KubernetesClientSetClient) ApplyServiceAccount(serviceAccount *v1.ServiceAccount) (*v1.ServiceAccount, error) {
+	created, err := c.clientset.CoreV1().ServiceAccounts(serviceAccount.Namespace).Create(serviceAccount)
+	if apierrors.IsAlreadyExists(err) {
+		return c.clientset.CoreV1().ServiceAccounts(serviceAccount.Namespace).Update(serviceAccount)
+	}
+	return created, err
+}
+
+// ApplyClusterRole creates the passed in cluster role, or updates it if it already exists
+func (c *KubernetesClientSetClient) ApplyClusterRole(role *rbacv1.ClusterRole) (*rbacv1.ClusterRole, error) {
+	created, err := c.clientset.RbacV1().ClusterRoles().Create(role)
+	if apierrors.IsAlreadyExists(err) {
+		return c.clientset.RbacV1().ClusterRoles().Update(role)
+	}
+	return created, err
+}
+
+// ApplyClusterRoleBinding creates the passed in cluster role binding, or updates it if it already exists
+func (c *KubernetesClientSetClient) ApplyClusterRoleBinding(binding *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error) {
+	created, err := c.clientset.RbacV1().ClusterRoleBindings().Create(binding)
+	if apierrors.IsAlreadyExists(err) {
+		return c.clientset.RbacV1().ClusterRoleBindings().Update(binding)
+	}
+	return created, err
+}
+
+// CreateJob creates the passed in job
+func (c *KubernetesClientSetClient) CreateJob(job *batchv1.Job) (*batchv1.Job, error) {
+	return c.clientset.BatchV1().Jobs(job.Namespace).Create(job)
+}
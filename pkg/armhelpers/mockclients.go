@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Azure/acs-engine/pkg/helpers"
@@ -19,10 +20,14 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2018-02-01/storage"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 )
 
 //MockACSEngineClient is an implementation of ACSEngineClient where all requests error out
@@ -44,21 +49,44 @@ type MockACSEngineClient struct {
 	FailDeleteNetworkInterface            bool
 	FailGetKubernetesClient               bool
 	FailListProviders                     bool
+	FailListResourceSkus                  bool
 	ShouldSupportVMIdentity               bool
 	FailDeleteRoleAssignment              bool
+	FailCreateRoleAssignment              bool
+	RoleAssignments                       []authorization.RoleAssignment
 	MockKubernetesClient                  *MockKubernetesClient
+	MockStorageClient                     *MockStorageClient
+	ResourceSkus                          []ResourceSku
+	FailGetStorageAccount                 bool
+	StorageAccounts                       []storage.Account
+	FailGetNATGateway                     bool
+	NATGateways                           []NATGateway
+	FailListPermissions                   bool
+	Permissions                           []authorization.Permission
+	// DeploymentModes records the modes of the deployments of DeployTemplate
+	DeploymentModes []resources.DeploymentMode
+	// FakeListVirtualMachineResult replaces the VMs listed by ListVirtualMachines when set
+	FakeListVirtualMachineResult func() []compute.VirtualMachine
+	// DeletedVirtualMachines records the names of the VMs deleted by DeleteVirtualMachine
+	DeletedVirtualMachines []string
 }
 
 //MockStorageClient mock implementation of StorageClient
 type MockStorageClient struct {
 	FailCreateContainer bool
 	FailSaveBlockBlob   bool
+	SaveBlockBlobFunc   func(container, blob string, b []byte) error
+	FailGetBlob         bool
+	GetBlobFunc         func(container, blob string) ([]byte, error)
 }
 
 //MockKubernetesClient mock implementation of KubernetesClient
 type MockKubernetesClient struct {
 	FailListPods          bool
+	FailListNodes         bool
+	ListNodesFunc         func() (*v1.NodeList, error)
 	FailGetNode           bool
+	GetNodeFunc           func(string) (*v1.Node, error)
 	UpdateNodeFunc        func(*v1.Node) (*v1.Node, error)
 	FailUpdateNode        bool
 	FailDeleteNode        bool
@@ -68,6 +96,12 @@ type MockKubernetesClient struct {
 	FailWaitForDelete     bool
 	ShouldSupportEviction bool
 	PodsList              *v1.PodList
+	NodesList             *v1.NodeList
+	FailApply             bool
+	FailCreateJob         bool
+	// Applied records the objects passed to the Apply methods, and Jobs the created jobs
+	Applied []interface{}
+	Jobs    []*batchv1.Job
 }
 
 // MockVirtualMachineListResultPage contains a page of VirtualMachine values.
@@ -186,8 +220,25 @@ func (mkc *MockKubernetesClient) ListPods(node *v1.Node) (*v1.PodList, error) {
 	return &v1.PodList{}, nil
 }
 
+// ListNodes returns all the nodes registered in the api server
+func (mkc *MockKubernetesClient) ListNodes() (*v1.NodeList, error) {
+	if mkc.ListNodesFunc != nil {
+		return mkc.ListNodesFunc()
+	}
+	if mkc.FailListNodes {
+		return nil, errors.New("ListNodes failed")
+	}
+	if mkc.NodesList != nil {
+		return mkc.NodesList, nil
+	}
+	return &v1.NodeList{}, nil
+}
+
 //GetNode returns details about node with passed in name
 func (mkc *MockKubernetesClient) GetNode(name string) (*v1.Node, error) {
+	if mkc.GetNodeFunc != nil {
+		return mkc.GetNodeFunc(name)
+	}
 	if mkc.FailGetNode {
 		return nil, errors.New("GetNode failed")
 	}
@@ -251,6 +302,60 @@ func (mkc *MockKubernetesClient) WaitForDelete(logger *log.Entry, pods []v1.Pod,
 	return []v1.Pod{}, nil
 }
 
+// ApplyConfigMap creates the passed in config map, or updates it if it already exists
+func (mkc *MockKubernetesClient) ApplyConfigMap(configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	if mkc.FailApply {
+		return nil, errors.New("ApplyConfigMap failed")
+	}
+	mkc.Applied = append(mkc.Applied, configMap)
+	return configMap, nil
+}
+
+// ApplySecret creates the passed in secret, or updates it if it already exists
+func (mkc *MockKubernetesClient) ApplySecret(secret *v1.Secret) (*v1.Secret, error) {
+	if mkc.FailApply {
+		return nil, errors.New("ApplySecret failed")
+	}
+	mkc.Applied = append(mkc.Applied, secret)
+	return secret, nil
+}
+
+// ApplyServiceAccount creates the passed in service account, or updates it if it already exists
+func (mkc *MockKubernetesClient) ApplyServiceAccount(serviceAccount *v1.ServiceAccount) (*v1.ServiceAccount, error) {
+	if mkc.FailApply {
+		return nil, errors.New("ApplyServiceAccount failed")
+	}
+	mkc.Applied = append(mkc.Applied, serviceAccount)
+	return serviceAccount, nil
+}
+
+// ApplyClusterRole creates the passed in cluster role, or updates it if it already exists
+func (mkc *MockKubernetesClient) ApplyClusterRole(role *rbacv1.ClusterRole) (*rbacv1.ClusterRole, error) {
+	if mkc.FailApply {
+		return nil, errors.New("ApplyClusterRole failed")
+	}
+	mkc.Applied = append(mkc.Applied, role)
+	return role, nil
+}
+
+// ApplyClusterRoleBinding creates the passed in cluster role binding, or updates it if it already exists
+func (mkc *MockKubernetesClient) ApplyClusterRoleBinding(binding *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error) {
+	if mkc.FailApply {
+		return nil, errors.New("ApplyClusterRoleBinding failed")
+	}
+	mkc.Applied = append(mkc.Applied, binding)
+	return binding, nil
+}
+
+// CreateJob creates the passed in job
+func (mkc *MockKubernetesClient) CreateJob(job *batchv1.Job) (*batchv1.Job, error) {
+	if mkc.FailCreateJob {
+		return nil, errors.New("CreateJob failed")
+	}
+	mkc.Jobs = append(mkc.Jobs, job)
+	return job, nil
+}
+
 //DeleteBlob mock
 func (msc *MockStorageClient) DeleteBlob(container, blob string, options *azStorage.DeleteBlobOptions) error {
 	return nil
@@ -266,12 +371,26 @@ func (msc *MockStorageClient) CreateContainer(container string, options *azStora
 
 //SaveBlockBlob mock
 func (msc *MockStorageClient) SaveBlockBlob(container, blob string, b []byte, options *azStorage.PutBlobOptions) error {
+	if msc.SaveBlockBlobFunc != nil {
+		return msc.SaveBlockBlobFunc(container, blob, b)
+	}
 	if !msc.FailSaveBlockBlob {
 		return nil
 	}
 	return errors.New("SaveBlockBlob failed")
 }
 
+//GetBlob mock
+func (msc *MockStorageClient) GetBlob(container, blob string) ([]byte, error) {
+	if msc.GetBlobFunc != nil {
+		return msc.GetBlobFunc(container, blob)
+	}
+	if msc.FailGetBlob {
+		return nil, errors.New("GetBlob failed")
+	}
+	return []byte("entity"), nil
+}
+
 //AddAcceptLanguages mock
 func (mc *MockACSEngineClient) AddAcceptLanguages(languages []string) {}
 
@@ -279,7 +398,8 @@ func (mc *MockACSEngineClient) AddAcceptLanguages(languages []string) {}
 func (mc *MockACSEngineClient) AddAuxiliaryTokens(tokens []string) {}
 
 //DeployTemplate mock
-func (mc *MockACSEngineClient) DeployTemplate(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}) (de resources.DeploymentExtended, err error) {
+func (mc *MockACSEngineClient) DeployTemplate(ctx context.Context, resourceGroup, name string, template, parameters map[string]interface{}, mode resources.DeploymentMode) (de resources.DeploymentExtended, err error) {
+	mc.DeploymentModes = append(mc.DeploymentModes, mode)
 	switch {
 	case mc.FailDeployTemplate:
 		return de, errors.New("DeployTemplate failed")
@@ -371,6 +491,18 @@ func (mc *MockACSEngineClient) ListVirtualMachines(ctx context.Context, resource
 		}, errors.New("ListVirtualMachines failed")
 	}
 
+	if mc.FakeListVirtualMachineResult != nil {
+		vms := mc.FakeListVirtualMachineResult()
+		return &MockVirtualMachineListResultPage{
+			Fn: func(lastResults compute.VirtualMachineListResult) (compute.VirtualMachineListResult, error) {
+				return compute.VirtualMachineListResult{}, nil
+			},
+			Vmlr: compute.VirtualMachineListResult{
+				Value: &vms,
+			},
+		}, nil
+	}
+
 	vm1Name := "k8s-agentpool1-12345678-0"
 
 	creationSourceString := "creationSource"
@@ -492,6 +624,7 @@ func (mc *MockACSEngineClient) DeleteVirtualMachine(ctx context.Context, resourc
 	if mc.FailDeleteVirtualMachine {
 		return errors.New("DeleteVirtualMachine failed")
 	}
+	mc.DeletedVirtualMachines = append(mc.DeletedVirtualMachines, name)
 
 	return nil
 }
@@ -529,7 +662,38 @@ func (mc *MockACSEngineClient) GetStorageClient(ctx context.Context, resourceGro
 		return nil, errors.New("GetStorageClient failed")
 	}
 
-	return &MockStorageClient{}, nil
+	if mc.MockStorageClient == nil {
+		mc.MockStorageClient = &MockStorageClient{}
+	}
+	return mc.MockStorageClient, nil
+}
+
+// GetStorageAccount mock, returns the storage account of StorageAccounts with the given resource group and name
+func (mc *MockACSEngineClient) GetStorageAccount(ctx context.Context, resourceGroup, accountName string) (storage.Account, error) {
+	if mc.FailGetStorageAccount {
+		return storage.Account{}, errors.New("GetStorageAccount failed")
+	}
+	suffix := strings.ToLower(fmt.Sprintf("/resourceGroups/%s/providers/Microsoft.Storage/storageAccounts/%s", resourceGroup, accountName))
+	for _, account := range mc.StorageAccounts {
+		if account.ID != nil && strings.HasSuffix(strings.ToLower(*account.ID), suffix) {
+			return account, nil
+		}
+	}
+	return storage.Account{}, fmt.Errorf("storage account %s not found in resource group %s", accountName, resourceGroup)
+}
+
+// GetNATGateway mock, returns the NAT gateway of NATGateways with the given resource group and name
+func (mc *MockACSEngineClient) GetNATGateway(ctx context.Context, resourceGroup, natGatewayName string) (NATGateway, error) {
+	if mc.FailGetNATGateway {
+		return NATGateway{}, errors.New("GetNATGateway failed")
+	}
+	suffix := strings.ToLower(fmt.Sprintf("/resourceGroups/%s/providers/Microsoft.Network/natGateways/%s", resourceGroup, natGatewayName))
+	for _, natGateway := range mc.NATGateways {
+		if natGateway.ID != nil && strings.HasSuffix(strings.ToLower(*natGateway.ID), suffix) {
+			return natGateway, nil
+		}
+	}
+	return NATGateway{}, fmt.Errorf("NAT gateway %s not found in resource group %s", natGatewayName, resourceGroup)
 }
 
 //DeleteNetworkInterface mock
@@ -578,11 +742,31 @@ func (mc *MockACSEngineClient) CreateUserAssignedID(location string, resourceGro
 	return &msi.Identity{}, nil
 }
 
+// GetUserAssignedID retrieves the specified user assigned msi.
+func (mc *MockACSEngineClient) GetUserAssignedID(ctx context.Context, resourceGroup string, userAssignedID string) (*msi.Identity, error) {
+	principalID := uuid.FromStringOrNil("55555555-6666-7777-8888-999999999999")
+	return &msi.Identity{Name: &userAssignedID, IdentityProperties: &msi.IdentityProperties{PrincipalID: &principalID}}, nil
+}
+
 // RBAC Mocks
 
 // CreateRoleAssignment creates a role assignment via the authorization client
 func (mc *MockACSEngineClient) CreateRoleAssignment(ctx context.Context, scope string, roleAssignmentName string, parameters authorization.RoleAssignmentCreateParameters) (authorization.RoleAssignment, error) {
-	return authorization.RoleAssignment{}, nil
+	if mc.FailCreateRoleAssignment {
+		return authorization.RoleAssignment{}, errors.New("CreateRoleAssignment failed")
+	}
+	id := fmt.Sprintf("%s/providers/Microsoft.Authorization/roleAssignments/%s", scope, roleAssignmentName)
+	assignment := authorization.RoleAssignment{
+		ID:   &id,
+		Name: &roleAssignmentName,
+		Properties: &authorization.RoleAssignmentPropertiesWithScope{
+			Scope:            &scope,
+			RoleDefinitionID: parameters.Properties.RoleDefinitionID,
+			PrincipalID:      parameters.Properties.PrincipalID,
+		},
+	}
+	mc.RoleAssignments = append(mc.RoleAssignments, assignment)
+	return assignment, nil
 }
 
 // CreateRoleAssignmentSimple is a wrapper around RoleAssignmentsClient.Create
@@ -621,6 +805,20 @@ func (mc *MockACSEngineClient) ListProviders(ctx context.Context) (resources.Pro
 	return resources.ProviderListResultPage{}, nil
 }
 
+// ListResourceSkus mock
+func (mc *MockACSEngineClient) ListResourceSkus(ctx context.Context, location string) ([]ResourceSku, error) {
+	if mc.FailListResourceSkus {
+		return nil, errors.New("ListResourceSkus failed")
+	}
+	var skus []ResourceSku
+	for _, sku := range mc.ResourceSkus {
+		if sku.IsOfferedIn(location) {
+			skus = append(skus, sku)
+		}
+	}
+	return skus, nil
+}
+
 // ListDeploymentOperations gets all deployments operations for a deployment.
 func (mc *MockACSEngineClient) ListDeploymentOperations(ctx context.Context, resourceGroupName string, deploymentName string, top *int32) (result DeploymentOperationsListResultPage, err error) {
 	resp := `{
@@ -698,11 +896,28 @@ func (mc *MockACSEngineClient) DeleteRoleAssignmentByID(ctx context.Context, rol
 	return authorization.RoleAssignment{}, nil
 }
 
+// ListPermissionsForResourceGroup mock, returns Permissions, or all the permissions when Permissions is nil
+func (mc *MockACSEngineClient) ListPermissionsForResourceGroup(ctx context.Context, resourceGroup string) ([]authorization.Permission, error) {
+	if mc.FailListPermissions {
+		return nil, errors.New("ListPermissionsForResourceGroup failed")
+	}
+	if mc.Permissions == nil {
+		return []authorization.Permission{{Actions: &[]string{"*"}, NotActions: &[]string{}}}, nil
+	}
+	return mc.Permissions, nil
+}
+
 // ListRoleAssignmentsForPrincipal (e.g. a VM) via the scope and the unique identifier of the principal
 func (mc *MockACSEngineClient) ListRoleAssignmentsForPrincipal(ctx context.Context, scope string, principalID string) (RoleAssignmentListResultPage, error) {
 	roleAssignments := []authorization.RoleAssignment{}
 
-	if mc.ShouldSupportVMIdentity {
+	if mc.RoleAssignments != nil {
+		for _, assignment := range mc.RoleAssignments {
+			if assignment.Properties != nil && assignment.Properties.PrincipalID != nil && *assignment.Properties.PrincipalID == principalID {
+				roleAssignments = append(roleAssignments, assignment)
+			}
+		}
+	} else if mc.ShouldSupportVMIdentity {
 		var assignmentID = "role-assignment-id"
 		var assignment = authorization.RoleAssignment{
 			ID: &assignmentID}
@@ -710,6 +925,9 @@ func (mc *MockACSEngineClient) ListRoleAssignmentsForPrincipal(ctx context.Conte
 	}
 
 	return &MockRoleAssignmentListResultPage{
+		Fn: func(authorization.RoleAssignmentListResult) (authorization.RoleAssignmentListResult, error) {
+			return authorization.RoleAssignmentListResult{}, nil
+		},
 		Ralr: authorization.RoleAssignmentListResult{
 			Value: &roleAssignments,
 		},
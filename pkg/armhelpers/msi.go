@@ -23,3 +23,12 @@ func (az *AzureClient) CreateUserAssignedID(location string, resourceGroup strin
 	log.Infof("Created %s in rg %s", userAssignedID, resourceGroup)
 	return &idCreated, nil
 }
+
+// GetUserAssignedID retrieves the specified user assigned msi.
+func (az *AzureClient) GetUserAssignedID(ctx context.Context, resourceGroup string, userAssignedID string) (*msi.Identity, error) {
+	id, err := az.msiClient.Get(ctx, resourceGroup, userAssignedID)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -5,8 +5,24 @@ package armhelpers
 
 import (
 	"context"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
 )
 
+// natGatewaysAPIVersion is the first network API version with NAT gateways, which the vendored network
+// client predates
+const natGatewaysAPIVersion = "2019-09-01"
+
+// NATGateway holds the properties of a NAT gateway needed to check it can be used by a cluster
+type NATGateway struct {
+	autorest.Response `json:"-"`
+	ID                *string `json:"id,omitempty"`
+	Name              *string `json:"name,omitempty"`
+	Location          *string `json:"location,omitempty"`
+}
+
 // DeleteNetworkInterface deletes the specified network interface.
 func (az *AzureClient) DeleteNetworkInterface(ctx context.Context, resourceGroup, nicName string) error {
 	future, err := az.interfacesClient.Delete(ctx, resourceGroup, nicName)
@@ -21,3 +37,42 @@ func (az *AzureClient) DeleteNetworkInterface(ctx context.Context, resourceGroup
 	_, err = future.Result(az.interfacesClient)
 	return err
 }
+
+// GetNATGateway returns the properties of the specified NAT gateway, e.g. its location
+func (az *AzureClient) GetNATGateway(ctx context.Context, resourceGroup, natGatewayName string) (result NATGateway, err error) {
+	client := az.interfacesClient
+	pathParameters := map[string]interface{}{
+		"natGatewayName":    autorest.Encode("path", natGatewayName),
+		"resourceGroupName": autorest.Encode("path", resourceGroup),
+		"subscriptionId":    autorest.Encode("path", client.SubscriptionID),
+	}
+	queryParameters := map[string]interface{}{
+		"api-version": natGatewaysAPIVersion,
+	}
+	req, err := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithBaseURL(client.BaseURI),
+		autorest.WithPathParameters("/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Network/natGateways/{natGatewayName}", pathParameters),
+		autorest.WithQueryParameters(queryParameters)).Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "armhelpers.AzureClient", "GetNATGateway", nil, "Failure preparing request")
+	}
+
+	resp, err := autorest.SendWithSender(client, req, azure.DoRetryWithRegistration(client.Client))
+	if err != nil {
+		result.Response = autorest.Response{Response: resp}
+		return result, autorest.NewErrorWithError(err, "armhelpers.AzureClient", "GetNATGateway", resp, "Failure sending request")
+	}
+
+	err = autorest.Respond(
+		resp,
+		client.ByInspecting(),
+		azure.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing())
+	result.Response = autorest.Response{Response: resp}
+	if err != nil {
+		return result, autorest.NewErrorWithError(err, "armhelpers.AzureClient", "GetNATGateway", resp, "Failure responding to request")
+	}
+	return result, nil
+}
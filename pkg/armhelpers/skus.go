@@ -0,0 +1,121 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package armhelpers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// resourceSkusAPIVersion is the version of the Microsoft.Compute/skus API, which the vendored compute SDK doesn't cover
+	resourceSkusAPIVersion = "2017-09-01"
+
+	// ResourceSkuRestrictionTypeLocation is the type of the restrictions which apply to a whole location
+	ResourceSkuRestrictionTypeLocation = "Location"
+	// ResourceSkuRestrictionTypeZone is the type of the restrictions which only apply to some zones of a location
+	ResourceSkuRestrictionTypeZone = "Zone"
+)
+
+// ResourceSku describes a SKU of a compute resource, e.g. a VM size, and where it is offered
+type ResourceSku struct {
+	ResourceType string                   `json:"resourceType,omitempty"`
+	Name         string                   `json:"name,omitempty"`
+	Locations    []string                 `json:"locations,omitempty"`
+	Restrictions []ResourceSkuRestriction `json:"restrictions,omitempty"`
+}
+
+// ResourceSkuRestriction describes why a SKU cannot be used by the subscription, and where
+type ResourceSkuRestriction struct {
+	Type string `json:"type,omitempty"`
+	// Values are the locations or the zones the restriction applies to, depending on its type
+	Values     []string `json:"values,omitempty"`
+	ReasonCode string   `json:"reasonCode,omitempty"`
+}
+
+// IsOfferedIn checks if the SKU is offered in the given location
+func (s ResourceSku) IsOfferedIn(location string) bool {
+	for _, l := range s.Locations {
+		if strings.EqualFold(l, location) {
+			return true
+		}
+	}
+	return false
+}
+
+// LocationRestriction returns the restriction which prevents the subscription from using the SKU in
+// the given location, if any
+func (s ResourceSku) LocationRestriction(location string) *ResourceSkuRestriction {
+	for i, r := range s.Restrictions {
+		if r.Type != ResourceSkuRestrictionTypeLocation {
+			continue
+		}
+		for _, l := range r.Values {
+			if strings.EqualFold(l, location) {
+				return &s.Restrictions[i]
+			}
+		}
+	}
+	return nil
+}
+
+type resourceSkusResult struct {
+	Value    []ResourceSku `json:"value,omitempty"`
+	NextLink string        `json:"nextLink,omitempty"`
+}
+
+// ListResourceSkus returns the compute SKUs offered in the given location, with the restrictions
+// which apply to the subscription
+func (az *AzureClient) ListResourceSkus(ctx context.Context, location string) ([]ResourceSku, error) {
+	client := az.virtualMachinesClient
+	req, err := autorest.CreatePreparer(
+		autorest.AsGet(),
+		autorest.WithBaseURL(client.BaseURI),
+		autorest.WithPathParameters("/subscriptions/{subscriptionId}/providers/Microsoft.Compute/skus", map[string]interface{}{
+			"subscriptionId": autorest.Encode("path", client.SubscriptionID),
+		}),
+		autorest.WithQueryParameters(map[string]interface{}{
+			"api-version": resourceSkusAPIVersion,
+		})).Prepare((&http.Request{}).WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare the request listing the resource SKUs")
+	}
+
+	var skus []ResourceSku
+	for {
+		resp, err := autorest.SendWithSender(client, req, azure.DoRetryWithRegistration(client.Client))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the resource SKUs")
+		}
+		var page resourceSkusResult
+		err = autorest.Respond(
+			resp,
+			client.ByInspecting(),
+			azure.WithErrorUnlessStatusCode(http.StatusOK),
+			autorest.ByUnmarshallingJSON(&page),
+			autorest.ByClosing())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the resource SKUs")
+		}
+		for _, sku := range page.Value {
+			if sku.IsOfferedIn(location) {
+				skus = append(skus, sku)
+			}
+		}
+		if page.NextLink == "" {
+			return skus, nil
+		}
+		req, err = autorest.CreatePreparer(
+			autorest.AsGet(),
+			autorest.WithBaseURL(page.NextLink)).Prepare((&http.Request{}).WithContext(ctx))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to prepare the request listing the next resource SKUs")
+		}
+	}
+}
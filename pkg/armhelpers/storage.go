@@ -6,6 +6,7 @@ package armhelpers
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
 
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2018-02-01/storage"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
@@ -34,6 +35,11 @@ func (az *AzureClient) GetStorageClient(ctx context.Context, resourceGroup, acco
 	}, nil
 }
 
+// GetStorageAccount returns the properties of the specified storage account, e.g. its location and kind
+func (az *AzureClient) GetStorageAccount(ctx context.Context, resourceGroup, accountName string) (storage.Account, error) {
+	return az.storageAccountsClient.GetProperties(ctx, resourceGroup, accountName)
+}
+
 func (az *AzureClient) getStorageKeys(ctx context.Context, resourceGroup, accountName string) ([]storage.AccountKey, error) {
 	storageKeysResult, err := az.storageAccountsClient.ListKeys(ctx, resourceGroup, accountName)
 	if err != nil {
@@ -69,6 +75,19 @@ func (as *AzureStorageClient) SaveBlockBlob(containerName, blobName string, b []
 	return blobRef.CreateBlockBlobFromReader(bytes.NewReader(b), options)
 }
 
+// GetBlob downloads the contents of the specified blob
+func (as *AzureStorageClient) GetBlob(containerName, blobName string) ([]byte, error) {
+	containerRef := getContainerRef(as.client, containerName)
+	blobRef := containerRef.GetBlobReference(blobName)
+
+	r, err := blobRef.Get(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
 func getContainerRef(client *azStorage.Client, containerName string) *azStorage.Container {
 	bs := client.GetBlobService()
 	return bs.GetContainerReference(containerName)
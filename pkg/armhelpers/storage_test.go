@@ -52,3 +52,22 @@ var _ = Describe("SaveBlockBlob Test", func() {
 		Expect(err).NotTo(BeNil())
 	})
 })
+
+var _ = Describe("GetBlob Test", func() {
+	It("Should pass if blob downloaded", func() {
+		client := MockStorageClient{
+			FailGetBlob: false,
+		}
+		b, err := client.GetBlob("fakeContainerName", "fakeBlobName")
+		Expect(err).To(BeNil())
+		Expect(b).To(Equal([]byte("entity")))
+	})
+
+	It("Should return error when blob download failed", func() {
+		client := MockStorageClient{
+			FailGetBlob: true,
+		}
+		_, err := client.GetBlob("fakeContainerName", "fakeBlobName")
+		Expect(err).NotTo(BeNil())
+	})
+})
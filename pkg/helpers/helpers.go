@@ -188,6 +188,17 @@ func CreateSaveSSH(username, outputDirectory string, s *i18n.Translator) (privat
 	return privateKey, publicKeyString, nil
 }
 
+// GetMaxPlatformFaultDomainCount returns the number of fault domains the availability sets
+// with managed disks of a region can be spread across
+func GetMaxPlatformFaultDomainCount(location string) int {
+	switch NormalizeAzureRegion(location) {
+	case "canadacentral", "centralus", "eastus", "eastus2", "northcentralus", "northeurope", "southcentralus", "westeurope", "westus":
+		return 3
+	default:
+		return 2
+	}
+}
+
 // GetCloudTargetEnv determines and returns whether the region is a sovereign cloud which
 // have their own data compliance regulations (China/Germany/USGov) or standard
 //  Azure public cloud
@@ -408,3 +408,23 @@ func TestGetCloudTargetEnv(t *testing.T) {
 	}
 
 }
+
+func TestGetMaxPlatformFaultDomainCount(t *testing.T) {
+	testcases := []struct {
+		location string
+		expected int
+	}{
+		{"eastus", 3},
+		{"West Europe", 3},
+		{"westus2", 2},
+		{"chinaeast", 2},
+		{"", 2},
+	}
+
+	for _, testcase := range testcases {
+		actual := GetMaxPlatformFaultDomainCount(testcase.location)
+		if testcase.expected != actual {
+			t.Errorf("expected GetMaxPlatformFaultDomainCount(%q) to return %d, but got %d", testcase.location, testcase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package helpers
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// LogFormatText writes human readable log lines
+	LogFormatText = "text"
+	// LogFormatJSON writes one JSON object per log entry, for ingestion by log pipelines
+	LogFormatJSON = "json"
+)
+
+// NewLogFormatter returns the formatter writing log entries in the given logging format
+func NewLogFormatter(format string) (log.Formatter, error) {
+	switch format {
+	case LogFormatText:
+		return &log.TextFormatter{}, nil
+	case LogFormatJSON:
+		return &log.JSONFormatter{}, nil
+	default:
+		return nil, errors.Errorf("unsupported logging format '%s', supported formats are '%s' and '%s'", format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// NewLogger returns a log entry writing to out in the given logging format, suitable for
+// passing to the cluster operations
+func NewLogger(format string, out io.Writer) (*log.Entry, error) {
+	formatter, err := NewLogFormatter(format)
+	if err != nil {
+		return nil, err
+	}
+	logger := log.New()
+	logger.Formatter = formatter
+	logger.Out = out
+	logger.Level = log.GetLevel()
+	return log.NewEntry(logger), nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestNewLogFormatter(t *testing.T) {
+	formatter, err := NewLogFormatter(LogFormatText)
+	if err != nil {
+		t.Fatalf("unexpected error for the %s logging format: %v", LogFormatText, err)
+	}
+	if _, ok := formatter.(*log.TextFormatter); !ok {
+		t.Errorf("expected a text formatter for the %s logging format, got %T", LogFormatText, formatter)
+	}
+
+	formatter, err = NewLogFormatter(LogFormatJSON)
+	if err != nil {
+		t.Fatalf("unexpected error for the %s logging format: %v", LogFormatJSON, err)
+	}
+	if _, ok := formatter.(*log.JSONFormatter); !ok {
+		t.Errorf("expected a JSON formatter for the %s logging format, got %T", LogFormatJSON, formatter)
+	}
+
+	if _, err = NewLogFormatter("xml"); err == nil {
+		t.Errorf("expected an error for an unsupported logging format")
+	}
+}
+
+func TestNewLoggerJSON(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := NewLogger(LogFormatJSON, &out)
+	if err != nil {
+		t.Fatalf("unexpected error creating a JSON logger: %v", err)
+	}
+	logger.WithFields(log.Fields{"operation": "scale", "node": "k8s-agent-1"}).Warn("deleting VM")
+
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON log entry, got %s: %v", out.String(), err)
+	}
+	for key, expected := range map[string]string{
+		"operation": "scale",
+		"node":      "k8s-agent-1",
+		"msg":       "deleting VM",
+		"level":     "warning",
+	} {
+		if entry[key] != expected {
+			t.Errorf("expected the log entry to have %s %s, got %v", key, expected, entry[key])
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Errorf("expected the log entry to have a time")
+	}
+
+	out.Reset()
+	logger, err = NewLogger(LogFormatText, &out)
+	if err != nil {
+		t.Fatalf("unexpected error creating a text logger: %v", err)
+	}
+	logger.WithField("node", "k8s-agent-1").Info("deleting VM")
+	if !strings.Contains(out.String(), "node=k8s-agent-1") || json.Valid(out.Bytes()) {
+		t.Errorf("expected a text log entry, got %s", out.String())
+	}
+}
@@ -44,6 +44,57 @@ func CreatePkiKeyCertPair(commonName string) (*PkiKeyCertPair, error) {
 	return caPair, nil
 }
 
+// CreatePkiClientKeyCertPair generates a pair of client certificate and private key signed by the CA pair,
+// e.g. by a certificate authority other than the one of the cluster
+func CreatePkiClientKeyCertPair(commonName string, caPair *PkiKeyCertPair) (*PkiKeyCertPair, error) {
+	caCertificate, err := pemToCertificate(caPair.CertificatePem)
+	if err != nil {
+		return nil, err
+	}
+	caPrivateKey, err := pemToKey(caPair.PrivateKeyPem)
+	if err != nil {
+		return nil, err
+	}
+	certificate, privateKey, err := createCertificate(commonName, caCertificate, caPrivateKey, false, false, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PkiKeyCertPair{CertificatePem: string(certificateToPem(certificate.Raw)), PrivateKeyPem: string(privateKeyToPem(privateKey))}, nil
+}
+
+// CreatePkiPrivateKey generates a private key which isn't tied to any certificate, e.g. to sign tokens
+func CreatePkiPrivateKey() (string, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, PkiKeySize)
+	if err != nil {
+		return "", err
+	}
+	return string(privateKeyToPem(privateKey)), nil
+}
+
+// ValidateCertificateAuthority checks that a certificate and a private key, e.g. of an intermediate CA of an
+// enterprise PKI, form a certificate authority which can sign the certificates of the cluster
+func ValidateCertificateAuthority(certificatePem string, privateKeyPem string) error {
+	certificate, err := pemToCertificate(certificatePem)
+	if err != nil {
+		return fmt.Errorf("the CA certificate is invalid: %s", err)
+	}
+	privateKey, err := pemToKey(privateKeyPem)
+	if err != nil {
+		return fmt.Errorf("the CA private key is invalid: %s", err)
+	}
+	publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+	if !ok || publicKey.N.Cmp(privateKey.N) != 0 || publicKey.E != privateKey.E {
+		return errors.New("the CA private key doesn't match the CA certificate")
+	}
+	if !certificate.BasicConstraintsValid || !certificate.IsCA {
+		return errors.New("the CA certificate isn't a certificate authority, its basic constraints don't allow it to issue certificates")
+	}
+	if certificate.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return errors.New("the key usage of the CA certificate doesn't allow it to sign certificates")
+	}
+	return nil
+}
+
 // CreatePki creates PKI certificates
 func CreatePki(extraFQDNs []string, extraIPs []net.IP, clusterDomain string, caPair *PkiKeyCertPair, masterCount int) (*PkiKeyCertPair, *PkiKeyCertPair, *PkiKeyCertPair, *PkiKeyCertPair, *PkiKeyCertPair, []*PkiKeyCertPair, error) {
 	start := time.Now()
@@ -186,6 +237,11 @@ func createCertificate(commonName string, caCertificate *x509.Certificate, caPri
 		template.ExtKeyUsage = append(template.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
 	}
 
+	// a certificate isn't valid any longer than its issuer, e.g. a provided intermediate CA
+	if !isCA && template.NotAfter.After(caCertificate.NotAfter) {
+		template.NotAfter = caCertificate.NotAfter
+	}
+
 	snMax := new(big.Int).Lsh(big.NewInt(1), 128)
 	template.SerialNumber, err = rand.Int(rand.Reader, snMax)
 	if err != nil {
@@ -252,5 +308,17 @@ func pemToKey(raw string) (*rsa.PrivateKey, error) {
 	if kpb == nil {
 		return nil, errors.New("The raw pem is not a valid PEM formatted block")
 	}
-	return x509.ParsePKCS1PrivateKey(kpb.Bytes)
+	if kpb.Type != "PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(kpb.Bytes)
+	}
+	// PKCS #8 keys, as exported by most enterprise PKIs
+	key, err := x509.ParsePKCS8PrivateKey(kpb.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("The private key is not an RSA key")
+	}
+	return rsaKey, nil
 }
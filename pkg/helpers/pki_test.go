@@ -4,11 +4,13 @@
 package helpers
 
 import (
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"net"
 	"testing"
+	"time"
 )
 
 func TestCreateCertificateWithOrganisation(t *testing.T) {
@@ -232,3 +234,131 @@ func TestCreatePkiKeyCertPair(t *testing.T) {
 		t.Errorf("unexpected error thrown while executing CreatePkiKeyCertPair : %s", err.Error())
 	}
 }
+
+func TestCreatePkiClientKeyCertPair(t *testing.T) {
+	caPair, err := CreatePkiKeyCertPair("proxyClientCA")
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreatePkiKeyCertPair : %s", err.Error())
+	}
+	clientPair, err := CreatePkiClientKeyCertPair("aggregator", caPair)
+	if err != nil {
+		t.Fatalf("unexpected error thrown while executing CreatePkiClientKeyCertPair : %s", err.Error())
+	}
+	caCertificate, err := pemToCertificate(caPair.CertificatePem)
+	if err != nil {
+		t.Fatalf("failed to parse the CA certificate: %s", err)
+	}
+	clientCertificate, err := pemToCertificate(clientPair.CertificatePem)
+	if err != nil {
+		t.Fatalf("failed to parse the client certificate: %s", err)
+	}
+	if clientCertificate.Subject.CommonName != "aggregator" || clientCertificate.IsCA {
+		t.Errorf("expected a client certificate issued to aggregator, got %s", clientCertificate.Subject.CommonName)
+	}
+	if err = clientCertificate.CheckSignatureFrom(caCertificate); err != nil {
+		t.Errorf("expected the client certificate to be signed by the CA: %s", err)
+	}
+	if _, err = pemToKey(clientPair.PrivateKeyPem); err != nil {
+		t.Errorf("failed to parse the client private key: %s", err)
+	}
+
+	if _, err = CreatePkiClientKeyCertPair("aggregator", &PkiKeyCertPair{CertificatePem: "caCertificate", PrivateKeyPem: caPair.PrivateKeyPem}); err == nil {
+		t.Error("expected an error issuing a certificate with an invalid CA")
+	}
+}
+
+func TestValidateCertificateAuthority(t *testing.T) {
+	caCertificate, caPrivateKey, err := createCertificate("ca", nil, nil, false, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %s", err)
+	}
+	otherCaCertificate, otherCaPrivateKey, err := createCertificate("otherca", nil, nil, false, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %s", err)
+	}
+	clientCertificate, clientPrivateKey, err := createCertificate("client", caCertificate, caPrivateKey, false, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %s", err)
+	}
+	// a CA whose key usage doesn't include signing certificates
+	template := *otherCaCertificate
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	noCertSignDerBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &otherCaPrivateKey.PublicKey, otherCaPrivateKey)
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %s", err)
+	}
+	pkcs8DerBytes, err := x509.MarshalPKCS8PrivateKey(caPrivateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal the private key: %s", err)
+	}
+	pkcs8PrivateKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DerBytes}))
+
+	tests := []struct {
+		name        string
+		certificate string
+		privateKey  string
+		expectedErr string
+	}{
+		{
+			name:        "generated CA",
+			certificate: string(certificateToPem(caCertificate.Raw)),
+			privateKey:  string(privateKeyToPem(caPrivateKey)),
+		},
+		{
+			name:        "PKCS #8 private key",
+			certificate: string(certificateToPem(caCertificate.Raw)),
+			privateKey:  pkcs8PrivateKey,
+		},
+		{
+			name:        "invalid certificate",
+			certificate: "certificate",
+			privateKey:  string(privateKeyToPem(caPrivateKey)),
+			expectedErr: "the CA certificate is invalid: The raw pem is not a valid PEM formatted block",
+		},
+		{
+			name:        "private key of another CA",
+			certificate: string(certificateToPem(caCertificate.Raw)),
+			privateKey:  string(privateKeyToPem(otherCaPrivateKey)),
+			expectedErr: "the CA private key doesn't match the CA certificate",
+		},
+		{
+			name:        "leaf certificate",
+			certificate: string(certificateToPem(clientCertificate.Raw)),
+			privateKey:  string(privateKeyToPem(clientPrivateKey)),
+			expectedErr: "the CA certificate isn't a certificate authority, its basic constraints don't allow it to issue certificates",
+		},
+		{
+			name:        "key usage without certificate signing",
+			certificate: string(certificateToPem(noCertSignDerBytes)),
+			privateKey:  string(privateKeyToPem(otherCaPrivateKey)),
+			expectedErr: "the key usage of the CA certificate doesn't allow it to sign certificates",
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateCertificateAuthority(test.certificate, test.privateKey)
+		if test.expectedErr == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if test.expectedErr != "" && (err == nil || err.Error() != test.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", test.name, test.expectedErr, err)
+		}
+	}
+}
+
+func TestCreateCertificateWithinIssuerValidity(t *testing.T) {
+	caCertificate, caPrivateKey, err := createCertificate("ca", nil, nil, false, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %s", err)
+	}
+	// an intermediate CA expiring before the generated certificates would
+	caCertificate.NotAfter = time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+
+	certificate, _, err := createCertificate("client", caCertificate, caPrivateKey, false, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %s", err)
+	}
+	if !certificate.NotAfter.Equal(caCertificate.NotAfter) {
+		t.Fatalf("expected the certificate to expire with its issuer at %s, got %s", caCertificate.NotAfter, certificate.NotAfter)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	azStorage "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// etcdSnapshotCommand saves an etcd v3 snapshot to the given path on a master node, writes it to stdout and removes it
+const etcdSnapshotCommand = "sudo ETCDCTL_API=3 etcdctl --endpoints=https://127.0.0.1:2379 " +
+	"--cacert=/etc/kubernetes/certs/ca.crt --cert=/etc/kubernetes/certs/etcdclient.crt --key=/etc/kubernetes/certs/etcdclient.key " +
+	"snapshot save %[1]s > /dev/null && sudo cat %[1]s; status=$?; sudo rm -f %[1]s; exit $status"
+
+// RemoteCommandRunner executes a command on a remote host and returns its output
+type RemoteCommandRunner func(cmd string) (string, error)
+
+// RemoteFileCopier writes contents to the file at path on a remote host
+type RemoteFileCopier func(contents []byte, path string) error
+
+// SSHCommandRunner returns a RemoteCommandRunner executing commands over SSH
+func SSHCommandRunner(user string, addr string, port int, sshKey []byte) RemoteCommandRunner {
+	return func(cmd string) (string, error) {
+		return RemoteRun(user, addr, port, sshKey, cmd)
+	}
+}
+
+// SSHJumpboxCommandRunner returns a RemoteCommandRunner executing commands over SSH on the host
+// at addr, reached through the jumpbox host
+func SSHJumpboxCommandRunner(user string, jumpboxAddr string, jumpboxPort int, sshKey []byte, addr string) RemoteCommandRunner {
+	return func(cmd string) (string, error) {
+		return RemoteRunThroughJumpbox(user, jumpboxAddr, jumpboxPort, sshKey, addr, cmd)
+	}
+}
+
+// SSHFileCopier returns a RemoteFileCopier writing files over SSH
+func SSHFileCopier(user string, addr string, port int, sshKey []byte) RemoteFileCopier {
+	return func(contents []byte, path string) error {
+		return RemoteCopy(user, addr, port, sshKey, contents, path)
+	}
+}
+
+// BackupEtcd takes an etcd snapshot on the master node reached by run and uploads it to
+// the specified storage container, returning the URI of the snapshot blob
+func BackupEtcd(az armhelpers.ACSEngineClient, logger *log.Entry, run RemoteCommandRunner, resourceGroup, storageAccountName, storageEndpointSuffix, containerName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	logger = logger.WithFields(log.Fields{LogFieldOperation: "backup-etcd", LogFieldResourceGroup: resourceGroup})
+
+	blobName := fmt.Sprintf("etcd-snapshot-%s.db", time.Now().UTC().Format("20060102T150405Z"))
+	logger.Infof("taking etcd snapshot %s", blobName)
+	snapshot, err := run(fmt.Sprintf(etcdSnapshotCommand, "/tmp/"+blobName))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to take etcd snapshot")
+	}
+	if len(snapshot) == 0 {
+		return "", errors.New("etcd snapshot is empty")
+	}
+
+	logger.Infof("uploading etcd snapshot to storage account %s, container %s", storageAccountName, containerName)
+	client, err := az.GetStorageClient(ctx, resourceGroup, storageAccountName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get storage client for account %s", storageAccountName)
+	}
+	if _, err = client.CreateContainer(containerName, &azStorage.CreateContainerOptions{Access: azStorage.ContainerAccessTypePrivate}); err != nil {
+		return "", errors.Wrapf(err, "failed to create storage container %s", containerName)
+	}
+	if err = client.SaveBlockBlob(containerName, blobName, []byte(snapshot), nil); err != nil {
+		return "", errors.Wrapf(err, "failed to upload etcd snapshot %s", blobName)
+	}
+
+	uri := fmt.Sprintf("https://%s.blob.%s/%s/%s", storageAccountName, storageEndpointSuffix, containerName, blobName)
+	logger.Infof("uploaded etcd snapshot to %s", uri)
+	return uri, nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ = Describe("Backup etcd operation tests", func() {
+	snapshotRunner := func(cmd string) (string, error) {
+		if !strings.Contains(cmd, "etcdctl") || !strings.Contains(cmd, "snapshot save") {
+			return "", errors.Errorf("unexpected command %s", cmd)
+		}
+		return "snapshot", nil
+	}
+
+	It("Should upload the etcd snapshot and return its blob URI", func() {
+		var uploadedContainer, uploadedBlob string
+		var uploaded []byte
+		mockClient := &armhelpers.MockACSEngineClient{MockStorageClient: &armhelpers.MockStorageClient{}}
+		mockClient.MockStorageClient.SaveBlockBlobFunc = func(container, blob string, b []byte) error {
+			uploadedContainer, uploadedBlob, uploaded = container, blob, b
+			return nil
+		}
+		uri, err := BackupEtcd(mockClient, log.NewEntry(log.New()), snapshotRunner, "rg", "account", "core.windows.net", "etcd-backups")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploadedContainer).To(Equal("etcd-backups"))
+		Expect(uploadedBlob).To(MatchRegexp(`^etcd-snapshot-\d{8}T\d{6}Z\.db$`))
+		Expect(string(uploaded)).To(Equal("snapshot"))
+
+		u, err := url.Parse(uri)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Scheme).To(Equal("https"))
+		Expect(u.Host).To(Equal("account.blob.core.windows.net"))
+		Expect(u.Path).To(Equal("/etcd-backups/" + uploadedBlob))
+	})
+	It("Should log the operation and resource group as structured fields", func() {
+		var out bytes.Buffer
+		logger, err := helpers.NewLogger(helpers.LogFormatJSON, &out)
+		Expect(err).NotTo(HaveOccurred())
+		mockClient := &armhelpers.MockACSEngineClient{MockStorageClient: &armhelpers.MockStorageClient{}}
+		_, err = BackupEtcd(mockClient, logger, snapshotRunner, "rg", "account", "core.windows.net", "etcd-backups")
+		Expect(err).NotTo(HaveOccurred())
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Expect(lines).NotTo(BeEmpty())
+		for _, line := range lines {
+			entry := map[string]interface{}{}
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			Expect(entry).To(HaveKeyWithValue(LogFieldOperation, "backup-etcd"))
+			Expect(entry).To(HaveKeyWithValue(LogFieldResourceGroup, "rg"))
+			Expect(entry).To(HaveKey("msg"))
+			Expect(entry["msg"]).NotTo(ContainSubstring("rg/"))
+		}
+	})
+	It("Should return error messages for failure to take the snapshot", func() {
+		mockClient := &armhelpers.MockACSEngineClient{}
+		failingRunner := func(cmd string) (string, error) {
+			return "", errors.New("etcdctl failed")
+		}
+		_, err := BackupEtcd(mockClient, log.NewEntry(log.New()), failingRunner, "rg", "account", "core.windows.net", "etcd-backups")
+		Expect(err).Should(HaveOccurred())
+	})
+	It("Should return error messages for failure to get the storage client", func() {
+		mockClient := &armhelpers.MockACSEngineClient{FailGetStorageClient: true}
+		_, err := BackupEtcd(mockClient, log.NewEntry(log.New()), snapshotRunner, "rg", "account", "core.windows.net", "etcd-backups")
+		Expect(err).Should(HaveOccurred())
+	})
+	It("Should return error messages for failure to upload the snapshot", func() {
+		mockClient := &armhelpers.MockACSEngineClient{MockStorageClient: &armhelpers.MockStorageClient{FailSaveBlockBlob: true}}
+		_, err := BackupEtcd(mockClient, log.NewEntry(log.New()), snapshotRunner, "rg", "account", "core.windows.net", "etcd-backups")
+		Expect(err).Should(HaveOccurred())
+	})
+})
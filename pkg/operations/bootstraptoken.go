@@ -0,0 +1,44 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// secretTypeBootstrapToken is the type of the secrets the apiserver authenticates bootstrap tokens with
+const secretTypeBootstrapToken v1.SecretType = "bootstrap.kubernetes.io/token"
+
+// ApplyBootstrapToken creates the secret of the bootstrap token of a cluster in its kube-system namespace.
+// The masters only create the secret of the token they were deployed with, so the secret of a token
+// generated after the previous one expired, e.g. on scale, must be created before nodes join with it
+func ApplyBootstrapToken(client armhelpers.KubernetesClient, logger *log.Entry, k *api.KubernetesConfig) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bootstrap-token-" + k.GetBootstrapTokenID(),
+			Namespace: metav1.NamespaceSystem,
+			Labels:    map[string]string{"addonmanager.kubernetes.io/mode": "EnsureExists"},
+		},
+		Type: secretTypeBootstrapToken,
+		StringData: map[string]string{
+			"description":                    "Short-lived token used by acs-engine provisioned nodes to join the cluster",
+			"token-id":                       k.GetBootstrapTokenID(),
+			"token-secret":                   k.GetBootstrapTokenSecret(),
+			"expiration":                     k.BootstrapTokenExpiration,
+			"usage-bootstrap-authentication": "true",
+			"usage-bootstrap-signing":        "true",
+			"auth-extra-groups":              "system:bootstrappers:acs-engine",
+		},
+	}
+	if _, err := client.ApplySecret(secret); err != nil {
+		return errors.Wrapf(err, "error creating the secret of bootstrap token %s", k.GetBootstrapTokenID())
+	}
+	logger.Infof("Created the secret of bootstrap token %s, which expires at %s.", k.GetBootstrapTokenID(), k.BootstrapTokenExpiration)
+	return nil
+}
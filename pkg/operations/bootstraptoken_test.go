@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+var _ = Describe("Bootstrap token operation tests", func() {
+	logger := log.NewEntry(log.New())
+	k := &api.KubernetesConfig{
+		BootstrapTokenTTL:        "2h",
+		BootstrapToken:           "abcdef.0123456789abcdef",
+		BootstrapTokenExpiration: "2026-10-17T12:00:00Z",
+	}
+
+	It("Should create the secret of the bootstrap token in the kube-system namespace", func() {
+		client := &armhelpers.MockKubernetesClient{}
+		Expect(ApplyBootstrapToken(client, logger, k)).To(Succeed())
+		Expect(client.Applied).To(HaveLen(1))
+
+		secret, ok := client.Applied[0].(*v1.Secret)
+		Expect(ok).To(BeTrue())
+		Expect(secret.Name).To(Equal("bootstrap-token-abcdef"))
+		Expect(secret.Namespace).To(Equal("kube-system"))
+		Expect(string(secret.Type)).To(Equal("bootstrap.kubernetes.io/token"))
+		Expect(secret.StringData).To(HaveKeyWithValue("token-id", "abcdef"))
+		Expect(secret.StringData).To(HaveKeyWithValue("token-secret", "0123456789abcdef"))
+		Expect(secret.StringData).To(HaveKeyWithValue("expiration", "2026-10-17T12:00:00Z"))
+		Expect(secret.StringData).To(HaveKeyWithValue("auth-extra-groups", "system:bootstrappers:acs-engine"))
+	})
+
+	It("Should fail when the secret cannot be created", func() {
+		client := &armhelpers.MockKubernetesClient{FailApply: true}
+		err := ApplyBootstrapToken(client, logger, k)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("error creating the secret of bootstrap token abcdef: ApplySecret failed"))
+	})
+})
@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// kubectlCommand runs kubectl on a master node with the credentials of the kubelet
+const kubectlCommand = "sudo kubectl --kubeconfig=/var/lib/kubelet/kubeconfig "
+
+const (
+	// listNodesCommand prints the name, internal IP address and OS of each node, one node per line
+	listNodesCommand = kubectlCommand + `get nodes -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.status.addresses[?(@.type=="InternalIP")].address}{" "}{.metadata.labels.beta\.kubernetes\.io/os}{"\n"}{end}'`
+	// listControlPlanePodsCommand prints the names of the static control plane pods of the masters
+	listControlPlanePodsCommand = kubectlCommand + "get pods --namespace=kube-system --selector=tier=control-plane -o name"
+	// podLogsCommand prints the logs of a kube-system pod
+	podLogsCommand = kubectlCommand + "logs --namespace=kube-system %s"
+)
+
+// nodeLogs are the files collected for each node, and the commands printing them
+var nodeLogs = []struct {
+	file    string
+	command string
+}{
+	{"kubelet.log", "sudo journalctl --unit=kubelet --no-pager"},
+	{"docker.log", "sudo journalctl --unit=docker --no-pager"},
+	{"containerd.log", "sudo journalctl --unit=containerd --no-pager"},
+	{"cloud-init.log", "sudo cat /var/log/cloud-init.log"},
+	{"cloud-init-output.log", "sudo cat /var/log/cloud-init-output.log"},
+	{"cluster-provision.log", "sudo cat /var/log/azure/cluster-provision.log"},
+}
+
+// CollectClusterLogs collects the kubelet, container runtime and provisioning logs of each Linux node
+// of the cluster, and the logs of the control plane pods, into a gzipped tarball written to out.
+// master runs commands on a master node, nodeRunner returns the runner for the node at an address.
+// Logs which cannot be collected are listed in errors.log in the tarball rather than failing the
+// collection, as the nodes of a broken cluster are expected to be partially unreachable
+func CollectClusterLogs(logger *log.Entry, master RemoteCommandRunner, nodeRunner func(addr string) RemoteCommandRunner, out io.Writer) error {
+	logger = logger.WithField(LogFieldOperation, "collect-logs")
+
+	logger.Infof("listing the cluster nodes")
+	nodes, err := master(listNodesCommand)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the cluster nodes")
+	}
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	var failures []string
+	collect := func(name string, run RemoteCommandRunner, cmd string) error {
+		output, err := run(cmd)
+		if err != nil {
+			logger.Warnf("failed to collect %s: %v", name, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			if len(output) == 0 {
+				return nil
+			}
+		}
+		return writeTarFile(tw, name, output)
+	}
+
+	if err = writeTarFile(tw, "nodes.txt", nodes); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(nodes), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name, addr := fields[0], fields[1]
+		nodeLogger := logger.WithField(LogFieldNode, name)
+		if len(fields) > 2 && fields[2] == "windows" {
+			nodeLogger.Warnf("skipping Windows node")
+			continue
+		}
+		nodeLogger.Infof("collecting node logs")
+		run := nodeRunner(addr)
+		for _, l := range nodeLogs {
+			if err = collect(path.Join(name, l.file), run, l.command); err != nil {
+				return err
+			}
+		}
+	}
+
+	logger.Infof("collecting control plane pod logs")
+	pods, err := master(listControlPlanePodsCommand)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("control plane pods: %v", err))
+	}
+	for _, pod := range strings.Fields(pods) {
+		pod = path.Base(pod)
+		if err = collect(path.Join("control-plane", pod+".log"), master, fmt.Sprintf(podLogsCommand, pod)); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		if err = writeTarFile(tw, "errors.log", strings.Join(failures, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the logs tarball")
+	}
+	if err = gz.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the logs tarball")
+	}
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name, contents string) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "failed to write %s to the logs tarball", name)
+	}
+	if _, err := tw.Write([]byte(contents)); err != nil {
+		return errors.Wrapf(err, "failed to write %s to the logs tarball", name)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func readTarball(b *bytes.Buffer) map[string]string {
+	gz, err := gzip.NewReader(b)
+	Expect(err).NotTo(HaveOccurred())
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		Expect(err).NotTo(HaveOccurred())
+		contents, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		files[hdr.Name] = string(contents)
+	}
+}
+
+var _ = Describe("Collect cluster logs operation tests", func() {
+	var masterCommands []string
+	var nodeCommands map[string][]string
+
+	masterRunner := func(cmd string) (string, error) {
+		masterCommands = append(masterCommands, cmd)
+		switch {
+		case strings.Contains(cmd, "get nodes"):
+			return "k8s-master-0 10.240.255.5 linux\nk8s-agentpool1-0 10.240.0.4 linux\n1000k8s000 10.240.0.5 windows\n", nil
+		case strings.Contains(cmd, "get pods"):
+			return "pod/kube-apiserver-k8s-master-0\npod/kube-scheduler-k8s-master-0\n", nil
+		case strings.Contains(cmd, "logs"):
+			return "logs of " + cmd[strings.LastIndex(cmd, " ")+1:], nil
+		}
+		return "", errors.Errorf("unexpected command %s", cmd)
+	}
+	nodeRunner := func(addr string) RemoteCommandRunner {
+		return func(cmd string) (string, error) {
+			nodeCommands[addr] = append(nodeCommands[addr], cmd)
+			if strings.Contains(cmd, "cloud-init-output") && addr == "10.240.0.4" {
+				return "", errors.New("connection refused")
+			}
+			return addr + ": " + cmd, nil
+		}
+	}
+
+	BeforeEach(func() {
+		masterCommands = nil
+		nodeCommands = map[string][]string{}
+	})
+
+	It("Should collect the node and control plane logs into a tarball", func() {
+		var out bytes.Buffer
+		err := CollectClusterLogs(log.NewEntry(log.New()), masterRunner, nodeRunner, &out)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(nodeCommands).To(HaveLen(2))
+		for _, addr := range []string{"10.240.255.5", "10.240.0.4"} {
+			Expect(nodeCommands[addr]).To(Equal([]string{
+				"sudo journalctl --unit=kubelet --no-pager",
+				"sudo journalctl --unit=docker --no-pager",
+				"sudo journalctl --unit=containerd --no-pager",
+				"sudo cat /var/log/cloud-init.log",
+				"sudo cat /var/log/cloud-init-output.log",
+				"sudo cat /var/log/azure/cluster-provision.log",
+			}))
+		}
+		Expect(masterCommands).To(Equal([]string{
+			listNodesCommand,
+			listControlPlanePodsCommand,
+			kubectlCommand + "logs --namespace=kube-system kube-apiserver-k8s-master-0",
+			kubectlCommand + "logs --namespace=kube-system kube-scheduler-k8s-master-0",
+		}))
+
+		files := readTarball(&out)
+		Expect(files).To(HaveKey("nodes.txt"))
+		Expect(files["k8s-master-0/kubelet.log"]).To(Equal("10.240.255.5: sudo journalctl --unit=kubelet --no-pager"))
+		Expect(files["k8s-agentpool1-0/cluster-provision.log"]).To(Equal("10.240.0.4: sudo cat /var/log/azure/cluster-provision.log"))
+		Expect(files["control-plane/kube-apiserver-k8s-master-0.log"]).To(Equal("logs of kube-apiserver-k8s-master-0"))
+		Expect(files).To(HaveKey("control-plane/kube-scheduler-k8s-master-0.log"))
+		Expect(files).NotTo(HaveKey("1000k8s000/kubelet.log"))
+
+		// a log failing to be collected is reported rather than failing the collection
+		Expect(files).NotTo(HaveKey("k8s-agentpool1-0/cloud-init-output.log"))
+		Expect(files["errors.log"]).To(Equal("k8s-agentpool1-0/cloud-init-output.log: connection refused\n"))
+	})
+	It("Should fail when the cluster nodes cannot be listed", func() {
+		var out bytes.Buffer
+		err := CollectClusterLogs(log.NewEntry(log.New()), func(cmd string) (string, error) {
+			return "", errors.New("connection refused")
+		}, nodeRunner, &out)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed to list the cluster nodes"))
+		Expect(nodeCommands).To(BeEmpty())
+	})
+})
@@ -45,32 +45,46 @@ func SafelyDrainNode(az armhelpers.ACSEngineClient, logger *log.Entry, masterURL
 
 // SafelyDrainNodeWithClient safely drains a node so that it can be deleted from the cluster
 func SafelyDrainNodeWithClient(client armhelpers.KubernetesClient, logger *log.Entry, nodeName string, timeout time.Duration) error {
+	logger = logger.WithField(LogFieldNode, nodeName)
 	//Mark the node unschedulable
+	node, _, err := setNodeUnschedulable(client, logger, nodeName, true)
+	if err != nil {
+		return err
+	}
+	logger.Info("Node has been marked unschedulable.")
+
+	//Evict pods in node
+	drainOp := &drainOperation{client: client, node: node, logger: logger, timeout: timeout}
+	return drainOp.deleteOrEvictPodsSimple()
+}
+
+// setNodeUnschedulable cordons or uncordons a node, retrying on concurrent modifications.
+// Returns the node, and whether it had to be updated
+func setNodeUnschedulable(client armhelpers.KubernetesClient, logger *log.Entry, nodeName string, unschedulable bool) (*v1.Node, bool, error) {
 	var node *v1.Node
 	var err error
 	for i := 0; i < cordonMaxRetries; i++ {
 		node, err = client.GetNode(nodeName)
 		if err != nil {
-			return err
+			return nil, false, err
 		}
-		node.Spec.Unschedulable = true
+		if node.Spec.Unschedulable == unschedulable {
+			return node, false, nil
+		}
+		node.Spec.Unschedulable = unschedulable
 		node, err = client.UpdateNode(node)
 		if err != nil {
 			// If this error is because of a concurrent modification get the update
 			// and then apply the change
 			if strings.Contains(err.Error(), kubernetesOptimisticLockErrorMsg) {
-				logger.Infof("Node %s got an error suggesting a concurrent modification. Will retry to cordon", nodeName)
+				logger.Info("Node got an error suggesting a concurrent modification. Will retry")
 				continue
 			}
-			return err
+			return nil, false, err
 		}
-		break
+		return node, true, nil
 	}
-	logger.Infof("Node %s has been marked unschedulable.", nodeName)
-
-	//Evict pods in node
-	drainOp := &drainOperation{client: client, node: node, logger: logger, timeout: timeout}
-	return drainOp.deleteOrEvictPodsSimple()
+	return node, true, err
 }
 
 func (o *drainOperation) deleteOrEvictPodsSimple() error {
@@ -4,9 +4,13 @@
 package operations
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/helpers"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
@@ -39,6 +43,24 @@ var _ = Describe("Safely Drain node operation tests", func() {
 		err := SafelyDrainNode(mockClient, log.NewEntry(log.New()), "http://bad.com/", "bad", "node", time.Minute)
 		Expect(err).ShouldNot(HaveOccurred())
 	})
+	It("Should log the node as a structured field", func() {
+		var out bytes.Buffer
+		logger, err := helpers.NewLogger(helpers.LogFormatJSON, &out)
+		Expect(err).NotTo(HaveOccurred())
+		mockClient := &armhelpers.MockACSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
+		err = SafelyDrainNode(mockClient, logger.WithField(LogFieldOperation, "upgrade"), "http://bad.com/", "bad", "k8s-agent-1", time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Expect(lines).NotTo(BeEmpty())
+		for _, line := range lines {
+			entry := map[string]interface{}{}
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			Expect(entry).To(HaveKeyWithValue(LogFieldNode, "k8s-agent-1"))
+			Expect(entry).To(HaveKeyWithValue(LogFieldOperation, "upgrade"))
+			Expect(entry["msg"]).NotTo(ContainSubstring("k8s-agent-1"))
+		}
+	})
 	It("Should return error messages for Failure to update node ", func() {
 		mockClient := &armhelpers.MockACSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
 		mockClient.MockKubernetesClient.FailUpdateNode = true
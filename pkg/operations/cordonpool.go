@@ -0,0 +1,82 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"sort"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// agentPoolLabel is the node label holding the name of the agent pool of a node
+const agentPoolLabel = "agentpool"
+
+// NodeCordonResult is the outcome of cordoning or uncordoning a node of an agent pool
+type NodeCordonResult struct {
+	Name string
+	// Changed is false when the node already was in the requested state
+	Changed bool
+	Error   error
+}
+
+// CordonPool marks all the nodes of an agent pool unschedulable, without draining or deleting them,
+// e.g. for maintenance. Nodes which are already unschedulable are left as they are. A failure to
+// cordon a node doesn't stop the other nodes from being cordoned, and is reported in its result
+func CordonPool(client armhelpers.KubernetesClient, logger *log.Entry, poolName string) ([]NodeCordonResult, error) {
+	return setPoolUnschedulable(client, logger, poolName, true)
+}
+
+// UncordonPool marks all the nodes of an agent pool schedulable again, e.g. once the maintenance
+// started by CordonPool is done. Nodes which are already schedulable are left as they are
+func UncordonPool(client armhelpers.KubernetesClient, logger *log.Entry, poolName string) ([]NodeCordonResult, error) {
+	return setPoolUnschedulable(client, logger, poolName, false)
+}
+
+func setPoolUnschedulable(client armhelpers.KubernetesClient, logger *log.Entry, poolName string, unschedulable bool) ([]NodeCordonResult, error) {
+	nodeList, err := client.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the nodes of the cluster")
+	}
+	var nodeNames []string
+	for _, node := range nodeList.Items {
+		if node.Labels[agentPoolLabel] == poolName {
+			nodeNames = append(nodeNames, node.Name)
+		}
+	}
+	if len(nodeNames) == 0 {
+		return nil, errors.Errorf("found no nodes in agent pool '%s'", poolName)
+	}
+	sort.Strings(nodeNames)
+
+	action := "uncordon"
+	if unschedulable {
+		action = "cordon"
+	}
+	results := make([]NodeCordonResult, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		nodeLogger := logger.WithField(LogFieldNode, nodeName)
+		_, changed, err := setNodeUnschedulable(client, nodeLogger, nodeName, unschedulable)
+		switch {
+		case err != nil:
+			changed = false
+			err = errors.Wrapf(err, "failed to %s node %s", action, nodeName)
+			nodeLogger.Error(err)
+		case changed:
+			nodeLogger.Infof("Node has been marked %s.", schedulability(unschedulable))
+		default:
+			nodeLogger.Infof("Node was already %s.", schedulability(unschedulable))
+		}
+		results = append(results, NodeCordonResult{Name: nodeName, Changed: changed, Error: err})
+	}
+	return results, nil
+}
+
+func schedulability(unschedulable bool) string {
+	if unschedulable {
+		return "unschedulable"
+	}
+	return "schedulable"
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newFakeNodesClient returns a mock Kubernetes client serving the given nodes, which records
+// the updates of the nodes and the number of updates of each of them
+func newFakeNodesClient(nodes ...v1.Node) (*armhelpers.MockKubernetesClient, map[string]*v1.Node, map[string]int) {
+	byName := map[string]*v1.Node{}
+	updates := map[string]int{}
+	for i := range nodes {
+		byName[nodes[i].Name] = nodes[i].DeepCopy()
+	}
+	client := &armhelpers.MockKubernetesClient{
+		NodesList: &v1.NodeList{Items: nodes},
+		GetNodeFunc: func(name string) (*v1.Node, error) {
+			node, ok := byName[name]
+			if !ok {
+				return nil, errors.Errorf("node %s not found", name)
+			}
+			return node.DeepCopy(), nil
+		},
+		UpdateNodeFunc: func(node *v1.Node) (*v1.Node, error) {
+			updates[node.Name]++
+			byName[node.Name] = node.DeepCopy()
+			return node, nil
+		},
+	}
+	return client, byName, updates
+}
+
+func newPoolNode(name, pool string, unschedulable bool) v1.Node {
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{agentPoolLabel: pool}},
+		Spec:       v1.NodeSpec{Unschedulable: unschedulable},
+	}
+}
+
+var _ = Describe("Cordon and uncordon agent pool operation tests", func() {
+	logger := log.NewEntry(log.New())
+
+	It("Should cordon every node of the pool and only of the pool", func() {
+		client, nodes, updates := newFakeNodesClient(
+			newPoolNode("k8s-agentpool1-1", "agentpool1", false),
+			newPoolNode("k8s-agentpool1-0", "agentpool1", false),
+			newPoolNode("k8s-agentpool2-0", "agentpool2", false),
+		)
+		results, err := CordonPool(client, logger, "agentpool1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]NodeCordonResult{
+			{Name: "k8s-agentpool1-0", Changed: true},
+			{Name: "k8s-agentpool1-1", Changed: true},
+		}))
+		Expect(nodes["k8s-agentpool1-0"].Spec.Unschedulable).To(BeTrue())
+		Expect(nodes["k8s-agentpool1-1"].Spec.Unschedulable).To(BeTrue())
+		Expect(nodes["k8s-agentpool2-0"].Spec.Unschedulable).To(BeFalse())
+		Expect(updates).NotTo(HaveKey("k8s-agentpool2-0"))
+	})
+
+	It("Should leave already cordoned nodes as they are", func() {
+		client, nodes, updates := newFakeNodesClient(
+			newPoolNode("k8s-agentpool1-0", "agentpool1", true),
+			newPoolNode("k8s-agentpool1-1", "agentpool1", false),
+		)
+		results, err := CordonPool(client, logger, "agentpool1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]NodeCordonResult{
+			{Name: "k8s-agentpool1-0", Changed: false},
+			{Name: "k8s-agentpool1-1", Changed: true},
+		}))
+		Expect(updates).To(Equal(map[string]int{"k8s-agentpool1-1": 1}))
+
+		results, err = CordonPool(client, logger, "agentpool1")
+		Expect(err).NotTo(HaveOccurred())
+		for _, result := range results {
+			Expect(result.Changed).To(BeFalse())
+			Expect(result.Error).NotTo(HaveOccurred())
+			Expect(nodes[result.Name].Spec.Unschedulable).To(BeTrue())
+		}
+		Expect(updates).To(Equal(map[string]int{"k8s-agentpool1-1": 1}))
+	})
+
+	It("Should uncordon every node of the pool", func() {
+		client, nodes, updates := newFakeNodesClient(
+			newPoolNode("k8s-agentpool1-0", "agentpool1", true),
+			newPoolNode("k8s-agentpool1-1", "agentpool1", false),
+			newPoolNode("k8s-agentpool2-0", "agentpool2", true),
+		)
+		results, err := UncordonPool(client, logger, "agentpool1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]NodeCordonResult{
+			{Name: "k8s-agentpool1-0", Changed: true},
+			{Name: "k8s-agentpool1-1", Changed: false},
+		}))
+		Expect(nodes["k8s-agentpool1-0"].Spec.Unschedulable).To(BeFalse())
+		Expect(nodes["k8s-agentpool2-0"].Spec.Unschedulable).To(BeTrue())
+		Expect(updates).To(Equal(map[string]int{"k8s-agentpool1-0": 1}))
+	})
+
+	It("Should report the nodes which failed and carry on with the others", func() {
+		client, nodes, _ := newFakeNodesClient(
+			newPoolNode("k8s-agentpool1-0", "agentpool1", false),
+			newPoolNode("k8s-agentpool1-1", "agentpool1", false),
+		)
+		updateNode := client.UpdateNodeFunc
+		client.UpdateNodeFunc = func(node *v1.Node) (*v1.Node, error) {
+			if node.Name == "k8s-agentpool1-0" {
+				return nil, errors.New("UpdateNode failed")
+			}
+			return updateNode(node)
+		}
+		results, err := CordonPool(client, logger, "agentpool1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Changed).To(BeFalse())
+		Expect(results[0].Error).To(MatchError("failed to cordon node k8s-agentpool1-0: UpdateNode failed"))
+		Expect(results[1]).To(Equal(NodeCordonResult{Name: "k8s-agentpool1-1", Changed: true}))
+		Expect(nodes["k8s-agentpool1-1"].Spec.Unschedulable).To(BeTrue())
+	})
+
+	It("Should retry on concurrent modifications", func() {
+		client, nodes, _ := newFakeNodesClient(newPoolNode("k8s-agentpool1-0", "agentpool1", false))
+		updateNode := client.UpdateNodeFunc
+		conflicts := 2
+		client.UpdateNodeFunc = func(node *v1.Node) (*v1.Node, error) {
+			if conflicts > 0 {
+				conflicts--
+				return nil, errors.New(kubernetesOptimisticLockErrorMsg)
+			}
+			return updateNode(node)
+		}
+		results, err := CordonPool(client, logger, "agentpool1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(Equal([]NodeCordonResult{{Name: "k8s-agentpool1-0", Changed: true}}))
+		Expect(nodes["k8s-agentpool1-0"].Spec.Unschedulable).To(BeTrue())
+	})
+
+	It("Should return an error when the pool has no nodes", func() {
+		client, _, _ := newFakeNodesClient(newPoolNode("k8s-agentpool2-0", "agentpool2", false))
+		_, err := CordonPool(client, logger, "agentpool1")
+		Expect(err).To(MatchError("found no nodes in agent pool 'agentpool1'"))
+	})
+
+	It("Should return an error when the nodes cannot be listed", func() {
+		client := &armhelpers.MockKubernetesClient{FailListNodes: true}
+		_, err := UncordonPool(client, logger, "agentpool1")
+		Expect(err).To(MatchError("error listing the nodes of the cluster: ListNodes failed"))
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"github.com/Azure/acs-engine/pkg/api"
 	"github.com/Azure/acs-engine/pkg/armhelpers"
 	"github.com/Azure/acs-engine/pkg/i18n"
+	"github.com/Azure/acs-engine/pkg/operations"
 	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
 )
@@ -40,6 +41,7 @@ func (uc *UpgradeCluster) UpgradeCluster(subscriptionID uuid.UUID, resourceGroup
 	uc.DataModel = cs
 	uc.NameSuffix = nameSuffix
 	uc.SSHKey = sshKey
+	uc.Logger = uc.Logger.WithFields(logrus.Fields{operations.LogFieldOperation: "dcos-upgrade", operations.LogFieldResourceGroup: resourceGroup})
 
 	upgradeVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
 	uc.Logger.Infof("Upgrading DCOS from %s to %s", uc.CurrentDcosVersion, upgradeVersion)
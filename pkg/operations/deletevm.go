@@ -23,17 +23,18 @@ const (
 func CleanDeleteVirtualMachine(az armhelpers.ACSEngineClient, logger *log.Entry, subscriptionID, resourceGroup, name string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
 	defer cancel()
-	logger.Infof("fetching VM: %s/%s", resourceGroup, name)
+	logger = logger.WithFields(log.Fields{LogFieldResourceGroup: resourceGroup, LogFieldNode: name})
+	logger.Info("fetching VM")
 	vm, err := az.GetVirtualMachine(ctx, resourceGroup, name)
 	if err != nil {
-		logger.Errorf("failed to get VM: %s/%s: %s", resourceGroup, name, err.Error())
+		logger.Errorf("failed to get VM: %s", err.Error())
 		return err
 	}
 
 	vhd := vm.VirtualMachineProperties.StorageProfile.OsDisk.Vhd
 	managedDisk := vm.VirtualMachineProperties.StorageProfile.OsDisk.ManagedDisk
 	if vhd == nil && managedDisk == nil {
-		logger.Errorf("failed to get a valid os disk URI for VM: %s/%s", resourceGroup, name)
+		logger.Error("failed to get a valid os disk URI for VM")
 
 		return errors.New("os disk does not have a VHD URI")
 	}
@@ -43,23 +44,23 @@ func CleanDeleteVirtualMachine(az armhelpers.ACSEngineClient, logger *log.Entry,
 	var nicName string
 	nicID := (*vm.VirtualMachineProperties.NetworkProfile.NetworkInterfaces)[0].ID
 	if nicID == nil {
-		logger.Warnf("NIC ID is not set for VM (%s/%s)", resourceGroup, name)
+		logger.Warn("NIC ID is not set for VM")
 	} else {
 		nicName, err = utils.ResourceName(*nicID)
 		if err != nil {
 			return err
 		}
-		logger.Infof("found nic name for VM (%s/%s): %s", resourceGroup, name, nicName)
+		logger.Infof("found nic name for VM: %s", nicName)
 	}
-	logger.Infof("deleting VM: %s/%s", resourceGroup, name)
-	logger.Infof("waiting for vm deletion: %s/%s", resourceGroup, name)
+	logger.Info("deleting VM")
+	logger.Info("waiting for vm deletion")
 	if err = az.DeleteVirtualMachine(ctx, resourceGroup, name); err != nil {
 		return err
 	}
 
 	if len(nicName) > 0 {
-		logger.Infof("deleting nic: %s/%s", resourceGroup, nicName)
-		logger.Infof("waiting for nic deletion: %s/%s", resourceGroup, nicName)
+		logger.Infof("deleting nic: %s", nicName)
+		logger.Infof("waiting for nic deletion: %s", nicName)
 		if err := az.DeleteNetworkInterface(ctx, resourceGroup, nicName); err != nil {
 			return err
 		}
@@ -84,9 +85,9 @@ func CleanDeleteVirtualMachine(az armhelpers.ACSEngineClient, logger *log.Entry,
 		}
 	} else if managedDisk != nil {
 		if osDiskName == nil {
-			logger.Warnf("osDisk is not set for VM %s/%s", resourceGroup, name)
+			logger.Warn("osDisk is not set for VM")
 		} else {
-			logger.Infof("deleting managed disk: %s/%s", resourceGroup, *osDiskName)
+			logger.Infof("deleting managed disk: %s", *osDiskName)
 			if err = az.DeleteManagedDisk(ctx, resourceGroup, *osDiskName); err != nil {
 				return err
 			}
@@ -33,6 +33,7 @@ type UpgradeAgentNode struct {
 	TemplateMap             map[string]interface{}
 	ParametersMap           map[string]interface{}
 	UpgradeContainerService *api.ContainerService
+	AgentPoolProfile        *api.AgentPoolProfile
 	SubscriptionID          string
 	ResourceGroup           string
 	Client                  armhelpers.ACSEngineClient
@@ -57,11 +58,12 @@ func (kan *UpgradeAgentNode) DeleteNode(vmName *string, drain bool) error {
 	if err != nil {
 		return err
 	}
+	logger := kan.logger.WithField(operations.LogFieldNode, *vmName)
 	// Cordon and drain the node
 	if drain {
 		err := operations.SafelyDrainNodeWithClient(client, kan.logger, *vmName, time.Minute)
 		if err != nil {
-			kan.logger.Warningf("Error draining agent VM %s. Proceeding with deletion. Error: %v", *vmName, err)
+			logger.Warningf("Error draining agent VM. Proceeding with deletion. Error: %v", err)
 			// Proceed with deletion anyways
 		}
 	}
@@ -73,7 +75,7 @@ func (kan *UpgradeAgentNode) DeleteNode(vmName *string, drain bool) error {
 	if err = client.DeleteNode(*vmName); err != nil {
 		statusErr, ok := err.(*errors.StatusError)
 		if ok && statusErr.ErrStatus.Reason != v1.StatusReasonNotFound {
-			kan.logger.Warnf("Node %s got an error while deregistering: %#v", *vmName, err)
+			logger.Warnf("Node got an error while deregistering: %#v", err)
 		}
 	}
 	return nil
@@ -107,7 +109,8 @@ func (kan *UpgradeAgentNode) Validate(vmName *string) error {
 		kan.logger.Warningf("VM name was empty. Skipping node condition check")
 		return nil
 	}
-	kan.logger.Infof("Validating %s", *vmName)
+	logger := kan.logger.WithField(operations.LogFieldNode, *vmName)
+	logger.Info("Validating")
 	var masterURL string
 	if kan.UpgradeContainerService.Properties.HostedMasterProfile != nil {
 		masterURL = kan.UpgradeContainerService.Properties.HostedMasterProfile.FQDN
@@ -130,16 +133,25 @@ func (kan *UpgradeAgentNode) Validate(vmName *string) error {
 		case <-retryTimer.C:
 			agentNode, err := client.GetNode(*vmName)
 			if err != nil {
-				kan.logger.Infof("Agent VM: %s status error: %v", *vmName, err)
+				logger.Infof("Agent VM status error: %v", err)
 				retryTimer.Reset(retry)
-			} else if isNodeReady(agentNode) {
-				kan.logger.Infof("Agent VM: %s is ready", *vmName)
+			} else if operations.IsNodeReady(agentNode) {
+				logger.Info("Agent VM is ready")
 				timeoutTimer.Stop()
-				return nil
+				return kan.applyAgentPoolTaintsAndLabels(client, *vmName)
 			} else {
-				kan.logger.Infof("Agent VM: %s not ready yet...", *vmName)
+				logger.Info("Agent VM not ready yet...")
 				retryTimer.Reset(retry)
 			}
 		}
 	}
 }
+
+// applyAgentPoolTaintsAndLabels applies the declared taints and labels of the agent pool to a node the upgrade
+// created, so that they're kept even when the node object of the replaced VM survived its deletion
+func (kan *UpgradeAgentNode) applyAgentPoolTaintsAndLabels(client armhelpers.KubernetesClient, nodeName string) error {
+	if kan.AgentPoolProfile == nil {
+		return nil
+	}
+	return operations.ApplyNodeTaintsAndLabels(client, kan.logger, nodeName, kan.AgentPoolProfile.GetKubernetesTaints(), kan.AgentPoolProfile.CustomNodeLabels)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/Azure/acs-engine/pkg/armhelpers"
 	"github.com/Azure/acs-engine/pkg/armhelpers/utils"
 	"github.com/Azure/acs-engine/pkg/i18n"
+	"github.com/Azure/acs-engine/pkg/operations"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-04-01/compute"
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
@@ -31,6 +32,12 @@ type ClusterTopology struct {
 
 	AgentPoolsToUpgrade map[string]bool
 	AgentPools          map[string]*AgentPoolTopology
+	// AgentPoolsUpgradeOrder holds the names of the agent pools in the order they are upgraded, after the masters
+	AgentPoolsUpgradeOrder []string
+	// AgentPoolOnly is set when a single agent pool is upgraded, the masters and the other agent pools are left untouched
+	AgentPoolOnly bool
+	// ControlPlaneVersion holds the oldest orchestrator version of the masters, read when a single agent pool is upgraded
+	ControlPlaneVersion string
 
 	AgentPoolScaleSetsToUpgrade []AgentPoolScaleSet
 
@@ -41,6 +48,7 @@ type ClusterTopology struct {
 // AgentPoolScaleSet contains necessary data required to upgrade a VMSS
 type AgentPoolScaleSet struct {
 	Name         string
+	PoolName     string
 	Sku          compute.Sku
 	Location     string
 	VMsToUpgrade []AgentPoolScaleSetVM
@@ -68,6 +76,9 @@ type UpgradeCluster struct {
 	ClusterTopology
 	Client      armhelpers.ACSEngineClient
 	StepTimeout *time.Duration
+	// MaxSurge is the number of agent nodes created at the target version before the nodes they replace are
+	// drained and deleted, 0 replaces the nodes one at a time with a single extra node
+	MaxSurge int
 }
 
 // MasterVMNamePrefix is the prefix for all master VM names for Kubernetes clusters
@@ -76,9 +87,51 @@ const MasterVMNamePrefix = "k8s-master-"
 // MasterPoolName pool name
 const MasterPoolName = "master"
 
-// UpgradeCluster runs the workflow to upgrade a Kubernetes cluster.
+// UpgradeCluster runs the workflow to upgrade a Kubernetes cluster. The masters are upgraded first,
+// then the agent pools in the order of agentPoolsToUpgrade.
 func (uc *UpgradeCluster) UpgradeCluster(subscriptionID uuid.UUID, az armhelpers.ACSEngineClient, kubeConfig, resourceGroup string,
 	cs *api.ContainerService, nameSuffix string, agentPoolsToUpgrade []string, acsengineVersion string) error {
+	uc.initClusterTopology(subscriptionID, resourceGroup, cs, nameSuffix, agentPoolsToUpgrade)
+	uc.AgentPoolsToUpgrade[MasterPoolName] = true
+
+	if err := uc.getClusterNodeStatus(subscriptionID, az, resourceGroup, kubeConfig); err != nil {
+		return uc.Translator.Errorf("Error while querying ARM for resources: %+v", err)
+	}
+
+	return uc.runUpgrade(kubeConfig, acsengineVersion)
+}
+
+// UpgradeAgentPool runs the workflow to upgrade the nodes of a single agent pool to the orchestrator version of the
+// data model, the masters and the other agent pools are left untouched. The version must be supported by the
+// control plane: it cannot be newer than the masters, nor more than two minor versions older.
+func (uc *UpgradeCluster) UpgradeAgentPool(subscriptionID uuid.UUID, az armhelpers.ACSEngineClient, kubeConfig, resourceGroup string,
+	cs *api.ContainerService, nameSuffix string, agentPool string, acsengineVersion string) error {
+	found := false
+	for _, app := range cs.Properties.AgentPoolProfiles {
+		if app.Name == agentPool {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("agent pool %s is not in the api model", agentPool)
+	}
+
+	uc.initClusterTopology(subscriptionID, resourceGroup, cs, nameSuffix, []string{agentPool})
+	uc.AgentPoolOnly = true
+
+	if err := uc.getClusterNodeStatus(subscriptionID, az, resourceGroup, kubeConfig); err != nil {
+		return uc.Translator.Errorf("Error while querying ARM for resources: %+v", err)
+	}
+
+	if err := uc.validateControlPlaneVersionSkew(agentPool); err != nil {
+		return err
+	}
+
+	return uc.runUpgrade(kubeConfig, acsengineVersion)
+}
+
+func (uc *UpgradeCluster) initClusterTopology(subscriptionID uuid.UUID, resourceGroup string, cs *api.ContainerService, nameSuffix string, agentPoolsToUpgrade []string) {
 	uc.ClusterTopology = ClusterTopology{}
 	uc.SubscriptionID = subscriptionID.String()
 	uc.ResourceGroup = resourceGroup
@@ -88,16 +141,15 @@ func (uc *UpgradeCluster) UpgradeCluster(subscriptionID uuid.UUID, az armhelpers
 	uc.UpgradedMasterVMs = &[]compute.VirtualMachine{}
 	uc.AgentPools = make(map[string]*AgentPoolTopology)
 	uc.AgentPoolsToUpgrade = make(map[string]bool)
+	uc.Logger = uc.Logger.WithFields(logrus.Fields{operations.LogFieldOperation: "upgrade", operations.LogFieldResourceGroup: resourceGroup})
 
 	for _, poolName := range agentPoolsToUpgrade {
 		uc.AgentPoolsToUpgrade[poolName] = true
 	}
-	uc.AgentPoolsToUpgrade[MasterPoolName] = true
-
-	if err := uc.getClusterNodeStatus(subscriptionID, az, resourceGroup, kubeConfig); err != nil {
-		return uc.Translator.Errorf("Error while querying ARM for resources: %+v", err)
-	}
+	uc.AgentPoolsUpgradeOrder = agentPoolsToUpgrade
+}
 
+func (uc *UpgradeCluster) runUpgrade(kubeConfig, acsengineVersion string) error {
 	var upgrader UpgradeWorkFlow
 	upgradeVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
 	uc.Logger.Infof("Upgrading to Kubernetes version %s\n", upgradeVersion)
@@ -105,16 +157,19 @@ func (uc *UpgradeCluster) UpgradeCluster(subscriptionID uuid.UUID, az armhelpers
 	case strings.HasPrefix(upgradeVersion, "1.6."):
 		upgrader16 := &Kubernetes16upgrader{}
 		upgrader16.Init(uc.Translator, uc.Logger, uc.ClusterTopology, uc.Client, kubeConfig, uc.StepTimeout, acsengineVersion)
+		upgrader16.MaxSurge = uc.MaxSurge
 		upgrader = upgrader16
 
 	case strings.HasPrefix(upgradeVersion, "1.7."):
 		upgrader17 := &Kubernetes17upgrader{}
 		upgrader17.Init(uc.Translator, uc.Logger, uc.ClusterTopology, uc.Client, kubeConfig, uc.StepTimeout, acsengineVersion)
+		upgrader17.MaxSurge = uc.MaxSurge
 		upgrader = upgrader17
 
 	case strings.HasPrefix(upgradeVersion, "1.8."):
 		upgrader18 := &Kubernetes18upgrader{}
 		upgrader18.Init(uc.Translator, uc.Logger, uc.ClusterTopology, uc.Client, kubeConfig, uc.StepTimeout, acsengineVersion)
+		upgrader18.MaxSurge = uc.MaxSurge
 		upgrader = upgrader18
 
 	case strings.HasPrefix(upgradeVersion, "1.9."),
@@ -124,6 +179,7 @@ func (uc *UpgradeCluster) UpgradeCluster(subscriptionID uuid.UUID, az armhelpers
 		strings.HasPrefix(upgradeVersion, "1.13."):
 		u := &Upgrader{}
 		u.Init(uc.Translator, uc.Logger, uc.ClusterTopology, uc.Client, kubeConfig, uc.StepTimeout, acsengineVersion)
+		u.MaxSurge = uc.MaxSurge
 		upgrader = u
 
 	default:
@@ -168,6 +224,13 @@ func (uc *UpgradeCluster) getClusterNodeStatus(subscriptionID uuid.UUID, az armh
 					Sku:      *vmScaleSet.Sku,
 					Location: *vmScaleSet.Location,
 				}
+				if vmScaleSet.Tags != nil && vmScaleSet.Tags["poolName"] != nil {
+					scaleSetToUpgrade.PoolName = *vmScaleSet.Tags["poolName"]
+				}
+				if uc.AgentPoolOnly && !uc.AgentPoolsToUpgrade[scaleSetToUpgrade.PoolName] {
+					uc.Logger.Infof("Skipping VMSS %s for upgrade as it does not belong to the agent pool being upgraded", *vmScaleSet.Name)
+					break
+				}
 				for _, vm := range vmScaleSetVMsPage.Values() {
 					scaleSetVMOrchestratorTypeAndVersion := uc.getClusterNodeVersion(kubeClient, *vm.Name, vm.Tags)
 					if scaleSetVMOrchestratorTypeAndVersion == "" {
@@ -210,6 +273,16 @@ func (uc *UpgradeCluster) getClusterNodeStatus(subscriptionID uuid.UUID, az armh
 				continue
 			}
 
+			if uc.AgentPoolOnly && strings.Contains(*(vm.Name), MasterVMNamePrefix) {
+				if strings.Contains(*(vm.Name), uc.NameSuffix) {
+					uc.Logger.Infof("Master VM name: %s, orchestrator: %s (control plane)\n", *vm.Name, vmOrchestratorTypeAndVersion)
+					if err := uc.setControlPlaneVersion(vmOrchestratorTypeAndVersion); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
 			if vmOrchestratorTypeAndVersion != targetOrchestratorTypeVersion {
 				if strings.Contains(*(vm.Name), MasterVMNamePrefix) {
 					if !strings.Contains(*(vm.Name), uc.NameSuffix) {
@@ -305,6 +378,44 @@ func (uc *UpgradeCluster) upgradable(vmOrchestratorTypeAndVersion string) error
 	return errors.Errorf("%s cannot be upgraded to %s", vmOrchestratorTypeAndVersion, uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion)
 }
 
+// setControlPlaneVersion keeps the oldest orchestrator version of the masters, each of the apiservers must
+// support the kubelets of the upgraded agent pool
+func (uc *UpgradeCluster) setControlPlaneVersion(vmOrchestratorTypeAndVersion string) error {
+	arr := strings.Split(vmOrchestratorTypeAndVersion, ":")
+	if len(arr) != 2 {
+		return errors.Errorf("Unsupported orchestrator tag format %s", vmOrchestratorTypeAndVersion)
+	}
+	ver, err := semver.Make(arr[1])
+	if err != nil {
+		return errors.Errorf("Unsupported orchestrator version format %s", arr[1])
+	}
+	if uc.ControlPlaneVersion == "" || ver.LT(semver.MustParse(uc.ControlPlaneVersion)) {
+		uc.ControlPlaneVersion = ver.String()
+	}
+	return nil
+}
+
+// validateControlPlaneVersionSkew ensures the control plane supports the kubelets of the agent pool once
+// upgraded: they cannot be newer than the masters, nor more than two minor versions older
+func (uc *UpgradeCluster) validateControlPlaneVersionSkew(agentPool string) error {
+	if uc.ControlPlaneVersion == "" {
+		return errors.Errorf("agent pool %s cannot be upgraded on its own, the version of the control plane could not be determined", agentPool)
+	}
+	upgradeVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
+	target, err := semver.Make(upgradeVersion)
+	if err != nil {
+		return errors.Errorf("Unsupported orchestrator version format %s", upgradeVersion)
+	}
+	controlPlane := semver.MustParse(uc.ControlPlaneVersion)
+	if target.GT(controlPlane) {
+		return errors.Errorf("agent pool %s cannot be upgraded to %s, which is newer than the control plane version %s", agentPool, upgradeVersion, uc.ControlPlaneVersion)
+	}
+	if target.Major != controlPlane.Major || target.Minor+2 < controlPlane.Minor {
+		return errors.Errorf("agent pool %s cannot be upgraded to %s, which is more than two minor versions older than the control plane version %s", agentPool, upgradeVersion, uc.ControlPlaneVersion)
+	}
+	return nil
+}
+
 func (uc *UpgradeCluster) addVMToAgentPool(vm compute.VirtualMachine, isUpgradableVM bool) error {
 	var poolIdentifier string
 	var poolPrefix string
@@ -322,7 +433,7 @@ func (uc *UpgradeCluster) addVMToAgentPool(vm compute.VirtualMachine, isUpgradab
 				agentPools = append(agentPools, k)
 			}
 		}
-		if len(agentPools) == 1 {
+		if len(agentPools) == 1 && (!uc.AgentPoolOnly || len(uc.DataModel.Properties.AgentPoolProfiles) == 1) {
 			vmPoolName = agentPools[0]
 		}
 	}
@@ -6,6 +6,7 @@ package kubernetesupgrade
 import (
 	"os"
 	"testing"
+	"time"
 
 	"fmt"
 
@@ -13,10 +14,12 @@ import (
 	"github.com/Azure/acs-engine/pkg/armhelpers"
 	"github.com/Azure/acs-engine/pkg/i18n"
 	. "github.com/Azure/acs-engine/pkg/test"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-04-01/compute"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
 )
 
 const TestACSEngineVersion = "1.0.0"
@@ -25,6 +28,30 @@ func TestUpgradeCluster(t *testing.T) {
 	RunSpecsWithReporters(t, "kubernetesupgrade", "Server Suite")
 }
 
+func mockClusterVM(name, orchestrator, poolName string) compute.VirtualMachine {
+	tags := map[string]*string{"orchestrator": &orchestrator}
+	if poolName != "" {
+		tags["poolName"] = &poolName
+	}
+	return compute.VirtualMachine{
+		Name: &name,
+		Tags: tags,
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			StorageProfile: &compute.StorageProfile{
+				OsDisk: &compute.OSDisk{OsType: compute.Linux},
+			},
+		},
+	}
+}
+
+func mockTwoPoolsContainerService(orchestratorVersion string) *api.ContainerService {
+	cs := api.CreateMockContainerService("testcluster", orchestratorVersion, 1, 1, false)
+	agentPool := *cs.Properties.AgentPoolProfiles[0]
+	agentPool.Name = "agentpool2"
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &agentPool)
+	return cs
+}
+
 var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 	AfterEach(func() {
 		// delete temp template directory
@@ -219,4 +246,343 @@ var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 		err := uc.UpgradeCluster(subID, nil, "kubeConfig", "TestRg", cs, "12345678", []string{"agentpool1"}, TestACSEngineVersion)
 		Expect(err).To(BeNil())
 	})
+
+	It("Should apply the taints and labels of the agent pool to the replacement nodes during upgrade operation", func() {
+		cs := api.CreateMockContainerService("testcluster", "1.7.16", 1, 1, false)
+		cs.Properties.AgentPoolProfiles[0].CustomNodeTaints = []string{"dedicated=gpu:NoSchedule"}
+		cs.Properties.AgentPoolProfiles[0].CustomNodeLabels = map[string]string{"team": "ml"}
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+		}
+
+		var updatedNodes []*v1.Node
+		mockClient := armhelpers.MockACSEngineClient{}
+		mockClient.MockKubernetesClient = &armhelpers.MockKubernetesClient{
+			UpdateNodeFunc: func(node *v1.Node) (*v1.Node, error) {
+				updatedNodes = append(updatedNodes, node)
+				return node, nil
+			},
+		}
+		uc.Client = &mockClient
+
+		subID, _ := uuid.FromString("DEC923E3-1EF1-4745-9516-37906D56DEC4")
+
+		err := uc.UpgradeCluster(subID, &mockClient, "kubeConfig", "TestRg", cs, "12345678", []string{"agentpool1"}, TestACSEngineVersion)
+		Expect(err).To(BeNil())
+
+		var taintedNodes int
+		for _, node := range updatedNodes {
+			if len(node.Spec.Taints) > 0 {
+				Expect(node.Spec.Taints).To(Equal([]v1.Taint{{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}}))
+				Expect(node.Labels).To(Equal(map[string]string{"team": "ml"}))
+				taintedNodes++
+			}
+		}
+		Expect(taintedNodes).NotTo(BeZero())
+	})
+
+	It("Should create the secret of the bootstrap token replacing an expired one during upgrade operation", func() {
+		cs := api.CreateMockContainerService("testcluster", "1.7.16", 1, 1, false)
+		k := cs.Properties.OrchestratorProfile.KubernetesConfig
+		k.BootstrapTokenTTL = "2h"
+		k.BootstrapToken = "abcdef.0123456789abcdef"
+		k.BootstrapTokenExpiration = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+		}
+
+		mockClient := armhelpers.MockACSEngineClient{}
+		mockClient.MockKubernetesClient = &armhelpers.MockKubernetesClient{}
+		uc.Client = &mockClient
+
+		subID, _ := uuid.FromString("DEC923E3-1EF1-4745-9516-37906D56DEC4")
+
+		err := uc.UpgradeCluster(subID, &mockClient, "kubeConfig", "TestRg", cs, "12345678", []string{"agentpool1"}, TestACSEngineVersion)
+		Expect(err).To(BeNil())
+
+		k = cs.Properties.OrchestratorProfile.KubernetesConfig
+		Expect(k.BootstrapToken).NotTo(Equal("abcdef.0123456789abcdef"))
+		Expect(mockClient.MockKubernetesClient.Applied).To(HaveLen(1))
+		secret, ok := mockClient.MockKubernetesClient.Applied[0].(*v1.Secret)
+		Expect(ok).To(BeTrue())
+		Expect(secret.Name).To(Equal("bootstrap-token-" + k.GetBootstrapTokenID()))
+		Expect(secret.StringData["token-secret"]).To(Equal(k.GetBootstrapTokenSecret()))
+	})
+
+	It("Should visit the agent pools in the upgrade order", func() {
+		pool := func(identifier, name string) *AgentPoolTopology {
+			return &AgentPoolTopology{Identifier: &identifier, Name: &name}
+		}
+		ku := &Upgrader{}
+		ku.ClusterTopology = ClusterTopology{
+			AgentPools: map[string]*AgentPoolTopology{
+				"k8s-user1-12345678":  pool("k8s-user1-12345678", "user1"),
+				"k8s-system-12345678": pool("k8s-system-12345678", "system"),
+				"k8s-user2-12345678":  pool("k8s-user2-12345678", "user2"),
+				"k8s-extra-12345678":  pool("k8s-extra-12345678", "extra"),
+			},
+			AgentPoolScaleSetsToUpgrade: []AgentPoolScaleSet{
+				{Name: "k8s-user1-12345678-vmss", PoolName: "user1"},
+				{Name: "k8s-untagged-12345678-vmss"},
+				{Name: "k8s-system-12345678-vmss", PoolName: "system"},
+				{Name: "k8s-user2-12345678-vmss", PoolName: "user2"},
+			},
+			AgentPoolsUpgradeOrder: []string{"system", "user2", "user1"},
+		}
+
+		var agentPools []string
+		for _, agentPool := range ku.agentPoolsInUpgradeOrder() {
+			agentPools = append(agentPools, *agentPool.Name)
+		}
+		Expect(agentPools).To(Equal([]string{"system", "user2", "user1", "extra"}))
+
+		var scaleSets []string
+		for _, scaleSet := range ku.scaleSetsInUpgradeOrder() {
+			scaleSets = append(scaleSets, scaleSet.Name)
+		}
+		Expect(scaleSets).To(Equal([]string{"k8s-system-12345678-vmss", "k8s-user2-12345678-vmss", "k8s-user1-12345678-vmss", "k8s-untagged-12345678-vmss"}))
+		Expect(ku.ClusterTopology.AgentPoolScaleSetsToUpgrade[0].Name).To(Equal("k8s-user1-12345678-vmss"))
+	})
+
+	It("Should record the upgrade order of the agent pools during upgrade operation", func() {
+		cs := api.CreateMockContainerService("testcluster", "1.7.16", 1, 1, false)
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+		}
+
+		mockClient := armhelpers.MockACSEngineClient{}
+		uc.Client = &mockClient
+
+		subID, _ := uuid.FromString("DEC923E3-1EF1-4745-9516-37906D56DEC4")
+
+		err := uc.UpgradeCluster(subID, nil, "kubeConfig", "TestRg", cs, "12345678", []string{"agentpool1", "agentpool2"}, TestACSEngineVersion)
+		Expect(err).To(BeNil())
+		Expect(uc.ClusterTopology.AgentPoolsUpgradeOrder).To(Equal([]string{"agentpool1", "agentpool2"}))
+	})
+
+	It("Should only recreate the VMs of the named agent pool during agent pool upgrade operation", func() {
+		cs := mockTwoPoolsContainerService("1.7.16")
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+		}
+
+		mockClient := armhelpers.MockACSEngineClient{}
+		mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+			return []compute.VirtualMachine{
+				mockClusterVM("k8s-master-12345678-0", "Kubernetes:1.7.16", ""),
+				mockClusterVM("k8s-agentpool1-12345678-0", "Kubernetes:1.7.9", "agentpool1"),
+				mockClusterVM("k8s-agentpool2-12345678-0", "Kubernetes:1.7.9", "agentpool2"),
+			}
+		}
+		uc.Client = &mockClient
+
+		subID, _ := uuid.FromString("DEC923E3-1EF1-4745-9516-37906D56DEC4")
+
+		err := uc.UpgradeAgentPool(subID, &mockClient, "kubeConfig", "TestRg", cs, "12345678", "agentpool2", TestACSEngineVersion)
+		Expect(err).To(BeNil())
+		Expect(uc.ClusterTopology.ControlPlaneVersion).To(Equal("1.7.16"))
+		Expect(*uc.ClusterTopology.MasterVMs).To(BeEmpty())
+		Expect(uc.ClusterTopology.AgentPools).To(HaveLen(1))
+		Expect(uc.ClusterTopology.AgentPools).To(HaveKey("agentpool2"))
+		Expect(mockClient.DeletedVirtualMachines).To(Equal([]string{"k8s-agentpool2-12345678-0"}))
+	})
+
+	It("Should return error message when the agent pool would be newer than the control plane during agent pool upgrade operation", func() {
+		cs := mockTwoPoolsContainerService("1.7.16")
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+		}
+
+		mockClient := armhelpers.MockACSEngineClient{}
+		mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+			return []compute.VirtualMachine{
+				mockClusterVM("k8s-master-12345678-0", "Kubernetes:1.7.9", ""),
+				mockClusterVM("k8s-agentpool1-12345678-0", "Kubernetes:1.7.9", "agentpool1"),
+				mockClusterVM("k8s-agentpool2-12345678-0", "Kubernetes:1.7.9", "agentpool2"),
+			}
+		}
+		uc.Client = &mockClient
+
+		subID, _ := uuid.FromString("DEC923E3-1EF1-4745-9516-37906D56DEC4")
+
+		err := uc.UpgradeAgentPool(subID, &mockClient, "kubeConfig", "TestRg", cs, "12345678", "agentpool2", TestACSEngineVersion)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("agent pool agentpool2 cannot be upgraded to 1.7.16, which is newer than the control plane version 1.7.9"))
+		Expect(mockClient.DeletedVirtualMachines).To(BeEmpty())
+	})
+
+	It("Should return error message when the agent pool is not in the api model during agent pool upgrade operation", func() {
+		cs := mockTwoPoolsContainerService("1.7.16")
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+		}
+
+		mockClient := armhelpers.MockACSEngineClient{}
+		uc.Client = &mockClient
+
+		subID, _ := uuid.FromString("DEC923E3-1EF1-4745-9516-37906D56DEC4")
+
+		err := uc.UpgradeAgentPool(subID, &mockClient, "kubeConfig", "TestRg", cs, "12345678", "agentpool3", TestACSEngineVersion)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("agent pool agentpool3 is not in the api model"))
+	})
+
+	It("Should keep the agent pools within two minor versions of the control plane", func() {
+		uc := UpgradeCluster{}
+		uc.ClusterTopology = ClusterTopology{
+			DataModel:           api.CreateMockContainerService("testcluster", "1.9.10", 1, 1, false),
+			ControlPlaneVersion: "1.11.5",
+		}
+		Expect(uc.validateControlPlaneVersionSkew("agentpool1")).To(BeNil())
+
+		uc.ClusterTopology.ControlPlaneVersion = "1.12.2"
+		err := uc.validateControlPlaneVersionSkew("agentpool1")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("agent pool agentpool1 cannot be upgraded to 1.9.10, which is more than two minor versions older than the control plane version 1.12.2"))
+
+		uc.ClusterTopology.ControlPlaneVersion = ""
+		err = uc.validateControlPlaneVersionSkew("agentpool1")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("agent pool agentpool1 cannot be upgraded on its own, the version of the control plane could not be determined"))
+	})
+
+	It("Should only deploy the scale set of the named agent pool during agent pool upgrade operation", func() {
+		ku := &Upgrader{}
+		ku.ClusterTopology = ClusterTopology{
+			AgentPoolsToUpgrade: map[string]bool{"agentpool2": true},
+			AgentPoolOnly:       true,
+		}
+		templateMap := map[string]interface{}{
+			"resources": []interface{}{
+				map[string]interface{}{"type": "Microsoft.Network/virtualNetworks", "name": "vnet"},
+				map[string]interface{}{"type": "Microsoft.Compute/virtualMachineScaleSets", "name": "agentpool1", "tags": map[string]interface{}{"poolName": "agentpool1"}},
+				map[string]interface{}{"type": "Microsoft.Compute/virtualMachineScaleSets", "name": "agentpool2", "tags": map[string]interface{}{"poolName": "agentpool2"}},
+			},
+		}
+		ku.removeScaleSetsNotUpgraded(templateMap)
+
+		var names []string
+		for _, resource := range templateMap["resources"].([]interface{}) {
+			names = append(names, resource.(map[string]interface{})["name"].(string))
+		}
+		Expect(names).To(Equal([]string{"vnet", "agentpool2"}))
+	})
+
+	It("Should create the surge nodes before deleting the nodes they replace", func() {
+		agentVMs := map[int]*vmInfo{}
+		for i := 0; i < 5; i++ {
+			agentVMs[i] = &vmInfo{fmt.Sprintf("k8s-agentpool1-12345678-%d", i), vmStatusNotUpgraded}
+		}
+		var operations []string
+		running, maxRunning := 5, 5
+		createNode := func(agentIndex int) (string, error) {
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			operations = append(operations, fmt.Sprintf("create %d", agentIndex))
+			return fmt.Sprintf("k8s-agentpool1-12345678-%d", agentIndex), nil
+		}
+		deleteNode := func(vmName string) error {
+			running--
+			Expect(running).To(BeNumerically(">=", 5))
+			operations = append(operations, "delete "+vmName)
+			return nil
+		}
+
+		err := surgeAgentNodes(agentVMs, 5, 2, createNode, deleteNode)
+		Expect(err).To(BeNil())
+		Expect(operations).To(Equal([]string{
+			"create 5", "create 6", "delete k8s-agentpool1-12345678-0", "delete k8s-agentpool1-12345678-1",
+			"create 0", "create 1", "delete k8s-agentpool1-12345678-2", "delete k8s-agentpool1-12345678-3",
+			"create 2", "delete k8s-agentpool1-12345678-4",
+		}))
+		Expect(maxRunning).To(Equal(7))
+		Expect(running).To(Equal(5))
+		Expect(agentVMs).To(HaveLen(5))
+		for _, vm := range agentVMs {
+			Expect(vm.status).To(Equal(vmStatusUpgraded))
+		}
+	})
+
+	It("Should remove the surplus nodes of an interrupted surge before creating more", func() {
+		agentVMs := map[int]*vmInfo{
+			0: {"k8s-agentpool1-12345678-0", vmStatusNotUpgraded},
+			1: {"k8s-agentpool1-12345678-1", vmStatusNotUpgraded},
+			2: {"k8s-agentpool1-12345678-2", vmStatusNotUpgraded},
+			3: {"k8s-agentpool1-12345678-3", vmStatusUpgraded},
+			4: {"k8s-agentpool1-12345678-4", vmStatusUpgraded},
+		}
+		var operations []string
+		createNode := func(agentIndex int) (string, error) {
+			operations = append(operations, fmt.Sprintf("create %d", agentIndex))
+			return fmt.Sprintf("k8s-agentpool1-12345678-%d", agentIndex), nil
+		}
+		deleteNode := func(vmName string) error {
+			operations = append(operations, "delete "+vmName)
+			return nil
+		}
+
+		err := surgeAgentNodes(agentVMs, 3, 2, createNode, deleteNode)
+		Expect(err).To(BeNil())
+		Expect(operations).To(Equal([]string{
+			"delete k8s-agentpool1-12345678-0", "delete k8s-agentpool1-12345678-1",
+			"create 0", "delete k8s-agentpool1-12345678-2",
+		}))
+		Expect(agentVMs).To(HaveLen(3))
+	})
+
+	It("Should stop the surge when a surge node fails to be created", func() {
+		agentVMs := map[int]*vmInfo{
+			0: {"k8s-agentpool1-12345678-0", vmStatusNotUpgraded},
+			1: {"k8s-agentpool1-12345678-1", vmStatusNotUpgraded},
+		}
+		var deleted []string
+		createNode := func(agentIndex int) (string, error) {
+			return "", fmt.Errorf("DeployTemplate failed")
+		}
+		deleteNode := func(vmName string) error {
+			deleted = append(deleted, vmName)
+			return nil
+		}
+
+		err := surgeAgentNodes(agentVMs, 2, 1, createNode, deleteNode)
+		Expect(err).NotTo(BeNil())
+		Expect(deleted).To(BeEmpty())
+		Expect(agentVMs).To(HaveLen(2))
+	})
+
+	It("Should replace the agent nodes with a surge during upgrade operation", func() {
+		cs := api.CreateMockContainerService("testcluster", "1.7.16", 1, 3, false)
+		uc := UpgradeCluster{
+			Translator: &i18n.Translator{},
+			Logger:     log.NewEntry(log.New()),
+			MaxSurge:   2,
+		}
+
+		mockClient := armhelpers.MockACSEngineClient{}
+		mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+			return []compute.VirtualMachine{
+				mockClusterVM("k8s-master-12345678-0", "Kubernetes:1.7.16", ""),
+				mockClusterVM("k8s-agentpool1-12345678-0", "Kubernetes:1.7.9", "agentpool1"),
+				mockClusterVM("k8s-agentpool1-12345678-1", "Kubernetes:1.7.9", "agentpool1"),
+				mockClusterVM("k8s-agentpool1-12345678-2", "Kubernetes:1.7.9", "agentpool1"),
+			}
+		}
+		uc.Client = &mockClient
+
+		subID, _ := uuid.FromString("DEC923E3-1EF1-4745-9516-37906D56DEC4")
+
+		err := uc.UpgradeCluster(subID, &mockClient, "kubeConfig", "TestRg", cs, "12345678", []string{"agentpool1"}, TestACSEngineVersion)
+		Expect(err).To(BeNil())
+		Expect(mockClient.DeletedVirtualMachines).To(Equal([]string{
+			"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1", "k8s-agentpool1-12345678-2",
+		}))
+	})
 })
@@ -13,6 +13,7 @@ import (
 	"github.com/Azure/acs-engine/pkg/armhelpers"
 	"github.com/Azure/acs-engine/pkg/i18n"
 	"github.com/Azure/acs-engine/pkg/operations"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -66,7 +67,8 @@ func (kmn *UpgradeMasterNode) CreateNode(ctx context.Context, poolName string, m
 		kmn.ResourceGroup,
 		deploymentName,
 		kmn.TemplateMap,
-		kmn.ParametersMap)
+		kmn.ParametersMap,
+		resources.Incremental)
 	return err
 }
 
@@ -82,6 +84,7 @@ func (kmn *UpgradeMasterNode) Validate(vmName *string) error {
 		return nil
 	}
 
+	logger := kmn.logger.WithField(operations.LogFieldNode, *vmName)
 	masterURL := kmn.UpgradeContainerService.Properties.MasterProfile.FQDN
 
 	client, err := kmn.Client.GetKubernetesClient(masterURL, kmn.kubeConfig, interval, kmn.timeout)
@@ -94,13 +97,13 @@ func (kmn *UpgradeMasterNode) Validate(vmName *string) error {
 		for {
 			masterNode, err := client.GetNode(*vmName)
 			if err != nil {
-				kmn.logger.Infof("Master VM: %s status error: %v\n", *vmName, err)
+				logger.Infof("Master VM status error: %v", err)
 				time.Sleep(time.Second * 5)
-			} else if isNodeReady(masterNode) {
-				kmn.logger.Infof("Master VM: %s is ready", *vmName)
+			} else if operations.IsNodeReady(masterNode) {
+				logger.Info("Master VM is ready")
 				ch <- struct{}{}
 			} else {
-				kmn.logger.Infof("Master VM: %s not ready yet...", *vmName)
+				logger.Info("Master VM not ready yet...")
 				time.Sleep(time.Second * 5)
 			}
 		}
@@ -111,7 +114,7 @@ func (kmn *UpgradeMasterNode) Validate(vmName *string) error {
 		case <-ch:
 			return nil
 		case <-time.After(kmn.timeout):
-			kmn.logger.Errorf("Node was not ready within %v", kmn.timeout)
+			logger.Errorf("Node was not ready within %v", kmn.timeout)
 			return errors.Errorf("Node was not ready within %v", kmn.timeout)
 		}
 	}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/Azure/acs-engine/pkg/acsengine"
@@ -17,8 +18,8 @@ import (
 	"github.com/Azure/acs-engine/pkg/armhelpers/utils"
 	"github.com/Azure/acs-engine/pkg/i18n"
 	"github.com/Azure/acs-engine/pkg/operations"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/sirupsen/logrus"
-	"k8s.io/api/core/v1"
 )
 
 // Upgrader holds information on upgrading an ACS cluster
@@ -30,6 +31,9 @@ type Upgrader struct {
 	kubeConfig       string
 	stepTimeout      *time.Duration
 	ACSEngineVersion string
+	// MaxSurge is the number of agent nodes created at the target version before the nodes they replace are
+	// drained and deleted, 0 replaces the nodes one at a time with a single extra node
+	MaxSurge int
 }
 
 type vmStatus int
@@ -61,8 +65,10 @@ func (ku *Upgrader) Init(translator *i18n.Translator, logger *logrus.Entry, clus
 func (ku *Upgrader) RunUpgrade() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Minute)
 	defer cancel()
-	if err := ku.upgradeMasterNodes(ctx); err != nil {
-		return err
+	if !ku.ClusterTopology.AgentPoolOnly {
+		if err := ku.upgradeMasterNodes(ctx); err != nil {
+			return err
+		}
 	}
 
 	if err := ku.upgradeAgentScaleSets(ctx); err != nil {
@@ -134,31 +140,32 @@ func (ku *Upgrader) upgradeMasterNodes(ctx context.Context) error {
 	upgradedMastersIndex := make(map[int]bool)
 
 	for _, vm := range *ku.ClusterTopology.UpgradedMasterVMs {
-		ku.logger.Infof("Master VM: %s is upgraded to expected orchestrator version", *vm.Name)
+		ku.logger.WithField(operations.LogFieldNode, *vm.Name).Info("Master VM is upgraded to expected orchestrator version")
 		masterIndex, _ := utils.GetVMNameIndex(vm.StorageProfile.OsDisk.OsType, *vm.Name)
 		upgradedMastersIndex[masterIndex] = true
 	}
 
 	for _, vm := range *ku.ClusterTopology.MasterVMs {
-		ku.logger.Infof("Upgrading Master VM: %s", *vm.Name)
+		logger := ku.logger.WithField(operations.LogFieldNode, *vm.Name)
+		logger.Info("Upgrading Master VM")
 
 		masterIndex, _ := utils.GetVMNameIndex(vm.StorageProfile.OsDisk.OsType, *vm.Name)
 
 		err := upgradeMasterNode.DeleteNode(vm.Name, false)
 		if err != nil {
-			ku.logger.Infof("Error deleting master VM: %s, err: %v", *vm.Name, err)
+			logger.Infof("Error deleting master VM, err: %v", err)
 			return err
 		}
 
 		err = upgradeMasterNode.CreateNode(ctx, "master", masterIndex)
 		if err != nil {
-			ku.logger.Infof("Error creating upgraded master VM: %s", *vm.Name)
+			logger.Info("Error creating upgraded master VM")
 			return err
 		}
 
 		err = upgradeMasterNode.Validate(vm.Name)
 		if err != nil {
-			ku.logger.Infof("Error validating upgraded master VM: %s", *vm.Name)
+			logger.Info("Error validating upgraded master VM")
 			return err
 		}
 
@@ -205,7 +212,7 @@ func (ku *Upgrader) upgradeMasterNodes(ctx context.Context) error {
 }
 
 func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
-	for _, agentPool := range ku.ClusterTopology.AgentPools {
+	for _, agentPool := range ku.agentPoolsInUpgradeOrder() {
 		// Upgrade Agent VMs
 		templateMap, parametersMap, err := ku.generateUpgradeTemplate(ku.ClusterTopology.DataModel, ku.ACSEngineVersion)
 		if err != nil {
@@ -239,7 +246,7 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 
 		if agentCount == 0 {
 			ku.logger.Infof("Agent pool '%s' is empty", *agentPool.Name)
-			return nil
+			continue
 		}
 
 		upgradeAgentNode := UpgradeAgentNode{
@@ -249,6 +256,7 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 		upgradeAgentNode.TemplateMap = templateMap
 		upgradeAgentNode.ParametersMap = parametersMap
 		upgradeAgentNode.UpgradeContainerService = ku.ClusterTopology.DataModel
+		upgradeAgentNode.AgentPoolProfile = ku.ClusterTopology.DataModel.Properties.AgentPoolProfiles[agentPoolIndex]
 		upgradeAgentNode.SubscriptionID = ku.ClusterTopology.SubscriptionID
 		upgradeAgentNode.ResourceGroup = ku.ClusterTopology.ResourceGroup
 		upgradeAgentNode.Client = ku.Client
@@ -309,7 +317,8 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 
 		// Create missing nodes to match agentCount. This could be due to previous upgrade failure
 		// If there are nodes that need to be upgraded, create one extra node, which will be used to take on the load from upgrading nodes.
-		if toBeUpgradedCount > 0 {
+		// With a surge, the surge nodes take on the load instead.
+		if toBeUpgradedCount > 0 && ku.MaxSurge == 0 {
 			agentCount++
 		}
 		for upgradedCount+toBeUpgradedCount < agentCount {
@@ -339,8 +348,40 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 		}
 
 		if toBeUpgradedCount == 0 {
-			ku.logger.Infof("No nodes to upgrade")
-			return nil
+			ku.logger.Infof("No nodes to upgrade in pool %s", *agentPool.Name)
+			continue
+		}
+
+		if ku.MaxSurge > 0 {
+			createNode := func(agentIndex int) (string, error) {
+				vmName, err := utils.GetK8sVMName(ku.DataModel.Properties, agentPoolIndex, agentIndex)
+				if err != nil {
+					ku.logger.Errorf("Error reconstructing agent VM name with index %d: %v", agentIndex, err)
+					return "", err
+				}
+				ku.logger.WithField(operations.LogFieldNode, vmName).Infof("Creating surge agent VM (index %d), pool name: %s", agentIndex, *agentPool.Name)
+				if err = upgradeAgentNode.CreateNode(ctx, *agentPool.Name, agentIndex); err != nil {
+					ku.logger.WithField(operations.LogFieldNode, vmName).Errorf("Error creating upgraded agent VM: %v", err)
+					return "", err
+				}
+				if err = upgradeAgentNode.Validate(&vmName); err != nil {
+					ku.logger.WithField(operations.LogFieldNode, vmName).Errorf("Error validating upgraded agent VM: %v", err)
+					return "", err
+				}
+				return vmName, nil
+			}
+			deleteNode := func(vmName string) error {
+				ku.logger.WithField(operations.LogFieldNode, vmName).Infof("Removing replaced agent VM, pool name: %s", *agentPool.Name)
+				if err := upgradeAgentNode.DeleteNode(&vmName, true); err != nil {
+					ku.logger.WithField(operations.LogFieldNode, vmName).Errorf("Error deleting agent VM: %v", err)
+					return err
+				}
+				return nil
+			}
+			if err := surgeAgentNodes(agentVMs, agentCount, ku.MaxSurge, createNode, deleteNode); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Upgrade nodes in agent pool
@@ -349,11 +390,11 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 			if vm.status != vmStatusNotUpgraded {
 				continue
 			}
-			ku.logger.Infof("Upgrading Agent VM: %s, pool name: %s", vm.name, *agentPool.Name)
+			ku.logger.WithField(operations.LogFieldNode, vm.name).Infof("Upgrading Agent VM, pool name: %s", *agentPool.Name)
 
 			err := upgradeAgentNode.DeleteNode(&vm.name, true)
 			if err != nil {
-				ku.logger.Errorf("Error deleting agent VM %s: %v", vm.name, err)
+				ku.logger.WithField(operations.LogFieldNode, vm.name).Errorf("Error deleting agent VM: %v", err)
 				return err
 			}
 
@@ -365,18 +406,18 @@ func (ku *Upgrader) upgradeAgentPools(ctx context.Context) error {
 
 			// do not create last node in favor of already created extra node.
 			if upgradedCount == toBeUpgradedCount-1 {
-				ku.logger.Infof("Skipping creation of VM %s (index %d)", vmName, agentIndex)
+				ku.logger.WithField(operations.LogFieldNode, vmName).Infof("Skipping creation of VM (index %d)", agentIndex)
 				delete(agentVMs, agentIndex)
 			} else {
 				err = upgradeAgentNode.CreateNode(ctx, *agentPool.Name, agentIndex)
 				if err != nil {
-					ku.logger.Errorf("Error creating upgraded agent VM %s: %v", vmName, err)
+					ku.logger.WithField(operations.LogFieldNode, vmName).Errorf("Error creating upgraded agent VM: %v", err)
 					return err
 				}
 
 				err = upgradeAgentNode.Validate(&vmName)
 				if err != nil {
-					ku.logger.Errorf("Error validating upgraded agent VM %s: %v", vmName, err)
+					ku.logger.WithField(operations.LogFieldNode, vmName).Errorf("Error validating upgraded agent VM: %v", err)
 					return err
 				}
 				vm.status = vmStatusUpgraded
@@ -407,6 +448,9 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 			ku.logger.Errorf("unable to update template, error: %v.", err)
 			return err
 		}
+		if ku.ClusterTopology.AgentPoolOnly {
+			ku.removeScaleSetsNotUpgraded(templateMap)
+		}
 
 		random := rand.New(rand.NewSource(time.Now().UnixNano()))
 		deploymentSuffix := random.Int31()
@@ -418,7 +462,8 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 			ku.ClusterTopology.ResourceGroup,
 			deploymentName,
 			templateMap,
-			parametersMap)
+			parametersMap,
+			resources.Incremental)
 
 		if err != nil {
 			ku.logger.Errorf("error applying upgrade template in upgradeAgentScaleSets: %v", err)
@@ -426,7 +471,7 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 		}
 	}
 
-	for _, vmssToUpgrade := range ku.ClusterTopology.AgentPoolScaleSetsToUpgrade {
+	for _, vmssToUpgrade := range ku.scaleSetsInUpgradeOrder() {
 		ku.logger.Infof("Upgrading VMSS %s", vmssToUpgrade.Name)
 
 		if len(vmssToUpgrade.VMsToUpgrade) == 0 {
@@ -434,17 +479,31 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 			continue
 		}
 
-		newCapacity := *vmssToUpgrade.Sku.Capacity + 1
-		ku.logger.Infof(
-			"VMSS %s current capacity is %d and new capacity will be %d while each node is swapped",
-			vmssToUpgrade.Name,
-			*vmssToUpgrade.Sku.Capacity,
-			newCapacity,
-		)
+		// The nodes are swapped in batches of the surge size, the capacity is raised by the size of a batch
+		// before its nodes are drained and deleted
+		surge := 1
+		if ku.MaxSurge > 0 {
+			surge = ku.MaxSurge
+		}
+		capacity := *vmssToUpgrade.Sku.Capacity
+		vmsToUpgrade := vmssToUpgrade.VMsToUpgrade
+		for len(vmsToUpgrade) > 0 {
+			batch := vmsToUpgrade
+			if len(batch) > surge {
+				batch = batch[:surge]
+			}
+			vmsToUpgrade = vmsToUpgrade[len(batch):]
 
-		*vmssToUpgrade.Sku.Capacity = newCapacity
+			newCapacity := capacity + int64(len(batch))
+			ku.logger.Infof(
+				"VMSS %s current capacity is %d and new capacity will be %d while %d nodes are swapped",
+				vmssToUpgrade.Name,
+				capacity,
+				newCapacity,
+				len(batch),
+			)
+			*vmssToUpgrade.Sku.Capacity = newCapacity
 
-		for _, vmToUpgrade := range vmssToUpgrade.VMsToUpgrade {
 			if err := ku.Client.SetVirtualMachineScaleSetCapacity(
 				ctx,
 				ku.ClusterTopology.ResourceGroup,
@@ -458,63 +517,56 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 
 			ku.logger.Infof("Successfully set capacity for VMSS %s", vmssToUpgrade.Name)
 
-			// Before we can delete the node we should safely and responsibly drain it
-			var kubeAPIServerURL string
-			getClientTimeout := 10 * time.Second
+			for _, vmToUpgrade := range batch {
+				// Before we can delete the node we should safely and responsibly drain it
+				var kubeAPIServerURL string
+				getClientTimeout := 10 * time.Second
 
-			if ku.DataModel.Properties.HostedMasterProfile != nil {
-				kubeAPIServerURL = ku.DataModel.Properties.HostedMasterProfile.FQDN
-			} else {
-				kubeAPIServerURL = ku.DataModel.Properties.MasterProfile.FQDN
-			}
-			client, err := ku.Client.GetKubernetesClient(
-				kubeAPIServerURL,
-				ku.kubeConfig,
-				interval,
-				getClientTimeout,
-			)
-			if err != nil {
-				ku.logger.Errorf("Error getting Kubernetes client: %v", err)
-				return err
-			}
+				if ku.DataModel.Properties.HostedMasterProfile != nil {
+					kubeAPIServerURL = ku.DataModel.Properties.HostedMasterProfile.FQDN
+				} else {
+					kubeAPIServerURL = ku.DataModel.Properties.MasterProfile.FQDN
+				}
+				client, err := ku.Client.GetKubernetesClient(
+					kubeAPIServerURL,
+					ku.kubeConfig,
+					interval,
+					getClientTimeout,
+				)
+				if err != nil {
+					ku.logger.Errorf("Error getting Kubernetes client: %v", err)
+					return err
+				}
 
-			ku.logger.Infof("Draining node %s", vmToUpgrade.Name)
-			err = operations.SafelyDrainNodeWithClient(
-				client,
-				ku.logger,
-				vmToUpgrade.Name,
-				time.Minute,
-			)
-			if err != nil {
-				ku.logger.Errorf("Error draining VM in VMSS: %v", err)
-				return err
-			}
+				logger := ku.logger.WithField(operations.LogFieldNode, vmToUpgrade.Name)
+				logger.Info("Draining node")
+				err = operations.SafelyDrainNodeWithClient(
+					client,
+					ku.logger,
+					vmToUpgrade.Name,
+					time.Minute,
+				)
+				if err != nil {
+					logger.Errorf("Error draining VM in VMSS: %v", err)
+					return err
+				}
 
-			ku.logger.Infof(
-				"Deleting VM %s in VMSS %s",
-				vmToUpgrade.Name,
-				vmssToUpgrade.Name,
-			)
+				logger.Infof("Deleting VM in VMSS %s", vmssToUpgrade.Name)
+
+				// At this point we have our buffer node that will replace the node to delete
+				// so we can just remove this current node then
+				if err := ku.Client.DeleteVirtualMachineScaleSetVM(
+					ctx,
+					ku.ClusterTopology.ResourceGroup,
+					vmssToUpgrade.Name,
+					vmToUpgrade.InstanceID,
+				); err != nil {
+					logger.Errorf("Failed to delete VM in VMSS %s", vmssToUpgrade.Name)
+					return err
+				}
 
-			// At this point we have our buffer node that will replace the node to delete
-			// so we can just remove this current node then
-			if err := ku.Client.DeleteVirtualMachineScaleSetVM(
-				ctx,
-				ku.ClusterTopology.ResourceGroup,
-				vmssToUpgrade.Name,
-				vmToUpgrade.InstanceID,
-			); err != nil {
-				ku.logger.Errorf(
-					"Failed to delete VM %s in VMSS %s",
-					vmToUpgrade.Name,
-					vmssToUpgrade.Name)
-				return err
+				logger.Infof("Successfully deleted VM in VMSS %s", vmssToUpgrade.Name)
 			}
-
-			ku.logger.Infof(
-				"Successfully deleted VM %s in VMSS %s",
-				vmToUpgrade.Name,
-				vmssToUpgrade.Name)
 		}
 		ku.logger.Infof("Completed upgrading VMSS %s", vmssToUpgrade.Name)
 	}
@@ -534,11 +586,22 @@ func (ku *Upgrader) generateUpgradeTemplate(upgradeContainerService *api.Contain
 		return nil, nil, ku.Translator.Errorf("failed to initialize template generator: %s", err.Error())
 	}
 
+	var bootstrapToken string
+	if upgradeContainerService.Properties.OrchestratorProfile.KubernetesConfig != nil {
+		bootstrapToken = upgradeContainerService.Properties.OrchestratorProfile.KubernetesConfig.BootstrapToken
+	}
 	_, err = upgradeContainerService.SetPropertiesDefaults(true, false)
 	if err != nil {
 		return nil, nil, ku.Translator.Errorf("error in SetPropertiesDefaults: %s", err.Error())
 
 	}
+	// the masters don't know the token generated as the previous one expired, the upgraded nodes can only
+	// join with it once its secret is created
+	if k := upgradeContainerService.Properties.OrchestratorProfile.KubernetesConfig; k.IsBootstrapTokenEnabled() && k.BootstrapToken != bootstrapToken {
+		if err = ku.applyBootstrapToken(k); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	var templateJSON string
 	var parametersJSON string
@@ -556,6 +619,135 @@ func (ku *Upgrader) generateUpgradeTemplate(upgradeContainerService *api.Contain
 	return templateMap, parametersMap, nil
 }
 
+// applyBootstrapToken creates the secret of the bootstrap token of the cluster through its API server
+func (ku *Upgrader) applyBootstrapToken(k *api.KubernetesConfig) error {
+	var kubeAPIServerURL string
+	if ku.DataModel.Properties.HostedMasterProfile != nil {
+		kubeAPIServerURL = ku.DataModel.Properties.HostedMasterProfile.FQDN
+	} else {
+		kubeAPIServerURL = ku.DataModel.Properties.MasterProfile.FQDN
+	}
+	client, err := ku.Client.GetKubernetesClient(kubeAPIServerURL, ku.kubeConfig, interval, 10*time.Second)
+	if err != nil {
+		ku.logger.Errorf("Error getting Kubernetes client: %v", err)
+		return err
+	}
+	return operations.ApplyBootstrapToken(client, ku.logger, k)
+}
+
+// removeScaleSetsNotUpgraded removes the scale sets of the agent pools not being upgraded from the template,
+// so that their models keep the orchestrator version of their nodes
+func (ku *Upgrader) removeScaleSetsNotUpgraded(templateMap map[string]interface{}) {
+	resources := []interface{}{}
+	for _, resource := range templateMap["resources"].([]interface{}) {
+		if resourceMap, ok := resource.(map[string]interface{}); ok && resourceMap["type"] == "Microsoft.Compute/virtualMachineScaleSets" {
+			tags, _ := resourceMap["tags"].(map[string]interface{})
+			if poolName, _ := tags["poolName"].(string); !ku.ClusterTopology.AgentPoolsToUpgrade[poolName] {
+				continue
+			}
+		}
+		resources = append(resources, resource)
+	}
+	templateMap["resources"] = resources
+}
+
+// agentPoolsInUpgradeOrder returns the agent pools of availability sets in the upgrade order of their names
+func (ku *Upgrader) agentPoolsInUpgradeOrder() []*AgentPoolTopology {
+	agentPools := make([]*AgentPoolTopology, 0, len(ku.ClusterTopology.AgentPools))
+	for _, agentPool := range ku.ClusterTopology.AgentPools {
+		agentPools = append(agentPools, agentPool)
+	}
+	sort.Slice(agentPools, func(i, j int) bool {
+		ri, rj := ku.upgradeRank(*agentPools[i].Name), ku.upgradeRank(*agentPools[j].Name)
+		if ri != rj {
+			return ri < rj
+		}
+		return *agentPools[i].Identifier < *agentPools[j].Identifier
+	})
+	return agentPools
+}
+
+// scaleSetsInUpgradeOrder returns the agent pool scale sets in the upgrade order of their pools, the scale sets
+// of the same rank keep the order ARM listed them in
+func (ku *Upgrader) scaleSetsInUpgradeOrder() []AgentPoolScaleSet {
+	scaleSets := make([]AgentPoolScaleSet, len(ku.ClusterTopology.AgentPoolScaleSetsToUpgrade))
+	copy(scaleSets, ku.ClusterTopology.AgentPoolScaleSetsToUpgrade)
+	sort.SliceStable(scaleSets, func(i, j int) bool {
+		return ku.upgradeRank(scaleSets[i].PoolName) < ku.upgradeRank(scaleSets[j].PoolName)
+	})
+	return scaleSets
+}
+
+// upgradeRank returns the position of an agent pool in the upgrade order, the pools missing from it are upgraded last
+func (ku *Upgrader) upgradeRank(poolName string) int {
+	for i, name := range ku.ClusterTopology.AgentPoolsUpgradeOrder {
+		if name == poolName {
+			return i
+		}
+	}
+	return len(ku.ClusterTopology.AgentPoolsUpgradeOrder)
+}
+
+// surgeAgentNodes replaces the agent nodes that aren't upgraded in batches of at most maxSurge nodes: the nodes of a
+// batch are created at the target version and validated before the nodes they replace are drained and deleted, so the
+// pool never runs below agentCount nodes nor above agentCount plus maxSurge. Surplus nodes left by an interrupted
+// surge are removed first.
+func surgeAgentNodes(agentVMs map[int]*vmInfo, agentCount, maxSurge int, createNode func(agentIndex int) (string, error), deleteNode func(vmName string) error) error {
+	var notUpgraded []int
+	running := 0
+	for agentIndex, vm := range agentVMs {
+		switch vm.status {
+		case vmStatusNotUpgraded:
+			notUpgraded = append(notUpgraded, agentIndex)
+			running++
+		case vmStatusUpgraded:
+			running++
+		}
+	}
+	sort.Ints(notUpgraded)
+
+	replace := func(count int) error {
+		for _, agentIndex := range notUpgraded[:count] {
+			if err := deleteNode(agentVMs[agentIndex].name); err != nil {
+				return err
+			}
+			delete(agentVMs, agentIndex)
+			running--
+		}
+		notUpgraded = notUpgraded[count:]
+		return nil
+	}
+
+	if surplus := running - agentCount; surplus > 0 {
+		if surplus > len(notUpgraded) {
+			surplus = len(notUpgraded)
+		}
+		if err := replace(surplus); err != nil {
+			return err
+		}
+	}
+
+	for len(notUpgraded) > 0 {
+		batch := maxSurge
+		if batch > len(notUpgraded) {
+			batch = len(notUpgraded)
+		}
+		for i := 0; i < batch; i++ {
+			agentIndex := getAvailableIndex(agentVMs)
+			vmName, err := createNode(agentIndex)
+			if err != nil {
+				return err
+			}
+			agentVMs[agentIndex] = &vmInfo{vmName, vmStatusUpgraded}
+			running++
+		}
+		if err := replace(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // return unused index within the range of agent indices, or subsequent index
 func getAvailableIndex(vms map[int]*vmInfo) int {
 	maxIndex := 0
@@ -574,14 +766,3 @@ func getAvailableIndex(vms map[int]*vmInfo) int {
 
 	return maxIndex + 1
 }
-
-// isNodeReady returns true if a node is ready; false otherwise.
-// Copied from: https://github.com/kubernetes/kubernetes/blob/886e04f1fffbb04faf8a9f9ee141143b2684ae68/pkg/api/v1/node/util.go#L40
-func isNodeReady(node *v1.Node) bool {
-	for _, c := range node.Status.Conditions {
-		if c.Type == v1.NodeReady {
-			return c.Status == v1.ConditionTrue
-		}
-	}
-	return false
-}
@@ -0,0 +1,15 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+// Fields attached to the log entries of cluster operations, so that log pipelines can
+// filter on them rather than parse them out of the messages
+const (
+	// LogFieldOperation names the operation being run, e.g. upgrade or scale
+	LogFieldOperation = "operation"
+	// LogFieldResourceGroup is the resource group of the cluster being operated on
+	LogFieldResourceGroup = "resourceGroup"
+	// LogFieldNode is the VM or Kubernetes node being operated on
+	LogFieldNode = "node"
+)
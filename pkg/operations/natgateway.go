@@ -0,0 +1,41 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+// ValidateNATGateway checks, before a cluster is generated and deployed, that the existing NAT gateway its
+// subnets are associated with exists in the subscription and in the location of the cluster, as a subnet
+// cannot use a NAT gateway of another region
+func ValidateNATGateway(ctx context.Context, client armhelpers.ACSEngineClient, properties *api.Properties, location string) error {
+	if properties.OrchestratorProfile == nil || !properties.OrchestratorProfile.KubernetesConfig.HasNATGateway() {
+		return nil
+	}
+	natGatewayID := properties.OrchestratorProfile.KubernetesConfig.NATGatewayID
+	resource, err := azure.ParseResourceID(natGatewayID)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the NAT gateway ID")
+	}
+	natGateway, err := client.GetNATGateway(ctx, resource.ResourceGroup, resource.ResourceName)
+	if err != nil {
+		return errors.Wrapf(err, "error getting NAT gateway %s", resource.ResourceName)
+	}
+	// the client only gets the NAT gateways of the subscription it was created for
+	if natGateway.ID == nil || !strings.EqualFold(*natGateway.ID, natGatewayID) {
+		return errors.Errorf("NAT gateway %s is not in the subscription of the cluster", resource.ResourceName)
+	}
+	if natGateway.Location == nil || helpers.NormalizeAzureRegion(*natGateway.Location) != helpers.NormalizeAzureRegion(location) {
+		return errors.Errorf("NAT gateway %s is not in location %s", resource.ResourceName, location)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const natGatewaysID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/egress/providers/Microsoft.Network/natGateways/"
+
+func newNATGatewayProperties(natGatewayID string) *api.Properties {
+	return &api.Properties{
+		OrchestratorProfile: &api.OrchestratorProfile{
+			OrchestratorType: api.Kubernetes,
+			KubernetesConfig: &api.KubernetesConfig{NATGatewayID: natGatewayID},
+		},
+	}
+}
+
+var _ = Describe("Existing NAT gateway validation tests", func() {
+	var client *armhelpers.MockACSEngineClient
+
+	BeforeEach(func() {
+		client = &armhelpers.MockACSEngineClient{
+			NATGateways: []armhelpers.NATGateway{
+				{ID: to.StringPtr(natGatewaysID + "westus2nat"), Name: to.StringPtr("westus2nat"), Location: to.StringPtr("West US 2")},
+				{ID: to.StringPtr(natGatewaysID + "eastusnat"), Name: to.StringPtr("eastusnat"), Location: to.StringPtr("eastus")},
+				{ID: to.StringPtr("/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/egress/providers/Microsoft.Network/natGateways/othersub"), Name: to.StringPtr("othersub"), Location: to.StringPtr("westus2")},
+			},
+		}
+	})
+
+	It("Should not get any NAT gateway when the cluster does not use one", func() {
+		client.FailGetNATGateway = true
+		Expect(ValidateNATGateway(context.Background(), client, newNATGatewayProperties(""), "westus2")).To(Succeed())
+	})
+
+	It("Should accept an existing NAT gateway in the location of the cluster", func() {
+		Expect(ValidateNATGateway(context.Background(), client, newNATGatewayProperties(natGatewaysID+"westus2nat"), "westus2")).To(Succeed())
+	})
+
+	It("Should reject a NAT gateway in another location", func() {
+		err := ValidateNATGateway(context.Background(), client, newNATGatewayProperties(natGatewaysID+"eastusnat"), "westus2")
+		Expect(err).To(MatchError("NAT gateway eastusnat is not in location westus2"))
+	})
+
+	It("Should reject a NAT gateway of another subscription", func() {
+		err := ValidateNATGateway(context.Background(), client, newNATGatewayProperties("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/egress/providers/Microsoft.Network/natGateways/othersub"), "westus2")
+		Expect(err).To(MatchError("NAT gateway othersub is not in the subscription of the cluster"))
+	})
+
+	It("Should return an error when the NAT gateway cannot be found", func() {
+		err := ValidateNATGateway(context.Background(), client, newNATGatewayProperties(natGatewaysID+"missing"), "westus2")
+		Expect(err).To(MatchError("error getting NAT gateway missing: NAT gateway missing not found in resource group egress"))
+	})
+})
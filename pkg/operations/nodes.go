@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+// IsNodeReady returns true if a node is ready; false otherwise.
+// Copied from: https://github.com/kubernetes/kubernetes/blob/886e04f1fffbb04faf8a9f9ee141143b2684ae68/pkg/api/v1/node/util.go#L40
+func IsNodeReady(node *v1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// WaitForNodes polls the API server every interval until the named nodes are registered and Ready. When the
+// timeout expires first, it returns the names of the nodes which didn't register or aren't Ready
+func WaitForNodes(client armhelpers.KubernetesClient, logger *log.Entry, nodeNames []string, interval, timeout time.Duration) ([]string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		notReady := nodeNames
+		nodes, err := client.ListNodes()
+		if err != nil {
+			logger.Infof("Failed to list the nodes: %v", err)
+		} else {
+			notReady = nodesNotReady(nodes, nodeNames)
+			if len(notReady) == 0 {
+				logger.Infof("The %d nodes are Ready", len(nodeNames))
+				return nil, nil
+			}
+			logger.Infof("Waiting for the nodes %s to be Ready", strings.Join(notReady, ", "))
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return notReady, errors.Errorf("%d of the %d nodes were not Ready within %v: %s", len(notReady), len(nodeNames), timeout, strings.Join(notReady, ", "))
+		}
+		time.Sleep(interval)
+	}
+}
+
+// nodesNotReady returns the sorted names of the nodes which aren't registered or aren't Ready, the node names
+// being the lowercase VM names
+func nodesNotReady(nodes *v1.NodeList, nodeNames []string) []string {
+	ready := map[string]bool{}
+	for i := range nodes.Items {
+		ready[strings.ToLower(nodes.Items[i].Name)] = IsNodeReady(&nodes.Items[i])
+	}
+	var notReady []string
+	for _, name := range nodeNames {
+		if !ready[strings.ToLower(name)] {
+			notReady = append(notReady, name)
+		}
+	}
+	sort.Strings(notReady)
+	return notReady
+}
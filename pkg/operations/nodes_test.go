@@ -0,0 +1,80 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"time"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+func readyNode(name string, status v1.ConditionStatus) v1.Node {
+	node := v1.Node{}
+	node.Name = name
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}
+	return node
+}
+
+var _ = Describe("Node readiness tests", func() {
+	It("Should only report a node with a true Ready condition as ready", func() {
+		node := &v1.Node{}
+		Expect(IsNodeReady(node)).To(BeFalse())
+
+		node.Status.Conditions = []v1.NodeCondition{
+			{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse},
+			{Type: v1.NodeReady, Status: v1.ConditionUnknown},
+		}
+		Expect(IsNodeReady(node)).To(BeFalse())
+
+		node.Status.Conditions[1].Status = v1.ConditionTrue
+		Expect(IsNodeReady(node)).To(BeTrue())
+	})
+
+	It("Should wait for the nodes joining late to be Ready", func() {
+		calls := 0
+		client := &armhelpers.MockKubernetesClient{
+			ListNodesFunc: func() (*v1.NodeList, error) {
+				calls++
+				nodes := &v1.NodeList{Items: []v1.Node{readyNode("k8s-agentpool1-12345678-0", v1.ConditionTrue)}}
+				// the new node registers on the second poll, and is Ready on the fourth one
+				if calls >= 2 {
+					status := v1.ConditionFalse
+					if calls >= 4 {
+						status = v1.ConditionTrue
+					}
+					nodes.Items = append(nodes.Items, readyNode("k8s-agentpool1-12345678-1", status))
+				}
+				return nodes, nil
+			},
+		}
+		notReady, err := WaitForNodes(client, log.NewEntry(log.New()), []string{"k8s-agentpool1-12345678-0", "K8S-AGENTPOOL1-12345678-1"}, time.Millisecond, time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notReady).To(BeEmpty())
+		Expect(calls).To(Equal(4))
+	})
+
+	It("Should report the nodes which didn't join within the timeout", func() {
+		client := &armhelpers.MockKubernetesClient{
+			NodesList: &v1.NodeList{Items: []v1.Node{
+				readyNode("k8s-agentpool1-12345678-0", v1.ConditionTrue),
+				readyNode("k8s-agentpool1-12345678-1", v1.ConditionUnknown),
+			}},
+		}
+		notReady, err := WaitForNodes(client, log.NewEntry(log.New()), []string{"k8s-agentpool1-12345678-2", "k8s-agentpool1-12345678-1", "k8s-agentpool1-12345678-0"}, time.Millisecond, 20*time.Millisecond)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("2 of the 3 nodes were not Ready within 20ms: k8s-agentpool1-12345678-1, k8s-agentpool1-12345678-2"))
+		Expect(notReady).To(Equal([]string{"k8s-agentpool1-12345678-1", "k8s-agentpool1-12345678-2"}))
+	})
+
+	It("Should report all the nodes when the API server can't list them", func() {
+		client := &armhelpers.MockKubernetesClient{FailListNodes: true}
+		notReady, err := WaitForNodes(client, log.NewEntry(log.New()), []string{"k8s-agentpool1-12345678-0"}, time.Millisecond, 5*time.Millisecond)
+		Expect(err).To(HaveOccurred())
+		Expect(notReady).To(Equal([]string{"k8s-agentpool1-12345678-0"}))
+	})
+})
@@ -0,0 +1,98 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+// ApplyNodeTaintsAndLabels makes a node carry the taints and labels declared for its agent pool, e.g. once an
+// upgrade has replaced it, since the kubelet registers its taints only when it creates the node object. A taint
+// replaces the taint of the node with the same key and effect, and the other taints and labels are kept. The
+// taints are formatted key=value:Effect. The node is only updated when it misses some of them
+func ApplyNodeTaintsAndLabels(client armhelpers.KubernetesClient, logger *log.Entry, nodeName string, taints []string, labels map[string]string) error {
+	if len(taints) == 0 && len(labels) == 0 {
+		return nil
+	}
+	parsedTaints := make([]v1.Taint, 0, len(taints))
+	for _, taint := range taints {
+		parsed, err := parseTaint(taint)
+		if err != nil {
+			return err
+		}
+		parsedTaints = append(parsedTaints, parsed)
+	}
+
+	node, err := client.GetNode(nodeName)
+	if err != nil {
+		return errors.Wrapf(err, "error getting node %s", nodeName)
+	}
+	changed := false
+	for _, taint := range parsedTaints {
+		if setNodeTaint(node, taint) {
+			changed = true
+		}
+	}
+	for key, value := range labels {
+		if current, ok := node.Labels[key]; !ok || current != value {
+			if node.Labels == nil {
+				node.Labels = map[string]string{}
+			}
+			node.Labels[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if _, err = client.UpdateNode(node); err != nil {
+		return errors.Wrapf(err, "error applying the taints and labels of node %s", nodeName)
+	}
+	logger.WithField(LogFieldNode, nodeName).Info("Applied the taints and labels of the agent pool to the node.")
+	return nil
+}
+
+// setNodeTaint adds the taint to the node, or updates the value of its taint with the same key and effect,
+// and returns false when the node already has the taint
+func setNodeTaint(node *v1.Node, taint v1.Taint) bool {
+	for i, t := range node.Spec.Taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect {
+			if t.Value == taint.Value {
+				return false
+			}
+			node.Spec.Taints[i].Value = taint.Value
+			return true
+		}
+	}
+	node.Spec.Taints = append(node.Spec.Taints, taint)
+	return true
+}
+
+// parseTaint parses a taint formatted key=value:Effect, or key:Effect
+func parseTaint(taint string) (v1.Taint, error) {
+	i := strings.LastIndex(taint, ":")
+	if i < 0 {
+		return v1.Taint{}, errors.Errorf("invalid taint %s, expected the format key=value:Effect", taint)
+	}
+	effect := v1.TaintEffect(taint[i+1:])
+	switch effect {
+	case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+	default:
+		return v1.Taint{}, errors.Errorf("invalid taint %s, unknown effect %s", taint, effect)
+	}
+	keyValue := strings.SplitN(taint[:i], "=", 2)
+	if keyValue[0] == "" {
+		return v1.Taint{}, errors.Errorf("invalid taint %s, the key is empty", taint)
+	}
+	parsed := v1.Taint{Key: keyValue[0], Effect: effect}
+	if len(keyValue) == 2 {
+		parsed.Value = keyValue[1]
+	}
+	return parsed, nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+var _ = Describe("Apply node taints and labels operation tests", func() {
+	logger := log.NewEntry(log.New())
+
+	It("Should apply the taints and labels of the agent pool to a replacement node", func() {
+		node := newPoolNode("k8s-agentpool1-0", "agentpool1", false)
+		node.Spec.Taints = []v1.Taint{{Key: "node.kubernetes.io/not-ready", Effect: v1.TaintEffectNoExecute}}
+		client, nodes, updates := newFakeNodesClient(node)
+
+		err := ApplyNodeTaintsAndLabels(client, logger, "k8s-agentpool1-0",
+			[]string{"dedicated=gpu:NoSchedule", "spot:PreferNoSchedule"}, map[string]string{"team": "ml"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updates["k8s-agentpool1-0"]).To(Equal(1))
+		Expect(nodes["k8s-agentpool1-0"].Spec.Taints).To(Equal([]v1.Taint{
+			{Key: "node.kubernetes.io/not-ready", Effect: v1.TaintEffectNoExecute},
+			{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "spot", Effect: v1.TaintEffectPreferNoSchedule},
+		}))
+		Expect(nodes["k8s-agentpool1-0"].Labels).To(Equal(map[string]string{agentPoolLabel: "agentpool1", "team": "ml"}))
+	})
+
+	It("Should replace the value of a taint with the same key and effect", func() {
+		node := newPoolNode("k8s-agentpool1-0", "agentpool1", false)
+		node.Spec.Taints = []v1.Taint{
+			{Key: "dedicated", Value: "cpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "dedicated", Value: "cpu", Effect: v1.TaintEffectNoExecute},
+		}
+		client, nodes, _ := newFakeNodesClient(node)
+
+		Expect(ApplyNodeTaintsAndLabels(client, logger, "k8s-agentpool1-0", []string{"dedicated=gpu:NoSchedule"}, nil)).To(Succeed())
+		Expect(nodes["k8s-agentpool1-0"].Spec.Taints).To(Equal([]v1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "dedicated", Value: "cpu", Effect: v1.TaintEffectNoExecute},
+		}))
+	})
+
+	It("Should not update a node which already has the taints and labels", func() {
+		node := newPoolNode("k8s-agentpool1-0", "agentpool1", false)
+		node.Spec.Taints = []v1.Taint{{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}}
+		client, _, updates := newFakeNodesClient(node)
+
+		Expect(ApplyNodeTaintsAndLabels(client, logger, "k8s-agentpool1-0", []string{"dedicated=gpu:NoSchedule"}, map[string]string{agentPoolLabel: "agentpool1"})).To(Succeed())
+		Expect(updates).To(BeEmpty())
+	})
+
+	It("Should reject an invalid taint", func() {
+		client, _, updates := newFakeNodesClient(newPoolNode("k8s-agentpool1-0", "agentpool1", false))
+
+		err := ApplyNodeTaintsAndLabels(client, logger, "k8s-agentpool1-0", []string{"dedicated=gpu"}, nil)
+		Expect(err).To(MatchError("invalid taint dedicated=gpu, expected the format key=value:Effect"))
+		err = ApplyNodeTaintsAndLabels(client, logger, "k8s-agentpool1-0", []string{"dedicated=gpu:NoRun"}, nil)
+		Expect(err).To(MatchError("invalid taint dedicated=gpu:NoRun, unknown effect NoRun"))
+		Expect(updates).To(BeEmpty())
+	})
+
+	It("Should return an error when the node cannot be updated", func() {
+		client := &armhelpers.MockKubernetesClient{FailUpdateNode: true}
+		err := ApplyNodeTaintsAndLabels(client, logger, "k8s-agentpool1-0", []string{"dedicated=gpu:NoSchedule"}, nil)
+		Expect(err).To(MatchError("error applying the taints and labels of node k8s-agentpool1-0: UpdateNode failed"))
+	})
+})
@@ -0,0 +1,101 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	"github.com/pkg/errors"
+)
+
+// roleRequirement is a built-in role the credentials of acs-engine need on the resource group of a cluster,
+// along with the actions of the role acs-engine performs
+type roleRequirement struct {
+	role    string
+	actions []string
+}
+
+var (
+	// contributorRequirement covers the deployment and the management of the resources of the cluster
+	contributorRequirement = roleRequirement{
+		role: "Contributor",
+		actions: []string{
+			"Microsoft.Resources/deployments/write",
+			"Microsoft.Compute/virtualMachines/write",
+			"Microsoft.Compute/virtualMachines/delete",
+			"Microsoft.Compute/virtualMachineScaleSets/write",
+			"Microsoft.Network/virtualNetworks/write",
+			"Microsoft.Network/loadBalancers/write",
+			"Microsoft.Network/networkInterfaces/delete",
+			"Microsoft.Storage/storageAccounts/write",
+		},
+	}
+	// userAccessAdministratorRequirement covers the role assignments of the managed identities of the VMs
+	userAccessAdministratorRequirement = roleRequirement{
+		role: "User Access Administrator",
+		actions: []string{
+			"Microsoft.Authorization/roleAssignments/write",
+			"Microsoft.Authorization/roleAssignments/delete",
+		},
+	}
+)
+
+// MissingPermission is an action the credentials of acs-engine cannot perform on the resource group of a cluster
+type MissingPermission struct {
+	Action string
+	// Role is the built-in role granting the action
+	Role string
+}
+
+// CheckPermissions checks, before any operation, that the credentials of the client have the role assignments
+// acs-engine needs on the resource group of a cluster: Contributor, and User Access Administrator when the VMs
+// use managed identities. The actions which the credentials cannot perform are returned
+func CheckPermissions(ctx context.Context, client armhelpers.ACSEngineClient, resourceGroup string, useManagedIdentity bool) ([]MissingPermission, error) {
+	permissions, err := client.ListPermissionsForResourceGroup(ctx, resourceGroup)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting the permissions on resource group %s", resourceGroup)
+	}
+	requirements := []roleRequirement{contributorRequirement}
+	if useManagedIdentity {
+		requirements = append(requirements, userAccessAdministratorRequirement)
+	}
+	var missing []MissingPermission
+	for _, requirement := range requirements {
+		for _, action := range requirement.actions {
+			if !isActionPermitted(permissions, action) {
+				missing = append(missing, MissingPermission{Action: action, Role: requirement.role})
+			}
+		}
+	}
+	return missing, nil
+}
+
+// isActionPermitted returns true if an action is among the actions, and not among the not actions, of one
+// of the permissions
+func isActionPermitted(permissions []authorization.Permission, action string) bool {
+	for _, permission := range permissions {
+		if permission.Actions == nil || !matchesAnyAction(*permission.Actions, action) {
+			continue
+		}
+		if permission.NotActions == nil || !matchesAnyAction(*permission.NotActions, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyAction returns true if an action matches one of the patterns, in which * is a wildcard
+func matchesAnyAction(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		expr := "(?i)^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1) + "$"
+		if matched, err := regexp.MatchString(expr, action); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
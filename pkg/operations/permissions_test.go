@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Check permissions operation tests", func() {
+	It("Should match the actions of the permissions with wildcards and regardless of case", func() {
+		permissions := []authorization.Permission{
+			{Actions: &[]string{"Microsoft.Compute/*", "microsoft.network/virtualnetworks/write"}, NotActions: &[]string{"Microsoft.Compute/*/delete"}},
+		}
+		Expect(isActionPermitted(permissions, "Microsoft.Compute/virtualMachines/write")).To(BeTrue())
+		Expect(isActionPermitted(permissions, "Microsoft.Network/virtualNetworks/write")).To(BeTrue())
+		Expect(isActionPermitted(permissions, "Microsoft.Compute/virtualMachines/delete")).To(BeFalse())
+		Expect(isActionPermitted(permissions, "Microsoft.Storage/storageAccounts/write")).To(BeFalse())
+	})
+
+	It("Should permit an action denied by a permission but granted by another", func() {
+		permissions := []authorization.Permission{
+			{Actions: &[]string{"*"}, NotActions: &[]string{"Microsoft.Authorization/*/Write"}},
+			{Actions: &[]string{"Microsoft.Authorization/*"}},
+		}
+		Expect(isActionPermitted(permissions, "Microsoft.Authorization/roleAssignments/write")).To(BeTrue())
+	})
+
+	It("Should return the missing permissions of the roles acs-engine needs", func() {
+		client := &armhelpers.MockACSEngineClient{Permissions: []authorization.Permission{}}
+		missing, err := CheckPermissions(context.Background(), client, "rg", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missing).To(HaveLen(len(contributorRequirement.actions)))
+		Expect(missing[0]).To(Equal(MissingPermission{Action: "Microsoft.Resources/deployments/write", Role: "Contributor"}))
+
+		missing, err = CheckPermissions(context.Background(), &armhelpers.MockACSEngineClient{}, "rg", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missing).To(BeEmpty())
+	})
+})
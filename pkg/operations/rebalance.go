@@ -0,0 +1,239 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DeschedulerStrategyLowNodeUtilization evicts pods from the overutilized nodes, so that they're
+	// rescheduled onto the underutilized ones, e.g. the nodes added by a scale up
+	DeschedulerStrategyLowNodeUtilization = "LowNodeUtilization"
+	// DeschedulerStrategyRemoveDuplicates evicts the pods of a controller sharing a node with another of its pods
+	DeschedulerStrategyRemoveDuplicates = "RemoveDuplicates"
+	// DeschedulerStrategyRemovePodsViolatingInterPodAntiAffinity evicts the pods violating the anti-affinity of other pods
+	DeschedulerStrategyRemovePodsViolatingInterPodAntiAffinity = "RemovePodsViolatingInterPodAntiAffinity"
+	// DeschedulerStrategyRemovePodsViolatingNodeAffinity evicts the pods whose node affinity their node no longer matches
+	DeschedulerStrategyRemovePodsViolatingNodeAffinity = "RemovePodsViolatingNodeAffinity"
+
+	// DefaultDeschedulerImage is the descheduler image the rebalancing job runs unless another one is given
+	DefaultDeschedulerImage = "k8s.gcr.io/descheduler/descheduler:v0.10.0"
+
+	deschedulerName       = "descheduler"
+	deschedulerPolicyFile = "policy.yaml"
+	deschedulerPolicyDir  = "/policy-dir"
+)
+
+// deschedulerStrategies are the descheduler strategies a rebalancing pass can run
+var deschedulerStrategies = []string{
+	DeschedulerStrategyLowNodeUtilization,
+	DeschedulerStrategyRemoveDuplicates,
+	DeschedulerStrategyRemovePodsViolatingInterPodAntiAffinity,
+	DeschedulerStrategyRemovePodsViolatingNodeAffinity,
+}
+
+// RebalanceOptions configures the descheduler pass rebalancing the pods of a cluster
+type RebalanceOptions struct {
+	// Strategies run by the descheduler, LowNodeUtilization when empty
+	Strategies []string
+	// Thresholds are the percentages of the allocatable cpu, memory and pods under which a node is underutilized
+	Thresholds map[v1.ResourceName]int
+	// TargetThresholds are the percentages over which a node is overutilized, and its pods are evicted
+	TargetThresholds map[v1.ResourceName]int
+	// Image of the descheduler, DefaultDeschedulerImage when empty
+	Image string
+}
+
+var (
+	defaultRebalanceThresholds       = map[v1.ResourceName]int{v1.ResourceCPU: 20, v1.ResourceMemory: 20, v1.ResourcePods: 20}
+	defaultRebalanceTargetThresholds = map[v1.ResourceName]int{v1.ResourceCPU: 50, v1.ResourceMemory: 50, v1.ResourcePods: 50}
+)
+
+// RebalancePods runs a single descheduler pass in a job of the kube-system namespace, e.g. after a scale up,
+// so that some of the pods of the existing nodes are evicted and rescheduled onto the new, idle nodes.
+// The descheduler policy is stored in a config map, and the descheduler is given the permissions to evict
+// pods. The created job is returned without waiting for it to complete
+func RebalancePods(client armhelpers.KubernetesClient, logger *log.Entry, options RebalanceOptions) (*batchv1.Job, error) {
+	policy, err := getDeschedulerPolicy(options)
+	if err != nil {
+		return nil, err
+	}
+	image := options.Image
+	if image == "" {
+		image = DefaultDeschedulerImage
+	}
+
+	objectMeta := metav1.ObjectMeta{
+		Name:      deschedulerName,
+		Namespace: metav1.NamespaceSystem,
+		Labels:    map[string]string{"app": deschedulerName},
+	}
+	if _, err = client.ApplyConfigMap(&v1.ConfigMap{
+		ObjectMeta: objectMeta,
+		Data:       map[string]string{deschedulerPolicyFile: policy},
+	}); err != nil {
+		return nil, errors.Wrap(err, "error storing the descheduler policy")
+	}
+	if err = applyDeschedulerRBAC(client, objectMeta); err != nil {
+		return nil, err
+	}
+
+	job, err := client.CreateJob(newDeschedulerJob(objectMeta, image))
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the descheduler job")
+	}
+	logger.Infof("Created job %s/%s rebalancing the pods of the cluster.", job.Namespace, job.Name)
+	return job, nil
+}
+
+func getDeschedulerPolicy(options RebalanceOptions) (string, error) {
+	strategyNames := options.Strategies
+	if len(strategyNames) == 0 {
+		strategyNames = []string{DeschedulerStrategyLowNodeUtilization}
+	}
+	thresholds, err := getRebalanceThresholds(options.Thresholds, defaultRebalanceThresholds)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid thresholds")
+	}
+	targetThresholds, err := getRebalanceThresholds(options.TargetThresholds, defaultRebalanceTargetThresholds)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid target thresholds")
+	}
+	for resource, threshold := range thresholds {
+		if target, ok := targetThresholds[resource]; ok && target < threshold {
+			return "", errors.Errorf("the target threshold of %s, %d%%, is lower than its threshold, %d%%", resource, target, threshold)
+		}
+	}
+
+	strategies := map[string]interface{}{}
+	for _, name := range strategyNames {
+		if !isDeschedulerStrategy(name) {
+			return "", errors.Errorf("unknown descheduler strategy %q, supported strategies are %q", name, deschedulerStrategies)
+		}
+		strategy := map[string]interface{}{"enabled": true}
+		if name == DeschedulerStrategyLowNodeUtilization {
+			strategy["params"] = map[string]interface{}{
+				"nodeResourceUtilizationThresholds": map[string]interface{}{
+					"thresholds":       thresholds,
+					"targetThresholds": targetThresholds,
+				},
+			}
+		}
+		strategies[name] = strategy
+	}
+	policy, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "descheduler/v1alpha1",
+		"kind":       "DeschedulerPolicy",
+		"strategies": strategies,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error serializing the descheduler policy")
+	}
+	return string(policy), nil
+}
+
+// getRebalanceThresholds returns the given thresholds, or the defaults if none is given
+func getRebalanceThresholds(thresholds, defaults map[v1.ResourceName]int) (map[v1.ResourceName]int, error) {
+	if len(thresholds) == 0 {
+		return defaults, nil
+	}
+	for resource, threshold := range thresholds {
+		if resource != v1.ResourceCPU && resource != v1.ResourceMemory && resource != v1.ResourcePods {
+			return nil, errors.Errorf("unsupported resource %s, supported resources are cpu, memory and pods", resource)
+		}
+		if threshold < 0 || threshold > 100 {
+			return nil, errors.Errorf("the threshold of %s, %d, is not a percentage", resource, threshold)
+		}
+	}
+	return thresholds, nil
+}
+
+func isDeschedulerStrategy(name string) bool {
+	for _, strategy := range deschedulerStrategies {
+		if name == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+func applyDeschedulerRBAC(client armhelpers.KubernetesClient, objectMeta metav1.ObjectMeta) error {
+	if _, err := client.ApplyServiceAccount(&v1.ServiceAccount{ObjectMeta: objectMeta}); err != nil {
+		return errors.Wrap(err, "error creating the descheduler service account")
+	}
+	clusterMeta := objectMeta
+	clusterMeta.Namespace = ""
+	if _, err := client.ApplyClusterRole(&rbacv1.ClusterRole{
+		ObjectMeta: clusterMeta,
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create", "update"}},
+			{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "watch", "list"}},
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "watch", "list", "delete"}},
+			{APIGroups: []string{""}, Resources: []string{"pods/eviction"}, Verbs: []string{"create"}},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "error creating the descheduler cluster role")
+	}
+	if _, err := client.ApplyClusterRoleBinding(&rbacv1.ClusterRoleBinding{
+		ObjectMeta: clusterMeta,
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: deschedulerName},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: objectMeta.Name, Namespace: objectMeta.Namespace},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "error creating the descheduler cluster role binding")
+	}
+	return nil
+}
+
+func newDeschedulerJob(objectMeta metav1.ObjectMeta, image string) *batchv1.Job {
+	backoffLimit := int32(0)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			// every pass runs in a job of its own
+			GenerateName: deschedulerName + "-",
+			Namespace:    objectMeta.Namespace,
+			Labels:       objectMeta.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: objectMeta.Labels},
+				Spec: v1.PodSpec{
+					ServiceAccountName: objectMeta.Name,
+					RestartPolicy:      v1.RestartPolicyNever,
+					NodeSelector:       map[string]string{"beta.kubernetes.io/os": "linux"},
+					Containers: []v1.Container{
+						{
+							Name:    deschedulerName,
+							Image:   image,
+							Command: []string{"/bin/descheduler", "--policy-config-file", deschedulerPolicyDir + "/" + deschedulerPolicyFile, "--v", "3"},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "policy-volume", MountPath: deschedulerPolicyDir},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "policy-volume",
+							VolumeSource: v1.VolumeSource{
+								ConfigMap: &v1.ConfigMapVolumeSource{
+									LocalObjectReference: v1.LocalObjectReference{Name: objectMeta.Name},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/ghodss/yaml"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// deschedulerPolicy is the part of the descheduler policy the tests check
+type deschedulerPolicy struct {
+	Kind       string `json:"kind"`
+	Strategies map[string]struct {
+		Enabled bool `json:"enabled"`
+		Params  struct {
+			NodeResourceUtilizationThresholds struct {
+				Thresholds       map[string]int `json:"thresholds"`
+				TargetThresholds map[string]int `json:"targetThresholds"`
+			} `json:"nodeResourceUtilizationThresholds"`
+		} `json:"params"`
+	} `json:"strategies"`
+}
+
+// getAppliedPolicy returns the descheduler policy stored in the config map applied with the mock client
+func getAppliedPolicy(client *armhelpers.MockKubernetesClient) deschedulerPolicy {
+	var policy deschedulerPolicy
+	for _, applied := range client.Applied {
+		if configMap, ok := applied.(*v1.ConfigMap); ok {
+			Expect(configMap.Namespace).To(Equal("kube-system"))
+			Expect(yaml.Unmarshal([]byte(configMap.Data["policy.yaml"]), &policy)).To(Succeed())
+		}
+	}
+	Expect(policy.Kind).To(Equal("DeschedulerPolicy"))
+	return policy
+}
+
+var _ = Describe("Rebalance pods operation tests", func() {
+	logger := log.NewEntry(log.New())
+
+	It("Should create a descheduler job running the low node utilization strategy by default", func() {
+		client := &armhelpers.MockKubernetesClient{}
+		job, err := RebalancePods(client, logger, RebalanceOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Jobs).To(Equal([]*batchv1.Job{job}))
+
+		Expect(job.Namespace).To(Equal("kube-system"))
+		Expect(job.GenerateName).To(Equal("descheduler-"))
+		Expect(*job.Spec.BackoffLimit).To(BeZero())
+		pod := job.Spec.Template.Spec
+		Expect(pod.ServiceAccountName).To(Equal("descheduler"))
+		Expect(pod.RestartPolicy).To(Equal(v1.RestartPolicyNever))
+		Expect(pod.Containers).To(HaveLen(1))
+		Expect(pod.Containers[0].Image).To(Equal(DefaultDeschedulerImage))
+		Expect(pod.Containers[0].Command).To(Equal([]string{"/bin/descheduler", "--policy-config-file", "/policy-dir/policy.yaml", "--v", "3"}))
+		Expect(pod.Containers[0].VolumeMounts[0].MountPath).To(Equal("/policy-dir"))
+		Expect(pod.Volumes[0].ConfigMap.Name).To(Equal("descheduler"))
+
+		policy := getAppliedPolicy(client)
+		Expect(policy.Strategies).To(HaveLen(1))
+		strategy := policy.Strategies[DeschedulerStrategyLowNodeUtilization]
+		Expect(strategy.Enabled).To(BeTrue())
+		Expect(strategy.Params.NodeResourceUtilizationThresholds.Thresholds).To(Equal(map[string]int{"cpu": 20, "memory": 20, "pods": 20}))
+		Expect(strategy.Params.NodeResourceUtilizationThresholds.TargetThresholds).To(Equal(map[string]int{"cpu": 50, "memory": 50, "pods": 50}))
+	})
+
+	It("Should configure the descheduler with the given strategies and thresholds", func() {
+		client := &armhelpers.MockKubernetesClient{}
+		_, err := RebalancePods(client, logger, RebalanceOptions{
+			Strategies:       []string{DeschedulerStrategyLowNodeUtilization, DeschedulerStrategyRemoveDuplicates},
+			Thresholds:       map[v1.ResourceName]int{v1.ResourceCPU: 10, v1.ResourcePods: 30},
+			TargetThresholds: map[v1.ResourceName]int{v1.ResourceCPU: 70, v1.ResourcePods: 60},
+			Image:            "myregistry.azurecr.io/descheduler:v0.10.0",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Jobs).To(HaveLen(1))
+		Expect(client.Jobs[0].Spec.Template.Spec.Containers[0].Image).To(Equal("myregistry.azurecr.io/descheduler:v0.10.0"))
+
+		policy := getAppliedPolicy(client)
+		Expect(policy.Strategies).To(HaveLen(2))
+		Expect(policy.Strategies[DeschedulerStrategyRemoveDuplicates].Enabled).To(BeTrue())
+		thresholds := policy.Strategies[DeschedulerStrategyLowNodeUtilization].Params.NodeResourceUtilizationThresholds
+		Expect(thresholds.Thresholds).To(Equal(map[string]int{"cpu": 10, "pods": 30}))
+		Expect(thresholds.TargetThresholds).To(Equal(map[string]int{"cpu": 70, "pods": 60}))
+	})
+
+	It("Should give the descheduler the permissions to evict pods", func() {
+		client := &armhelpers.MockKubernetesClient{}
+		_, err := RebalancePods(client, logger, RebalanceOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		var role *rbacv1.ClusterRole
+		var binding *rbacv1.ClusterRoleBinding
+		var serviceAccount *v1.ServiceAccount
+		for _, applied := range client.Applied {
+			switch o := applied.(type) {
+			case *rbacv1.ClusterRole:
+				role = o
+			case *rbacv1.ClusterRoleBinding:
+				binding = o
+			case *v1.ServiceAccount:
+				serviceAccount = o
+			}
+		}
+		Expect(serviceAccount.Name).To(Equal("descheduler"))
+		Expect(serviceAccount.Namespace).To(Equal("kube-system"))
+		Expect(role.Rules).To(ContainElement(rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/eviction"}, Verbs: []string{"create"}}))
+		Expect(binding.RoleRef.Name).To(Equal(role.Name))
+		Expect(binding.Subjects).To(Equal([]rbacv1.Subject{{Kind: "ServiceAccount", Name: "descheduler", Namespace: "kube-system"}}))
+	})
+
+	It("Should reject an unknown strategy", func() {
+		client := &armhelpers.MockKubernetesClient{}
+		_, err := RebalancePods(client, logger, RebalanceOptions{Strategies: []string{"HighNodeUtilization"}})
+		Expect(err).To(MatchError(`unknown descheduler strategy "HighNodeUtilization", supported strategies are ["LowNodeUtilization" "RemoveDuplicates" "RemovePodsViolatingInterPodAntiAffinity" "RemovePodsViolatingNodeAffinity"]`))
+		Expect(client.Applied).To(BeEmpty())
+		Expect(client.Jobs).To(BeEmpty())
+	})
+
+	It("Should reject invalid thresholds", func() {
+		client := &armhelpers.MockKubernetesClient{}
+		_, err := RebalancePods(client, logger, RebalanceOptions{Thresholds: map[v1.ResourceName]int{v1.ResourceStorage: 20}})
+		Expect(err).To(MatchError("invalid thresholds: unsupported resource storage, supported resources are cpu, memory and pods"))
+
+		_, err = RebalancePods(client, logger, RebalanceOptions{TargetThresholds: map[v1.ResourceName]int{v1.ResourceCPU: 120}})
+		Expect(err).To(MatchError("invalid target thresholds: the threshold of cpu, 120, is not a percentage"))
+
+		_, err = RebalancePods(client, logger, RebalanceOptions{
+			Thresholds:       map[v1.ResourceName]int{v1.ResourceCPU: 60},
+			TargetThresholds: map[v1.ResourceName]int{v1.ResourceCPU: 40},
+		})
+		Expect(err).To(MatchError("the target threshold of cpu, 40%, is lower than its threshold, 60%"))
+		Expect(client.Jobs).To(BeEmpty())
+	})
+
+	It("Should return an error when the job cannot be created", func() {
+		client := &armhelpers.MockKubernetesClient{FailCreateJob: true}
+		_, err := RebalancePods(client, logger, RebalanceOptions{})
+		Expect(err).To(MatchError("error creating the descheduler job: CreateJob failed"))
+
+		client = &armhelpers.MockKubernetesClient{FailApply: true}
+		_, err = RebalancePods(client, logger, RebalanceOptions{})
+		Expect(err).To(MatchError("error storing the descheduler policy: ApplyConfigMap failed"))
+		Expect(client.Jobs).To(BeEmpty())
+	})
+})
@@ -6,6 +6,7 @@ package operations
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"net"
 
@@ -14,6 +15,40 @@ import (
 
 // RemoteRun executes remote command
 func RemoteRun(user string, addr string, port int, sshKey []byte, cmd string) (string, error) {
+	return remoteRun(user, addr, port, sshKey, cmd, nil)
+}
+
+// RemoteCopy writes contents to the file at path on the remote host
+func RemoteCopy(user string, addr string, port int, sshKey []byte, contents []byte, path string) error {
+	_, err := remoteRun(user, addr, port, sshKey, fmt.Sprintf("cat > %s", path), bytes.NewReader(contents))
+	return err
+}
+
+// RemoteRunThroughJumpbox executes remote command on the host at addr, which is reached by
+// tunneling the SSH connection through the jumpbox host, e.g. an agent node behind a master
+func RemoteRunThroughJumpbox(user string, jumpboxAddr string, jumpboxPort int, sshKey []byte, addr string, cmd string) (string, error) {
+	config := sshClientConfig(user, sshKey)
+	jumpbox, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", jumpboxAddr, jumpboxPort), config)
+	if err != nil {
+		return "", err
+	}
+	defer jumpbox.Close()
+	// Connect to the host from the jumpbox
+	conn, err := jumpbox.Dial("tcp", fmt.Sprintf("%s:22", addr))
+	if err != nil {
+		return "", err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	defer client.Close()
+	return runSession(client, cmd, nil)
+}
+
+func sshClientConfig(user string, sshKey []byte) *ssh.ClientConfig {
 	// Create the Signer for this private key.
 	signer, err := ssh.ParsePrivateKey(sshKey)
 	if err != nil {
@@ -21,18 +56,26 @@ func RemoteRun(user string, addr string, port int, sshKey []byte, cmd string) (s
 	}
 
 	// Authentication
-	config := &ssh.ClientConfig{
+	return &ssh.ClientConfig{
 		User: user,
 		Auth: []ssh.AuthMethod{
 			ssh.PublicKeys(signer),
 		},
 		HostKeyCallback: func(string, net.Addr, ssh.PublicKey) error { return nil },
 	}
+}
+
+func remoteRun(user string, addr string, port int, sshKey []byte, cmd string, stdin io.Reader) (string, error) {
+	config := sshClientConfig(user, sshKey)
 	// Connect
 	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", addr, port), config)
 	if err != nil {
 		return "", err
 	}
+	return runSession(client, cmd, stdin)
+}
+
+func runSession(client *ssh.Client, cmd string, stdin io.Reader) (string, error) {
 	// Create a session. It is one session per command.
 	session, err := client.NewSession()
 	if err != nil {
@@ -41,6 +84,7 @@ func RemoteRun(user string, addr string, port int, sshKey []byte, cmd string) (s
 	defer session.Close()
 	var b bytes.Buffer
 	session.Stdout = &b // get output
+	session.Stdin = stdin
 
 	err = session.Run(cmd)
 	return b.String(), err
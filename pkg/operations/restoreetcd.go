@@ -0,0 +1,122 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	etcdDataDir             = "/var/lib/etcddisk"
+	etcdRestoreDataDir      = "/var/lib/etcddisk-restore"
+	etcdRestoreSnapshotPath = "/tmp/etcd-restore.db"
+
+	// etcdSnapshotRestoreCommand restores the snapshot into a new single-member cluster data dir, using the
+	// member name and peer URL the etcd service on this master is configured with
+	etcdSnapshotRestoreCommand = "PEER_URL=$(grep -o -- '--initial-advertise-peer-urls \"[^\"]*\"' /etc/default/etcd | cut -d'\"' -f2) && " +
+		"sudo rm -rf " + etcdRestoreDataDir + " && " +
+		"sudo ETCDCTL_API=3 etcdctl snapshot restore " + etcdRestoreSnapshotPath + " --name $(hostname) " +
+		"--initial-cluster $(hostname)=$PEER_URL --initial-advertise-peer-urls $PEER_URL " +
+		"--initial-cluster-token k8s-etcd-cluster --data-dir " + etcdRestoreDataDir
+	// replaceEtcdDataCommand swaps the etcd data for the restored data
+	replaceEtcdDataCommand = "sudo rm -rf " + etcdDataDir + "/member && " +
+		"sudo mv " + etcdRestoreDataDir + "/member " + etcdDataDir + "/member && " +
+		"sudo chown -R etcd:etcd " + etcdDataDir
+	// startControlPlaneCommand starts etcd and puts the control plane static pod manifests back
+	startControlPlaneCommand = "sudo systemctl start etcd && " +
+		"sudo sh -c 'mv /etc/kubernetes/manifests-stopped/*.yaml /etc/kubernetes/manifests/'"
+	etcdRestoreCleanupCommand = "sudo rm -rf " + etcdRestoreSnapshotPath + " " + etcdRestoreDataDir
+)
+
+// stopControlPlaneCommand removes the control plane static pod manifests, waits for the apiserver serving
+// on apiServerPort to go away and stops etcd
+func stopControlPlaneCommand(apiServerPort int) string {
+	return "sudo mkdir -p /etc/kubernetes/manifests-stopped && " +
+		"sudo sh -c 'mv /etc/kubernetes/manifests/*.yaml /etc/kubernetes/manifests-stopped/' && " +
+		fmt.Sprintf("timeout 300 sh -c 'while curl -sk https://127.0.0.1:%d/healthz > /dev/null; do sleep 5; done' && ", apiServerPort) +
+		"sudo systemctl stop etcd"
+}
+
+// RestoreEtcd replaces the etcd data on the master node reached by run and copyFile with the snapshot
+// stored in the specified blob, reinitializing etcd as a single-member cluster. The control plane
+// is unavailable during the restore and all cluster state written after the snapshot is lost,
+// so the restore is only performed when confirmed is true. Only clusters with a single master are
+// supported, as the etcd members of the other masters would keep their data and split the cluster.
+func RestoreEtcd(az armhelpers.ACSEngineClient, logger *log.Entry, run RemoteCommandRunner, copyFile RemoteFileCopier, properties *api.Properties, resourceGroup, storageAccountName, containerName, blobName string, confirmed bool) error {
+	if properties.MasterProfile == nil {
+		return errors.New("restoring etcd is only supported on clusters with masters")
+	}
+	if properties.MasterProfile.Count > 1 {
+		return errors.Errorf("restoring etcd is only supported on clusters with a single master, the cluster has %d masters", properties.MasterProfile.Count)
+	}
+	if !confirmed {
+		return errors.New("restoring etcd replaces all cluster state and must be explicitly confirmed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	logger = logger.WithFields(log.Fields{LogFieldOperation: "restore-etcd", LogFieldResourceGroup: resourceGroup})
+
+	logger.Infof("downloading etcd snapshot %s from storage account %s, container %s", blobName, storageAccountName, containerName)
+	client, err := az.GetStorageClient(ctx, resourceGroup, storageAccountName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get storage client for account %s", storageAccountName)
+	}
+	snapshot, err := client.GetBlob(containerName, blobName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download etcd snapshot %s", blobName)
+	}
+	if len(snapshot) == 0 {
+		return errors.Errorf("etcd snapshot %s is empty", blobName)
+	}
+
+	logger.Infof("copying etcd snapshot to %s", etcdRestoreSnapshotPath)
+	if err = copyFile(snapshot, etcdRestoreSnapshotPath); err != nil {
+		return errors.Wrap(err, "failed to copy etcd snapshot to the master node")
+	}
+	defer func() {
+		if _, cleanupErr := run(etcdRestoreCleanupCommand); cleanupErr != nil {
+			logger.Warnf("failed to clean up etcd restore files: %s", cleanupErr.Error())
+		}
+	}()
+
+	logger.Info("restoring etcd snapshot")
+	if _, err = run(etcdSnapshotRestoreCommand); err != nil {
+		return errors.Wrap(err, "failed to restore etcd snapshot")
+	}
+
+	logger.Info("stopping control plane")
+	if _, err = run(stopControlPlaneCommand(properties.OrchestratorProfile.KubernetesConfig.GetAPIServerSecurePort())); err != nil {
+		err = errors.Wrap(err, "failed to stop control plane")
+		startControlPlane(logger, run)
+		return err
+	}
+
+	logger.Infof("replacing etcd data in %s", etcdDataDir)
+	if _, err = run(replaceEtcdDataCommand); err != nil {
+		err = errors.Wrap(err, "failed to replace etcd data")
+		startControlPlane(logger, run)
+		return err
+	}
+
+	logger.Info("starting control plane")
+	if _, err = run(startControlPlaneCommand); err != nil {
+		return errors.Wrap(err, "failed to start control plane")
+	}
+	return nil
+}
+
+// startControlPlane makes a best effort to bring the control plane back after a failed restore
+func startControlPlane(logger *log.Entry, run RemoteCommandRunner) {
+	logger.Warn("attempting to start control plane after failed etcd restore")
+	if _, err := run(startControlPlaneCommand); err != nil {
+		logger.Errorf("failed to start control plane: %s", err.Error())
+	}
+}
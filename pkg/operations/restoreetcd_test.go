@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// fakeMaster records the commands run and files copied on a master node
+type fakeMaster struct {
+	steps   []string
+	files   map[string][]byte
+	failCmd string
+}
+
+func (m *fakeMaster) run(cmd string) (string, error) {
+	m.steps = append(m.steps, cmd)
+	if cmd == m.failCmd {
+		return "", errors.New("command failed")
+	}
+	return "", nil
+}
+
+func (m *fakeMaster) copyFile(contents []byte, path string) error {
+	m.steps = append(m.steps, "copy "+path)
+	m.files[path] = contents
+	return nil
+}
+
+var _ = Describe("Restore etcd operation tests", func() {
+	var master *fakeMaster
+	var mockClient *armhelpers.MockACSEngineClient
+	var properties *api.Properties
+
+	BeforeEach(func() {
+		master = &fakeMaster{files: map[string][]byte{}}
+		properties = api.CreateMockContainerService("testcluster", "1.10.9", 1, 1, false).Properties
+		mockClient = &armhelpers.MockACSEngineClient{MockStorageClient: &armhelpers.MockStorageClient{}}
+		mockClient.MockStorageClient.GetBlobFunc = func(container, blob string) ([]byte, error) {
+			Expect(container).To(Equal("etcd-backups"))
+			Expect(blob).To(Equal("etcd-snapshot.db"))
+			return []byte("snapshot"), nil
+		}
+	})
+
+	It("Should restore the snapshot with the control plane stopped", func() {
+		err := RestoreEtcd(mockClient, log.NewEntry(log.New()), master.run, master.copyFile, properties, "rg", "account", "etcd-backups", "etcd-snapshot.db", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(master.files[etcdRestoreSnapshotPath]).To(Equal([]byte("snapshot")))
+		Expect(master.steps).To(Equal([]string{
+			"copy " + etcdRestoreSnapshotPath,
+			etcdSnapshotRestoreCommand,
+			stopControlPlaneCommand(443),
+			replaceEtcdDataCommand,
+			startControlPlaneCommand,
+			etcdRestoreCleanupCommand,
+		}))
+	})
+	It("Should wait for the apiserver on its custom secure port to stop", func() {
+		properties.OrchestratorProfile.KubernetesConfig.APIServerSecurePort = 6443
+		err := RestoreEtcd(mockClient, log.NewEntry(log.New()), master.run, master.copyFile, properties, "rg", "account", "etcd-backups", "etcd-snapshot.db", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(master.steps).To(ContainElement(stopControlPlaneCommand(6443)))
+		Expect(stopControlPlaneCommand(6443)).To(ContainSubstring("https://127.0.0.1:6443/healthz"))
+	})
+	It("Should not touch the master unless confirmed", func() {
+		err := RestoreEtcd(mockClient, log.NewEntry(log.New()), master.run, master.copyFile, properties, "rg", "account", "etcd-backups", "etcd-snapshot.db", false)
+		Expect(err).Should(HaveOccurred())
+		Expect(master.steps).To(BeEmpty())
+	})
+	It("Should not touch the masters of a cluster with several masters", func() {
+		properties.MasterProfile.Count = 3
+		err := RestoreEtcd(mockClient, log.NewEntry(log.New()), master.run, master.copyFile, properties, "rg", "account", "etcd-backups", "etcd-snapshot.db", true)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).To(Equal("restoring etcd is only supported on clusters with a single master, the cluster has 3 masters"))
+		Expect(master.steps).To(BeEmpty())
+	})
+	It("Should not stop the control plane when the snapshot cannot be downloaded", func() {
+		mockClient.MockStorageClient.GetBlobFunc = nil
+		mockClient.MockStorageClient.FailGetBlob = true
+		err := RestoreEtcd(mockClient, log.NewEntry(log.New()), master.run, master.copyFile, properties, "rg", "account", "etcd-backups", "etcd-snapshot.db", true)
+		Expect(err).Should(HaveOccurred())
+		Expect(master.steps).To(BeEmpty())
+	})
+	It("Should not stop the control plane when the snapshot cannot be restored", func() {
+		master.failCmd = etcdSnapshotRestoreCommand
+		err := RestoreEtcd(mockClient, log.NewEntry(log.New()), master.run, master.copyFile, properties, "rg", "account", "etcd-backups", "etcd-snapshot.db", true)
+		Expect(err).Should(HaveOccurred())
+		Expect(master.steps).NotTo(ContainElement(stopControlPlaneCommand(443)))
+	})
+	It("Should start the control plane again when the etcd data cannot be replaced", func() {
+		master.failCmd = replaceEtcdDataCommand
+		err := RestoreEtcd(mockClient, log.NewEntry(log.New()), master.run, master.copyFile, properties, "rg", "account", "etcd-backups", "etcd-snapshot.db", true)
+		Expect(err).Should(HaveOccurred())
+		Expect(master.steps).To(Equal([]string{
+			"copy " + etcdRestoreSnapshotPath,
+			etcdSnapshotRestoreCommand,
+			stopControlPlaneCommand(443),
+			replaceEtcdDataCommand,
+			startControlPlaneCommand,
+			etcdRestoreCleanupCommand,
+		}))
+	})
+})
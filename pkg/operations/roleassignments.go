@@ -0,0 +1,150 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/api/common"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// IdentityRoleAssignment is a role assignment the managed identity of a cluster needs
+type IdentityRoleAssignment struct {
+	// PrincipalID is the object id of the managed identity
+	PrincipalID string
+	// Role is the name of the built-in role
+	Role             string
+	RoleDefinitionID string
+	Scope            string
+}
+
+// RepairRoleAssignments checks that the managed identity of a cluster, the user assigned identity or else the
+// system assigned identities of the masters, has the role assignments the cloud provider needs: Contributor on the
+// resource group of the cluster, and Network Contributor on a custom VNET. The missing role assignments are created,
+// and returned, so that repairing a cluster twice is harmless
+func RepairRoleAssignments(ctx context.Context, client armhelpers.ACSEngineClient, logger *log.Entry, cs *api.ContainerService, subscriptionID, resourceGroup string) ([]IdentityRoleAssignment, error) {
+	properties := cs.Properties
+	if properties.OrchestratorProfile == nil || properties.OrchestratorProfile.KubernetesConfig == nil ||
+		!properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity {
+		return nil, errors.New("the cluster doesn't use managed identities")
+	}
+	principalIDs, err := getManagedIdentityPrincipalIDs(ctx, client, properties, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	required, err := getRequiredRoleAssignments(properties, subscriptionID, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []IdentityRoleAssignment
+	for _, principalID := range principalIDs {
+		for _, assignment := range required {
+			assignment.PrincipalID = principalID
+			exists, err := hasRoleAssignment(ctx, client, assignment)
+			if err != nil {
+				return created, err
+			}
+			if exists {
+				continue
+			}
+			parameters := authorization.RoleAssignmentCreateParameters{
+				Properties: &authorization.RoleAssignmentProperties{
+					RoleDefinitionID: to.StringPtr(assignment.RoleDefinitionID),
+					PrincipalID:      to.StringPtr(assignment.PrincipalID),
+				},
+			}
+			if _, err = client.CreateRoleAssignment(ctx, assignment.Scope, uuid.NewV4().String(), parameters); err != nil {
+				return created, errors.Wrapf(err, "error assigning the %s role on %s to principal %s", assignment.Role, assignment.Scope, assignment.PrincipalID)
+			}
+			logger.Infof("Assigned the %s role on %s to principal %s.", assignment.Role, assignment.Scope, assignment.PrincipalID)
+			created = append(created, assignment)
+		}
+	}
+	return created, nil
+}
+
+// getManagedIdentityPrincipalIDs returns the object id of the user assigned identity of the cluster, or else the
+// object ids of the system assigned identities of the masters
+func getManagedIdentityPrincipalIDs(ctx context.Context, client armhelpers.ACSEngineClient, properties *api.Properties, resourceGroup string) ([]string, error) {
+	if userAssignedID := properties.OrchestratorProfile.KubernetesConfig.UserAssignedID; userAssignedID != "" {
+		identity, err := client.GetUserAssignedID(ctx, resourceGroup, userAssignedID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting the user assigned identity %s", userAssignedID)
+		}
+		if identity.IdentityProperties == nil || identity.PrincipalID == nil {
+			return nil, errors.Errorf("the user assigned identity %s has no principal", userAssignedID)
+		}
+		return []string{identity.PrincipalID.String()}, nil
+	}
+	if properties.MasterProfile == nil {
+		return nil, errors.New("the cluster has no masters, and no user assigned identity")
+	}
+	var principalIDs []string
+	for i := 0; i < properties.MasterProfile.Count; i++ {
+		name := fmt.Sprintf("%s%d", properties.GetMasterVMPrefix(), i)
+		vm, err := client.GetVirtualMachine(ctx, resourceGroup, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting VM %s", name)
+		}
+		if vm.Identity == nil || vm.Identity.PrincipalID == nil {
+			return nil, errors.Errorf("VM %s has no system assigned identity", name)
+		}
+		principalIDs = append(principalIDs, *vm.Identity.PrincipalID)
+	}
+	return principalIDs, nil
+}
+
+// getRequiredRoleAssignments returns the role assignments the managed identity of a cluster needs
+func getRequiredRoleAssignments(properties *api.Properties, subscriptionID, resourceGroup string) ([]IdentityRoleAssignment, error) {
+	required := []IdentityRoleAssignment{{
+		Role:             "Contributor",
+		RoleDefinitionID: fmt.Sprintf(armhelpers.AADRoleReferenceTemplate, subscriptionID, armhelpers.AADContributorRoleID),
+		Scope:            fmt.Sprintf(AADRoleResourceGroupScopeTemplate, subscriptionID, resourceGroup),
+	}}
+	if properties.MasterProfile != nil && properties.MasterProfile.IsCustomVNET() {
+		vnetSubscriptionID, vnetResourceGroup, vnetName, _, err := common.GetVNETSubnetIDComponents(properties.MasterProfile.VnetSubnetID)
+		if err != nil {
+			return nil, err
+		}
+		required = append(required, IdentityRoleAssignment{
+			Role:             "Network Contributor",
+			RoleDefinitionID: fmt.Sprintf(armhelpers.AADRoleReferenceTemplate, vnetSubscriptionID, armhelpers.AADNetworkContributorRoleID),
+			Scope: fmt.Sprintf(AADRoleResourceGroupScopeTemplate+"/providers/Microsoft.Network/virtualNetworks/%s",
+				vnetSubscriptionID, vnetResourceGroup, vnetName),
+		})
+	}
+	return required, nil
+}
+
+// hasRoleAssignment returns true if the principal is assigned the role on the scope, or on a parent scope
+func hasRoleAssignment(ctx context.Context, client armhelpers.ACSEngineClient, assignment IdentityRoleAssignment) (bool, error) {
+	roleID := assignment.RoleDefinitionID[strings.LastIndex(assignment.RoleDefinitionID, "/")+1:]
+	page, err := client.ListRoleAssignmentsForPrincipal(ctx, assignment.Scope, assignment.PrincipalID)
+	for ; err == nil && page.NotDone(); err = page.Next() {
+		for _, existing := range page.Values() {
+			if existing.Properties == nil || existing.Properties.RoleDefinitionID == nil || existing.Properties.Scope == nil {
+				continue
+			}
+			scope := strings.ToLower(strings.TrimSuffix(*existing.Properties.Scope, "/"))
+			if strings.HasSuffix(strings.ToLower(*existing.Properties.RoleDefinitionID), "/"+strings.ToLower(roleID)) &&
+				(scope == "" || strings.HasPrefix(strings.ToLower(assignment.Scope)+"/", scope+"/")) {
+				return true, nil
+			}
+		}
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "error listing the role assignments of principal %s on %s", assignment.PrincipalID, assignment.Scope)
+	}
+	return false, nil
+}
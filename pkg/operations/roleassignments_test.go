@@ -0,0 +1,136 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	testSubscriptionID = "00000000-0000-0000-0000-000000000000"
+	// the principal of the system assigned identities of the VMs of the mock client
+	testVMPrincipalID = "00000000-1111-2222-3333-444444444444"
+	// the principal of the user assigned identities of the mock client
+	testUserAssignedPrincipalID = "55555555-6666-7777-8888-999999999999"
+
+	testResourceGroupScope = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+	testContributorRoleID  = "/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.Authorization/roleDefinitions/b24988ac-6180-42a0-ab88-20f7382dd24c"
+)
+
+func newManagedIdentityContainerService(masterCount int) *api.ContainerService {
+	cs := api.CreateMockContainerService("testcluster", "1.10.8", masterCount, 3, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity = true
+	return cs
+}
+
+func newRoleAssignment(principalID, roleDefinitionID, scope string) authorization.RoleAssignment {
+	return authorization.RoleAssignment{
+		ID: to.StringPtr(scope + "/providers/Microsoft.Authorization/roleAssignments/existing"),
+		Properties: &authorization.RoleAssignmentPropertiesWithScope{
+			PrincipalID:      to.StringPtr(principalID),
+			RoleDefinitionID: to.StringPtr(roleDefinitionID),
+			Scope:            to.StringPtr(scope),
+		},
+	}
+}
+
+var _ = Describe("Repair role assignments operation tests", func() {
+	logger := log.NewEntry(log.New())
+
+	It("Should assign the Contributor role on the resource group to the masters", func() {
+		client := &armhelpers.MockACSEngineClient{ShouldSupportVMIdentity: true, RoleAssignments: []authorization.RoleAssignment{}}
+		created, err := RepairRoleAssignments(context.Background(), client, logger, newManagedIdentityContainerService(1), testSubscriptionID, "rg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(Equal([]IdentityRoleAssignment{{
+			PrincipalID:      testVMPrincipalID,
+			Role:             "Contributor",
+			RoleDefinitionID: testContributorRoleID,
+			Scope:            testResourceGroupScope,
+		}}))
+		Expect(client.RoleAssignments).To(HaveLen(1))
+		Expect(*client.RoleAssignments[0].Properties.PrincipalID).To(Equal(testVMPrincipalID))
+		Expect(*client.RoleAssignments[0].Properties.Scope).To(Equal(testResourceGroupScope))
+
+		// repairing again is harmless
+		created, err = RepairRoleAssignments(context.Background(), client, logger, newManagedIdentityContainerService(1), testSubscriptionID, "rg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeEmpty())
+		Expect(client.RoleAssignments).To(HaveLen(1))
+	})
+
+	It("Should assign the Network Contributor role on a custom VNET", func() {
+		cs := newManagedIdentityContainerService(1)
+		cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/vnetrg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/masters"
+		existing := newRoleAssignment(testVMPrincipalID, testContributorRoleID, testResourceGroupScope)
+		client := &armhelpers.MockACSEngineClient{ShouldSupportVMIdentity: true, RoleAssignments: []authorization.RoleAssignment{existing}}
+
+		created, err := RepairRoleAssignments(context.Background(), client, logger, cs, testSubscriptionID, "rg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(Equal([]IdentityRoleAssignment{{
+			PrincipalID:      testVMPrincipalID,
+			Role:             "Network Contributor",
+			RoleDefinitionID: "/subscriptions/11111111-1111-1111-1111-111111111111/providers/Microsoft.Authorization/roleDefinitions/4d97b98b-1d4f-4787-a291-c67834d212e7",
+			Scope:            "/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/vnetrg/providers/Microsoft.Network/virtualNetworks/vnet",
+		}}))
+		Expect(client.RoleAssignments).To(HaveLen(2))
+		Expect(client.RoleAssignments[0]).To(Equal(existing))
+	})
+
+	It("Should leave a role assigned on a parent scope alone", func() {
+		cs := newManagedIdentityContainerService(1)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.UserAssignedID = "clusteridentity"
+		subscriptionContributor := newRoleAssignment(testUserAssignedPrincipalID,
+			"/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.Authorization/roleDefinitions/B24988AC-6180-42A0-AB88-20F7382DD24C",
+			"/subscriptions/00000000-0000-0000-0000-000000000000")
+		client := &armhelpers.MockACSEngineClient{RoleAssignments: []authorization.RoleAssignment{subscriptionContributor}}
+
+		created, err := RepairRoleAssignments(context.Background(), client, logger, cs, testSubscriptionID, "rg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeEmpty())
+		Expect(client.RoleAssignments).To(Equal([]authorization.RoleAssignment{subscriptionContributor}))
+	})
+
+	It("Should not count a role assigned on another resource group", func() {
+		cs := newManagedIdentityContainerService(1)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.UserAssignedID = "clusteridentity"
+		client := &armhelpers.MockACSEngineClient{RoleAssignments: []authorization.RoleAssignment{
+			newRoleAssignment(testUserAssignedPrincipalID, testContributorRoleID, testResourceGroupScope+"2"),
+		}}
+
+		created, err := RepairRoleAssignments(context.Background(), client, logger, cs, testSubscriptionID, "rg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(HaveLen(1))
+		Expect(created[0].PrincipalID).To(Equal(testUserAssignedPrincipalID))
+		Expect(client.RoleAssignments).To(HaveLen(2))
+	})
+
+	It("Should return an error when the cluster doesn't use managed identities", func() {
+		client := &armhelpers.MockACSEngineClient{}
+		cs := api.CreateMockContainerService("testcluster", "1.10.8", 1, 3, false)
+		_, err := RepairRoleAssignments(context.Background(), client, logger, cs, testSubscriptionID, "rg")
+		Expect(err).To(MatchError("the cluster doesn't use managed identities"))
+	})
+
+	It("Should return an error when a master has no system assigned identity", func() {
+		client := &armhelpers.MockACSEngineClient{RoleAssignments: []authorization.RoleAssignment{}}
+		cs := newManagedIdentityContainerService(1)
+		_, err := RepairRoleAssignments(context.Background(), client, logger, cs, testSubscriptionID, "rg")
+		Expect(err).To(MatchError("VM " + cs.Properties.GetMasterVMPrefix() + "0 has no system assigned identity"))
+		Expect(client.RoleAssignments).To(BeEmpty())
+	})
+
+	It("Should return an error when a role assignment cannot be created", func() {
+		client := &armhelpers.MockACSEngineClient{ShouldSupportVMIdentity: true, FailCreateRoleAssignment: true, RoleAssignments: []authorization.RoleAssignment{}}
+		_, err := RepairRoleAssignments(context.Background(), client, logger, newManagedIdentityContainerService(1), testSubscriptionID, "rg")
+		Expect(err).To(MatchError("error assigning the Contributor role on " + testResourceGroupScope + " to principal " + testVMPrincipalID + ": CreateRoleAssignment failed"))
+	})
+})
@@ -37,7 +37,7 @@ func ScaleDownVMs(az armhelpers.ACSEngineClient, logger *log.Entry, subscription
 		errDetails := <-errChan
 		if errDetails != nil {
 			failedVMDeletions.PushBack(errDetails)
-			logger.Errorf("Vm '%s' failed to delete with error: '%s'", errDetails.Name, errDetails.Error.Error())
+			logger.WithField(LogFieldNode, errDetails.Name).Errorf("Vm failed to delete with error: '%s'", errDetails.Error.Error())
 		}
 	}
 	if failedVMDeletions.Len() > 0 {
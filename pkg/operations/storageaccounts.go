@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2018-02-01/storage"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+// storageAccountUse is an existing storage account holding the VHDs of a profile of the cluster
+type storageAccountUse struct {
+	profile          string
+	storageAccountID string
+}
+
+// ValidateStorageAccounts checks, before a cluster is generated and deployed, that the existing storage
+// accounts referenced by its profiles exist, are in the location of the cluster, and can hold the page
+// blobs of the VHDs. All the storage accounts which cannot be used are reported in the returned error
+func ValidateStorageAccounts(ctx context.Context, client armhelpers.ACSEngineClient, properties *api.Properties, location string) error {
+	var invalid []string
+	for _, use := range getStorageAccountUses(properties) {
+		resource, err := azure.ParseResourceID(use.storageAccountID)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing the storage account ID of %s", use.profile)
+		}
+		account, err := client.GetStorageAccount(ctx, resource.ResourceGroup, resource.ResourceName)
+		if err != nil {
+			return errors.Wrapf(err, "error getting storage account %s of %s", resource.ResourceName, use.profile)
+		}
+		// the client only gets the storage accounts of the subscription it was created for
+		if account.ID == nil || !strings.EqualFold(*account.ID, use.storageAccountID) {
+			invalid = append(invalid, fmt.Sprintf("storage account %s of %s is not in the subscription of the cluster", resource.ResourceName, use.profile))
+			continue
+		}
+		if account.Location == nil || helpers.NormalizeAzureRegion(*account.Location) != helpers.NormalizeAzureRegion(location) {
+			invalid = append(invalid, fmt.Sprintf("storage account %s of %s is not in location %s", resource.ResourceName, use.profile, location))
+		}
+		if account.Kind == storage.BlobStorage {
+			invalid = append(invalid, fmt.Sprintf("storage account %s of %s is a %s account, which cannot hold the page blobs of VHDs", resource.ResourceName, use.profile, account.Kind))
+		}
+	}
+	if len(invalid) > 0 {
+		return errors.Errorf("the cluster cannot use its existing storage accounts:\n%s", strings.Join(invalid, "\n"))
+	}
+	return nil
+}
+
+func getStorageAccountUses(properties *api.Properties) []storageAccountUse {
+	var uses []storageAccountUse
+	if properties.MasterProfile != nil && properties.MasterProfile.StorageAccountID != "" {
+		uses = append(uses, storageAccountUse{"the master profile", properties.MasterProfile.StorageAccountID})
+	}
+	for _, pool := range properties.AgentPoolProfiles {
+		if pool.StorageAccountID != "" {
+			uses = append(uses, storageAccountUse{fmt.Sprintf("agent pool '%s'", pool.Name), pool.StorageAccountID})
+		}
+	}
+	return uses
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2018-02-01/storage"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const storageAccountsID = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/vhds/providers/Microsoft.Storage/storageAccounts/"
+
+func newStorageAccount(name, location string, kind storage.Kind) storage.Account {
+	return storage.Account{ID: to.StringPtr(storageAccountsID + name), Name: to.StringPtr(name), Location: to.StringPtr(location), Kind: kind}
+}
+
+func newStorageAccountsProperties(masterStorageAccountID string, agentStorageAccountIDs ...string) *api.Properties {
+	properties := &api.Properties{
+		MasterProfile: &api.MasterProfile{StorageProfile: api.StorageAccount, StorageAccountID: masterStorageAccountID},
+	}
+	for i, storageAccountID := range agentStorageAccountIDs {
+		properties.AgentPoolProfiles = append(properties.AgentPoolProfiles, &api.AgentPoolProfile{
+			Name:             []string{"agentpool1", "agentpool2", "agentpool3"}[i],
+			StorageProfile:   api.StorageAccount,
+			StorageAccountID: storageAccountID,
+		})
+	}
+	return properties
+}
+
+var _ = Describe("Existing storage accounts validation tests", func() {
+	var client *armhelpers.MockACSEngineClient
+
+	BeforeEach(func() {
+		otherSubscription := newStorageAccount("othersub", "westus2", storage.StorageV2)
+		otherSubscription.ID = to.StringPtr("/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/vhds/providers/Microsoft.Storage/storageAccounts/othersub")
+		client = &armhelpers.MockACSEngineClient{
+			StorageAccounts: []storage.Account{
+				newStorageAccount("mastervhds", "westus2", storage.Storage),
+				newStorageAccount("agentvhds", "West US 2", storage.StorageV2),
+				newStorageAccount("eastusvhds", "eastus", storage.Storage),
+				newStorageAccount("blobs", "westus2", storage.BlobStorage),
+				otherSubscription,
+			},
+		}
+	})
+
+	It("Should not get any storage account when the cluster creates its storage accounts", func() {
+		client.FailGetStorageAccount = true
+		properties := newStorageAccountsProperties("", "")
+		Expect(ValidateStorageAccounts(context.Background(), client, properties, "westus2")).To(Succeed())
+	})
+
+	It("Should accept existing storage accounts in the location of the cluster", func() {
+		properties := newStorageAccountsProperties(storageAccountsID+"mastervhds", storageAccountsID+"agentvhds", "")
+		Expect(ValidateStorageAccounts(context.Background(), client, properties, "westus2")).To(Succeed())
+	})
+
+	It("Should report all the storage accounts which cannot be used at once", func() {
+		properties := newStorageAccountsProperties(storageAccountsID+"eastusvhds", storageAccountsID+"blobs",
+			"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/vhds/providers/Microsoft.Storage/storageAccounts/othersub")
+		err := ValidateStorageAccounts(context.Background(), client, properties, "westus2")
+		Expect(err).To(MatchError("the cluster cannot use its existing storage accounts:\n" +
+			"storage account eastusvhds of the master profile is not in location westus2\n" +
+			"storage account blobs of agent pool 'agentpool1' is a BlobStorage account, which cannot hold the page blobs of VHDs\n" +
+			"storage account othersub of agent pool 'agentpool2' is not in the subscription of the cluster"))
+	})
+
+	It("Should return an error when a storage account cannot be found", func() {
+		properties := newStorageAccountsProperties("", storageAccountsID+"missing")
+		err := ValidateStorageAccounts(context.Background(), client, properties, "westus2")
+		Expect(err).To(MatchError("error getting storage account missing of agent pool 'agentpool1': storage account missing not found in resource group vhds"))
+	})
+})
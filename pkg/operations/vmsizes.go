@@ -0,0 +1,74 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	"github.com/pkg/errors"
+)
+
+// virtualMachinesResourceType is the resource type of the SKUs of the VM sizes
+const virtualMachinesResourceType = "virtualMachines"
+
+// vmSizeUse is a VM size required by a profile of the cluster
+type vmSizeUse struct {
+	profile string
+	vmSize  string
+}
+
+// ValidateVMSizes checks, before a cluster is generated and deployed, that the VM sizes of all its
+// profiles are offered in the location and not restricted for the subscription. All the VM sizes
+// which cannot be deployed are reported in the returned error, rather than only the first of them
+func ValidateVMSizes(ctx context.Context, client armhelpers.ACSEngineClient, properties *api.Properties, location string) error {
+	skus, err := client.ListResourceSkus(ctx, location)
+	if err != nil {
+		return errors.Wrapf(err, "error listing the VM sizes offered in location %s", location)
+	}
+	offered := map[string]armhelpers.ResourceSku{}
+	for _, sku := range skus {
+		if sku.ResourceType == virtualMachinesResourceType {
+			offered[strings.ToLower(sku.Name)] = sku
+		}
+	}
+
+	var invalid []string
+	for _, use := range getVMSizeUses(properties) {
+		sku, ok := offered[strings.ToLower(use.vmSize)]
+		if !ok {
+			invalid = append(invalid, fmt.Sprintf("VM size %s of %s is not offered in location %s", use.vmSize, use.profile, location))
+			continue
+		}
+		if r := sku.LocationRestriction(location); r != nil {
+			invalid = append(invalid, fmt.Sprintf("VM size %s of %s is restricted for the subscription in location %s (%s)", use.vmSize, use.profile, location, r.ReasonCode))
+		}
+	}
+	if len(invalid) > 0 {
+		return errors.Errorf("the cluster cannot be deployed in location %s:\n%s", location, strings.Join(invalid, "\n"))
+	}
+	return nil
+}
+
+func getVMSizeUses(properties *api.Properties) []vmSizeUse {
+	var uses []vmSizeUse
+	if properties.MasterProfile != nil && properties.MasterProfile.VMSize != "" {
+		uses = append(uses, vmSizeUse{"the master profile", properties.MasterProfile.VMSize})
+	}
+	for _, pool := range properties.AgentPoolProfiles {
+		uses = append(uses, vmSizeUse{fmt.Sprintf("agent pool '%s'", pool.Name), pool.VMSize})
+	}
+	// the api model may not have been defaulted yet
+	if o := properties.OrchestratorProfile; o != nil && o.KubernetesConfig != nil && o.KubernetesConfig.PrivateCluster != nil {
+		privateCluster := o.KubernetesConfig.PrivateCluster
+		if helpers.IsTrueBoolPointer(privateCluster.Enabled) && privateCluster.JumpboxProfile != nil {
+			uses = append(uses, vmSizeUse{"the jumpbox", privateCluster.JumpboxProfile.VMSize})
+		}
+	}
+	return uses
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	"context"
+
+	"github.com/Azure/acs-engine/pkg/api"
+	"github.com/Azure/acs-engine/pkg/armhelpers"
+	"github.com/Azure/acs-engine/pkg/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newVMSizeSku(name string, locations ...string) armhelpers.ResourceSku {
+	return armhelpers.ResourceSku{ResourceType: "virtualMachines", Name: name, Locations: locations}
+}
+
+func newVMSizesProperties(masterVMSize string, agentVMSizes ...string) *api.Properties {
+	properties := &api.Properties{
+		MasterProfile: &api.MasterProfile{VMSize: masterVMSize},
+	}
+	for i, vmSize := range agentVMSizes {
+		properties.AgentPoolProfiles = append(properties.AgentPoolProfiles, &api.AgentPoolProfile{
+			Name:   []string{"agentpool1", "agentpool2", "agentpool3"}[i],
+			VMSize: vmSize,
+		})
+	}
+	return properties
+}
+
+var _ = Describe("VM sizes validation tests", func() {
+	var client *armhelpers.MockACSEngineClient
+
+	BeforeEach(func() {
+		restricted := newVMSizeSku("Standard_M128s", "westus2", "eastus")
+		restricted.Restrictions = []armhelpers.ResourceSkuRestriction{
+			{Type: armhelpers.ResourceSkuRestrictionTypeLocation, Values: []string{"westus2"}, ReasonCode: "NotAvailableForSubscription"},
+		}
+		zoneRestricted := newVMSizeSku("Standard_D4s_v3", "westus2")
+		zoneRestricted.Restrictions = []armhelpers.ResourceSkuRestriction{
+			{Type: armhelpers.ResourceSkuRestrictionTypeZone, Values: []string{"westus2"}, ReasonCode: "NotAvailableForSubscription"},
+		}
+		client = &armhelpers.MockACSEngineClient{
+			ResourceSkus: []armhelpers.ResourceSku{
+				newVMSizeSku("Standard_D2_v2", "westus2", "eastus"),
+				newVMSizeSku("Standard_DS2_v2", "westus2"),
+				newVMSizeSku("Standard_NC6", "eastus"),
+				restricted,
+				zoneRestricted,
+				{ResourceType: "disks", Name: "Premium_LRS", Locations: []string{"westus2"}},
+			},
+		}
+	})
+
+	It("Should accept VM sizes offered in the location", func() {
+		properties := newVMSizesProperties("Standard_D2_v2", "Standard_DS2_v2", "standard_d2_v2")
+		Expect(ValidateVMSizes(context.Background(), client, properties, "westus2")).To(Succeed())
+	})
+
+	It("Should ignore the restrictions which only apply to some zones", func() {
+		properties := newVMSizesProperties("Standard_D2_v2", "Standard_D4s_v3")
+		Expect(ValidateVMSizes(context.Background(), client, properties, "westus2")).To(Succeed())
+	})
+
+	It("Should reject a VM size not offered in the location", func() {
+		properties := newVMSizesProperties("Standard_D2_v2", "Standard_NC6")
+		err := ValidateVMSizes(context.Background(), client, properties, "westus2")
+		Expect(err).To(MatchError("the cluster cannot be deployed in location westus2:\n" +
+			"VM size Standard_NC6 of agent pool 'agentpool1' is not offered in location westus2"))
+	})
+
+	It("Should reject a VM size restricted for the subscription", func() {
+		properties := newVMSizesProperties("Standard_M128s")
+		err := ValidateVMSizes(context.Background(), client, properties, "westus2")
+		Expect(err).To(MatchError("the cluster cannot be deployed in location westus2:\n" +
+			"VM size Standard_M128s of the master profile is restricted for the subscription in location westus2 (NotAvailableForSubscription)"))
+
+		Expect(ValidateVMSizes(context.Background(), client, properties, "eastus")).To(Succeed())
+	})
+
+	It("Should report all the invalid VM sizes at once", func() {
+		properties := newVMSizesProperties("Standard_M128s", "Standard_D2_v2", "Standard_NC6", "Premium_LRS")
+		properties.OrchestratorProfile = &api.OrchestratorProfile{
+			KubernetesConfig: &api.KubernetesConfig{
+				PrivateCluster: &api.PrivateCluster{
+					Enabled:        helpers.PointerToBool(true),
+					JumpboxProfile: &api.PrivateJumpboxProfile{VMSize: "Standard_A1"},
+				},
+			},
+		}
+		err := ValidateVMSizes(context.Background(), client, properties, "westus2")
+		Expect(err).To(MatchError("the cluster cannot be deployed in location westus2:\n" +
+			"VM size Standard_M128s of the master profile is restricted for the subscription in location westus2 (NotAvailableForSubscription)\n" +
+			"VM size Standard_NC6 of agent pool 'agentpool2' is not offered in location westus2\n" +
+			"VM size Premium_LRS of agent pool 'agentpool3' is not offered in location westus2\n" +
+			"VM size Standard_A1 of the jumpbox is not offered in location westus2"))
+	})
+
+	It("Should return an error when the VM sizes cannot be listed", func() {
+		client.FailListResourceSkus = true
+		err := ValidateVMSizes(context.Background(), client, newVMSizesProperties("Standard_D2_v2"), "westus2")
+		Expect(err).To(MatchError("error listing the VM sizes offered in location westus2: ListResourceSkus failed"))
+	})
+})